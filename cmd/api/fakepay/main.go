@@ -0,0 +1,81 @@
+// fakepay sends the API a webhook of the fake payment provider, signed with
+// the API's PAYMENT_WEBHOOK_SECRET, so deposits can be paid and refunded
+// offline:
+//
+//	fakepay -session cs_... -amount 25
+//	fakepay -session cs_... -amount 25 -event refund.completed
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/payment/provider/fake"
+	"juno/pkg/api/transaction"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	var (
+		urlFlag     string
+		secretFlag  string
+		sessionFlag string
+		amountFlag  string
+		eventFlag   string
+	)
+
+	flag.StringVar(&urlFlag, "url", "http://localhost:8080/payments/webhook", "webhook URL of the API")
+	flag.StringVar(&secretFlag, "secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "secret the API verifies webhooks with")
+	flag.StringVar(&sessionFlag, "session", "", "checkout session of the payment")
+	flag.StringVar(&amountFlag, "amount", "", "amount of the payment, in tokens")
+	flag.StringVar(&eventFlag, "event", string(payment.CheckoutCompletedEvent), "event to send")
+
+	flag.Parse()
+
+	if sessionFlag == "" || secretFlag == "" {
+		log.Fatalf("a session and a secret are required")
+	}
+
+	amount, err := transaction.ParseAmount(amountFlag)
+
+	if err != nil {
+		log.Fatalf("invalid amount %q: %v", amountFlag, err)
+	}
+
+	webhook, err := fake.New(secretFlag).Sign(&payment.Event{
+		ID:        "evt_" + uuid.NewString(),
+		Type:      payment.EventType(eventFlag),
+		SessionID: sessionFlag,
+		Amount:    amount,
+	})
+
+	if err != nil {
+		log.Fatalf("failed to sign webhook: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", urlFlag, bytes.NewReader(webhook.Body))
+
+	if err != nil {
+		log.Fatalf("failed to create request: %v", err)
+	}
+
+	req.Header = webhook.Header
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		log.Fatalf("failed to send webhook: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+
+	fmt.Println(res.Status, string(body))
+}
@@ -2,16 +2,15 @@ package main
 
 import (
 	"database/sql"
-	"encoding/json"
 	"flag"
-	"fmt"
 	"juno/cmd/api/run/config"
 	"log"
-	"os"
 
+	"juno/pkg/api/extractor/job/billing"
 	extractorJobMig "juno/pkg/api/extractor/job/migration/mysql"
 	extractorJobRepo "juno/pkg/api/extractor/job/repo/mysql"
 	extractorJobSvc "juno/pkg/api/extractor/job/service"
+	extractorJobStore "juno/pkg/api/extractor/job/store/fs"
 
 	selectorMig "juno/pkg/api/extractor/selector/migration/mysql"
 	selectorRepo "juno/pkg/api/extractor/selector/repo/mysql"
@@ -29,15 +28,28 @@ import (
 	strategyRepo "juno/pkg/api/extractor/strategy/repo/strategy/mysql"
 	strategyService "juno/pkg/api/extractor/strategy/service"
 
+	strategyAggregationRepo "juno/pkg/api/extractor/strategy/repo/aggregation/mysql"
 	strategyFieldRepo "juno/pkg/api/extractor/strategy/repo/field/mysql"
 	strategyFilterRepo "juno/pkg/api/extractor/strategy/repo/filter/mysql"
+	strategyScopeRepo "juno/pkg/api/extractor/strategy/repo/scope/mysql"
 	strategySelectorRepo "juno/pkg/api/extractor/strategy/repo/selector/mysql"
 
 	ranagMig "juno/pkg/api/ranag/migration/mysql"
 	ranagRepo "juno/pkg/api/ranag/repo/mysql"
 	ranagSvc "juno/pkg/api/ranag/service"
 
+	nodeMig "juno/pkg/api/node/migration/mysql"
+	nodeRepo "juno/pkg/api/node/repo/mysql"
+	nodeSvc "juno/pkg/api/node/service"
+
+	tranMig "juno/pkg/api/transaction/migration/mysql"
+	tranRepo "juno/pkg/api/transaction/repo/mysql"
+	tranSvc "juno/pkg/api/transaction/service"
+
+	tokenService "juno/pkg/api/token/service"
+
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
 )
 
 func setupDatabase(connectionString string, migrations func(*sql.DB) error) *sql.DB {
@@ -67,10 +79,19 @@ func main() {
 	fieldDB := setupDatabase(config.FieldDB, fieldMig.ExecuteMigrations)
 	strategyDB := setupDatabase(config.StrategyDB, strategyMig.ExecuteMigrations)
 	ranagDB := setupDatabase(config.RanagDB, ranagMig.ExecuteMigrations)
+	nodeDB := setupDatabase(config.NodeDB, nodeMig.ExecuteMigrations)
+	tranDB := setupDatabase(config.TranDB, tranMig.ExecuteMigrations)
 
 	ranagRepo := ranagRepo.New(ranagDB)
 	ranagSvc := ranagSvc.New(ranagRepo)
 
+	nodeRepo := nodeRepo.New(nodeDB)
+	nodeSvc := nodeSvc.New(nodeRepo)
+
+	tranRepo := tranRepo.New(tranDB)
+	tranSvc := tranSvc.New(logrus.New(), tranRepo)
+	tokenSvc := tokenService.New(tranSvc)
+
 	selectorRepo := selectorRepo.New(selectorDB)
 	selectorSvc := selectorService.New(selectorRepo)
 
@@ -83,27 +104,24 @@ func main() {
 	strategyRepo := strategyRepo.New(strategyDB)
 	strategySelectorRepo := strategySelectorRepo.New(strategyDB)
 	strategyFieldRepo := strategyFieldRepo.New(strategyDB)
+	strategyAggregationRepo := strategyAggregationRepo.New(strategyDB)
+	strategyScopeRepo := strategyScopeRepo.New(strategyDB)
 	strategyFilterRepo := strategyFilterRepo.New(strategyDB)
-	strategySvc := strategyService.New(strategyRepo, strategyFilterRepo, strategyFieldRepo, strategySelectorRepo, filterSvc, fieldSvc, selectorSvc)
+	strategySvc := strategyService.New(strategyRepo, strategyFilterRepo, strategyFieldRepo, strategySelectorRepo, strategyAggregationRepo, strategyScopeRepo, filterSvc, fieldSvc, selectorSvc)
 
 	extractionJobRepo := extractorJobRepo.New(extractionJobDB)
-	extractionJobSvc := extractorJobSvc.New(extractionJobRepo, strategySvc, ranagSvc)
-
-	extractionJobSvc.ProcessPending()
-
-	type data []map[string]interface{}
-
-	bytes, err := os.ReadFile("data.json")
-
-	if err != nil {
-		log.Fatalf("failed to read data.json: %v", err)
+	extractionJobStore := extractorJobStore.New(config.JobResultsDir)
+	extractionJobSvc := extractorJobSvc.New(
+		extractionJobRepo,
+		strategySvc,
+		ranagSvc,
+		extractorJobSvc.WithResultStore(extractionJobStore),
+		extractorJobSvc.WithBilling(tokenSvc, billing.DefaultPricing),
+		extractorJobSvc.WithNodeService(nodeSvc),
+	)
+
+	// drains the pending jobs once, alongside any API workers
+	if err := extractionJobSvc.ProcessPending(); err != nil {
+		log.Fatalf("failed to process jobs: %v", err)
 	}
-
-	var d data
-
-	if err := json.Unmarshal(bytes, &d); err != nil {
-		log.Fatalf("failed to unmarshal data: %v", err)
-	}
-
-	fmt.Printf("Total data: %d\n", len(d))
 }
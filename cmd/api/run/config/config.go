@@ -14,6 +14,46 @@ type Config struct {
 	FieldDB         string
 	StrategyDB      string
 	RanagDB         string
+	PaymentDB       string
+	PayoutDB        string
+	UsageDB         string
+	ChallengeDB     string
+	MismatchDB      string
+	ApiKeyDB        string
+	AuthDB          string
+
+	// JobResultsDir is where the rows of finished jobs are stored.
+	JobResultsDir string
+
+	// PaymentProvider is the provider deposits are paid with, which signs
+	// its webhooks with PaymentWebhookSecret. There is no default: the fake
+	// provider keeps its sessions in memory and pays checkouts for anyone
+	// holding the secret, so it is only accepted in Dev.
+	PaymentProvider      string
+	PaymentWebhookSecret string
+
+	// Dev allows what is only fit for local development, like the fake
+	// payment provider.
+	Dev bool
+
+	// PayoutMinimum is the smallest amount of tokens paid out and
+	// PayoutHold how long earnings are held before they can be paid out.
+	// The defaults of the payout service apply when they are empty.
+	PayoutMinimum string
+	PayoutHold    string
+
+	// ReceiptGraceUntil is until when, as an RFC 3339 time, nodes and
+	// ranags that registered no key are still paid without receipts. They
+	// are not paid without one when it is empty.
+	ReceiptGraceUntil string
+
+	// AdminToken authenticates the admin API, where payouts are reviewed.
+	// The admin API is disabled when it is empty.
+	AdminToken string
+
+	// ReportTokens are the comma separated tokens balancers report crawls
+	// with, one for each balancer. Crawls are not accepted when it is empty.
+	ReportTokens string
 }
 
 // LoadConfig reads environment variables and returns a Config struct.
@@ -29,6 +69,28 @@ func LoadConfig() *Config {
 		FieldDB:         getEnv("FIELD_DB", "root:juno@tcp(localhost:3306)/field?parseTime=true"),
 		StrategyDB:      getEnv("STRATEGY_DB", "root:juno@tcp(localhost:3306)/strategy?parseTime=true"),
 		RanagDB:         getEnv("RANAG_DB", "root:juno@tcp(localhost:3306)/ranag?parseTime=true"),
+		PaymentDB:       getEnv("PAYMENT_DB", "root:juno@tcp(localhost:3306)/payment?parseTime=true"),
+		PayoutDB:        getEnv("PAYOUT_DB", "root:juno@tcp(localhost:3306)/payout?parseTime=true"),
+		UsageDB:         getEnv("USAGE_DB", "root:juno@tcp(localhost:3306)/usage?parseTime=true"),
+		ChallengeDB:     getEnv("CHALLENGE_DB", "root:juno@tcp(localhost:3306)/challenge?parseTime=true"),
+		MismatchDB:      getEnv("MISMATCH_DB", "root:juno@tcp(localhost:3306)/mismatch?parseTime=true"),
+		ApiKeyDB:        getEnv("API_KEY_DB", "root:juno@tcp(localhost:3306)/apikey?parseTime=true"),
+		AuthDB:          getEnv("AUTH_DB", "root:juno@tcp(localhost:3306)/auth?parseTime=true"),
+
+		JobResultsDir: getEnv("JOB_RESULTS_DIR", "results"),
+
+		PaymentProvider:      getEnv("PAYMENT_PROVIDER", ""),
+		PaymentWebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+
+		Dev: getEnv("DEV", "") == "true",
+
+		PayoutMinimum: getEnv("PAYOUT_MINIMUM", ""),
+		PayoutHold:    getEnv("PAYOUT_HOLD", ""),
+
+		ReceiptGraceUntil: getEnv("RECEIPT_GRACE_UNTIL", ""),
+
+		AdminToken:   getEnv("ADMIN_TOKEN", ""),
+		ReportTokens: getEnv("REPORT_TOKENS", ""),
 	}
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"juno/cmd/api/run/config"
@@ -10,6 +11,8 @@ import (
 	nodeRepo "juno/pkg/api/node/repo/mysql"
 	nodeSvc "juno/pkg/api/node/service"
 	"log"
+	"strings"
+	"time"
 
 	tranHandler "juno/pkg/api/transaction/handler"
 	tranMig "juno/pkg/api/transaction/migration/mysql"
@@ -19,11 +22,42 @@ import (
 	tokenHandler "juno/pkg/api/token/handler"
 	tokenService "juno/pkg/api/token/service"
 
+	"juno/pkg/api/payment"
+	paymentHandler "juno/pkg/api/payment/handler"
+	paymentMig "juno/pkg/api/payment/migration/mysql"
+	paymentPolicy "juno/pkg/api/payment/policy"
+	"juno/pkg/api/payment/provider/fake"
+	paymentRepo "juno/pkg/api/payment/repo/mysql"
+	paymentSvc "juno/pkg/api/payment/service"
+
+	payoutHandler "juno/pkg/api/payout/handler"
+	payoutMig "juno/pkg/api/payout/migration/mysql"
+	payoutPolicy "juno/pkg/api/payout/policy"
+	payoutRepo "juno/pkg/api/payout/repo/mysql"
+	payoutSvc "juno/pkg/api/payout/service"
+
+	usageHandler "juno/pkg/api/usage/handler"
+	usageMig "juno/pkg/api/usage/migration/mysql"
+	usageRepo "juno/pkg/api/usage/repo/mysql"
+	usageSvc "juno/pkg/api/usage/service"
+
+	challengeHandler "juno/pkg/api/challenge/handler"
+	challengeMig "juno/pkg/api/challenge/migration/mysql"
+	challengeRepo "juno/pkg/api/challenge/repo/mysql"
+	challengeSvc "juno/pkg/api/challenge/service"
+
+	mismatchHandler "juno/pkg/api/mismatch/handler"
+	mismatchMig "juno/pkg/api/mismatch/migration/mysql"
+	mismatchRepo "juno/pkg/api/mismatch/repo/mysql"
+	mismatchSvc "juno/pkg/api/mismatch/service"
+
+	"juno/pkg/api/extractor/job/billing"
 	extractorJobHandler "juno/pkg/api/extractor/job/handler"
 	extractorJobMig "juno/pkg/api/extractor/job/migration/mysql"
 	extractorJobPolicy "juno/pkg/api/extractor/job/policy"
 	extractorJobRepo "juno/pkg/api/extractor/job/repo/mysql"
 	extractorJobSvc "juno/pkg/api/extractor/job/service"
+	extractorJobStore "juno/pkg/api/extractor/job/store/fs"
 
 	selectorHandler "juno/pkg/api/extractor/selector/handler"
 	selectorMig "juno/pkg/api/extractor/selector/migration/mysql"
@@ -49,8 +83,10 @@ import (
 	strategyRepo "juno/pkg/api/extractor/strategy/repo/strategy/mysql"
 	strategyService "juno/pkg/api/extractor/strategy/service"
 
+	strategyAggregationRepo "juno/pkg/api/extractor/strategy/repo/aggregation/mysql"
 	strategyFieldRepo "juno/pkg/api/extractor/strategy/repo/field/mysql"
 	strategyFilterRepo "juno/pkg/api/extractor/strategy/repo/filter/mysql"
+	strategyScopeRepo "juno/pkg/api/extractor/strategy/repo/scope/mysql"
 	strategySelectorRepo "juno/pkg/api/extractor/strategy/repo/selector/mysql"
 
 	balancerHandler "juno/pkg/api/balancer/handler"
@@ -72,9 +108,20 @@ import (
 	userSvc "juno/pkg/api/user/service"
 
 	authHandler "juno/pkg/api/auth/handler"
+	authMig "juno/pkg/api/auth/migration/mysql"
+	authRepo "juno/pkg/api/auth/repo/mysql"
 	authSvc "juno/pkg/api/auth/service"
 
+	apiKeyHandler "juno/pkg/api/apikey/handler"
+	apiKeyMig "juno/pkg/api/apikey/migration/mysql"
+	apiKeyPolicy "juno/pkg/api/apikey/policy"
+	apiKeyRepo "juno/pkg/api/apikey/repo/mysql"
+	apiKeySvc "juno/pkg/api/apikey/service"
+
+	"juno/pkg/api/challenge"
+	"juno/pkg/api/mismatch"
 	"juno/pkg/api/router"
+	"juno/pkg/api/transaction"
 
 	_ "github.com/go-sql-driver/mysql"
 
@@ -94,11 +141,99 @@ func setupDatabase(connectionString string, migrations func(*sql.DB) error) *sql
 	return db
 }
 
+// setupPaymentProvider returns the configured payment provider, nil when
+// PAYMENT_PROVIDER is unset.
+func setupPaymentProvider(c *config.Config) payment.Provider {
+	switch c.PaymentProvider {
+	case "":
+		return nil
+	case fake.Name:
+		if !c.Dev {
+			log.Fatalf("the fake payment provider is only for development, set DEV=true to use it")
+		}
+
+		if c.PaymentWebhookSecret == "" {
+			log.Fatalf("PAYMENT_WEBHOOK_SECRET is required to verify payment webhooks")
+		}
+
+		return fake.New(c.PaymentWebhookSecret)
+	}
+
+	log.Fatalf("unknown payment provider %q", c.PaymentProvider)
+	return nil
+}
+
+// payoutOptions applies the payout minimum and hold of the config, when set.
+func payoutOptions(c *config.Config) []func(s *payoutSvc.Service) {
+	var opts []func(s *payoutSvc.Service)
+
+	if c.PayoutMinimum != "" {
+		minimum, err := transaction.ParseAmount(c.PayoutMinimum)
+		if err != nil {
+			log.Fatalf("invalid PAYOUT_MINIMUM: %v", err)
+		}
+
+		opts = append(opts, payoutSvc.WithMinimum(minimum))
+	}
+
+	if c.PayoutHold != "" {
+		hold, err := time.ParseDuration(c.PayoutHold)
+		if err != nil {
+			log.Fatalf("invalid PAYOUT_HOLD: %v", err)
+		}
+
+		opts = append(opts, payoutSvc.WithHold(hold))
+	}
+
+	return opts
+}
+
+// jobOptions applies the receipt grace period of the config, when set.
+func jobOptions(c *config.Config) []func(s *extractorJobSvc.Service) {
+	var opts []func(s *extractorJobSvc.Service)
+
+	if c.ReceiptGraceUntil != "" {
+		until, err := time.Parse(time.RFC3339, c.ReceiptGraceUntil)
+		if err != nil {
+			log.Fatalf("invalid RECEIPT_GRACE_UNTIL: %v", err)
+		}
+
+		opts = append(opts, extractorJobSvc.WithReceiptGrace(until))
+	}
+
+	return opts
+}
+
+// reportTokens splits the report tokens of the config, skipping empty ones.
+func reportTokens(c *config.Config) []string {
+	var tokens []string
+
+	for _, token := range strings.Split(c.ReportTokens, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
 func main() {
 
 	var portFlag string
 	flag.StringVar(&portFlag, "port", "8080", "port to run the server on")
 
+	var workersFlag int
+	flag.IntVar(&workersFlag, "workers", 2, "number of workers processing extraction jobs, 0 to process none")
+
+	var challengeIntervalFlag time.Duration
+	flag.DurationVar(&challengeIntervalFlag, "challenge-interval", challenge.DefaultInterval, "how often every node is challenged to prove it stores its pages, 0 to challenge none")
+
+	var challengeConcurrencyFlag int
+	flag.IntVar(&challengeConcurrencyFlag, "challenge-concurrency", challenge.DefaultConcurrency, "how many nodes are challenged at once")
+
+	var mismatchThresholdFlag int
+	flag.IntVar(&mismatchThresholdFlag, "mismatch-threshold", mismatch.DefaultThreshold, "how many mismatches within a day exclude a node from the shard maps for a day, 0 to exclude none")
+
 	flag.Parse()
 
 	config := config.LoadConfig()
@@ -112,6 +247,13 @@ func main() {
 	fieldDB := setupDatabase(config.FieldDB, fieldMig.ExecuteMigrations)
 	strategyDB := setupDatabase(config.StrategyDB, strategyMig.ExecuteMigrations)
 	ranagDB := setupDatabase(config.RanagDB, ranagMig.ExecuteMigrations)
+	paymentDB := setupDatabase(config.PaymentDB, paymentMig.ExecuteMigrations)
+	payoutDB := setupDatabase(config.PayoutDB, payoutMig.ExecuteMigrations)
+	usageDB := setupDatabase(config.UsageDB, usageMig.ExecuteMigrations)
+	challengeDB := setupDatabase(config.ChallengeDB, challengeMig.ExecuteMigrations)
+	mismatchDB := setupDatabase(config.MismatchDB, mismatchMig.ExecuteMigrations)
+	apiKeyDB := setupDatabase(config.ApiKeyDB, apiKeyMig.ExecuteMigrations)
+	authDB := setupDatabase(config.AuthDB, authMig.ExecuteMigrations)
 
 	logger := logrus.New()
 
@@ -127,6 +269,33 @@ func main() {
 	tokenSvc := tokenService.New(tranSvc)
 	tokenHandler := tokenHandler.New(logger, tokenSvc)
 
+	// tokens cannot be deposited without a payment provider
+	var payments payment.Handler
+	if provider := setupPaymentProvider(config); provider != nil {
+		paymentRepo := paymentRepo.New(paymentDB)
+		paymentSvc := paymentSvc.New(paymentRepo, provider, tokenSvc)
+		paymentPolicy := paymentPolicy.New()
+		payments = paymentHandler.New(logger, paymentPolicy, paymentSvc)
+	}
+
+	payoutRepo := payoutRepo.New(payoutDB)
+	payoutSvc := payoutSvc.New(payoutRepo, tokenSvc, payoutOptions(config)...)
+	payoutPolicy := payoutPolicy.New()
+	payoutHandler := payoutHandler.New(logger, payoutPolicy, payoutSvc)
+
+	usageRepo := usageRepo.New(usageDB)
+	usageSvc := usageSvc.New(usageRepo)
+	usageHandler := usageHandler.New(logger, usageSvc)
+
+	challengeRepo := challengeRepo.New(challengeDB)
+	challengeSvc := challengeSvc.New(
+		challengeRepo,
+		nodeSvc,
+		challengeSvc.WithInterval(challengeIntervalFlag),
+		challengeSvc.WithConcurrency(challengeConcurrencyFlag),
+	)
+	challengeHandler := challengeHandler.New(logger, nodePolicy, nodeSvc, challengeSvc)
+
 	balancerRepo := balancerRepo.New(balancerDB)
 	balancerSvc := balancerSvc.New(balancerRepo)
 	balancerPolicy := balancerPolicy.New()
@@ -137,6 +306,10 @@ func main() {
 	ranagPolicy := ranagPolicy.New()
 	ranagHandler := ranagHandler.New(logger, ranagPolicy, ranagSvc)
 
+	mismatchRepo := mismatchRepo.New(mismatchDB)
+	mismatchSvc := mismatchSvc.New(mismatchRepo, nodeSvc, mismatchSvc.WithExclusion(mismatchThresholdFlag, mismatch.DefaultWindow, mismatch.DefaultExclusion))
+	mismatchHandler := mismatchHandler.New(logger, nodePolicy, nodeSvc, mismatchSvc)
+
 	selectorRepo := selectorRepo.New(selectorDB)
 	selectorSvc := selectorService.New(selectorRepo)
 	selectorPolicy := selectorPolicy.New()
@@ -155,25 +328,52 @@ func main() {
 	strategyRepo := strategyRepo.New(strategyDB)
 	strategySelectorRepo := strategySelectorRepo.New(strategyDB)
 	strategyFieldRepo := strategyFieldRepo.New(strategyDB)
+	strategyAggregationRepo := strategyAggregationRepo.New(strategyDB)
+	strategyScopeRepo := strategyScopeRepo.New(strategyDB)
 	strategyFilterRepo := strategyFilterRepo.New(strategyDB)
-	strategySvc := strategyService.New(strategyRepo, strategyFilterRepo, strategyFieldRepo, strategySelectorRepo, filterSvc, fieldSvc, selectorSvc)
+	strategySvc := strategyService.New(strategyRepo, strategyFilterRepo, strategyFieldRepo, strategySelectorRepo, strategyAggregationRepo, strategyScopeRepo, filterSvc, fieldSvc, selectorSvc)
 	strategyPolicy := strategyPolicy.New()
-	strategyHandler := strategyHandler.New(strategyPolicy, strategySvc)
 
 	extractionJobRepo := extractorJobRepo.New(extractionJobDB)
-	extractionJobSvc := extractorJobSvc.New(extractionJobRepo, strategySvc, ranagSvc)
+	extractionJobStore := extractorJobStore.New(config.JobResultsDir)
+	extractionJobSvc := extractorJobSvc.New(
+		extractionJobRepo,
+		strategySvc,
+		ranagSvc,
+		append([]func(s *extractorJobSvc.Service){
+			extractorJobSvc.WithResultStore(extractionJobStore),
+			extractorJobSvc.WithBilling(tokenSvc, billing.DefaultPricing),
+			extractorJobSvc.WithNodeService(nodeSvc),
+			extractorJobSvc.WithReceipts(usageSvc),
+			extractorJobSvc.WithMismatches(mismatchSvc),
+		}, jobOptions(config)...)...,
+	)
 	extractionJobPolicy := extractorJobPolicy.New()
 	extractionJobHandler := extractorJobHandler.New(extractionJobSvc, extractionJobPolicy)
 
+	// strategies are estimated by planning jobs on them
+	strategyHandler := strategyHandler.New(strategyPolicy, strategySvc, extractionJobSvc)
+
 	userRepo := userRepo.New(userDB)
 
 	userSvc := userSvc.New(logger, userRepo)
 	policy := userPolicy.New()
 	userHandler := userHandler.New(logger, policy, userSvc)
 
-	authSvc := authSvc.New(logger, userSvc)
+	authRepo := authRepo.New(authDB)
+	keyring, err := authSvc.KeyringFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load the signing keys: %v", err)
+	}
+
+	authSvc := authSvc.New(logger, userSvc, authRepo, authSvc.WithKeyring(keyring))
 	authHandler := authHandler.New(logger, authSvc)
 
+	apiKeyRepo := apiKeyRepo.New(apiKeyDB)
+	apiKeySvc := apiKeySvc.New(apiKeyRepo, userSvc)
+	apiKeyPolicy := apiKeyPolicy.New()
+	apiKeyHandler := apiKeyHandler.New(logger, apiKeyPolicy, apiKeySvc)
+
 	r := router.New(
 		nodeHandler,
 		balancerHandler,
@@ -185,9 +385,27 @@ func main() {
 		fieldHandler,
 		strategyHandler,
 		tokenHandler,
+		payments,
+		payoutHandler,
+		usageHandler,
+		challengeHandler,
+		mismatchHandler,
 		userHandler,
 		authHandler,
+		authSvc,
+		apiKeyHandler,
+		apiKeySvc,
+		config.AdminToken,
+		reportTokens(config),
 	)
 
+	go func() {
+		extractionJobSvc.Run(context.Background(), workersFlag)
+	}()
+
+	go func() {
+		challengeSvc.Run(context.Background())
+	}()
+
 	r.Run(":" + portFlag)
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"flag"
 	"juno/pkg/api/client"
+	"juno/pkg/balancer/outcome"
+	"juno/pkg/balancer/queue"
+	"os"
 	"time"
 
 	queueRepo "juno/pkg/balancer/queue/repo/bolt"
@@ -12,9 +15,22 @@ import (
 	policyRepo "juno/pkg/balancer/policy/repo/bolt"
 	policyService "juno/pkg/balancer/policy/service"
 
-	robotstxtRepo "juno/pkg/balancer/robotstxt/repo/mem"
+	robotstxtRepo "juno/pkg/balancer/robotstxt/repo/bolt"
 	robotstxtService "juno/pkg/balancer/robotstxt/service"
 
+	queueHandler "juno/pkg/balancer/queue/handler"
+
+	adminHandler "juno/pkg/balancer/admin/handler"
+	outcomeService "juno/pkg/balancer/outcome/service"
+
+	discoveryHandler "juno/pkg/balancer/discovery/handler"
+	"juno/pkg/balancer/scope"
+	scopeHandler "juno/pkg/balancer/scope/handler"
+	scopeService "juno/pkg/balancer/scope/service"
+
+	discoveryRepo "juno/pkg/balancer/discovery/repo/bolt"
+	discoveryService "juno/pkg/balancer/discovery/service"
+
 	crawlHandler "juno/pkg/balancer/crawl/handler"
 	crawlService "juno/pkg/balancer/crawl/service"
 
@@ -36,6 +52,30 @@ func main() {
 	var queueDBPath string
 	flag.StringVar(&queueDBPath, "queue-db", "queue.db", "Queue DB Path")
 
+	var robotsDBPath string
+	flag.StringVar(&robotsDBPath, "robots-db", "robots.db", "Robots.txt DB Path")
+
+	var discoveryDBPath string
+	flag.StringVar(&discoveryDBPath, "discovery-db", "discovery.db", "Sitemap and feed discovery DB Path")
+
+	var scopeRulesPath string
+	flag.StringVar(&scopeRulesPath, "scope-rules", "", "Path to a JSON file with URL scope rules")
+
+	var maxAttempts int
+	flag.IntVar(&maxAttempts, "max-attempts", queue.DefaultMaxAttempts, "Crawl attempts before a URL is dead-lettered")
+
+	var visibilityTimeout time.Duration
+	flag.DurationVar(&visibilityTimeout, "visibility-timeout", queue.DefaultVisibilityTimeout, "How long a leased URL stays hidden before it is re-delivered")
+
+	var adminToken string
+	flag.StringVar(&adminToken, "admin-token", os.Getenv("BALANCER_ADMIN_TOKEN"), "Bearer token for the admin API, disabled when empty")
+
+	var reportToken string
+	flag.StringVar(&reportToken, "report-token", os.Getenv("REPORT_TOKEN"), "Bearer token of the balancer crawls are reported to the API with, no reports when empty")
+
+	var challengeSample float64
+	flag.Float64Var(&challengeSample, "challenge-sample", 0.01, "Share of crawls reported to the API, which challenges nodes to prove they store them")
+
 	var port string
 	flag.StringVar(&port, "port", "7070", "Port to run the server on")
 
@@ -64,31 +104,105 @@ func main() {
 	queueService := queueService.New(
 		logger,
 		queueRepo,
+		queueService.WithMaxAttempts(maxAttempts),
+		queueService.WithVisibilityTimeout(visibilityTimeout),
 	)
 
+	robotstxtRepo, err := robotstxtRepo.New(robotsDBPath)
+
+	if err != nil {
+		panic(err)
+	}
+
 	robotstxtService := robotstxtService.New(
-		robotstxtRepo.New(),
+		robotstxtRepo,
+		robotstxtService.WithLogger(logger),
+		robotstxtService.WithPolicyService(policyService),
 	)
 
+	scopeRules := scope.DefaultRules()
+
+	if scopeRulesPath != "" {
+		scopeRules, err = scope.LoadRules(scopeRulesPath)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	scopeService, err := scopeService.New(scopeRules, queueService)
+
+	if err != nil {
+		panic(err)
+	}
+
+	discoveryRepo, err := discoveryRepo.New(discoveryDBPath)
+
+	if err != nil {
+		panic(err)
+	}
+
+	discoveryService := discoveryService.New(
+		discoveryService.WithLogger(logger),
+		discoveryService.WithRepository(discoveryRepo),
+		discoveryService.WithQueueService(queueService),
+		discoveryService.WithRobotsTxtService(robotstxtService),
+		discoveryService.WithScopeService(scopeService),
+	)
+
+	outcomeService := outcomeService.New(outcome.DefaultHistory, outcome.DefaultMaxHosts)
+
 	crawlService := crawlService.New(
 		crawlService.WithLogger(logger),
 		crawlService.WithApiClient(apiClient),
 		crawlService.WithQueueService(queueService),
 		crawlService.WithPolicyService(policyService),
+		crawlService.WithDiscoveryService(discoveryService),
+		crawlService.WithOutcomeService(outcomeService),
 		crawlService.WithShardFetchInterval(time.Minute),
+		crawlService.WithCrawlReports(reportToken, challengeSample),
 	)
 
 	crawlHandler := crawlHandler.New(
 		logger,
 		queueService,
 		robotstxtService,
+		scopeService,
 	)
 
 	go func() {
 		crawlService.ProcessQueue(context.Background())
 	}()
 
-	r := router.New(crawlHandler)
+	go func() {
+		discoveryService.Run(context.Background())
+	}()
+
+	queueHandler := queueHandler.New(
+		logger,
+		queueService,
+	)
+
+	discoveryHandler := discoveryHandler.New(
+		logger,
+		discoveryService,
+	)
+
+	scopeHandler := scopeHandler.New(scopeService)
+
+	adminHandler := adminHandler.New(
+		logger,
+		queueService,
+		policyService,
+		crawlService,
+		outcomeService,
+	)
+
+	if adminToken == "" {
+		logger.Warn("no admin token set, the admin API is disabled")
+	}
+
+	r := router.New(crawlHandler, queueHandler, discoveryHandler, scopeHandler, adminHandler, adminToken)
 
 	r.Run(":" + port)
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"juno/pkg/balancer/admin"
+	"juno/pkg/balancer/admin/dto"
+	"juno/pkg/balancer/client"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage: balancerctl [-balancer URL] [-token TOKEN] <command> [args]
+
+Commands:
+  queue                                  queue depth by state and host
+  peek [n]                               next n queued URLs
+  purge <hostname>                       drop every queued URL of a host
+  pause                                  stop processing the queue
+  resume                                 resume processing the queue
+  policy <hostname>                      show the crawl policy of a host
+  set-policy <hostname> [-interval D] [-blocked true|false]
+                                         override the crawl policy of a host,
+                                         -interval "" restores the default
+  outcomes <hostname> [limit]            recent crawl outcomes of a host
+  dead-letters                           URLs that exhausted their attempts
+  replay <url>...                        requeue dead letters
+`
+
+func main() {
+
+	var balancerFlag string
+	flag.StringVar(&balancerFlag, "balancer", "http://localhost:7070", "Balancer URL")
+
+	var tokenFlag string
+	flag.StringVar(&tokenFlag, "token", os.Getenv("BALANCER_ADMIN_TOKEN"), "Admin token")
+
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	args := flag.Args()
+
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := client.NewAdmin(balancerFlag, tokenFlag)
+
+	var res any
+	var err error
+
+	switch args[0] {
+	case "queue":
+		res, err = c.Queue()
+	case "peek":
+		res, err = c.Peek(intArg(args, 1, admin.DefaultPeekSize))
+	case "purge":
+		res, err = c.PurgeHost(hostnameArg(args))
+	case "pause":
+		res, err = c.Pause()
+	case "resume":
+		res, err = c.Resume()
+	case "policy":
+		res, err = c.Policy(hostnameArg(args))
+	case "set-policy":
+		res, err = setPolicy(c, hostnameArg(args), args[2:])
+	case "outcomes":
+		res, err = c.Outcomes(hostnameArg(args), intArg(args, 2, admin.DefaultOutcomeLimit))
+	case "dead-letters":
+		res, err = c.DeadLetters()
+	case "replay":
+		if len(args) < 2 {
+			fail("replay needs at least one url")
+		}
+		res, err = c.Replay(args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fail(err.Error())
+	}
+
+	out, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		fail(err.Error())
+	}
+
+	fmt.Println(string(out))
+}
+
+func setPolicy(c *client.AdminClient, hostname string, args []string) (*dto.PolicyResponse, error) {
+	var req dto.UpdatePolicyRequest
+
+	fs := flag.NewFlagSet("set-policy", flag.ExitOnError)
+	fs.Func("interval", "Crawl interval, e.g. 1m", func(v string) error {
+		req.CrawlInterval = &v
+		return nil
+	})
+	fs.Func("blocked", "Block or unblock the host", func(v string) error {
+		blocked, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		req.Blocked = &blocked
+		return nil
+	})
+
+	fs.Parse(args)
+
+	if req.CrawlInterval == nil && req.Blocked == nil {
+		fail("set-policy needs -interval or -blocked")
+	}
+
+	return c.UpdatePolicy(hostname, req)
+}
+
+func hostnameArg(args []string) string {
+	if len(args) < 2 {
+		fail(args[0] + " needs a hostname")
+	}
+
+	return args[1]
+}
+
+func intArg(args []string, i int, def int) int {
+	if len(args) <= i {
+		return def
+	}
+
+	n, err := strconv.Atoi(args[i])
+	if err != nil {
+		fail(fmt.Sprintf("%s is not a number", args[i]))
+	}
+
+	return n
+}
+
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, "balancerctl:", msg)
+	os.Exit(1)
+}
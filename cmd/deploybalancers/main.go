@@ -46,10 +46,29 @@ func main() {
 			}
 			file.Close()
 		}
+		discoveryDBPath := fmt.Sprintf("%s/discovery.db", hostDir)
+		// if the file does not exist, create it
+		if _, err := os.Stat(discoveryDBPath); os.IsNotExist(err) {
+			file, err := os.Create(discoveryDBPath)
+			if err != nil {
+				log.Fatalf("Error creating discovery.db file: %v", err)
+			}
+			file.Close()
+		}
+		robotsDBPath := fmt.Sprintf("%s/robots.db", hostDir)
+		// if the file does not exist, create it
+		if _, err := os.Stat(robotsDBPath); os.IsNotExist(err) {
+			file, err := os.Create(robotsDBPath)
+			if err != nil {
+				log.Fatalf("Error creating robots.db file: %v", err)
+			}
+			file.Close()
+		}
 
 		containerConfig := &container.Config{
 			Image: "busybox", // Adjust the image as necessary
 			Cmd:   []string{"/balancer", "-port", fmt.Sprintf("%d", port)},
+			Env:   []string{"BALANCER_ADMIN_TOKEN=" + os.Getenv("BALANCER_ADMIN_TOKEN")},
 			ExposedPorts: map[nat.Port]struct{}{
 				nat.Port(fmt.Sprintf("%d", port) + "/tcp"): {}, // Exposing the port inside the container
 			},
@@ -70,6 +89,8 @@ func main() {
 				"/etc/ssl/certs:/etc/ssl/certs",
 				fmt.Sprintf("%s:/queue.db", queueDBPath),
 				fmt.Sprintf("%s:/policy.db", policyDBPath),
+				fmt.Sprintf("%s:/robots.db", robotsDBPath),
+				fmt.Sprintf("%s:/discovery.db", discoveryDBPath),
 			},
 		}
 
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"juno/pkg/api/client"
+	"juno/pkg/shard"
+
+	balancerService "juno/pkg/node/balancer/service"
+	pageRepo "juno/pkg/node/page/repo/bolt"
+	pageService "juno/pkg/node/page/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Pages used to be sharded by their whole URL and are now sharded by their
+// host. Run this once on every node, while the node is stopped, to move its
+// pages to the shard of their host. A moved page usually belongs to a shard
+// the node does not serve, so its URL is queued for a re-crawl, which lets
+// the balancer send it to the nodes of the right shard.
+func main() {
+
+	var apiURL string
+	flag.StringVar(&apiURL, "api-url", "http://localhost:8080", "URL of the API server")
+	var pageDBPath string
+	flag.StringVar(&pageDBPath, "page-db-path", "page.db", "Path to the page database")
+	var recrawl bool
+	flag.BoolVar(&recrawl, "recrawl", true, "Queue the moved pages for a re-crawl")
+
+	flag.Parse()
+
+	logger := logrus.New()
+
+	repo, err := pageRepo.New(pageDBPath)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer repo.Close()
+
+	urls, err := pageService.New(repo).Reshard()
+
+	if err != nil {
+		panic(err)
+	}
+
+	logger.Infof("moved %d pages to the shard of their host", len(urls))
+
+	if !recrawl || len(urls) == 0 {
+		return
+	}
+
+	res, err := client.New(apiURL).GetBalancers()
+
+	if err != nil {
+		panic(err)
+	}
+
+	var balancers [shard.SHARDS][]string
+	for shardNum, b := range res.Shards {
+		balancers[shardNum] = b
+	}
+
+	balancerSvc := balancerService.New(balancerService.WithLogger(logger))
+	balancerSvc.SetBalancers(balancers)
+
+	if err := balancerSvc.SendBatchedLinks(urls); err != nil {
+		logger.Errorf("failed to queue every moved page for a re-crawl: %v", err)
+	}
+}
@@ -21,9 +21,13 @@ import (
 	infoHandler "juno/pkg/node/info/handler"
 	infoService "juno/pkg/node/info/service"
 
+	challengeHandler "juno/pkg/node/challenge/handler"
+	challengeService "juno/pkg/node/challenge/service"
+
 	"time"
 
 	"juno/pkg/node/router"
+	"juno/pkg/receipt"
 
 	"github.com/sirupsen/logrus"
 )
@@ -38,6 +42,8 @@ func main() {
 	flag.StringVar(&storageDir, "storage-dir", "storage", "Directory to store downloaded HTML files")
 	var port string
 	flag.StringVar(&port, "port", "9090", "Port to run the server on")
+	var keyFile string
+	flag.StringVar(&keyFile, "key-file", "receipt.key", "Path to the key receipts are signed with, generated when missing")
 
 	flag.Parse()
 
@@ -47,6 +53,16 @@ func main() {
 
 	logger := logrus.New()
 
+	key, err := receipt.LoadKey(keyFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	signer := receipt.NewSigner(key)
+
+	logger.WithField("public_key", signer.PublicKey()).Info("register the public key with the node to be paid for its work")
+
 	pageRepo, err := pageRepo.New(pageDBPath)
 
 	if err != nil {
@@ -69,6 +85,7 @@ func main() {
 	fetcherService := fetcherService.New()
 
 	crawlService := crawlService.New(
+		logger,
 		balancerService,
 		pageService,
 		storageService,
@@ -83,16 +100,21 @@ func main() {
 		pageService,
 		storageService,
 		htmlService,
+		extractionService.WithSigner(signer),
 	)
 	extractionHandler := extractionHandler.New(logger, extracionSvc)
 
 	infoSvc := infoService.New(pageService)
 	infoHandler := infoHandler.New(infoSvc)
 
+	challengeSvc := challengeService.New(pageService, storageService)
+	challengeHandler := challengeHandler.New(logger, challengeSvc)
+
 	r := router.New(
 		crawlHandler,
 		extractionHandler,
 		infoHandler,
+		challengeHandler,
 	)
 
 	r.Run(":" + port)
@@ -3,8 +3,10 @@ package main
 import (
 	"flag"
 	"juno/pkg/api/client"
+	"juno/pkg/ranag"
 	"juno/pkg/ranag/router"
 	"juno/pkg/ranag/service"
+	"juno/pkg/receipt"
 	"time"
 
 	"juno/pkg/ranag/handler"
@@ -18,12 +20,43 @@ func main() {
 	var port string
 	flag.StringVar(&apiURL, "api-url", "http://127.0.0.1:8080", "API URL")
 	flag.StringVar(&port, "port", "6060", "Port to run the server on")
+
+	var address string
+	flag.StringVar(&address, "address", "", "Address the ranag is registered with in the API, set to delegate to child ranags")
+
+	var hedgePercentile float64
+	flag.Float64Var(&hedgePercentile, "hedge-percentile", ranag.DefaultHedgePercentile, "Latency percentile after which a shard request is hedged, 0 disables hedging")
+
+	var maxNodeConcurrency int
+	flag.IntVar(&maxNodeConcurrency, "max-node-concurrency", ranag.MaxNodeConcurrency, "Max requests in flight to a single node")
+
+	var shardConcurrency int
+	flag.IntVar(&shardConcurrency, "shard-concurrency", ranag.DefaultShardConcurrency, "Max shards of a range queried at once")
+
+	var verifySample float64
+	flag.Float64Var(&verifySample, "verify-sample", 0, "Fraction of shards whose answer is compared with other replicas, 0 disables verification")
+
+	var keyFile string
+	flag.StringVar(&keyFile, "key-file", "receipt.key", "Path to the key receipts are signed with, generated when missing")
+
 	flag.Parse()
 
 	if apiURL == "" {
 		panic("api-url is required")
 	}
 
+	logger := logrus.New()
+
+	key, err := receipt.LoadKey(keyFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	signer := receipt.NewSigner(key)
+
+	logger.WithField("public_key", signer.PublicKey()).Info("register the public key with the ranag to be paid for its work")
+
 	s := service.New(
 		service.WithApiClient(
 			client.New(
@@ -31,9 +64,14 @@ func main() {
 			),
 		),
 
-		service.WithLogger(
-			logrus.New(),
-		),
+		service.WithLogger(logger),
+		service.WithSigner(signer),
+
+		service.WithAddress(address),
+		service.WithHedgePercentile(hedgePercentile),
+		service.WithNodeConcurrency(min(ranag.DefaultNodeConcurrency, maxNodeConcurrency), maxNodeConcurrency),
+		service.WithShardConcurrency(shardConcurrency),
+		service.WithVerification(verifySample),
 
 		service.WithShardFetchInterval(time.Minute),
 	)
@@ -6,7 +6,7 @@ import (
 )
 
 func main() {
-	shard := shard.GetShard("https://en.wikipedia.org/wiki/2024_Japanese_general_election")
+	shard := shard.GetURLShard("https://en.wikipedia.org/wiki/2024_Japanese_general_election")
 
 	fmt.Printf("Shard: %d\n", shard)
 }
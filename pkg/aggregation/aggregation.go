@@ -0,0 +1,485 @@
+// Package aggregation implements the reduce operations a strategy can
+// declare. Nodes fold the rows of a shard into partials, ranags and the API
+// merge partials and the API finalizes them into results, so only the
+// partials travel over the network instead of every row.
+package aggregation
+
+import (
+	"errors"
+	"fmt"
+	"juno/pkg/util"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Op string
+
+const (
+	OpCount         Op = "count"
+	OpCountDistinct Op = "count_distinct"
+	OpGroupBy       Op = "group_by"
+	OpTopK          Op = "top_k"
+	OpHistogram     Op = "histogram"
+)
+
+type Metric string
+
+const (
+	MetricSum Metric = "sum"
+	MetricAvg Metric = "avg"
+	MetricMin Metric = "min"
+	MetricMax Metric = "max"
+)
+
+const (
+	// MaxGroups caps the groups of a group_by partial. Rows of further groups
+	// are counted in OtherGroup.
+	MaxGroups  = 10_000
+	OtherGroup = "_other"
+
+	MaxTopK = 1_000
+	// TopKOverfetch is how many times k values a top_k partial keeps, so
+	// values that are not in the top k of every shard can still make it into
+	// the merged top k.
+	TopKOverfetch = 10
+)
+
+// Aggregation is a reduce operation over the extracted rows. Field and Value
+// are field names of the strategy.
+type Aggregation struct {
+	Name string `json:"name"`
+	Op   Op     `json:"op"`
+
+	// Field is counted by count (rows where it is empty are skipped), is the
+	// group key of group_by, the counted value of count_distinct and top_k
+	// and the bucketed number of histogram
+	Field string `json:"field,omitempty"`
+
+	// Metric is computed per group over Value, without it group_by counts
+	Metric Metric `json:"metric,omitempty"`
+	Value  string `json:"value,omitempty"`
+
+	K        int     `json:"k,omitempty"`
+	Interval float64 `json:"interval,omitempty"`
+}
+
+func (a Aggregation) Validate() error {
+	var errs []error
+
+	if a.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+
+	if a.Op != OpCount && a.Field == "" {
+		errs = append(errs, fmt.Errorf("field is required for %s", a.Op))
+	}
+
+	switch a.Op {
+	case OpCount, OpCountDistinct:
+	case OpGroupBy:
+		switch a.Metric {
+		case "":
+		case MetricSum, MetricAvg, MetricMin, MetricMax:
+			if a.Value == "" {
+				errs = append(errs, fmt.Errorf("value is required for %s", a.Metric))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown metric %q", a.Metric))
+		}
+	case OpTopK:
+		if a.K < 1 || a.K > MaxTopK {
+			errs = append(errs, fmt.Errorf("k must be between 1 and %d", MaxTopK))
+		}
+	case OpHistogram:
+		if a.Interval <= 0 {
+			errs = append(errs, errors.New("interval must be greater than 0"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown op %q", a.Op))
+	}
+
+	if len(errs) > 0 {
+		return util.ValidationErrs(errs)
+	}
+
+	return nil
+}
+
+// Validate validates every aggregation and that their names are unique.
+func Validate(aggs []*Aggregation) error {
+	names := map[string]bool{}
+
+	for _, a := range aggs {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("aggregation %q: %w", a.Name, err)
+		}
+
+		if names[a.Name] {
+			return fmt.Errorf("aggregation %q is declared twice", a.Name)
+		}
+
+		names[a.Name] = true
+	}
+
+	return nil
+}
+
+type Stats struct {
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+func (s *Stats) add(v float64) {
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+
+	s.Count++
+	s.Sum += v
+}
+
+func (s *Stats) merge(o *Stats) {
+	if o.Count == 0 {
+		return
+	}
+
+	if s.Count == 0 || o.Min < s.Min {
+		s.Min = o.Min
+	}
+
+	if s.Count == 0 || o.Max > s.Max {
+		s.Max = o.Max
+	}
+
+	s.Count += o.Count
+	s.Sum += o.Sum
+}
+
+// Partial is the mergeable state of one aggregation. Only the member of its
+// op is set.
+type Partial struct {
+	Count   int64             `json:"count,omitempty"`
+	Sketch  *Sketch           `json:"sketch,omitempty"`
+	Groups  map[string]*Stats `json:"groups,omitempty"`
+	Counts  map[string]int64  `json:"counts,omitempty"`
+	Buckets map[int64]int64   `json:"buckets,omitempty"`
+}
+
+// Partials are the partials of a strategy's aggregations by name.
+type Partials map[string]*Partial
+
+func NewPartials(aggs []*Aggregation) Partials {
+	p := make(Partials, len(aggs))
+
+	for _, a := range aggs {
+		p[a.Name] = newPartial(a)
+	}
+
+	return p
+}
+
+func newPartial(a *Aggregation) *Partial {
+	partial := &Partial{}
+	partial.init(a)
+	return partial
+}
+
+// init allocates the member of the op, which is left out of the JSON of an
+// empty partial.
+func (p *Partial) init(a *Aggregation) {
+	switch {
+	case a.Op == OpCountDistinct && p.Sketch == nil:
+		p.Sketch = NewSketch()
+	case a.Op == OpGroupBy && p.Groups == nil:
+		p.Groups = map[string]*Stats{}
+	case a.Op == OpTopK && p.Counts == nil:
+		p.Counts = map[string]int64{}
+	case a.Op == OpHistogram && p.Buckets == nil:
+		p.Buckets = map[int64]int64{}
+	}
+}
+
+func (p Partials) partial(a *Aggregation) *Partial {
+	partial, ok := p[a.Name]
+
+	if !ok || partial == nil {
+		partial = &Partial{}
+		p[a.Name] = partial
+	}
+
+	partial.init(a)
+	return partial
+}
+
+// Add folds an extracted row into the partials.
+func (p Partials) Add(aggs []*Aggregation, row map[string]interface{}) {
+	for _, a := range aggs {
+		partial := p.partial(a)
+		key := stringValue(row[a.Field])
+
+		switch a.Op {
+		case OpCount:
+			if a.Field == "" || key != "" {
+				partial.Count++
+			}
+		case OpCountDistinct:
+			if key != "" {
+				partial.Sketch.Add(key)
+			}
+		case OpGroupBy:
+			if key == "" {
+				continue
+			}
+
+			v := 0.0
+			if a.Metric != "" {
+				var ok bool
+				if v, ok = numberValue(row[a.Value]); !ok {
+					continue
+				}
+			}
+
+			if _, ok := partial.Groups[key]; !ok && len(partial.Groups) >= MaxGroups {
+				key = OtherGroup
+			}
+
+			stats, ok := partial.Groups[key]
+			if !ok {
+				stats = &Stats{}
+				partial.Groups[key] = stats
+			}
+
+			stats.add(v)
+		case OpTopK:
+			if key != "" {
+				partial.Counts[key]++
+			}
+		case OpHistogram:
+			if v, ok := numberValue(row[a.Field]); ok {
+				partial.Buckets[int64(math.Floor(v/a.Interval))]++
+			}
+		}
+	}
+}
+
+// Merge merges o into the partials and compacts them.
+func (p Partials) Merge(aggs []*Aggregation, o Partials) {
+	for _, a := range aggs {
+		src, ok := o[a.Name]
+		if !ok || src == nil {
+			continue
+		}
+
+		dst := p.partial(a)
+
+		switch a.Op {
+		case OpCount:
+			dst.Count += src.Count
+		case OpCountDistinct:
+			if src.Sketch != nil {
+				dst.Sketch.Merge(src.Sketch)
+			}
+		case OpGroupBy:
+			for key, stats := range src.Groups {
+				if _, ok := dst.Groups[key]; !ok && len(dst.Groups) >= MaxGroups {
+					key = OtherGroup
+				}
+
+				if _, ok := dst.Groups[key]; !ok {
+					dst.Groups[key] = &Stats{}
+				}
+
+				dst.Groups[key].merge(stats)
+			}
+		case OpTopK:
+			for value, count := range src.Counts {
+				dst.Counts[value] += count
+			}
+		case OpHistogram:
+			for bucket, count := range src.Buckets {
+				dst.Buckets[bucket] += count
+			}
+		}
+	}
+
+	p.Compact(aggs)
+}
+
+// Compact trims top_k partials to the values that can still make the top k.
+// It is called before partials are sent on.
+func (p Partials) Compact(aggs []*Aggregation) {
+	for _, a := range aggs {
+		partial, ok := p[a.Name]
+		if !ok || a.Op != OpTopK || len(partial.Counts) <= a.K*TopKOverfetch {
+			continue
+		}
+
+		kept := map[string]int64{}
+		for _, c := range topCounts(partial.Counts, a.K*TopKOverfetch) {
+			kept[c.Value] = c.Count
+		}
+
+		partial.Counts = kept
+	}
+}
+
+type Group struct {
+	Key   string   `json:"key"`
+	Count int64    `json:"count"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type Bucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int64   `json:"count"`
+}
+
+// Finalize turns the merged partials into the results by aggregation name:
+// an int64 for count and count_distinct, []*Group for group_by (largest
+// groups first), []*ValueCount for top_k and []*Bucket for histogram.
+// count_distinct is estimated with a standard error of about 1.6%.
+func Finalize(aggs []*Aggregation, p Partials) map[string]interface{} {
+	results := make(map[string]interface{}, len(aggs))
+
+	for _, a := range aggs {
+		partial := p.partial(a)
+
+		switch a.Op {
+		case OpCount:
+			results[a.Name] = partial.Count
+		case OpCountDistinct:
+			results[a.Name] = int64(partial.Sketch.Estimate())
+		case OpGroupBy:
+			results[a.Name] = groups(a, partial.Groups)
+		case OpTopK:
+			results[a.Name] = topCounts(partial.Counts, a.K)
+		case OpHistogram:
+			results[a.Name] = buckets(a, partial.Buckets)
+		}
+	}
+
+	return results
+}
+
+func groups(a *Aggregation, stats map[string]*Stats) []*Group {
+	groups := make([]*Group, 0, len(stats))
+
+	for key, s := range stats {
+		g := &Group{Key: key, Count: s.Count}
+
+		var v float64
+		switch a.Metric {
+		case MetricSum:
+			v = s.Sum
+		case MetricAvg:
+			v = s.Sum / float64(s.Count)
+		case MetricMin:
+			v = s.Min
+		case MetricMax:
+			v = s.Max
+		}
+
+		if a.Metric != "" {
+			g.Value = &v
+		}
+
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	return groups
+}
+
+func topCounts(counts map[string]int64, k int) []*ValueCount {
+	values := make([]*ValueCount, 0, len(counts))
+
+	for value, count := range counts {
+		values = append(values, &ValueCount{Value: value, Count: count})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+
+	if len(values) > k {
+		values = values[:k]
+	}
+
+	return values
+}
+
+func buckets(a *Aggregation, counts map[int64]int64) []*Bucket {
+	buckets := make([]*Bucket, 0, len(counts))
+
+	for bucket, count := range counts {
+		buckets = append(buckets, &Bucket{
+			From:  float64(bucket) * a.Interval,
+			To:    float64(bucket+1) * a.Interval,
+			Count: count,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].From < buckets[j].From
+	})
+
+	return buckets
+}
+
+func stringValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(fmt.Sprint(v))
+}
+
+// numberValue parses numbers extracted as text, thousands separators
+// included.
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+
+	s := strings.ReplaceAll(stringValue(v), ",", "")
+
+	if s == "" {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+
+	return f, true
+}
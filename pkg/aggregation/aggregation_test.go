@@ -0,0 +1,161 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+var rows = []map[string]interface{}{
+	{"category": "books", "price": "10", "author": "a"},
+	{"category": "books", "price": "30", "author": "b"},
+	{"category": "games", "price": "1,000", "author": "a"},
+	{"category": "games", "price": "n/a", "author": "c"},
+	{"category": "", "price": "5", "author": "a"},
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		aggs  []*Aggregation
+		valid bool
+	}{
+		{"count", []*Aggregation{{Name: "rows", Op: OpCount}}, true},
+		{"missing name", []*Aggregation{{Op: OpCount}}, false},
+		{"unknown op", []*Aggregation{{Name: "x", Op: "median", Field: "price"}}, false},
+		{"distinct without field", []*Aggregation{{Name: "x", Op: OpCountDistinct}}, false},
+		{"metric without value", []*Aggregation{{Name: "x", Op: OpGroupBy, Field: "category", Metric: MetricSum}}, false},
+		{"unknown metric", []*Aggregation{{Name: "x", Op: OpGroupBy, Field: "category", Metric: "p99", Value: "price"}}, false},
+		{"top k without k", []*Aggregation{{Name: "x", Op: OpTopK, Field: "author"}}, false},
+		{"histogram without interval", []*Aggregation{{Name: "x", Op: OpHistogram, Field: "price"}}, false},
+		{"duplicate names", []*Aggregation{{Name: "x", Op: OpCount}, {Name: "x", Op: OpCount}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.aggs)
+
+			if tt.valid && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	aggs := []*Aggregation{
+		{Name: "rows", Op: OpCount},
+		{Name: "categorized", Op: OpCount, Field: "category"},
+		{Name: "authors", Op: OpCountDistinct, Field: "author"},
+		{Name: "revenue", Op: OpGroupBy, Field: "category", Metric: MetricSum, Value: "price"},
+		{Name: "avg_price", Op: OpGroupBy, Field: "category", Metric: MetricAvg, Value: "price"},
+		{Name: "per_category", Op: OpGroupBy, Field: "category"},
+		{Name: "top_author", Op: OpTopK, Field: "author", K: 1},
+		{Name: "prices", Op: OpHistogram, Field: "price", Interval: 100},
+	}
+
+	// every row on its own shard, merged as the ranags and the API do
+	merged := NewPartials(aggs)
+	for _, row := range rows {
+		shard := NewPartials(aggs)
+		shard.Add(aggs, row)
+
+		b, err := json.Marshal(shard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded Partials
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		merged.Merge(aggs, decoded)
+	}
+
+	results := Finalize(aggs, merged)
+
+	if results["rows"] != int64(5) {
+		t.Errorf("expected 5 rows, got %v", results["rows"])
+	}
+
+	if results["categorized"] != int64(4) {
+		t.Errorf("expected 4 categorized rows, got %v", results["categorized"])
+	}
+
+	if results["authors"] != int64(3) {
+		t.Errorf("expected 3 authors, got %v", results["authors"])
+	}
+
+	revenue := results["revenue"].([]*Group)
+	if len(revenue) != 2 || revenue[0].Key != "books" || *revenue[0].Value != 40 || *revenue[1].Value != 1000 {
+		t.Errorf("unexpected revenue %s", dump(revenue))
+	}
+
+	avg := results["avg_price"].([]*Group)
+	if *avg[0].Value != 20 {
+		t.Errorf("expected avg book price 20, got %v", *avg[0].Value)
+	}
+
+	perCategory := results["per_category"].([]*Group)
+	if perCategory[0].Key != "books" || perCategory[0].Count != 2 || perCategory[1].Count != 2 || perCategory[0].Value != nil {
+		t.Errorf("unexpected groups %s", dump(perCategory))
+	}
+
+	top := results["top_author"].([]*ValueCount)
+	if len(top) != 1 || top[0].Value != "a" || top[0].Count != 3 {
+		t.Errorf("unexpected top authors %s", dump(top))
+	}
+
+	prices := results["prices"].([]*Bucket)
+	if len(prices) != 2 || prices[0].From != 0 || prices[0].Count != 3 || prices[1].From != 1000 || prices[1].Count != 1 {
+		t.Errorf("unexpected histogram %s", dump(prices))
+	}
+}
+
+func TestMaxGroups(t *testing.T) {
+	aggs := []*Aggregation{{Name: "x", Op: OpGroupBy, Field: "key"}}
+	p := NewPartials(aggs)
+
+	for i := 0; i < MaxGroups+5; i++ {
+		p.Add(aggs, map[string]interface{}{"key": fmt.Sprint(i)})
+	}
+
+	if len(p["x"].Groups) != MaxGroups+1 {
+		t.Errorf("expected %d groups, got %d", MaxGroups+1, len(p["x"].Groups))
+	}
+
+	if p["x"].Groups[OtherGroup].Count != 5 {
+		t.Errorf("expected 5 rows in %s, got %d", OtherGroup, p["x"].Groups[OtherGroup].Count)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	aggs := []*Aggregation{{Name: "x", Op: OpTopK, Field: "key", K: 1}}
+	p := NewPartials(aggs)
+
+	for i := 0; i < 50; i++ {
+		for j := 0; j <= i; j++ {
+			p.Add(aggs, map[string]interface{}{"key": fmt.Sprint(i)})
+		}
+	}
+
+	p.Compact(aggs)
+
+	if len(p["x"].Counts) != TopKOverfetch {
+		t.Fatalf("expected %d values, got %d", TopKOverfetch, len(p["x"].Counts))
+	}
+
+	if p["x"].Counts["49"] != 50 {
+		t.Errorf("expected the most frequent value to be kept")
+	}
+}
+
+func dump(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
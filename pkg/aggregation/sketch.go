@@ -0,0 +1,77 @@
+package aggregation
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// sketchPrecision gives 4096 registers, about 5KB on the wire and a
+// standard error of 1.04/sqrt(4096).
+const sketchPrecision = 12
+
+// Sketch is a HyperLogLog estimate of the number of distinct values. Sketches
+// merge without losing accuracy, which exact sets of values cannot do in a
+// bounded size.
+type Sketch struct {
+	Registers []uint8 `json:"registers"`
+}
+
+func NewSketch() *Sketch {
+	return &Sketch{Registers: make([]uint8, 1<<sketchPrecision)}
+}
+
+func (s *Sketch) Add(value string) {
+	h := murmur3.Sum64([]byte(value))
+
+	register := h >> (64 - sketchPrecision)
+	rank := uint8(bits.LeadingZeros64(h<<sketchPrecision|1<<(sketchPrecision-1))) + 1
+
+	if rank > s.Registers[register] {
+		s.Registers[register] = rank
+	}
+}
+
+// Merge keeps the max of every register. Sketches of another precision are
+// ignored.
+func (s *Sketch) Merge(o *Sketch) {
+	if len(o.Registers) != len(s.Registers) {
+		return
+	}
+
+	for i, r := range o.Registers {
+		if r > s.Registers[i] {
+			s.Registers[i] = r
+		}
+	}
+}
+
+func (s *Sketch) Estimate() uint64 {
+	m := float64(len(s.Registers))
+
+	if m == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	zeros := 0
+
+	for _, r := range s.Registers {
+		sum += math.Ldexp(1, -int(r))
+
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+
+	// linear counting is more accurate for small cardinalities
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(math.Round(estimate))
+}
@@ -0,0 +1,41 @@
+package aggregation
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSketch(t *testing.T) {
+	t.Run("should count small sets exactly", func(t *testing.T) {
+		s := NewSketch()
+
+		for i := 0; i < 3; i++ {
+			s.Add("a")
+			s.Add("b")
+		}
+
+		if s.Estimate() != 2 {
+			t.Errorf("expected 2, got %d", s.Estimate())
+		}
+	})
+
+	t.Run("should estimate merged sketches", func(t *testing.T) {
+		a, b := NewSketch(), NewSketch()
+
+		for i := 0; i < 60_000; i++ {
+			a.Add(fmt.Sprint(i))
+		}
+
+		// overlaps with a by half
+		for i := 30_000; i < 100_000; i++ {
+			b.Add(fmt.Sprint(i))
+		}
+
+		a.Merge(b)
+
+		if e := float64(a.Estimate()); math.Abs(e-100_000)/100_000 > 0.05 {
+			t.Errorf("expected about 100000, got %.0f", e)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"juno/pkg/api/user"
+	"juno/pkg/can"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound      = errors.New("api key not found")
+	ErrInvalidName   = errors.New("invalid api key name")
+	ErrInvalidScope  = errors.New("invalid api key scope")
+	ErrInvalidKey    = errors.New("invalid api key")
+	ErrExpiredKey    = errors.New("expired api key")
+	ErrInvalidExpiry = errors.New("api key expiry is not in the future")
+)
+
+// Prefix starts every API key, so keys are told apart from JWTs.
+const Prefix = "juno_"
+
+type Scope string
+
+const (
+	JobsRead        Scope = "jobs:read"
+	JobsWrite       Scope = "jobs:write"
+	StrategiesWrite Scope = "strategies:write"
+	TokensRead      Scope = "tokens:read"
+	NodesManage     Scope = "nodes:manage"
+)
+
+// Scopes are the scopes a key can be given.
+var Scopes = []Scope{JobsRead, JobsWrite, StrategiesWrite, TokensRead, NodesManage}
+
+// Key lets scripts and servers call the API on behalf of a user. Only the
+// hash of the secret is stored, which is shown once when the key is created.
+type Key struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Name   string
+	// Hint is the start of the secret, so the user can tell keys apart
+	Hint   string
+	Hash   string
+	Scopes []Scope
+	// ExpiresAt is zero for keys that do not expire
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Expired reports whether the key expired by now.
+func (k *Key) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+func (k *Key) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type Repository interface {
+	Create(k *Key) error
+	Get(id uuid.UUID) (*Key, error)
+	GetByHash(hash string) (*Key, error)
+	ListByUserID(userID uuid.UUID) ([]*Key, error)
+	Delete(id uuid.UUID) error
+}
+
+type Service interface {
+	// Create creates a key of the user with the scopes and returns it with
+	// its secret, which cannot be recovered later. A zero expiresAt never
+	// expires.
+	Create(userID uuid.UUID, name string, scopes []Scope, expiresAt time.Time) (*Key, string, error)
+	Get(id uuid.UUID) (*Key, error)
+	ListByUserID(userID uuid.UUID) ([]*Key, error)
+	// Revoke deletes the key, which is rejected from then on.
+	Revoke(id uuid.UUID) error
+	// Authenticate returns the key of the secret and the user it belongs
+	// to. It returns ErrInvalidKey for unknown secrets and ErrExpiredKey
+	// for expired keys.
+	Authenticate(secret string) (*Key, *user.User, error)
+}
+
+type Policy interface {
+	CanRead(ctx context.Context, k *Key) can.Result
+	CanList(ctx context.Context, keys []*Key) can.Result
+	CanRevoke(ctx context.Context, k *Key) can.Result
+}
+
+type Handler interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Revoke(c *gin.Context)
+}
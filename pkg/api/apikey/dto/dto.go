@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"juno/pkg/api/apikey"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type Key struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Hint      string   `json:"hint"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+func NewKeyFromDomain(k *apikey.Key) *Key {
+	key := &Key{
+		ID:        k.ID.String(),
+		Name:      k.Name,
+		Hint:      k.Hint,
+		Scopes:    make([]string, len(k.Scopes)),
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+
+	for i, scope := range k.Scopes {
+		key.Scopes[i] = string(scope)
+	}
+
+	if !k.ExpiresAt.IsZero() {
+		key.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return key
+}
+
+type CreateKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresAt is when the key stops working, never when it is left out
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateKeyResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Key *Key `json:"result,omitempty"`
+	// Secret is the key itself, which is only ever shown here
+	Secret string `json:"secret,omitempty"`
+}
+
+func NewSuccessCreateKeyResponse(k *apikey.Key, secret string) CreateKeyResponse {
+	return CreateKeyResponse{
+		Status: SUCCESS,
+		Key:    NewKeyFromDomain(k),
+		Secret: secret,
+	}
+}
+
+func NewErrorCreateKeyResponse(message string) CreateKeyResponse {
+	return CreateKeyResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type ListKeysResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Keys []*Key `json:"result,omitempty"`
+}
+
+func NewSuccessListKeysResponse(keys []*apikey.Key) ListKeysResponse {
+	res := ListKeysResponse{
+		Status: SUCCESS,
+		Keys:   make([]*Key, len(keys)),
+	}
+
+	for i, k := range keys {
+		res.Keys[i] = NewKeyFromDomain(k)
+	}
+
+	return res
+}
+
+func NewErrorListKeysResponse(message string) ListKeysResponse {
+	return ListKeysResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type RevokeKeyResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewSuccessRevokeKeyResponse() RevokeKeyResponse {
+	return RevokeKeyResponse{
+		Status: SUCCESS,
+	}
+}
+
+func NewErrorRevokeKeyResponse(message string) RevokeKeyResponse {
+	return RevokeKeyResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
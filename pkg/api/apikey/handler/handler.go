@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/apikey/dto"
+	"juno/pkg/api/auth"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger        logrus.FieldLogger
+	policy        apikey.Policy
+	apiKeyService apikey.Service
+}
+
+func New(logger logrus.FieldLogger, policy apikey.Policy, apiKeyService apikey.Service) *Handler {
+	return &Handler{
+		logger:        logger,
+		policy:        policy,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// Create creates a key of the user and answers with its secret, the only
+// time it is shown.
+func (h *Handler) Create(c *gin.Context) {
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	var req dto.CreateKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorCreateKeyResponse(err.Error()))
+		return
+	}
+
+	scopes := make([]apikey.Scope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = apikey.Scope(scope)
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	k, secret, err := h.apiKeyService.Create(u.ID, req.Name, scopes, expiresAt)
+
+	switch err {
+	case nil:
+		c.JSON(201, dto.NewSuccessCreateKeyResponse(k, secret))
+	case apikey.ErrInvalidName, apikey.ErrInvalidScope, apikey.ErrInvalidExpiry:
+		c.JSON(400, dto.NewErrorCreateKeyResponse(err.Error()))
+	default:
+		h.logger.WithError(err).Error("failed to create api key")
+		c.JSON(500, dto.NewErrorCreateKeyResponse("failed to create api key"))
+	}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	keys, err := h.apiKeyService.ListByUserID(u.ID)
+	if err != nil {
+		c.JSON(500, dto.NewErrorListKeysResponse("failed to fetch api keys"))
+		return
+	}
+
+	h.policy.CanList(c.Request.Context(), keys).
+		Allow(func() {
+			c.JSON(200, dto.NewSuccessListKeysResponse(keys))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorListKeysResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorListKeysResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorRevokeKeyResponse("invalid api key ID"))
+		return
+	}
+
+	k, err := h.apiKeyService.Get(id)
+	if err != nil {
+		c.JSON(404, dto.NewErrorRevokeKeyResponse(apikey.ErrNotFound.Error()))
+		return
+	}
+
+	h.policy.CanRevoke(c.Request.Context(), k).
+		Allow(func() {
+			switch err := h.apiKeyService.Revoke(id); err {
+			case nil:
+				c.JSON(200, dto.NewSuccessRevokeKeyResponse())
+			case apikey.ErrNotFound:
+				c.JSON(404, dto.NewErrorRevokeKeyResponse(err.Error()))
+			default:
+				h.logger.WithError(err).Error("failed to revoke api key")
+				c.JSON(500, dto.NewErrorRevokeKeyResponse("failed to revoke api key"))
+			}
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorRevokeKeyResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorRevokeKeyResponse(err.Error()))
+		})
+}
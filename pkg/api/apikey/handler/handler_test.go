@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/apikey/dto"
+	"juno/pkg/api/apikey/policy"
+	"juno/pkg/api/apikey/repo/mem"
+	"juno/pkg/api/apikey/service"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/user"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func send(handle func(c *gin.Context), userID uuid.UUID, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request = httptest.NewRequestWithContext(
+		auth.WithUser(context.Background(), &user.User{ID: userID}),
+		method,
+		path,
+		strings.NewReader(body),
+	)
+
+	if _, id, ok := strings.Cut(path, "/keys/"); ok {
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: id})
+	}
+
+	handle(c)
+
+	return w
+}
+
+func newHandler() (*Handler, apikey.Service) {
+	s := service.New(mem.New(), nil)
+	return New(logrus.New(), policy.New(), s), s
+}
+
+func TestCreate(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	for _, tc := range []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "success", body: `{"name": "cron", "scopes": ["jobs:read", "jobs:write"], "expires_at": "` + expiresAt + `"}`, expected: 201},
+		{name: "without expiry", body: `{"name": "cron", "scopes": ["tokens:read"]}`, expected: 201},
+		{name: "unknown scope", body: `{"name": "cron", "scopes": ["admin"]}`, expected: 400},
+		{name: "no scopes", body: `{"name": "cron", "scopes": []}`, expected: 400},
+		{name: "expired", body: `{"name": "cron", "scopes": ["jobs:read"], "expires_at": "2001-01-01T00:00:00Z"}`, expected: 400},
+		{name: "invalid body", body: `{"scopes": "jobs:read"}`, expected: 400},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := newHandler()
+
+			w := send(h.Create, uuid.New(), "POST", "/auth/keys", tc.body)
+
+			if w.Code != tc.expected {
+				t.Fatalf("Expected %d, got %d: %s", tc.expected, w.Code, w.Body.String())
+			}
+
+			if w.Code != 201 {
+				return
+			}
+
+			var res dto.CreateKeyResponse
+
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if !strings.HasPrefix(res.Secret, apikey.Prefix) || !strings.HasPrefix(res.Secret, res.Key.Hint) {
+				t.Errorf("Expected the secret with its hint, got %+v", res)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	h, s := newHandler()
+	userID := uuid.New()
+
+	s.Create(userID, "cron", []apikey.Scope{apikey.JobsRead}, time.Time{})
+	s.Create(uuid.New(), "other", []apikey.Scope{apikey.JobsRead}, time.Time{})
+
+	w := send(h.List, userID, "GET", "/auth/keys", "")
+
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var res dto.ListKeysResponse
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(res.Keys) != 1 || res.Keys[0].Name != "cron" {
+		t.Errorf("Expected the user's key, got %+v", res.Keys)
+	}
+
+	if strings.Contains(w.Body.String(), "hash") {
+		t.Errorf("Expected the hash left out, got %s", w.Body.String())
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	h, s := newHandler()
+	userID := uuid.New()
+
+	k, _, err := s.Create(userID, "cron", []apikey.Scope{apikey.JobsRead}, time.Time{})
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if w := send(h.Revoke, uuid.New(), "DELETE", "/auth/keys/"+k.ID.String(), ""); w.Code != 403 {
+		t.Errorf("Expected 403 for another user, got %d", w.Code)
+	}
+
+	if w := send(h.Revoke, userID, "DELETE", "/auth/keys/nope", ""); w.Code != 400 {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+
+	if w := send(h.Revoke, userID, "DELETE", "/auth/keys/"+k.ID.String(), ""); w.Code != 200 {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+
+	if w := send(h.Revoke, userID, "DELETE", "/auth/keys/"+k.ID.String(), ""); w.Code != 404 {
+		t.Errorf("Expected 404 once revoked, got %d", w.Code)
+	}
+}
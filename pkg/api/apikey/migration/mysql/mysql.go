@@ -0,0 +1,50 @@
+package mysql
+
+import "database/sql"
+
+var migrations = map[string]string{
+	"create_api_keys_table": `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL,
+			name VARCHAR(64) NOT NULL,
+			hint VARCHAR(16) NOT NULL,
+			hash CHAR(64) NOT NULL,
+			scopes VARCHAR(255) NOT NULL,
+			expires_at TIMESTAMP NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			UNIQUE (hash),
+			INDEX (user_id)
+		);`,
+}
+
+func ExecuteMigrations(db *sql.DB) error {
+
+	// create migrations table if it doesn't exist
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+			name VARCHAR(255) NOT NULL PRIMARY KEY
+		);`); err != nil {
+		return err
+	}
+
+	for name, migration := range migrations {
+
+		// check if migration has already been executed
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = ?", name).Scan(&count); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(migration); err != nil {
+			return err
+		}
+
+		db.Exec("INSERT INTO migrations (name) VALUES (?)", name)
+	}
+
+	return nil
+}
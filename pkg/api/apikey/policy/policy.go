@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"context"
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/auth"
+	"juno/pkg/can"
+)
+
+type Policy struct{}
+
+func New() *Policy {
+	return &Policy{}
+}
+
+func (p *Policy) CanRead(ctx context.Context, k *apikey.Key) can.Result {
+	return owns(ctx, k)
+}
+
+func (p *Policy) CanList(ctx context.Context, keys []*apikey.Key) can.Result {
+	for _, k := range keys {
+		if result := owns(ctx, k); !result.Allowed {
+			return result
+		}
+	}
+
+	return can.Allowed()
+}
+
+func (p *Policy) CanRevoke(ctx context.Context, k *apikey.Key) can.Result {
+	return owns(ctx, k)
+}
+
+func owns(ctx context.Context, k *apikey.Key) can.Result {
+	user := auth.MustUserFromContext(ctx)
+
+	if k.UserID != user.ID {
+		return can.Denied("api key does not belong to user")
+	}
+
+	return can.Allowed()
+}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"context"
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/user"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRead(t *testing.T) {
+	t.Run("only the owner can read a key", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanRead(ctx, &apikey.Key{UserID: userID}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanRead(ctx, &apikey.Key{UserID: uuid.New()}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("only the owner can list keys", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanList(ctx, []*apikey.Key{{UserID: userID}}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanList(ctx, []*apikey.Key{{UserID: userID}, {UserID: uuid.New()}}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	t.Run("only the owner can revoke a key", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanRevoke(ctx, &apikey.Key{UserID: userID}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanRevoke(ctx, &apikey.Key{UserID: uuid.New()}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
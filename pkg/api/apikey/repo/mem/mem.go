@@ -0,0 +1,92 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/apikey"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]apikey.Key
+}
+
+func New() *Repository {
+	return &Repository{keys: make(map[uuid.UUID]apikey.Key)}
+}
+
+func (r *Repository) Create(k *apikey.Key) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys {
+		if existing.ID == k.ID || existing.Hash == k.Hash {
+			return errors.New("unique key violation")
+		}
+	}
+
+	r.keys[k.ID] = *k
+
+	return nil
+}
+
+func (r *Repository) Get(id uuid.UUID) (*apikey.Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.keys[id]
+	if !ok {
+		return nil, apikey.ErrNotFound
+	}
+
+	return &k, nil
+}
+
+func (r *Repository) GetByHash(hash string) (*apikey.Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.keys {
+		if k.Hash == hash {
+			return &k, nil
+		}
+	}
+
+	return nil, apikey.ErrNotFound
+}
+
+// ListByUserID returns the user's keys, newest first.
+func (r *Repository) ListByUserID(userID uuid.UUID) ([]*apikey.Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []*apikey.Key
+
+	for _, k := range r.keys {
+		if k.UserID == userID {
+			keys = append(keys, &k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r *Repository) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[id]; !ok {
+		return apikey.ErrNotFound
+	}
+
+	delete(r.keys, id)
+
+	return nil
+}
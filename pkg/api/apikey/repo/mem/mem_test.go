@@ -0,0 +1,54 @@
+package mem
+
+import (
+	"juno/pkg/api/apikey"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRepository(t *testing.T) {
+	repo := New()
+	userID := uuid.New()
+	now := time.Now()
+
+	first := &apikey.Key{ID: uuid.New(), UserID: userID, Name: "cron", Hash: "a", CreatedAt: now}
+	second := &apikey.Key{ID: uuid.New(), UserID: userID, Name: "server", Hash: "b", CreatedAt: now.Add(time.Minute)}
+
+	for _, k := range []*apikey.Key{first, second, {ID: uuid.New(), UserID: uuid.New(), Hash: "c"}} {
+		if err := repo.Create(k); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	}
+
+	if err := repo.Create(&apikey.Key{ID: uuid.New(), Hash: "a"}); err == nil {
+		t.Errorf("Expected a duplicate hash rejected")
+	}
+
+	if k, err := repo.GetByHash("b"); err != nil || k.ID != second.ID {
+		t.Errorf("Expected the second key, got %+v, %v", k, err)
+	}
+
+	keys, err := repo.ListByUserID(userID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(keys) != 2 || keys[0].ID != second.ID {
+		t.Errorf("Expected the user's 2 keys newest first, got %+v", keys)
+	}
+
+	if err := repo.Delete(first.ID); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if _, err := repo.Get(first.ID); err != apikey.ErrNotFound {
+		t.Errorf("Expected %v, got %v", apikey.ErrNotFound, err)
+	}
+
+	if err := repo.Delete(first.ID); err != apikey.ErrNotFound {
+		t.Errorf("Expected %v, got %v", apikey.ErrNotFound, err)
+	}
+}
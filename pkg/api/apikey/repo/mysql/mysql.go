@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"juno/pkg/api/apikey"
+
+	"github.com/google/uuid"
+)
+
+const selectKeys = "SELECT id, user_id, name, hint, hash, scopes, expires_at, created_at FROM api_keys"
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*apikey.Key, error) {
+	var (
+		k         apikey.Key
+		scopes    string
+		expiresAt sql.NullTime
+	)
+
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Hint, &k.Hash, &scopes, &expiresAt, &k.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, apikey.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &k.Scopes); err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		k.ExpiresAt = expiresAt.Time
+	}
+
+	return &k, nil
+}
+
+func (r *Repository) Create(k *apikey.Key) error {
+	scopes, err := json.Marshal(k.Scopes)
+
+	if err != nil {
+		return err
+	}
+
+	expiresAt := sql.NullTime{Time: k.ExpiresAt, Valid: !k.ExpiresAt.IsZero()}
+
+	_, err = r.db.Exec(
+		"INSERT INTO api_keys (id, user_id, name, hint, hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		k.ID, k.UserID, k.Name, k.Hint, k.Hash, string(scopes), expiresAt, k.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *Repository) Get(id uuid.UUID) (*apikey.Key, error) {
+	return scan(r.db.QueryRow(selectKeys+" WHERE id = ?", id))
+}
+
+func (r *Repository) GetByHash(hash string) (*apikey.Key, error) {
+	return scan(r.db.QueryRow(selectKeys+" WHERE hash = ?", hash))
+}
+
+func (r *Repository) ListByUserID(userID uuid.UUID) ([]*apikey.Key, error) {
+	rows, err := r.db.Query(selectKeys+" WHERE user_id = ? ORDER BY created_at DESC", userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var keys []*apikey.Key
+
+	for rows.Next() {
+		k, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *Repository) Delete(id uuid.UUID) error {
+	res, err := r.db.Exec("DELETE FROM api_keys WHERE id = ?", id)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return apikey.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/apikey/migration/mysql"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/apikey_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestRepository(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	k := &apikey.Key{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Name:      "cron",
+		Hint:      "juno_abcdef",
+		Hash:      uuid.NewString(),
+		Scopes:    []apikey.Scope{apikey.JobsRead, apikey.JobsWrite},
+		ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	defer db.Exec("DELETE FROM api_keys WHERE user_id = ?", k.UserID)
+
+	if err := repo.Create(k); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	got, err := repo.GetByHash(k.Hash)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if got.ID != k.ID || len(got.Scopes) != 2 || !got.ExpiresAt.Equal(k.ExpiresAt) {
+		t.Errorf("Expected %+v, got %+v", k, got)
+	}
+
+	keys, err := repo.ListByUserID(k.UserID)
+
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected the user's key, got %+v, %v", keys, err)
+	}
+
+	if err := repo.Delete(k.ID); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if _, err := repo.Get(k.ID); err != apikey.ErrNotFound {
+		t.Errorf("Expected %v, got %v", apikey.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/user"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SecretSize is how many random bytes a secret holds
+	SecretSize = 32
+	// HintLength is how much of the secret is kept to tell keys apart
+	HintLength    = len(apikey.Prefix) + 6
+	MaxNameLength = 64
+)
+
+type Service struct {
+	repo        apikey.Repository
+	userService user.Service
+	now         func() time.Time
+}
+
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func New(repo apikey.Repository, userService user.Service, opts ...func(s *Service)) *Service {
+	s := &Service{
+		repo:        repo,
+		userService: userService,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Hash is what a secret is stored and looked up by. Secrets are random, so a
+// plain hash cannot be reversed.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Service) Create(userID uuid.UUID, name string, scopes []apikey.Scope, expiresAt time.Time) (*apikey.Key, string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > MaxNameLength {
+		return nil, "", apikey.ErrInvalidName
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", apikey.ErrInvalidScope
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(apikey.Scopes, scope) {
+			return nil, "", apikey.ErrInvalidScope
+		}
+	}
+
+	now := s.now().UTC()
+
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return nil, "", apikey.ErrInvalidExpiry
+	}
+
+	b := make([]byte, SecretSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+
+	secret := apikey.Prefix + base64.RawURLEncoding.EncodeToString(b)
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	k := &apikey.Key{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Hint:      secret[:HintLength],
+		Hash:      Hash(secret),
+		Scopes:    slices.Compact(scopes),
+		CreatedAt: now,
+	}
+
+	if !expiresAt.IsZero() {
+		k.ExpiresAt = expiresAt.UTC()
+	}
+
+	if err := s.repo.Create(k); err != nil {
+		return nil, "", err
+	}
+
+	return k, secret, nil
+}
+
+func (s *Service) Get(id uuid.UUID) (*apikey.Key, error) {
+	return s.repo.Get(id)
+}
+
+func (s *Service) ListByUserID(userID uuid.UUID) ([]*apikey.Key, error) {
+	return s.repo.ListByUserID(userID)
+}
+
+func (s *Service) Revoke(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func (s *Service) Authenticate(secret string) (*apikey.Key, *user.User, error) {
+	if !strings.HasPrefix(secret, apikey.Prefix) {
+		return nil, nil, apikey.ErrInvalidKey
+	}
+
+	k, err := s.repo.GetByHash(Hash(secret))
+
+	if err == apikey.ErrNotFound {
+		return nil, nil, apikey.ErrInvalidKey
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if k.Expired(s.now()) {
+		return nil, nil, apikey.ErrExpiredKey
+	}
+
+	u, err := s.userService.Get(k.UserID)
+
+	if err == user.ErrNotFound || err == nil && u == nil {
+		return nil, nil, apikey.ErrInvalidKey
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return k, u, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/apikey/repo/mem"
+	"juno/pkg/api/user"
+	userRepo "juno/pkg/api/user/repo/mem"
+	userService "juno/pkg/api/user/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func newService(t *testing.T) (*Service, *user.User, *time.Time) {
+	t.Helper()
+
+	users := userService.New(logrus.New(), userRepo.New())
+	u, err := users.Create("Jane", "jane@example.com", "password123")
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	now := time.Now()
+
+	return New(mem.New(), users, WithClock(func() time.Time { return now })), u, &now
+}
+
+func TestCreate(t *testing.T) {
+	s, u, now := newService(t)
+
+	t.Run("success", func(t *testing.T) {
+		k, secret, err := s.Create(u.ID, " cron ", []apikey.Scope{apikey.JobsWrite, apikey.JobsRead, apikey.JobsRead}, time.Time{})
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if k.Name != "cron" || len(k.Scopes) != 2 || k.Hash == secret || k.Hash != Hash(secret) {
+			t.Errorf("Expected a named key storing the hash of its secret, got %+v", k)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tc := range []struct {
+			name      string
+			scopes    []apikey.Scope
+			expiresAt time.Time
+			err       error
+		}{
+			{name: "", scopes: []apikey.Scope{apikey.JobsRead}, err: apikey.ErrInvalidName},
+			{name: "cron", err: apikey.ErrInvalidScope},
+			{name: "cron", scopes: []apikey.Scope{"admin"}, err: apikey.ErrInvalidScope},
+			{name: "cron", scopes: []apikey.Scope{apikey.JobsRead}, expiresAt: now.Add(-time.Minute), err: apikey.ErrInvalidExpiry},
+		} {
+			if _, _, err := s.Create(u.ID, tc.name, tc.scopes, tc.expiresAt); err != tc.err {
+				t.Errorf("%q %v: expected %v, got %v", tc.name, tc.scopes, tc.err, err)
+			}
+		}
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	s, u, now := newService(t)
+
+	k, secret, err := s.Create(u.ID, "cron", []apikey.Scope{apikey.JobsRead}, now.Add(time.Hour))
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		got, owner, err := s.Authenticate(secret)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if got.ID != k.ID || owner.ID != u.ID || !got.HasScope(apikey.JobsRead) {
+			t.Errorf("Expected the key and its owner, got %+v, %+v", got, owner)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		for _, secret := range []string{"", apikey.Prefix + "nope", "eyJhbGciOiJIUzI1NiJ9"} {
+			if _, _, err := s.Authenticate(secret); err != apikey.ErrInvalidKey {
+				t.Errorf("%q: expected %v, got %v", secret, apikey.ErrInvalidKey, err)
+			}
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		*now = now.Add(time.Hour)
+
+		if _, _, err := s.Authenticate(secret); err != apikey.ErrExpiredKey {
+			t.Errorf("Expected %v, got %v", apikey.ErrExpiredKey, err)
+		}
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		k, secret, _ := s.Create(u.ID, "server", []apikey.Scope{apikey.JobsRead}, time.Time{})
+
+		if err := s.Revoke(k.ID); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if _, _, err := s.Authenticate(secret); err != apikey.ErrInvalidKey {
+			t.Errorf("Expected %v, got %v", apikey.ErrInvalidKey, err)
+		}
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		_, secret, _ := s.Create(uuid.New(), "orphan", []apikey.Scope{apikey.JobsRead}, time.Time{})
+
+		if _, _, err := s.Authenticate(secret); err != apikey.ErrInvalidKey {
+			t.Errorf("Expected %v, got %v", apikey.ErrInvalidKey, err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"juno/pkg/api/apikey"
 	"juno/pkg/api/user"
 )
 
@@ -9,6 +10,7 @@ import (
 type contextKey string
 
 const userContextKey = contextKey("authUser")
+const keyContextKey = contextKey("authKey")
 
 // Store the user in the context
 func WithUser(ctx context.Context, user *user.User) context.Context {
@@ -28,3 +30,15 @@ func MustUserFromContext(ctx context.Context) *user.User {
 	}
 	return user
 }
+
+// Store the API key the request was made with in the context
+func WithKey(ctx context.Context, k *apikey.Key) context.Context {
+	return context.WithValue(ctx, keyContextKey, k)
+}
+
+// Retrieve the API key from the context. There is none when the request was
+// made with a session token.
+func KeyFromContext(ctx context.Context) (*apikey.Key, bool) {
+	k, ok := ctx.Value(keyContextKey).(*apikey.Key)
+	return k, ok
+}
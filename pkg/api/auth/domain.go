@@ -2,18 +2,73 @@ package auth
 
 import (
 	"errors"
+	"juno/pkg/api/user"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 var ErrInvalidEmailOrPassword = errors.New("invalid email or password")
 var ErrInvalidToken = errors.New("invalid token")
 var ErrExpiredToken = errors.New("expired token")
+var ErrRevokedToken = errors.New("revoked token")
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
+// Tokens are what a user signs in with: a short-lived access token, and a
+// refresh token that is exchanged for new tokens once the access token
+// expired.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// RefreshToken can be exchanged once for new tokens. The refresh tokens
+// exchanged for one another since the user signed in form a family, which is
+// revoked as a whole when a used token comes back, as it was then stolen.
+type RefreshToken struct {
+	ID       uuid.UUID
+	FamilyID uuid.UUID
+	UserID   uuid.UUID
+	// Hash is the hash of the token, which is only known to the user
+	Hash      string
+	ExpiresAt time.Time
+	// UsedAt is when the token was exchanged, zero until it is
+	UsedAt    time.Time
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+type Repository interface {
+	CreateRefreshToken(t *RefreshToken) error
+	// GetRefreshToken returns ErrInvalidRefreshToken for unknown hashes.
+	GetRefreshToken(hash string) (*RefreshToken, error)
+	// UseRefreshToken marks the token used unless it already was, in which
+	// case it returns ErrRefreshTokenReused.
+	UseRefreshToken(id uuid.UUID, at time.Time) error
+	RevokeFamily(familyID uuid.UUID, at time.Time) error
+	// RevokeAccessToken puts the ID of an access token on the revocation
+	// list until it expires.
+	RevokeAccessToken(id string, expiresAt time.Time) error
+	IsRevoked(id string) (bool, error)
+}
 
 type Handler interface {
 	Token(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
 }
 
 type Service interface {
-	Authenticate(email, password string) (string, error)
+	Authenticate(email, password string) (*Tokens, error)
+	// Refresh exchanges a refresh token for new tokens. Reusing a refresh
+	// token revokes its family and returns ErrRefreshTokenReused.
+	Refresh(refreshToken string) (*Tokens, error)
+	// Logout revokes the access token and the family of the refresh token,
+	// when there is one.
+	Logout(token, refreshToken string) error
+	// TokenToUser returns the user of an access token that is neither
+	// expired nor revoked.
+	TokenToUser(token string) (*user.User, error)
 }
@@ -1,5 +1,7 @@
 package dto
 
+import "juno/pkg/api/auth"
+
 const (
 	SUCCESS = "success"
 	ERROR   = "error"
@@ -10,17 +12,29 @@ type TokenRequest struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest names the refresh token to revoke with the access token, so
+// the session cannot be refreshed either.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type TokenResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
 
-	Token string `json:"token,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
-func NewSuccessTokenResponse(token string) TokenResponse {
+func NewSuccessTokenResponse(tokens *auth.Tokens) TokenResponse {
 	return TokenResponse{
-		Status: SUCCESS,
-		Token:  token,
+		Status:       SUCCESS,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}
 }
 
@@ -30,3 +44,21 @@ func NewErrorTokenResponse(message string) TokenResponse {
 		Message: message,
 	}
 }
+
+type LogoutResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewSuccessLogoutResponse() LogoutResponse {
+	return LogoutResponse{
+		Status: SUCCESS,
+	}
+}
+
+func NewErrorLogoutResponse(message string) LogoutResponse {
+	return LogoutResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
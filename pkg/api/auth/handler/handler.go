@@ -29,7 +29,7 @@ func (h *Handler) Token(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.Authenticate(req.Email, req.Password)
+	tokens, err := h.authService.Authenticate(req.Email, req.Password)
 
 	if err != nil {
 		h.logger.Error(err)
@@ -37,5 +37,54 @@ func (h *Handler) Token(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, dto.NewSuccessTokenResponse(token))
+	c.JSON(200, dto.NewSuccessTokenResponse(tokens))
+}
+
+// Refresh exchanges a refresh token for new tokens.
+func (h *Handler) Refresh(c *gin.Context) {
+	var req dto.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorTokenResponse(err.Error()))
+		return
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken)
+
+	switch err {
+	case nil:
+		c.JSON(200, dto.NewSuccessTokenResponse(tokens))
+	case auth.ErrInvalidRefreshToken, auth.ErrRefreshTokenReused:
+		c.JSON(401, dto.NewErrorTokenResponse(err.Error()))
+	default:
+		h.logger.WithError(err).Error("failed to refresh token")
+		c.JSON(500, dto.NewErrorTokenResponse("failed to refresh token"))
+	}
+}
+
+// Logout revokes the access token the request was made with, and the refresh
+// token of the request.
+func (h *Handler) Logout(c *gin.Context) {
+	var req dto.LogoutRequest
+
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, dto.NewErrorLogoutResponse(err.Error()))
+			return
+		}
+	}
+
+	err := h.authService.Logout(c.GetHeader("Authorization"), req.RefreshToken)
+
+	switch err {
+	case nil:
+		c.JSON(200, dto.NewSuccessLogoutResponse())
+	case auth.ErrInvalidRefreshToken:
+		c.JSON(400, dto.NewErrorLogoutResponse(err.Error()))
+	case auth.ErrInvalidToken, auth.ErrExpiredToken:
+		c.JSON(401, dto.NewErrorLogoutResponse(err.Error()))
+	default:
+		h.logger.WithError(err).Error("failed to log out")
+		c.JSON(500, dto.NewErrorLogoutResponse("failed to log out"))
+	}
 }
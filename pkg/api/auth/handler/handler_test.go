@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"juno/pkg/api/auth/dto"
+	authRepo "juno/pkg/api/auth/repo/mem"
 	authService "juno/pkg/api/auth/service"
 	"juno/pkg/api/user"
 	usrRepo "juno/pkg/api/user/repo/mem"
@@ -47,7 +48,7 @@ func TestToken(t *testing.T) {
 			t.Errorf("expected err to be nil, got %v", err)
 		}
 
-		authSvc := authService.New(logrus.New(), usrSvc)
+		authSvc := authService.New(logrus.New(), usrSvc, authRepo.New())
 		authHandler := New(logrus.New(), authSvc)
 
 		var req dto.TokenRequest
@@ -84,7 +85,7 @@ func TestToken(t *testing.T) {
 		}
 
 		// Validate the token using TokenToUser
-		parsedUser, err := authService.TokenToUser(resp.Token)
+		parsedUser, err := authSvc.TokenToUser(resp.Token)
 
 		if err != nil {
 			t.Fatalf("Expected no error when parsing token, got %v", err)
@@ -122,7 +123,7 @@ func TestToken(t *testing.T) {
 			t.Errorf("expected err to be nil, got %v", err)
 		}
 
-		authSvc := authService.New(logrus.New(), usrSvc)
+		authSvc := authService.New(logrus.New(), usrSvc, authRepo.New())
 		authHandler := New(logrus.New(), authSvc)
 
 		var req dto.TokenRequest
@@ -170,3 +171,107 @@ func TestToken(t *testing.T) {
 
 	})
 }
+
+func newSignedInHandler(t *testing.T) (*Handler, *authService.Service, string, string) {
+	t.Helper()
+	t.Setenv("SECRET", "secret")
+
+	hash, _ := util.BcryptPassword("password")
+	u := &user.User{ID: uuid.New(), Email: randomEmail(), Password: hash}
+
+	usrRepo := usrRepo.New()
+	usrRepo.Create(u)
+
+	authSvc := authService.New(logrus.New(), usrService.New(logrus.New(), usrRepo), authRepo.New())
+
+	tokens, err := authSvc.Authenticate(u.Email, "password")
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return New(logrus.New(), authSvc), authSvc, tokens.AccessToken, tokens.RefreshToken
+}
+
+func TestRefresh(t *testing.T) {
+	refresh := func(h *Handler, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+		tc.Request = httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(body))
+
+		h.Refresh(tc)
+
+		return w
+	}
+
+	h, _, _, refreshToken := newSignedInHandler(t)
+
+	w := refresh(h, `{"refresh_token":"`+refreshToken+`"}`)
+
+	if w.Code != 200 {
+		t.Fatalf("expected status code 200, got %v", w.Code)
+	}
+
+	var resp dto.TokenResponse
+
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Error parsing response: %s", err)
+	}
+
+	if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == refreshToken {
+		t.Errorf("Expected new tokens, got %+v", resp)
+	}
+
+	if w := refresh(h, `{"refresh_token":"`+refreshToken+`"}`); w.Code != 401 {
+		t.Errorf("expected status code 401 for a reused token, got %v", w.Code)
+	}
+
+	if w := refresh(h, `{}`); w.Code != 400 {
+		t.Errorf("expected status code 400, got %v", w.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	logout := func(h *Handler, token, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+		tc.Request = httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBufferString(body))
+		tc.Request.Header.Set("Authorization", token)
+
+		h.Logout(tc)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		h, authSvc, accessToken, refreshToken := newSignedInHandler(t)
+
+		if w := logout(h, accessToken, `{"refresh_token":"`+refreshToken+`"}`); w.Code != 200 {
+			t.Fatalf("expected status code 200, got %v", w.Code)
+		}
+
+		if _, err := authSvc.TokenToUser(accessToken); err == nil {
+			t.Errorf("Expected the access token revoked")
+		}
+
+		if _, err := authSvc.Refresh(refreshToken); err == nil {
+			t.Errorf("Expected the refresh token revoked")
+		}
+	})
+
+	t.Run("without a refresh token", func(t *testing.T) {
+		h, _, accessToken, _ := newSignedInHandler(t)
+
+		if w := logout(h, accessToken, ""); w.Code != 200 {
+			t.Errorf("expected status code 200, got %v", w.Code)
+		}
+	})
+
+	t.Run("unknown refresh token", func(t *testing.T) {
+		h, _, accessToken, _ := newSignedInHandler(t)
+
+		if w := logout(h, accessToken, `{"refresh_token":"nope"}`); w.Code != 400 {
+			t.Errorf("expected status code 400, got %v", w.Code)
+		}
+	})
+}
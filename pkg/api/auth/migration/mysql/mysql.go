@@ -0,0 +1,56 @@
+package mysql
+
+import "database/sql"
+
+var migrations = map[string]string{
+	"create_refresh_tokens_table": `
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id VARCHAR(36) PRIMARY KEY,
+			family_id VARCHAR(36) NOT NULL,
+			user_id VARCHAR(36) NOT NULL,
+			hash CHAR(64) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP NULL,
+			revoked_at TIMESTAMP NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			UNIQUE (hash),
+			INDEX (family_id)
+		);`,
+	"create_revoked_tokens_table": `
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			id VARCHAR(36) PRIMARY KEY,
+			expires_at TIMESTAMP NOT NULL,
+			INDEX (expires_at)
+		);`,
+}
+
+func ExecuteMigrations(db *sql.DB) error {
+
+	// create migrations table if it doesn't exist
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+			name VARCHAR(255) NOT NULL PRIMARY KEY
+		);`); err != nil {
+		return err
+	}
+
+	for name, migration := range migrations {
+
+		// check if migration has already been executed
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = ?", name).Scan(&count); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(migration); err != nil {
+			return err
+		}
+
+		db.Exec("INSERT INTO migrations (name) VALUES (?)", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/auth"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	mu            sync.Mutex
+	refreshTokens map[uuid.UUID]auth.RefreshToken
+	// revoked holds the expiry of the revoked access tokens by ID
+	revoked map[string]time.Time
+}
+
+func New() *Repository {
+	return &Repository{
+		refreshTokens: make(map[uuid.UUID]auth.RefreshToken),
+		revoked:       make(map[string]time.Time),
+	}
+}
+
+func (r *Repository) CreateRefreshToken(t *auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.refreshTokens {
+		if existing.ID == t.ID || existing.Hash == t.Hash {
+			return errors.New("unique key violation")
+		}
+	}
+
+	r.refreshTokens[t.ID] = *t
+
+	return nil
+}
+
+func (r *Repository) GetRefreshToken(hash string) (*auth.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.refreshTokens {
+		if t.Hash == hash {
+			return &t, nil
+		}
+	}
+
+	return nil, auth.ErrInvalidRefreshToken
+}
+
+func (r *Repository) UseRefreshToken(id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.refreshTokens[id]
+
+	if !ok {
+		return auth.ErrInvalidRefreshToken
+	}
+
+	if !t.UsedAt.IsZero() {
+		return auth.ErrRefreshTokenReused
+	}
+
+	t.UsedAt = at
+	r.refreshTokens[id] = t
+
+	return nil
+}
+
+func (r *Repository) RevokeFamily(familyID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.refreshTokens {
+		if t.FamilyID == familyID && t.RevokedAt.IsZero() {
+			t.RevokedAt = at
+			r.refreshTokens[id] = t
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) RevokeAccessToken(id string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for revoked, exp := range r.revoked {
+		if exp.Before(now) {
+			delete(r.revoked, revoked)
+		}
+	}
+
+	r.revoked[id] = expiresAt
+
+	return nil
+}
+
+func (r *Repository) IsRevoked(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.revoked[id]
+
+	return ok, nil
+}
@@ -0,0 +1,66 @@
+package mem
+
+import (
+	"juno/pkg/api/auth"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRefreshTokens(t *testing.T) {
+	repo := New()
+	familyID := uuid.New()
+	now := time.Now()
+
+	first := &auth.RefreshToken{ID: uuid.New(), FamilyID: familyID, Hash: "a", ExpiresAt: now.Add(time.Hour)}
+	second := &auth.RefreshToken{ID: uuid.New(), FamilyID: familyID, Hash: "b", ExpiresAt: now.Add(time.Hour)}
+	other := &auth.RefreshToken{ID: uuid.New(), FamilyID: uuid.New(), Hash: "c", ExpiresAt: now.Add(time.Hour)}
+
+	for _, token := range []*auth.RefreshToken{first, second, other} {
+		if err := repo.CreateRefreshToken(token); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	}
+
+	if _, err := repo.GetRefreshToken("d"); err != auth.ErrInvalidRefreshToken {
+		t.Errorf("Expected %v, got %v", auth.ErrInvalidRefreshToken, err)
+	}
+
+	if err := repo.UseRefreshToken(first.ID, now); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := repo.UseRefreshToken(first.ID, now); err != auth.ErrRefreshTokenReused {
+		t.Errorf("Expected %v, got %v", auth.ErrRefreshTokenReused, err)
+	}
+
+	if err := repo.RevokeFamily(familyID, now); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	for hash, revoked := range map[string]bool{"a": true, "b": true, "c": false} {
+		token, err := repo.GetRefreshToken(hash)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if token.RevokedAt.IsZero() == revoked {
+			t.Errorf("%s: expected revoked %v, got %+v", hash, revoked, token)
+		}
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	repo := New()
+
+	repo.RevokeAccessToken("expired", time.Now().Add(-time.Minute))
+	repo.RevokeAccessToken("valid", time.Now().Add(time.Hour))
+
+	for id, expected := range map[string]bool{"expired": false, "valid": true, "other": false} {
+		if revoked, _ := repo.IsRevoked(id); revoked != expected {
+			t.Errorf("%s: expected %v, got %v", id, expected, revoked)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) CreateRefreshToken(t *auth.RefreshToken) error {
+	_, err := r.db.Exec(
+		"INSERT INTO refresh_tokens (id, family_id, user_id, hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		t.ID, t.FamilyID, t.UserID, t.Hash, t.ExpiresAt, t.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *Repository) GetRefreshToken(hash string) (*auth.RefreshToken, error) {
+	var (
+		t         auth.RefreshToken
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	err := r.db.QueryRow(
+		"SELECT id, family_id, user_id, hash, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE hash = ?",
+		hash,
+	).Scan(&t.ID, &t.FamilyID, &t.UserID, &t.Hash, &t.ExpiresAt, &usedAt, &revokedAt, &t.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.UsedAt = usedAt.Time
+	t.RevokedAt = revokedAt.Time
+
+	return &t, nil
+}
+
+// UseRefreshToken only marks a token that was not used yet, so of two
+// concurrent exchanges only one succeeds.
+func (r *Repository) UseRefreshToken(id uuid.UUID, at time.Time) error {
+	res, err := r.db.Exec("UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", at, id)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return auth.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (r *Repository) RevokeFamily(familyID uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", at, familyID)
+
+	return err
+}
+
+// RevokeAccessToken also drops the revoked tokens that expired since, as
+// they are rejected anyway.
+func (r *Repository) RevokeAccessToken(id string, expiresAt time.Time) error {
+	if _, err := r.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now().UTC()); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec("INSERT IGNORE INTO revoked_tokens (id, expires_at) VALUES (?, ?)", id, expiresAt)
+
+	return err
+}
+
+func (r *Repository) IsRevoked(id string) (bool, error) {
+	var count int
+
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE id = ?", id).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/auth/migration/mysql"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/auth_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestRefreshTokens(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	token := &auth.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		UserID:    uuid.New(),
+		Hash:      uuid.NewString(),
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+
+	defer db.Exec("DELETE FROM refresh_tokens WHERE family_id = ?", token.FamilyID)
+
+	if err := repo.CreateRefreshToken(token); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := repo.UseRefreshToken(token.ID, now); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := repo.UseRefreshToken(token.ID, now); err != auth.ErrRefreshTokenReused {
+		t.Errorf("Expected %v, got %v", auth.ErrRefreshTokenReused, err)
+	}
+
+	if err := repo.RevokeFamily(token.FamilyID, now); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	got, err := repo.GetRefreshToken(token.Hash)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if !got.UsedAt.Equal(now) || !got.RevokedAt.Equal(now) {
+		t.Errorf("Expected the token used and revoked, got %+v", got)
+	}
+
+	if _, err := repo.GetRefreshToken("unknown"); err != auth.ErrInvalidRefreshToken {
+		t.Errorf("Expected %v, got %v", auth.ErrInvalidRefreshToken, err)
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	id := uuid.NewString()
+
+	defer db.Exec("DELETE FROM revoked_tokens WHERE id = ?", id)
+
+	if err := repo.RevokeAccessToken(id, time.Now().Add(time.Hour).UTC()); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if revoked, err := repo.IsRevoked(id); err != nil || !revoked {
+		t.Errorf("Expected revoked, got %v, %v", revoked, err)
+	}
+}
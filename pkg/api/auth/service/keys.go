@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"juno/pkg/api/auth"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Keyring holds the keys access tokens are signed with, by the kid in the
+// tokens' header. Tokens without a kid are signed with SECRET.
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// KeyringFromEnv reads the signing keys from SIGNING_KEYS, as comma separated
+// kid=secret pairs, and signs with the key SIGNING_KEY_ID names. Without
+// SIGNING_KEY_ID tokens are signed with SECRET. To rotate keys, add a new key,
+// sign with it and remove the old key once the tokens it signed expired.
+//
+// Once SIGNING_KEY_ID is set, tokens without a kid are only accepted with
+// ACCEPT_LEGACY_TOKENS=true, so SECRET can be retired after the tokens it
+// signed expired. It fails when SIGNING_KEY_ID names a key SIGNING_KEYS does
+// not hold, or when there is no key to sign with.
+func KeyringFromEnv() (*Keyring, error) {
+	k := &Keyring{
+		current: os.Getenv("SIGNING_KEY_ID"),
+		keys:    map[string][]byte{},
+	}
+
+	for _, pair := range strings.Split(os.Getenv("SIGNING_KEYS"), ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(pair), "=")
+
+		if ok && kid != "" && secret != "" {
+			k.keys[kid] = []byte(secret)
+		}
+	}
+
+	if secret := os.Getenv("SECRET"); secret != "" && (k.current == "" || os.Getenv("ACCEPT_LEGACY_TOKENS") == "true") {
+		k.keys[""] = []byte(secret)
+	}
+
+	if _, ok := k.keys[k.current]; !ok {
+		if k.current == "" {
+			return nil, errors.New("SECRET or SIGNING_KEY_ID required")
+		}
+
+		return nil, fmt.Errorf("signing key %q not in SIGNING_KEYS", k.current)
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) sign(claims jwt.MapClaims) (string, error) {
+	if k == nil {
+		return "", auth.ErrInvalidToken
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	if k.current != "" {
+		token.Header["kid"] = k.current
+	}
+
+	return token.SignedString(k.keys[k.current])
+}
+
+// key returns the key a token was signed with.
+func (k *Keyring) key(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || k == nil {
+		return nil, auth.ErrInvalidToken
+	}
+
+	kid, _ := t.Header["kid"].(string)
+
+	key, ok := k.keys[kid]
+
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return key, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"juno/pkg/api/auth"
+	authRepo "juno/pkg/api/auth/repo/mem"
+	"juno/pkg/api/user"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSigningKeys(t *testing.T) {
+	// newService reads the keys from the environment as it is now
+	newService := func(t *testing.T) *Service {
+		t.Helper()
+
+		keys, err := KeyringFromEnv()
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return New(logrus.New(), nil, authRepo.New(), WithKeyring(keys))
+	}
+
+	t.Setenv("SECRET", "mysecretkey")
+	t.Setenv("SIGNING_KEYS", "")
+	t.Setenv("SIGNING_KEY_ID", "")
+	t.Setenv("ACCEPT_LEGACY_TOKENS", "")
+
+	u := &user.User{ID: uuid.New(), Email: "test@example.com"}
+
+	legacy, err := newService(t).token(u)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	t.Setenv("SIGNING_KEYS", "k1=first")
+	t.Setenv("SIGNING_KEY_ID", "k1")
+
+	first, err := newService(t).token(u)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	t.Run("rotation overlap", func(t *testing.T) {
+		t.Setenv("SIGNING_KEYS", "k1=first, k2=second")
+		t.Setenv("SIGNING_KEY_ID", "k2")
+		t.Setenv("ACCEPT_LEGACY_TOKENS", "true")
+
+		s := newService(t)
+
+		second, err := s.token(u)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		for name, token := range map[string]string{"legacy": legacy, "first": first, "second": second} {
+			if _, err := s.TokenToUser(token); err != nil {
+				t.Errorf("%s: expected nil, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("retired secret", func(t *testing.T) {
+		if _, err := newService(t).TokenToUser(legacy); err == nil {
+			t.Errorf("Expected the token without a kid rejected")
+		}
+	})
+
+	t.Run("removed key", func(t *testing.T) {
+		t.Setenv("SIGNING_KEYS", "k2=second")
+		t.Setenv("SIGNING_KEY_ID", "k2")
+
+		if _, err := newService(t).TokenToUser(first); err == nil {
+			t.Errorf("Expected the token of the removed key rejected")
+		}
+	})
+
+	t.Run("without a secret", func(t *testing.T) {
+		t.Setenv("SECRET", "")
+
+		if _, err := newService(t).TokenToUser(first); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("unknown current key", func(t *testing.T) {
+		t.Setenv("SIGNING_KEY_ID", "k3")
+
+		if _, err := KeyringFromEnv(); err == nil {
+			t.Errorf("Expected the missing key refused")
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		t.Setenv("SECRET", "")
+		t.Setenv("SIGNING_KEY_ID", "")
+
+		if _, err := KeyringFromEnv(); err == nil {
+			t.Errorf("Expected an error")
+		}
+
+		if _, err := New(logrus.New(), nil, authRepo.New()).token(u); err != auth.ErrInvalidToken {
+			t.Errorf("Expected %v, got %v", auth.ErrInvalidToken, err)
+		}
+	})
+
+	t.Run("same secret under another kid", func(t *testing.T) {
+		// a token cannot pick a key by claiming a kid it was not signed with
+		t.Setenv("SIGNING_KEYS", "k1=other")
+
+		if _, err := newService(t).TokenToUser(first); err == nil {
+			t.Errorf("Expected the token rejected")
+		}
+	})
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"juno/pkg/api/auth"
 	"juno/pkg/api/user"
 	"juno/pkg/util"
-	"os"
 	"strings"
 	"time"
 
@@ -13,22 +16,69 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// AccessTokenTTL is how long access tokens are valid, and so how long a
+	// rotated signing key has to be kept
+	AccessTokenTTL = time.Hour
+	// DefaultRefreshTTL is how long a user stays signed in without using
+	// the API
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+	// RefreshTokenSize is how many random bytes a refresh token holds
+	RefreshTokenSize = 32
+)
+
 type Service struct {
 	logger      logrus.FieldLogger
 	userService user.Service
+	repo        auth.Repository
+	refreshTTL  time.Duration
+	now         func() time.Time
+	// keys sign and verify the access tokens
+	keys *Keyring
+}
+
+func WithRefreshTTL(ttl time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.refreshTTL = ttl
+	}
+}
+
+// WithKeyring sets the keys access tokens are signed with. They are read
+// from the environment otherwise.
+func WithKeyring(keys *Keyring) func(s *Service) {
+	return func(s *Service) {
+		s.keys = keys
+	}
+}
+
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
 }
 
-func New(logger *logrus.Logger, userService user.Service) *Service {
-	return &Service{
+func New(logger *logrus.Logger, userService user.Service, repo auth.Repository, opts ...func(s *Service)) *Service {
+	s := &Service{
 		logger:      logger,
 		userService: userService,
+		repo:        repo,
+		refreshTTL:  DefaultRefreshTTL,
+		now:         time.Now,
+	}
+
+	// without valid keys in the environment no token is signed or accepted
+	s.keys, _ = KeyringFromEnv()
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
-func TokenToUser(token string) (*user.User, error) {
-	t, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("SECRET")), nil
-	})
+// parse verifies an access token and returns its claims.
+func (s *Service) parse(token string) (jwt.MapClaims, error) {
+	t, err := jwt.Parse(token, s.keys.key)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Token is expired") {
@@ -53,57 +103,218 @@ func TokenToUser(token string) (*user.User, error) {
 		return nil, auth.ErrInvalidToken
 	}
 
-	if int64(exp) < time.Now().Unix() {
+	if int64(exp) < s.now().Unix() {
 		return nil, auth.ErrExpiredToken
 	}
 
-	parseID, err := uuid.Parse(claims["id"].(string))
+	return claims, nil
+}
+
+func claimsToUser(claims jwt.MapClaims) (*user.User, error) {
+	id, _ := claims["id"].(string)
+	parseID, err := uuid.Parse(id)
 
 	if err != nil {
 		return nil, auth.ErrInvalidToken
 	}
 
+	name, _ := claims["name"].(string)
+	email, _ := claims["email"].(string)
+
 	u := &user.User{
 		ID:    parseID,
-		Name:  claims["name"].(string),
-		Email: claims["email"].(string),
+		Name:  name,
+		Email: email,
 	}
 
 	return u, nil
 }
 
-func Token(u *user.User) (string, error) {
+// token signs an access token of the user with the current signing key.
+func (s *Service) token(u *user.User) (string, error) {
+	now := s.now()
+
 	claims := jwt.MapClaims{
+		"jti":   uuid.NewString(),
 		"id":    u.ID.String(),
 		"name":  u.Name,
 		"email": u.Email,
-		"exp":   time.Now().Add(time.Hour * 1).Unix(),
+		"iat":   now.Unix(),
+		"exp":   now.Add(AccessTokenTTL).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
-
-	if err != nil {
-		return "", err
-	}
+	return s.keys.sign(claims)
+}
 
-	return tokenString, nil
+// hashToken is what a refresh token is stored and looked up by. Refresh
+// tokens are random, so a plain hash cannot be reversed.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-func (s *Service) Authenticate(email, password string) (string, error) {
+func (s *Service) Authenticate(email, password string) (*auth.Tokens, error) {
 	u, err := s.userService.FirstWhereEmail(email)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if u == nil {
-		return "", auth.ErrInvalidEmailOrPassword
+		return nil, auth.ErrInvalidEmailOrPassword
 	}
 
 	if err := util.CompareBcryptPassword(u.Password, password); err != nil {
-		return "", auth.ErrInvalidEmailOrPassword
+		return nil, auth.ErrInvalidEmailOrPassword
+	}
+
+	return s.issue(u, uuid.New())
+}
+
+// issue signs an access token of the user and stores a new refresh token in
+// the family.
+func (s *Service) issue(u *user.User, familyID uuid.UUID) (*auth.Tokens, error) {
+	access, err := s.token(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, RefreshTokenSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	refresh := base64.RawURLEncoding.EncodeToString(b)
+	now := s.now().UTC()
+
+	err = s.repo.CreateRefreshToken(&auth.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    u.ID,
+		Hash:      hashToken(refresh),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Tokens{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *Service) Refresh(refreshToken string) (*auth.Tokens, error) {
+	t, err := s.repo.GetRefreshToken(hashToken(refreshToken))
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+
+	if !t.RevokedAt.IsZero() || !now.Before(t.ExpiresAt) {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+
+	if !t.UsedAt.IsZero() {
+		return nil, s.reused(t, now)
+	}
+
+	// a concurrent exchange of the same token is a reuse as well
+	switch err := s.repo.UseRefreshToken(t.ID, now); err {
+	case nil:
+	case auth.ErrRefreshTokenReused:
+		return nil, s.reused(t, now)
+	default:
+		return nil, err
+	}
+
+	u, err := s.userService.Get(t.UserID)
+
+	if err == user.ErrNotFound {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(u, t.FamilyID)
+}
+
+// reused revokes the family of a refresh token that came back after it was
+// exchanged. Either the user or whoever stole the token used it first, so
+// neither may keep the session.
+func (s *Service) reused(t *auth.RefreshToken, now time.Time) error {
+	s.logger.WithField("user_id", t.UserID).Warn("refresh token reused, revoking its family")
+
+	if err := s.repo.RevokeFamily(t.FamilyID, now); err != nil {
+		return err
+	}
+
+	return auth.ErrRefreshTokenReused
+}
+
+func (s *Service) Logout(token, refreshToken string) error {
+	claims, err := s.parse(token)
+
+	if err != nil {
+		return err
+	}
+
+	u, err := claimsToUser(claims)
+
+	if err != nil {
+		return err
+	}
+
+	// tokens issued before access tokens had an ID cannot be revoked, and
+	// expire within the hour
+	if id, _ := claims["jti"].(string); id != "" {
+		exp := claims["exp"].(float64)
+
+		if err := s.repo.RevokeAccessToken(id, time.Unix(int64(exp), 0).UTC()); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	t, err := s.repo.GetRefreshToken(hashToken(refreshToken))
+
+	if err != nil {
+		return err
+	}
+
+	if t.UserID != u.ID {
+		return auth.ErrInvalidRefreshToken
+	}
+
+	return s.repo.RevokeFamily(t.FamilyID, s.now().UTC())
+}
+
+func (s *Service) TokenToUser(token string) (*user.User, error) {
+	claims, err := s.parse(token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if id, _ := claims["jti"].(string); id != "" {
+		revoked, err := s.repo.IsRevoked(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if revoked {
+			return nil, auth.ErrRevokedToken
+		}
 	}
 
-	return Token(u)
+	return claimsToUser(claims)
 }
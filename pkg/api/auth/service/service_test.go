@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"juno/pkg/api/auth"
+	authRepo "juno/pkg/api/auth/repo/mem"
 	"juno/pkg/api/user"
 	"juno/pkg/util"
 
@@ -35,6 +36,7 @@ func generateTestJWT(userID uuid.UUID, email string, secret string, exp time.Tim
 func TestTokenToUser(t *testing.T) {
 	os.Setenv("SECRET", "mysecretkey")
 	secret := os.Getenv("SECRET")
+	service := New(logrus.New(), nil, authRepo.New())
 
 	userID := uuid.New()
 	email := "test@example.com"
@@ -43,7 +45,7 @@ func TestTokenToUser(t *testing.T) {
 		exp := time.Now().Add(time.Hour)
 		token := generateTestJWT(userID, email, secret, exp)
 
-		u, err := TokenToUser(token)
+		u, err := service.TokenToUser(token)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -65,7 +67,7 @@ func TestTokenToUser(t *testing.T) {
 		exp := time.Now().Add(-time.Hour)
 		token := generateTestJWT(userID, email, secret, exp)
 
-		_, err := TokenToUser(token)
+		_, err := service.TokenToUser(token)
 		if !errors.Is(err, auth.ErrExpiredToken) {
 			t.Errorf("Expected error %v, got %v", auth.ErrExpiredToken, err)
 		}
@@ -74,16 +76,18 @@ func TestTokenToUser(t *testing.T) {
 	t.Run("Invalid token", func(t *testing.T) {
 		invalidToken := "invalid.token.here"
 
-		_, err := TokenToUser(invalidToken)
+		_, err := service.TokenToUser(invalidToken)
 		if err == nil {
 			t.Errorf("Expected error, got nil")
 		}
 	})
 }
 
-// Test Token function
+// Test token function
 func TestToken(t *testing.T) {
 	os.Setenv("SECRET", "mysecretkey")
+	now := time.Now().Add(-30 * time.Minute)
+	service := New(logrus.New(), nil, authRepo.New(), WithClock(func() time.Time { return now }))
 
 	userID := uuid.New()
 	email := "test@example.com"
@@ -93,13 +97,13 @@ func TestToken(t *testing.T) {
 	}
 
 	t.Run("Generate valid token", func(t *testing.T) {
-		token, err := Token(u)
+		token, err := service.token(u)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
 
 		// Validate the token using TokenToUser
-		parsedUser, err := TokenToUser(token)
+		parsedUser, err := service.TokenToUser(token)
 		if err != nil {
 			t.Fatalf("Expected no error when parsing token, got %v", err)
 		}
@@ -111,6 +115,19 @@ func TestToken(t *testing.T) {
 			t.Errorf("Expected email %v, got %v", u.Email, parsedUser.Email)
 		}
 	})
+
+	t.Run("Expires on the service's clock", func(t *testing.T) {
+		token, _ := service.token(u)
+
+		claims, err := service.parse(token)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if exp := int64(claims["exp"].(float64)); exp != now.Add(AccessTokenTTL).Unix() {
+			t.Errorf("Expected the token to expire at %d, got %d", now.Add(AccessTokenTTL).Unix(), exp)
+		}
+	})
 }
 
 // Test Authenticate method
@@ -128,16 +145,16 @@ func TestAuthenticate(t *testing.T) {
 	usrService := usrService.New(logrus.New(), usrRepo)
 
 	logger := logrus.New()
-	service := New(logger, usrService)
+	service := New(logger, usrService, authRepo.New())
 
 	t.Run("Valid credentials", func(t *testing.T) {
-		token, err := service.Authenticate("test@example.com", "validpassword")
+		tokens, err := service.Authenticate("test@example.com", "validpassword")
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
 
 		// Verify the token is valid
-		_, err = TokenToUser(token)
+		_, err = service.TokenToUser(tokens.AccessToken)
 		if err != nil {
 			t.Errorf("Expected valid token, got error: %v", err)
 		}
@@ -157,3 +174,127 @@ func TestAuthenticate(t *testing.T) {
 		}
 	})
 }
+
+func newService(t *testing.T) (*Service, *user.User, *time.Time) {
+	t.Helper()
+	t.Setenv("SECRET", "mysecretkey")
+
+	pass, _ := util.BcryptPassword("validpassword")
+	u := &user.User{ID: uuid.New(), Email: "test@example.com", Password: pass}
+
+	usrRepo := usrRepo.New()
+	usrRepo.Create(u)
+
+	now := time.Now()
+	s := New(logrus.New(), usrService.New(logrus.New(), usrRepo), authRepo.New(), WithClock(func() time.Time { return now }))
+
+	return s, u, &now
+}
+
+func TestRefresh(t *testing.T) {
+	t.Run("rotation", func(t *testing.T) {
+		s, u, _ := newService(t)
+
+		tokens, err := s.Authenticate(u.Email, "validpassword")
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		refreshed, err := s.Refresh(tokens.RefreshToken)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if refreshed.RefreshToken == tokens.RefreshToken {
+			t.Errorf("Expected a new refresh token")
+		}
+
+		if parsed, err := s.TokenToUser(refreshed.AccessToken); err != nil || parsed.ID != u.ID {
+			t.Errorf("Expected the user's access token, got %+v, %v", parsed, err)
+		}
+
+		if _, err := s.Refresh(refreshed.RefreshToken); err != nil {
+			t.Errorf("Expected the new refresh token usable, got %v", err)
+		}
+	})
+
+	t.Run("reuse revokes the family", func(t *testing.T) {
+		s, u, _ := newService(t)
+
+		tokens, _ := s.Authenticate(u.Email, "validpassword")
+		refreshed, _ := s.Refresh(tokens.RefreshToken)
+
+		if _, err := s.Refresh(tokens.RefreshToken); err != auth.ErrRefreshTokenReused {
+			t.Errorf("Expected %v, got %v", auth.ErrRefreshTokenReused, err)
+		}
+
+		if _, err := s.Refresh(refreshed.RefreshToken); err != auth.ErrInvalidRefreshToken {
+			t.Errorf("Expected the family revoked, got %v", err)
+		}
+
+		// other sign-ins are not affected
+		other, _ := s.Authenticate(u.Email, "validpassword")
+
+		if _, err := s.Refresh(other.RefreshToken); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s, u, now := newService(t)
+
+		tokens, _ := s.Authenticate(u.Email, "validpassword")
+		*now = now.Add(DefaultRefreshTTL)
+
+		if _, err := s.Refresh(tokens.RefreshToken); err != auth.ErrInvalidRefreshToken {
+			t.Errorf("Expected %v, got %v", auth.ErrInvalidRefreshToken, err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		s, _, _ := newService(t)
+
+		if _, err := s.Refresh("nope"); err != auth.ErrInvalidRefreshToken {
+			t.Errorf("Expected %v, got %v", auth.ErrInvalidRefreshToken, err)
+		}
+	})
+}
+
+func TestLogout(t *testing.T) {
+	s, u, _ := newService(t)
+
+	tokens, err := s.Authenticate(u.Email, "validpassword")
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	other, _ := s.Authenticate(u.Email, "validpassword")
+
+	if err := s.Logout(tokens.AccessToken, tokens.RefreshToken); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if _, err := s.TokenToUser(tokens.AccessToken); err != auth.ErrRevokedToken {
+		t.Errorf("Expected %v, got %v", auth.ErrRevokedToken, err)
+	}
+
+	if _, err := s.Refresh(tokens.RefreshToken); err != auth.ErrInvalidRefreshToken {
+		t.Errorf("Expected %v, got %v", auth.ErrInvalidRefreshToken, err)
+	}
+
+	t.Run("of another user's refresh token", func(t *testing.T) {
+		stranger := &user.User{ID: uuid.New()}
+		token, _ := s.token(stranger)
+
+		if err := s.Logout(token, other.RefreshToken); err != auth.ErrInvalidRefreshToken {
+			t.Errorf("Expected %v, got %v", auth.ErrInvalidRefreshToken, err)
+		}
+
+		if _, err := s.Refresh(other.RefreshToken); err != nil {
+			t.Errorf("Expected the refresh token kept, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package challenge
+
+import (
+	"context"
+	"errors"
+	"juno/pkg/api/node"
+	"juno/pkg/proof"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoCrawl        = errors.New("nothing crawled into the node")
+	ErrInvalidURL     = errors.New("invalid url")
+	ErrInvalidVersion = errors.New("invalid version")
+	ErrNoSpotCheck    = errors.New("no spot check left")
+	ErrHashMismatch   = errors.New("stored version does not match its hash")
+	ErrWrongAnswer    = errors.New("wrong answer to the challenge")
+)
+
+const (
+	// DefaultInterval is how often every node is challenged, and
+	// DefaultTimeout how long it has to answer.
+	DefaultInterval = 10 * time.Minute
+	DefaultTimeout  = 10 * time.Second
+	// DefaultConcurrency is how many nodes are challenged at once.
+	DefaultConcurrency = 16
+	// SpotChecks is how many challenges are prepared from a version each time
+	// it is downloaded.
+	SpotChecks = 8
+	// DefaultResultsLimit is how many of a node's latest results are listed.
+	DefaultResultsLimit = 50
+)
+
+type Kind string
+
+const (
+	// KindHash downloads the stored version from the node and checks its
+	// hash, and KindMAC asks the node to answer a challenge on it.
+	KindHash Kind = "hash"
+	KindMAC  Kind = "hmac"
+)
+
+// Crawl is a URL a balancer crawled into the shard of a node, which the node
+// is challenged to prove it stores.
+type Crawl struct {
+	ID    uuid.UUID
+	URL   string
+	Shard int
+	// Node is the address of the node that stored it
+	Node string
+	// Version is the hash of the version the node reported it stored when
+	// it crawled the page, which it is challenged on
+	Version string
+	// SpotChecks are challenges on the version prepared with their answers,
+	// each used once
+	SpotChecks []SpotCheck
+	CreatedAt  time.Time
+}
+
+// SpotCheck is a challenge and its answer.
+type SpotCheck struct {
+	Challenge proof.Challenge `json:"challenge"`
+	MAC       []byte          `json:"mac"`
+}
+
+// Result is the outcome of a challenge of a node.
+type Result struct {
+	ID        uuid.UUID
+	NodeID    uuid.UUID
+	CrawlID   uuid.UUID
+	Kind      Kind
+	Passed    bool
+	Error     string
+	CreatedAt time.Time
+}
+
+type Repository interface {
+	CreateCrawl(c *Crawl) error
+	// RandomCrawl returns a random crawl into the node at the address. It
+	// returns ErrNoCrawl when there is none.
+	RandomCrawl(node string) (*Crawl, error)
+	UpdateCrawl(c *Crawl) error
+	// TakeSpotCheck removes the first spot check of the crawl and returns
+	// it in one step, so each is used once even across API instances. It
+	// returns ErrNoSpotCheck when none is left.
+	TakeSpotCheck(id uuid.UUID) (*SpotCheck, error)
+	CreateResult(r *Result) error
+	// ListResults lists the node's latest results, newest first.
+	ListResults(nodeID uuid.UUID, limit int) ([]*Result, error)
+}
+
+type Service interface {
+	// RecordCrawl records that a balancer crawled the URL into the node at
+	// the address, which stored the version with the hash.
+	RecordCrawl(url, node, version string) (*Crawl, error)
+	// Challenge challenges the node on a random crawl into its shards and
+	// scores its reputation with the result. It returns ErrNoCrawl when
+	// nothing was crawled into them.
+	Challenge(ctx context.Context, n *node.Node) (*Result, error)
+	ListResults(nodeID uuid.UUID) ([]*Result, error)
+	// Run challenges every node each interval until ctx is done.
+	Run(ctx context.Context)
+}
+
+type Handler interface {
+	RecordCrawl(c *gin.Context)
+	Results(c *gin.Context)
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"juno/pkg/api/challenge"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type RecordCrawlRequest struct {
+	URL  string `json:"url" binding:"required"`
+	Node string `json:"node" binding:"required"`
+	// Version is the hash of the version the node stored
+	Version string `json:"version" binding:"required"`
+}
+
+type Crawl struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Shard     int    `json:"shard"`
+	Node      string `json:"node"`
+	CreatedAt string `json:"created_at"`
+}
+
+type RecordCrawlResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Crawl   *Crawl `json:"crawl,omitempty"`
+}
+
+func NewSuccessRecordCrawlResponse(c *challenge.Crawl) *RecordCrawlResponse {
+	return &RecordCrawlResponse{
+		Status: SUCCESS,
+		Crawl: &Crawl{
+			ID:        c.ID.String(),
+			URL:       c.URL,
+			Shard:     c.Shard,
+			Node:      c.Node,
+			CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func NewErrorRecordCrawlResponse(message string) *RecordCrawlResponse {
+	return &RecordCrawlResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type Result struct {
+	ID        string `json:"id"`
+	NodeID    string `json:"node_id"`
+	CrawlID   string `json:"crawl_id"`
+	Kind      string `json:"kind"`
+	Passed    bool   `json:"passed"`
+	Error     string `json:"error,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListResultsResponse struct {
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+	Results []*Result `json:"results,omitempty"`
+}
+
+func NewSuccessListResultsResponse(results []*challenge.Result) *ListResultsResponse {
+	res := make([]*Result, len(results))
+
+	for i, r := range results {
+		res[i] = &Result{
+			ID:        r.ID.String(),
+			NodeID:    r.NodeID.String(),
+			CrawlID:   r.CrawlID.String(),
+			Kind:      string(r.Kind),
+			Passed:    r.Passed,
+			Error:     r.Error,
+			CreatedAt: r.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return &ListResultsResponse{
+		Status:  SUCCESS,
+		Results: res,
+	}
+}
+
+func NewErrorListResultsResponse(message string) *ListResultsResponse {
+	return &ListResultsResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"juno/pkg/api/challenge"
+	"juno/pkg/api/challenge/dto"
+	"juno/pkg/api/node"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger           logrus.FieldLogger
+	nodePolicy       node.Policy
+	nodeService      node.Service
+	challengeService challenge.Service
+}
+
+func New(logger logrus.FieldLogger, nodePolicy node.Policy, nodeService node.Service, challengeService challenge.Service) *Handler {
+	return &Handler{
+		logger:           logger,
+		nodePolicy:       nodePolicy,
+		nodeService:      nodeService,
+		challengeService: challengeService,
+	}
+}
+
+// RecordCrawl records a crawl a balancer reported, which the node that
+// stored it is later challenged on.
+func (h *Handler) RecordCrawl(c *gin.Context) {
+	var req dto.RecordCrawlRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorRecordCrawlResponse(err.Error()))
+		return
+	}
+
+	cr, err := h.challengeService.RecordCrawl(req.URL, req.Node, req.Version)
+
+	switch err {
+	case nil:
+		c.JSON(201, dto.NewSuccessRecordCrawlResponse(cr))
+	case challenge.ErrInvalidURL, challenge.ErrInvalidVersion:
+		c.JSON(400, dto.NewErrorRecordCrawlResponse(err.Error()))
+	default:
+		h.logger.WithError(err).Error("failed to record crawl")
+		c.JSON(500, dto.NewErrorRecordCrawlResponse("failed to record crawl"))
+	}
+}
+
+// Results lists the latest challenges of a node to its owner.
+func (h *Handler) Results(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, dto.NewErrorListResultsResponse(err.Error()))
+		return
+	}
+
+	n, err := h.nodeService.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorListResultsResponse(node.ErrNotFound.Error()))
+		return
+	}
+
+	h.nodePolicy.CanRead(c.Request.Context(), n).
+		Allow(func() {
+			results, err := h.challengeService.ListResults(n.ID)
+
+			if err != nil {
+				h.logger.WithError(err).Error("failed to list challenge results")
+				c.JSON(500, dto.NewErrorListResultsResponse("failed to list challenge results"))
+				return
+			}
+
+			c.JSON(200, dto.NewSuccessListResultsResponse(results))
+		}).
+		Deny(func(reason string) {
+			c.JSON(404, dto.NewErrorListResultsResponse(node.ErrNotFound.Error()))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorListResultsResponse(node.ErrInternal.Error()))
+		})
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/challenge"
+	"juno/pkg/api/challenge/dto"
+	"juno/pkg/api/node"
+	nodePolicy "juno/pkg/api/node/policy"
+	nodeRepo "juno/pkg/api/node/repo/mem"
+	nodeService "juno/pkg/api/node/service"
+	"juno/pkg/api/user"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type mockChallengeService struct {
+	url, node string
+	results   []*challenge.Result
+}
+
+func (m *mockChallengeService) RecordCrawl(url, node, version string) (*challenge.Crawl, error) {
+	if !strings.HasPrefix(url, "http") {
+		return nil, challenge.ErrInvalidURL
+	}
+
+	m.url, m.node = url, node
+
+	return &challenge.Crawl{ID: uuid.New(), URL: url, Node: node, Version: version}, nil
+}
+
+func (m *mockChallengeService) Challenge(ctx context.Context, n *node.Node) (*challenge.Result, error) {
+	return nil, challenge.ErrNoCrawl
+}
+
+func (m *mockChallengeService) ListResults(nodeID uuid.UUID) ([]*challenge.Result, error) {
+	return m.results, nil
+}
+
+func (m *mockChallengeService) Run(ctx context.Context) {}
+
+func TestRecordCrawl(t *testing.T) {
+	record := func(service *mockChallengeService, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+
+		tc.Request = httptest.NewRequest("POST", "/crawls", strings.NewReader(body))
+
+		New(logrus.New(), nodePolicy.New(), nil, service).RecordCrawl(tc)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		service := &mockChallengeService{}
+
+		w := record(service, `{"url":"http://example.com","node":"node1:8080","version":"abc"}`)
+
+		if w.Code != 201 {
+			t.Fatalf("Expected 201, got %d", w.Code)
+		}
+
+		if service.url != "http://example.com" || service.node != "node1:8080" {
+			t.Errorf("Expected the crawl recorded, got %s on %s", service.url, service.node)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{`{"url":"http://example.com","version":"abc"}`, `{"url":"http://example.com","node":"node1:8080"}`, `{"url":"example.com","node":"node1:8080","version":"abc"}`} {
+			if w := record(&mockChallengeService{}, body); w.Code != 400 {
+				t.Errorf("%s: expected 400, got %d", body, w.Code)
+			}
+		}
+	})
+}
+
+func TestResults(t *testing.T) {
+	nodes := nodeService.New(nodeRepo.New())
+	ownerID := uuid.New()
+
+	n, err := nodes.Create(ownerID, "node1.example.com:8080", [][2]int{{0, 10}})
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	service := &mockChallengeService{
+		results: []*challenge.Result{{ID: uuid.New(), NodeID: n.ID, Kind: challenge.KindMAC, Passed: true}},
+	}
+
+	results := func(userID uuid.UUID, id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+
+		tc.Request = httptest.NewRequestWithContext(
+			auth.WithUser(context.Background(), &user.User{ID: userID}),
+			"GET",
+			"/nodes/"+id+"/challenges",
+			nil,
+		)
+		tc.Params = gin.Params{{Key: "id", Value: id}}
+
+		New(logrus.New(), nodePolicy.New(), nodes, service).Results(tc)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := results(ownerID, n.ID.String())
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		var res dto.ListResultsResponse
+
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(res.Results) != 1 || !res.Results[0].Passed || res.Results[0].Kind != "hmac" {
+			t.Errorf("Expected the passed challenge, got %+v", res.Results)
+		}
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		if w := results(uuid.New(), n.ID.String()); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("unknown node", func(t *testing.T) {
+		if w := results(ownerID, uuid.NewString()); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		if w := results(ownerID, "nope"); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package mysql
+
+import "database/sql"
+
+var migrations = map[string]string{
+	"create_crawls_table": `
+		CREATE TABLE IF NOT EXISTS crawls (
+			id VARCHAR(36) PRIMARY KEY,
+			url TEXT NOT NULL,
+			shard INT NOT NULL,
+			node VARCHAR(255) NOT NULL,
+			version VARCHAR(32) NOT NULL DEFAULT '',
+			spot_checks MEDIUMTEXT NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX (node)
+		);`,
+
+	"create_challenge_results_table": `
+		CREATE TABLE IF NOT EXISTS challenge_results (
+			id VARCHAR(36) PRIMARY KEY,
+			node_id VARCHAR(36) NOT NULL,
+			crawl_id VARCHAR(36) NOT NULL,
+			kind VARCHAR(16) NOT NULL,
+			passed BOOLEAN NOT NULL,
+			error TEXT NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX (node_id, created_at)
+		);`,
+}
+
+func ExecuteMigrations(db *sql.DB) error {
+
+	// create migrations table if it doesn't exist
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+			name VARCHAR(255) NOT NULL PRIMARY KEY
+		);`); err != nil {
+		return err
+	}
+
+	for name, migration := range migrations {
+
+		// check if migration has already been executed
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = ?", name).Scan(&count); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(migration); err != nil {
+			return err
+		}
+
+		db.Exec("INSERT INTO migrations (name) VALUES (?)", name)
+	}
+
+	return nil
+}
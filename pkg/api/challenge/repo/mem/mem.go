@@ -0,0 +1,129 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/challenge"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	mu      sync.Mutex
+	crawls  map[uuid.UUID]challenge.Crawl
+	results map[uuid.UUID]challenge.Result
+}
+
+func New() *Repository {
+	return &Repository{
+		crawls:  make(map[uuid.UUID]challenge.Crawl),
+		results: make(map[uuid.UUID]challenge.Result),
+	}
+}
+
+func (r *Repository) CreateCrawl(c *challenge.Crawl) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.crawls[c.ID]; ok {
+		return errors.New("primary key violation")
+	}
+
+	r.crawls[c.ID] = clone(c)
+
+	return nil
+}
+
+func (r *Repository) RandomCrawl(node string) (*challenge.Crawl, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var crawls []challenge.Crawl
+
+	for _, c := range r.crawls {
+		if c.Node == node {
+			crawls = append(crawls, c)
+		}
+	}
+
+	if len(crawls) == 0 {
+		return nil, challenge.ErrNoCrawl
+	}
+
+	c := clone(&crawls[rand.Intn(len(crawls))])
+
+	return &c, nil
+}
+
+func (r *Repository) UpdateCrawl(c *challenge.Crawl) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.crawls[c.ID]; !ok {
+		return errors.New("not found")
+	}
+
+	r.crawls[c.ID] = clone(c)
+
+	return nil
+}
+
+func (r *Repository) TakeSpotCheck(id uuid.UUID) (*challenge.SpotCheck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.crawls[id]
+
+	if !ok || len(c.SpotChecks) == 0 {
+		return nil, challenge.ErrNoSpotCheck
+	}
+
+	check := c.SpotChecks[0]
+	c.SpotChecks = slices.Clone(c.SpotChecks[1:])
+	r.crawls[id] = c
+
+	return &check, nil
+}
+
+func (r *Repository) CreateResult(res *challenge.Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.results[res.ID]; ok {
+		return errors.New("primary key violation")
+	}
+
+	r.results[res.ID] = *res
+
+	return nil
+}
+
+func (r *Repository) ListResults(nodeID uuid.UUID, limit int) ([]*challenge.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var results []*challenge.Result
+
+	for _, res := range r.results {
+		if res.NodeID == nodeID {
+			results = append(results, &res)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+
+	return results[:min(limit, len(results))], nil
+}
+
+// clone copies the crawl with its spot checks, which are used up in place.
+func clone(c *challenge.Crawl) challenge.Crawl {
+	cloned := *c
+	cloned.SpotChecks = slices.Clone(c.SpotChecks)
+
+	return cloned
+}
@@ -0,0 +1,107 @@
+package mem
+
+import (
+	"juno/pkg/api/challenge"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRandomCrawl(t *testing.T) {
+	repo := New()
+
+	if _, err := repo.RandomCrawl("node1:8080"); err != challenge.ErrNoCrawl {
+		t.Errorf("Expected %v, got %v", challenge.ErrNoCrawl, err)
+	}
+
+	c := &challenge.Crawl{ID: uuid.New(), URL: "http://example.com", Node: "node1:8080"}
+	repo.CreateCrawl(c)
+	repo.CreateCrawl(&challenge.Crawl{ID: uuid.New(), URL: "http://example.org", Node: "node2:8080"})
+
+	found, err := repo.RandomCrawl("node1:8080")
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if found.ID != c.ID {
+		t.Errorf("Expected the crawl into the node, got %v", found.URL)
+	}
+}
+
+func TestUpdateCrawl(t *testing.T) {
+	repo := New()
+
+	c := &challenge.Crawl{ID: uuid.New(), Node: "node1:8080", SpotChecks: make([]challenge.SpotCheck, 2)}
+	repo.CreateCrawl(c)
+
+	// crawls are copied, so spot checks used up are only stored on update
+	found, _ := repo.RandomCrawl("node1:8080")
+	found.SpotChecks = found.SpotChecks[1:]
+
+	if stored, _ := repo.RandomCrawl("node1:8080"); len(stored.SpotChecks) != 2 {
+		t.Errorf("Expected 2 spot checks, got %d", len(stored.SpotChecks))
+	}
+
+	if err := repo.UpdateCrawl(found); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if stored, _ := repo.RandomCrawl("node1:8080"); len(stored.SpotChecks) != 1 {
+		t.Errorf("Expected 1 spot check, got %d", len(stored.SpotChecks))
+	}
+}
+
+func TestTakeSpotCheck(t *testing.T) {
+	repo := New()
+
+	c := &challenge.Crawl{ID: uuid.New(), Node: "node1:8080", SpotChecks: []challenge.SpotCheck{{MAC: []byte{1}}, {MAC: []byte{2}}}}
+	repo.CreateCrawl(c)
+
+	for _, mac := range []byte{1, 2} {
+		check, err := repo.TakeSpotCheck(c.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check.MAC[0] != mac {
+			t.Errorf("Expected spot check %d, got %d", mac, check.MAC[0])
+		}
+	}
+
+	if _, err := repo.TakeSpotCheck(c.ID); err != challenge.ErrNoSpotCheck {
+		t.Errorf("Expected %v, got %v", challenge.ErrNoSpotCheck, err)
+	}
+
+	if _, err := repo.TakeSpotCheck(uuid.New()); err != challenge.ErrNoSpotCheck {
+		t.Errorf("Expected %v, got %v", challenge.ErrNoSpotCheck, err)
+	}
+}
+
+func TestListResults(t *testing.T) {
+	repo := New()
+	nodeID := uuid.New()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		repo.CreateResult(&challenge.Result{ID: uuid.New(), NodeID: nodeID, CreatedAt: now.Add(time.Duration(i) * time.Minute)})
+	}
+
+	repo.CreateResult(&challenge.Result{ID: uuid.New(), NodeID: uuid.New(), CreatedAt: now})
+
+	results, err := repo.ListResults(nodeID, 2)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if !results[0].CreatedAt.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("Expected the newest result first, got %v", results[0].CreatedAt)
+	}
+}
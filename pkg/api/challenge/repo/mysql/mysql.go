@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"juno/pkg/api/challenge"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) CreateCrawl(c *challenge.Crawl) error {
+	checks, err := json.Marshal(c.SpotChecks)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
+		"INSERT INTO crawls (id, url, shard, node, version, spot_checks, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		c.ID, c.URL, c.Shard, c.Node, c.Version, string(checks), c.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *Repository) RandomCrawl(node string) (*challenge.Crawl, error) {
+	var (
+		c      challenge.Crawl
+		checks string
+	)
+
+	err := r.db.QueryRow(
+		"SELECT id, url, shard, node, version, spot_checks, created_at FROM crawls WHERE node = ? ORDER BY RAND() LIMIT 1",
+		node,
+	).Scan(&c.ID, &c.URL, &c.Shard, &c.Node, &c.Version, &checks, &c.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, challenge.ErrNoCrawl
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(checks), &c.SpotChecks); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *Repository) UpdateCrawl(c *challenge.Crawl) error {
+	checks, err := json.Marshal(c.SpotChecks)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("UPDATE crawls SET version = ?, spot_checks = ? WHERE id = ?", c.Version, string(checks), c.ID)
+
+	return err
+}
+
+// TakeSpotCheck locks the row of the crawl while it removes the spot check.
+func (r *Repository) TakeSpotCheck(id uuid.UUID) (*challenge.SpotCheck, error) {
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var (
+		raw    string
+		checks []challenge.SpotCheck
+	)
+
+	err = tx.QueryRow("SELECT spot_checks FROM crawls WHERE id = ? FOR UPDATE", id).Scan(&raw)
+
+	if err == sql.ErrNoRows {
+		return nil, challenge.ErrNoSpotCheck
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(raw), &checks); err != nil {
+		return nil, err
+	}
+
+	if len(checks) == 0 {
+		return nil, challenge.ErrNoSpotCheck
+	}
+
+	rest, err := json.Marshal(checks[1:])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE crawls SET spot_checks = ? WHERE id = ?", string(rest), id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &checks[0], nil
+}
+
+func (r *Repository) CreateResult(res *challenge.Result) error {
+	_, err := r.db.Exec(
+		"INSERT INTO challenge_results (id, node_id, crawl_id, kind, passed, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		res.ID, res.NodeID, res.CrawlID, res.Kind, res.Passed, res.Error, res.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *Repository) ListResults(nodeID uuid.UUID, limit int) ([]*challenge.Result, error) {
+	rows, err := r.db.Query(
+		"SELECT id, node_id, crawl_id, kind, passed, error, created_at FROM challenge_results WHERE node_id = ? ORDER BY created_at DESC LIMIT ?",
+		nodeID, limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var results []*challenge.Result
+
+	for rows.Next() {
+		var res challenge.Result
+
+		if err := rows.Scan(&res.ID, &res.NodeID, &res.CrawlID, &res.Kind, &res.Passed, &res.Error, &res.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		results = append(results, &res)
+	}
+
+	return results, rows.Err()
+}
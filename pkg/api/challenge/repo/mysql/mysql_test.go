@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/challenge"
+	"juno/pkg/api/challenge/migration/mysql"
+	"juno/pkg/proof"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/challenge_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestCrawls(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	node := uuid.NewString() + ":8080"
+
+	defer db.Exec("DELETE FROM crawls WHERE node = ?", node)
+
+	if _, err := repo.RandomCrawl(node); err != challenge.ErrNoCrawl {
+		t.Fatalf("Expected %v, got %v", challenge.ErrNoCrawl, err)
+	}
+
+	c := &challenge.Crawl{
+		ID:        uuid.New(),
+		URL:       "http://example.com",
+		Shard:     3,
+		Node:      node,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := repo.CreateCrawl(c); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	c.Version = "00112233445566778899aabbccddeeff"
+	c.SpotChecks = []challenge.SpotCheck{
+		{Challenge: proof.Challenge{Nonce: []byte{1}, Ranges: []proof.Range{{Offset: 2, Length: 3}}}, MAC: []byte{4}},
+	}
+
+	if err := repo.UpdateCrawl(c); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	found, err := repo.RandomCrawl(node)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if found.ID != c.ID || found.Version != c.Version || found.Shard != 3 {
+		t.Errorf("Expected %+v, got %+v", c, found)
+	}
+
+	if len(found.SpotChecks) != 1 || found.SpotChecks[0].Challenge.Ranges[0].Length != 3 {
+		t.Errorf("Expected the spot checks stored, got %+v", found.SpotChecks)
+	}
+
+	check, err := repo.TakeSpotCheck(c.ID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if check.MAC[0] != 4 {
+		t.Errorf("Expected the spot check stored, got %+v", check)
+	}
+
+	if _, err := repo.TakeSpotCheck(c.ID); err != challenge.ErrNoSpotCheck {
+		t.Errorf("Expected %v, got %v", challenge.ErrNoSpotCheck, err)
+	}
+}
+
+func TestListResults(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	nodeID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	defer db.Exec("DELETE FROM challenge_results WHERE node_id = ?", nodeID)
+
+	for i, passed := range []bool{true, false} {
+		r := &challenge.Result{
+			ID:        uuid.New(),
+			NodeID:    nodeID,
+			CrawlID:   uuid.New(),
+			Kind:      challenge.KindMAC,
+			Passed:    passed,
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+
+		if err := repo.CreateResult(r); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	}
+
+	results, err := repo.ListResults(nodeID, 10)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(results) != 2 || results[0].Passed {
+		t.Errorf("Expected the failed result first, got %v", results)
+	}
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"juno/pkg/api/challenge"
+	"juno/pkg/api/node"
+	challengeDto "juno/pkg/node/challenge/dto"
+	"juno/pkg/node/client"
+	"juno/pkg/node/page"
+	"juno/pkg/proof"
+	"juno/pkg/shard"
+	"juno/pkg/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo        challenge.Repository
+	nodeService node.Service
+	interval    time.Duration
+	timeout     time.Duration
+	concurrency int
+	now         func() time.Time
+}
+
+// WithInterval sets how often Run challenges every node.
+func WithInterval(interval time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.interval = interval
+	}
+}
+
+// WithTimeout sets how long a node has to answer a challenge before it fails.
+func WithTimeout(timeout time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.timeout = timeout
+	}
+}
+
+// WithConcurrency sets how many nodes Run challenges at once.
+func WithConcurrency(concurrency int) func(s *Service) {
+	return func(s *Service) {
+		s.concurrency = concurrency
+	}
+}
+
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func New(repo challenge.Repository, nodeService node.Service, opts ...func(s *Service)) *Service {
+	s := &Service{
+		repo:        repo,
+		nodeService: nodeService,
+		interval:    challenge.DefaultInterval,
+		timeout:     challenge.DefaultTimeout,
+		concurrency: challenge.DefaultConcurrency,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Service) RecordCrawl(rawURL, nodeAddr, version string) (*challenge.Crawl, error) {
+	if !url.IsHTTPOrHTTPS(rawURL) {
+		return nil, challenge.ErrInvalidURL
+	}
+
+	if _, err := page.ParseVersionHash(version); err != nil {
+		return nil, challenge.ErrInvalidVersion
+	}
+
+	c := &challenge.Crawl{
+		ID:        uuid.New(),
+		URL:       rawURL,
+		Shard:     shard.GetURLShard(rawURL),
+		Node:      nodeAddr,
+		Version:   version,
+		CreatedAt: s.now().UTC(),
+	}
+
+	if err := s.repo.CreateCrawl(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Challenge first downloads the version of the crawl the node stored, and
+// prepares spot checks on it. Each later challenge uses up one spot check,
+// which the node can only answer while it still stores the version. The
+// version is the one the node reported when it crawled the page, so a node
+// that fetches the page again when challenged only passes while the page did
+// not change.
+func (s *Service) Challenge(ctx context.Context, n *node.Node) (*challenge.Result, error) {
+	c, err := s.repo.RandomCrawl(n.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// nodes are not challenged on shards they were unassigned from, nor on
+	// crawls reported without the version they stored
+	if !n.HasShard(c.Shard) || c.Version == "" {
+		return nil, challenge.ErrNoCrawl
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		kind    challenge.Kind
+		failure error
+	)
+
+	// spot checks are used up whether they are answered or not
+	check, err := s.repo.TakeSpotCheck(c.ID)
+
+	switch err {
+	case nil:
+		kind = challenge.KindMAC
+		failure = s.ask(ctx, n, c, check)
+	case challenge.ErrNoSpotCheck:
+		kind = challenge.KindHash
+
+		var blob []byte
+		blob, failure = s.download(ctx, n, c)
+
+		if failure == nil {
+			if err := s.prepare(c, blob); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, err
+	}
+
+	return s.score(n, c, kind, failure)
+}
+
+// download fetches the version of the crawl.
+func (s *Service) download(ctx context.Context, n *node.Node, c *challenge.Crawl) ([]byte, error) {
+	blob, err := client.GetBlob(ctx, n.Address, c.URL, c.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if page.NewVersionHash(blob).String() != c.Version {
+		return nil, challenge.ErrHashMismatch
+	}
+
+	return blob, nil
+}
+
+// prepare stores spot checks on the blob with their answers.
+func (s *Service) prepare(c *challenge.Crawl, blob []byte) error {
+	checks := make([]challenge.SpotCheck, challenge.SpotChecks)
+
+	for i := range checks {
+		ch, err := proof.NewChallenge(len(blob))
+
+		if err != nil {
+			return err
+		}
+
+		checks[i] = challenge.SpotCheck{Challenge: *ch, MAC: ch.MAC(blob)}
+	}
+
+	c.SpotChecks = checks
+
+	return s.repo.UpdateCrawl(c)
+}
+
+func (s *Service) ask(ctx context.Context, n *node.Node, c *challenge.Crawl, check *challenge.SpotCheck) error {
+	res, err := client.SendChallengeRequest(ctx, n.Address, &challengeDto.ChallengeRequest{
+		URL:       c.URL,
+		Version:   c.Version,
+		Challenge: &check.Challenge,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if res.Hash != c.Version {
+		return challenge.ErrHashMismatch
+	}
+
+	if !hmac.Equal(res.MAC, check.MAC) {
+		return challenge.ErrWrongAnswer
+	}
+
+	return nil
+}
+
+// score records the outcome of the challenge and moves the node's reputation
+// towards it.
+func (s *Service) score(n *node.Node, c *challenge.Crawl, kind challenge.Kind, failure error) (*challenge.Result, error) {
+	r := &challenge.Result{
+		ID:        uuid.New(),
+		NodeID:    n.ID,
+		CrawlID:   c.ID,
+		Kind:      kind,
+		Passed:    failure == nil,
+		CreatedAt: s.now().UTC(),
+	}
+
+	if failure != nil {
+		r.Error = failure.Error()
+	}
+
+	if err := s.repo.CreateResult(r); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.nodeService.Score(n.ID, r.Passed); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *Service) ListResults(nodeID uuid.UUID) ([]*challenge.Result, error) {
+	return s.repo.ListResults(nodeID, challenge.DefaultResultsLimit)
+}
+
+// Run challenges every node each interval, as many of them at once as the
+// concurrency, until ctx is done. It does nothing when the interval is not positive.
+func (s *Service) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.challengeAll(ctx)
+		}
+	}
+}
+
+func (s *Service) challengeAll(ctx context.Context) {
+	// excluded nodes are challenged too, so they can earn their
+	// reputation back
+	nodes, err := s.nodeService.All()
+
+	if err != nil {
+		fmt.Printf("failed to list nodes to challenge: %v\n", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	// bounds the challenges running at once, which span every node
+	slots := make(chan struct{}, max(s.concurrency, 1))
+
+	for _, n := range nodes {
+		slots <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer func() { <-slots }()
+			defer wg.Done()
+
+			r, err := s.Challenge(ctx, n)
+
+			switch {
+			case err == challenge.ErrNoCrawl:
+			case err != nil:
+				fmt.Printf("failed to challenge node %s: %v\n", n.ID, err)
+			case !r.Passed:
+				fmt.Printf("node %s failed a challenge: %s\n", n.ID, r.Error)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"juno/pkg/api/challenge"
+	"juno/pkg/api/challenge/repo/mem"
+	"juno/pkg/api/node"
+	nodeRepo "juno/pkg/api/node/repo/mem"
+	nodeService "juno/pkg/api/node/service"
+	"juno/pkg/node/page"
+	"juno/pkg/shard"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/h2non/gock"
+)
+
+const pageURL = "http://example.com/home"
+
+var (
+	blob    = []byte("<html><body>stored page</body></html>")
+	version = page.NewVersionHash(blob).String()
+)
+
+type fixture struct {
+	repo        *mem.Repository
+	nodeService *nodeService.Service
+	service     *Service
+	node        *node.Node
+}
+
+func setup(t *testing.T) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		repo:        mem.New(),
+		nodeService: nodeService.New(nodeRepo.New()),
+	}
+
+	f.service = New(f.repo, f.nodeService)
+
+	n, err := f.nodeService.Create(uuid.New(), "node1.example.com:8080", [][2]int{{shard.GetURLShard(pageURL), 1}})
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	f.node = n
+
+	return f
+}
+
+func (f *fixture) crawl(t *testing.T) *challenge.Crawl {
+	t.Helper()
+
+	c, err := f.repo.RandomCrawl(f.node.Address)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return c
+}
+
+func (f *fixture) reputation(t *testing.T) float64 {
+	t.Helper()
+
+	n, err := f.nodeService.Get(f.node.ID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return n.Reputation
+}
+
+func TestRecordCrawl(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := setup(t)
+
+		c, err := f.service.RecordCrawl(pageURL, "node1.example.com:8080", version)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if c.Shard != shard.GetURLShard(pageURL) || c.Node != "node1.example.com:8080" || c.Version != version {
+			t.Errorf("Expected the crawl into the shard of the url, got %+v", c)
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		f := setup(t)
+
+		for _, v := range []string{"", "abc"} {
+			if _, err := f.service.RecordCrawl(pageURL, "node1.example.com:8080", v); err != challenge.ErrInvalidVersion {
+				t.Errorf("Expected %v, got %v", challenge.ErrInvalidVersion, err)
+			}
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		f := setup(t)
+
+		if _, err := f.service.RecordCrawl("ftp://example.com", "node1.example.com:8080", version); err != challenge.ErrInvalidURL {
+			t.Errorf("Expected %v, got %v", challenge.ErrInvalidURL, err)
+		}
+	})
+}
+
+func TestChallenge(t *testing.T) {
+	t.Run("nothing crawled", func(t *testing.T) {
+		f := setup(t)
+
+		if _, err := f.service.Challenge(context.Background(), f.node); err != challenge.ErrNoCrawl {
+			t.Errorf("Expected %v, got %v", challenge.ErrNoCrawl, err)
+		}
+	})
+
+	t.Run("unassigned shard", func(t *testing.T) {
+		f := setup(t)
+
+		if _, err := f.service.RecordCrawl("http://other.org/", f.node.Address, version); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if _, err := f.service.Challenge(context.Background(), f.node); err != challenge.ErrNoCrawl {
+			t.Errorf("Expected %v, got %v", challenge.ErrNoCrawl, err)
+		}
+	})
+
+	t.Run("crawl without a version", func(t *testing.T) {
+		f := setup(t)
+
+		c, _ := f.service.RecordCrawl(pageURL, f.node.Address, version)
+		c.Version = ""
+		f.repo.UpdateCrawl(c)
+
+		if _, err := f.service.Challenge(context.Background(), f.node); err != challenge.ErrNoCrawl {
+			t.Errorf("Expected %v, got %v", challenge.ErrNoCrawl, err)
+		}
+	})
+
+	t.Run("downloads the version and prepares spot checks", func(t *testing.T) {
+		f := setup(t)
+		defer gock.Off()
+
+		f.service.RecordCrawl(pageURL, f.node.Address, version)
+
+		gock.New("http://node1.example.com:8080").
+			Get("/blob").
+			MatchParam("url", pageURL).
+			MatchParam("version", version).
+			Reply(200).
+			BodyString(string(blob))
+
+		r, err := f.service.Challenge(context.Background(), f.node)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if !r.Passed || r.Kind != challenge.KindHash {
+			t.Errorf("Expected a passed hash challenge, got %+v", r)
+		}
+
+		c := f.crawl(t)
+
+		if len(c.SpotChecks) != challenge.SpotChecks {
+			t.Errorf("Expected %d spot checks, got %d", challenge.SpotChecks, len(c.SpotChecks))
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	// prepared records a crawl the node was already challenged on.
+	prepared := func(t *testing.T, f *fixture) *challenge.Crawl {
+		t.Helper()
+
+		c, _ := f.service.RecordCrawl(pageURL, f.node.Address, version)
+
+		if err := f.service.prepare(c, blob); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return c
+	}
+
+	t.Run("passes a spot check", func(t *testing.T) {
+		f := setup(t)
+		defer gock.Off()
+
+		c := prepared(t, f)
+
+		gock.New("http://node1.example.com:8080").
+			Post("/challenge").
+			Reply(200).
+			JSON(map[string]interface{}{"status": "success", "hash": c.Version, "mac": c.SpotChecks[0].MAC})
+
+		r, err := f.service.Challenge(context.Background(), f.node)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if !r.Passed || r.Kind != challenge.KindMAC {
+			t.Errorf("Expected a passed hmac challenge, got %+v", r)
+		}
+
+		if got := len(f.crawl(t).SpotChecks); got != challenge.SpotChecks-1 {
+			t.Errorf("Expected the spot check used up, got %d left", got)
+		}
+
+		if got := f.reputation(t); got != node.MaxReputation {
+			t.Errorf("Expected %v, got %v", node.MaxReputation, got)
+		}
+	})
+
+	t.Run("fails a wrong answer", func(t *testing.T) {
+		f := setup(t)
+		defer gock.Off()
+
+		c := prepared(t, f)
+
+		gock.New("http://node1.example.com:8080").
+			Post("/challenge").
+			Reply(200).
+			JSON(map[string]interface{}{"status": "success", "hash": c.Version, "mac": []byte("guess")})
+
+		r, err := f.service.Challenge(context.Background(), f.node)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if r.Passed || r.Error != challenge.ErrWrongAnswer.Error() {
+			t.Errorf("Expected a failed challenge, got %+v", r)
+		}
+
+		if got := f.reputation(t); got >= node.MaxReputation {
+			t.Errorf("Expected the reputation lowered, got %v", got)
+		}
+
+		results, _ := f.service.ListResults(f.node.ID)
+
+		if len(results) != 1 || results[0].ID != r.ID {
+			t.Errorf("Expected the result recorded, got %v", results)
+		}
+	})
+
+	t.Run("fails a page fetched again after it changed", func(t *testing.T) {
+		f := setup(t)
+		defer gock.Off()
+
+		f.service.RecordCrawl(pageURL, f.node.Address, page.NewVersionHash([]byte("crawled page")).String())
+
+		gock.New("http://node1.example.com:8080").
+			Get("/blob").
+			Reply(200).
+			BodyString(string(blob))
+
+		r, err := f.service.Challenge(context.Background(), f.node)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if r.Passed || r.Error != challenge.ErrHashMismatch.Error() {
+			t.Errorf("Expected a failed challenge, got %+v", r)
+		}
+	})
+
+	t.Run("fails a node that does not answer", func(t *testing.T) {
+		f := setup(t)
+		defer gock.Off()
+
+		prepared(t, f)
+
+		gock.New("http://node1.example.com:8080").
+			Post("/challenge").
+			Reply(404)
+
+		r, err := f.service.Challenge(context.Background(), f.node)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if r.Passed {
+			t.Errorf("Expected a failed challenge, got %+v", r)
+		}
+	})
+}
+
+func TestChallengeAll(t *testing.T) {
+	t.Run("challenges excluded nodes", func(t *testing.T) {
+		f := setup(t)
+		defer gock.Off()
+
+		if _, err := f.nodeService.Exclude(f.node.ID, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		f.service.RecordCrawl(pageURL, f.node.Address, version)
+
+		gock.New("http://node1.example.com:8080").
+			Get("/blob").
+			MatchParam("url", pageURL).
+			MatchParam("version", version).
+			Reply(200).
+			BodyString(string(blob))
+
+		f.service.challengeAll(context.Background())
+
+		if !gock.IsDone() {
+			t.Errorf("Expected the excluded node to be challenged")
+		}
+	})
+}
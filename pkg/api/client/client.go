@@ -1,10 +1,15 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	balancerDto "juno/pkg/api/balancer/dto"
+	challengeDto "juno/pkg/api/challenge/dto"
 	nodeDto "juno/pkg/api/node/dto"
+	ranagDto "juno/pkg/api/ranag/dto"
 	"net/http"
+	"net/url"
 )
 
 type Client struct {
@@ -52,3 +57,57 @@ func (c *Client) GetBalancers() (*balancerDto.AllShardsBalancersResponse, error)
 
 	return &res, nil
 }
+
+// GetRanagChildren lists the ranags the ranag at address delegates to.
+func (c *Client) GetRanagChildren(address string) (*ranagDto.ListRanagsResponse, error) {
+	var res ranagDto.ListRanagsResponse
+	resp, err := http.Get(c.baseURL + "/shards/ranags/children?address=" + url.QueryEscape(address))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&res)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ReportCrawl reports that the version of the page at url was stored on the
+// node, which is then challenged to prove it still stores it. Reports are
+// only accepted with the report token of the balancer.
+func (c *Client) ReportCrawl(reportToken, url, node, version string) error {
+	b, err := json.Marshal(challengeDto.RecordCrawlRequest{URL: url, Node: node, Version: version})
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/crawls", bytes.NewBuffer(b))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+reportToken)
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to report crawl: status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
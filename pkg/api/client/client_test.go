@@ -3,6 +3,7 @@ package client
 import (
 	balcnerDto "juno/pkg/api/balancer/dto"
 	nodeDto "juno/pkg/api/node/dto"
+	ranagDto "juno/pkg/api/ranag/dto"
 	"testing"
 
 	"github.com/h2non/gock"
@@ -112,3 +113,68 @@ func TestGetBalancers(t *testing.T) {
 		}
 	})
 }
+
+func TestGetRanagChildren(t *testing.T) {
+	t.Run("should return the children of a ranag", func(t *testing.T) {
+		baseURL := "http://localhost:8080"
+		client := New(baseURL)
+		expected := &ranagDto.ListRanagsResponse{
+			Status: ranagDto.SUCCESS,
+			Ranags: []*ranagDto.Ranag{
+				{Address: "child.com:8000", ShardAssignments: [][2]int{{0, 50}}},
+			},
+		}
+
+		defer gock.Off()
+
+		gock.New(baseURL).
+			Get("/shards/ranags/children").
+			MatchParam("address", "parent.com:8000").
+			Reply(200).
+			JSON(expected)
+
+		res, err := client.GetRanagChildren("parent.com:8000")
+
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if len(res.Ranags) != 1 || res.Ranags[0].Address != "child.com:8000" {
+			t.Errorf("expected child.com:8000 but got %v", res.Ranags)
+		}
+	})
+}
+
+func TestReportCrawl(t *testing.T) {
+	baseURL := "http://localhost:8080"
+
+	t.Run("should report a crawl", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New(baseURL).
+			Post("/crawls").
+			MatchHeader("Authorization", "Bearer secret").
+			JSON(map[string]string{"url": "http://example.com", "node": "node1:8080", "version": "abc"}).
+			Reply(201)
+
+		if err := New(baseURL).ReportCrawl("secret", "http://example.com", "node1:8080", "abc"); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("not all expectations were met")
+		}
+	})
+
+	t.Run("should return error when rejected", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New(baseURL).
+			Post("/crawls").
+			Reply(401)
+
+		if err := New(baseURL).ReportCrawl("wrong", "http://example.com", "node1:8080", "abc"); err == nil {
+			t.Errorf("expected error but got nil")
+		}
+	})
+}
@@ -0,0 +1,134 @@
+// Package billing prices jobs. A job is charged for every shard that answered
+// and every row it returned. The platform takes its fee from what a job is
+// charged and the owners of the ranags and nodes that answered earn the rest.
+package billing
+
+import (
+	"juno/pkg/api/transaction"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+type Pricing struct {
+	ShardPrice transaction.Amount
+	RowPrice   transaction.Amount
+	// RowsPerShard is how many rows a shard is expected to return when the
+	// cost of a job is estimated
+	RowsPerShard float64
+	// PlatformShare is the part of an answer's cost the platform keeps as
+	// its fee, and RanagShare the part of the rest its ranag earns. The
+	// nodes that answered the shards split what is left.
+	PlatformShare float64
+	RanagShare    float64
+}
+
+var DefaultPricing = Pricing{
+	ShardPrice:    1_000,
+	RowPrice:      100,
+	RowsPerShard:  10,
+	PlatformShare: 0.1,
+	RanagShare:    0.2,
+}
+
+// Estimate is the cost of a job on the shards returning the expected rows.
+func (p Pricing) Estimate(shards int) transaction.Amount {
+	return p.Cost(shards, int(math.Round(float64(shards)*p.RowsPerShard)))
+}
+
+// Cost is the cost of the shards that answered and the rows they returned.
+func (p Pricing) Cost(shards, rows int) transaction.Amount {
+	return p.ShardPrice.Mul(shards) + p.RowPrice.Mul(rows)
+}
+
+// Meter adds up the cost of a job's answers and which ranags and nodes earn
+// it. It is not safe for concurrent use.
+type Meter struct {
+	pricing  Pricing
+	cost     transaction.Amount
+	earnings map[transaction.Earner]transaction.Amount
+	weights  map[transaction.Earner]float64
+}
+
+func NewMeter(pricing Pricing) *Meter {
+	return &Meter{
+		pricing:  pricing,
+		earnings: make(map[transaction.Earner]transaction.Amount),
+		weights:  make(map[transaction.Earner]float64),
+	}
+}
+
+// Weigh scales what the earner earns by weight, from 0 to 1, e.g. by the
+// reputation of a node. The platform keeps the rest.
+func (m *Meter) Weigh(earner transaction.Earner, weight float64) {
+	m.weights[earner] = min(max(weight, 0), 1)
+}
+
+// Add meters a ranag's answer. nodes holds the node that answered each shard.
+// A ranag or node without an owner did not prove its work with a receipt, and
+// the platform keeps its part. What does not split evenly between the shards
+// goes to the ranag.
+func (m *Meter) Add(ranag transaction.Earner, nodes []transaction.Earner, rows int) {
+	cost := m.pricing.Cost(len(nodes), rows)
+
+	if cost == 0 {
+		return
+	}
+
+	m.cost += cost
+
+	earned := cost - cost.Share(m.pricing.PlatformShare)
+
+	if len(nodes) == 0 {
+		m.credit(ranag, earned)
+		return
+	}
+
+	perShard := (earned - earned.Share(m.pricing.RanagShare)) / transaction.Amount(len(nodes))
+
+	m.credit(ranag, earned-perShard.Mul(len(nodes)))
+
+	for _, node := range nodes {
+		m.credit(node, perShard)
+	}
+}
+
+func (m *Meter) credit(earner transaction.Earner, amount transaction.Amount) {
+	if earner.OwnerID == uuid.Nil {
+		return
+	}
+
+	if weight, ok := m.weights[earner]; ok {
+		amount = transaction.Amount(math.Floor(float64(amount) * weight))
+	}
+
+	m.earnings[earner] += amount
+}
+
+// Cost is the cost metered so far.
+func (m *Meter) Cost() transaction.Amount {
+	return m.cost
+}
+
+// Settlement returns the cost to charge, at most limit, and what every ranag
+// and node earns of it. Earnings are scaled down with the cost when it is capped, and
+// the platform's fee is what the earnings leave of the cost.
+func (m *Meter) Settlement(limit transaction.Amount) (transaction.Amount, map[transaction.Earner]transaction.Amount) {
+	cost := min(m.cost, limit)
+
+	earnings := make(map[transaction.Earner]transaction.Amount, len(m.earnings))
+
+	if cost <= 0 {
+		return 0, earnings
+	}
+
+	scale := float64(cost) / float64(m.cost)
+
+	for earner, amount := range m.earnings {
+		if scaled := transaction.Amount(math.Floor(float64(amount) * scale)); scaled > 0 {
+			earnings[earner] = scaled
+		}
+	}
+
+	return cost, earnings
+}
@@ -0,0 +1,114 @@
+package billing
+
+import (
+	"juno/pkg/api/transaction"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var pricing = Pricing{
+	ShardPrice:    1000,
+	RowPrice:      500,
+	RowsPerShard:  4,
+	PlatformShare: 0.25,
+	RanagShare:    0.2,
+}
+
+func TestEstimate(t *testing.T) {
+	if estimate := pricing.Estimate(10); estimate != 30_000 {
+		t.Errorf("Expected 30000, got %d", estimate)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	ranag := transaction.Earner{OwnerID: uuid.New(), SourceID: uuid.New()}
+	node := transaction.Earner{OwnerID: uuid.New(), SourceID: uuid.New()}
+
+	t.Run("splits the cost between the platform, the ranag and the nodes", func(t *testing.T) {
+		m := NewMeter(pricing)
+
+		// 2 shards and 4 rows cost 4000, of which 1000 is the fee
+		m.Add(ranag, []transaction.Earner{node, node}, 4)
+
+		if m.Cost() != 4000 {
+			t.Fatalf("Expected 4000, got %d", m.Cost())
+		}
+
+		cost, earnings := m.Settlement(100_000)
+
+		if cost != 4000 {
+			t.Errorf("Expected 4000, got %d", cost)
+		}
+
+		if earnings[ranag] != 600 || earnings[node] != 2400 {
+			t.Errorf("Expected 600 and 2400, got %v", earnings)
+		}
+	})
+
+	t.Run("keeps the parts of nodes and ranags without receipts", func(t *testing.T) {
+		m := NewMeter(Pricing{ShardPrice: 1000, RanagShare: 0.2})
+
+		// the nodes share 2400 of 3000, 800 a shard
+		m.Add(ranag, []transaction.Earner{node, {}, node}, 0)
+
+		cost, earnings := m.Settlement(100_000)
+
+		if cost != 3000 {
+			t.Errorf("Expected 3000, got %d", cost)
+		}
+
+		if earnings[ranag] != 600 || earnings[node] != 1600 || len(earnings) != 2 {
+			t.Errorf("Expected 600 and 1600 and nothing else credited, got %v", earnings)
+		}
+
+		m = NewMeter(Pricing{ShardPrice: 1000, RanagShare: 0.2})
+
+		m.Add(transaction.Earner{}, []transaction.Earner{node}, 0)
+
+		if _, earnings := m.Settlement(100_000); earnings[node] != 800 || len(earnings) != 1 {
+			t.Errorf("Expected only the node credited, got %v", earnings)
+		}
+	})
+
+	t.Run("weighs earnings", func(t *testing.T) {
+		m := NewMeter(Pricing{ShardPrice: 1000, RanagShare: 0.2})
+		m.Weigh(node, 0.5)
+
+		m.Add(ranag, []transaction.Earner{node}, 0)
+
+		cost, earnings := m.Settlement(100_000)
+
+		if cost != 1000 || earnings[ranag] != 200 || earnings[node] != 400 {
+			t.Errorf("Expected half of the node's 800 credited, got %d and %v", cost, earnings)
+		}
+	})
+
+	t.Run("caps the cost", func(t *testing.T) {
+		m := NewMeter(pricing)
+
+		m.Add(ranag, []transaction.Earner{node, node}, 4)
+
+		cost, earnings := m.Settlement(2000)
+
+		if cost != 2000 {
+			t.Errorf("Expected 2000, got %d", cost)
+		}
+
+		if earnings[ranag] != 300 || earnings[node] != 1200 {
+			t.Errorf("Expected the earnings halved, got %v", earnings)
+		}
+	})
+
+	t.Run("nothing answered", func(t *testing.T) {
+		m := NewMeter(pricing)
+
+		m.Add(ranag, nil, 0)
+
+		cost, earnings := m.Settlement(10 * transaction.Token)
+
+		if cost != 0 || len(earnings) != 0 {
+			t.Errorf("Expected nothing to settle, got %d and %v", cost, earnings)
+		}
+	})
+}
@@ -3,7 +3,11 @@ package job
 import (
 	"context"
 	"errors"
+	"io"
+	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/api/transaction"
+	"juno/pkg/api/usage"
 	"juno/pkg/can"
 	"time"
 
@@ -12,7 +16,18 @@ import (
 )
 
 var (
-	ErrNotFound = errors.New("job not found")
+	ErrNotFound        = errors.New("job not found")
+	ErrNoJob           = errors.New("no job to claim")
+	ErrLeaseLost       = errors.New("job lease lost")
+	ErrResultsNotFound = errors.New("job results not found")
+	ErrUnknownFormat   = errors.New("unknown export format")
+	ErrCancelled       = errors.New("job cancelled")
+	ErrFinished        = errors.New("job already finished")
+)
+
+const (
+	DefaultResultsLimit = 100
+	MaxResultsLimit     = 1_000
 )
 
 type JobStatus string
@@ -22,14 +37,42 @@ const (
 	RunningStatus   JobStatus = "running"
 	CompletedStatus JobStatus = "completed"
 	FailedStatus    JobStatus = "failed"
+	CancelledStatus JobStatus = "cancelled"
 )
 
+// Finished reports whether a job with the status will not change anymore.
+func (s JobStatus) Finished() bool {
+	return s == CompletedStatus || s == FailedStatus || s == CancelledStatus
+}
+
 type Job struct {
 	ID         uuid.UUID `json:"id"`
 	UserID     uuid.UUID `json:"user_id"`
 	Status     JobStatus `json:"status"`
 	StrategyID uuid.UUID `json:"strategy_id"`
 
+	// PlannedShards is how many shards the job was planned on, and
+	// AnsweredShards how many of them answered. Gaps are the shard ranges,
+	// as offset and total, that no ranag covers.
+	PlannedShards  int      `json:"planned_shards"`
+	AnsweredShards int      `json:"answered_shards"`
+	Gaps           [][2]int `json:"gaps"`
+
+	// FailedShards are the planned shards no ranag answered, and
+	// RowsCollected the rows the answered ones returned so far. Both are
+	// updated while the job runs.
+	FailedShards  int    `json:"failed_shards"`
+	RowsCollected int    `json:"rows_collected"`
+	Error         string `json:"error"`
+
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+
+	// Escrowed is what was held from the user's balance when the job was
+	// created, and Cost what the job was charged of it when it finished.
+	Escrowed transaction.Amount `json:"escrowed"`
+	Cost     transaction.Amount `json:"cost"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -52,18 +95,108 @@ type Repository interface {
 	ListByUserID(userID uuid.UUID) ([]*Job, error)
 	ListByStatus(status JobStatus) ([]*Job, error)
 	Update(job *Job) error
+	// UpdateProgress stores the coverage and progress of a job but not its
+	// status, so a running job cannot undo its cancellation.
+	UpdateProgress(job *Job) error
+	// Transition stores the job like Update, but only while its stored
+	// status is still from. It reports whether it stored the job.
+	Transition(job *Job, from JobStatus) (bool, error)
+
+	// Claim marks the oldest pending job, or a running one whose lease
+	// expired, as running and leases it to the owner. A cancelled job whose
+	// lease expired before it was finished is leased as it is, to be
+	// settled. It returns ErrNoJob when there is none.
+	Claim(owner string, lease time.Duration) (*Job, error)
+	// Renew extends the owner's lease on the job. It returns ErrLeaseLost
+	// when another owner claimed it.
+	Renew(id uuid.UUID, owner string, lease time.Duration) error
+	// Finish stores the outcome of a job the owner ran, like Update, and
+	// drops its lease. It returns ErrLeaseLost when another owner claimed
+	// the job since.
+	Finish(job *Job, owner string) error
+}
+
+// Format is a format job results are exported in.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatJSON   Format = "json"
+)
+
+// Column is a column of a job's results, in the order it is exported.
+type Column struct {
+	Name string          `json:"name"`
+	Type field.FieldType `json:"type"`
+}
+
+// Manifest describes the stored results of a job. Bytes is the size of the
+// rows as stored.
+type Manifest struct {
+	Rows   int      `json:"rows"`
+	Bytes  int64    `json:"bytes"`
+	Schema []Column `json:"schema"`
+}
+
+// ResultStore keeps the rows a job produced. Every method but Write returns
+// ErrResultsNotFound when the job has no results stored.
+type ResultStore interface {
+	// Write replaces the job's rows and returns their manifest.
+	Write(jobID uuid.UUID, schema []Column, rows []map[string]interface{}) (*Manifest, error)
+	Manifest(jobID uuid.UUID) (*Manifest, error)
+	// Read returns up to limit rows from offset and the total number of
+	// rows.
+	Read(jobID uuid.UUID, offset, limit int) ([]map[string]interface{}, int, error)
+	// Stream calls fn with every row in order, until fn returns an error.
+	Stream(jobID uuid.UUID, fn func(row map[string]interface{}) error) error
+}
+
+// Escrow holds the tokens users pay their jobs with.
+type Escrow interface {
+	// Escrow holds the amount back from the user's balance. It returns
+	// token.ErrInsufficientBalance when the balance does not cover it.
+	Escrow(userID, jobID uuid.UUID, amount transaction.Amount) error
+	// Settle releases what was escrowed for the job, charges the user its
+	// cost and credits the earnings to the owners of their earners,
+	// atomically.
+	Settle(userID, jobID uuid.UUID, escrowed, cost transaction.Amount, earnings map[transaction.Earner]transaction.Amount) error
+}
+
+// Receipts keeps the verified receipts of the work ranags and nodes did for
+// jobs.
+type Receipts interface {
+	Record(receipts []*usage.Receipt) error
+	ListByJobID(jobID uuid.UUID) ([]*usage.Receipt, error)
 }
 
 type Service interface {
 	Create(userID uuid.UUID, strategyID uuid.UUID) (*Job, error)
 	Get(id uuid.UUID) (*Job, error)
 	ListByUserID(userID uuid.UUID) ([]*Job, error)
+	Results(id uuid.UUID, offset, limit int) ([]map[string]interface{}, int, error)
+	Manifest(id uuid.UUID) (*Manifest, error)
+	// Receipts lists the verified receipts of the work done for the job.
+	Receipts(id uuid.UUID) ([]*usage.Receipt, error)
+	// Export writes all the job's results to w in the format.
+	Export(id uuid.UUID, format Format, w io.Writer) error
+	// Cancel stops a pending or running job. It returns ErrFinished when the
+	// job already finished.
+	Cancel(id uuid.UUID) (*Job, error)
+	// Watch sends the job every time it changes, until it finishes or ctx
+	// is done.
+	Watch(ctx context.Context, id uuid.UUID) (<-chan *Job, error)
 }
 
 type Handler interface {
 	Create(c *gin.Context)
 	Get(c *gin.Context)
 	List(c *gin.Context)
+	Results(c *gin.Context)
+	Manifest(c *gin.Context)
+	Receipts(c *gin.Context)
+	Cancel(c *gin.Context)
+	Events(c *gin.Context)
 }
 
 type Policy interface {
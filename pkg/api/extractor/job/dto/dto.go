@@ -2,6 +2,7 @@ package dto
 
 import (
 	"juno/pkg/api/extractor/job"
+	"juno/pkg/api/transaction"
 	"time"
 )
 
@@ -15,6 +16,20 @@ type Job struct {
 	UserID     string `json:"user_id"`
 	StrategyID string `json:"strategy_id"`
 	Status     string `json:"status"`
+
+	PlannedShards  int      `json:"planned_shards"`
+	AnsweredShards int      `json:"answered_shards"`
+	Gaps           [][2]int `json:"gaps,omitempty"`
+
+	FailedShards  int    `json:"failed_shards"`
+	RowsCollected int    `json:"rows_collected"`
+	Error         string `json:"error,omitempty"`
+
+	Escrowed transaction.Amount `json:"escrowed"`
+	Cost     transaction.Amount `json:"cost"`
+
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
 	CreatedAt  string `json:"created_at"`
 	UpdatedAt  string `json:"updated_at"`
 }
@@ -31,14 +46,36 @@ type CreateJobResponse struct {
 }
 
 func NewJobFromDomain(j *job.Job) *Job {
-	return &Job{
+	dto := &Job{
 		ID:         j.ID.String(),
 		UserID:     j.UserID.String(),
 		StrategyID: j.StrategyID.String(),
 		Status:     string(j.Status),
-		CreatedAt:  j.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:  j.UpdatedAt.Format(time.RFC3339),
+
+		PlannedShards:  j.PlannedShards,
+		AnsweredShards: j.AnsweredShards,
+		Gaps:           j.Gaps,
+
+		FailedShards:  j.FailedShards,
+		RowsCollected: j.RowsCollected,
+		Error:         j.Error,
+
+		Escrowed: j.Escrowed,
+		Cost:     j.Cost,
+
+		CreatedAt: j.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: j.UpdatedAt.Format(time.RFC3339),
 	}
+
+	if j.StartedAt != nil {
+		dto.StartedAt = j.StartedAt.Format(time.RFC3339)
+	}
+
+	if j.FinishedAt != nil {
+		dto.FinishedAt = j.FinishedAt.Format(time.RFC3339)
+	}
+
+	return dto
 }
 
 func NewSuccessCreateJobResponse(job *job.Job) CreateJobResponse {
@@ -76,6 +113,27 @@ func NewErrorGetJobResponse(message string) GetJobResponse {
 	}
 }
 
+type CancelJobResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Job *Job `json:"result,omitempty"`
+}
+
+func NewSuccessCancelJobResponse(job *job.Job) CancelJobResponse {
+	return CancelJobResponse{
+		Status: SUCCESS,
+		Job:    NewJobFromDomain(job),
+	}
+}
+
+func NewErrorCancelJobResponse(message string) CancelJobResponse {
+	return CancelJobResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
 type ListJobsResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
@@ -102,3 +160,76 @@ func NewErrorListJobsResponse(message string) ListJobsResponse {
 		Message: message,
 	}
 }
+
+type GetJobResultsResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Results []map[string]interface{} `json:"results,omitempty"`
+	Offset  int                      `json:"offset"`
+	Limit   int                      `json:"limit"`
+	Total   int                      `json:"total"`
+}
+
+func NewSuccessGetJobResultsResponse(rows []map[string]interface{}, offset, limit, total int) GetJobResultsResponse {
+	return GetJobResultsResponse{
+		Status:  SUCCESS,
+		Results: rows,
+		Offset:  offset,
+		Limit:   limit,
+		Total:   total,
+	}
+}
+
+func NewErrorGetJobResultsResponse(message string) GetJobResultsResponse {
+	return GetJobResultsResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type Manifest struct {
+	Rows   int      `json:"rows"`
+	Bytes  int64    `json:"bytes"`
+	Schema []Column `json:"schema"`
+}
+
+func NewManifestFromDomain(m *job.Manifest) *Manifest {
+	schema := make([]Column, 0, len(m.Schema))
+
+	for _, c := range m.Schema {
+		schema = append(schema, Column{Name: c.Name, Type: string(c.Type)})
+	}
+
+	return &Manifest{
+		Rows:   m.Rows,
+		Bytes:  m.Bytes,
+		Schema: schema,
+	}
+}
+
+type GetJobManifestResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Manifest *Manifest `json:"manifest,omitempty"`
+}
+
+func NewSuccessGetJobManifestResponse(m *job.Manifest) GetJobManifestResponse {
+	return GetJobManifestResponse{
+		Status:   SUCCESS,
+		Manifest: NewManifestFromDomain(m),
+	}
+}
+
+func NewErrorGetJobManifestResponse(message string) GetJobManifestResponse {
+	return GetJobManifestResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
@@ -0,0 +1,259 @@
+// Package export writes the results of a job in the formats they are
+// downloaded in. Columns follow the strategy's fields, then the metadata
+// nodes add to every row, and values are converted to the type of their
+// field.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"juno/pkg/api/extractor/field"
+	"juno/pkg/api/extractor/job"
+	"juno/pkg/api/extractor/strategy"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TypeJSON is the type of the columns holding aggregation results, which are
+// exported as they are.
+const TypeJSON field.FieldType = "json"
+
+// MetaColumns are added by nodes to every extracted row.
+var MetaColumns = []job.Column{
+	{Name: "_juno_meta_url", Type: field.FieldTypeString},
+}
+
+// Schema returns the columns of a strategy's results: its fields and the
+// meta columns, or one column per aggregation when it aggregates.
+func Schema(strat *strategy.Strategy) []job.Column {
+	var schema []job.Column
+
+	if len(strat.Aggregations) > 0 {
+		for _, a := range strat.Aggregations {
+			schema = append(schema, job.Column{Name: a.Name, Type: TypeJSON})
+		}
+
+		return schema
+	}
+
+	for _, f := range strat.Fields {
+		schema = append(schema, job.Column{Name: f.Name, Type: f.Type})
+	}
+
+	return append(schema, MetaColumns...)
+}
+
+// ParseFormat returns the format named by s.
+func ParseFormat(s string) (job.Format, error) {
+	switch f := job.Format(strings.ToLower(s)); f {
+	case job.FormatCSV, job.FormatNDJSON, job.FormatJSON:
+		return f, nil
+	}
+
+	return "", job.ErrUnknownFormat
+}
+
+// ContentType returns the media type of the format.
+func ContentType(f job.Format) string {
+	switch f {
+	case job.FormatCSV:
+		return "text/csv; charset=utf-8"
+	case job.FormatNDJSON:
+		return "application/x-ndjson"
+	}
+
+	return "application/json"
+}
+
+// Convert converts a stored value to the column type. Values that do not
+// parse as the type are exported as null.
+func Convert(v interface{}, t field.FieldType) interface{} {
+	switch t {
+	case field.FieldTypeInteger:
+		switch n := v.(type) {
+		case float64:
+			if n == math.Trunc(n) {
+				return int64(n)
+			}
+		case string:
+			s := strings.TrimSpace(n)
+
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i
+			}
+
+			if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) {
+				return int64(f)
+			}
+		}
+
+		return nil
+	case field.FieldTypeFloat:
+		switch n := v.(type) {
+		case float64:
+			return n
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil {
+				return f
+			}
+		}
+
+		return nil
+	case field.FieldTypeString:
+		switch s := v.(type) {
+		case nil, string:
+			return s
+		case float64:
+			return strconv.FormatFloat(s, 'f', -1, 64)
+		}
+	}
+
+	return v
+}
+
+// Writer writes rows in a format. Close must be called after the last row.
+type Writer interface {
+	Write(row map[string]interface{}) error
+	Close() error
+}
+
+// NewWriter returns a writer of rows with the schema's columns, in order.
+func NewWriter(w io.Writer, f job.Format, schema []job.Column) (Writer, error) {
+	switch f {
+	case job.FormatCSV:
+		cw := &csvWriter{w: csv.NewWriter(w), schema: schema}
+
+		header := make([]string, len(schema))
+		for i, c := range schema {
+			header[i] = c.Name
+		}
+
+		if err := cw.w.Write(header); err != nil {
+			return nil, err
+		}
+
+		return cw, nil
+	case job.FormatNDJSON:
+		return &jsonWriter{w: w, schema: schema}, nil
+	case job.FormatJSON:
+		return &jsonWriter{w: w, schema: schema, array: true}, nil
+	}
+
+	return nil, job.ErrUnknownFormat
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	schema []job.Column
+}
+
+func (c *csvWriter) Write(row map[string]interface{}) error {
+	record := make([]string, len(c.schema))
+
+	for i, col := range c.schema {
+		s, err := csvValue(Convert(row[col.Name], col.Type))
+
+		if err != nil {
+			return err
+		}
+
+		record[i] = s
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func csvValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+
+	b, err := json.Marshal(v)
+
+	return string(b), err
+}
+
+// jsonWriter writes every row as an object with its keys in schema order,
+// on its own line for NDJSON or in an array for JSON.
+type jsonWriter struct {
+	w      io.Writer
+	schema []job.Column
+	array  bool
+	rows   int
+}
+
+func (j *jsonWriter) Write(row map[string]interface{}) error {
+	var buf bytes.Buffer
+
+	if j.array {
+		if j.rows == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+	}
+
+	buf.WriteByte('{')
+
+	for i, col := range j.schema {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(col.Name)
+		if err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(Convert(row[col.Name], col.Type))
+		if err != nil {
+			return err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+
+	if !j.array {
+		buf.WriteByte('\n')
+	}
+
+	j.rows++
+
+	_, err := j.w.Write(buf.Bytes())
+
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if !j.array {
+		return nil
+	}
+
+	if j.rows == 0 {
+		_, err := io.WriteString(j.w, "[]")
+		return err
+	}
+
+	_, err := io.WriteString(j.w, "]")
+
+	return err
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bytes"
+	"juno/pkg/aggregation"
+	"juno/pkg/api/extractor/field"
+	"juno/pkg/api/extractor/job"
+	"juno/pkg/api/extractor/strategy"
+	"testing"
+)
+
+var schema = []job.Column{
+	{Name: "title", Type: field.FieldTypeString},
+	{Name: "price", Type: field.FieldTypeFloat},
+	{Name: "stock", Type: field.FieldTypeInteger},
+	{Name: "_juno_meta_url", Type: field.FieldTypeString},
+}
+
+var rows = []map[string]interface{}{
+	{"title": "charger, USB-C", "price": " 10.5", "stock": "3", "_juno_meta_url": "http://example.com/1"},
+	{"title": "cable", "price": "n/a", "stock": 2.0, "_juno_meta_url": "http://example.com/2"},
+}
+
+func TestSchema(t *testing.T) {
+	strat := &strategy.Strategy{
+		Fields: []*field.Field{
+			{Name: "title", Type: field.FieldTypeString},
+			{Name: "price", Type: field.FieldTypeFloat},
+		},
+	}
+
+	got := Schema(strat)
+
+	if len(got) != 3 || got[0].Name != "title" || got[1].Type != field.FieldTypeFloat || got[2].Name != "_juno_meta_url" {
+		t.Errorf("Expected the fields then the meta columns, got %v", got)
+	}
+
+	strat.Aggregations = []*aggregation.Aggregation{{Name: "products", Op: aggregation.OpCount}}
+
+	got = Schema(strat)
+
+	if len(got) != 1 || got[0] != (job.Column{Name: "products", Type: TypeJSON}) {
+		t.Errorf("Expected a column per aggregation, got %v", got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		typ   field.FieldType
+		want  interface{}
+	}{
+		{"42", field.FieldTypeInteger, int64(42)},
+		{" 42.0 ", field.FieldTypeInteger, int64(42)},
+		{42.5, field.FieldTypeInteger, nil},
+		{"forty", field.FieldTypeInteger, nil},
+		{"1.25", field.FieldTypeFloat, 1.25},
+		{3.0, field.FieldTypeFloat, 3.0},
+		{"", field.FieldTypeFloat, nil},
+		{"abc", field.FieldTypeString, "abc"},
+		{12.0, field.FieldTypeString, "12"},
+		{nil, field.FieldTypeString, nil},
+	}
+
+	for _, tt := range tests {
+		if got := Convert(tt.value, tt.typ); got != tt.want {
+			t.Errorf("Expected %v (%T) for %v as %s, got %v (%T)", tt.want, tt.want, tt.value, tt.typ, got, got)
+		}
+	}
+}
+
+func write(t *testing.T, f job.Format, rows []map[string]interface{}) string {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, f, schema)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		want := "title,price,stock,_juno_meta_url\n" +
+			"\"charger, USB-C\",10.5,3,http://example.com/1\n" +
+			"cable,,2,http://example.com/2\n"
+
+		if got := write(t, job.FormatCSV, rows); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		want := `{"title":"charger, USB-C","price":10.5,"stock":3,"_juno_meta_url":"http://example.com/1"}` + "\n" +
+			`{"title":"cable","price":null,"stock":2,"_juno_meta_url":"http://example.com/2"}` + "\n"
+
+		if got := write(t, job.FormatNDJSON, rows); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		want := `[{"title":"charger, USB-C","price":10.5,"stock":3,"_juno_meta_url":"http://example.com/1"},` +
+			`{"title":"cable","price":null,"stock":2,"_juno_meta_url":"http://example.com/2"}]`
+
+		if got := write(t, job.FormatJSON, rows); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+
+		if got := write(t, job.FormatJSON, nil); got != "[]" {
+			t.Errorf("Expected [], got %q", got)
+		}
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("CSV"); err != nil || f != job.FormatCSV {
+		t.Errorf("Expected csv, got %v, %v", f, err)
+	}
+
+	if _, err := ParseFormat("xml"); err != job.ErrUnknownFormat {
+		t.Errorf("Expected %v, got %v", job.ErrUnknownFormat, err)
+	}
+}
@@ -1,9 +1,17 @@
 package handler
 
 import (
+	"compress/gzip"
+	"fmt"
+	"io"
 	"juno/pkg/api/auth"
 	"juno/pkg/api/extractor/job"
 	"juno/pkg/api/extractor/job/dto"
+	"juno/pkg/api/extractor/job/export"
+	"juno/pkg/api/token"
+	usageDto "juno/pkg/api/usage/dto"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -35,6 +43,11 @@ func (h *Handler) Create(c *gin.Context) {
 		Allow(func() {
 			job, err := h.jobService.Create(u.ID, uuid.MustParse(req.StrategyID))
 
+			if err == token.ErrInsufficientBalance {
+				c.JSON(402, dto.NewErrorCreateJobResponse(err.Error()))
+				return
+			}
+
 			if err != nil {
 				c.JSON(400, dto.NewErrorCreateJobResponse(err.Error()))
 				return
@@ -95,3 +108,270 @@ func (h *Handler) List(c *gin.Context) {
 			c.JSON(500, dto.NewErrorListJobsResponse(err.Error()))
 		})
 }
+
+// Results returns a page of the job's results, or all of them as a download
+// when a format is given.
+func (h *Handler) Results(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorGetJobResultsResponse("invalid job ID"))
+		return
+	}
+
+	if f := c.Query("format"); f != "" {
+		format, err := export.ParseFormat(f)
+		if err != nil {
+			c.JSON(400, dto.NewErrorGetJobResultsResponse(err.Error()))
+			return
+		}
+
+		h.export(c, jobID, format)
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(400, dto.NewErrorGetJobResultsResponse("invalid offset"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(job.DefaultResultsLimit)))
+	if err != nil || limit < 1 {
+		c.JSON(400, dto.NewErrorGetJobResultsResponse("invalid limit"))
+		return
+	}
+
+	limit = min(limit, job.MaxResultsLimit)
+
+	j, err := h.jobService.Get(jobID)
+	if err != nil {
+		c.JSON(404, dto.NewErrorGetJobResultsResponse("job not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), j).
+		Allow(func() {
+			rows, total, err := h.jobService.Results(j.ID, offset, limit)
+
+			if err == job.ErrResultsNotFound {
+				c.JSON(404, dto.NewErrorGetJobResultsResponse("job has no results"))
+				return
+			}
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorGetJobResultsResponse(err.Error()))
+				return
+			}
+
+			c.JSON(200, dto.NewSuccessGetJobResultsResponse(rows, offset, limit, total))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorGetJobResultsResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorGetJobResultsResponse(err.Error()))
+		})
+}
+
+func (h *Handler) export(c *gin.Context, jobID uuid.UUID, format job.Format) {
+	j, err := h.jobService.Get(jobID)
+	if err != nil {
+		c.JSON(404, dto.NewErrorGetJobResultsResponse("job not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), j).
+		Allow(func() {
+			_, err := h.jobService.Manifest(j.ID)
+
+			if err == job.ErrResultsNotFound {
+				c.JSON(404, dto.NewErrorGetJobResultsResponse("job has no results"))
+				return
+			}
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorGetJobResultsResponse(err.Error()))
+				return
+			}
+
+			c.Header("Content-Type", export.ContentType(format))
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, j.ID, format))
+			c.Header("Vary", "Accept-Encoding")
+
+			var w io.Writer = c.Writer
+			var gz *gzip.Writer
+
+			if strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+				c.Header("Content-Encoding", "gzip")
+				gz = gzip.NewWriter(c.Writer)
+				w = gz
+			}
+
+			c.Status(200)
+
+			// the status is sent with the first rows, so a failure midway
+			// leaves the download truncated
+			if err := h.jobService.Export(j.ID, format, w); err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+
+			if gz != nil {
+				gz.Close()
+			}
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorGetJobResultsResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorGetJobResultsResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Manifest(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorGetJobManifestResponse("invalid job ID"))
+		return
+	}
+
+	j, err := h.jobService.Get(jobID)
+	if err != nil {
+		c.JSON(404, dto.NewErrorGetJobManifestResponse("job not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), j).
+		Allow(func() {
+			m, err := h.jobService.Manifest(j.ID)
+
+			if err == job.ErrResultsNotFound {
+				c.JSON(404, dto.NewErrorGetJobManifestResponse("job has no results"))
+				return
+			}
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorGetJobManifestResponse(err.Error()))
+				return
+			}
+
+			c.JSON(200, dto.NewSuccessGetJobManifestResponse(m))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorGetJobManifestResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorGetJobManifestResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Receipts(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, usageDto.NewErrorListReceiptsResponse("invalid job ID"))
+		return
+	}
+
+	j, err := h.jobService.Get(jobID)
+	if err != nil {
+		c.JSON(404, usageDto.NewErrorListReceiptsResponse("job not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), j).
+		Allow(func() {
+			receipts, err := h.jobService.Receipts(j.ID)
+
+			if err != nil {
+				c.JSON(500, usageDto.NewErrorListReceiptsResponse(err.Error()))
+				return
+			}
+
+			c.JSON(200, usageDto.NewSuccessListReceiptsResponse(receipts))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, usageDto.NewErrorListReceiptsResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, usageDto.NewErrorListReceiptsResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Cancel(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorCancelJobResponse("invalid job ID"))
+		return
+	}
+
+	j, err := h.jobService.Get(jobID)
+	if err != nil {
+		c.JSON(404, dto.NewErrorCancelJobResponse("job not found"))
+		return
+	}
+
+	h.policy.CanUpdate(c.Request.Context(), j).
+		Allow(func() {
+			cancelled, err := h.jobService.Cancel(j.ID)
+
+			if err == job.ErrFinished {
+				c.JSON(409, dto.NewErrorCancelJobResponse(err.Error()))
+				return
+			}
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorCancelJobResponse(err.Error()))
+				return
+			}
+
+			c.JSON(200, dto.NewSuccessCancelJobResponse(cancelled))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorCancelJobResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorCancelJobResponse(err.Error()))
+		})
+}
+
+// Events streams the job as server-sent "progress" events every time it
+// changes, until it finishes or the client goes away.
+func (h *Handler) Events(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorGetJobResponse("invalid job ID"))
+		return
+	}
+
+	j, err := h.jobService.Get(jobID)
+	if err != nil {
+		c.JSON(404, dto.NewErrorGetJobResponse("job not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), j).
+		Allow(func() {
+			updates, err := h.jobService.Watch(c.Request.Context(), j.ID)
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorGetJobResponse(err.Error()))
+				return
+			}
+
+			c.Header("Cache-Control", "no-cache")
+			c.Status(200)
+
+			// the updates stop when the client goes away
+			for update := range updates {
+				c.SSEvent("progress", dto.NewJobFromDomain(update))
+				c.Writer.Flush()
+			}
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorGetJobResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorGetJobResponse(err.Error()))
+		})
+}
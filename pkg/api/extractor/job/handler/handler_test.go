@@ -2,15 +2,22 @@ package handler
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"io"
 	"juno/pkg/api/auth"
+	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/job"
 	"juno/pkg/api/extractor/job/dto"
+	"juno/pkg/api/extractor/job/export"
 	"juno/pkg/api/extractor/job/policy"
+	"juno/pkg/api/token"
+	"juno/pkg/api/usage"
+	usageDto "juno/pkg/api/usage/dto"
 	"juno/pkg/api/user"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +25,10 @@ import (
 )
 
 type mockJobService struct {
-	withError  error
-	withUserID uuid.UUID
+	withError   error
+	withUserID  uuid.UUID
+	withResults []map[string]interface{}
+	withStatus  job.JobStatus
 }
 
 func (m *mockJobService) Create(userID, strategyID uuid.UUID) (*job.Job, error) {
@@ -62,6 +71,65 @@ func (m *mockJobService) ListByUserID(userID uuid.UUID) ([]*job.Job, error) {
 	}, nil
 }
 
+func (m *mockJobService) Results(id uuid.UUID, offset, limit int) ([]map[string]interface{}, int, error) {
+	if m.withResults == nil {
+		return nil, 0, job.ErrResultsNotFound
+	}
+
+	start := min(offset, len(m.withResults))
+	end := min(start+limit, len(m.withResults))
+
+	return m.withResults[start:end], len(m.withResults), nil
+}
+
+var mockSchema = []job.Column{{Name: "title", Type: field.FieldTypeString}}
+
+func (m *mockJobService) Manifest(id uuid.UUID) (*job.Manifest, error) {
+	if m.withResults == nil {
+		return nil, job.ErrResultsNotFound
+	}
+
+	return &job.Manifest{Rows: len(m.withResults), Bytes: 42, Schema: mockSchema}, nil
+}
+
+func (m *mockJobService) Receipts(id uuid.UUID) ([]*usage.Receipt, error) {
+	return []*usage.Receipt{{ID: uuid.New(), JobID: id, SignerType: usage.NodeSigner, Shards: []int{3}, Rows: 1}}, nil
+}
+
+func (m *mockJobService) Export(id uuid.UUID, format job.Format, w io.Writer) error {
+	ew, err := export.NewWriter(w, format, mockSchema)
+
+	if err != nil {
+		return err
+	}
+
+	for _, row := range m.withResults {
+		if err := ew.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return ew.Close()
+}
+
+func (m *mockJobService) Cancel(id uuid.UUID) (*job.Job, error) {
+	if m.withStatus.Finished() {
+		return nil, job.ErrFinished
+	}
+
+	return &job.Job{ID: id, UserID: m.withUserID, Status: job.CancelledStatus}, nil
+}
+
+func (m *mockJobService) Watch(ctx context.Context, id uuid.UUID) (<-chan *job.Job, error) {
+	updates := make(chan *job.Job, 2)
+
+	updates <- &job.Job{ID: id, UserID: m.withUserID, Status: job.RunningStatus, PlannedShards: 10, AnsweredShards: 5}
+	updates <- &job.Job{ID: id, UserID: m.withUserID, Status: job.CompletedStatus, PlannedShards: 10, AnsweredShards: 10}
+	close(updates)
+
+	return updates, nil
+}
+
 func (m *mockJobService) Update(q *job.Job) error {
 	if m.withError != nil {
 		return m.withError
@@ -127,6 +195,31 @@ func TestCreate(t *testing.T) {
 			t.Errorf("Expected %s, got %s", req.StrategyID, res.Job.StrategyID)
 		}
 	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+
+		h := New(&mockJobService{withError: token.ErrInsufficientBalance}, policy.New())
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+
+		encoded, _ := json.Marshal(dto.CreateJobRequest{
+			StrategyID: uuid.New().String(),
+		})
+
+		c.Request = httptest.NewRequest("POST", "/jobs", bytes.NewBuffer(encoded)).WithContext(
+			auth.WithUser(context.Background(), &user.User{
+				ID: uuid.New(),
+			}),
+		)
+
+		h.Create(c)
+
+		if w.Code != 402 {
+			t.Errorf("Expected 402, got %d", w.Code)
+		}
+	})
 }
 
 func TestGet(t *testing.T) {
@@ -233,3 +326,330 @@ func TestList(t *testing.T) {
 		}
 	})
 }
+
+func TestResults(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	request := func(h *Handler, query string, headers ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest("GET", "/jobs/"+jobID.String()+"/results"+query, nil).WithContext(
+			auth.WithUser(context.Background(), &user.User{
+				ID: userID,
+			}),
+		)
+		for i := 0; i+1 < len(headers); i += 2 {
+			c.Request.Header.Set(headers[i], headers[i+1])
+		}
+		c.Params = gin.Params{{Key: "id", Value: jobID.String()}}
+
+		h.Results(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		h := New(&mockJobService{
+			withUserID: userID,
+			withResults: []map[string]interface{}{
+				{"title": "a"},
+				{"title": "b"},
+				{"title": "c"},
+			},
+		}, policy.New())
+
+		w := request(h, "?offset=1&limit=1")
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		var res dto.GetJobResultsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if res.Total != 3 || res.Offset != 1 || res.Limit != 1 {
+			t.Errorf("Expected page 1/1 of 3, got %+v", res)
+		}
+
+		if len(res.Results) != 1 || res.Results[0]["title"] != "b" {
+			t.Errorf("Expected the second row, got %v", res.Results)
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID}, policy.New())
+
+		if w := request(h, ""); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID}, policy.New())
+
+		if w := request(h, "?limit=0"); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		h := New(&mockJobService{
+			withUserID:  uuid.New(),
+			withResults: []map[string]interface{}{{"title": "a"}},
+		}, policy.New())
+
+		if w := request(h, ""); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+
+		if w := request(h, "?format=csv"); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		h := New(&mockJobService{
+			withUserID:  userID,
+			withResults: []map[string]interface{}{{"title": "a"}, {"title": "b"}},
+		}, policy.New())
+
+		w := request(h, "?format=csv")
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("Expected text/csv, got %s", ct)
+		}
+
+		if body := w.Body.String(); body != "title\na\nb\n" {
+			t.Errorf("Expected a header and 2 rows, got %q", body)
+		}
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		h := New(&mockJobService{
+			withUserID:  userID,
+			withResults: []map[string]interface{}{{"title": "a"}},
+		}, policy.New())
+
+		w := request(h, "?format=ndjson", "Accept-Encoding", "gzip, deflate")
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+			t.Fatalf("Expected gzip, got %q", enc)
+		}
+
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		b, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if string(b) != "{\"title\":\"a\"}\n" {
+			t.Errorf("Expected the row, got %q", b)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID}, policy.New())
+
+		if w := request(h, "?format=xml"); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("export without results", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID}, policy.New())
+
+		if w := request(h, "?format=json"); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestManifest(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	h := New(&mockJobService{
+		withUserID:  userID,
+		withResults: []map[string]interface{}{{"title": "a"}},
+	}, policy.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request = httptest.NewRequest("GET", "/jobs/"+jobID.String()+"/manifest", nil).WithContext(
+		auth.WithUser(context.Background(), &user.User{
+			ID: userID,
+		}),
+	)
+	c.Params = gin.Params{{Key: "id", Value: jobID.String()}}
+
+	h.Manifest(c)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var res dto.GetJobManifestResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if res.Manifest.Rows != 1 || res.Manifest.Bytes != 42 || len(res.Manifest.Schema) != 1 || res.Manifest.Schema[0].Type != "string" {
+		t.Errorf("Expected the manifest, got %+v", res.Manifest)
+	}
+}
+
+func TestReceipts(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	h := New(&mockJobService{withUserID: userID}, policy.New())
+
+	request := func(userID uuid.UUID) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest("GET", "/jobs/"+jobID.String()+"/receipts", nil).WithContext(
+			auth.WithUser(context.Background(), &user.User{
+				ID: userID,
+			}),
+		)
+		c.Params = gin.Params{{Key: "id", Value: jobID.String()}}
+
+		h.Receipts(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := request(userID)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		var res usageDto.ListReceiptsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(res.Receipts) != 1 || res.Receipts[0].Rows != 1 {
+			t.Errorf("Expected the receipt, got %+v", res.Receipts)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		if w := request(uuid.New()); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestCancel(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	request := func(h *Handler, userID uuid.UUID) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest("POST", "/jobs/"+jobID.String()+"/cancel", nil).WithContext(
+			auth.WithUser(context.Background(), &user.User{
+				ID: userID,
+			}),
+		)
+		c.Params = gin.Params{{Key: "id", Value: jobID.String()}}
+
+		h.Cancel(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID}, policy.New())
+
+		w := request(h, userID)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		var res dto.CancelJobResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if res.Job.Status != string(job.CancelledStatus) {
+			t.Errorf("Expected %s, got %s", job.CancelledStatus, res.Job.Status)
+		}
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID, withStatus: job.CompletedStatus}, policy.New())
+
+		if w := request(h, userID); w.Code != 409 {
+			t.Errorf("Expected 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		h := New(&mockJobService{withUserID: userID}, policy.New())
+
+		if w := request(h, uuid.New()); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestEvents(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	h := New(&mockJobService{withUserID: userID}, policy.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request = httptest.NewRequest("GET", "/jobs/"+jobID.String()+"/events", nil).WithContext(
+		auth.WithUser(context.Background(), &user.User{
+			ID: userID,
+		}),
+	)
+	c.Params = gin.Params{{Key: "id", Value: jobID.String()}}
+
+	h.Events(c)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %s", ct)
+	}
+
+	body := w.Body.String()
+
+	if strings.Count(body, "event:progress") != 2 {
+		t.Errorf("Expected 2 progress events, got %q", body)
+	}
+
+	if !strings.Contains(body, `"status":"completed"`) {
+		t.Errorf("Expected the last event to complete the job, got %q", body)
+	}
+}
@@ -1,7 +1,12 @@
 package mysql
 
-import "database/sql"
+import (
+	"database/sql"
+	"sort"
+)
 
+// migrations run in the order of their names, so tables are altered after
+// they were created
 var migrations = map[string]string{
 	"create_jobs_table": `
 		CREATE TABLE IF NOT EXISTS jobs (
@@ -12,6 +17,43 @@ var migrations = map[string]string{
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 		);`,
+	"create_job_coverage_table": `
+		CREATE TABLE IF NOT EXISTS job_coverage (
+			job_id VARCHAR(36) PRIMARY KEY,
+			planned_shards INT NOT NULL DEFAULT 0,
+			answered_shards INT NOT NULL DEFAULT 0,
+			gaps JSON NOT NULL
+		);`,
+	"create_job_progress_table": `
+		CREATE TABLE IF NOT EXISTS job_progress (
+			job_id VARCHAR(36) PRIMARY KEY,
+			failed_shards INT NOT NULL DEFAULT 0,
+			rows_collected INT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL,
+			started_at TIMESTAMP(6) NULL,
+			finished_at TIMESTAMP(6) NULL
+		);`,
+	"create_job_billing_table": `
+		CREATE TABLE IF NOT EXISTS job_billing (
+			job_id VARCHAR(36) PRIMARY KEY,
+			escrowed DOUBLE NOT NULL DEFAULT 0,
+			cost DOUBLE NOT NULL DEFAULT 0
+		);`,
+	"create_job_leases_table": `
+		CREATE TABLE IF NOT EXISTS job_leases (
+			job_id VARCHAR(36) PRIMARY KEY,
+			owner VARCHAR(255) NOT NULL,
+			expires_at TIMESTAMP(6) NOT NULL
+		);`,
+
+	// escrows and costs are kept in millionths of a token like the ledger
+	// they are settled against
+	"migrate_job_billing_1_micro_tokens": `
+		UPDATE job_billing SET escrowed = ROUND(escrowed * 1000000), cost = ROUND(cost * 1000000);`,
+	"migrate_job_billing_2_bigint": `
+		ALTER TABLE job_billing
+			MODIFY escrowed BIGINT NOT NULL DEFAULT 0,
+			MODIFY cost BIGINT NOT NULL DEFAULT 0;`,
 }
 
 func ExecuteMigrations(db *sql.DB) error {
@@ -23,7 +65,15 @@ func ExecuteMigrations(db *sql.DB) error {
 		return err
 	}
 
-	for name, migration := range migrations {
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		migration := migrations[name]
 
 		// check if migration has already been executed
 		var count int
@@ -2,26 +2,42 @@ package mem
 
 import (
 	"juno/pkg/api/extractor/job"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+type lease struct {
+	owner     string
+	expiresAt time.Time
+}
+
 type Repository struct {
-	jobs map[uuid.UUID]job.Job
+	mu     sync.Mutex
+	jobs   map[uuid.UUID]job.Job
+	leases map[uuid.UUID]lease
 }
 
 func New() *Repository {
 	return &Repository{
-		jobs: make(map[uuid.UUID]job.Job),
+		jobs:   make(map[uuid.UUID]job.Job),
+		leases: make(map[uuid.UUID]lease),
 	}
 }
 
 func (r *Repository) Create(q *job.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.jobs[q.ID] = *q
 	return nil
 }
 
 func (r *Repository) Get(id uuid.UUID) (*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	q, ok := r.jobs[id]
 	if !ok {
 		return nil, job.ErrNotFound
@@ -31,6 +47,9 @@ func (r *Repository) Get(id uuid.UUID) (*job.Job, error) {
 }
 
 func (r *Repository) ListByStatus(status job.JobStatus) ([]*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	var jobs []*job.Job
 	for _, q := range r.jobs {
 		if q.Status == status {
@@ -42,6 +61,9 @@ func (r *Repository) ListByStatus(status job.JobStatus) ([]*job.Job, error) {
 }
 
 func (r *Repository) ListByUserID(userID uuid.UUID) ([]*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	var jobs []*job.Job
 	for _, q := range r.jobs {
 		if q.UserID == userID {
@@ -53,6 +75,106 @@ func (r *Repository) ListByUserID(userID uuid.UUID) ([]*job.Job, error) {
 }
 
 func (r *Repository) Update(q *job.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.jobs[q.ID] = *q
 	return nil
 }
+
+func (r *Repository) UpdateProgress(q *job.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[q.ID]
+	if !ok {
+		return job.ErrNotFound
+	}
+
+	updated := *q
+	updated.Status = stored.Status
+	r.jobs[q.ID] = updated
+
+	return nil
+}
+
+func (r *Repository) Transition(q *job.Job, from job.JobStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[q.ID]
+	if !ok {
+		return false, job.ErrNotFound
+	}
+
+	if stored.Status != from {
+		return false, nil
+	}
+
+	r.jobs[q.ID] = *q
+
+	return true, nil
+}
+
+func (r *Repository) Claim(owner string, d time.Duration) (*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var claimed *job.Job
+
+	for _, q := range r.jobs {
+		l, leased := r.leases[q.ID]
+		expired := (q.Status == job.RunningStatus || q.Status == job.CancelledStatus) && leased && l.expiresAt.Before(now)
+
+		if q.Status != job.PendingStatus && !expired {
+			continue
+		}
+
+		if claimed == nil || q.CreatedAt.Before(claimed.CreatedAt) {
+			claimed = &q
+		}
+	}
+
+	if claimed == nil {
+		return nil, job.ErrNoJob
+	}
+
+	// cancelled jobs are only claimed to be settled
+	if claimed.Status != job.CancelledStatus {
+		claimed.Status = job.RunningStatus
+	}
+
+	r.jobs[claimed.ID] = *claimed
+	r.leases[claimed.ID] = lease{owner: owner, expiresAt: now.Add(d)}
+
+	return claimed, nil
+}
+
+func (r *Repository) Renew(id uuid.UUID, owner string, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leases[id].owner != owner {
+		return job.ErrLeaseLost
+	}
+
+	r.leases[id] = lease{owner: owner, expiresAt: time.Now().Add(d)}
+
+	return nil
+}
+
+func (r *Repository) Finish(q *job.Job, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leases[q.ID].owner != owner {
+		return job.ErrLeaseLost
+	}
+
+	r.jobs[q.ID] = *q
+	delete(r.leases, q.ID)
+
+	return nil
+}
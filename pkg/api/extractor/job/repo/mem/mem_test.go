@@ -3,6 +3,7 @@ package mem
 import (
 	"juno/pkg/api/extractor/job"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -98,3 +99,160 @@ func Test(t *testing.T) {
 		t.Errorf("Expected 1, got %d", len(list))
 	}
 }
+
+func TestClaim(t *testing.T) {
+	repo := New()
+
+	older := &job.Job{ID: uuid.New(), Status: job.PendingStatus, CreatedAt: time.Now().Add(-time.Minute)}
+	newer := &job.Job{ID: uuid.New(), Status: job.PendingStatus, CreatedAt: time.Now()}
+
+	repo.Create(newer)
+	repo.Create(older)
+
+	claimed, err := repo.Claim("worker-1", time.Minute)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if claimed.ID != older.ID || claimed.Status != job.RunningStatus {
+		t.Errorf("Expected the older job to run, got %+v", claimed)
+	}
+
+	if err := repo.Renew(older.ID, "worker-2", time.Minute); err != job.ErrLeaseLost {
+		t.Errorf("Expected %v, got %v", job.ErrLeaseLost, err)
+	}
+
+	if _, err := repo.Claim("worker-2", time.Minute); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if _, err := repo.Claim("worker-2", time.Minute); err != job.ErrNoJob {
+		t.Errorf("Expected %v, got %v", job.ErrNoJob, err)
+	}
+
+	// an expired lease is claimed again
+	if err := repo.Renew(older.ID, "worker-1", -time.Second); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	claimed, err = repo.Claim("worker-2", time.Minute)
+
+	if err != nil || claimed.ID != older.ID {
+		t.Fatalf("Expected the expired job, got %v, %v", claimed, err)
+	}
+
+	if err := repo.Renew(older.ID, "worker-1", time.Minute); err != job.ErrLeaseLost {
+		t.Errorf("Expected %v, got %v", job.ErrLeaseLost, err)
+	}
+	// a cancelled job is claimed to be settled once its lease expired, and
+	// not after it finished
+	cancelled := *claimed
+	cancelled.Status = job.CancelledStatus
+	repo.Update(&cancelled)
+	repo.Renew(older.ID, "worker-2", -time.Second)
+
+	claimed, err = repo.Claim("worker-3", time.Minute)
+
+	if err != nil || claimed.ID != older.ID || claimed.Status != job.CancelledStatus {
+		t.Fatalf("Expected the cancelled job, got %v, %v", claimed, err)
+	}
+
+	if err := repo.Finish(claimed, "worker-3"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	repo.Renew(older.ID, "worker-3", -time.Second)
+
+	if _, err := repo.Claim("worker-3", time.Minute); err != job.ErrNoJob {
+		t.Errorf("Expected %v, got %v", job.ErrNoJob, err)
+	}
+}
+
+func TestUpdateProgress(t *testing.T) {
+	repo := New()
+
+	q := &job.Job{ID: uuid.New(), Status: job.RunningStatus}
+	repo.Create(q)
+
+	cancelled := *q
+	cancelled.Status = job.CancelledStatus
+	repo.Update(&cancelled)
+
+	q.RowsCollected = 10
+
+	if err := repo.UpdateProgress(q); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	check, _ := repo.Get(q.ID)
+
+	if check.Status != job.CancelledStatus || check.RowsCollected != 10 {
+		t.Errorf("Expected the progress without the status, got %+v", check)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	repo := New()
+
+	q := &job.Job{ID: uuid.New(), Status: job.PendingStatus}
+	repo.Create(q)
+
+	claimed, _ := repo.Claim("worker-1", time.Minute)
+
+	// the lease expires and another worker claims the job
+	repo.Renew(q.ID, "worker-1", -time.Second)
+	repo.Claim("worker-2", time.Minute)
+
+	claimed.Status = job.CompletedStatus
+
+	if err := repo.Finish(claimed, "worker-1"); err != job.ErrLeaseLost {
+		t.Errorf("Expected %v, got %v", job.ErrLeaseLost, err)
+	}
+
+	check, _ := repo.Get(q.ID)
+
+	if check.Status != job.RunningStatus {
+		t.Errorf("Expected %s, got %s", job.RunningStatus, check.Status)
+	}
+
+	if err := repo.Finish(claimed, "worker-2"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	check, _ = repo.Get(q.ID)
+
+	if check.Status != job.CompletedStatus {
+		t.Errorf("Expected %s, got %s", job.CompletedStatus, check.Status)
+	}
+}
+
+func TestTransition(t *testing.T) {
+	repo := New()
+
+	q := &job.Job{ID: uuid.New(), Status: job.PendingStatus}
+	repo.Create(q)
+
+	repo.Claim("worker", time.Minute)
+
+	cancelled := *q
+	cancelled.Status = job.CancelledStatus
+
+	ok, err := repo.Transition(&cancelled, job.PendingStatus)
+
+	if err != nil || ok {
+		t.Errorf("Expected a claimed job not to transition from pending, got %v and %v", ok, err)
+	}
+
+	ok, err = repo.Transition(&cancelled, job.RunningStatus)
+
+	if err != nil || !ok {
+		t.Errorf("Expected the job to transition from running, got %v and %v", ok, err)
+	}
+
+	check, _ := repo.Get(q.ID)
+
+	if check.Status != job.CancelledStatus {
+		t.Errorf("Expected %s, got %s", job.CancelledStatus, check.Status)
+	}
+}
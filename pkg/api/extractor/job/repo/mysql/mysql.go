@@ -2,11 +2,18 @@ package mysql
 
 import (
 	"database/sql"
+	"encoding/json"
 	"juno/pkg/api/extractor/job"
+	"juno/pkg/api/transaction"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// the coverage, progress and billing live in their own tables so jobs created
+// before they existed read as not yet planned
+const selectJobs = "SELECT j.id, j.user_id, j.strategy_id, j.status, c.planned_shards, c.answered_shards, c.gaps, p.failed_shards, p.rows_collected, p.error, p.started_at, p.finished_at, b.escrowed, b.cost, j.created_at, j.updated_at FROM jobs j LEFT JOIN job_coverage c ON c.job_id = j.id LEFT JOIN job_progress p ON p.job_id = j.id LEFT JOIN job_billing b ON b.job_id = j.id"
+
 type Repository struct {
 	db *sql.DB
 }
@@ -17,10 +24,74 @@ func New(db *sql.DB) *Repository {
 	}
 }
 
-func (r *Repository) Get(id uuid.UUID) (*job.Job, error) {
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*job.Job, error) {
 	var j job.Job
+	var planned, answered, failed, rows sql.NullInt64
+	var gaps, jobErr sql.NullString
+	var started, finished sql.NullTime
+	var escrowed, cost sql.NullInt64
+
+	err := row.Scan(&j.ID, &j.UserID, &j.StrategyID, &j.Status, &planned, &answered, &gaps, &failed, &rows, &jobErr, &started, &finished, &escrowed, &cost, &j.CreatedAt, &j.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	j.PlannedShards = int(planned.Int64)
+	j.AnsweredShards = int(answered.Int64)
+	j.FailedShards = int(failed.Int64)
+	j.RowsCollected = int(rows.Int64)
+	j.Error = jobErr.String
+	j.Escrowed = transaction.Amount(escrowed.Int64)
+	j.Cost = transaction.Amount(cost.Int64)
+
+	if started.Valid {
+		j.StartedAt = &started.Time
+	}
+
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+
+	if gaps.Valid {
+		if err := json.Unmarshal([]byte(gaps.String), &j.Gaps); err != nil {
+			return nil, err
+		}
+	}
+
+	return &j, nil
+}
+
+func (r *Repository) query(query string, args ...any) ([]*job.Job, error) {
+	rows, err := r.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var jobs []*job.Job
+
+	for rows.Next() {
+		j, err := scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
 
-	err := r.db.QueryRow("SELECT id, user_id, strategy_id, status, created_at, updated_at FROM jobs WHERE id = ?", id).Scan(&j.ID, &j.UserID, &j.StrategyID, &j.Status, &j.CreatedAt, &j.UpdatedAt)
+func (r *Repository) Get(id uuid.UUID) (*job.Job, error) {
+	j, err := scan(r.db.QueryRow(selectJobs+" WHERE j.id = ?", id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -29,73 +100,236 @@ func (r *Repository) Get(id uuid.UUID) (*job.Job, error) {
 		return nil, err
 	}
 
-	return &j, nil
+	return j, nil
 }
 
 func (r *Repository) Create(j *job.Job) error {
-	_, err := r.db.Exec("INSERT INTO jobs (id, user_id, strategy_id, status) VALUES (?, ?, ?, ?)", j.ID, j.UserID, j.StrategyID, j.Status)
+	tx, err := r.db.Begin()
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO jobs (id, user_id, strategy_id, status) VALUES (?, ?, ?, ?)", j.ID, j.UserID, j.StrategyID, j.Status)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO job_billing (job_id, escrowed, cost) VALUES (?, ?, ?)", j.ID, j.Escrowed, j.Cost)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *Repository) ListByUserID(userID uuid.UUID) ([]*job.Job, error) {
-	rows, err := r.db.Query("SELECT id, user_id, strategy_id, status, created_at, updated_at FROM jobs WHERE user_id = ?", userID)
+	return r.query(selectJobs+" WHERE j.user_id = ?", userID)
+}
+
+func (r *Repository) ListByStatus(status job.JobStatus) ([]*job.Job, error) {
+	return r.query(selectJobs+" WHERE j.status = ?", status)
+}
+
+// execer is a connection or a transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (r *Repository) Update(j *job.Job) error {
+	return update(r.db, j)
+}
+
+func update(e execer, j *job.Job) error {
+	_, err := e.Exec("UPDATE jobs SET status = ? WHERE id = ?", j.Status, j.ID)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var jobs []*job.Job
+	_, err = e.Exec(
+		"INSERT INTO job_billing (job_id, escrowed, cost) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE escrowed = VALUES(escrowed), cost = VALUES(cost)",
+		j.ID, j.Escrowed, j.Cost,
+	)
 
-	for rows.Next() {
-		var j job.Job
+	if err != nil {
+		return err
+	}
 
-		err := rows.Scan(&j.ID, &j.UserID, &j.StrategyID, &j.Status, &j.CreatedAt, &j.UpdatedAt)
+	return updateProgress(e, j)
+}
 
-		if err != nil {
-			return nil, err
-		}
+func (r *Repository) UpdateProgress(j *job.Job) error {
+	return updateProgress(r.db, j)
+}
+
+func updateProgress(e execer, j *job.Job) error {
+	gaps, err := json.Marshal(j.Gaps)
+
+	if err != nil {
+		return err
+	}
 
-		jobs = append(jobs, &j)
+	_, err = e.Exec(
+		"INSERT INTO job_coverage (job_id, planned_shards, answered_shards, gaps) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE planned_shards = VALUES(planned_shards), answered_shards = VALUES(answered_shards), gaps = VALUES(gaps)",
+		j.ID, j.PlannedShards, j.AnsweredShards, gaps,
+	)
+
+	if err != nil {
+		return err
 	}
 
-	return jobs, nil
+	_, err = e.Exec(
+		"INSERT INTO job_progress (job_id, failed_shards, rows_collected, error, started_at, finished_at) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE failed_shards = VALUES(failed_shards), rows_collected = VALUES(rows_collected), error = VALUES(error), started_at = VALUES(started_at), finished_at = VALUES(finished_at)",
+		j.ID, j.FailedShards, j.RowsCollected, j.Error, j.StartedAt, j.FinishedAt,
+	)
+
+	return err
 }
 
-func (r *Repository) ListByStatus(status job.JobStatus) ([]*job.Job, error) {
-	rows, err := r.db.Query("SELECT id, user_id, strategy_id, status, created_at, updated_at FROM jobs WHERE status = ?", status)
+// Transition changes the status with a conditional update, which waits for
+// a concurrent Claim of the job and then misses if the job is running.
+func (r *Repository) Transition(j *job.Job, from job.JobStatus) (bool, error) {
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE jobs SET status = ? WHERE id = ? AND status = ?", j.Status, j.ID, from)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	if n == 0 {
+		return false, nil
+	}
+
+	if err := update(tx, j); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Claim locks the job row it picks and skips the ones other API instances
+// hold, so concurrent claims never return the same job.
+func (r *Repository) Claim(owner string, lease time.Duration) (*job.Job, error) {
+	tx, err := r.db.Begin()
 
 	if err != nil {
 		return nil, err
 	}
 
-	var jobs []*job.Job
+	defer tx.Rollback()
 
-	for rows.Next() {
-		var j job.Job
+	var id uuid.UUID
 
-		err := rows.Scan(&j.ID, &j.UserID, &j.StrategyID, &j.Status, &j.CreatedAt, &j.UpdatedAt)
+	// a cancelled job keeps its lease until its run settled it
+	err = tx.QueryRow(
+		"SELECT j.id FROM jobs j LEFT JOIN job_leases l ON l.job_id = j.id WHERE j.status = ? OR (j.status IN (?, ?) AND l.expires_at < NOW(6)) ORDER BY j.created_at LIMIT 1 FOR UPDATE OF j SKIP LOCKED",
+		job.PendingStatus, job.RunningStatus, job.CancelledStatus,
+	).Scan(&id)
 
-		if err != nil {
-			return nil, err
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, job.ErrNoJob
 		}
+		return nil, err
+	}
+
+	// cancelled jobs are only claimed to be settled
+	if _, err := tx.Exec("UPDATE jobs SET status = ? WHERE id = ? AND status <> ?", job.RunningStatus, id, job.CancelledStatus); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO job_leases (job_id, owner, expires_at) VALUES (?, ?, NOW(6) + INTERVAL ? MICROSECOND) ON DUPLICATE KEY UPDATE owner = VALUES(owner), expires_at = VALUES(expires_at)",
+		id, owner, lease.Microseconds(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
 
-		jobs = append(jobs, &j)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
-	return jobs, nil
+	return r.Get(id)
 }
 
-func (r *Repository) Update(j *job.Job) error {
-	_, err := r.db.Exec("UPDATE jobs SET status = ? WHERE id = ?", j.Status, j.ID)
+func (r *Repository) Renew(id uuid.UUID, owner string, lease time.Duration) error {
+	res, err := r.db.Exec(
+		"UPDATE job_leases SET expires_at = NOW(6) + INTERVAL ? MICROSECOND WHERE job_id = ? AND owner = ?",
+		lease.Microseconds(), id, owner,
+	)
 
 	if err != nil {
 		return err
 	}
 
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return job.ErrLeaseLost
+	}
+
 	return nil
 }
+
+// Finish locks the job's lease while it stores the job, so an owner that
+// lost the lease can not overwrite the run of the owner that claimed it. The
+// lease is dropped with it.
+func (r *Repository) Finish(j *job.Job, owner string) error {
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var holder string
+
+	err = tx.QueryRow("SELECT owner FROM job_leases WHERE job_id = ? FOR UPDATE", j.ID).Scan(&holder)
+
+	if err == sql.ErrNoRows || (err == nil && holder != owner) {
+		return job.ErrLeaseLost
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := update(tx, j); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_leases WHERE job_id = ?", j.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
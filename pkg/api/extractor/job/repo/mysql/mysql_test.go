@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 	"testing"
+	"time"
 
 	"juno/pkg/api/extractor/job"
 	"juno/pkg/api/extractor/job/migration/mysql"
@@ -251,3 +252,282 @@ func TestUpdate(t *testing.T) {
 		}
 	})
 }
+
+func TestTransition(t *testing.T) {
+	db := setupDB(t)
+	repo := New(db)
+
+	j := &job.Job{
+		ID:     uuid.New(),
+		Status: job.RunningStatus,
+	}
+
+	repo.Create(j)
+
+	defer db.Exec("DELETE FROM jobs WHERE id = ?", j.ID)
+
+	j.Status = job.CancelledStatus
+
+	ok, err := repo.Transition(j, job.PendingStatus)
+
+	if err != nil || ok {
+		t.Errorf("Expected a running job not to transition from pending, got %v and %v", ok, err)
+	}
+
+	ok, err = repo.Transition(j, job.RunningStatus)
+
+	if err != nil || !ok {
+		t.Errorf("Expected the job to transition from running, got %v and %v", ok, err)
+	}
+
+	check, _ := repo.Get(j.ID)
+
+	if check == nil || check.Status != job.CancelledStatus {
+		t.Errorf("Expected %s, got %+v", job.CancelledStatus, check)
+	}
+}
+
+func TestUpdateCoverage(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db := setupDB(t)
+		repo := New(db)
+
+		j := &job.Job{
+			ID: uuid.New(),
+		}
+
+		err := repo.Create(j)
+
+		defer db.Exec("DELETE FROM jobs WHERE id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_coverage WHERE job_id = ?", j.ID)
+
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		j.PlannedShards = 90
+		j.AnsweredShards = 80
+		j.Gaps = [][2]int{{90, 10}}
+
+		if err := repo.Update(j); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		check, err := repo.Get(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check.PlannedShards != 90 || check.AnsweredShards != 80 || len(check.Gaps) != 1 || check.Gaps[0] != [2]int{90, 10} {
+			t.Errorf("Expected the coverage to be stored, got %+v", check)
+		}
+	})
+}
+
+func TestClaim(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db := setupDB(t)
+		repo := New(db)
+
+		j := &job.Job{
+			ID:     uuid.New(),
+			Status: job.PendingStatus,
+		}
+
+		err := repo.Create(j)
+
+		defer db.Exec("DELETE FROM jobs WHERE id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_leases WHERE job_id = ?", j.ID)
+
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		// older pending jobs left in the test database are claimed first
+		for {
+			claimed, err := repo.Claim("worker-1", time.Minute)
+
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if claimed.ID == j.ID {
+				break
+			}
+		}
+
+		check, err := repo.Get(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check.Status != job.RunningStatus {
+			t.Errorf("Expected %s, got %s", job.RunningStatus, check.Status)
+		}
+
+		if err := repo.Renew(j.ID, "worker-1", time.Minute); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		if err := repo.Renew(j.ID, "worker-2", time.Minute); err != job.ErrLeaseLost {
+			t.Errorf("Expected %v, got %v", job.ErrLeaseLost, err)
+		}
+
+		check.Status = job.CompletedStatus
+
+		if err := repo.Finish(check, "worker-2"); err != job.ErrLeaseLost {
+			t.Errorf("Expected %v, got %v", job.ErrLeaseLost, err)
+		}
+
+		if err := repo.Finish(check, "worker-1"); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		check, err = repo.Get(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check.Status != job.CompletedStatus {
+			t.Errorf("Expected %s, got %s", job.CompletedStatus, check.Status)
+		}
+	})
+
+	t.Run("cancelled with an expired lease", func(t *testing.T) {
+		db := setupDB(t)
+		repo := New(db)
+
+		j := &job.Job{
+			ID:     uuid.New(),
+			Status: job.CancelledStatus,
+		}
+
+		err := repo.Create(j)
+
+		defer db.Exec("DELETE FROM jobs WHERE id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_leases WHERE job_id = ?", j.ID)
+
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		// the run of the job was lost before it settled it
+		db.Exec("INSERT INTO job_leases (job_id, owner, expires_at) VALUES (?, ?, NOW(6) - INTERVAL 1 SECOND)", j.ID, "lost")
+
+		for {
+			claimed, err := repo.Claim("worker-1", time.Minute)
+
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if claimed.ID == j.ID {
+				if claimed.Status != job.CancelledStatus {
+					t.Errorf("Expected %s, got %s", job.CancelledStatus, claimed.Status)
+				}
+				break
+			}
+		}
+
+		if err := repo.Finish(j, "worker-1"); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := repo.Renew(j.ID, "worker-1", time.Minute); err != job.ErrLeaseLost {
+			t.Errorf("Expected the lease dropped, got %v", err)
+		}
+	})
+}
+
+func TestUpdateProgress(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db := setupDB(t)
+		repo := New(db)
+
+		j := &job.Job{
+			ID:     uuid.New(),
+			Status: job.RunningStatus,
+		}
+
+		err := repo.Create(j)
+
+		defer db.Exec("DELETE FROM jobs WHERE id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_coverage WHERE job_id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_progress WHERE job_id = ?", j.ID)
+
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		db.Exec("UPDATE jobs SET status = ? WHERE id = ?", job.CancelledStatus, j.ID)
+
+		started := time.Now().UTC().Truncate(time.Microsecond)
+
+		j.FailedShards = 2
+		j.RowsCollected = 10
+		j.Error = "2 shards failed"
+		j.StartedAt = &started
+
+		if err := repo.UpdateProgress(j); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, err := repo.Get(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check.Status != job.CancelledStatus {
+			t.Errorf("Expected %s, got %s", job.CancelledStatus, check.Status)
+		}
+
+		if check.FailedShards != 2 || check.RowsCollected != 10 || check.Error != j.Error || check.StartedAt == nil || check.FinishedAt != nil {
+			t.Errorf("Expected the progress to be stored, got %+v", check)
+		}
+	})
+}
+
+func TestBilling(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db := setupDB(t)
+		repo := New(db)
+
+		j := &job.Job{
+			ID:       uuid.New(),
+			Status:   job.PendingStatus,
+			Escrowed: 1_500_001,
+		}
+
+		err := repo.Create(j)
+
+		defer db.Exec("DELETE FROM jobs WHERE id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_billing WHERE job_id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_coverage WHERE job_id = ?", j.ID)
+		defer db.Exec("DELETE FROM job_progress WHERE job_id = ?", j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		j.Status = job.CompletedStatus
+		j.Cost = 333_333
+
+		if err := repo.Update(j); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, err := repo.Get(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check.Escrowed != 1_500_001 || check.Cost != 333_333 {
+			t.Errorf("Expected 1.500001 escrowed and a cost of 0.333333, got %s and %s", check.Escrowed, check.Cost)
+		}
+	})
+}
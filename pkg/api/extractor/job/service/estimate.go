@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"juno/pkg/aggregation"
+	"juno/pkg/api/extractor/job/billing"
+	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/api/node"
+	nodeClient "juno/pkg/node/client"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// nodePagesTTL is how long the page counts of nodes are reused across
+// estimates.
+const nodePagesTTL = 5 * time.Minute
+
+// nodePages is how many pages a node holds per assigned shard, and when it
+// said so.
+type nodePages struct {
+	perShard float64
+	at       time.Time
+}
+
+// Estimate plans a job on the strategy the way it would run, without
+// running it. Pages are counted from what the nodes holding the planned
+// shards report, spread evenly over their shards. With sampleShards, the
+// strategy also runs on that many random planned shards to count the rows
+// they return. Strategies with aggregations are not sampled, their single
+// row would be the answer.
+func (s *Service) Estimate(ctx context.Context, strat *strategy.Strategy, sampleShards int) (*strategy.Estimate, error) {
+	if s.nodeService == nil {
+		return nil, fmt.Errorf("no node service to count pages with")
+	}
+
+	ranges, err := s.ranagService.GroupByRange()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := targetShards(strat)
+	p := planCover(ranges, targets)
+
+	e := &strategy.Estimate{
+		Shards: p.planned,
+		Gaps:   p.gaps,
+		Escrow: s.pricing.Estimate(countShards(targets)),
+	}
+
+	pages, err := s.shardPages(p)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Pages = pages
+	e.Evaluations = pages * len(strat.Fields)
+
+	// pages without any field value set are skipped, and aggregations are
+	// returned as a single row that is not paid for
+	charged := 0
+
+	if len(strat.Fields) > 0 {
+		charged = pages
+	}
+
+	if len(strat.Aggregations) > 0 {
+		e.Rows = 1
+		charged = 0
+	} else {
+		e.Rows = charged
+	}
+
+	e.Cost = min(s.pricing.Cost(p.planned, charged), e.Escrow)
+
+	if sampleShards > 0 && len(p.segments) > 0 && len(strat.Aggregations) == 0 {
+		e.SampleShards, e.SampleRows = s.sample(ctx, strat, p, sampleShards)
+	}
+
+	return e, nil
+}
+
+// shardPages adds up the pages on the planned shards. A shard counts the
+// average of what its nodes hold per shard, and shards no node reported on
+// count the average of the others.
+func (s *Service) shardPages(p *plan) (int, error) {
+	shards, err := s.nodeService.AllShardsNodes()
+
+	if err != nil {
+		return 0, err
+	}
+
+	perShard := s.pagesPerShard(shards)
+
+	total, known, unknown := 0.0, 0, 0
+
+	for _, seg := range p.segments {
+		for sh := seg.offset; sh < seg.offset+seg.total; sh++ {
+			sum, reported := 0.0, 0
+
+			for _, n := range shards[sh] {
+				if pages, ok := perShard[n.Address]; ok {
+					sum += pages
+					reported++
+				}
+			}
+
+			if reported == 0 {
+				unknown++
+				continue
+			}
+
+			total += sum / float64(reported)
+			known++
+		}
+	}
+
+	if known > 0 {
+		total += total / float64(known) * float64(unknown)
+	}
+
+	return int(math.Round(total)), nil
+}
+
+// pagesPerShard returns how many pages every node holds per assigned shard,
+// by address. Nodes are only asked when their count is older than
+// nodePagesTTL, and left out when they do not answer.
+func (s *Service) pagesPerShard(shards map[int][]*node.Node) map[string]float64 {
+	nodes := map[string]*node.Node{}
+
+	for _, ns := range shards {
+		for _, n := range ns {
+			nodes[n.Address] = n
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	perShard := map[string]float64{}
+	now := time.Now()
+
+	s.nodePagesLock.Lock()
+	for addr, pages := range s.nodePages {
+		if _, ok := nodes[addr]; ok && now.Sub(pages.at) < nodePagesTTL {
+			perShard[addr] = pages.perShard
+		}
+	}
+	s.nodePagesLock.Unlock()
+
+	// the nodes to ask are listed before any answer is written
+	fetch := map[string]int{}
+
+	for addr, n := range nodes {
+		if _, ok := perShard[addr]; ok {
+			continue
+		}
+
+		assigned := 0
+		for _, a := range n.ShardAssignments {
+			assigned += a[1]
+		}
+
+		if assigned > 0 {
+			fetch[addr] = assigned
+		}
+	}
+
+	for addr, assigned := range fetch {
+		wg.Add(1)
+		go func(addr string, assigned int) {
+			defer wg.Done()
+
+			res, err := nodeClient.SendInfoRequest(addr)
+
+			if err != nil || res.Info == nil {
+				fmt.Printf("failed to get the info of node %s: %v\n", addr, err)
+				return
+			}
+
+			pages := nodePages{perShard: float64(res.Info.PageCount) / float64(assigned), at: now}
+
+			s.nodePagesLock.Lock()
+			s.nodePages[addr] = pages
+			s.nodePagesLock.Unlock()
+
+			mu.Lock()
+			perShard[addr] = pages.perShard
+			mu.Unlock()
+		}(addr, assigned)
+	}
+
+	wg.Wait()
+
+	return perShard
+}
+
+// sample runs the strategy on up to n random planned shards, each on its
+// segment's ranags, and returns the shards and how many rows they returned.
+func (s *Service) sample(ctx context.Context, strat *strategy.Strategy, p *plan, n int) ([]int, int) {
+	picked := map[int]bool{}
+
+	for len(picked) < min(n, p.planned) {
+		picked[rand.Intn(p.planned)] = true
+	}
+
+	var segments []*segment
+
+	// the picked indexes are shards counted along the segments
+	for i := range picked {
+		for _, seg := range p.segments {
+			if i < seg.total {
+				segments = append(segments, &segment{offset: seg.offset + i, total: 1, ranags: seg.ranags})
+				break
+			}
+
+			i -= seg.total
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].offset < segments[j].offset
+	})
+
+	res := &result{
+		partials: aggregation.NewPartials(strat.Aggregations),
+		meter:    billing.NewMeter(billing.Pricing{}),
+	}
+
+	var wg sync.WaitGroup
+
+	for _, seg := range segments {
+		wg.Add(1)
+		go func(seg *segment) {
+			defer wg.Done()
+			s.querySegment(ctx, strat, seg, res)
+		}(seg)
+	}
+
+	wg.Wait()
+
+	shards := make([]int, len(segments))
+	for i, seg := range segments {
+		shards[i] = seg.offset
+	}
+
+	return shards, len(res.data)
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"juno/pkg/api/extractor/field"
+	"juno/pkg/api/extractor/job/billing"
+	"juno/pkg/api/extractor/job/repo/mem"
+	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/api/node"
+	"juno/pkg/api/ranag"
+	"juno/pkg/api/transaction"
+	"juno/pkg/node/info"
+	"testing"
+
+	nodeRepo "juno/pkg/api/node/repo/mem"
+	nodeService "juno/pkg/api/node/service"
+	ranagRepo "juno/pkg/api/ranag/repo/mem"
+	ranagService "juno/pkg/api/ranag/service"
+	infoDto "juno/pkg/node/info/dto"
+	ranagDto "juno/pkg/ranag/dto"
+
+	"github.com/google/uuid"
+	"github.com/h2non/gock"
+)
+
+func TestEstimate(t *testing.T) {
+	pricing := billing.Pricing{
+		ShardPrice:   100,
+		RowPrice:     10,
+		RowsPerShard: 2,
+	}
+
+	setup := func() *Service {
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+		})
+
+		nodeRepo := nodeRepo.New()
+
+		nodeRepo.Create(node.New(uuid.New(), uuid.New(), "node1:9090", [][2]int{{0, 50000}}))
+		nodeRepo.Create(node.New(uuid.New(), uuid.New(), "node2:9090", [][2]int{{50000, 50000}}))
+
+		return New(
+			mem.New(),
+			&mockStrategyService{},
+			ranagService.New(ranagRepo),
+			WithBilling(nil, pricing),
+			WithNodeService(nodeService.New(nodeRepo)),
+		)
+	}
+
+	strat := &strategy.Strategy{
+		ID: uuid.New(),
+		Fields: []*field.Field{
+			{Name: "title", Type: field.FieldTypeString},
+			{Name: "price", Type: field.FieldTypeFloat},
+		},
+	}
+
+	t.Run("plans the job from the page counts", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://node1:9090").
+			Get("/info").
+			Reply(200).
+			JSON(infoDto.NewSuccessInfoResponse(&info.Info{PageCount: 50000}))
+
+		// node2 does not answer, its shards count as many pages as node1's
+		gock.New("http://node2:9090").
+			Get("/info").
+			Reply(500)
+
+		e, err := setup().Estimate(context.Background(), strat, 0)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if e.Shards != 100000 || e.Pages != 100000 || e.Evaluations != 200000 || e.Rows != 100000 {
+			t.Errorf("Expected 100000 shards, pages and rows and 200000 evaluations, got %+v", e)
+		}
+
+		if e.Cost != 11*transaction.Token || e.Escrow != 12*transaction.Token {
+			t.Errorf("Expected a cost of 11 and an escrow of 12, got %s and %s", e.Cost, e.Escrow)
+		}
+
+		if len(e.SampleShards) != 0 || e.SampleRows != 0 {
+			t.Errorf("Expected no sample, got %v", e.SampleShards)
+		}
+	})
+
+	t.Run("samples random shards", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://node1:9090").
+			Get("/info").
+			Reply(200).
+			JSON(infoDto.NewSuccessInfoResponse(&info.Info{PageCount: 50000}))
+
+		gock.New("http://node2:9090").
+			Get("/info").
+			Reply(200).
+			JSON(infoDto.NewSuccessInfoResponse(&info.Info{PageCount: 50000}))
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Times(3).
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{{"title": "charger", "price": 10.0}},
+				[]*ranagDto.ShardStatus{{Status: ranagDto.ShardOK, Attempts: 1}},
+			))
+
+		e, err := setup().Estimate(context.Background(), strat, 3)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(e.SampleShards) != 3 || e.SampleRows != 3 {
+			t.Fatalf("Expected 3 shards and rows, got %v and %v", e.SampleShards, e.SampleRows)
+		}
+
+		for i, sh := range e.SampleShards {
+			if sh < 0 || sh >= 100000 || (i > 0 && sh <= e.SampleShards[i-1]) {
+				t.Errorf("Expected distinct planned shards in order, got %v", e.SampleShards)
+			}
+		}
+	})
+
+	t.Run("reuses the page counts of nodes", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://node1:9090").
+			Get("/info").
+			Reply(200).
+			JSON(infoDto.NewSuccessInfoResponse(&info.Info{PageCount: 50000}))
+
+		gock.New("http://node2:9090").
+			Get("/info").
+			Reply(200).
+			JSON(infoDto.NewSuccessInfoResponse(&info.Info{PageCount: 50000}))
+
+		s := setup()
+
+		s.Estimate(context.Background(), strat, 0)
+		e, err := s.Estimate(context.Background(), strat, 0)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if e.Pages != 100000 {
+			t.Errorf("Expected 100000 pages from the cached counts, got %d", e.Pages)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Expected every node to be asked once")
+		}
+	})
+
+	t.Run("without nodes", func(t *testing.T) {
+		s := New(mem.New(), &mockStrategyService{}, nil)
+
+		if _, err := s.Estimate(context.Background(), strat, 0); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+}
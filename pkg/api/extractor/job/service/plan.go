@@ -0,0 +1,112 @@
+package service
+
+import (
+	"juno/pkg/api/ranag"
+	"sort"
+)
+
+// segment is a run of shards a job queries on one ranag. The ranags all
+// cover the whole run and are tried in order.
+type segment struct {
+	offset int
+	total  int
+	ranags []*ranag.Ranag
+}
+
+type plan struct {
+	segments []*segment
+	// gaps are the requested shard ranges no ranag covers, as offset and
+	// total
+	gaps    [][2]int
+	planned int
+}
+
+// planCover splits the requested shard ranges into segments that do not
+// overlap, each on the ranag assignment reaching furthest from where the
+// previous segment ended, so as few ranag requests as possible are sent.
+// Every ranag whose assignment covers a segment is kept to fall back to.
+func planCover(ranges map[[2]int][]*ranag.Ranag, requested [][2]int) *plan {
+	assignments := make([][2]int, 0, len(ranges))
+	for r := range ranges {
+		assignments = append(assignments, r)
+	}
+
+	// the widest assignment first among equal ones, so plans are stable
+	sort.Slice(assignments, func(i, j int) bool {
+		if assignments[i][0] != assignments[j][0] {
+			return assignments[i][0] < assignments[j][0]
+		}
+		return assignments[i][1] > assignments[j][1]
+	})
+
+	p := &plan{}
+
+	for _, req := range requested {
+		pos, end := req[0], req[0]+req[1]
+
+		for pos < end {
+			best := -1
+			next := end
+
+			for i, a := range assignments {
+				if a[0] <= pos && a[0]+a[1] > pos {
+					if best == -1 || a[0]+a[1] > assignments[best][0]+assignments[best][1] {
+						best = i
+					}
+				} else if a[0] > pos && a[0] < next {
+					next = a[0]
+				}
+			}
+
+			if best == -1 {
+				p.gaps = append(p.gaps, [2]int{pos, next - pos})
+				pos = next
+				continue
+			}
+
+			segEnd := min(assignments[best][0]+assignments[best][1], end)
+			seg := &segment{offset: pos, total: segEnd - pos}
+
+			seg.ranags = append(seg.ranags, sortedByAddress(ranges[assignments[best]])...)
+
+			var fallbacks []*ranag.Ranag
+			for i, a := range assignments {
+				if i != best && a[0] <= pos && a[0]+a[1] >= segEnd {
+					fallbacks = append(fallbacks, ranges[a]...)
+				}
+			}
+
+			for _, r := range sortedByAddress(fallbacks) {
+				if !containsRanag(seg.ranags, r) {
+					seg.ranags = append(seg.ranags, r)
+				}
+			}
+
+			p.segments = append(p.segments, seg)
+			p.planned += seg.total
+			pos = segEnd
+		}
+	}
+
+	return p
+}
+
+func sortedByAddress(ranags []*ranag.Ranag) []*ranag.Ranag {
+	sorted := append([]*ranag.Ranag(nil), ranags...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Address < sorted[j].Address
+	})
+
+	return sorted
+}
+
+func containsRanag(ranags []*ranag.Ranag, r *ranag.Ranag) bool {
+	for _, c := range ranags {
+		if c.ID == r.ID {
+			return true
+		}
+	}
+
+	return false
+}
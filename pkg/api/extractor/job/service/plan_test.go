@@ -0,0 +1,108 @@
+package service
+
+import (
+	"juno/pkg/api/ranag"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newRanag(address string) *ranag.Ranag {
+	return &ranag.Ranag{ID: uuid.New(), Address: address}
+}
+
+func TestPlanCover(t *testing.T) {
+	a, b, c := newRanag("a:6060"), newRanag("b:6060"), newRanag("c:6060")
+
+	t.Run("should cover overlapping ranges without overlap", func(t *testing.T) {
+		p := planCover(map[[2]int][]*ranag.Ranag{
+			{0, 60}:  {a},
+			{40, 60}: {b},
+			{50, 10}: {c},
+		}, [][2]int{{0, 100}})
+
+		if len(p.gaps) != 0 {
+			t.Errorf("expected no gaps, got %v", p.gaps)
+		}
+
+		if p.planned != 100 {
+			t.Errorf("expected 100 planned shards, got %d", p.planned)
+		}
+
+		if len(p.segments) != 2 {
+			t.Fatalf("expected 2 segments, got %d", len(p.segments))
+		}
+
+		first, second := p.segments[0], p.segments[1]
+
+		if first.offset != 0 || first.total != 60 || first.ranags[0] != a || len(first.ranags) != 1 {
+			t.Errorf("expected shards 0-59 on a, got %+v", first)
+		}
+
+		if second.offset != 60 || second.total != 40 || second.ranags[0] != b {
+			t.Errorf("expected shards 60-99 on b, got %+v", second)
+		}
+	})
+
+	t.Run("should report gaps", func(t *testing.T) {
+		p := planCover(map[[2]int][]*ranag.Ranag{
+			{10, 20}: {a},
+			{50, 20}: {b},
+		}, [][2]int{{0, 100}})
+
+		expected := [][2]int{{0, 10}, {30, 20}, {70, 30}}
+
+		if len(p.gaps) != len(expected) {
+			t.Fatalf("expected gaps %v, got %v", expected, p.gaps)
+		}
+
+		for i, gap := range expected {
+			if p.gaps[i] != gap {
+				t.Errorf("expected gap %v, got %v", gap, p.gaps[i])
+			}
+		}
+
+		if p.planned != 40 {
+			t.Errorf("expected 40 planned shards, got %d", p.planned)
+		}
+	})
+
+	t.Run("should keep the ranags covering a segment to fall back to", func(t *testing.T) {
+		p := planCover(map[[2]int][]*ranag.Ranag{
+			{0, 100}: {b, a},
+			{0, 50}:  {c},
+		}, [][2]int{{0, 100}})
+
+		if len(p.segments) != 1 {
+			t.Fatalf("expected 1 segment, got %d", len(p.segments))
+		}
+
+		ranags := p.segments[0].ranags
+
+		if len(ranags) != 2 || ranags[0] != a || ranags[1] != b {
+			t.Errorf("expected a then b, got %v", ranags)
+		}
+	})
+
+	t.Run("should only plan the requested shards", func(t *testing.T) {
+		p := planCover(map[[2]int][]*ranag.Ranag{
+			{0, 100}: {a},
+		}, [][2]int{{10, 5}, {90, 20}})
+
+		if p.planned != 15 {
+			t.Errorf("expected 15 planned shards, got %d", p.planned)
+		}
+
+		if len(p.gaps) != 1 || p.gaps[0] != [2]int{100, 10} {
+			t.Errorf("expected gap [100 10], got %v", p.gaps)
+		}
+	})
+}
+
+func TestUnanswered(t *testing.T) {
+	runs := unanswered([2]int{0, 6}, map[int]bool{1: true, 2: true, 5: true})
+
+	if len(runs) != 2 || runs[0] != [2]int{0, 1} || runs[1] != [2]int{3, 2} {
+		t.Errorf("expected [[0 1] [3 2]], got %v", runs)
+	}
+}
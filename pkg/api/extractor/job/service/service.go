@@ -1,33 +1,188 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"juno/pkg/aggregation"
 	"juno/pkg/api/extractor/job"
+	"juno/pkg/api/extractor/job/billing"
+	"juno/pkg/api/extractor/job/export"
+	resultStore "juno/pkg/api/extractor/job/store/mem"
 	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/node"
 	"juno/pkg/api/ranag"
+	"juno/pkg/api/transaction"
+	"juno/pkg/api/usage"
 	"juno/pkg/ranag/client"
+	ranagDto "juno/pkg/ranag/dto"
+	"juno/pkg/receipt"
+	"juno/pkg/shard"
 	"os"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	DefaultLease         = time.Minute
+	DefaultPollInterval  = 5 * time.Second
+	DefaultWatchInterval = time.Second
+)
+
 type Service struct {
 	jobRepo         job.Repository
 	strategyService strategy.Service
 	ranagService    ranag.Service
+	resultStore     job.ResultStore
+
+	// jobs are free without an escrow
+	escrow  job.Escrow
+	pricing billing.Pricing
+
+	// nodeService finds who owns the nodes that answered and how many pages
+	// they hold
+	nodeService node.Service
+	// nodePages caches the page counts of nodes for estimates, by address
+	nodePagesLock sync.Mutex
+	nodePages     map[string]nodePages
+
+	// receipts keeps the verified receipts of the work done for jobs
+	receipts job.Receipts
+	// receiptGrace is until when nodes and ranags without a key are paid
+	receiptGrace time.Time
+	// mismatches records the shards whose replicas disagreed on what they
+	// signed when verifying an answer
+	mismatches mismatch.Service
+	// requestID names the requests sent to the ranags, which their receipts
+	// are signed for
+	requestID func() string
+
+	// owner names this instance on the leases of the jobs it claims
+	owner         string
+	lease         time.Duration
+	pollInterval  time.Duration
+	watchInterval time.Duration
+
+	// running cancels the jobs this instance runs, by ID
+	runningLock sync.Mutex
+	running     map[uuid.UUID]context.CancelCauseFunc
 }
 
-func New(jobRepo job.Repository, strategyService strategy.Service, ranagService ranag.Service) *Service {
+func New(jobRepo job.Repository, strategyService strategy.Service, ranagService ranag.Service, opts ...func(s *Service)) *Service {
 
-	return &Service{
+	s := &Service{
 		jobRepo:         jobRepo,
 		strategyService: strategyService,
 		ranagService:    ranagService,
+		resultStore:     resultStore.New(),
+		requestID:       uuid.NewString,
+
+		owner:         newOwner(),
+		lease:         DefaultLease,
+		pollInterval:  DefaultPollInterval,
+		watchInterval: DefaultWatchInterval,
+
+		running:   make(map[uuid.UUID]context.CancelCauseFunc),
+		nodePages: make(map[string]nodePages),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithResultStore sets where the rows of finished jobs are kept. Results are
+// kept in memory otherwise.
+func WithResultStore(store job.ResultStore) func(s *Service) {
+	return func(s *Service) {
+		s.resultStore = store
+	}
+}
+
+// WithBilling makes users pay for their jobs. The estimated cost is escrowed
+// when a job is created, and the actual cost settled when it finishes and
+// credited to the owners of the ranags and nodes that answered, which are
+// found with the node service.
+func WithBilling(escrow job.Escrow, pricing billing.Pricing) func(s *Service) {
+	return func(s *Service) {
+		s.escrow = escrow
+		s.pricing = pricing
+	}
+}
+
+// WithNodeService sets where nodes are looked up, to credit their owners and
+// count their pages.
+func WithNodeService(nodeService node.Service) func(s *Service) {
+	return func(s *Service) {
+		s.nodeService = nodeService
+	}
+}
+
+// WithReceipts keeps the receipts the ranags and nodes that answered a job
+// signed, once verified. Receipts are verified either way and work without a
+// valid receipt is not paid.
+func WithReceipts(receipts job.Receipts) func(s *Service) {
+	return func(s *Service) {
+		s.receipts = receipts
+	}
+}
+
+// WithMismatches reports the shards whose replicas disagreed on the rows
+// they signed when the ranags verified them, so nodes that return other rows
+// than their replicas lose reputation.
+func WithMismatches(mismatches mismatch.Service) func(s *Service) {
+	return func(s *Service) {
+		s.mismatches = mismatches
+	}
+}
+
+// WithReceiptGrace pays the nodes and ranags that have not registered a key
+// until then, without receipts, so their operators have time to register one.
+func WithReceiptGrace(until time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.receiptGrace = until
+	}
+}
+
+// WithLease sets how long a claimed job stays leased without being renewed.
+// Leases are renewed three times per period while the job runs.
+func WithLease(lease time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.lease = lease
 	}
 }
 
+// WithPollInterval sets how long an idle worker waits before claiming again.
+func WithPollInterval(interval time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.pollInterval = interval
+	}
+}
+
+// WithWatchInterval sets how often watched jobs are read for changes.
+func WithWatchInterval(interval time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.watchInterval = interval
+	}
+}
+
+func newOwner() string {
+	host, err := os.Hostname()
+
+	if err != nil {
+		host = "api"
+	}
+
+	return host + "-" + uuid.NewString()
+}
+
 func (s *Service) Get(id uuid.UUID) (*job.Job, error) {
 	return s.jobRepo.Get(id)
 }
@@ -47,9 +202,23 @@ func (s *Service) Create(userID, strategyID uuid.UUID) (*job.Job, error) {
 		Status:     job.PendingStatus,
 	}
 
+	if s.escrow != nil {
+		q.Escrowed = s.pricing.Estimate(countShards(targetShards(e)))
+
+		if err := s.escrow.Escrow(userID, q.ID, q.Escrowed); err != nil {
+			return nil, err
+		}
+	}
+
 	err = s.jobRepo.Create(q)
 
 	if err != nil {
+		if s.escrow != nil {
+			if err := s.escrow.Settle(userID, q.ID, q.Escrowed, 0, nil); err != nil {
+				fmt.Printf("failed to release the escrow of job %s: %v\n", q.ID, err)
+			}
+		}
+
 		return nil, err
 	}
 
@@ -69,112 +238,848 @@ func (s *Service) ListByUserID(userID uuid.UUID) ([]*job.Job, error) {
 	return s.jobRepo.ListByUserID(userID)
 }
 
-func (s *Service) process(j *job.Job) error {
-	strat, err := s.strategyService.Get(j.StrategyID)
+func (s *Service) Results(id uuid.UUID, offset, limit int) ([]map[string]interface{}, int, error) {
+	return s.resultStore.Read(id, offset, limit)
+}
+
+func (s *Service) Receipts(id uuid.UUID) ([]*usage.Receipt, error) {
+	if s.receipts == nil {
+		return nil, nil
+	}
+
+	return s.receipts.ListByJobID(id)
+}
+
+func (s *Service) Manifest(id uuid.UUID) (*job.Manifest, error) {
+	return s.resultStore.Manifest(id)
+}
+
+// Export streams the job's results to w in the columns of its manifest.
+func (s *Service) Export(id uuid.UUID, format job.Format, w io.Writer) error {
+	m, err := s.resultStore.Manifest(id)
+
 	if err != nil {
 		return err
 	}
 
-	ranges, err := s.ranagService.GroupByRange()
+	ew, err := export.NewWriter(w, format, m.Schema)
+
 	if err != nil {
 		return err
 	}
 
-	if len(ranges) == 0 {
-		return fmt.Errorf("no ranges found")
+	if err := s.resultStore.Stream(id, ew.Write); err != nil {
+		return err
 	}
 
-	var (
-		data           []map[string]interface{}
-		totalShardsHit int
-		mu             sync.Mutex
-		wg             sync.WaitGroup
-	)
+	return ew.Close()
+}
 
-	for rval, rs := range ranges {
-		for _, r := range rs {
-			wg.Add(1)
-			go func(rval [2]int, r *ranag.Ranag) {
-				defer wg.Done()
-
-				client := client.New(r.Address)
-				res, err := client.SendRangeAggregationRequest(
-					rval[0],
-					rval[1],
-					strat.Selectors,
-					strat.Fields,
-					strat.Filters,
-				)
-				if err != nil {
-					fmt.Println(err)
-					return
+// result collects what the ranags answered for a job.
+type result struct {
+	mu       sync.Mutex
+	job      *job.Job
+	data     []map[string]interface{}
+	partials aggregation.Partials
+	answered int
+	failed   int
+	meter    *billing.Meter
+	// nodes are the nodes that may answer, by address
+	nodes map[string]*node.Node
+	// grace pays nodes and ranags without a key yet
+	grace bool
+}
+
+// meterAnswer meters a ranag's answer and marks its shards answered. Only
+// proven work is charged: shards whose node did not sign a valid receipt are
+// not, and rows are charged up to what the nodes' receipts count. The
+// ranag's part of the shards a child ranag answered goes to the child. The
+// replicas that verified a shard are paid as shards of their own, without
+// rows. It returns the verified receipts. The result's lock must be held.
+func (s *Service) meterAnswer(res *result, r *ranag.Ranag, requestID string, answer *ranagDto.RangeAggregatorResponse, answered map[int]bool) []*usage.Receipt {
+	var receipts []*usage.Receipt
+
+	earner, rc := res.verifyRanag(r, requestID, answer.Receipt)
+	if rc != nil {
+		receipts = append(receipts, rc)
+	}
+
+	// the receipts the children signed for the runs delegated to them
+	delegations := map[string]*receipt.Receipt{}
+	for _, shard := range answer.Shards {
+		for _, d := range shard.Delegations {
+			delegations[d.Ranag] = d.Receipt
+		}
+	}
+
+	children := map[string]transaction.Earner{}
+	nodes := map[transaction.Earner][]transaction.Earner{}
+	var verifiers []transaction.Earner
+	rows, proven := 0, true
+
+	for _, shard := range answer.Shards {
+		if !shard.Answered() || answered[shard.Shard] {
+			continue
+		}
+
+		answered[shard.Shard] = true
+		res.answered++
+
+		if shard.Verification != nil {
+			vs, rcs := res.verifyVerifiers(requestID, shard)
+			verifiers = append(verifiers, vs...)
+			receipts = append(receipts, rcs...)
+		}
+
+		n, rc := res.verifyNode(requestID, shard.Shard, shard.Node, shard.Receipt)
+
+		if n.OwnerID == uuid.Nil {
+			continue
+		}
+
+		if rc != nil {
+			receipts = append(receipts, rc)
+			rows += rc.Rows
+		} else {
+			// paid without a receipt during the grace period
+			proven = false
+		}
+
+		by := earner
+
+		if shard.Via != "" {
+			child, ok := children[shard.Via]
+
+			if !ok {
+				child, rc = s.verifyChild(res, shard.Via, requestID, delegations[shard.Via])
+				children[shard.Via] = child
+
+				if rc != nil {
+					receipts = append(receipts, rc)
 				}
+			}
 
-				mu.Lock()
-				defer mu.Unlock()
+			if dc := delegations[shard.Via]; child.OwnerID != uuid.Nil && (dc == nil || dc.Covers(shard.Shard)) {
+				by = child
+			}
+		}
 
-				totalShardsHit += rval[1]
-				data = append(data, res.Aggregations...)
+		nodes[by] = append(nodes[by], n)
+	}
 
-			}(rval, r)
-			break
+	charged := len(answer.Aggregations)
+	if proven {
+		charged = min(charged, rows)
+	}
+
+	res.meter.Add(earner, nodes[earner], charged)
+
+	for by, ns := range nodes {
+		if by != earner {
+			res.meter.Add(by, ns, 0)
+		}
+	}
+
+	if len(verifiers) > 0 {
+		res.meter.Add(earner, verifiers, 0)
+	}
+
+	return receipts
+}
+
+// verifyVerifiers returns who earns the parts of the other replicas that
+// verified the shard, and their receipts. Each replica is paid once, and only
+// with a valid receipt of the request and shard like the node that answered.
+func (res *result) verifyVerifiers(requestID string, status *ranagDto.ShardStatus) ([]transaction.Earner, []*usage.Receipt) {
+	var earners []transaction.Earner
+	var receipts []*usage.Receipt
+
+	seen := map[string]bool{status.Node: true}
+
+	// the first answer is the one the shard was answered with
+	for _, a := range status.Verification.Answers[min(1, len(status.Verification.Answers)):] {
+		if seen[a.Node] {
+			continue
+		}
+
+		seen[a.Node] = true
+
+		n, rc := res.verifyNode(requestID, status.Shard, a.Node, a.Receipt)
+
+		if n.OwnerID == uuid.Nil {
+			continue
+		}
+
+		earners = append(earners, n)
+
+		if rc != nil {
+			receipts = append(receipts, rc)
+		}
+	}
+
+	return earners, receipts
+}
+
+// reportMismatches reports the shards of the answer whose replicas disagreed
+// when verifying it. The mismatch service compares only what the nodes
+// signed for the request.
+func (s *Service) reportMismatches(r *ranag.Ranag, requestID string, answer *ranagDto.RangeAggregatorResponse, answered map[int]bool) {
+	if s.mismatches == nil {
+		return
+	}
+
+	for _, shard := range answer.Shards {
+		if !answered[shard.Shard] || shard.Verification == nil || len(shard.Verification.Conflicts()) == 0 {
+			continue
+		}
+
+		if _, err := s.mismatches.Report(r.ID, requestID, shard.Shard, shard.Verification); err != nil && err != mismatch.ErrNoConflict {
+			fmt.Printf("failed to report the mismatch of shard %d: %v\n", shard.Shard, err)
+		}
+	}
+}
+
+// verifyChild returns who earns the ranag part of the shards delegated to the
+// child ranag at the address, and its receipt.
+func (s *Service) verifyChild(res *result, address, requestID string, rc *receipt.Receipt) (transaction.Earner, *usage.Receipt) {
+	child, err := s.ranagService.GetByAddress(address)
+
+	if err != nil {
+		return transaction.Earner{}, nil
+	}
+
+	return res.verifyRanag(child, requestID, rc)
+}
+
+// verifyRanag returns who earns the ranag's part of an answer to the request,
+// and the ranag's receipt. The platform keeps the part when the ranag did not
+// sign a receipt of the request with its registered key, unless it has no key
+// yet during the grace period.
+func (res *result) verifyRanag(r *ranag.Ranag, requestID string, rc *receipt.Receipt) (transaction.Earner, *usage.Receipt) {
+	if r.PublicKey == "" && res.grace {
+		return transaction.Earner{OwnerID: r.OwnerID, SourceID: r.ID}, nil
+	}
+
+	if rc == nil || rc.RequestID != requestID || receipt.Verify(r.PublicKey, rc) != nil {
+		return transaction.Earner{}, nil
+	}
+
+	return transaction.Earner{OwnerID: r.OwnerID, SourceID: r.ID}, newReceipt(usage.RanagSigner, r.ID, r.OwnerID, rc)
+}
+
+// verifyNode returns who earns the part of the node at the address that
+// answered the shard, and the node's receipt. Nobody does when the node is
+// unknown or did not sign a receipt of the request and shard with its
+// registered key, unless it has no key yet during the grace period.
+func (res *result) verifyNode(requestID string, shard int, address string, rc *receipt.Receipt) (transaction.Earner, *usage.Receipt) {
+	n, ok := res.nodes[address]
+
+	if ok && n.PublicKey == "" && res.grace {
+		return transaction.Earner{OwnerID: n.OwnerID, SourceID: n.ID}, nil
+	}
+
+	if !ok || rc == nil || rc.RequestID != requestID || !rc.Covers(shard) || receipt.Verify(n.PublicKey, rc) != nil {
+		return transaction.Earner{}, nil
+	}
+
+	return transaction.Earner{OwnerID: n.OwnerID, SourceID: n.ID}, newReceipt(usage.NodeSigner, n.ID, n.OwnerID, rc)
+}
+
+func newReceipt(signerType usage.SignerType, signerID, ownerID uuid.UUID, rc *receipt.Receipt) *usage.Receipt {
+	return &usage.Receipt{
+		RequestID:  rc.RequestID,
+		SignerType: signerType,
+		SignerID:   signerID,
+		OwnerID:    ownerID,
+		Shards:     rc.Shards,
+		Pages:      rc.Pages,
+		Rows:       rc.Rows,
+		Signature:  rc.Signature,
+		IssuedAt:   rc.IssuedAt,
+	}
+}
+
+// record keeps the verified receipts of the job's work. Receipts of results
+// without a job, such as samples, are not kept.
+func (s *Service) record(j *job.Job, receipts []*usage.Receipt) {
+	if j == nil || s.receipts == nil || len(receipts) == 0 {
+		return
+	}
+
+	for _, rc := range receipts {
+		rc.JobID = j.ID
+	}
+
+	if err := s.receipts.Record(receipts); err != nil {
+		fmt.Printf("failed to record the receipts of job %s: %v\n", j.ID, err)
+	}
+}
+
+// progress records what was collected so far on the job and stores it. The
+// result's lock must be held, which also keeps the updates in order. Results
+// without a job, such as samples, are not recorded.
+func (s *Service) progress(res *result) {
+	if res.job == nil {
+		return
+	}
+
+	res.job.AnsweredShards = res.answered
+	res.job.FailedShards = res.failed
+	res.job.RowsCollected = len(res.data)
+
+	if err := s.jobRepo.UpdateProgress(res.job); err != nil {
+		fmt.Printf("failed to update the progress of job %s: %v\n", res.job.ID, err)
+	}
+}
+
+// process queries the job's strategy and returns the schema and rows of its
+// results. An aggregating strategy returns a single row of the aggregation
+// results. The answers are metered on meter. Cancelling ctx cancels the
+// requests to the ranags.
+func (s *Service) process(ctx context.Context, j *job.Job, meter *billing.Meter) ([]job.Column, []map[string]interface{}, error) {
+	strat, err := s.strategyService.Get(j.StrategyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ranges, err := s.ranagService.GroupByRange()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(ranges) == 0 {
+		return nil, nil, fmt.Errorf("no ranges found")
+	}
+
+	p := planCover(ranges, targetShards(strat))
+
+	j.PlannedShards = p.planned
+	j.Gaps = p.gaps
+
+	for _, gap := range p.gaps {
+		fmt.Printf("no ranag covers shards %d-%d\n", gap[0], gap[0]+gap[1]-1)
+	}
+
+	if len(p.segments) == 0 {
+		return nil, nil, fmt.Errorf("no ranag covers the requested shards")
+	}
+
+	// with aggregations the ranags return partials instead of rows
+	res := &result{
+		job:      j,
+		partials: aggregation.NewPartials(strat.Aggregations),
+		meter:    meter,
+		grace:    time.Now().Before(s.receiptGrace),
+	}
+
+	if s.nodeService != nil {
+		if res.nodes, err = s.nodesByAddress(); err != nil {
+			return nil, nil, err
+		}
+
+		// nodes earn by their reputation
+		for _, n := range res.nodes {
+			meter.Weigh(transaction.Earner{OwnerID: n.OwnerID, SourceID: n.ID}, n.Reputation)
 		}
 	}
 
+	res.mu.Lock()
+	s.progress(res)
+	res.mu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, seg := range p.segments {
+		wg.Add(1)
+		go func(seg *segment) {
+			defer wg.Done()
+			s.querySegment(ctx, strat, seg, res)
+		}(seg)
+	}
+
 	// Wait for all goroutines to complete
 	wg.Wait()
 
-	fmt.Println("Total shards hit: ", totalShardsHit)
+	fmt.Printf("%d of %d planned shards answered\n", j.AnsweredShards, j.PlannedShards)
+
+	if len(strat.Aggregations) > 0 {
+		return export.Schema(strat), []map[string]interface{}{aggregation.Finalize(strat.Aggregations, res.partials)}, nil
+	}
+
+	return export.Schema(strat), res.data, nil
+}
+
+// querySegment queries the segment's ranags in order. The shards a ranag
+// did not answer, or all of them when it failed, are asked of the next one.
+func (s *Service) querySegment(ctx context.Context, strat *strategy.Strategy, seg *segment, res *result) {
+	pending := [][2]int{{seg.offset, seg.total}}
+
+	for _, r := range seg.ranags {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var failed [][2]int
+
+		for _, run := range pending {
+			// the ranag and its nodes sign their receipts for the request
+			requestID := s.requestID()
+
+			answer, err := s.queryRanag(ctx, strat, r, run, requestID)
+
+			if err != nil {
+				fmt.Printf("ranag %s failed shards %d-%d: %v\n", r.Address, run[0], run[0]+run[1]-1, err)
+				failed = append(failed, run)
+				continue
+			}
+
+			answered := map[int]bool{}
+
+			res.mu.Lock()
+			receipts := s.meterAnswer(res, r, requestID, answer, answered)
+			res.data = append(res.data, answer.Aggregations...)
+			res.partials.Merge(strat.Aggregations, answer.Partials)
+			s.progress(res)
+			res.mu.Unlock()
+
+			s.record(res.job, receipts)
+			s.reportMismatches(r, requestID, answer, answered)
+
+			failed = append(failed, unanswered(run, answered)...)
+		}
+
+		if len(failed) == 0 {
+			return
+		}
+
+		pending = failed
+	}
+
+	// no ranag answered the shards left
+	res.mu.Lock()
+	for _, run := range pending {
+		res.failed += run[1]
+	}
+	s.progress(res)
+	res.mu.Unlock()
+}
+
+func (s *Service) queryRanag(ctx context.Context, strat *strategy.Strategy, r *ranag.Ranag, run [2]int, requestID string) (*ranagDto.RangeAggregatorResponse, error) {
+	client := client.New(r.Address)
+
+	if len(strat.Aggregations) > 0 {
+		return client.SendRangeReduceRequestContext(
+			ctx,
+			requestID,
+			run[0],
+			run[1],
+			strat.Selectors,
+			strat.Fields,
+			strat.Filters,
+			strat.Aggregations,
+			strat.Scope,
+		)
+	}
+
+	return client.SendRangeAggregationRequestContext(
+		ctx,
+		requestID,
+		run[0],
+		run[1],
+		strat.Selectors,
+		strat.Fields,
+		strat.Filters,
+		strat.Scope,
+	)
+}
+
+// nodesByAddress maps the address of every node to the node.
+func (s *Service) nodesByAddress() (map[string]*node.Node, error) {
+	shards, err := s.nodeService.AllShardsNodes()
 
-	// Serialize data to JSON
-	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := os.WriteFile("data.json", jsonData, 0644); err != nil {
-		return err
+	byAddress := map[string]*node.Node{}
+
+	for _, nodes := range shards {
+		for _, n := range nodes {
+			byAddress[n.Address] = n
+		}
 	}
 
-	return nil
+	return byAddress, nil
+}
+
+// targetShards are the shard ranges a strategy has to query: the shards of
+// its scope's hosts, or every shard.
+func targetShards(strat *strategy.Strategy) [][2]int {
+	shards, ok := strat.Scope.Shards()
+
+	if !ok {
+		return [][2]int{{0, shard.SHARDS}}
+	}
+
+	var runs [][2]int
+
+	for _, sh := range shards {
+		if last := len(runs) - 1; last >= 0 && runs[last][0]+runs[last][1] == sh {
+			runs[last][1]++
+		} else {
+			runs = append(runs, [2]int{sh, 1})
+		}
+	}
+
+	return runs
+}
+
+func countShards(runs [][2]int) int {
+	count := 0
+
+	for _, run := range runs {
+		count += run[1]
+	}
+
+	return count
 }
 
+// unanswered splits the shards of the run that did not answer into runs.
+func unanswered(run [2]int, answered map[int]bool) [][2]int {
+	var runs [][2]int
+
+	for shard := run[0]; shard < run[0]+run[1]; shard++ {
+		if answered[shard] {
+			continue
+		}
+
+		if last := len(runs) - 1; last >= 0 && runs[last][0]+runs[last][1] == shard {
+			runs[last][1]++
+		} else {
+			runs = append(runs, [2]int{shard, 1})
+		}
+	}
+
+	return runs
+}
+
+// ProcessPending claims and processes jobs until none is pending.
 func (s *Service) ProcessPending() error {
-	jobs, err := s.jobRepo.ListByStatus(job.PendingStatus)
+	processed := 0
 
-	if err != nil {
-		return err
+	for {
+		j, err := s.jobRepo.Claim(s.owner, s.lease)
+
+		if err == job.ErrNoJob {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.run(context.Background(), j); err != nil {
+			return err
+		}
+
+		processed++
 	}
 
-	if len(jobs) == 0 {
+	if processed == 0 {
 		fmt.Println("no pending jobs")
 	}
 
-	for _, j := range jobs {
+	return nil
+}
 
-		j.Status = job.RunningStatus
+// Run claims and processes jobs on the given number of workers until the
+// context is done. Several API instances can run workers on the same jobs.
+func (s *Service) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
 
-		err := s.jobRepo.Update(j)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Service) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		j, err := s.jobRepo.Claim(s.owner, s.lease)
 
 		if err != nil {
-			return err
+			if err != job.ErrNoJob {
+				fmt.Printf("failed to claim a job: %v\n", err)
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(s.pollInterval):
+			}
+
+			continue
 		}
 
-		err = s.process(j)
+		if err := s.run(ctx, j); err != nil {
+			fmt.Printf("job %s: %v\n", j.ID, err)
+		}
+	}
+}
+
+// run processes a claimed job while renewing its lease. When the lease is
+// lost, or the context is done, the job is left to whoever claims it next.
+func (s *Service) run(ctx context.Context, j *job.Job) error {
+	// a cancelled job was claimed because its run was lost before it was
+	// settled, what it metered is lost with it
+	if j.Status == job.CancelledStatus {
+		return s.finish(j, billing.NewMeter(s.pricing))
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	s.runningLock.Lock()
+	s.running[j.ID] = cancel
+	s.runningLock.Unlock()
+
+	defer func() {
+		s.runningLock.Lock()
+		delete(s.running, j.ID)
+		s.runningLock.Unlock()
+	}()
+
+	started := time.Now()
+	j.StartedAt = &started
+	j.FinishedAt = nil
+	j.Error = ""
+
+	go s.renew(ctx, cancel, j.ID)
+
+	meter := billing.NewMeter(s.pricing)
+
+	schema, rows, err := s.process(ctx, j, meter)
+
+	cancelled := errors.Is(context.Cause(ctx), job.ErrCancelled) || s.cancelled(j.ID)
+
+	if ctx.Err() != nil && !cancelled {
+		return fmt.Errorf("job abandoned: %w", context.Cause(ctx))
+	}
+
+	if err == nil && !cancelled {
+		_, err = s.resultStore.Write(j.ID, schema, rows)
+		j.RowsCollected = len(rows)
+	}
+
+	switch {
+	case cancelled:
+		j.Status = job.CancelledStatus
+		j.Error = job.ErrCancelled.Error()
+	case err != nil:
+		fmt.Println(err)
+		j.Status = job.FailedStatus
+		j.Error = err.Error()
+	default:
+		j.Status = job.CompletedStatus
+	}
+
+	return s.finish(j, meter)
+}
+
+// finish settles the job and stores its outcome.
+func (s *Service) finish(j *job.Job, meter *billing.Meter) error {
+	// the run is only settled while it still holds the lease, another
+	// instance that claimed the job settles its own run
+	if err := s.jobRepo.Renew(j.ID, s.owner, s.lease); err != nil {
+		return fmt.Errorf("job abandoned: %w", err)
+	}
+
+	// a job that failed to settle is left to be run again, as nothing was
+	// charged
+	if err := s.settle(j, meter); err != nil {
+		return fmt.Errorf("failed to settle: %w", err)
+	}
+
+	finished := time.Now()
+	j.FinishedAt = &finished
+
+	return s.jobRepo.Finish(j, s.owner)
+}
+
+// settle charges the job what was metered, up to what was escrowed. Failed
+// jobs are not charged, cancelled ones pay for what answered before.
+func (s *Service) settle(j *job.Job, meter *billing.Meter) error {
+	if s.escrow == nil {
+		return nil
+	}
+
+	var (
+		cost     transaction.Amount
+		earnings map[transaction.Earner]transaction.Amount
+	)
+
+	if j.Status != job.FailedStatus {
+		cost, earnings = meter.Settlement(j.Escrowed)
+	}
+
+	if err := s.escrow.Settle(j.UserID, j.ID, j.Escrowed, cost, earnings); err != nil {
+		return err
+	}
+
+	j.Cost = cost
+
+	return nil
+}
+
+// cancelled reports whether the job was cancelled, possibly on another
+// instance.
+func (s *Service) cancelled(id uuid.UUID) bool {
+	j, err := s.jobRepo.Get(id)
+
+	return err == nil && j.Status == job.CancelledStatus
+}
+
+func (s *Service) renew(ctx context.Context, cancel context.CancelCauseFunc, id uuid.UUID) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := s.jobRepo.Renew(id, s.owner, s.lease)
+
+		if err == job.ErrLeaseLost {
+			cancel(err)
+			return
+		}
 
 		if err != nil {
-			fmt.Println(err)
-			j.Status = job.FailedStatus
-		} else {
-			j.Status = job.CompletedStatus
+			fmt.Printf("failed to renew the lease of job %s: %v\n", id, err)
+			continue
+		}
+
+		// jobs cancelled on another instance are noticed here
+		if s.cancelled(id) {
+			cancel(job.ErrCancelled)
+			return
 		}
+	}
+}
 
-		err = s.jobRepo.Update(j)
+func (s *Service) Cancel(id uuid.UUID) (*job.Job, error) {
+	for {
+		j, err := s.jobRepo.Get(id)
 
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if j.Status.Finished() {
+			return nil, job.ErrFinished
+		}
+
+		from := *j
+		finished := time.Now()
+
+		j.Status = job.CancelledStatus
+		j.Error = job.ErrCancelled.Error()
+		j.FinishedAt = &finished
+
+		// a worker may claim or finish the job meanwhile, then the
+		// transition misses and the job is read again
+		ok, err := s.jobRepo.Transition(j, from.Status)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		// jobs that never ran have nothing to pay, running ones are settled
+		// by their worker, or once its lease expired by whoever claims the
+		// job next
+		if from.Status == job.PendingStatus && s.escrow != nil {
+			if err := s.escrow.Settle(j.UserID, j.ID, j.Escrowed, 0, nil); err != nil {
+				// put the job back, so the escrow is released by its run
+				// or by the next cancel
+				if _, err := s.jobRepo.Transition(&from, job.CancelledStatus); err != nil {
+					fmt.Printf("failed to put back job %s after its escrow was not released: %v\n", j.ID, err)
+				}
+
+				return nil, err
+			}
+		}
+
+		s.runningLock.Lock()
+		if cancel, ok := s.running[id]; ok {
+			cancel(job.ErrCancelled)
 		}
+		s.runningLock.Unlock()
+
+		return j, nil
 	}
+}
 
-	return nil
+// Watch reads the job every watch interval, so it follows jobs running on
+// any instance.
+func (s *Service) Watch(ctx context.Context, id uuid.UUID) (<-chan *job.Job, error) {
+	j, err := s.jobRepo.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(chan *job.Job)
+
+	go func() {
+		defer close(updates)
+
+		ticker := time.NewTicker(s.watchInterval)
+		defer ticker.Stop()
+
+		var last *job.Job
+
+		for {
+			if last == nil || !reflect.DeepEqual(last, j) {
+				select {
+				case updates <- j:
+				case <-ctx.Done():
+					return
+				}
+
+				last = j
+			}
+
+			if j.Status.Finished() {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next, err := s.jobRepo.Get(id)
+
+			if err != nil {
+				fmt.Printf("failed to watch job %s: %v\n", id, err)
+				return
+			}
+
+			j = next
+		}
+	}()
+
+	return updates, nil
 }
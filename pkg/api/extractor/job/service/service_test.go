@@ -1,21 +1,71 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"juno/pkg/aggregation"
+	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/job"
+	"juno/pkg/api/extractor/job/billing"
 	"juno/pkg/api/extractor/job/repo/mem"
+	resultStore "juno/pkg/api/extractor/job/store/mem"
 	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/node"
 	"juno/pkg/api/ranag"
+	"juno/pkg/api/token"
+	"juno/pkg/api/transaction"
+	"juno/pkg/api/usage"
+	"juno/pkg/receipt"
+	"juno/pkg/replica"
+	"juno/pkg/scope"
+	"juno/pkg/shard"
+	"sync"
 	"testing"
+	"time"
 
 	ranagDto "juno/pkg/ranag/dto"
 
 	ranagRepo "juno/pkg/api/ranag/repo/mem"
 	ranagService "juno/pkg/api/ranag/service"
 
+	nodeRepo "juno/pkg/api/node/repo/mem"
+	nodeService "juno/pkg/api/node/service"
+
+	tokenService "juno/pkg/api/token/service"
+	tranRepo "juno/pkg/api/transaction/repo/mem"
+	tranService "juno/pkg/api/transaction/service"
+
+	usageRepo "juno/pkg/api/usage/repo/mem"
+	usageService "juno/pkg/api/usage/service"
+
 	"github.com/google/uuid"
 	"github.com/h2non/gock"
+	"github.com/sirupsen/logrus"
 )
 
+type mockMismatchService struct {
+	mu       sync.Mutex
+	reported []string
+}
+
+func (m *mockMismatchService) Report(ranagID uuid.UUID, requestID string, shard int, v *replica.Verification) (*mismatch.Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reported = append(m.reported, fmt.Sprintf("%s/%d", requestID, shard))
+
+	return &mismatch.Report{ID: uuid.New(), RanagID: ranagID, RequestID: requestID, Shard: shard, Conflicts: v.Conflicts()}, nil
+}
+
+func (m *mockMismatchService) ListEntries(nodeID uuid.UUID) ([]*mismatch.Entry, error) {
+	return nil, nil
+}
+
 type mockStrategyService struct {
 	returnStrategy *strategy.Strategy
 	returnError    error
@@ -65,6 +115,18 @@ func (m *mockStrategyService) RemoveSelector(id uuid.UUID, selectorID uuid.UUID)
 	return nil
 }
 
+func (m *mockStrategyService) AddAggregation(id uuid.UUID, agg *aggregation.Aggregation) error {
+	return nil
+}
+
+func (m *mockStrategyService) RemoveAggregation(id uuid.UUID, name string) error {
+	return nil
+}
+
+func (m *mockStrategyService) SetScope(id uuid.UUID, s *scope.Scope) error {
+	return nil
+}
+
 func TestCreate(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		repo := mem.New()
@@ -292,6 +354,7 @@ func TestProcessPending(t *testing.T) {
 						"price":         10.0,
 					},
 				},
+				[]*ranagDto.ShardStatus{{Shard: 0, Status: ranagDto.ShardOK, Attempts: 1}},
 			))
 
 		repo := mem.New()
@@ -340,3 +403,1072 @@ func TestProcessPending(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessAggregations(t *testing.T) {
+	t.Run("writes the finalized aggregations", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			MatchType("json").
+			BodyString(`"aggregations"`).
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeReduceResponse(
+				aggregation.Partials{
+					"products": {Count: 12},
+				},
+				[]*ranagDto.ShardStatus{{Shard: 0, Status: ranagDto.ShardOK, Attempts: 1}},
+			))
+
+		repo := mem.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:      uuid.New(),
+			Address: "ranag:8080",
+			ShardAssignments: [][2]int{
+				{
+					0, 100000,
+				},
+			},
+		})
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+				Aggregations: []*aggregation.Aggregation{
+					{Name: "products", Op: aggregation.OpCount},
+				},
+			},
+		}, ranagService.New(ranagRepo))
+
+		j, err := service.Create(uuid.New(), strategyID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, _ := repo.Get(j.ID)
+
+		if check.Status != job.CompletedStatus {
+			t.Errorf("Expected %s, got %s", job.CompletedStatus, check.Status)
+		}
+
+		rows, total, err := service.Results(j.ID, 0, job.DefaultResultsLimit)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if total != 1 || rows[0]["products"] != int64(12) {
+			t.Errorf("Expected a row of 12 products, got %v", rows)
+		}
+	})
+}
+
+func TestProcessFailover(t *testing.T) {
+	t.Run("falls back to another ranag and records the coverage", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag1:8080").
+			Post("/aggregate").
+			Reply(500)
+
+		gock.New("http://ranag2:8080").
+			Post("/aggregate").
+			MatchType("json").
+			BodyString(`"offset":0,"total":2,`).
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{{"product_title": "charger"}},
+				[]*ranagDto.ShardStatus{
+					{Shard: 0, Status: ranagDto.ShardOK, Attempts: 1},
+					{Shard: 1, Status: ranagDto.ShardFailed, Attempts: 1},
+				},
+			))
+
+		repo := mem.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		for _, address := range []string{"ranag1:8080", "ranag2:8080"} {
+			ranagRepo.Create(&ranag.Ranag{
+				ID:               uuid.New(),
+				Address:          address,
+				ShardAssignments: [][2]int{{0, 2}},
+			})
+		}
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+			},
+		}, ranagService.New(ranagRepo))
+
+		j, err := service.Create(uuid.New(), strategyID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, _ := repo.Get(j.ID)
+
+		if check.Status != job.CompletedStatus {
+			t.Errorf("Expected %s, got %s", job.CompletedStatus, check.Status)
+		}
+
+		if check.PlannedShards != 2 || check.AnsweredShards != 1 {
+			t.Errorf("Expected 1 of 2 shards answered, got %d of %d", check.AnsweredShards, check.PlannedShards)
+		}
+
+		if len(check.Gaps) != 1 || check.Gaps[0] != [2]int{2, 99998} {
+			t.Errorf("Expected a gap from shard 2, got %v", check.Gaps)
+		}
+
+		if check.FailedShards != 1 || check.RowsCollected != 1 {
+			t.Errorf("Expected 1 failed shard and 1 row, got %d and %d", check.FailedShards, check.RowsCollected)
+		}
+
+		if check.StartedAt == nil || check.FinishedAt == nil || check.FinishedAt.Before(*check.StartedAt) {
+			t.Errorf("Expected the start and finish to be recorded, got %v and %v", check.StartedAt, check.FinishedAt)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+}
+
+func TestProcessScope(t *testing.T) {
+	t.Run("only queries the shards of the scope's hosts", func(t *testing.T) {
+
+		defer gock.Off()
+
+		sh := shard.GetShard("example.com")
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			MatchType("json").
+			BodyString(fmt.Sprintf(`"offset":%d,"total":1,.*"scope":\{"hosts":\["example.com"\]\}`, sh)).
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{{"product_title": "charger"}},
+				[]*ranagDto.ShardStatus{{Shard: sh, Status: ranagDto.ShardOK, Attempts: 1}},
+			))
+
+		repo := mem.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+		})
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID:    strategyID,
+				Scope: &scope.Scope{Hosts: []string{"example.com"}},
+			},
+		}, ranagService.New(ranagRepo))
+
+		j, err := service.Create(uuid.New(), strategyID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, _ := repo.Get(j.ID)
+
+		if check.PlannedShards != 1 || check.AnsweredShards != 1 || len(check.Gaps) != 0 {
+			t.Errorf("Expected 1 planned and answered shard, got %+v", check)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("workers of several instances share the pending jobs", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Persist().
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{{"product_title": "charger"}},
+				[]*ranagDto.ShardStatus{{Shard: 0, Status: ranagDto.ShardOK, Attempts: 1}},
+			))
+
+		repo := mem.New()
+		store := resultStore.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+		})
+
+		newService := func() *Service {
+			return New(repo, &mockStrategyService{
+				returnStrategy: &strategy.Strategy{
+					ID: strategyID,
+				},
+			}, ranagService.New(ranagRepo), WithResultStore(store), WithPollInterval(time.Millisecond))
+		}
+
+		services := []*Service{newService(), newService()}
+
+		var jobs []*job.Job
+
+		for i := 0; i < 6; i++ {
+			j, err := services[0].Create(uuid.New(), strategyID)
+
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			jobs = append(jobs, j)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var wg sync.WaitGroup
+
+		for _, s := range services {
+			wg.Add(1)
+			go func(s *Service) {
+				defer wg.Done()
+				s.Run(ctx, 2)
+			}(s)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+
+		for _, j := range jobs {
+			for {
+				check, _ := repo.Get(j.ID)
+
+				if check.Status == job.CompletedStatus {
+					break
+				}
+
+				if time.Now().After(deadline) {
+					t.Fatalf("Expected job %s to complete, got %s", j.ID, check.Status)
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			rows, _, err := store.Read(j.ID, 0, job.DefaultResultsLimit)
+
+			if err != nil || len(rows) != 1 {
+				t.Errorf("Expected the job's row to be stored, got %v, %v", rows, err)
+			}
+		}
+
+		cancel()
+		wg.Wait()
+	})
+
+	t.Run("leaves a job whose lease was lost", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			Delay(100 * time.Millisecond).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{{"product_title": "charger"}},
+				[]*ranagDto.ShardStatus{{Shard: 0, Status: ranagDto.ShardOK, Attempts: 1}},
+			))
+
+		repo := &lostLeaseRepository{mem.New()}
+		store := resultStore.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+		})
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+			},
+		}, ranagService.New(ranagRepo), WithResultStore(store), WithLease(30*time.Millisecond))
+
+		j, err := service.Create(uuid.New(), strategyID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		claimed, err := repo.Claim("other", time.Minute)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.run(context.Background(), claimed); !errors.Is(err, job.ErrLeaseLost) {
+			t.Errorf("Expected %v, got %v", job.ErrLeaseLost, err)
+		}
+
+		check, _ := repo.Get(j.ID)
+
+		if check.Status != job.RunningStatus {
+			t.Errorf("Expected %s, got %s", job.RunningStatus, check.Status)
+		}
+
+		if _, _, err := store.Read(j.ID, 0, 1); err != job.ErrResultsNotFound {
+			t.Errorf("Expected no results, got %v", err)
+		}
+	})
+}
+
+// lostLeaseRepository fails to renew any lease, as if another instance
+// claimed every job.
+type lostLeaseRepository struct {
+	*mem.Repository
+}
+
+func (r *lostLeaseRepository) Renew(id uuid.UUID, owner string, lease time.Duration) error {
+	return job.ErrLeaseLost
+}
+
+func TestExport(t *testing.T) {
+	t.Run("exports the rows typed in the strategy's columns", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{
+					{"product_title": "charger", "price": "10.50", "_juno_meta_url": "http://example.com/charger"},
+				},
+				[]*ranagDto.ShardStatus{{Shard: 0, Status: ranagDto.ShardOK, Attempts: 1}},
+			))
+
+		repo := mem.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+		})
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+				Fields: []*field.Field{
+					{Name: "product_title", Type: field.FieldTypeString},
+					{Name: "price", Type: field.FieldTypeFloat},
+				},
+			},
+		}, ranagService.New(ranagRepo))
+
+		j, err := service.Create(uuid.New(), strategyID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		m, err := service.Manifest(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if m.Rows != 1 || len(m.Schema) != 3 || m.Schema[2].Name != "_juno_meta_url" {
+			t.Errorf("Expected 1 row in 3 columns, got %+v", m)
+		}
+
+		var buf bytes.Buffer
+
+		if err := service.Export(j.ID, job.FormatCSV, &buf); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		want := "product_title,price,_juno_meta_url\ncharger,10.5,http://example.com/charger\n"
+
+		if buf.String() != want {
+			t.Errorf("Expected %q, got %q", want, buf.String())
+		}
+	})
+}
+
+func TestCancel(t *testing.T) {
+	t.Run("cancels a pending job", func(t *testing.T) {
+		repo := mem.New()
+		strategyID := uuid.New()
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+			},
+		}, nil)
+
+		j, _ := service.Create(uuid.New(), strategyID)
+
+		cancelled, err := service.Cancel(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if cancelled.Status != job.CancelledStatus || cancelled.FinishedAt == nil {
+			t.Errorf("Expected the job to be cancelled, got %+v", cancelled)
+		}
+
+		if _, err := repo.Claim("worker", time.Minute); err != job.ErrNoJob {
+			t.Errorf("Expected a cancelled job not to be claimed, got %v", err)
+		}
+
+		if _, err := service.Cancel(j.ID); err != job.ErrFinished {
+			t.Errorf("Expected %v, got %v", job.ErrFinished, err)
+		}
+	})
+
+	t.Run("cancels the ranag requests of a running job", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			Delay(5 * time.Second).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(nil, nil))
+
+		repo := mem.New()
+		store := resultStore.New()
+		strategyID := uuid.New()
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+		})
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+			},
+		}, ranagService.New(ranagRepo), WithResultStore(store))
+
+		j, _ := service.Create(uuid.New(), strategyID)
+
+		claimed, err := repo.Claim(service.owner, time.Minute)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		done := make(chan error)
+
+		go func() {
+			done <- service.run(context.Background(), claimed)
+		}()
+
+		// wait for the job to start querying
+		for {
+			check, _ := repo.Get(j.ID)
+			if check.StartedAt != nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		if _, err := service.Cancel(j.ID); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the ranag request to be cancelled")
+		}
+
+		check, _ := repo.Get(j.ID)
+
+		if check.Status != job.CancelledStatus || check.Error != job.ErrCancelled.Error() {
+			t.Errorf("Expected the job to stay cancelled, got %+v", check)
+		}
+
+		if _, _, err := store.Read(j.ID, 0, 1); err != job.ErrResultsNotFound {
+			t.Errorf("Expected no results, got %v", err)
+		}
+	})
+}
+
+func TestWatch(t *testing.T) {
+	repo := mem.New()
+	strategyID := uuid.New()
+
+	service := New(repo, &mockStrategyService{
+		returnStrategy: &strategy.Strategy{
+			ID: strategyID,
+		},
+	}, nil, WithWatchInterval(time.Millisecond))
+
+	j, _ := service.Create(uuid.New(), strategyID)
+
+	updates, err := service.Watch(context.Background(), j.ID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if first := <-updates; first.Status != job.PendingStatus {
+		t.Errorf("Expected %s, got %s", job.PendingStatus, first.Status)
+	}
+
+	running := *j
+	running.Status = job.RunningStatus
+	running.AnsweredShards = 5
+	repo.Update(&running)
+
+	if next := <-updates; next.AnsweredShards != 5 {
+		t.Errorf("Expected 5 answered shards, got %d", next.AnsweredShards)
+	}
+
+	service.Cancel(j.ID)
+
+	if last := <-updates; last.Status != job.CancelledStatus {
+		t.Errorf("Expected %s, got %s", job.CancelledStatus, last.Status)
+	}
+
+	if _, ok := <-updates; ok {
+		t.Errorf("Expected the updates to end with the job")
+	}
+}
+
+func TestBilling(t *testing.T) {
+	pricing := billing.Pricing{
+		ShardPrice:    100,
+		RowPrice:      10_000,
+		PlatformShare: 0.2,
+		RanagShare:    0.5,
+	}
+
+	ranagSigner, nodeSigner := newSigner(t), newSigner(t)
+	receipts := usageService.New(usageRepo.New())
+
+	setup := func(deposit transaction.Amount) (*Service, *mem.Repository, *tokenService.Service, uuid.UUID, uuid.UUID, uuid.UUID) {
+		repo := mem.New()
+		strategyID := uuid.New()
+		nodeOwnerID := uuid.New()
+		ranagOwnerID := uuid.New()
+
+		tokens := tokenService.New(tranService.New(logrus.New(), tranRepo.New()))
+
+		ranagRepo := ranagRepo.New()
+
+		ranagRepo.Create(&ranag.Ranag{
+			ID:               uuid.New(),
+			OwnerID:          ranagOwnerID,
+			Address:          "ranag:8080",
+			ShardAssignments: [][2]int{{0, 100000}},
+			PublicKey:        ranagSigner.PublicKey(),
+		})
+
+		nodeRepo := nodeRepo.New()
+
+		n := node.New(uuid.New(), nodeOwnerID, "node1:9090", [][2]int{{0, 100000}})
+		n.PublicKey = nodeSigner.PublicKey()
+		nodeRepo.Create(n)
+
+		service := New(repo, &mockStrategyService{
+			returnStrategy: &strategy.Strategy{
+				ID: strategyID,
+			},
+		}, ranagService.New(ranagRepo), WithBilling(tokens, pricing), WithNodeService(nodeService.New(nodeRepo)), WithReceipts(receipts))
+		service.requestID = func() string { return "req-1" }
+
+		userID := uuid.New()
+		tokens.Deposit(userID, uuid.New(), deposit)
+
+		return service, repo, tokens, userID, nodeOwnerID, ranagOwnerID
+	}
+
+	t.Run("settles the metered cost", func(t *testing.T) {
+
+		defer gock.Off()
+
+		res := ranagDto.NewSuccessRangeAggregatorResponse(
+			[]map[string]interface{}{{"price": 10.0}},
+			[]*ranagDto.ShardStatus{
+				{Shard: 0, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-1", 0)},
+				{Shard: 1, Status: ranagDto.ShardOK, Node: "gone:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-1", 1)},
+				{Shard: 2, Status: ranagDto.ShardFailed, Node: "node1:9090", Attempts: 1},
+			},
+		)
+		res.Receipt = sign(t, ranagSigner, "req-1", 0, 1)
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			JSON(res)
+
+		service, repo, tokens, userID, nodeOwnerID, ranagOwnerID := setup(100 * transaction.Token)
+
+		j, err := service.Create(userID, strategyID(service))
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		// 100000 shards at 0.0001
+		if j.Escrowed != 10*transaction.Token {
+			t.Errorf("Expected 10 escrowed, got %s", j.Escrowed)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Available != 90*transaction.Token || b.Escrowed != 10*transaction.Token {
+			t.Errorf("Expected 90 available and 10 escrowed while the job is pending, got %+v", b)
+		}
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, _ := repo.Get(j.ID)
+
+		// the shard of the known node and its row, the shard of the unknown
+		// node is not charged
+		if check.Cost != 10_100 {
+			t.Errorf("Expected a cost of 0.0101, got %s", check.Cost)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Available != 100*transaction.Token-10_100 || b.Escrowed != 0 {
+			t.Errorf("Expected the rest of the escrow refunded, got %+v", b)
+		}
+
+		// the platform keeps 2020
+		expected := map[uuid.UUID]transaction.Amount{
+			nodeOwnerID:  4040,
+			ranagOwnerID: 4040,
+		}
+
+		for id, amount := range expected {
+			if b, _ := tokens.Balance(id); b.Earnings != amount {
+				t.Errorf("Expected earnings of %s, got %s", amount, b.Earnings)
+			}
+		}
+
+		recorded, _ := service.Receipts(j.ID)
+
+		if len(recorded) != 2 {
+			t.Fatalf("Expected the receipts of the ranag and the known node, got %d", len(recorded))
+		}
+
+		for _, rc := range recorded {
+			if rc.JobID != j.ID || rc.RequestID != "req-1" {
+				t.Errorf("Expected a receipt of req-1 for the job, got %+v", rc)
+			}
+
+			if rc.SignerType == usage.NodeSigner && rc.OwnerID != nodeOwnerID {
+				t.Errorf("Expected the node owner, got %s", rc.OwnerID)
+			}
+		}
+	})
+
+	t.Run("does not charge work without a valid receipt", func(t *testing.T) {
+
+		defer gock.Off()
+
+		res := ranagDto.NewSuccessRangeAggregatorResponse(
+			[]map[string]interface{}{{"price": 10.0}},
+			[]*ranagDto.ShardStatus{
+				// signed by another key, for another request and shard
+				{Shard: 0, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, ranagSigner, "req-1", 0)},
+				{Shard: 1, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-0", 1)},
+				{Shard: 2, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-1", 0)},
+			},
+		)
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			JSON(res)
+
+		service, repo, tokens, userID, nodeOwnerID, ranagOwnerID := setup(100 * transaction.Token)
+
+		j, _ := service.Create(userID, strategyID(service))
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check, _ := repo.Get(j.ID); check.Cost != 0 {
+			t.Errorf("Expected no cost, got %s", check.Cost)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Available != 100*transaction.Token {
+			t.Errorf("Expected the escrow refunded, got %+v", b)
+		}
+
+		for _, id := range []uuid.UUID{nodeOwnerID, ranagOwnerID} {
+			if b, _ := tokens.Balance(id); b.Earnings != 0 {
+				t.Errorf("Expected no earnings, got %s", b.Earnings)
+			}
+		}
+
+		if recorded, _ := service.Receipts(j.ID); len(recorded) != 0 {
+			t.Errorf("Expected no receipts, got %d", len(recorded))
+		}
+	})
+
+	t.Run("pays nodes without a key during the grace period", func(t *testing.T) {
+
+		defer gock.Off()
+
+		res := ranagDto.NewSuccessRangeAggregatorResponse(
+			[]map[string]interface{}{{"price": 10.0}},
+			[]*ranagDto.ShardStatus{
+				{Shard: 0, Status: ranagDto.ShardOK, Node: "node2.com:9090", Attempts: 1},
+			},
+		)
+		res.Receipt = sign(t, ranagSigner, "req-1", 0)
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			JSON(res)
+
+		service, repo, tokens, userID, _, _ := setup(100 * transaction.Token)
+		service.receiptGrace = time.Now().Add(time.Hour)
+
+		keyless, _ := service.nodeService.Create(uuid.New(), "node2.com:9090", [][2]int{{0, 100000}})
+
+		j, _ := service.Create(userID, strategyID(service))
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		// the row is charged without a node receipt counting it
+		if check, _ := repo.Get(j.ID); check.Cost != 10_100 {
+			t.Errorf("Expected a cost of 0.0101, got %s", check.Cost)
+		}
+
+		if b, _ := tokens.Balance(keyless.OwnerID); b.Earnings != 4040 {
+			t.Errorf("Expected earnings of 0.00404, got %s", b.Earnings)
+		}
+	})
+
+	t.Run("pays child ranags for the shards delegated to them", func(t *testing.T) {
+
+		defer gock.Off()
+
+		childSigner := newSigner(t)
+
+		res := ranagDto.NewSuccessRangeAggregatorResponse(
+			[]map[string]interface{}{{"price": 10.0}},
+			[]*ranagDto.ShardStatus{
+				{Shard: 0, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-1", 0)},
+				{
+					Shard: 1, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-1", 1),
+					Via:         "child.com:8080",
+					Delegations: []*ranagDto.Delegation{{Ranag: "child.com:8080", Receipt: sign(t, childSigner, "req-1", 1)}},
+				},
+			},
+		)
+		res.Receipt = sign(t, ranagSigner, "req-1", 0, 1)
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			JSON(res)
+
+		service, repo, tokens, userID, nodeOwnerID, ranagOwnerID := setup(100 * transaction.Token)
+
+		parent, _ := service.ranagService.GetByAddress("ranag:8080")
+		child, err := service.ranagService.Create(ranagOwnerID, "child.com:8080", [][2]int{{1, 1}}, parent.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		service.ranagService.RegisterKey(child.ID, childSigner.PublicKey())
+
+		j, _ := service.Create(userID, strategyID(service))
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check, _ := repo.Get(j.ID); check.Cost != 10_200 {
+			t.Errorf("Expected a cost of 0.0102, got %s", check.Cost)
+		}
+
+		if b, _ := tokens.Balance(nodeOwnerID); b.Earnings != 4080 {
+			t.Errorf("Expected earnings of 0.00408, got %s", b.Earnings)
+		}
+
+		// the child earns the ranag part of the shard it answered
+		earnings, _ := tokens.Earnings(ranagOwnerID, time.Now(), time.Now())
+		expected := map[uuid.UUID]transaction.Amount{parent.ID: 4040, child.ID: 40}
+
+		if len(earnings) != 2 {
+			t.Fatalf("Expected the earnings of both ranags, got %d", len(earnings))
+		}
+
+		for _, e := range earnings {
+			if e.Amount != expected[e.SourceID] {
+				t.Errorf("Expected earnings of %s, got %s", expected[e.SourceID], e.Amount)
+			}
+		}
+
+		if recorded, _ := service.Receipts(j.ID); len(recorded) != 4 {
+			t.Errorf("Expected the receipts of both ranags and both shards, got %d", len(recorded))
+		}
+	})
+
+	t.Run("pays the replicas that verified a shard and reports their mismatch", func(t *testing.T) {
+
+		defer gock.Off()
+
+		verifierSigner := newSigner(t)
+
+		res := ranagDto.NewSuccessRangeAggregatorResponse(
+			[]map[string]interface{}{{"price": 10.0}},
+			[]*ranagDto.ShardStatus{
+				{
+					Shard: 0, Status: ranagDto.ShardOK, Node: "node1:9090", Attempts: 1, Receipt: sign(t, nodeSigner, "req-1", 0),
+					Verification: &replica.Verification{Answers: []*replica.Answer{
+						{Node: "node1:9090", Receipt: sign(t, nodeSigner, "req-1", 0), Versions: map[string]string{"v1": "x"}},
+						{Node: "node2.com:9090", Receipt: sign(t, verifierSigner, "req-1", 0), Versions: map[string]string{"v1": "y"}},
+						// signed for another request
+						{Node: "node2.com:9090", Receipt: sign(t, verifierSigner, "req-0", 0)},
+						{Node: "node3.com:9090", Receipt: sign(t, verifierSigner, "req-1", 0)},
+					}},
+				},
+			},
+		)
+		res.Receipt = sign(t, ranagSigner, "req-1", 0)
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			Reply(200).
+			JSON(res)
+
+		service, repo, tokens, userID, _, _ := setup(100 * transaction.Token)
+
+		mismatches := &mockMismatchService{}
+		service.mismatches = mismatches
+
+		verifier, _ := service.nodeService.Create(uuid.New(), "node2.com:9090", [][2]int{{0, 100000}})
+		service.nodeService.RegisterKey(verifier.ID, verifierSigner.PublicKey())
+
+		j, _ := service.Create(userID, strategyID(service))
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		// the verifier's shard is charged without rows
+		if check, _ := repo.Get(j.ID); check.Cost != 10_200 {
+			t.Errorf("Expected a cost of 0.0102, got %s", check.Cost)
+		}
+
+		if b, _ := tokens.Balance(verifier.OwnerID); b.Earnings != 40 {
+			t.Errorf("Expected earnings of 0.00004, got %s", b.Earnings)
+		}
+
+		if recorded, _ := service.Receipts(j.ID); len(recorded) != 3 {
+			t.Errorf("Expected the receipts of the ranag, the node and the verifier, got %d", len(recorded))
+		}
+
+		if len(mismatches.reported) != 1 || mismatches.reported[0] != "req-1/0" {
+			t.Errorf("Expected the mismatch of shard 0 reported, got %v", mismatches.reported)
+		}
+	})
+
+	t.Run("releases the escrow of a cancelled job its worker lost", func(t *testing.T) {
+		service, repo, tokens, userID, _, _ := setup(100 * transaction.Token)
+
+		j, _ := service.Create(userID, strategyID(service))
+
+		// the worker dies before it settles the job, its lease expires
+		if _, err := repo.Claim("lost", -time.Second); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if _, err := service.Cancel(j.ID); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Escrowed != 10*transaction.Token {
+			t.Errorf("Expected the escrow left to the worker, got %+v", b)
+		}
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Available != 100*transaction.Token || b.Escrowed != 0 {
+			t.Errorf("Expected the escrow released, got %+v", b)
+		}
+
+		if check, _ := repo.Get(j.ID); check.Status != job.CancelledStatus || check.Cost != 0 {
+			t.Errorf("Expected the job to stay cancelled without a cost, got %+v", check)
+		}
+
+		if _, err := repo.Claim("worker", time.Minute); err != job.ErrNoJob {
+			t.Errorf("Expected the settled job not claimed again, got %v", err)
+		}
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		service, repo, _, userID, _, _ := setup(transaction.Token)
+
+		_, err := service.Create(userID, strategyID(service))
+
+		if err != token.ErrInsufficientBalance {
+			t.Fatalf("Expected %v, got %v", token.ErrInsufficientBalance, err)
+		}
+
+		if jobs, _ := repo.ListByUserID(userID); len(jobs) != 0 {
+			t.Errorf("Expected no job, got %d", len(jobs))
+		}
+	})
+
+	t.Run("releases the escrow of unanswered and cancelled jobs", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://ranag:8080").
+			Post("/aggregate").
+			ReplyError(errors.New("connection refused"))
+
+		service, repo, tokens, userID, _, _ := setup(100 * transaction.Token)
+
+		cancelled, _ := service.Create(userID, strategyID(service))
+		service.Cancel(cancelled.ID)
+
+		unanswered, _ := service.Create(userID, strategyID(service))
+
+		if err := service.ProcessPending(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if check, _ := repo.Get(unanswered.ID); !check.Status.Finished() || check.Cost != 0 {
+			t.Errorf("Expected the job to finish without cost, got %s and %s", check.Status, check.Cost)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Available != 100*transaction.Token || b.Escrowed != 0 {
+			t.Errorf("Expected 100 available and nothing escrowed, got %+v", b)
+		}
+	})
+
+	t.Run("leaves the escrow of a job claimed while it is cancelled to its worker", func(t *testing.T) {
+		service, repo, tokens, userID, _, _ := setup(100 * transaction.Token)
+		service.jobRepo = &claimingRepository{Repository: repo}
+
+		j, _ := service.Create(userID, strategyID(service))
+
+		cancelled, err := service.Cancel(j.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if cancelled.Status != job.CancelledStatus {
+			t.Errorf("Expected %s, got %s", job.CancelledStatus, cancelled.Status)
+		}
+
+		if b, _ := tokens.Balance(userID); b.Escrowed != j.Escrowed {
+			t.Errorf("Expected %s escrowed until the worker settles, got %+v", j.Escrowed, b)
+		}
+	})
+}
+
+// claimingRepository claims the job after the first read of it, like a
+// worker racing the caller.
+type claimingRepository struct {
+	*mem.Repository
+	claimed bool
+}
+
+func (r *claimingRepository) Get(id uuid.UUID) (*job.Job, error) {
+	j, err := r.Repository.Get(id)
+
+	if !r.claimed {
+		r.claimed = true
+		r.Repository.Claim("worker", time.Minute)
+	}
+
+	return j, err
+}
+
+func strategyID(s *Service) uuid.UUID {
+	strat, _ := s.strategyService.Get(uuid.Nil)
+	return strat.ID
+}
+
+func newSigner(t *testing.T) *receipt.Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return receipt.NewSigner(key)
+}
+
+func sign(t *testing.T, s *receipt.Signer, requestID string, shards ...int) *receipt.Receipt {
+	t.Helper()
+
+	r := &receipt.Receipt{RequestID: requestID, Shards: shards, Rows: 1}
+
+	if err := s.Sign(r); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return r
+}
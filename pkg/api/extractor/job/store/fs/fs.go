@@ -0,0 +1,201 @@
+package fs
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"juno/pkg/api/extractor/job"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// maxRowSize caps the size of a stored row.
+const maxRowSize = 64 << 20
+
+// Store keeps the rows of each job as newline-delimited JSON in its own
+// file, so pages are read without decoding the rows before them, next to a
+// JSON manifest.
+type Store struct {
+	dir string
+}
+
+func New(dir string) *Store {
+
+	// create if not exists
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+
+	return &Store{
+		dir: dir,
+	}
+}
+
+func (s *Store) path(jobID uuid.UUID) string {
+	return filepath.Join(s.dir, jobID.String()+".ndjson")
+}
+
+func (s *Store) manifestPath(jobID uuid.UUID) string {
+	return filepath.Join(s.dir, jobID.String()+".manifest.json")
+}
+
+// Write replaces the job's rows, then its manifest. Both are written to a
+// temporary file first so readers never see a partial result.
+func (s *Store) Write(jobID uuid.UUID, schema []job.Column, rows []map[string]interface{}) (*job.Manifest, error) {
+	m := &job.Manifest{
+		Rows:   len(rows),
+		Schema: schema,
+	}
+
+	err := s.replace(s.path(jobID), func(w io.Writer) error {
+		cw := &countingWriter{w: w}
+		enc := json.NewEncoder(cw)
+
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+
+		m.Bytes = cw.n
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.replace(s.manifestPath(jobID), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(m)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *Store) replace(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func (s *Store) Manifest(jobID uuid.UUID) (*job.Manifest, error) {
+	b, err := os.ReadFile(s.manifestPath(jobID))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, job.ErrResultsNotFound
+		}
+		return nil, err
+	}
+
+	var m job.Manifest
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (s *Store) Read(jobID uuid.UUID, offset, limit int) ([]map[string]interface{}, int, error) {
+	rows := []map[string]interface{}{}
+	total := 0
+
+	err := s.scan(jobID, func(line []byte) error {
+		if total >= offset && len(rows) < limit {
+			var row map[string]interface{}
+
+			if err := json.Unmarshal(line, &row); err != nil {
+				return err
+			}
+
+			rows = append(rows, row)
+		}
+
+		total++
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return rows, total, nil
+}
+
+func (s *Store) Stream(jobID uuid.UUID, fn func(row map[string]interface{}) error) error {
+	return s.scan(jobID, func(line []byte) error {
+		var row map[string]interface{}
+
+		if err := json.Unmarshal(line, &row); err != nil {
+			return err
+		}
+
+		return fn(row)
+	})
+}
+
+func (s *Store) scan(jobID uuid.UUID, fn func(line []byte) error) error {
+	f, err := os.Open(s.path(jobID))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return job.ErrResultsNotFound
+		}
+		return err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxRowSize)
+
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"juno/pkg/api/extractor/field"
+	"juno/pkg/api/extractor/job"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestStore(t *testing.T) {
+	s := New(t.TempDir())
+	jobID := uuid.New()
+
+	if _, _, err := s.Read(jobID, 0, 10); err != job.ErrResultsNotFound {
+		t.Fatalf("Expected %v, got %v", job.ErrResultsNotFound, err)
+	}
+
+	if _, err := s.Manifest(jobID); err != job.ErrResultsNotFound {
+		t.Fatalf("Expected %v, got %v", job.ErrResultsNotFound, err)
+	}
+
+	rows := []map[string]interface{}{
+		{"title": "a"},
+		{"title": "b"},
+		{"title": "c"},
+	}
+
+	schema := []job.Column{{Name: "title", Type: field.FieldTypeString}}
+
+	m, err := s.Write(jobID, schema, rows)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	// three rows of {"title":"a"} and a newline
+	if m.Rows != 3 || m.Bytes != 42 || len(m.Schema) != 1 {
+		t.Errorf("Expected a manifest of 3 rows and 42 bytes, got %+v", m)
+	}
+
+	if check, err := s.Manifest(jobID); err != nil || check.Rows != m.Rows || check.Bytes != m.Bytes || check.Schema[0] != schema[0] {
+		t.Errorf("Expected the stored manifest, got %+v, %v", check, err)
+	}
+
+	var streamed []string
+
+	err = s.Stream(jobID, func(row map[string]interface{}) error {
+		streamed = append(streamed, row["title"].(string))
+		return nil
+	})
+
+	if err != nil || len(streamed) != 3 || streamed[2] != "c" {
+		t.Errorf("Expected every row in order, got %v, %v", streamed, err)
+	}
+
+	page, total, err := s.Read(jobID, 1, 1)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if total != 3 {
+		t.Errorf("Expected 3, got %d", total)
+	}
+
+	if len(page) != 1 || page[0]["title"] != "b" {
+		t.Errorf("Expected the second row, got %v", page)
+	}
+
+	// writing again replaces the rows
+	if _, err := s.Write(jobID, schema, rows[:1]); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	page, total, err = s.Read(jobID, 5, 10)
+
+	if err != nil || total != 1 || len(page) != 0 {
+		t.Errorf("Expected an empty page of 1 row, got %v, %d, %v", page, total, err)
+	}
+}
@@ -0,0 +1,99 @@
+package mem
+
+import (
+	"encoding/json"
+	"juno/pkg/api/extractor/job"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type results struct {
+	manifest job.Manifest
+	rows     []map[string]interface{}
+}
+
+type Store struct {
+	mu      sync.Mutex
+	results map[uuid.UUID]*results
+}
+
+func New() *Store {
+	return &Store{
+		results: make(map[uuid.UUID]*results),
+	}
+}
+
+func (s *Store) Write(jobID uuid.UUID, schema []job.Column, rows []map[string]interface{}) (*job.Manifest, error) {
+	// the size the rows would take as newline-delimited JSON
+	var size int64
+
+	for _, row := range rows {
+		b, err := json.Marshal(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		size += int64(len(b)) + 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &results{
+		manifest: job.Manifest{Rows: len(rows), Bytes: size, Schema: schema},
+		rows:     append([]map[string]interface{}{}, rows...),
+	}
+
+	s.results[jobID] = r
+
+	m := r.manifest
+	return &m, nil
+}
+
+func (s *Store) Manifest(jobID uuid.UUID) (*job.Manifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.results[jobID]
+	if !ok {
+		return nil, job.ErrResultsNotFound
+	}
+
+	m := r.manifest
+	return &m, nil
+}
+
+func (s *Store) Read(jobID uuid.UUID, offset, limit int) ([]map[string]interface{}, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.results[jobID]
+	if !ok {
+		return nil, 0, job.ErrResultsNotFound
+	}
+
+	start := min(offset, len(r.rows))
+	end := min(start+limit, len(r.rows))
+
+	return append([]map[string]interface{}{}, r.rows[start:end]...), len(r.rows), nil
+}
+
+func (s *Store) Stream(jobID uuid.UUID, fn func(row map[string]interface{}) error) error {
+	s.mu.Lock()
+	r, ok := s.results[jobID]
+	s.mu.Unlock()
+
+	if !ok {
+		return job.ErrResultsNotFound
+	}
+
+	for _, row := range r.rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,10 +3,13 @@ package strategy
 import (
 	"context"
 	"errors"
+	"juno/pkg/aggregation"
 	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/filter"
 	"juno/pkg/api/extractor/selector"
+	"juno/pkg/api/transaction"
 	"juno/pkg/can"
+	"juno/pkg/scope"
 	"juno/pkg/util"
 	"time"
 
@@ -15,6 +18,41 @@ import (
 )
 
 var ErrNotFound = errors.New("strategy not found")
+var ErrAggregationExists = errors.New("strategy already has an aggregation with this name")
+var ErrNoEstimator = errors.New("strategies cannot be estimated")
+
+// MaxSampleShards caps how many shards an estimate samples rows from.
+const MaxSampleShards = 10
+
+// Estimate is what running a strategy is expected to scan, return and cost.
+type Estimate struct {
+	// Shards are the shards a job would query and Gaps the requested shard
+	// ranges, as offset and total, no ranag covers
+	Shards int
+	Gaps   [][2]int
+	// Pages are the pages stored on the shards, as the nodes report them,
+	// and Evaluations how many selector evaluations scanning them takes
+	Pages       int
+	Evaluations int
+	// Rows are the rows the job is expected to return and pay for, one per
+	// scanned page at most
+	Rows int
+	Cost transaction.Amount
+	// Escrow is what creating the job holds from the balance
+	Escrow transaction.Amount
+
+	// SampleShards are the shards the strategy was run on and SampleRows
+	// how many rows they returned. The rows themselves are not paid for, so
+	// they are not returned.
+	SampleShards []int
+	SampleRows   int
+}
+
+// Estimator plans strategies without running them, or runs them on a sample
+// of their shards.
+type Estimator interface {
+	Estimate(ctx context.Context, strategy *Strategy, sampleShards int) (*Estimate, error)
+}
 
 type Strategy struct {
 	ID        uuid.UUID
@@ -23,6 +61,11 @@ type Strategy struct {
 	Selectors []*selector.Selector
 	Filters   []*filter.Filter
 	Fields    []*field.Field
+	// Aggregations reduce the extracted rows, a strategy without them
+	// returns every row
+	Aggregations []*aggregation.Aggregation
+	// Scope narrows the strategy to some hosts, nil runs it over every page
+	Scope     *scope.Scope
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -54,6 +97,10 @@ type Service interface {
 	RemoveFilter(id, filterID uuid.UUID) error
 	AddField(id, fieldID uuid.UUID) error
 	RemoveField(id, fieldID uuid.UUID) error
+	AddAggregation(id uuid.UUID, agg *aggregation.Aggregation) error
+	RemoveAggregation(id uuid.UUID, name string) error
+	// SetScope replaces the strategy's scope, an empty scope removes it.
+	SetScope(id uuid.UUID, s *scope.Scope) error
 	ListByUserID(userID uuid.UUID) ([]*Strategy, error)
 }
 
@@ -83,6 +130,18 @@ type StrategyFieldRepository interface {
 	RemoveField(strategyID, fieldID uuid.UUID) error
 }
 
+type StrategyAggregationRepository interface {
+	AddAggregation(strategyID uuid.UUID, agg *aggregation.Aggregation) error
+	ListAggregations(strategyID uuid.UUID) ([]*aggregation.Aggregation, error)
+	RemoveAggregation(strategyID uuid.UUID, name string) error
+}
+
+type StrategyScopeRepository interface {
+	SetScope(strategyID uuid.UUID, s *scope.Scope) error
+	// GetScope returns nil when the strategy has no scope.
+	GetScope(strategyID uuid.UUID) (*scope.Scope, error)
+}
+
 type Handler interface {
 	Create(c *gin.Context)
 	Get(c *gin.Context)
@@ -96,6 +155,13 @@ type Handler interface {
 
 	AddField(c *gin.Context)
 	RemoveField(c *gin.Context)
+
+	AddAggregation(c *gin.Context)
+	RemoveAggregation(c *gin.Context)
+
+	SetScope(c *gin.Context)
+
+	Estimate(c *gin.Context)
 }
 
 type Policy interface {
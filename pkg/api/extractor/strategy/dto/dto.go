@@ -1,7 +1,10 @@
 package dto
 
 import (
+	"juno/pkg/aggregation"
 	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/api/transaction"
+	"juno/pkg/scope"
 	"time"
 
 	fieldDto "juno/pkg/api/extractor/field/dto"
@@ -22,6 +25,9 @@ type Strategy struct {
 	Filters   []*filterDto.Filter     `json:"filters"`
 	CreatedAt string                  `json:"created_at"`
 	UpdatedAt string                  `json:"updated_at"`
+
+	Aggregations []*aggregation.Aggregation `json:"aggregations"`
+	Scope        *scope.Scope               `json:"scope,omitempty"`
 }
 
 func NewStrategyFromDomain(s *strategy.Strategy) *Strategy {
@@ -41,14 +47,21 @@ func NewStrategyFromDomain(s *strategy.Strategy) *Strategy {
 		flds = append(flds, fieldDto.NewFieldFromDomain(fld))
 	}
 
+	aggs := s.Aggregations
+	if aggs == nil {
+		aggs = make([]*aggregation.Aggregation, 0)
+	}
+
 	return &Strategy{
-		ID:        s.ID.String(),
-		Name:      s.Name,
-		Selectors: sels,
-		Filters:   fils,
-		Fields:    flds,
-		CreatedAt: s.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: s.UpdatedAt.Format(time.RFC3339),
+		ID:           s.ID.String(),
+		Name:         s.Name,
+		Selectors:    sels,
+		Filters:      fils,
+		Fields:       flds,
+		CreatedAt:    s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    s.UpdatedAt.Format(time.RFC3339),
+		Aggregations: aggs,
+		Scope:        s.Scope,
 	}
 }
 
@@ -256,3 +269,151 @@ func NewErrorRemoveFieldResponse(err error) *RemoveFieldResponse {
 		Message: err.Error(),
 	}
 }
+
+type AddAggregationRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	Op       string  `json:"op" binding:"required"`
+	Field    string  `json:"field"`
+	Metric   string  `json:"metric"`
+	Value    string  `json:"value"`
+	K        int     `json:"k"`
+	Interval float64 `json:"interval"`
+}
+
+func (r AddAggregationRequest) ToDomain() *aggregation.Aggregation {
+	return &aggregation.Aggregation{
+		Name:     r.Name,
+		Op:       aggregation.Op(r.Op),
+		Field:    r.Field,
+		Metric:   aggregation.Metric(r.Metric),
+		Value:    r.Value,
+		K:        r.K,
+		Interval: r.Interval,
+	}
+}
+
+type AddAggregationResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewSuccessAddAggregationResponse() *AddAggregationResponse {
+	return &AddAggregationResponse{
+		Status: SUCCESS,
+	}
+}
+
+func NewErrorAddAggregationResponse(err error) *AddAggregationResponse {
+	return &AddAggregationResponse{
+		Status:  ERROR,
+		Message: err.Error(),
+	}
+}
+
+type RemoveAggregationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type RemoveAggregationResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewSuccessRemoveAggregationResponse() *RemoveAggregationResponse {
+	return &RemoveAggregationResponse{
+		Status: SUCCESS,
+	}
+}
+
+func NewErrorRemoveAggregationResponse(err error) *RemoveAggregationResponse {
+	return &RemoveAggregationResponse{
+		Status:  ERROR,
+		Message: err.Error(),
+	}
+}
+
+// SetScopeRequest sets one of hosts, host_glob and url_prefix, or none to
+// remove the scope.
+type SetScopeRequest struct {
+	Hosts     []string `json:"hosts"`
+	HostGlob  string   `json:"host_glob"`
+	URLPrefix string   `json:"url_prefix"`
+}
+
+func (r SetScopeRequest) ToDomain() *scope.Scope {
+	return &scope.Scope{
+		Hosts:     r.Hosts,
+		HostGlob:  r.HostGlob,
+		URLPrefix: r.URLPrefix,
+	}
+}
+
+type SetScopeResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewSuccessSetScopeResponse() *SetScopeResponse {
+	return &SetScopeResponse{
+		Status: SUCCESS,
+	}
+}
+
+func NewErrorSetScopeResponse(err error) *SetScopeResponse {
+	return &SetScopeResponse{
+		Status:  ERROR,
+		Message: err.Error(),
+	}
+}
+
+type EstimateRequest struct {
+	SampleShards int `json:"sample_shards" binding:"min=0,max=10"`
+}
+
+type Estimate struct {
+	Shards      int                `json:"shards"`
+	Gaps        [][2]int           `json:"gaps,omitempty"`
+	Pages       int                `json:"pages"`
+	Evaluations int                `json:"evaluations"`
+	Rows        int                `json:"rows"`
+	Cost        transaction.Amount `json:"cost"`
+	Escrow      transaction.Amount `json:"escrow"`
+
+	SampleShards []int `json:"sample_shards,omitempty"`
+	SampleRows   int   `json:"sample_rows"`
+}
+
+func NewEstimateFromDomain(e *strategy.Estimate) *Estimate {
+	return &Estimate{
+		Shards:       e.Shards,
+		Gaps:         e.Gaps,
+		Pages:        e.Pages,
+		Evaluations:  e.Evaluations,
+		Rows:         e.Rows,
+		Cost:         e.Cost,
+		Escrow:       e.Escrow,
+		SampleShards: e.SampleShards,
+		SampleRows:   e.SampleRows,
+	}
+}
+
+type EstimateResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Estimate *Estimate `json:"estimate,omitempty"`
+}
+
+func NewSuccessEstimateResponse(e *strategy.Estimate) *EstimateResponse {
+	return &EstimateResponse{
+		Status:   SUCCESS,
+		Estimate: NewEstimateFromDomain(e),
+	}
+}
+
+func NewErrorEstimateResponse(err error) *EstimateResponse {
+	return &EstimateResponse{
+		Status:  ERROR,
+		Message: err.Error(),
+	}
+}
@@ -11,14 +11,16 @@ import (
 )
 
 type Handler struct {
-	service strategy.Service
-	policy  strategy.Policy
+	service   strategy.Service
+	policy    strategy.Policy
+	estimator strategy.Estimator
 }
 
-func New(policy strategy.Policy, service strategy.Service) *Handler {
+func New(policy strategy.Policy, service strategy.Service, estimator strategy.Estimator) *Handler {
 	return &Handler{
-		policy:  policy,
-		service: service,
+		policy:    policy,
+		service:   service,
+		estimator: estimator,
 	}
 }
 
@@ -351,3 +353,198 @@ func (h *Handler) RemoveField(c *gin.Context) {
 			c.JSON(500, dto.NewErrorRemoveFieldResponse(err))
 		})
 }
+
+func (h *Handler) AddAggregation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, dto.NewErrorAddAggregationResponse(err))
+		return
+	}
+
+	var req dto.AddAggregationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorAddAggregationResponse(err))
+		return
+	}
+
+	agg := req.ToDomain()
+
+	if err := agg.Validate(); err != nil {
+		c.JSON(400, dto.NewErrorAddAggregationResponse(err))
+		return
+	}
+
+	strat, err := h.service.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorAddAggregationResponse(err))
+		return
+	}
+
+	h.policy.CanUpdate(c.Request.Context(), strat).
+		Allow(func() {
+
+			err = h.service.AddAggregation(id, agg)
+
+			if errors.Is(err, strategy.ErrAggregationExists) {
+				c.JSON(409, dto.NewErrorAddAggregationResponse(err))
+				return
+			}
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorAddAggregationResponse(err))
+				return
+			}
+
+			c.JSON(204, dto.NewSuccessAddAggregationResponse())
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorAddAggregationResponse(errors.New(reason)))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorAddAggregationResponse(err))
+		})
+}
+
+func (h *Handler) RemoveAggregation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, dto.NewErrorRemoveAggregationResponse(err))
+		return
+	}
+
+	var req dto.RemoveAggregationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorRemoveAggregationResponse(err))
+		return
+	}
+
+	strat, err := h.service.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorRemoveAggregationResponse(err))
+		return
+	}
+
+	h.policy.CanUpdate(c.Request.Context(), strat).
+		Allow(func() {
+
+			err = h.service.RemoveAggregation(id, req.Name)
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorRemoveAggregationResponse(err))
+				return
+			}
+
+			c.JSON(204, dto.NewSuccessRemoveAggregationResponse())
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorRemoveAggregationResponse(errors.New(reason)))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorRemoveAggregationResponse(err))
+		})
+}
+
+func (h *Handler) SetScope(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, dto.NewErrorSetScopeResponse(err))
+		return
+	}
+
+	var req dto.SetScopeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorSetScopeResponse(err))
+		return
+	}
+
+	sc := req.ToDomain()
+
+	if err := sc.Validate(); err != nil {
+		c.JSON(400, dto.NewErrorSetScopeResponse(err))
+		return
+	}
+
+	strat, err := h.service.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorSetScopeResponse(err))
+		return
+	}
+
+	h.policy.CanUpdate(c.Request.Context(), strat).
+		Allow(func() {
+
+			err = h.service.SetScope(id, sc)
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorSetScopeResponse(err))
+				return
+			}
+
+			c.JSON(204, dto.NewSuccessSetScopeResponse())
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorSetScopeResponse(errors.New(reason)))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorSetScopeResponse(err))
+		})
+}
+
+// Estimate plans a job on the strategy and, when asked to, runs it on a few
+// of its shards. An empty body estimates without sampling.
+func (h *Handler) Estimate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, dto.NewErrorEstimateResponse(err))
+		return
+	}
+
+	var req dto.EstimateRequest
+
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, dto.NewErrorEstimateResponse(err))
+			return
+		}
+	}
+
+	strat, err := h.service.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorEstimateResponse(err))
+		return
+	}
+
+	h.policy.CanRead(c.Request.Context(), strat).
+		Allow(func() {
+			if h.estimator == nil {
+				c.JSON(501, dto.NewErrorEstimateResponse(strategy.ErrNoEstimator))
+				return
+			}
+
+			estimate, err := h.estimator.Estimate(c.Request.Context(), strat, req.SampleShards)
+
+			if err != nil {
+				c.JSON(500, dto.NewErrorEstimateResponse(err))
+				return
+			}
+
+			c.JSON(200, dto.NewSuccessEstimateResponse(estimate))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorEstimateResponse(errors.New(reason)))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorEstimateResponse(err))
+		})
+}
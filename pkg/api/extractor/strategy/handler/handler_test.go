@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"juno/pkg/aggregation"
 	"juno/pkg/api/auth"
 	"juno/pkg/api/extractor/strategy"
 	"juno/pkg/api/extractor/strategy/dto"
 	"juno/pkg/api/user"
 	"juno/pkg/can"
+	"juno/pkg/scope"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -21,6 +23,8 @@ type mockService struct {
 	returnStrategy  *strategy.Strategy
 	returnStrategys []*strategy.Strategy
 	returnError     error
+
+	addAggregationError error
 }
 
 func (m mockService) Create(userID uuid.UUID, name string) (*strategy.Strategy, error) {
@@ -63,6 +67,18 @@ func (m mockService) RemoveField(id, fieldID uuid.UUID) error {
 	return m.returnError
 }
 
+func (m mockService) AddAggregation(id uuid.UUID, agg *aggregation.Aggregation) error {
+	return m.addAggregationError
+}
+
+func (m mockService) RemoveAggregation(id uuid.UUID, name string) error {
+	return m.returnError
+}
+
+func (m mockService) SetScope(id uuid.UUID, s *scope.Scope) error {
+	return m.returnError
+}
+
 type mockPolicy struct {
 	allowed bool
 	err     error
@@ -98,7 +114,7 @@ func TestCreate(t *testing.T) {
 		}
 		handler := New(&mockPolicy{allowed: true}, mockService{
 			returnStrategy: expect,
-		})
+		}, nil)
 
 		req := dto.CreateStrategyRequest{
 			Name: expect.Name,
@@ -153,7 +169,7 @@ func TestCreate(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -182,7 +198,7 @@ func TestCreate(t *testing.T) {
 	})
 
 	t.Run("forbidden", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: false, reason: "reason"}, mockService{})
+		handler := New(&mockPolicy{allowed: false, reason: "reason"}, mockService{}, nil)
 
 		req := dto.CreateStrategyRequest{
 			Name: "Value equals Charger",
@@ -234,7 +250,7 @@ func TestGet(t *testing.T) {
 		}
 		handler := New(&mockPolicy{allowed: true}, mockService{
 			returnStrategy: expect,
-		})
+		}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -278,7 +294,7 @@ func TestGet(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
 		handler := New(&mockPolicy{allowed: true}, mockService{
 			returnError: strategy.ErrNotFound,
-		})
+		}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -313,7 +329,7 @@ func TestGet(t *testing.T) {
 	})
 
 	t.Run("forbidden", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: false, reason: "reason"}, mockService{})
+		handler := New(&mockPolicy{allowed: false, reason: "reason"}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -363,7 +379,7 @@ func TestList(t *testing.T) {
 		}
 		handler := New(&mockPolicy{allowed: true}, mockService{
 			returnStrategys: expect,
-		})
+		}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -406,7 +422,7 @@ func TestList(t *testing.T) {
 	})
 
 	t.Run("forbidden", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: false, reason: "reason"}, mockService{})
+		handler := New(&mockPolicy{allowed: false, reason: "reason"}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -441,7 +457,7 @@ func TestList(t *testing.T) {
 
 func TestAddSelector(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -469,7 +485,7 @@ func TestAddSelector(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -489,7 +505,7 @@ func TestAddSelector(t *testing.T) {
 
 func TestRemoveSelector(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -517,7 +533,7 @@ func TestRemoveSelector(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -537,7 +553,7 @@ func TestRemoveSelector(t *testing.T) {
 
 func TestAddFilter(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -565,7 +581,7 @@ func TestAddFilter(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -585,7 +601,7 @@ func TestAddFilter(t *testing.T) {
 
 func TestRemoveFilter(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -613,7 +629,7 @@ func TestRemoveFilter(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -633,7 +649,7 @@ func TestRemoveFilter(t *testing.T) {
 
 func TestAddField(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -661,7 +677,7 @@ func TestAddField(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -681,7 +697,7 @@ func TestAddField(t *testing.T) {
 
 func TestRemoveField(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -709,7 +725,7 @@ func TestRemoveField(t *testing.T) {
 	})
 
 	t.Run("bad request", func(t *testing.T) {
-		handler := New(&mockPolicy{allowed: true}, mockService{})
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
 
 		w := httptest.NewRecorder()
 
@@ -726,3 +742,217 @@ func TestRemoveField(t *testing.T) {
 		}
 	})
 }
+
+func TestAddAggregation(t *testing.T) {
+	send := func(svc mockService, policy *mockPolicy, body string) *httptest.ResponseRecorder {
+		handler := New(policy, svc, nil)
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: uuid.New().String()})
+
+		c.Request = httptest.NewRequest("POST", "/strategies/"+uuid.New().String()+"/aggregations", strings.NewReader(body)).
+			WithContext(auth.WithUser(context.Background(), &user.User{ID: uuid.New()}))
+
+		handler.AddAggregation(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := send(mockService{}, &mockPolicy{allowed: true}, `{"name": "revenue", "op": "group_by", "field": "category", "metric": "sum", "value": "price"}`)
+
+		if w.Code != 204 {
+			t.Errorf("Expected 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid aggregation", func(t *testing.T) {
+		w := send(mockService{}, &mockPolicy{allowed: true}, `{"name": "top", "op": "top_k", "field": "title"}`)
+
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		w := send(mockService{addAggregationError: strategy.ErrAggregationExists}, &mockPolicy{allowed: true}, `{"name": "pages", "op": "count"}`)
+
+		if w.Code != 409 {
+			t.Errorf("Expected 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		w := send(mockService{}, &mockPolicy{allowed: false, reason: "reason"}, `{"name": "pages", "op": "count"}`)
+
+		if w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestRemoveAggregation(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: uuid.New().String()})
+
+		c.Request = httptest.NewRequest("DELETE", "/strategies/"+uuid.New().String()+"/aggregations", strings.NewReader(`{"name": "pages"}`)).
+			WithContext(auth.WithUser(context.Background(), &user.User{ID: uuid.New()}))
+
+		handler.RemoveAggregation(c)
+
+		if w.Code != 204 {
+			t.Errorf("Expected 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		handler := New(&mockPolicy{allowed: true}, mockService{}, nil)
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: uuid.New().String()})
+
+		c.Request = httptest.NewRequest("DELETE", "/strategies/"+uuid.New().String()+"/aggregations", strings.NewReader("bad json")).
+			WithContext(auth.WithUser(context.Background(), &user.User{ID: uuid.New()}))
+
+		handler.RemoveAggregation(c)
+
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestSetScope(t *testing.T) {
+	send := func(policy *mockPolicy, body string) *httptest.ResponseRecorder {
+		handler := New(policy, mockService{}, nil)
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: uuid.New().String()})
+
+		c.Request = httptest.NewRequest("PUT", "/strategies/"+uuid.New().String()+"/scope", strings.NewReader(body)).
+			WithContext(auth.WithUser(context.Background(), &user.User{ID: uuid.New()}))
+
+		handler.SetScope(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := send(&mockPolicy{allowed: true}, `{"hosts": ["amazon.com", "ebay.com"]}`)
+
+		if w.Code != 204 {
+			t.Errorf("Expected 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		w := send(&mockPolicy{allowed: true}, `{"hosts": ["amazon.com"], "host_glob": "*.amazon.com"}`)
+
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		w := send(&mockPolicy{allowed: false, reason: "reason"}, `{"host_glob": "*.amazon.com"}`)
+
+		if w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
+
+type mockEstimator struct {
+	sampleShards int
+}
+
+func (m *mockEstimator) Estimate(ctx context.Context, strat *strategy.Strategy, sampleShards int) (*strategy.Estimate, error) {
+	m.sampleShards = sampleShards
+
+	return &strategy.Estimate{
+		Shards:       100,
+		Pages:        2000,
+		Evaluations:  4000,
+		Rows:         2000,
+		Cost:         300_000,
+		SampleShards: []int{7},
+		SampleRows:   1,
+	}, nil
+}
+
+func TestEstimate(t *testing.T) {
+	send := func(policy *mockPolicy, estimator strategy.Estimator, body string) *httptest.ResponseRecorder {
+		handler := New(policy, mockService{returnStrategy: &strategy.Strategy{ID: uuid.New()}}, estimator)
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: uuid.New().String()})
+
+		c.Request = httptest.NewRequest("POST", "/strategies/"+uuid.New().String()+"/estimate", strings.NewReader(body)).
+			WithContext(auth.WithUser(context.Background(), &user.User{ID: uuid.New()}))
+
+		handler.Estimate(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		estimator := &mockEstimator{}
+
+		w := send(&mockPolicy{allowed: true}, estimator, `{"sample_shards": 3}`)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		if estimator.sampleShards != 3 {
+			t.Errorf("Expected 3 sample shards, got %d", estimator.sampleShards)
+		}
+
+		var res dto.EstimateResponse
+
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if res.Estimate.Pages != 2000 || res.Estimate.Cost != 300_000 || res.Estimate.SampleRows != 1 {
+			t.Errorf("Expected the estimate, got %+v", res.Estimate)
+		}
+	})
+
+	t.Run("without a body", func(t *testing.T) {
+		estimator := &mockEstimator{}
+
+		if w := send(&mockPolicy{allowed: true}, estimator, ""); w.Code != 200 {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+
+		if estimator.sampleShards != 0 {
+			t.Errorf("Expected no sample, got %d", estimator.sampleShards)
+		}
+	})
+
+	t.Run("too many sample shards", func(t *testing.T) {
+		if w := send(&mockPolicy{allowed: true}, &mockEstimator{}, `{"sample_shards": 11}`); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		if w := send(&mockPolicy{allowed: false, reason: "reason"}, &mockEstimator{}, ""); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
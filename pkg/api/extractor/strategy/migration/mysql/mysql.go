@@ -29,6 +29,24 @@ var migrations = map[string]string{
 			filter_id VARCHAR(36) NOT NULL,
 			PRIMARY KEY (strategy_id, filter_id)
 		);`,
+	"create_strategy_aggregations_table": `
+		CREATE TABLE IF NOT EXISTS strategy_aggregations (
+			strategy_id VARCHAR(36) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			op VARCHAR(32) NOT NULL,
+			field VARCHAR(255) NOT NULL DEFAULT '',
+			metric VARCHAR(16) NOT NULL DEFAULT '',
+			value_field VARCHAR(255) NOT NULL DEFAULT '',
+			k INT NOT NULL DEFAULT 0,
+			bucket_interval DOUBLE NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (strategy_id, name)
+		);`,
+	"create_strategy_scopes_table": `
+		CREATE TABLE IF NOT EXISTS strategy_scopes (
+			strategy_id VARCHAR(36) PRIMARY KEY,
+			scope JSON NOT NULL
+		);`,
 }
 
 func ExecuteMigrations(db *sql.DB) error {
@@ -0,0 +1,44 @@
+package mem
+
+import (
+	"juno/pkg/aggregation"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	aggregations map[uuid.UUID][]*aggregation.Aggregation
+}
+
+func New() *Repository {
+	return &Repository{
+		aggregations: make(map[uuid.UUID][]*aggregation.Aggregation),
+	}
+}
+
+func (r *Repository) AddAggregation(strategyID uuid.UUID, agg *aggregation.Aggregation) error {
+	a := *agg
+	r.aggregations[strategyID] = append(r.aggregations[strategyID], &a)
+	return nil
+}
+
+func (r *Repository) ListAggregations(strategyID uuid.UUID) ([]*aggregation.Aggregation, error) {
+	var aggs []*aggregation.Aggregation
+	for _, a := range r.aggregations[strategyID] {
+		agg := *a
+		aggs = append(aggs, &agg)
+	}
+
+	return aggs, nil
+}
+
+func (r *Repository) RemoveAggregation(strategyID uuid.UUID, name string) error {
+	var aggs []*aggregation.Aggregation
+	for _, a := range r.aggregations[strategyID] {
+		if a.Name != name {
+			aggs = append(aggs, a)
+		}
+	}
+	r.aggregations[strategyID] = aggs
+	return nil
+}
@@ -0,0 +1,75 @@
+package mem
+
+import (
+	"juno/pkg/aggregation"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAddAggregation(t *testing.T) {
+	repo := New()
+	strategyID := uuid.New()
+
+	err := repo.AddAggregation(strategyID, &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount})
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	err = repo.AddAggregation(strategyID, &aggregation.Aggregation{Name: "titles", Op: aggregation.OpCountDistinct, Field: "title"})
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if len(repo.aggregations[strategyID]) != 2 {
+		t.Errorf("Expected 2, got %d", len(repo.aggregations[strategyID]))
+	}
+}
+
+func TestRemoveAggregation(t *testing.T) {
+	repo := New()
+	strategyID := uuid.New()
+
+	err := repo.AddAggregation(strategyID, &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount})
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	err = repo.RemoveAggregation(strategyID, "pages")
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if len(repo.aggregations[strategyID]) != 0 {
+		t.Errorf("Expected 0, got %d", len(repo.aggregations[strategyID]))
+	}
+}
+
+func TestListAggregations(t *testing.T) {
+	repo := New()
+	strategyID := uuid.New()
+
+	err := repo.AddAggregation(strategyID, &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount})
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	list, err := repo.ListAggregations(strategyID)
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("Expected 1, got %d", len(list))
+	}
+
+	if list[0].Name != "pages" {
+		t.Errorf("Expected pages, got %s", list[0].Name)
+	}
+}
@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/aggregation"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) AddAggregation(strategyID uuid.UUID, agg *aggregation.Aggregation) error {
+	_, err := r.db.Exec(
+		"INSERT INTO strategy_aggregations (strategy_id, name, op, field, metric, value_field, k, bucket_interval) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		strategyID, agg.Name, agg.Op, agg.Field, agg.Metric, agg.Value, agg.K, agg.Interval,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) ListAggregations(strategyID uuid.UUID) ([]*aggregation.Aggregation, error) {
+	rows, err := r.db.Query("SELECT name, op, field, metric, value_field, k, bucket_interval FROM strategy_aggregations WHERE strategy_id = ? ORDER BY created_at, name", strategyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var aggs []*aggregation.Aggregation
+
+	for rows.Next() {
+		var a aggregation.Aggregation
+
+		err := rows.Scan(&a.Name, &a.Op, &a.Field, &a.Metric, &a.Value, &a.K, &a.Interval)
+
+		if err != nil {
+			return nil, err
+		}
+
+		aggs = append(aggs, &a)
+	}
+
+	return aggs, nil
+}
+
+func (r *Repository) RemoveAggregation(strategyID uuid.UUID, name string) error {
+	_, err := r.db.Exec("DELETE FROM strategy_aggregations WHERE strategy_id = ? AND name = ?", strategyID, name)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
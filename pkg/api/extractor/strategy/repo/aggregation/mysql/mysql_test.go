@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/aggregation"
+	"juno/pkg/api/extractor/strategy/migration/mysql"
+	"log"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func setupDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/strategy_test?parseTime=true")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = mysql.ExecuteMigrations(db)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestRepo(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db := setupDB(t)
+		defer db.Close()
+
+		repo := New(db)
+
+		id := uuid.New()
+		agg := &aggregation.Aggregation{
+			Name:   "revenue",
+			Op:     aggregation.OpGroupBy,
+			Field:  "category",
+			Metric: aggregation.MetricSum,
+			Value:  "price",
+		}
+
+		err := repo.AddAggregation(id, agg)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		aggs, err := repo.ListAggregations(id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(aggs) != 1 {
+			t.Fatalf("expected 1, got %d", len(aggs))
+		}
+
+		if *aggs[0] != *agg {
+			t.Errorf("expected %+v, got %+v", agg, aggs[0])
+		}
+
+		err = repo.RemoveAggregation(id, agg.Name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		aggs, err = repo.ListAggregations(id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(aggs) != 0 {
+			t.Errorf("expected 0, got %d", len(aggs))
+		}
+	})
+}
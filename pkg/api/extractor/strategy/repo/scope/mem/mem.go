@@ -0,0 +1,37 @@
+package mem
+
+import (
+	"juno/pkg/scope"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	scopes map[uuid.UUID]scope.Scope
+}
+
+func New() *Repository {
+	return &Repository{
+		scopes: make(map[uuid.UUID]scope.Scope),
+	}
+}
+
+func (r *Repository) SetScope(strategyID uuid.UUID, s *scope.Scope) error {
+	if s.Empty() {
+		delete(r.scopes, strategyID)
+		return nil
+	}
+
+	r.scopes[strategyID] = *s
+	return nil
+}
+
+func (r *Repository) GetScope(strategyID uuid.UUID) (*scope.Scope, error) {
+	s, ok := r.scopes[strategyID]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,45 @@
+package mem
+
+import (
+	"juno/pkg/scope"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSetScope(t *testing.T) {
+	repo := New()
+	strategyID := uuid.New()
+
+	err := repo.SetScope(strategyID, &scope.Scope{Hosts: []string{"example.com"}})
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	s, err := repo.GetScope(strategyID)
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if s == nil || len(s.Hosts) != 1 || s.Hosts[0] != "example.com" {
+		t.Errorf("Expected example.com, got %+v", s)
+	}
+
+	err = repo.SetScope(strategyID, &scope.Scope{})
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	s, err = repo.GetScope(strategyID)
+
+	if err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if s != nil {
+		t.Errorf("Expected the scope to be cleared, got %+v", s)
+	}
+}
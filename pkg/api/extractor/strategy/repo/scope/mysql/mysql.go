@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"juno/pkg/scope"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) SetScope(strategyID uuid.UUID, s *scope.Scope) error {
+	if s.Empty() {
+		_, err := r.db.Exec("DELETE FROM strategy_scopes WHERE strategy_id = ?", strategyID)
+		return err
+	}
+
+	encoded, err := json.Marshal(s)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
+		"INSERT INTO strategy_scopes (strategy_id, scope) VALUES (?, ?) ON DUPLICATE KEY UPDATE scope = VALUES(scope)",
+		strategyID, encoded,
+	)
+
+	return err
+}
+
+func (r *Repository) GetScope(strategyID uuid.UUID) (*scope.Scope, error) {
+	var encoded string
+
+	err := r.db.QueryRow("SELECT scope FROM strategy_scopes WHERE strategy_id = ?", strategyID).Scan(&encoded)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var s scope.Scope
+
+	if err := json.Unmarshal([]byte(encoded), &s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
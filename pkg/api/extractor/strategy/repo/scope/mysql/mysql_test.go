@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/extractor/strategy/migration/mysql"
+	"juno/pkg/scope"
+	"log"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func setupDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/strategy_test?parseTime=true")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = mysql.ExecuteMigrations(db)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestRepo(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db := setupDB(t)
+		defer db.Close()
+
+		repo := New(db)
+
+		id := uuid.New()
+		defer db.Exec("DELETE FROM strategy_scopes WHERE strategy_id = ?", id)
+
+		err := repo.SetScope(id, &scope.Scope{HostGlob: "*.example.com"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := repo.GetScope(id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s == nil || s.HostGlob != "*.example.com" {
+			t.Errorf("Expected *.example.com, got %+v", s)
+		}
+
+		if err := repo.SetScope(id, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err = repo.GetScope(id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s != nil {
+			t.Errorf("Expected the scope to be cleared, got %+v", s)
+		}
+	})
+}
@@ -1,10 +1,12 @@
 package service
 
 import (
+	"juno/pkg/aggregation"
 	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/filter"
 	"juno/pkg/api/extractor/selector"
 	"juno/pkg/api/extractor/strategy"
+	"juno/pkg/scope"
 
 	"github.com/google/uuid"
 )
@@ -14,6 +16,8 @@ type Service struct {
 	stratFilterRepo   strategy.StrategyFilterRepository
 	stratFieldRepo    strategy.StrategyFieldRepository
 	stratSelectorRepo strategy.StrategySelectorRepository
+	stratAggRepo      strategy.StrategyAggregationRepository
+	stratScopeRepo    strategy.StrategyScopeRepository
 
 	filterService   filter.Service
 	fieldService    field.Service
@@ -25,6 +29,8 @@ func New(
 	stratFilterRepo strategy.StrategyFilterRepository,
 	stratFieldRepo strategy.StrategyFieldRepository,
 	stratSelectorRepo strategy.StrategySelectorRepository,
+	stratAggRepo strategy.StrategyAggregationRepository,
+	stratScopeRepo strategy.StrategyScopeRepository,
 	filterService filter.Service,
 	fieldService field.Service,
 	selectorService selector.Service,
@@ -34,6 +40,8 @@ func New(
 		stratFilterRepo:   stratFilterRepo,
 		stratFieldRepo:    stratFieldRepo,
 		stratSelectorRepo: stratSelectorRepo,
+		stratAggRepo:      stratAggRepo,
+		stratScopeRepo:    stratScopeRepo,
 		filterService:     filterService,
 		fieldService:      fieldService,
 		selectorService:   selectorService,
@@ -117,6 +125,18 @@ func (s *Service) Get(id uuid.UUID) (*strategy.Strategy, error) {
 		strat.Fields = append(strat.Fields, field)
 	}
 
+	strat.Aggregations, err = s.stratAggRepo.ListAggregations(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	strat.Scope, err = s.stratScopeRepo.GetScope(id)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return strat, nil
 }
 
@@ -184,6 +204,18 @@ func (s *Service) ListByUserID(userID uuid.UUID) ([]*strategy.Strategy, error) {
 
 			strat.Fields = append(strat.Fields, field)
 		}
+
+		strat.Aggregations, err = s.stratAggRepo.ListAggregations(strat.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		strat.Scope, err = s.stratScopeRepo.GetScope(strat.ID)
+
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return strats, nil
@@ -267,3 +299,49 @@ func (s *Service) RemoveField(id, fieldID uuid.UUID) error {
 
 	return s.stratFieldRepo.RemoveField(id, fieldID)
 }
+
+func (s *Service) AddAggregation(id uuid.UUID, agg *aggregation.Aggregation) error {
+	if _, err := s.strategyRepo.Get(id); err != nil {
+		return err
+	}
+
+	if err := agg.Validate(); err != nil {
+		return err
+	}
+
+	aggs, err := s.stratAggRepo.ListAggregations(id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, a := range aggs {
+		if a.Name == agg.Name {
+			return strategy.ErrAggregationExists
+		}
+	}
+
+	return s.stratAggRepo.AddAggregation(id, agg)
+}
+
+func (s *Service) RemoveAggregation(id uuid.UUID, name string) error {
+	if _, err := s.strategyRepo.Get(id); err != nil {
+		return err
+	}
+
+	return s.stratAggRepo.RemoveAggregation(id, name)
+}
+
+func (s *Service) SetScope(id uuid.UUID, sc *scope.Scope) error {
+	if _, err := s.strategyRepo.Get(id); err != nil {
+		return err
+	}
+
+	if sc != nil {
+		if err := sc.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return s.stratScopeRepo.SetScope(id, sc)
+}
@@ -1,12 +1,16 @@
 package service
 
 import (
+	"juno/pkg/aggregation"
 	"juno/pkg/api/extractor/strategy"
 	"juno/pkg/api/extractor/strategy/repo/strategy/mem"
+	"juno/pkg/scope"
 	"testing"
 
+	stratAggRepo "juno/pkg/api/extractor/strategy/repo/aggregation/mem"
 	stratFieldRepo "juno/pkg/api/extractor/strategy/repo/field/mem"
 	stratFilterRepo "juno/pkg/api/extractor/strategy/repo/filter/mem"
+	stratScopeRepo "juno/pkg/api/extractor/strategy/repo/scope/mem"
 	stratSelectorRepo "juno/pkg/api/extractor/strategy/repo/selector/mem"
 
 	fieldRepo "juno/pkg/api/extractor/field/repo/mem"
@@ -35,6 +39,8 @@ func setup() (*Service, *mem.Repository, *stratFieldRepo.Repository, *stratSelec
 		stratFilterRepo,
 		stratFieldRepo,
 		stratSelectorRepo,
+		stratAggRepo.New(),
+		stratScopeRepo.New(),
 		filterService,
 		fieldService,
 		selectorService)
@@ -549,3 +555,169 @@ func TestRemoveSelector(t *testing.T) {
 		}
 	})
 }
+
+func TestAddAggregation(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		service, strategyRepo, _, _, _, _, _, _ := setup()
+
+		strat := &strategy.Strategy{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Name:   "strategy_name",
+		}
+
+		strategyRepo.Create(strat)
+
+		err := service.AddAggregation(strat.ID, &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount})
+
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		check, _ := service.Get(strat.ID)
+
+		if len(check.Aggregations) != 1 {
+			t.Fatalf("Expected 1, got %d", len(check.Aggregations))
+		}
+
+		if check.Aggregations[0].Name != "pages" {
+			t.Errorf("Expected pages, got %s", check.Aggregations[0].Name)
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		service, strategyRepo, _, _, _, _, _, _ := setup()
+
+		strat := &strategy.Strategy{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Name:   "strategy_name",
+		}
+
+		strategyRepo.Create(strat)
+
+		agg := &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount}
+
+		if err := service.AddAggregation(strat.ID, agg); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.AddAggregation(strat.ID, agg); err != strategy.ErrAggregationExists {
+			t.Errorf("Expected %v, got %v", strategy.ErrAggregationExists, err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		service, strategyRepo, _, _, _, _, _, _ := setup()
+
+		strat := &strategy.Strategy{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Name:   "strategy_name",
+		}
+
+		strategyRepo.Create(strat)
+
+		err := service.AddAggregation(strat.ID, &aggregation.Aggregation{Name: "top", Op: aggregation.OpTopK, Field: "title"})
+
+		if err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		service, _, _, _, _, _, _, _ := setup()
+
+		err := service.AddAggregation(uuid.New(), &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount})
+
+		if err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+}
+
+func TestRemoveAggregation(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		service, strategyRepo, _, _, _, _, _, _ := setup()
+
+		strat := &strategy.Strategy{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Name:   "strategy_name",
+		}
+
+		strategyRepo.Create(strat)
+
+		if err := service.AddAggregation(strat.ID, &aggregation.Aggregation{Name: "pages", Op: aggregation.OpCount}); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := service.RemoveAggregation(strat.ID, "pages"); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		check, _ := service.Get(strat.ID)
+
+		if len(check.Aggregations) != 0 {
+			t.Errorf("Expected 0, got %d", len(check.Aggregations))
+		}
+	})
+}
+
+func TestSetScope(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		service, strategyRepo, _, _, _, _, _, _ := setup()
+
+		strat := &strategy.Strategy{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Name:   "strategy_name",
+		}
+
+		strategyRepo.Create(strat)
+
+		if err := service.SetScope(strat.ID, &scope.Scope{URLPrefix: "https://example.com/dp/"}); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, _ := service.Get(strat.ID)
+
+		if check.Scope == nil || check.Scope.URLPrefix != "https://example.com/dp/" {
+			t.Errorf("Expected the url prefix scope, got %+v", check.Scope)
+		}
+
+		if err := service.SetScope(strat.ID, nil); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		check, _ = service.Get(strat.ID)
+
+		if check.Scope != nil {
+			t.Errorf("Expected no scope, got %+v", check.Scope)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		service, strategyRepo, _, _, _, _, _, _ := setup()
+
+		strat := &strategy.Strategy{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Name:   "strategy_name",
+		}
+
+		strategyRepo.Create(strat)
+
+		if err := service.SetScope(strat.ID, &scope.Scope{URLPrefix: "/dp/"}); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		service, _, _, _, _, _, _, _ := setup()
+
+		if err := service.SetScope(uuid.New(), &scope.Scope{HostGlob: "*.example.com"}); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+}
@@ -2,21 +2,20 @@ package middleware
 
 import (
 	"context"
+	"juno/pkg/api/apikey"
 	"juno/pkg/api/auth"
-	"juno/pkg/api/auth/service"
+	"juno/pkg/api/user"
 	"net/http"
-	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware checks if the user is authenticated
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware checks if the user is authenticated, with a session token of
+// authService or an API key of apiKeyService. Without apiKeyService only
+// session tokens are accepted.
+func AuthMiddleware(authService auth.Service, apiKeyService apikey.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if os.Getenv("SECRET") == "" {
-			panic("SECRET environment variable required")
-		}
-
 		token := c.GetHeader("Authorization")
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -24,7 +23,12 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		u, err := service.TokenToUser(token)
+		if secret := strings.TrimPrefix(token, "Bearer "); apiKeyService != nil && strings.HasPrefix(secret, apikey.Prefix) {
+			authenticateKey(c, apiKeyService, secret)
+			return
+		}
+
+		u, err := authService.TokenToUser(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort() // Prevent further handlers from running
@@ -35,3 +39,26 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// authenticateKey lets the request through on behalf of the key's owner,
+// keeping the key in the context for its scopes to be checked.
+func authenticateKey(c *gin.Context, apiKeyService apikey.Service, secret string) {
+	k, owner, err := apiKeyService.Authenticate(secret)
+
+	switch err {
+	case nil:
+	case apikey.ErrInvalidKey, apikey.ErrExpiredKey:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate"})
+		c.Abort()
+		return
+	}
+
+	u := &user.User{ID: owner.ID, Name: owner.Name, Email: owner.Email}
+
+	c.Request = c.Request.WithContext(auth.WithKey(auth.WithUser(context.Background(), u), k))
+	c.Next()
+}
@@ -1,7 +1,11 @@
 package middleware
 
 import (
+	"juno/pkg/api/apikey"
 	"juno/pkg/api/auth"
+	authRepo "juno/pkg/api/auth/repo/mem"
+	"juno/pkg/api/auth/service"
+	"juno/pkg/api/user"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -11,11 +15,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 // Helper function to generate a JWT token for testing
 func generateValidJWT(userID uuid.UUID, email string) string {
 	claims := jwt.MapClaims{
+		"jti":   uuid.NewString(),
 		"id":    userID.String(),
 		"email": email,
 		"name":  "Test User",
@@ -27,6 +33,10 @@ func generateValidJWT(userID uuid.UUID, email string) string {
 	return tokenString
 }
 
+func newAuthService() *service.Service {
+	return service.New(logrus.New(), nil, authRepo.New())
+}
+
 // TestAuthMiddleware tests the AuthMiddleware for various cases
 func TestAuthMiddleware(t *testing.T) {
 	// Set up the SECRET environment variable
@@ -35,7 +45,7 @@ func TestAuthMiddleware(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.Use(AuthMiddleware())
+	r.Use(AuthMiddleware(newAuthService(), nil))
 
 	// Define a protected route
 	r.GET("/protected", func(c *gin.Context) {
@@ -122,3 +132,91 @@ func TestAuthMiddleware(t *testing.T) {
 		}
 	})
 }
+
+type mockApiKeyService struct {
+	apikey.Service
+	key *apikey.Key
+}
+
+func (m *mockApiKeyService) Authenticate(secret string) (*apikey.Key, *user.User, error) {
+	if secret != apikey.Prefix+"secret" {
+		return nil, nil, apikey.ErrInvalidKey
+	}
+
+	return m.key, &user.User{ID: m.key.UserID, Password: "hash"}, nil
+}
+
+func TestAuthMiddlewareApiKey(t *testing.T) {
+	os.Setenv("SECRET", "mysecretkey")
+	gin.SetMode(gin.TestMode)
+
+	k := &apikey.Key{ID: uuid.New(), UserID: uuid.New(), Scopes: []apikey.Scope{apikey.JobsRead}}
+
+	r := gin.New()
+	r.Use(AuthMiddleware(newAuthService(), &mockApiKeyService{key: k}))
+	r.GET("/protected", func(c *gin.Context) {
+		u := auth.MustUserFromContext(c.Request.Context())
+		key, ok := auth.KeyFromContext(c.Request.Context())
+
+		if u.Password != "" || ok && key.ID != k.ID {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unexpected context"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"user_id": u.ID.String()})
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"valid key", apikey.Prefix + "secret", http.StatusOK},
+		{"valid bearer key", "Bearer " + apikey.Prefix + "secret", http.StatusOK},
+		{"invalid key", apikey.Prefix + "wrong", http.StatusUnauthorized},
+		{"session token", generateValidJWT(uuid.New(), "test@example.com"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", tt.header)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, but got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRevokedToken(t *testing.T) {
+	os.Setenv("SECRET", "mysecretkey")
+	gin.SetMode(gin.TestMode)
+
+	authService := newAuthService()
+
+	r := gin.New()
+	r.Use(AuthMiddleware(authService, nil))
+	r.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+	})
+
+	token := generateValidJWT(uuid.New(), "test@example.com")
+
+	if err := authService.Logout(token, ""); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, but got %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportAuth only lets requests through that carry one of the report tokens
+// as a bearer token. Each balancer gets a token of its own, so one can be
+// revoked without the others and none of them reaches the admin API. No
+// tokens disable the report endpoints.
+func ReportAuth(tokens []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(tokens) == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Reports disabled"})
+			c.Abort()
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.Abort()
+			return
+		}
+
+		given := strings.TrimPrefix(header, "Bearer ")
+		for _, token := range tokens {
+			if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReportAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(tokens []string) *gin.Engine {
+		r := gin.New()
+		r.Use(ReportAuth(tokens))
+		r.POST("/crawls", func(c *gin.Context) {
+			c.JSON(http.StatusCreated, gin.H{"message": "Reported"})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		tokens []string
+		header string
+		status int
+	}{
+		{"missing authorization header", []string{"one"}, "", http.StatusUnauthorized},
+		{"wrong token", []string{"one", "two"}, "Bearer wrong", http.StatusUnauthorized},
+		{"empty token", []string{"one", ""}, "Bearer ", http.StatusUnauthorized},
+		{"token of one balancer", []string{"one", "two"}, "Bearer one", http.StatusCreated},
+		{"token of another balancer", []string{"one", "two"}, "Bearer two", http.StatusCreated},
+		{"reports disabled", nil, "Bearer one", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/crawls", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			newRouter(tt.tokens).ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d but got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
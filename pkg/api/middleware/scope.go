@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope lets requests through that were made with a session token, or
+// with an API key carrying any of the scopes. Without scopes no API key is let
+// through, for what only the user should do themselves.
+func RequireScope(scopes ...apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k, ok := auth.KeyFromContext(c.Request.Context())
+
+		if !ok {
+			c.Next()
+			return
+		}
+
+		for _, scope := range scopes {
+			if k.HasScope(scope) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks the required scope"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"juno/pkg/api/apikey"
+	"juno/pkg/api/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(k *apikey.Key, scopes ...apikey.Scope) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if k != nil {
+				c.Request = c.Request.WithContext(auth.WithKey(context.Background(), k))
+			}
+		})
+		r.Use(RequireScope(scopes...))
+		r.GET("/jobs", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+		})
+		return r
+	}
+
+	reader := &apikey.Key{Scopes: []apikey.Scope{apikey.JobsRead}}
+
+	tests := []struct {
+		name   string
+		key    *apikey.Key
+		scopes []apikey.Scope
+		status int
+	}{
+		{"session token", nil, []apikey.Scope{apikey.JobsWrite}, http.StatusOK},
+		{"key with the scope", reader, []apikey.Scope{apikey.JobsRead}, http.StatusOK},
+		{"key with one of the scopes", reader, []apikey.Scope{apikey.StrategiesWrite, apikey.JobsRead}, http.StatusOK},
+		{"key without the scope", reader, []apikey.Scope{apikey.JobsWrite}, http.StatusForbidden},
+		{"session only", reader, nil, http.StatusForbidden},
+		{"session only with a session token", nil, nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/jobs", nil)
+			w := httptest.NewRecorder()
+
+			newRouter(tt.key, tt.scopes...).ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d but got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package mismatch
+
+import (
+	"errors"
+	"juno/pkg/replica"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoConflict      = errors.New("replicas agree")
+	ErrDuplicateReport = errors.New("mismatch already reported")
+)
+
+const (
+	// DefaultThreshold is how many mismatches a node may be blamed for
+	// within DefaultWindow before it is excluded for DefaultExclusion.
+	DefaultThreshold = 3
+	DefaultWindow    = 24 * time.Hour
+	DefaultExclusion = 24 * time.Hour
+	// DefaultEntriesLimit is how many of a node's latest entries are listed.
+	DefaultEntriesLimit = 50
+	// MaxAnswerAge is how long after a node signed an answer it is still
+	// compared, and MaxClockSkew how far ahead of ours its clock may be.
+	MaxAnswerAge = time.Hour
+	MaxClockSkew = time.Minute
+)
+
+// Report is a mismatch between the replicas of a shard that verified the
+// answer of a ranag to a request.
+type Report struct {
+	ID        uuid.UUID
+	RanagID   uuid.UUID
+	RequestID string
+	Shard     int
+	// Conflicts are the versions the replicas that signed their answers
+	// disagreed on
+	Conflicts []*replica.Conflict
+	CreatedAt time.Time
+}
+
+// Entry is a node's part in a mismatch, which makes up the node's ledger.
+type Entry struct {
+	ID       uuid.UUID
+	ReportID uuid.UUID
+	NodeID   uuid.UUID
+	Shard    int
+	// Version is the version the node disagreed on, and Digest what it
+	// answered for it
+	Version string
+	Digest  string
+	// Blamed is set when the node disagreed with the majority
+	Blamed    bool
+	CreatedAt time.Time
+}
+
+type Repository interface {
+	// Create stores the report with its entries. It returns
+	// ErrDuplicateReport when the shard was already reported for the ranag's
+	// request.
+	Create(r *Report, entries []*Entry) error
+	// CountBlamed counts the entries the node was blamed in since the time.
+	CountBlamed(nodeID uuid.UUID, since time.Time) (int, error)
+	// ListEntries lists the node's latest entries, newest first.
+	ListEntries(nodeID uuid.UUID, limit int) ([]*Entry, error)
+}
+
+type Service interface {
+	// Report records the mismatch between the replicas that verified a shard
+	// of the request sent to the ranag. Only answers the nodes signed for the
+	// request are compared. Nodes that disagreed with the majority lose
+	// reputation, and repeat offenders are excluded from the shard maps for a
+	// while. It returns ErrNoConflict when the signed answers agree.
+	Report(ranagID uuid.UUID, requestID string, shard int, v *replica.Verification) (*Report, error)
+	ListEntries(nodeID uuid.UUID) ([]*Entry, error)
+}
+
+type Handler interface {
+	Entries(c *gin.Context)
+}
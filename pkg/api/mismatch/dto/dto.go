@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"juno/pkg/api/mismatch"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type Entry struct {
+	ID        string `json:"id"`
+	ReportID  string `json:"report_id"`
+	Shard     int    `json:"shard"`
+	Version   string `json:"version"`
+	Digest    string `json:"digest"`
+	Blamed    bool   `json:"blamed"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListEntriesResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message,omitempty"`
+	Entries []*Entry `json:"entries,omitempty"`
+}
+
+func NewSuccessListEntriesResponse(entries []*mismatch.Entry) *ListEntriesResponse {
+	res := make([]*Entry, len(entries))
+
+	for i, e := range entries {
+		res[i] = &Entry{
+			ID:        e.ID.String(),
+			ReportID:  e.ReportID.String(),
+			Shard:     e.Shard,
+			Version:   e.Version,
+			Digest:    e.Digest,
+			Blamed:    e.Blamed,
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return &ListEntriesResponse{
+		Status:  SUCCESS,
+		Entries: res,
+	}
+}
+
+func NewErrorListEntriesResponse(message string) *ListEntriesResponse {
+	return &ListEntriesResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
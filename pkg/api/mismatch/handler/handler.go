@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/mismatch/dto"
+	"juno/pkg/api/node"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger          logrus.FieldLogger
+	nodePolicy      node.Policy
+	nodeService     node.Service
+	mismatchService mismatch.Service
+}
+
+func New(logger logrus.FieldLogger, nodePolicy node.Policy, nodeService node.Service, mismatchService mismatch.Service) *Handler {
+	return &Handler{
+		logger:          logger,
+		nodePolicy:      nodePolicy,
+		nodeService:     nodeService,
+		mismatchService: mismatchService,
+	}
+}
+
+// Entries lists the latest mismatches of a node to its owner.
+func (h *Handler) Entries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, dto.NewErrorListEntriesResponse(err.Error()))
+		return
+	}
+
+	n, err := h.nodeService.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorListEntriesResponse(node.ErrNotFound.Error()))
+		return
+	}
+
+	h.nodePolicy.CanRead(c.Request.Context(), n).
+		Allow(func() {
+			entries, err := h.mismatchService.ListEntries(n.ID)
+
+			if err != nil {
+				h.logger.WithError(err).Error("failed to list mismatches")
+				c.JSON(500, dto.NewErrorListEntriesResponse("failed to list mismatches"))
+				return
+			}
+
+			c.JSON(200, dto.NewSuccessListEntriesResponse(entries))
+		}).
+		Deny(func(reason string) {
+			c.JSON(404, dto.NewErrorListEntriesResponse(node.ErrNotFound.Error()))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorListEntriesResponse(node.ErrInternal.Error()))
+		})
+}
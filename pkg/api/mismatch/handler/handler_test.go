@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/mismatch/dto"
+	nodePolicy "juno/pkg/api/node/policy"
+	nodeRepo "juno/pkg/api/node/repo/mem"
+	nodeService "juno/pkg/api/node/service"
+	"juno/pkg/api/user"
+	"juno/pkg/replica"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type mockMismatchService struct {
+	entries []*mismatch.Entry
+}
+
+func (m *mockMismatchService) Report(ranagID uuid.UUID, requestID string, shard int, v *replica.Verification) (*mismatch.Report, error) {
+	return nil, mismatch.ErrNoConflict
+}
+
+func (m *mockMismatchService) ListEntries(nodeID uuid.UUID) ([]*mismatch.Entry, error) {
+	return m.entries, nil
+}
+
+func TestEntries(t *testing.T) {
+	nodes := nodeService.New(nodeRepo.New())
+	ownerID := uuid.New()
+
+	n, err := nodes.Create(ownerID, "node1.example.com:8080", [][2]int{{0, 10}})
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	service := &mockMismatchService{
+		entries: []*mismatch.Entry{{ID: uuid.New(), NodeID: n.ID, Shard: 3, Version: "v1", Digest: "y", Blamed: true}},
+	}
+
+	entries := func(userID uuid.UUID, id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+
+		tc.Request = httptest.NewRequestWithContext(
+			auth.WithUser(context.Background(), &user.User{ID: userID}),
+			"GET",
+			"/nodes/"+id+"/mismatches",
+			nil,
+		)
+		tc.Params = gin.Params{{Key: "id", Value: id}}
+
+		New(logrus.New(), nodePolicy.New(), nodes, service).Entries(tc)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := entries(ownerID, n.ID.String())
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		var res dto.ListEntriesResponse
+
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(res.Entries) != 1 || !res.Entries[0].Blamed {
+			t.Errorf("Expected the blamed entry, got %+v", res.Entries)
+		}
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		if w := entries(uuid.New(), n.ID.String()); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		if w := entries(ownerID, "nope"); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
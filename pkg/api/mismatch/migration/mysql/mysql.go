@@ -0,0 +1,60 @@
+package mysql
+
+import "database/sql"
+
+var migrations = map[string]string{
+	"create_mismatch_reports_table": `
+		CREATE TABLE IF NOT EXISTS mismatch_reports (
+			id VARCHAR(36) PRIMARY KEY,
+			ranag_id VARCHAR(36) NOT NULL,
+			request_id VARCHAR(64) NOT NULL,
+			shard INT NOT NULL,
+			conflicts TEXT NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			UNIQUE (ranag_id, request_id, shard)
+		);`,
+
+	"create_mismatch_entries_table": `
+		CREATE TABLE IF NOT EXISTS mismatch_entries (
+			id VARCHAR(36) PRIMARY KEY,
+			report_id VARCHAR(36) NOT NULL,
+			node_id VARCHAR(36) NOT NULL,
+			shard INT NOT NULL,
+			version TEXT NOT NULL,
+			digest VARCHAR(64) NOT NULL,
+			blamed BOOLEAN NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX (node_id, created_at)
+		);`,
+}
+
+func ExecuteMigrations(db *sql.DB) error {
+
+	// create migrations table if it doesn't exist
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+			name VARCHAR(255) NOT NULL PRIMARY KEY
+		);`); err != nil {
+		return err
+	}
+
+	for name, migration := range migrations {
+
+		// check if migration has already been executed
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = ?", name).Scan(&count); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(migration); err != nil {
+			return err
+		}
+
+		db.Exec("INSERT INTO migrations (name) VALUES (?)", name)
+	}
+
+	return nil
+}
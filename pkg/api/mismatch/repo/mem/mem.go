@@ -0,0 +1,81 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/mismatch"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	mu      sync.Mutex
+	reports map[uuid.UUID]mismatch.Report
+	entries map[uuid.UUID]mismatch.Entry
+}
+
+func New() *Repository {
+	return &Repository{
+		reports: make(map[uuid.UUID]mismatch.Report),
+		entries: make(map[uuid.UUID]mismatch.Entry),
+	}
+}
+
+func (r *Repository) Create(report *mismatch.Report, entries []*mismatch.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.reports[report.ID]; ok {
+		return errors.New("primary key violation")
+	}
+
+	for _, stored := range r.reports {
+		if stored.RanagID == report.RanagID && stored.RequestID == report.RequestID && stored.Shard == report.Shard {
+			return mismatch.ErrDuplicateReport
+		}
+	}
+
+	r.reports[report.ID] = *report
+
+	for _, e := range entries {
+		r.entries[e.ID] = *e
+	}
+
+	return nil
+}
+
+func (r *Repository) CountBlamed(nodeID uuid.UUID, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+
+	for _, e := range r.entries {
+		if e.NodeID == nodeID && e.Blamed && !e.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *Repository) ListEntries(nodeID uuid.UUID, limit int) ([]*mismatch.Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []*mismatch.Entry
+
+	for _, e := range r.entries {
+		if e.NodeID == nodeID {
+			entries = append(entries, &e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+
+	return entries[:min(limit, len(entries))], nil
+}
@@ -0,0 +1,74 @@
+package mem
+
+import (
+	"juno/pkg/api/mismatch"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newReport(ranagID uuid.UUID, requestID string, nodeID uuid.UUID, blamed bool, createdAt time.Time) (*mismatch.Report, []*mismatch.Entry) {
+	r := &mismatch.Report{ID: uuid.New(), RanagID: ranagID, RequestID: requestID, Shard: 3, CreatedAt: createdAt}
+
+	return r, []*mismatch.Entry{
+		{ID: uuid.New(), ReportID: r.ID, NodeID: nodeID, Shard: 3, Blamed: blamed, CreatedAt: createdAt},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	repo := New()
+	ranagID := uuid.New()
+
+	if err := repo.Create(newReport(ranagID, "req-1", uuid.New(), true, time.Now())); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := repo.Create(newReport(ranagID, "req-1", uuid.New(), true, time.Now())); err != mismatch.ErrDuplicateReport {
+		t.Errorf("Expected %v, got %v", mismatch.ErrDuplicateReport, err)
+	}
+
+	if err := repo.Create(newReport(uuid.New(), "req-1", uuid.New(), true, time.Now())); err != nil {
+		t.Errorf("Expected the report of another ranag stored, got %v", err)
+	}
+}
+
+func TestCountBlamed(t *testing.T) {
+	repo := New()
+	nodeID := uuid.New()
+	now := time.Now()
+
+	repo.Create(newReport(uuid.New(), "req-1", nodeID, true, now.Add(-2*time.Hour)))
+	repo.Create(newReport(uuid.New(), "req-2", nodeID, true, now))
+	repo.Create(newReport(uuid.New(), "req-3", nodeID, false, now))
+
+	count, err := repo.CountBlamed(nodeID, now.Add(-time.Hour))
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Expected 1, got %d", count)
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	repo := New()
+	nodeID := uuid.New()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		repo.Create(newReport(uuid.New(), "req-1", nodeID, i%2 == 0, now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	entries, err := repo.ListEntries(nodeID, 2)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(entries) != 2 || !entries[0].CreatedAt.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("Expected the 2 newest entries, got %+v", entries)
+	}
+}
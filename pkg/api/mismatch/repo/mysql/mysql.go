@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"juno/pkg/api/mismatch"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create stores the report with its entries in one transaction, so a node is
+// never blamed for a report that was not recorded.
+func (r *Repository) Create(report *mismatch.Report, entries []*mismatch.Entry) error {
+	conflicts, err := json.Marshal(report.Conflicts)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	// a report of the same shard for the same request is skipped
+	res, err := tx.Exec(
+		"INSERT IGNORE INTO mismatch_reports (id, ranag_id, request_id, shard, conflicts, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		report.ID, report.RanagID, report.RequestID, report.Shard, string(conflicts), report.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return mismatch.ErrDuplicateReport
+	}
+
+	for _, e := range entries {
+		if _, err := tx.Exec(
+			"INSERT INTO mismatch_entries (id, report_id, node_id, shard, version, digest, blamed, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			e.ID, e.ReportID, e.NodeID, e.Shard, e.Version, e.Digest, e.Blamed, e.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) CountBlamed(nodeID uuid.UUID, since time.Time) (int, error) {
+	var count int
+
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM mismatch_entries WHERE node_id = ? AND blamed AND created_at >= ?",
+		nodeID, since,
+	).Scan(&count)
+
+	return count, err
+}
+
+func (r *Repository) ListEntries(nodeID uuid.UUID, limit int) ([]*mismatch.Entry, error) {
+	rows, err := r.db.Query(
+		"SELECT id, report_id, node_id, shard, version, digest, blamed, created_at FROM mismatch_entries WHERE node_id = ? ORDER BY created_at DESC LIMIT ?",
+		nodeID, limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []*mismatch.Entry
+
+	for rows.Next() {
+		var e mismatch.Entry
+
+		if err := rows.Scan(&e.ID, &e.ReportID, &e.NodeID, &e.Shard, &e.Version, &e.Digest, &e.Blamed, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/mismatch/migration/mysql"
+	"juno/pkg/replica"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/mismatch_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestReports(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	ranagID := uuid.New()
+	nodeID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	defer db.Exec("DELETE FROM mismatch_reports WHERE ranag_id = ?", ranagID)
+	defer db.Exec("DELETE FROM mismatch_entries WHERE node_id = ?", nodeID)
+
+	for i, requestID := range []string{"req-1", "req-2"} {
+		r := &mismatch.Report{
+			ID:        uuid.New(),
+			RanagID:   ranagID,
+			RequestID: requestID,
+			Shard:     3,
+			Conflicts: []*replica.Conflict{{Version: "v1", Digests: map[string]string{"a.com:9090": "x", "b.com:9090": "y"}}},
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+
+		entries := []*mismatch.Entry{
+			{ID: uuid.New(), ReportID: r.ID, NodeID: nodeID, Shard: 3, Version: "v1", Digest: "y", Blamed: i == 1, CreatedAt: r.CreatedAt},
+		}
+
+		if err := repo.Create(r, entries); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+	}
+
+	duplicate := &mismatch.Report{ID: uuid.New(), RanagID: ranagID, RequestID: "req-1", Shard: 3, CreatedAt: now}
+
+	if err := repo.Create(duplicate, nil); err != mismatch.ErrDuplicateReport {
+		t.Errorf("Expected %v, got %v", mismatch.ErrDuplicateReport, err)
+	}
+
+	count, err := repo.CountBlamed(nodeID, now)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Expected 1, got %d", count)
+	}
+
+	entries, err := repo.ListEntries(nodeID, 10)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(entries) != 2 || !entries[0].Blamed {
+		t.Errorf("Expected the blamed entry first, got %+v", entries)
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/node"
+	"juno/pkg/replica"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo        mismatch.Repository
+	nodeService node.Service
+	threshold   int
+	window      time.Duration
+	exclusion   time.Duration
+	now         func() time.Time
+}
+
+// WithExclusion excludes nodes blamed for threshold mismatches within the
+// window from the shard maps for the duration. A threshold of 0 never
+// excludes nodes.
+func WithExclusion(threshold int, window, duration time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.threshold = threshold
+		s.window = window
+		s.exclusion = duration
+	}
+}
+
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func New(repo mismatch.Repository, nodeService node.Service, opts ...func(s *Service)) *Service {
+	s := &Service{
+		repo:        repo,
+		nodeService: nodeService,
+		threshold:   mismatch.DefaultThreshold,
+		window:      mismatch.DefaultWindow,
+		exclusion:   mismatch.DefaultExclusion,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Report compares the answers the replicas signed with their registered key
+// for the shard of the request, recently enough that they are not replayed.
+// Each node gets one entry per report, blamed when it disagreed with the
+// majority on any version, and without a majority no node is blamed.
+func (s *Service) Report(ranagID uuid.UUID, requestID string, shard int, v *replica.Verification) (*mismatch.Report, error) {
+	now := s.now().UTC()
+	nodes := map[string]*node.Node{}
+	signed := &replica.Verification{}
+
+	for _, a := range v.Answers {
+		if _, ok := nodes[a.Node]; ok {
+			continue
+		}
+
+		n, err := s.nodeService.GetByAddress(a.Node)
+
+		if err != nil || !n.HasShard(shard) || a.Check(n.PublicKey, requestID, shard) != nil {
+			continue
+		}
+
+		if a.Receipt.IssuedAt.Before(now.Add(-mismatch.MaxAnswerAge)) || a.Receipt.IssuedAt.After(now.Add(mismatch.MaxClockSkew)) {
+			continue
+		}
+
+		nodes[a.Node] = n
+		signed.Answers = append(signed.Answers, a)
+	}
+
+	conflicts := signed.Conflicts()
+
+	if len(conflicts) == 0 {
+		return nil, mismatch.ErrNoConflict
+	}
+
+	report := &mismatch.Report{
+		ID:        uuid.New(),
+		RanagID:   ranagID,
+		RequestID: requestID,
+		Shard:     shard,
+		Conflicts: conflicts,
+		CreatedAt: now,
+	}
+
+	var entries []*mismatch.Entry
+	byNode := map[string]*mismatch.Entry{}
+
+	for _, c := range conflicts {
+		majority, _ := c.Majority()
+
+		for _, address := range slices.Sorted(maps.Keys(c.Digests)) {
+			digest := c.Digests[address]
+			blamed := majority != "" && digest != majority
+
+			if e, ok := byNode[address]; ok {
+				if blamed && !e.Blamed {
+					e.Version, e.Digest, e.Blamed = c.Version, digest, true
+				}
+				continue
+			}
+
+			e := &mismatch.Entry{
+				ID:        uuid.New(),
+				ReportID:  report.ID,
+				NodeID:    nodes[address].ID,
+				Shard:     shard,
+				Version:   c.Version,
+				Digest:    digest,
+				Blamed:    blamed,
+				CreatedAt: now,
+			}
+
+			byNode[address] = e
+			entries = append(entries, e)
+		}
+	}
+
+	if err := s.repo.Create(report, entries); err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if !e.Blamed {
+			continue
+		}
+
+		if err := s.blame(e.NodeID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// blame lowers the node's reputation, and excludes it once it was blamed too
+// often within the window.
+func (s *Service) blame(nodeID uuid.UUID, now time.Time) error {
+	if _, err := s.nodeService.Score(nodeID, false); err != nil {
+		return err
+	}
+
+	if s.threshold <= 0 {
+		return nil
+	}
+
+	count, err := s.repo.CountBlamed(nodeID, now.Add(-s.window))
+
+	if err != nil {
+		return err
+	}
+
+	if count < s.threshold {
+		return nil
+	}
+
+	_, err = s.nodeService.Exclude(nodeID, now.Add(s.exclusion))
+
+	return err
+}
+
+func (s *Service) ListEntries(nodeID uuid.UUID) ([]*mismatch.Entry, error) {
+	return s.repo.ListEntries(nodeID, mismatch.DefaultEntriesLimit)
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"juno/pkg/api/mismatch"
+	"juno/pkg/api/mismatch/repo/mem"
+	"juno/pkg/api/node"
+	nodeRepo "juno/pkg/api/node/repo/mem"
+	nodeService "juno/pkg/api/node/service"
+	"juno/pkg/receipt"
+	"juno/pkg/replica"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const shard = 7
+
+type fixture struct {
+	service     *Service
+	nodeService *nodeService.Service
+	signers     []*receipt.Signer
+	nodes       []*node.Node
+	ranagID     uuid.UUID
+	now         time.Time
+}
+
+func setup(t *testing.T, opts ...func(s *Service)) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		ranagID: uuid.New(),
+		now:     time.Now().UTC(),
+	}
+
+	clock := func() time.Time { return f.now }
+
+	nodes := nodeRepo.New()
+	f.nodeService = nodeService.New(nodes, nodeService.WithClock(clock))
+
+	for _, addr := range []string{"a.com:9090", "b.com:9090", "c.com:9090"} {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		signer := receipt.NewSigner(key)
+
+		n := node.New(uuid.New(), uuid.New(), addr, [][2]int{{0, 10}})
+		n.PublicKey = signer.PublicKey()
+		nodes.Create(n)
+
+		f.nodes = append(f.nodes, n)
+		f.signers = append(f.signers, signer)
+	}
+
+	f.service = New(mem.New(), f.nodeService, append([]func(s *Service){WithClock(clock)}, opts...)...)
+
+	return f
+}
+
+// answer is what the node signed it yielded from each version, for the shard
+// of the request.
+func (f *fixture) answer(t *testing.T, i int, requestID string, versions map[string]string) *replica.Answer {
+	t.Helper()
+
+	rc := &receipt.Receipt{RequestID: requestID, Shards: []int{shard}, Digest: replica.DigestVersions(versions)}
+
+	if err := f.signers[i].Sign(rc); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return &replica.Answer{Node: f.nodes[i].Address, Receipt: rc, Versions: versions}
+}
+
+// verification is the nodes' signed answers of the digests each yielded from
+// version v1.
+func (f *fixture) verification(t *testing.T, requestID string, digests ...string) *replica.Verification {
+	t.Helper()
+
+	v := &replica.Verification{}
+
+	for i, d := range digests {
+		v.Answers = append(v.Answers, f.answer(t, i, requestID, map[string]string{"v1": d}))
+	}
+
+	return v
+}
+
+// report reports the nodes' signed answers of the digests each yielded from
+// version v1 for the shard of the request.
+func (f *fixture) report(t *testing.T, requestID string, digests ...string) (*mismatch.Report, error) {
+	t.Helper()
+
+	return f.service.Report(f.ranagID, requestID, shard, f.verification(t, requestID, digests...))
+}
+
+func (f *fixture) node(t *testing.T, i int) *node.Node {
+	t.Helper()
+
+	n, err := f.nodeService.Get(f.nodes[i].ID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return n
+}
+
+func TestReport(t *testing.T) {
+	t.Run("blames the node the majority disagrees with", func(t *testing.T) {
+		f := setup(t)
+
+		r, err := f.report(t, "req-1", "x", "y", "x")
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(r.Conflicts) != 1 || r.Conflicts[0].Version != "v1" {
+			t.Errorf("Expected a conflict on v1, got %+v", r.Conflicts)
+		}
+
+		if f.node(t, 1).Reputation >= node.MaxReputation {
+			t.Errorf("Expected the reputation of the odd node lowered")
+		}
+
+		if f.node(t, 0).Reputation != node.MaxReputation || f.node(t, 2).Reputation != node.MaxReputation {
+			t.Errorf("Expected the reputation of the majority kept")
+		}
+
+		entries, _ := f.service.ListEntries(f.nodes[1].ID)
+
+		if len(entries) != 1 || !entries[0].Blamed || entries[0].Version != "v1" || entries[0].Digest != "y" {
+			t.Errorf("Expected the node's ledger to blame it, got %+v", entries)
+		}
+	})
+
+	t.Run("blames no one without a majority", func(t *testing.T) {
+		f := setup(t)
+
+		if _, err := f.report(t, "req-1", "x", "y"); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if f.node(t, i).Reputation != node.MaxReputation {
+				t.Errorf("Expected the reputation of node %d kept", i)
+			}
+
+			entries, _ := f.service.ListEntries(f.nodes[i].ID)
+
+			if len(entries) != 1 || entries[0].Blamed {
+				t.Errorf("Expected an entry without blame, got %+v", entries)
+			}
+		}
+	})
+
+	t.Run("compares only the versions replicas share", func(t *testing.T) {
+		f := setup(t)
+
+		// the odd node alone scanned v2, which the others do not hold
+		v := &replica.Verification{Answers: []*replica.Answer{
+			f.answer(t, 0, "req-1", map[string]string{"v1": "x"}),
+			f.answer(t, 1, "req-1", map[string]string{"v1": "x", "v2": "z"}),
+			f.answer(t, 2, "req-1", map[string]string{"v1": "x"}),
+		}}
+
+		if _, err := f.service.Report(f.ranagID, "req-1", shard, v); err != mismatch.ErrNoConflict {
+			t.Errorf("Expected %v, got %v", mismatch.ErrNoConflict, err)
+		}
+
+		if f.node(t, 1).Reputation != node.MaxReputation {
+			t.Errorf("Expected the reputation of the node kept")
+		}
+	})
+
+	t.Run("blames a node once per report", func(t *testing.T) {
+		f := setup(t, WithExclusion(2, time.Hour, 24*time.Hour))
+
+		v := &replica.Verification{}
+		for i, d := range []string{"x", "y", "x"} {
+			v.Answers = append(v.Answers, f.answer(t, i, "req-1", map[string]string{"v1": d, "v2": d}))
+		}
+
+		if _, err := f.service.Report(f.ranagID, "req-1", shard, v); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if f.node(t, 1).Excluded(f.now) {
+			t.Errorf("Expected the node not excluded")
+		}
+
+		entries, _ := f.service.ListEntries(f.nodes[1].ID)
+
+		if len(entries) != 1 {
+			t.Errorf("Expected one entry, got %+v", entries)
+		}
+	})
+
+	t.Run("excludes repeat offenders", func(t *testing.T) {
+		f := setup(t, WithExclusion(2, time.Hour, 24*time.Hour))
+
+		for i := 0; i < 2; i++ {
+			if _, err := f.report(t, fmt.Sprintf("req-%d", i), "x", "y", "x"); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if excluded := f.node(t, 1).Excluded(f.now); excluded != (i == 1) {
+				t.Errorf("After %d reports: expected excluded %v, got %v", i+1, i == 1, excluded)
+			}
+		}
+
+		shards, _ := f.nodeService.AllShardsNodes()
+
+		for _, n := range shards[shard] {
+			if n.ID == f.nodes[1].ID {
+				t.Errorf("Expected the offender left out of the shard maps")
+			}
+		}
+	})
+
+	t.Run("forgives mismatches outside the window", func(t *testing.T) {
+		f := setup(t, WithExclusion(2, 30*time.Minute, 24*time.Hour))
+
+		f.report(t, "req-1", "x", "y", "x")
+		f.now = f.now.Add(45 * time.Minute)
+		f.report(t, "req-2", "x", "y", "x")
+
+		if f.node(t, 1).Excluded(f.now) {
+			t.Errorf("Expected the node not excluded")
+		}
+	})
+
+	t.Run("leaves out answers the nodes did not sign", func(t *testing.T) {
+		f := setup(t)
+
+		tampered := f.verification(t, "req-1", "x", "y")
+		tampered.Answers[0].Versions = map[string]string{"v1": "y"}
+
+		forged := f.verification(t, "req-1", "x", "y")
+		forged.Answers[0].Receipt.Digest = replica.DigestVersions(map[string]string{"v1": "y"})
+
+		other := f.verification(t, "req-1", "x", "y")
+		other.Answers[0] = f.answer(t, 0, "req-2", map[string]string{"v1": "y"})
+
+		stale := f.verification(t, "req-1", "x", "y")
+
+		for name, test := range map[string]struct {
+			requestID    string
+			verification *replica.Verification
+			now          time.Time
+		}{
+			"tampered digests":   {"req-1", tampered, f.now},
+			"forged receipt":     {"req-1", forged, f.now},
+			"other request":      {"req-1", other, f.now},
+			"unknown request":    {"req-3", f.verification(t, "req-1", "x", "y"), f.now},
+			"stale answers":      {"req-1", stale, f.now.Add(2 * mismatch.MaxAnswerAge)},
+			"answers from ahead": {"req-1", f.verification(t, "req-1", "x", "y"), f.now.Add(-2 * mismatch.MaxClockSkew)},
+		} {
+			f.now = test.now
+
+			if _, err := f.service.Report(f.ranagID, test.requestID, shard, test.verification); err != mismatch.ErrNoConflict {
+				t.Errorf("%s: expected %v, got %v", name, mismatch.ErrNoConflict, err)
+			}
+
+			f.now = time.Now().UTC()
+		}
+
+		if f.node(t, 0).Reputation != node.MaxReputation || f.node(t, 1).Reputation != node.MaxReputation {
+			t.Errorf("Expected the reputations kept")
+		}
+	})
+
+	t.Run("leaves out nodes of other shards", func(t *testing.T) {
+		f := setup(t)
+
+		v := f.verification(t, "req-1", "x", "y", "x")
+
+		if _, err := f.service.Report(f.ranagID, "req-1", 50, v); err != mismatch.ErrNoConflict {
+			t.Errorf("Expected %v, got %v", mismatch.ErrNoConflict, err)
+		}
+	})
+
+	t.Run("rejects duplicates", func(t *testing.T) {
+		f := setup(t)
+
+		f.report(t, "req-1", "x", "y", "x")
+
+		if _, err := f.report(t, "req-1", "x", "y", "x"); err != mismatch.ErrDuplicateReport {
+			t.Errorf("Expected %v, got %v", mismatch.ErrDuplicateReport, err)
+		}
+
+		if f.node(t, 1).Reputation != node.MaxReputation*(1-node.ReputationSmoothing) {
+			t.Errorf("Expected the node blamed once, got reputation %v", f.node(t, 1).Reputation)
+		}
+	})
+}
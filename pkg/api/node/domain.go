@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"juno/pkg/can"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,8 +15,18 @@ var ErrAddressExists = errors.New("address already exists")
 var ErrInvalidAddress = errors.New("invalid address")
 var ErrInvalidShards = errors.New("invalid shards")
 var ErrNotFound = errors.New("node not found")
+var ErrInvalidPublicKey = errors.New("invalid public key")
 var ErrInternal = errors.New("internal error")
 
+const (
+	// MaxReputation is the reputation of a node that never failed a
+	// challenge, which new nodes start with.
+	MaxReputation = 1.0
+	// ReputationSmoothing is the weight of the latest challenge in a node's
+	// reputation.
+	ReputationSmoothing = 0.1
+)
+
 type Repository interface {
 	All() ([]*Node, error)
 	Create(n *Node) error
@@ -23,15 +34,32 @@ type Repository interface {
 	ListByOwnerID(ownerID uuid.UUID) ([]*Node, error)
 	FirstWhereAddress(address string) (*Node, error)
 	Update(n *Node) error
+	// Score moves the stored reputation of the node towards the outcome of a
+	// challenge in a single update, so concurrent challenges all count.
+	Score(id uuid.UUID, passed bool) error
+	// Exclude stores the exclusion of the node alone, so it does not
+	// overwrite its reputation or edits of the node.
+	Exclude(id uuid.UUID, until time.Time) error
 	Delete(id uuid.UUID) error
 }
 
 type Service interface {
+	// AllShardsNodes maps every shard to its nodes, leaving out excluded
+	// nodes.
 	AllShardsNodes() (map[int][]*Node, error)
+	// All lists every registered node, excluded ones too.
+	All() ([]*Node, error)
 	Get(id uuid.UUID) (*Node, error)
+	GetByAddress(address string) (*Node, error)
 	ListByOwnerID(ownerID uuid.UUID) ([]*Node, error)
 	Create(ownerID uuid.UUID, addr string, shardAssignments [][2]int) (*Node, error)
 	Update(id uuid.UUID, n *Node) (*Node, error)
+	// RegisterKey sets the public key the node's receipts are verified with.
+	RegisterKey(id uuid.UUID, publicKey string) (*Node, error)
+	// Score updates the node's reputation with the outcome of a challenge.
+	Score(id uuid.UUID, passed bool) (*Node, error)
+	// Exclude leaves the node out of the shard maps until the time.
+	Exclude(id uuid.UUID, until time.Time) (*Node, error)
 	Delete(id uuid.UUID) error
 }
 
@@ -41,6 +69,7 @@ type Handler interface {
 	Get(c *gin.Context)
 	Create(c *gin.Context)
 	Update(c *gin.Context)
+	RegisterKey(c *gin.Context)
 	Delete(c *gin.Context)
 }
 
@@ -57,6 +86,15 @@ type Node struct {
 	OwnerID          uuid.UUID `json:"owner_id"`
 	Address          string    `json:"address"`
 	ShardAssignments [][2]int  `json:"shard_assignments"`
+	// PublicKey verifies the receipts of the work the node is paid for
+	PublicKey string `json:"public_key"`
+	// Reputation is the moving average of the storage challenges the node
+	// passed, from 0 to MaxReputation. It weighs the node in shard maps and
+	// scales its earnings.
+	Reputation float64 `json:"reputation"`
+	// ExcludedUntil is when the node is put back in the shard maps after it
+	// was excluded for repeatedly answering differently than its replicas
+	ExcludedUntil time.Time `json:"excluded_until"`
 }
 
 func New(id, ownerID uuid.UUID, address string, shardAssignments [][2]int) *Node {
@@ -65,5 +103,36 @@ func New(id, ownerID uuid.UUID, address string, shardAssignments [][2]int) *Node
 		OwnerID:          ownerID,
 		Address:          address,
 		ShardAssignments: shardAssignments,
+		Reputation:       MaxReputation,
+	}
+}
+
+// Score moves the node's reputation towards the outcome of a challenge.
+func (n *Node) Score(passed bool) {
+	n.Reputation = n.Reputation*(1-ReputationSmoothing) + Outcome(passed)*ReputationSmoothing
+}
+
+// Outcome is the reputation a challenge moves a node towards.
+func Outcome(passed bool) float64 {
+	if passed {
+		return MaxReputation
 	}
+
+	return 0
+}
+
+// Excluded reports whether the node is left out of the shard maps at now.
+func (n *Node) Excluded(now time.Time) bool {
+	return n.ExcludedUntil.After(now)
+}
+
+// HasShard reports whether the node is assigned the shard.
+func (n *Node) HasShard(shard int) bool {
+	for _, s := range n.ShardAssignments {
+		if shard >= s[0] && shard < s[0]+s[1] {
+			return true
+		}
+	}
+
+	return false
 }
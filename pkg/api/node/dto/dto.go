@@ -2,6 +2,7 @@ package dto
 
 import (
 	"juno/pkg/api/node"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -17,6 +18,9 @@ type Node struct {
 	Address          string   `json:"address"`
 	Status           string   `json:"status"`
 	ShardAssignments [][2]int `json:"shard_assignments"`
+	PublicKey        string   `json:"public_key,omitempty"`
+	Reputation       float64  `json:"reputation"`
+	ExcludedUntil    string   `json:"excluded_until,omitempty"`
 }
 
 func NewNodeFromDomain(n *node.Node) *Node {
@@ -25,9 +29,21 @@ func NewNodeFromDomain(n *node.Node) *Node {
 		OwnerID:          n.OwnerID.String(),
 		Address:          n.Address,
 		ShardAssignments: n.ShardAssignments,
+		PublicKey:        n.PublicKey,
+		Reputation:       n.Reputation,
+		ExcludedUntil:    excludedUntil(n),
 	}
 }
 
+// excludedUntil is when the node's exclusion ends, if it was ever excluded.
+func excludedUntil(n *node.Node) string {
+	if n.ExcludedUntil.IsZero() {
+		return ""
+	}
+
+	return n.ExcludedUntil.Format(time.RFC3339)
+}
+
 func (n Node) ToDomain() (*node.Node, error) {
 	id, err := uuid.Parse(n.ID)
 	if err != nil {
@@ -134,6 +150,12 @@ func (r UpdateNodeRequest) ToDomain() (*node.Node, error) {
 	}, nil
 }
 
+// RegisterKeyRequest registers the public key the node's receipts are
+// verified with, as the node logs it when it starts.
+type RegisterKeyRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
 type UpdateNodeResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"` // Only present when there's an error
@@ -178,18 +200,23 @@ type AllShardsNodesResponse struct {
 	Message string `json:"message,omitempty"` // Only present when there's an error
 
 	Shards map[int][]string `json:"shards,omitempty"` // Only present when successful
+	// Reputations weigh the nodes, by address, when they are picked
+	Reputations map[string]float64 `json:"reputations,omitempty"`
 }
 
 func NewSuccessAllShardsNodesResponse(shards map[int][]*node.Node) AllShardsNodesResponse {
 	m := make(map[int][]string)
+	reputations := make(map[string]float64)
 	for i, nodes := range shards {
 		for _, node := range nodes {
 			m[i] = append(m[i], node.Address)
+			reputations[node.Address] = node.Reputation
 		}
 	}
 	return AllShardsNodesResponse{
-		Status: SUCCESS,
-		Shards: m,
+		Status:      SUCCESS,
+		Shards:      m,
+		Reputations: reputations,
 	}
 }
 
@@ -238,6 +238,72 @@ func (h *Handler) Update(c *gin.Context) {
 
 }
 
+func (h *Handler) RegisterKey(c *gin.Context) {
+
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	n, err := h.nodeService.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorGetNodeResponse(
+			node.ErrNotFound.Error(),
+		))
+		return
+	}
+
+	h.policy.CanUpdate(c.Request.Context(), n).
+		Allow(func() {
+
+			var req dto.RegisterKeyRequest
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			n, err := h.nodeService.RegisterKey(id, req.PublicKey)
+
+			if err == node.ErrInvalidPublicKey {
+				c.JSON(400, dto.NewErrorUpdateNodeResponse(
+					err.Error(),
+				))
+				return
+			}
+
+			if err != nil {
+				h.logger.Debug(
+					logrus.Fields{
+						"error": err.Error(),
+						"user":  u.ID,
+						"node":  id,
+					})
+				c.JSON(500, dto.NewErrorUpdateNodeResponse(
+					node.ErrInternal.Error(),
+				))
+				return
+			}
+			c.JSON(200, dto.NewSuccessUpdateNodeResponse(n))
+		}).
+		Deny(func(reason string) {
+			c.JSON(401, dto.NewErrorUpdateNodeResponse(
+				reason,
+			))
+		}).
+		Err(func(err error) {
+			h.logger.Debug(err)
+			c.JSON(500, dto.NewErrorUpdateNodeResponse(
+				node.ErrInternal.Error(),
+			))
+		})
+
+}
+
 func (h *Handler) Delete(c *gin.Context) {
 
 	u := auth.MustUserFromContext(c.Request.Context())
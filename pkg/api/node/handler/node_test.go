@@ -720,3 +720,43 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisterKey(t *testing.T) {
+	ownerID := uuid.New()
+
+	for _, tc := range []struct {
+		name     string
+		userID   uuid.UUID
+		body     string
+		expected int
+	}{
+		{name: "success", userID: ownerID, body: `{"public_key": "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`, expected: 200},
+		{name: "invalid key", userID: ownerID, body: `{"public_key": "c2hvcnQ="}`, expected: 400},
+		{name: "missing key", userID: ownerID, body: `{}`, expected: 400},
+		{name: "not the owner", userID: uuid.New(), body: `{"public_key": "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`, expected: 401},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mem.New()
+			handler := New(logrus.New(), policy.New(), service.New(repo))
+
+			n := &node.Node{ID: uuid.New(), OwnerID: ownerID, Address: "example.com:8000"}
+
+			if err := repo.Create(n); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest("PUT", "/nodes/"+n.ID.String()+"/key", strings.NewReader(tc.body)).
+				WithContext(auth.WithUser(context.Background(), &user.User{ID: tc.userID}))
+			c.Params = gin.Params{{Key: "id", Value: n.ID.String()}}
+
+			handler.RegisterKey(c)
+
+			if w.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package mysql
 
-import "database/sql"
+import (
+	"database/sql"
+	"sort"
+)
 
 var migrations = map[string]string{
 	"create_nodes_table": `
@@ -14,6 +17,18 @@ var migrations = map[string]string{
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 		);`,
+
+	// the key the receipts of the node's work are verified with
+	"migrate_nodes_public_key": `
+		ALTER TABLE nodes ADD COLUMN public_key VARCHAR(64) NOT NULL DEFAULT '';`,
+
+	// the moving average of the storage challenges the node passed
+	"migrate_nodes_reputation": `
+		ALTER TABLE nodes ADD COLUMN reputation DOUBLE NOT NULL DEFAULT 1;`,
+
+	// when the node is put back in the shard maps after it was excluded
+	"migrate_nodes_reputation_excluded_until": `
+		ALTER TABLE nodes ADD COLUMN excluded_until TIMESTAMP NULL;`,
 }
 
 func ExecuteMigrations(db *sql.DB) error {
@@ -25,7 +40,16 @@ func ExecuteMigrations(db *sql.DB) error {
 		return err
 	}
 
-	for name, migration := range migrations {
+	// columns are added after their tables are created
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		migration := migrations[name]
 
 		// check if migration has already been executed
 		var count int
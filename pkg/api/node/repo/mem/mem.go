@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"juno/pkg/api/node"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -77,6 +78,30 @@ func (r *Repository) Update(n *node.Node) error {
 	return nil
 }
 
+func (r *Repository) Score(id uuid.UUID, passed bool) error {
+	stored, ok := r.nodes[id]
+
+	if !ok {
+		return errors.New("not found")
+	}
+
+	stored.Score(passed)
+
+	return nil
+}
+
+func (r *Repository) Exclude(id uuid.UUID, until time.Time) error {
+	stored, ok := r.nodes[id]
+
+	if !ok {
+		return errors.New("not found")
+	}
+
+	stored.ExcludedUntil = until
+
+	return nil
+}
+
 func (r *Repository) Delete(id uuid.UUID) error {
 	if _, ok := r.nodes[id]; !ok {
 		return errors.New("not found")
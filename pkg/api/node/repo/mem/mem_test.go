@@ -295,3 +295,31 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestScore(t *testing.T) {
+	n := node.New(uuid.New(), uuid.New(), "http://example.com", nil)
+
+	repo := New()
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if err := repo.Score(n.ID, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if err := repo.Score(n.ID, false); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := node.MaxReputation * (1 - node.ReputationSmoothing) * (1 - node.ReputationSmoothing)
+
+	if repo.nodes[n.ID].Reputation != expected {
+		t.Errorf("Expected reputation %v, got %v", expected, repo.nodes[n.ID].Reputation)
+	}
+
+	if err := repo.Score(uuid.New(), true); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"juno/pkg/api/node"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -23,7 +24,7 @@ func (r *Repo) Create(n *node.Node) error {
 		return err
 	}
 
-	_, err = r.db.Exec("INSERT INTO nodes (id, owner_id, address, shard_assignments) VALUES (?, ?, ?, ?)", n.ID, n.OwnerID, n.Address, string(assignments))
+	_, err = r.db.Exec("INSERT INTO nodes (id, owner_id, address, shard_assignments, public_key, reputation) VALUES (?, ?, ?, ?, ?, ?)", n.ID, n.OwnerID, n.Address, string(assignments), n.PublicKey, n.Reputation)
 	if err != nil {
 		return err
 	}
@@ -34,7 +35,7 @@ func (r *Repo) Create(n *node.Node) error {
 func (r *Repo) All() ([]*node.Node, error) {
 	var nodes []*node.Node
 
-	rows, err := r.db.Query("SELECT id, owner_id, address, shard_assignments FROM nodes")
+	rows, err := r.db.Query("SELECT id, owner_id, address, shard_assignments, public_key, reputation, excluded_until FROM nodes")
 
 	if err != nil {
 		return nil, err
@@ -43,7 +44,8 @@ func (r *Repo) All() ([]*node.Node, error) {
 	for rows.Next() {
 		var n node.Node
 		var shardAssignmentJson string
-		err = rows.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
+		var excludedUntil sql.NullTime
+		err = rows.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson, &n.PublicKey, &n.Reputation, &excludedUntil)
 		if err != nil {
 			return nil, err
 		}
@@ -53,6 +55,8 @@ func (r *Repo) All() ([]*node.Node, error) {
 			return nil, err
 		}
 
+		n.ExcludedUntil = excludedUntil.Time
+
 		nodes = append(nodes, &n)
 	}
 
@@ -63,8 +67,9 @@ func (r *Repo) Get(id uuid.UUID) (*node.Node, error) {
 	var n node.Node
 
 	var shardAssignmentJson string
+	var excludedUntil sql.NullTime
 
-	err := r.db.QueryRow("SELECT id, owner_id, address, shard_assignments FROM nodes WHERE id = ?", id).Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
+	err := r.db.QueryRow("SELECT id, owner_id, address, shard_assignments, public_key, reputation, excluded_until FROM nodes WHERE id = ?", id).Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson, &n.PublicKey, &n.Reputation, &excludedUntil)
 	if err != nil {
 		return nil, err
 	}
@@ -74,13 +79,15 @@ func (r *Repo) Get(id uuid.UUID) (*node.Node, error) {
 		return nil, err
 	}
 
+	n.ExcludedUntil = excludedUntil.Time
+
 	return &n, nil
 }
 
 func (r *Repo) ListByOwnerID(ownerID uuid.UUID) ([]*node.Node, error) {
 	var nodes []*node.Node
 
-	rows, err := r.db.Query("SELECT id, owner_id, address, shard_assignments FROM nodes WHERE owner_id = ?", ownerID)
+	rows, err := r.db.Query("SELECT id, owner_id, address, shard_assignments, public_key, reputation, excluded_until FROM nodes WHERE owner_id = ?", ownerID)
 
 	if err != nil {
 		return nil, err
@@ -89,7 +96,8 @@ func (r *Repo) ListByOwnerID(ownerID uuid.UUID) ([]*node.Node, error) {
 	for rows.Next() {
 		var n node.Node
 		var shardAssignmentJson string
-		err = rows.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
+		var excludedUntil sql.NullTime
+		err = rows.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson, &n.PublicKey, &n.Reputation, &excludedUntil)
 		if err != nil {
 			return nil, err
 		}
@@ -99,6 +107,8 @@ func (r *Repo) ListByOwnerID(ownerID uuid.UUID) ([]*node.Node, error) {
 			return nil, err
 		}
 
+		n.ExcludedUntil = excludedUntil.Time
+
 		nodes = append(nodes, &n)
 	}
 
@@ -108,7 +118,8 @@ func (r *Repo) ListByOwnerID(ownerID uuid.UUID) ([]*node.Node, error) {
 func (r *Repo) FirstWhereAddress(address string) (*node.Node, error) {
 	var n node.Node
 	var shardAssignmentJson string
-	err := r.db.QueryRow("SELECT id, owner_id, address, shard_assignments FROM nodes WHERE address = ?", address).Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
+	var excludedUntil sql.NullTime
+	err := r.db.QueryRow("SELECT id, owner_id, address, shard_assignments, public_key, reputation, excluded_until FROM nodes WHERE address = ?", address).Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson, &n.PublicKey, &n.Reputation, &excludedUntil)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +129,8 @@ func (r *Repo) FirstWhereAddress(address string) (*node.Node, error) {
 		return nil, err
 	}
 
+	n.ExcludedUntil = excludedUntil.Time
+
 	return &n, nil
 }
 
@@ -128,7 +141,7 @@ func (r *Repo) Update(n *node.Node) error {
 		return err
 	}
 
-	_, err = r.db.Exec("UPDATE nodes SET owner_id = ?, address = ?, shard_assignments = ? WHERE id = ?", n.OwnerID, n.Address, string(assignments), n.ID)
+	_, err = r.db.Exec("UPDATE nodes SET owner_id = ?, address = ?, shard_assignments = ?, public_key = ? WHERE id = ?", n.OwnerID, n.Address, string(assignments), n.PublicKey, n.ID)
 	if err != nil {
 		return err
 	}
@@ -136,6 +149,28 @@ func (r *Repo) Update(n *node.Node) error {
 	return nil
 }
 
+// Score applies the moving average of Node.Score to the stored reputation.
+func (r *Repo) Score(id uuid.UUID, passed bool) error {
+	_, err := r.db.Exec(
+		"UPDATE nodes SET reputation = reputation * ? + ? WHERE id = ?",
+		1-node.ReputationSmoothing, node.Outcome(passed)*node.ReputationSmoothing, id,
+	)
+
+	return err
+}
+
+func (r *Repo) Exclude(id uuid.UUID, until time.Time) error {
+	var excludedUntil sql.NullTime
+
+	if !until.IsZero() {
+		excludedUntil = sql.NullTime{Time: until, Valid: true}
+	}
+
+	_, err := r.db.Exec("UPDATE nodes SET excluded_until = ? WHERE id = ?", excludedUntil, id)
+
+	return err
+}
+
 func (r *Repo) Delete(id uuid.UUID) error {
 	_, err := r.db.Exec("DELETE FROM nodes WHERE id = ?", id)
 	if err != nil {
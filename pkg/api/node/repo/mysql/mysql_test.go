@@ -480,3 +480,46 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestScore(t *testing.T) {
+	t.Run("counts every outcome", func(t *testing.T) {
+		n := node.New(uuid.New(), uuid.New(), "http://example.com", nil)
+
+		conn, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/node_test?parseTime=true")
+		if err != nil {
+			t.Errorf("Error connecting to database: %s", err)
+		}
+		err = mysql.ExecuteMigrations(conn)
+		if err != nil {
+			t.Errorf("Error executing migrations: %s", err)
+		}
+
+		defer conn.Close()
+
+		defer conn.Exec("DELETE FROM nodes WHERE id = ?", n.ID)
+
+		repo := New(conn)
+
+		if err := repo.Create(n); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		for range 2 {
+			if err := repo.Score(n.ID, false); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		}
+
+		stored, err := repo.Get(n.ID)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := node.MaxReputation * (1 - node.ReputationSmoothing) * (1 - node.ReputationSmoothing)
+
+		if diff := stored.Reputation - expected; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("Expected reputation %v, got %v", expected, stored.Reputation)
+		}
+	})
+}
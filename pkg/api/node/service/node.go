@@ -2,19 +2,37 @@ package service
 
 import (
 	"juno/pkg/api/node"
+	"juno/pkg/receipt"
 	"juno/pkg/shard"
 	"juno/pkg/util"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
 	repo node.Repository
+	now  func() time.Time
 }
 
-func New(repo node.Repository) *Service {
-	return &Service{repo: repo}
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func New(repo node.Repository, opts ...func(s *Service)) *Service {
+	s := &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *Service) Get(id uuid.UUID) (*node.Node, error) {
@@ -26,6 +44,16 @@ func (s *Service) Get(id uuid.UUID) (*node.Node, error) {
 	return n, nil
 }
 
+func (s *Service) GetByAddress(address string) (*node.Node, error) {
+	n, err := s.repo.FirstWhereAddress(address)
+
+	if err != nil || n == nil {
+		return nil, node.ErrNotFound
+	}
+
+	return n, nil
+}
+
 func (s *Service) ListByOwnerID(ownerID uuid.UUID) ([]*node.Node, error) {
 	nodes, err := s.repo.ListByOwnerID(ownerID)
 
@@ -75,8 +103,13 @@ func (s *Service) AllShardsNodes() (map[int][]*node.Node, error) {
 	}
 
 	shardsNodes := make(map[int][]*node.Node)
+	now := s.now()
 
 	for _, n := range nodes {
+		if n.Excluded(now) {
+			continue
+		}
+
 		for _, s := range n.ShardAssignments {
 			for i := s[0]; i < s[0]+s[1]; i++ {
 				shardsNodes[i] = append(shardsNodes[i], n)
@@ -87,6 +120,16 @@ func (s *Service) AllShardsNodes() (map[int][]*node.Node, error) {
 	return shardsNodes, nil
 }
 
+func (s *Service) All() ([]*node.Node, error) {
+	nodes, err := s.repo.All()
+
+	if err != nil {
+		return nil, node.ErrInternal
+	}
+
+	return nodes, nil
+}
+
 func (s *Service) Create(ownerID uuid.UUID, addr string, shardAssignments [][2]int) (*node.Node, error) {
 	if found, _ := s.repo.FirstWhereAddress(addr); found != nil {
 		return nil, node.ErrAddressExists
@@ -111,6 +154,7 @@ func (s *Service) Create(ownerID uuid.UUID, addr string, shardAssignments [][2]i
 		OwnerID:          ownerID,
 		Address:          addr,
 		ShardAssignments: shardAssignments,
+		Reputation:       node.MaxReputation,
 	}
 
 	err := s.repo.Create(n)
@@ -155,6 +199,50 @@ func (s *Service) Update(id uuid.UUID, dirty *node.Node) (*node.Node, error) {
 	return n, nil
 }
 
+func (s *Service) RegisterKey(id uuid.UUID, publicKey string) (*node.Node, error) {
+	if _, err := receipt.ParsePublicKey(publicKey); err != nil {
+		return nil, node.ErrInvalidPublicKey
+	}
+
+	n, err := s.repo.Get(id)
+
+	if err != nil {
+		return nil, node.ErrNotFound
+	}
+
+	n.PublicKey = publicKey
+
+	if err := s.repo.Update(n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (s *Service) Score(id uuid.UUID, passed bool) (*node.Node, error) {
+	if _, err := s.repo.Get(id); err != nil {
+		return nil, node.ErrNotFound
+	}
+
+	if err := s.repo.Score(id, passed); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Get(id)
+}
+
+func (s *Service) Exclude(id uuid.UUID, until time.Time) (*node.Node, error) {
+	if _, err := s.repo.Get(id); err != nil {
+		return nil, node.ErrNotFound
+	}
+
+	if err := s.repo.Exclude(id, until.UTC()); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Get(id)
+}
+
 func (s *Service) Delete(id uuid.UUID) error {
 
 	n, err := s.repo.Get(id)
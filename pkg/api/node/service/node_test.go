@@ -6,6 +6,7 @@ import (
 	"juno/pkg/api/user"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -461,3 +462,129 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisterKey(t *testing.T) {
+	repo := mem.New()
+	svc := New(repo)
+
+	n := &node.Node{ID: uuid.New(), OwnerID: uuid.New(), Address: "example.com:8000"}
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		registered, err := svc.RegisterKey(n.ID, "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=")
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if registered.PublicKey != "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=" {
+			t.Errorf("Expected the key registered, got %q", registered.PublicKey)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := svc.RegisterKey(n.ID, "c2hvcnQ="); err != node.ErrInvalidPublicKey {
+			t.Errorf("Expected %v, got %v", node.ErrInvalidPublicKey, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := svc.RegisterKey(uuid.New(), "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="); err != node.ErrNotFound {
+			t.Errorf("Expected %v, got %v", node.ErrNotFound, err)
+		}
+	})
+}
+
+func TestScore(t *testing.T) {
+	repo := mem.New()
+	svc := New(repo)
+
+	n := node.New(uuid.New(), uuid.New(), "example.com:8000", nil)
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	scored, err := svc.Score(n.ID, false)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if scored.Reputation >= node.MaxReputation {
+		t.Errorf("Expected the reputation lowered, got %v", scored.Reputation)
+	}
+
+	if _, err := svc.Score(uuid.New(), true); err != node.ErrNotFound {
+		t.Errorf("Expected %v, got %v", node.ErrNotFound, err)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	repo := mem.New()
+	svc := New(repo, WithClock(func() time.Time { return now }))
+
+	n := node.New(uuid.New(), uuid.New(), "example.com:8000", [][2]int{{0, 2}})
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := svc.Exclude(n.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	shards, _ := svc.AllShardsNodes()
+
+	if len(shards) != 0 {
+		t.Errorf("Expected the excluded node left out, got %d shards", len(shards))
+	}
+
+	nodes, err := svc.All()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(nodes) != 1 || nodes[0].ID != n.ID {
+		t.Errorf("Expected the excluded node listed, got %v", nodes)
+	}
+
+	now = now.Add(2 * time.Hour)
+
+	shards, _ = svc.AllShardsNodes()
+
+	if len(shards[1]) != 1 {
+		t.Errorf("Expected the node back once its exclusion ended, got %v", shards[1])
+	}
+
+	if _, err := svc.Exclude(uuid.New(), now); err != node.ErrNotFound {
+		t.Errorf("Expected %v, got %v", node.ErrNotFound, err)
+	}
+}
+
+func TestGetByAddress(t *testing.T) {
+	repo := mem.New()
+	svc := New(repo)
+
+	n := node.New(uuid.New(), uuid.New(), "example.com:8000", nil)
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	found, err := svc.GetByAddress("example.com:8000")
+
+	if err != nil || found.ID != n.ID {
+		t.Errorf("Expected node %s, got %v, %v", n.ID, found, err)
+	}
+
+	if _, err := svc.GetByAddress("example.org:8000"); err != node.ErrNotFound {
+		t.Errorf("Expected %v, got %v", node.ErrNotFound, err)
+	}
+}
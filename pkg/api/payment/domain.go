@@ -0,0 +1,139 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"juno/pkg/api/transaction"
+	"juno/pkg/can"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound         = errors.New("payment not found")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrAmountMismatch   = errors.New("event amount does not match the payment")
+	ErrNotRefundable    = errors.New("payment cannot be refunded")
+	ErrConflict         = errors.New("payment changed concurrently")
+)
+
+type Status string
+
+const (
+	// PendingStatus is a payment whose checkout was not paid yet
+	PendingStatus Status = "pending"
+	PaidStatus    Status = "paid"
+	// RefundingStatus is a paid payment whose refund the provider has yet
+	// to confirm. Its tokens are already taken back.
+	RefundingStatus Status = "refunding"
+	RefundedStatus  Status = "refunded"
+	// DisputedStatus is a payment the provider refunded on its own after
+	// its tokens were spent, so they could not be taken back. The user owes
+	// the amount until it is settled outside the ledger.
+	DisputedStatus Status = "disputed"
+)
+
+// Payment buys a user tokens through a provider's checkout session.
+type Payment struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Provider    string
+	SessionID   string
+	CheckoutURL string
+	Amount      transaction.Amount
+	Status      Status
+	// RefundID identifies the refund of the payment once one started
+	RefundID  uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Session is a checkout session of a provider, where the user pays.
+type Session struct {
+	ID  string
+	URL string
+}
+
+type EventType string
+
+const (
+	CheckoutCompletedEvent EventType = "checkout.completed"
+	RefundCompletedEvent   EventType = "refund.completed"
+	RefundFailedEvent      EventType = "refund.failed"
+)
+
+// Event is what a verified webhook of a provider tells about a session.
+type Event struct {
+	ID        string             `json:"id"`
+	Type      EventType          `json:"type"`
+	SessionID string             `json:"session_id"`
+	Amount    transaction.Amount `json:"amount"`
+}
+
+type Provider interface {
+	Name() string
+	// CreateCheckout opens a session where the user pays for the payment.
+	CreateCheckout(p *Payment) (*Session, error)
+	// VerifyWebhook checks the webhook was signed by the provider and
+	// returns its event. It returns ErrInvalidSignature when it was not.
+	VerifyWebhook(header http.Header, body []byte) (*Event, error)
+	// Refund asks the provider to refund the payment. A RefundCompletedEvent
+	// or RefundFailedEvent tells how it went.
+	Refund(p *Payment) error
+}
+
+// Ledger credits the tokens payments buy and takes them back when they are
+// refunded. Every method does its work once per payment or refund, however
+// many times it is called.
+type Ledger interface {
+	Deposit(userID, paymentID uuid.UUID, amount transaction.Amount) error
+	// Withdraw returns token.ErrInsufficientBalance when the user spent
+	// the tokens.
+	Withdraw(userID, refundID uuid.UUID, amount transaction.Amount) error
+	// RestoreWithdrawal gives back what a refund that failed withdrew.
+	RestoreWithdrawal(userID, refundID uuid.UUID, amount transaction.Amount) error
+}
+
+type Repository interface {
+	Create(p *Payment) error
+	Get(id uuid.UUID) (*Payment, error)
+	GetBySessionID(provider, sessionID string) (*Payment, error)
+	ListByUserID(userID uuid.UUID) ([]*Payment, error)
+	// Update stores the payment if its stored status is still from, so
+	// concurrent changes cannot both apply. It returns ErrConflict when the
+	// status changed.
+	Update(p *Payment, from Status) error
+}
+
+type Service interface {
+	// Checkout creates a payment of the amount and opens its checkout. The
+	// user is credited when the provider tells the payment was paid.
+	Checkout(userID uuid.UUID, amount transaction.Amount) (*Payment, error)
+	Get(id uuid.UUID) (*Payment, error)
+	ListByUserID(userID uuid.UUID) ([]*Payment, error)
+	// Refund takes the payment's tokens back and asks the provider to
+	// refund it. It returns ErrNotRefundable unless the payment was paid.
+	Refund(id uuid.UUID) (*Payment, error)
+	// HandleWebhook verifies a webhook of the provider and applies its
+	// event. Events delivered again change nothing.
+	HandleWebhook(header http.Header, body []byte) error
+}
+
+type Handler interface {
+	Checkout(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Refund(c *gin.Context)
+	Webhook(c *gin.Context)
+}
+
+type Policy interface {
+	CanCreate() can.Result
+	CanGet(ctx context.Context, p *Payment) can.Result
+	CanList(ctx context.Context, payments []*Payment) can.Result
+	CanRefund(ctx context.Context, p *Payment) can.Result
+}
@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"juno/pkg/api/payment"
+	"juno/pkg/api/transaction"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type Payment struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"user_id"`
+	Provider    string             `json:"provider"`
+	CheckoutURL string             `json:"checkout_url"`
+	Amount      transaction.Amount `json:"amount"`
+	Status      string             `json:"status"`
+	CreatedAt   string             `json:"created_at"`
+	UpdatedAt   string             `json:"updated_at"`
+}
+
+func NewPaymentFromDomain(p *payment.Payment) *Payment {
+	return &Payment{
+		ID:          p.ID.String(),
+		UserID:      p.UserID.String(),
+		Provider:    p.Provider,
+		CheckoutURL: p.CheckoutURL,
+		Amount:      p.Amount,
+		Status:      string(p.Status),
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type CheckoutRequest struct {
+	Amount transaction.Amount `json:"amount" binding:"required"`
+}
+
+type PaymentResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Payment *Payment `json:"result,omitempty"`
+}
+
+func NewSuccessPaymentResponse(p *payment.Payment) PaymentResponse {
+	return PaymentResponse{
+		Status:  SUCCESS,
+		Payment: NewPaymentFromDomain(p),
+	}
+}
+
+func NewErrorPaymentResponse(message string) PaymentResponse {
+	return PaymentResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type ListPaymentsResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Payments []*Payment `json:"result,omitempty"`
+}
+
+func NewSuccessListPaymentsResponse(payments []*payment.Payment) ListPaymentsResponse {
+	res := ListPaymentsResponse{
+		Status:   SUCCESS,
+		Payments: make([]*Payment, 0, len(payments)),
+	}
+
+	for _, p := range payments {
+		res.Payments = append(res.Payments, NewPaymentFromDomain(p))
+	}
+
+	return res
+}
+
+func NewErrorListPaymentsResponse(message string) ListPaymentsResponse {
+	return ListPaymentsResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type WebhookResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewSuccessWebhookResponse() WebhookResponse {
+	return WebhookResponse{
+		Status: SUCCESS,
+	}
+}
+
+func NewErrorWebhookResponse(message string) WebhookResponse {
+	return WebhookResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
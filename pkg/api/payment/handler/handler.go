@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"io"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/payment/dto"
+	"juno/pkg/api/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxWebhookSize bounds the webhooks read, as anyone can send them
+const maxWebhookSize = 1 << 16
+
+type Handler struct {
+	logger         logrus.FieldLogger
+	policy         payment.Policy
+	paymentService payment.Service
+}
+
+func New(logger logrus.FieldLogger, policy payment.Policy, paymentService payment.Service) *Handler {
+	return &Handler{
+		logger:         logger,
+		policy:         policy,
+		paymentService: paymentService,
+	}
+}
+
+// Checkout starts a deposit. The tokens are credited once the checkout it
+// returns is paid.
+func (h *Handler) Checkout(c *gin.Context) {
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	var req dto.CheckoutRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorPaymentResponse(err.Error()))
+		return
+	}
+
+	h.policy.CanCreate().
+		Allow(func() {
+			p, err := h.paymentService.Checkout(u.ID, req.Amount)
+
+			if err == payment.ErrInvalidAmount {
+				c.JSON(400, dto.NewErrorPaymentResponse(err.Error()))
+				return
+			}
+
+			if err != nil {
+				c.JSON(502, dto.NewErrorPaymentResponse(err.Error()))
+				return
+			}
+
+			c.JSON(201, dto.NewSuccessPaymentResponse(p))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorPaymentResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorPaymentResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorPaymentResponse("invalid payment ID"))
+		return
+	}
+
+	p, err := h.paymentService.Get(id)
+	if err != nil {
+		c.JSON(404, dto.NewErrorPaymentResponse("payment not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), p).
+		Allow(func() {
+			c.JSON(200, dto.NewSuccessPaymentResponse(p))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorPaymentResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorPaymentResponse(err.Error()))
+		})
+}
+
+func (h *Handler) List(c *gin.Context) {
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	payments, err := h.paymentService.ListByUserID(u.ID)
+	if err != nil {
+		c.JSON(500, dto.NewErrorListPaymentsResponse("failed to fetch payments"))
+		return
+	}
+
+	h.policy.CanList(c.Request.Context(), payments).
+		Allow(func() {
+			c.JSON(200, dto.NewSuccessListPaymentsResponse(payments))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorListPaymentsResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorListPaymentsResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Refund(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorPaymentResponse("invalid payment ID"))
+		return
+	}
+
+	p, err := h.paymentService.Get(id)
+	if err != nil {
+		c.JSON(404, dto.NewErrorPaymentResponse("payment not found"))
+		return
+	}
+
+	h.policy.CanRefund(c.Request.Context(), p).
+		Allow(func() {
+			p, err := h.paymentService.Refund(p.ID)
+
+			switch err {
+			case nil:
+				c.JSON(202, dto.NewSuccessPaymentResponse(p))
+			case payment.ErrNotRefundable:
+				c.JSON(409, dto.NewErrorPaymentResponse(err.Error()))
+			case token.ErrInsufficientBalance:
+				c.JSON(402, dto.NewErrorPaymentResponse("the payment's tokens were spent"))
+			default:
+				c.JSON(502, dto.NewErrorPaymentResponse(err.Error()))
+			}
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorPaymentResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorPaymentResponse(err.Error()))
+		})
+}
+
+// Webhook receives the events of the payment provider. It is not behind
+// authentication: webhooks are trusted by their signature. Failures other
+// than a bad webhook answer 500 so the provider delivers it again.
+func (h *Handler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize))
+	if err != nil {
+		c.JSON(400, dto.NewErrorWebhookResponse(err.Error()))
+		return
+	}
+
+	switch err := h.paymentService.HandleWebhook(c.Request.Header, body); err {
+	case nil:
+		c.JSON(200, dto.NewSuccessWebhookResponse())
+	case payment.ErrInvalidSignature:
+		c.JSON(401, dto.NewErrorWebhookResponse(err.Error()))
+	case payment.ErrNotFound, payment.ErrAmountMismatch:
+		h.logger.WithError(err).Warn("rejected payment webhook")
+		c.JSON(400, dto.NewErrorWebhookResponse(err.Error()))
+	default:
+		h.logger.WithError(err).Error("failed to handle payment webhook")
+		c.JSON(500, dto.NewErrorWebhookResponse("failed to handle webhook"))
+	}
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/payment/dto"
+	"juno/pkg/api/payment/policy"
+	"juno/pkg/api/token"
+	"juno/pkg/api/transaction"
+	"juno/pkg/api/user"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type mockPaymentService struct {
+	withError   error
+	withPayment *payment.Payment
+
+	webhookBody string
+}
+
+func (m *mockPaymentService) Checkout(userID uuid.UUID, amount transaction.Amount) (*payment.Payment, error) {
+	if m.withError != nil {
+		return nil, m.withError
+	}
+
+	return &payment.Payment{ID: uuid.New(), UserID: userID, Amount: amount, Status: payment.PendingStatus, CheckoutURL: "fake://checkout/cs_1"}, nil
+}
+
+func (m *mockPaymentService) Get(id uuid.UUID) (*payment.Payment, error) {
+	if m.withPayment == nil {
+		return nil, payment.ErrNotFound
+	}
+
+	return m.withPayment, nil
+}
+
+func (m *mockPaymentService) ListByUserID(userID uuid.UUID) ([]*payment.Payment, error) {
+	return []*payment.Payment{{ID: uuid.New(), UserID: userID}}, m.withError
+}
+
+func (m *mockPaymentService) Refund(id uuid.UUID) (*payment.Payment, error) {
+	if m.withError != nil {
+		return nil, m.withError
+	}
+
+	m.withPayment.Status = payment.RefundingStatus
+
+	return m.withPayment, nil
+}
+
+func (m *mockPaymentService) HandleWebhook(header http.Header, body []byte) error {
+	m.webhookBody = string(body)
+	return m.withError
+}
+
+func send(h *Handler, handle func(c *gin.Context), userID uuid.UUID, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request = httptest.NewRequestWithContext(
+		auth.WithUser(context.Background(), &user.User{ID: userID}),
+		method,
+		path,
+		strings.NewReader(body),
+	)
+
+	if id := strings.TrimPrefix(path, "/payments/"); id != path {
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strings.TrimSuffix(id, "/refund")})
+	}
+
+	handle(c)
+
+	return w
+}
+
+func TestCheckout(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPaymentService{})
+
+		w := send(h, h.Checkout, uuid.New(), "POST", "/tokens/deposit", `{"amount": 12.5}`)
+
+		if w.Code != 201 {
+			t.Fatalf("Expected 201, got %d", w.Code)
+		}
+
+		var res dto.PaymentResponse
+
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if res.Payment.Amount != 12_500_000 || res.Payment.CheckoutURL == "" || res.Payment.Status != string(payment.PendingStatus) {
+			t.Errorf("Expected a pending payment of 12.5 with a checkout, got %+v", res.Payment)
+		}
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPaymentService{withError: payment.ErrInvalidAmount})
+
+		if w := send(h, h.Checkout, uuid.New(), "POST", "/tokens/deposit", `{"amount": -1}`); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+
+		if w := send(h, h.Checkout, uuid.New(), "POST", "/tokens/deposit", `{"amount": "ten"}`); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	userID := uuid.New()
+	p := &payment.Payment{ID: uuid.New(), UserID: userID, Status: payment.PaidStatus}
+
+	t.Run("success", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPaymentService{withPayment: p})
+
+		if w := send(h, h.Get, userID, "GET", "/payments/"+p.ID.String(), ""); w.Code != 200 {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPaymentService{})
+
+		if w := send(h, h.Get, userID, "GET", "/payments/"+uuid.NewString(), ""); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPaymentService{withPayment: p})
+
+		if w := send(h, h.Get, uuid.New(), "GET", "/payments/"+p.ID.String(), ""); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	h := New(logrus.New(), policy.New(), &mockPaymentService{})
+
+	w := send(h, h.List, uuid.New(), "GET", "/payments", "")
+
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var res dto.ListPaymentsResponse
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(res.Payments) != 1 {
+		t.Errorf("Expected 1, got %d", len(res.Payments))
+	}
+}
+
+func TestRefund(t *testing.T) {
+	userID := uuid.New()
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		userID   uuid.UUID
+		expected int
+	}{
+		{name: "success", userID: userID, expected: 202},
+		{name: "not refundable", err: payment.ErrNotRefundable, userID: userID, expected: 409},
+		{name: "spent tokens", err: token.ErrInsufficientBalance, userID: userID, expected: 402},
+		{name: "provider failure", err: errors.New("provider unavailable"), userID: userID, expected: 502},
+		{name: "forbidden", userID: uuid.New(), expected: 403},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &payment.Payment{ID: uuid.New(), UserID: userID, Status: payment.PaidStatus}
+
+			h := New(logrus.New(), policy.New(), &mockPaymentService{withPayment: p, withError: tc.err})
+
+			if w := send(h, h.Refund, tc.userID, "POST", "/payments/"+p.ID.String()+"/refund", ""); w.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "success", expected: 200},
+		{name: "invalid signature", err: payment.ErrInvalidSignature, expected: 401},
+		{name: "unknown session", err: payment.ErrNotFound, expected: 400},
+		{name: "failure", err: errors.New("database unavailable"), expected: 500},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			service := &mockPaymentService{withError: tc.err}
+			h := New(logrus.New(), policy.New(), service)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			// webhooks are not authenticated
+			c.Request = httptest.NewRequest("POST", "/payments/webhook", strings.NewReader(`{"id":"evt_1"}`))
+
+			h.Webhook(c)
+
+			if w.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, w.Code)
+			}
+
+			if service.webhookBody != `{"id":"evt_1"}` {
+				t.Errorf("Expected the body handed over, got %s", service.webhookBody)
+			}
+		})
+	}
+}
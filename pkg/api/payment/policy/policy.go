@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"context"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payment"
+	"juno/pkg/can"
+)
+
+type Policy struct{}
+
+func New() *Policy {
+	return &Policy{}
+}
+
+func (p *Policy) CanCreate() can.Result {
+	return can.Allowed()
+}
+
+func (p *Policy) CanGet(ctx context.Context, pay *payment.Payment) can.Result {
+	user := auth.MustUserFromContext(ctx)
+
+	if pay.UserID != user.ID {
+		return can.Denied("payment does not belong to user")
+	}
+
+	return can.Allowed()
+}
+
+func (p *Policy) CanList(ctx context.Context, payments []*payment.Payment) can.Result {
+	user := auth.MustUserFromContext(ctx)
+
+	for _, pay := range payments {
+		if pay.UserID != user.ID {
+			return can.Denied("payment does not belong to user")
+		}
+	}
+
+	return can.Allowed()
+}
+
+// CanRefund lets users refund their own payments, as long as they did not
+// spend the tokens.
+func (p *Policy) CanRefund(ctx context.Context, pay *payment.Payment) can.Result {
+	user := auth.MustUserFromContext(ctx)
+
+	if pay.UserID != user.ID {
+		return can.Denied("payment does not belong to user")
+	}
+
+	return can.Allowed()
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"context"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/user"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreate(t *testing.T) {
+	t.Run("anyone can pay", func(t *testing.T) {
+		p := New()
+
+		if !p.CanCreate().Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("only the payer can get a payment", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanGet(ctx, &payment.Payment{UserID: userID}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanGet(ctx, &payment.Payment{UserID: uuid.New()}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("only the payer can list payments", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanList(ctx, []*payment.Payment{{UserID: userID}}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanList(ctx, []*payment.Payment{{UserID: userID}, {UserID: uuid.New()}}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
+
+func TestRefund(t *testing.T) {
+	t.Run("only the payer can refund a payment", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanRefund(ctx, &payment.Payment{UserID: userID}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanRefund(ctx, &payment.Payment{UserID: uuid.New()}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
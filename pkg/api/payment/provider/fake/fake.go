@@ -0,0 +1,284 @@
+// Package fake is a payment provider that runs in process, so payments can be
+// made offline. Checkouts are paid by calling Pay, which returns the webhook
+// a real provider would send, signed with the shared secret the same way.
+// Webhooks signed elsewhere with Sign, such as by cmd/api/fakepay, update the
+// sessions the provider knows when they are verified.
+package fake
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"juno/pkg/api/payment"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const Name = "fake"
+
+// SignatureHeader holds when a webhook was signed and its signature, as
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+const SignatureHeader = "Juno-Signature"
+
+var (
+	ErrUnknownSession = errors.New("unknown checkout session")
+	ErrNotPaid        = errors.New("checkout session was not paid")
+	ErrRefunded       = errors.New("checkout session was already refunded")
+)
+
+// Webhook is a signed webhook, as it would be delivered.
+type Webhook struct {
+	Header http.Header
+	Body   []byte
+}
+
+type session struct {
+	payment  payment.Payment
+	paid     bool
+	refunded bool
+}
+
+type Provider struct {
+	secret      []byte
+	checkoutURL string
+	tolerance   time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// WithCheckoutURL sets the URL checkout sessions are opened at, followed by
+// their ID.
+func WithCheckoutURL(url string) func(p *Provider) {
+	return func(p *Provider) {
+		p.checkoutURL = url
+	}
+}
+
+// WithTolerance sets how old a webhook may be before it is rejected, so
+// captured webhooks cannot be replayed later.
+func WithTolerance(tolerance time.Duration) func(p *Provider) {
+	return func(p *Provider) {
+		p.tolerance = tolerance
+	}
+}
+
+func WithClock(now func() time.Time) func(p *Provider) {
+	return func(p *Provider) {
+		p.now = now
+	}
+}
+
+func New(secret string, opts ...func(p *Provider)) *Provider {
+	p := &Provider{
+		secret:      []byte(secret),
+		checkoutURL: "fake://checkout/",
+		tolerance:   5 * time.Minute,
+		now:         time.Now,
+		sessions:    make(map[string]*session),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *Provider) Name() string {
+	return Name
+}
+
+func (p *Provider) CreateCheckout(pay *payment.Payment) (*payment.Session, error) {
+	id := "cs_" + randomID()
+
+	s := &session{payment: *pay}
+	s.payment.SessionID = id
+
+	p.mu.Lock()
+	p.sessions[id] = s
+	p.mu.Unlock()
+
+	return &payment.Session{ID: id, URL: p.checkoutURL + id}, nil
+}
+
+// Refund refunds a paid session. The refund completes when CompleteRefund is
+// called.
+func (p *Provider) Refund(pay *payment.Payment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[pay.SessionID]
+
+	if !ok {
+		return ErrUnknownSession
+	}
+
+	if !s.paid {
+		return ErrNotPaid
+	}
+
+	if s.refunded {
+		return ErrRefunded
+	}
+
+	return nil
+}
+
+// Pay pays the session as the user would at its checkout and returns the
+// webhook telling it.
+func (p *Provider) Pay(sessionID string) (*Webhook, error) {
+	p.mu.Lock()
+	s, ok := p.sessions[sessionID]
+
+	if ok {
+		s.paid = true
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+
+	return p.event(payment.CheckoutCompletedEvent, s)
+}
+
+// CompleteRefund refunds the paid session, asked to or not, and returns the
+// webhook telling it.
+func (p *Provider) CompleteRefund(sessionID string) (*Webhook, error) {
+	return p.settleRefund(sessionID, payment.RefundCompletedEvent)
+}
+
+// FailRefund returns the webhook telling the session's refund failed.
+func (p *Provider) FailRefund(sessionID string) (*Webhook, error) {
+	return p.settleRefund(sessionID, payment.RefundFailedEvent)
+}
+
+func (p *Provider) settleRefund(sessionID string, t payment.EventType) (*Webhook, error) {
+	p.mu.Lock()
+	s, ok := p.sessions[sessionID]
+
+	if ok && s.paid && t == payment.RefundCompletedEvent {
+		s.refunded = true
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+
+	if !s.paid {
+		return nil, ErrNotPaid
+	}
+
+	return p.event(t, s)
+}
+
+func (p *Provider) event(t payment.EventType, s *session) (*Webhook, error) {
+	return p.Sign(&payment.Event{
+		ID:        "evt_" + randomID(),
+		Type:      t,
+		SessionID: s.payment.SessionID,
+		Amount:    s.payment.Amount,
+	})
+}
+
+// Sign signs the event as a webhook of the provider, so webhooks can be sent
+// for sessions of another process.
+func (p *Provider) Sign(e *payment.Event) (*Webhook, error) {
+	body, err := json.Marshal(e)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := strconv.FormatInt(p.now().Unix(), 10)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(SignatureHeader, fmt.Sprintf("t=%s,v1=%s", t, p.signature(t, body)))
+
+	return &Webhook{Header: header, Body: body}, nil
+}
+
+func (p *Provider) VerifyWebhook(header http.Header, body []byte) (*payment.Event, error) {
+	var t, signature string
+
+	for _, part := range strings.Split(header.Get(SignatureHeader), ",") {
+		k, v, _ := strings.Cut(part, "=")
+
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			signature = v
+		}
+	}
+
+	sent, err := strconv.ParseInt(t, 10, 64)
+
+	if err != nil || signature == "" {
+		return nil, payment.ErrInvalidSignature
+	}
+
+	if age := p.now().Sub(time.Unix(sent, 0)); age > p.tolerance || age < -p.tolerance {
+		return nil, payment.ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(p.signature(t, body))) {
+		return nil, payment.ErrInvalidSignature
+	}
+
+	var e payment.Event
+
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, err
+	}
+
+	p.observe(&e)
+
+	return &e, nil
+}
+
+// observe keeps the session up to date with an event it did not send itself.
+func (p *Provider) observe(e *payment.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[e.SessionID]
+
+	if !ok {
+		s = &session{payment: payment.Payment{SessionID: e.SessionID, Amount: e.Amount}}
+		p.sessions[e.SessionID] = s
+	}
+
+	switch e.Type {
+	case payment.CheckoutCompletedEvent:
+		s.paid = true
+	case payment.RefundCompletedEvent:
+		s.refunded = true
+	}
+}
+
+func (p *Provider) signature(t string, body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
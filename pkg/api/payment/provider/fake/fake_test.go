@@ -0,0 +1,113 @@
+package fake
+
+import (
+	"juno/pkg/api/payment"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhook(t *testing.T) {
+	newSession := func(p *Provider) string {
+		session, err := p.CreateCheckout(&payment.Payment{ID: uuid.New(), Amount: 25})
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return session.ID
+	}
+
+	t.Run("verifies its own webhooks", func(t *testing.T) {
+		p := New("secret")
+
+		sessionID := newSession(p)
+
+		webhook, err := p.Pay(sessionID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		e, err := p.VerifyWebhook(webhook.Header, webhook.Body)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if e.Type != payment.CheckoutCompletedEvent || e.SessionID != sessionID || e.Amount != 25 {
+			t.Errorf("Expected the checkout of the session completed, got %+v", e)
+		}
+	})
+
+	t.Run("rejects other secrets and tampered bodies", func(t *testing.T) {
+		p := New("secret")
+
+		webhook, _ := p.Pay(newSession(p))
+
+		if _, err := New("other").VerifyWebhook(webhook.Header, webhook.Body); err != payment.ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", payment.ErrInvalidSignature, err)
+		}
+
+		tampered := []byte(string(webhook.Body[:len(webhook.Body)-3]) + "99}")
+
+		if _, err := p.VerifyWebhook(webhook.Header, tampered); err != payment.ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", payment.ErrInvalidSignature, err)
+		}
+
+		webhook.Header.Del(SignatureHeader)
+
+		if _, err := p.VerifyWebhook(webhook.Header, webhook.Body); err != payment.ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", payment.ErrInvalidSignature, err)
+		}
+	})
+
+	t.Run("rejects old webhooks", func(t *testing.T) {
+		signed := New("secret", WithClock(func() time.Time { return time.Now().Add(-time.Hour) }))
+
+		webhook, _ := signed.Pay(newSession(signed))
+
+		if _, err := New("secret").VerifyWebhook(webhook.Header, webhook.Body); err != payment.ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", payment.ErrInvalidSignature, err)
+		}
+	})
+}
+
+func TestRefund(t *testing.T) {
+	p := New("secret")
+
+	session, _ := p.CreateCheckout(&payment.Payment{ID: uuid.New(), Amount: 25})
+	pay := &payment.Payment{SessionID: session.ID, Amount: 25}
+
+	if err := p.Refund(pay); err != ErrNotPaid {
+		t.Errorf("Expected %v, got %v", ErrNotPaid, err)
+	}
+
+	p.Pay(session.ID)
+
+	if err := p.Refund(pay); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	p.CompleteRefund(session.ID)
+
+	if err := p.Refund(pay); err != ErrRefunded {
+		t.Errorf("Expected %v, got %v", ErrRefunded, err)
+	}
+
+	t.Run("sessions paid elsewhere", func(t *testing.T) {
+		p := New("secret")
+
+		// signed by another process, such as fakepay
+		webhook, _ := New("secret").Sign(&payment.Event{ID: "evt_1", Type: payment.CheckoutCompletedEvent, SessionID: "cs_1", Amount: 25})
+
+		if _, err := p.VerifyWebhook(webhook.Header, webhook.Body); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if err := p.Refund(&payment.Payment{SessionID: "cs_1"}); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/payment"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Repository keeps copies of the payments, so a status is only changed
+// through Update.
+type Repository struct {
+	mu       sync.Mutex
+	payments map[uuid.UUID]payment.Payment
+}
+
+func New() *Repository {
+	return &Repository{payments: make(map[uuid.UUID]payment.Payment)}
+}
+
+func (r *Repository) Create(p *payment.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.payments[p.ID]; ok {
+		return errors.New("primary key violation")
+	}
+
+	for _, existing := range r.payments {
+		if existing.Provider == p.Provider && existing.SessionID == p.SessionID {
+			return errors.New("unique key violation")
+		}
+	}
+
+	r.payments[p.ID] = *p
+
+	return nil
+}
+
+func (r *Repository) Get(id uuid.UUID) (*payment.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.payments[id]
+	if !ok {
+		return nil, payment.ErrNotFound
+	}
+
+	return &p, nil
+}
+
+func (r *Repository) GetBySessionID(provider, sessionID string) (*payment.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.payments {
+		if p.Provider == provider && p.SessionID == sessionID {
+			return &p, nil
+		}
+	}
+
+	return nil, payment.ErrNotFound
+}
+
+func (r *Repository) ListByUserID(userID uuid.UUID) ([]*payment.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var payments []*payment.Payment
+
+	for _, p := range r.payments {
+		if p.UserID == userID {
+			payments = append(payments, &p)
+		}
+	}
+
+	return payments, nil
+}
+
+func (r *Repository) Update(p *payment.Payment, from payment.Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.payments[p.ID]
+	if !ok {
+		return payment.ErrNotFound
+	}
+
+	if stored.Status != from {
+		return payment.ErrConflict
+	}
+
+	r.payments[p.ID] = *p
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package mem
+
+import (
+	"juno/pkg/api/payment"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newPayment() *payment.Payment {
+	return &payment.Payment{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Provider:  "fake",
+		SessionID: "cs_" + uuid.NewString(),
+		Amount:    25,
+		Status:    payment.PendingStatus,
+	}
+}
+
+func TestCreate(t *testing.T) {
+	repo := New()
+
+	p := newPayment()
+
+	if err := repo.Create(p); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if err := repo.Create(p); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	other := newPayment()
+	other.SessionID = p.SessionID
+
+	if err := repo.Create(other); err == nil {
+		t.Errorf("Expected error for a session paid twice, got nil")
+	}
+}
+
+func TestGetBySessionID(t *testing.T) {
+	repo := New()
+
+	p := newPayment()
+	repo.Create(p)
+
+	check, err := repo.GetBySessionID("fake", p.SessionID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if check.ID != p.ID {
+		t.Errorf("Expected %s, got %s", p.ID, check.ID)
+	}
+
+	if _, err := repo.GetBySessionID("other", p.SessionID); err != payment.ErrNotFound {
+		t.Errorf("Expected %v, got %v", payment.ErrNotFound, err)
+	}
+}
+
+func TestListByUserID(t *testing.T) {
+	repo := New()
+
+	p := newPayment()
+	repo.Create(p)
+	repo.Create(newPayment())
+
+	payments, err := repo.ListByUserID(p.UserID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(payments) != 1 || payments[0].ID != p.ID {
+		t.Errorf("Expected the user's payment, got %v", payments)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	repo := New()
+
+	p := newPayment()
+	repo.Create(p)
+
+	p.Status = payment.PaidStatus
+
+	if err := repo.Update(p, payment.PendingStatus); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	// a concurrent change made from the same status loses
+	p.Status = payment.RefundingStatus
+
+	if err := repo.Update(p, payment.PendingStatus); err != payment.ErrConflict {
+		t.Errorf("Expected %v, got %v", payment.ErrConflict, err)
+	}
+
+	if check, _ := repo.Get(p.ID); check.Status != payment.PaidStatus {
+		t.Errorf("Expected %s, got %s", payment.PaidStatus, check.Status)
+	}
+}
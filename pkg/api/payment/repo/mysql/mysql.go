@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/payment"
+
+	"github.com/google/uuid"
+)
+
+const selectPayments = "SELECT id, user_id, provider, session_id, checkout_url, amount, status, refund_id, created_at, updated_at FROM payments"
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*payment.Payment, error) {
+	var p payment.Payment
+	var refundID sql.NullString
+
+	err := row.Scan(&p.ID, &p.UserID, &p.Provider, &p.SessionID, &p.CheckoutURL, &p.Amount, &p.Status, &refundID, &p.CreatedAt, &p.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, payment.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if refundID.Valid {
+		if p.RefundID, err = uuid.Parse(refundID.String); err != nil {
+			return nil, err
+		}
+	}
+
+	return &p, nil
+}
+
+func nullUUID(id uuid.UUID) sql.NullString {
+	return sql.NullString{String: id.String(), Valid: id != uuid.Nil}
+}
+
+func (r *Repository) Create(p *payment.Payment) error {
+	_, err := r.db.Exec(
+		"INSERT INTO payments (id, user_id, provider, session_id, checkout_url, amount, status, refund_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		p.ID, p.UserID, p.Provider, p.SessionID, p.CheckoutURL, p.Amount, p.Status, nullUUID(p.RefundID),
+	)
+
+	return err
+}
+
+func (r *Repository) Get(id uuid.UUID) (*payment.Payment, error) {
+	return scan(r.db.QueryRow(selectPayments+" WHERE id = ?", id))
+}
+
+func (r *Repository) GetBySessionID(provider, sessionID string) (*payment.Payment, error) {
+	return scan(r.db.QueryRow(selectPayments+" WHERE provider = ? AND session_id = ?", provider, sessionID))
+}
+
+func (r *Repository) ListByUserID(userID uuid.UUID) ([]*payment.Payment, error) {
+	rows, err := r.db.Query(selectPayments+" WHERE user_id = ? ORDER BY created_at DESC", userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var payments []*payment.Payment
+
+	for rows.Next() {
+		p, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *Repository) Update(p *payment.Payment, from payment.Status) error {
+	res, err := r.db.Exec(
+		"UPDATE payments SET status = ?, refund_id = ? WHERE id = ? AND status = ?",
+		p.Status, nullUUID(p.RefundID), p.ID, from,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return payment.ErrConflict
+	}
+
+	return nil
+}
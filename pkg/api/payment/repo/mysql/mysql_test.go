@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/payment/migration/mysql"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/payment_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func newPayment() *payment.Payment {
+	return &payment.Payment{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		Provider:    "fake",
+		SessionID:   "cs_" + uuid.NewString(),
+		CheckoutURL: "fake://checkout/",
+		Amount:      25_000_001,
+		Status:      payment.PendingStatus,
+	}
+}
+
+func TestCreate(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	p := newPayment()
+
+	defer db.Exec("DELETE FROM payments WHERE id = ?", p.ID)
+
+	if err := repo.Create(p); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	check, err := repo.GetBySessionID("fake", p.SessionID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if check.ID != p.ID || check.Amount != p.Amount || check.Status != payment.PendingStatus {
+		t.Errorf("Expected %+v, got %+v", p, check)
+	}
+
+	other := newPayment()
+	other.SessionID = p.SessionID
+
+	if err := repo.Create(other); err == nil {
+		t.Errorf("Expected error for a session paid twice, got nil")
+	}
+
+	if _, err := repo.Get(uuid.New()); err != payment.ErrNotFound {
+		t.Errorf("Expected %v, got %v", payment.ErrNotFound, err)
+	}
+}
+
+func TestListByUserID(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	p := newPayment()
+
+	defer db.Exec("DELETE FROM payments WHERE id = ?", p.ID)
+
+	repo.Create(p)
+
+	payments, err := repo.ListByUserID(p.UserID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(payments) != 1 || payments[0].ID != p.ID {
+		t.Errorf("Expected the user's payment, got %v", payments)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	p := newPayment()
+
+	defer db.Exec("DELETE FROM payments WHERE id = ?", p.ID)
+
+	repo.Create(p)
+
+	p.Status = payment.RefundingStatus
+	p.RefundID = uuid.New()
+
+	if err := repo.Update(p, payment.PendingStatus); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	p.Status = payment.PaidStatus
+
+	if err := repo.Update(p, payment.PendingStatus); err != payment.ErrConflict {
+		t.Errorf("Expected %v, got %v", payment.ErrConflict, err)
+	}
+
+	check, _ := repo.Get(p.ID)
+
+	if check.Status != payment.RefundingStatus || check.RefundID != p.RefundID {
+		t.Errorf("Expected the refund stored, got %+v", check)
+	}
+}
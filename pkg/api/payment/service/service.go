@@ -0,0 +1,203 @@
+package service
+
+import (
+	"juno/pkg/api/payment"
+	"juno/pkg/api/token"
+	"juno/pkg/api/transaction"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	paymentRepo payment.Repository
+	provider    payment.Provider
+	ledger      payment.Ledger
+}
+
+func New(paymentRepo payment.Repository, provider payment.Provider, ledger payment.Ledger) *Service {
+	return &Service{
+		paymentRepo: paymentRepo,
+		provider:    provider,
+		ledger:      ledger,
+	}
+}
+
+func (s *Service) Checkout(userID uuid.UUID, amount transaction.Amount) (*payment.Payment, error) {
+	if amount <= 0 {
+		return nil, payment.ErrInvalidAmount
+	}
+
+	now := time.Now()
+
+	p := &payment.Payment{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Provider:  s.provider.Name(),
+		Amount:    amount,
+		Status:    payment.PendingStatus,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	session, err := s.provider.CreateCheckout(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.SessionID = session.ID
+	p.CheckoutURL = session.URL
+
+	if err := s.paymentRepo.Create(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Service) Get(id uuid.UUID) (*payment.Payment, error) {
+	return s.paymentRepo.Get(id)
+}
+
+func (s *Service) ListByUserID(userID uuid.UUID) ([]*payment.Payment, error) {
+	return s.paymentRepo.ListByUserID(userID)
+}
+
+func (s *Service) Refund(id uuid.UUID) (*payment.Payment, error) {
+	p, err := s.paymentRepo.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Status != payment.PaidStatus {
+		return nil, payment.ErrNotRefundable
+	}
+
+	p.RefundID = uuid.New()
+
+	if err := s.transition(p, payment.PaidStatus, payment.RefundingStatus); err == payment.ErrConflict {
+		return nil, payment.ErrNotRefundable
+	} else if err != nil {
+		return nil, err
+	}
+
+	// the tokens are taken back first so they cannot be spent while the
+	// provider refunds them
+	if err := s.ledger.Withdraw(p.UserID, p.RefundID, p.Amount); err != nil {
+		if err := s.transition(p, payment.RefundingStatus, payment.PaidStatus); err != nil {
+			return nil, err
+		}
+
+		return nil, err
+	}
+
+	if err := s.provider.Refund(p); err != nil {
+		if err := s.restore(p); err != nil {
+			return nil, err
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Service) HandleWebhook(header http.Header, body []byte) error {
+	e, err := s.provider.VerifyWebhook(header, body)
+
+	if err != nil {
+		return err
+	}
+
+	switch e.Type {
+	case payment.CheckoutCompletedEvent, payment.RefundCompletedEvent, payment.RefundFailedEvent:
+	default:
+		// providers send events we have no use for
+		return nil
+	}
+
+	p, err := s.paymentRepo.GetBySessionID(s.provider.Name(), e.SessionID)
+
+	if err != nil {
+		return err
+	}
+
+	if e.Amount != p.Amount {
+		return payment.ErrAmountMismatch
+	}
+
+	switch e.Type {
+	case payment.CheckoutCompletedEvent:
+		err = s.paid(p)
+	case payment.RefundCompletedEvent:
+		err = s.refunded(p, e)
+	default:
+		if p.Status == payment.RefundingStatus {
+			err = s.restore(p)
+		}
+	}
+
+	// another delivery of the event applied it first
+	if err == payment.ErrConflict {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Service) paid(p *payment.Payment) error {
+	if p.Status != payment.PendingStatus {
+		return nil
+	}
+
+	if err := s.ledger.Deposit(p.UserID, p.ID, p.Amount); err != nil {
+		return err
+	}
+
+	return s.transition(p, payment.PendingStatus, payment.PaidStatus)
+}
+
+// refunded completes a refund. Providers also refund payments nobody asked
+// them to, in which case the tokens are taken back now, once per event, or
+// the payment is disputed when they were spent.
+func (s *Service) refunded(p *payment.Payment, e *payment.Event) error {
+	switch p.Status {
+	case payment.RefundingStatus:
+		return s.transition(p, payment.RefundingStatus, payment.RefundedStatus)
+	case payment.PaidStatus:
+		p.RefundID = uuid.NewSHA1(p.ID, []byte(e.ID))
+
+		err := s.ledger.Withdraw(p.UserID, p.RefundID, p.Amount)
+
+		if err == token.ErrInsufficientBalance {
+			return s.transition(p, payment.PaidStatus, payment.DisputedStatus)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return s.transition(p, payment.PaidStatus, payment.RefundedStatus)
+	}
+
+	return nil
+}
+
+// restore gives back the tokens of a refund that failed.
+func (s *Service) restore(p *payment.Payment) error {
+	if err := s.ledger.RestoreWithdrawal(p.UserID, p.RefundID, p.Amount); err != nil {
+		return err
+	}
+
+	return s.transition(p, payment.RefundingStatus, payment.PaidStatus)
+}
+
+func (s *Service) transition(p *payment.Payment, from, to payment.Status) error {
+	p.Status = to
+	p.UpdatedAt = time.Now()
+
+	return s.paymentRepo.Update(p, from)
+}
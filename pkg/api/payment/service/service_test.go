@@ -0,0 +1,323 @@
+package service
+
+import (
+	"errors"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/payment/provider/fake"
+	"juno/pkg/api/payment/repo/mem"
+	"juno/pkg/api/token"
+	tokenService "juno/pkg/api/token/service"
+	"juno/pkg/api/transaction"
+	tranRepo "juno/pkg/api/transaction/repo/mem"
+	tranService "juno/pkg/api/transaction/service"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// failingProvider fails the refunds of the fake provider
+type failingProvider struct {
+	*fake.Provider
+}
+
+func (p *failingProvider) Refund(pay *payment.Payment) error {
+	return errors.New("provider unavailable")
+}
+
+func setup(provider payment.Provider) (*Service, *tokenService.Service) {
+	tokens := tokenService.New(tranService.New(logrus.New(), tranRepo.New()))
+
+	return New(mem.New(), provider, tokens), tokens
+}
+
+func deliver(t *testing.T, s *Service, webhook *fake.Webhook, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := s.HandleWebhook(webhook.Header, webhook.Body); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+}
+
+func available(tokens *tokenService.Service, userID uuid.UUID) transaction.Amount {
+	b, _ := tokens.Balance(userID)
+	return b.Available
+}
+
+func TestCheckout(t *testing.T) {
+	t.Run("credits the user once the checkout is paid", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		userID := uuid.New()
+
+		p, err := s.Checkout(userID, 25*transaction.Token)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if p.Status != payment.PendingStatus || p.SessionID == "" || p.CheckoutURL == "" {
+			t.Errorf("Expected a pending payment with a checkout, got %+v", p)
+		}
+
+		if balance := available(tokens, userID); balance != 0 {
+			t.Errorf("Expected nothing credited before the payment, got %s", balance)
+		}
+
+		webhook, err := provider.Pay(p.SessionID)
+
+		// providers deliver webhooks more than once
+		deliver(t, s, webhook, err)
+		deliver(t, s, webhook, err)
+
+		if balance := available(tokens, userID); balance != 25*transaction.Token {
+			t.Errorf("Expected 25 credited once, got %s", balance)
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payment.PaidStatus {
+			t.Errorf("Expected %s, got %s", payment.PaidStatus, check.Status)
+		}
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		s, _ := setup(fake.New("secret"))
+
+		if _, err := s.Checkout(uuid.New(), 0); err != payment.ErrInvalidAmount {
+			t.Errorf("Expected %v, got %v", payment.ErrInvalidAmount, err)
+		}
+	})
+}
+
+func TestHandleWebhook(t *testing.T) {
+	t.Run("rejects webhooks that were not signed by the provider", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		userID := uuid.New()
+		p, _ := s.Checkout(userID, 25*transaction.Token)
+
+		forged, _ := fake.New("guessed").Sign(&payment.Event{ID: "evt_1", Type: payment.CheckoutCompletedEvent, SessionID: p.SessionID, Amount: p.Amount})
+
+		if err := s.HandleWebhook(forged.Header, forged.Body); err != payment.ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", payment.ErrInvalidSignature, err)
+		}
+
+		if balance := available(tokens, userID); balance != 0 {
+			t.Errorf("Expected nothing credited, got %s", balance)
+		}
+	})
+
+	t.Run("rejects amounts other than the payment's", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		userID := uuid.New()
+		p, _ := s.Checkout(userID, 25*transaction.Token)
+
+		webhook, _ := provider.Sign(&payment.Event{ID: "evt_1", Type: payment.CheckoutCompletedEvent, SessionID: p.SessionID, Amount: 2500 * transaction.Token})
+
+		if err := s.HandleWebhook(webhook.Header, webhook.Body); err != payment.ErrAmountMismatch {
+			t.Errorf("Expected %v, got %v", payment.ErrAmountMismatch, err)
+		}
+
+		if balance := available(tokens, userID); balance != 0 {
+			t.Errorf("Expected nothing credited, got %s", balance)
+		}
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, _ := setup(provider)
+
+		webhook, _ := provider.Sign(&payment.Event{ID: "evt_1", Type: payment.CheckoutCompletedEvent, SessionID: "cs_unknown", Amount: 1})
+
+		if err := s.HandleWebhook(webhook.Header, webhook.Body); err != payment.ErrNotFound {
+			t.Errorf("Expected %v, got %v", payment.ErrNotFound, err)
+		}
+	})
+
+	t.Run("ignores other events", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, _ := setup(provider)
+
+		webhook, _ := provider.Sign(&payment.Event{ID: "evt_1", Type: "customer.created"})
+
+		if err := s.HandleWebhook(webhook.Header, webhook.Body); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+}
+
+func TestRefund(t *testing.T) {
+	paid := func(t *testing.T, provider *fake.Provider, s *Service) *payment.Payment {
+		p, _ := s.Checkout(uuid.New(), 25*transaction.Token)
+
+		webhook, err := provider.Pay(p.SessionID)
+		deliver(t, s, webhook, err)
+
+		p, _ = s.Get(p.ID)
+
+		return p
+	}
+
+	t.Run("success", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		p := paid(t, provider, s)
+
+		refunding, err := s.Refund(p.ID)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if refunding.Status != payment.RefundingStatus {
+			t.Errorf("Expected %s, got %s", payment.RefundingStatus, refunding.Status)
+		}
+
+		// the tokens cannot be spent while the provider refunds them
+		if balance := available(tokens, p.UserID); balance != 0 {
+			t.Errorf("Expected the tokens taken back, got %s", balance)
+		}
+
+		if _, err := s.Refund(p.ID); err != payment.ErrNotRefundable {
+			t.Errorf("Expected %v, got %v", payment.ErrNotRefundable, err)
+		}
+
+		webhook, err := provider.CompleteRefund(p.SessionID)
+		deliver(t, s, webhook, err)
+		deliver(t, s, webhook, err)
+
+		if check, _ := s.Get(p.ID); check.Status != payment.RefundedStatus {
+			t.Errorf("Expected %s, got %s", payment.RefundedStatus, check.Status)
+		}
+
+		if balance := available(tokens, p.UserID); balance != 0 {
+			t.Errorf("Expected 0, got %s", balance)
+		}
+	})
+
+	t.Run("spent tokens", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		p := paid(t, provider, s)
+
+		tokens.Escrow(p.UserID, uuid.New(), 10*transaction.Token)
+
+		if _, err := s.Refund(p.ID); err != token.ErrInsufficientBalance {
+			t.Errorf("Expected %v, got %v", token.ErrInsufficientBalance, err)
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payment.PaidStatus {
+			t.Errorf("Expected %s, got %s", payment.PaidStatus, check.Status)
+		}
+	})
+
+	t.Run("not paid", func(t *testing.T) {
+		s, _ := setup(fake.New("secret"))
+
+		p, _ := s.Checkout(uuid.New(), 25*transaction.Token)
+
+		if _, err := s.Refund(p.ID); err != payment.ErrNotRefundable {
+			t.Errorf("Expected %v, got %v", payment.ErrNotRefundable, err)
+		}
+	})
+
+	t.Run("restores the tokens when the provider fails", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(&failingProvider{provider})
+
+		p := paid(t, provider, s)
+
+		if _, err := s.Refund(p.ID); err == nil {
+			t.Fatalf("Expected error, got nil")
+		}
+
+		if balance := available(tokens, p.UserID); balance != 25*transaction.Token {
+			t.Errorf("Expected 25, got %s", balance)
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payment.PaidStatus {
+			t.Errorf("Expected %s, got %s", payment.PaidStatus, check.Status)
+		}
+
+		// the next refund takes the tokens back again
+		s.provider = provider
+
+		if _, err := s.Refund(p.ID); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if balance := available(tokens, p.UserID); balance != 0 {
+			t.Errorf("Expected 0, got %s", balance)
+		}
+	})
+
+	t.Run("restores the tokens when the refund fails", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		p := paid(t, provider, s)
+
+		s.Refund(p.ID)
+
+		webhook, err := provider.FailRefund(p.SessionID)
+		deliver(t, s, webhook, err)
+		deliver(t, s, webhook, err)
+
+		if balance := available(tokens, p.UserID); balance != 25*transaction.Token {
+			t.Errorf("Expected 25 restored once, got %s", balance)
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payment.PaidStatus {
+			t.Errorf("Expected %s, got %s", payment.PaidStatus, check.Status)
+		}
+	})
+
+	t.Run("refunds the provider made on its own", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		p := paid(t, provider, s)
+
+		webhook, err := provider.CompleteRefund(p.SessionID)
+		deliver(t, s, webhook, err)
+		deliver(t, s, webhook, err)
+
+		if balance := available(tokens, p.UserID); balance != 0 {
+			t.Errorf("Expected the tokens taken back once, got %s", balance)
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payment.RefundedStatus {
+			t.Errorf("Expected %s, got %s", payment.RefundedStatus, check.Status)
+		}
+	})
+
+	t.Run("disputes refunds the provider made on its own of spent tokens", func(t *testing.T) {
+		provider := fake.New("secret")
+		s, tokens := setup(provider)
+
+		p := paid(t, provider, s)
+
+		tokens.Escrow(p.UserID, uuid.New(), 10*transaction.Token)
+
+		webhook, err := provider.CompleteRefund(p.SessionID)
+		deliver(t, s, webhook, err)
+		deliver(t, s, webhook, err)
+
+		if check, _ := s.Get(p.ID); check.Status != payment.DisputedStatus {
+			t.Errorf("Expected %s, got %s", payment.DisputedStatus, check.Status)
+		}
+
+		if balance := available(tokens, p.UserID); balance != 15*transaction.Token {
+			t.Errorf("Expected the tokens left alone, got %s", balance)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"juno/pkg/api/transaction"
+	"juno/pkg/can"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound          = errors.New("payout not found")
+	ErrInvalidAmount     = errors.New("invalid amount")
+	ErrBelowMinimum      = errors.New("payout is below the minimum")
+	ErrExceedsPayable    = errors.New("payout exceeds the earnings that can be paid out")
+	ErrInvalidTransition = errors.New("payout cannot change to that status")
+	ErrConflict          = errors.New("payout changed concurrently")
+)
+
+type Status string
+
+const (
+	// RequestedStatus is a payout waiting to be reviewed. Its amount is
+	// already locked.
+	RequestedStatus Status = "requested"
+	// ApprovedStatus is a payout waiting to be paid
+	ApprovedStatus Status = "approved"
+	PaidStatus     Status = "paid"
+	// RejectedStatus is a payout whose amount was given back to the
+	// operator's earnings
+	RejectedStatus Status = "rejected"
+)
+
+// Payout pays a node operator out some of their earnings.
+type Payout struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Amount transaction.Amount
+	Status Status
+	// Reason is why the payout was rejected
+	Reason string
+	// Reference identifies the transfer that paid the payout
+	Reference string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Ledger holds the earnings payouts are paid from. Every method but Payable
+// does its work once per payout, however many times it is called.
+type Ledger interface {
+	// Payable is what the user can be paid out of their earnings, leaving
+	// out what they earned after since.
+	Payable(userID uuid.UUID, since time.Time) (transaction.Amount, error)
+	// LockPayout takes the amount out of the user's earnings while the
+	// payout is processed. It returns token.ErrInsufficientBalance when
+	// they did not earn it before heldSince, checked atomically with the
+	// lock.
+	LockPayout(userID, payoutID uuid.UUID, amount transaction.Amount, heldSince time.Time) error
+	// PayPayout pays the locked amount out of the ledger.
+	PayPayout(userID, payoutID uuid.UUID, amount transaction.Amount) error
+	// ReleasePayout gives the locked amount back to the user's earnings.
+	ReleasePayout(userID, payoutID uuid.UUID, amount transaction.Amount) error
+}
+
+type Repository interface {
+	Create(p *Payout) error
+	Get(id uuid.UUID) (*Payout, error)
+	ListByUserID(userID uuid.UUID) ([]*Payout, error)
+	ListByStatus(status Status) ([]*Payout, error)
+	// Update stores the payout if its stored status is still from, so
+	// concurrent changes cannot both apply. It returns ErrConflict when the
+	// status changed.
+	Update(p *Payout, from Status) error
+}
+
+type Service interface {
+	// Request locks the amount of the user's earnings and asks for it to be
+	// paid out. It returns ErrBelowMinimum when the amount is below the
+	// minimum payout and ErrExceedsPayable when it is more than the user
+	// earned before the hold period.
+	Request(userID uuid.UUID, amount transaction.Amount) (*Payout, error)
+	Get(id uuid.UUID) (*Payout, error)
+	ListByUserID(userID uuid.UUID) ([]*Payout, error)
+	ListByStatus(status Status) ([]*Payout, error)
+	// Approve approves a requested payout to be paid.
+	Approve(id uuid.UUID) (*Payout, error)
+	// Reject gives the amount of a requested or approved payout back to
+	// the user's earnings.
+	Reject(id uuid.UUID, reason string) (*Payout, error)
+	// MarkPaid records that an approved payout was paid by the transfer
+	// the reference identifies.
+	MarkPaid(id uuid.UUID, reference string) (*Payout, error)
+}
+
+type Handler interface {
+	Request(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+
+	// the handlers of the admin API, which is not behind user
+	// authentication
+	ListByStatus(c *gin.Context)
+	Approve(c *gin.Context)
+	Reject(c *gin.Context)
+	MarkPaid(c *gin.Context)
+}
+
+type Policy interface {
+	CanRequest() can.Result
+	CanGet(ctx context.Context, p *Payout) can.Result
+	CanList(ctx context.Context, payouts []*Payout) can.Result
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"juno/pkg/api/payout"
+	"juno/pkg/api/transaction"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type Payout struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id"`
+	Amount    transaction.Amount `json:"amount"`
+	Status    string             `json:"status"`
+	Reason    string             `json:"reason,omitempty"`
+	Reference string             `json:"reference,omitempty"`
+	CreatedAt string             `json:"created_at"`
+	UpdatedAt string             `json:"updated_at"`
+}
+
+func NewPayoutFromDomain(p *payout.Payout) *Payout {
+	return &Payout{
+		ID:        p.ID.String(),
+		UserID:    p.UserID.String(),
+		Amount:    p.Amount,
+		Status:    string(p.Status),
+		Reason:    p.Reason,
+		Reference: p.Reference,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type PayoutRequest struct {
+	Amount transaction.Amount `json:"amount" binding:"required"`
+}
+
+type RejectRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type MarkPaidRequest struct {
+	Reference string `json:"reference" binding:"required"`
+}
+
+type PayoutResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Payout *Payout `json:"result,omitempty"`
+}
+
+func NewSuccessPayoutResponse(p *payout.Payout) PayoutResponse {
+	return PayoutResponse{
+		Status: SUCCESS,
+		Payout: NewPayoutFromDomain(p),
+	}
+}
+
+func NewErrorPayoutResponse(message string) PayoutResponse {
+	return PayoutResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type ListPayoutsResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Payouts []*Payout `json:"result,omitempty"`
+}
+
+func NewSuccessListPayoutsResponse(payouts []*payout.Payout) ListPayoutsResponse {
+	res := ListPayoutsResponse{
+		Status:  SUCCESS,
+		Payouts: make([]*Payout, 0, len(payouts)),
+	}
+
+	for _, p := range payouts {
+		res.Payouts = append(res.Payouts, NewPayoutFromDomain(p))
+	}
+
+	return res
+}
+
+func NewErrorListPayoutsResponse(message string) ListPayoutsResponse {
+	return ListPayoutsResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payout"
+	"juno/pkg/api/payout/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger        logrus.FieldLogger
+	policy        payout.Policy
+	payoutService payout.Service
+}
+
+func New(logger logrus.FieldLogger, policy payout.Policy, payoutService payout.Service) *Handler {
+	return &Handler{
+		logger:        logger,
+		policy:        policy,
+		payoutService: payoutService,
+	}
+}
+
+// Request asks for some of the user's earnings to be paid out.
+func (h *Handler) Request(c *gin.Context) {
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	var req dto.PayoutRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorPayoutResponse(err.Error()))
+		return
+	}
+
+	h.policy.CanRequest().
+		Allow(func() {
+			p, err := h.payoutService.Request(u.ID, req.Amount)
+
+			switch err {
+			case nil:
+				c.JSON(201, dto.NewSuccessPayoutResponse(p))
+			case payout.ErrInvalidAmount, payout.ErrBelowMinimum:
+				c.JSON(400, dto.NewErrorPayoutResponse(err.Error()))
+			case payout.ErrExceedsPayable:
+				c.JSON(402, dto.NewErrorPayoutResponse(err.Error()))
+			default:
+				h.logger.WithError(err).Error("failed to request payout")
+				c.JSON(500, dto.NewErrorPayoutResponse("failed to request payout"))
+			}
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorPayoutResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorPayoutResponse(err.Error()))
+		})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorPayoutResponse("invalid payout ID"))
+		return
+	}
+
+	p, err := h.payoutService.Get(id)
+	if err != nil {
+		c.JSON(404, dto.NewErrorPayoutResponse("payout not found"))
+		return
+	}
+
+	h.policy.CanGet(c.Request.Context(), p).
+		Allow(func() {
+			c.JSON(200, dto.NewSuccessPayoutResponse(p))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorPayoutResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorPayoutResponse(err.Error()))
+		})
+}
+
+func (h *Handler) List(c *gin.Context) {
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	payouts, err := h.payoutService.ListByUserID(u.ID)
+	if err != nil {
+		c.JSON(500, dto.NewErrorListPayoutsResponse("failed to fetch payouts"))
+		return
+	}
+
+	h.policy.CanList(c.Request.Context(), payouts).
+		Allow(func() {
+			c.JSON(200, dto.NewSuccessListPayoutsResponse(payouts))
+		}).
+		Deny(func(reason string) {
+			c.JSON(403, dto.NewErrorListPayoutsResponse(reason))
+		}).
+		Err(func(err error) {
+			c.JSON(500, dto.NewErrorListPayoutsResponse(err.Error()))
+		})
+}
+
+// ListByStatus lists the payouts of a status, the requested ones by default,
+// for review.
+func (h *Handler) ListByStatus(c *gin.Context) {
+	status := payout.Status(c.DefaultQuery("status", string(payout.RequestedStatus)))
+
+	switch status {
+	case payout.RequestedStatus, payout.ApprovedStatus, payout.PaidStatus, payout.RejectedStatus:
+	default:
+		c.JSON(400, dto.NewErrorListPayoutsResponse("invalid status"))
+		return
+	}
+
+	payouts, err := h.payoutService.ListByStatus(status)
+	if err != nil {
+		c.JSON(500, dto.NewErrorListPayoutsResponse("failed to fetch payouts"))
+		return
+	}
+
+	c.JSON(200, dto.NewSuccessListPayoutsResponse(payouts))
+}
+
+func (h *Handler) Approve(c *gin.Context) {
+	h.review(c, func(id uuid.UUID) (*payout.Payout, error) {
+		return h.payoutService.Approve(id)
+	})
+}
+
+func (h *Handler) Reject(c *gin.Context) {
+	var req dto.RejectRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorPayoutResponse(err.Error()))
+		return
+	}
+
+	h.review(c, func(id uuid.UUID) (*payout.Payout, error) {
+		return h.payoutService.Reject(id, req.Reason)
+	})
+}
+
+func (h *Handler) MarkPaid(c *gin.Context) {
+	var req dto.MarkPaidRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, dto.NewErrorPayoutResponse(err.Error()))
+		return
+	}
+
+	h.review(c, func(id uuid.UUID) (*payout.Payout, error) {
+		return h.payoutService.MarkPaid(id, req.Reference)
+	})
+}
+
+// review changes the status of the payout in the path.
+func (h *Handler) review(c *gin.Context, change func(id uuid.UUID) (*payout.Payout, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(400, dto.NewErrorPayoutResponse("invalid payout ID"))
+		return
+	}
+
+	p, err := change(id)
+
+	switch err {
+	case nil:
+		c.JSON(200, dto.NewSuccessPayoutResponse(p))
+	case payout.ErrNotFound:
+		c.JSON(404, dto.NewErrorPayoutResponse(err.Error()))
+	case payout.ErrInvalidTransition:
+		c.JSON(409, dto.NewErrorPayoutResponse(err.Error()))
+	default:
+		h.logger.WithError(err).Error("failed to review payout")
+		c.JSON(500, dto.NewErrorPayoutResponse("failed to review payout"))
+	}
+}
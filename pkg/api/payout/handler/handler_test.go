@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payout"
+	"juno/pkg/api/payout/dto"
+	"juno/pkg/api/payout/policy"
+	"juno/pkg/api/transaction"
+	"juno/pkg/api/user"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type mockPayoutService struct {
+	withError  error
+	withPayout *payout.Payout
+
+	status payout.Status
+	reason string
+}
+
+func (m *mockPayoutService) Request(userID uuid.UUID, amount transaction.Amount) (*payout.Payout, error) {
+	if m.withError != nil {
+		return nil, m.withError
+	}
+
+	return &payout.Payout{ID: uuid.New(), UserID: userID, Amount: amount, Status: payout.RequestedStatus}, nil
+}
+
+func (m *mockPayoutService) Get(id uuid.UUID) (*payout.Payout, error) {
+	if m.withPayout == nil {
+		return nil, payout.ErrNotFound
+	}
+
+	return m.withPayout, nil
+}
+
+func (m *mockPayoutService) ListByUserID(userID uuid.UUID) ([]*payout.Payout, error) {
+	return []*payout.Payout{{ID: uuid.New(), UserID: userID}}, m.withError
+}
+
+func (m *mockPayoutService) ListByStatus(status payout.Status) ([]*payout.Payout, error) {
+	m.status = status
+	return []*payout.Payout{{ID: uuid.New(), Status: status}}, m.withError
+}
+
+func (m *mockPayoutService) Approve(id uuid.UUID) (*payout.Payout, error) {
+	return m.review(payout.ApprovedStatus)
+}
+
+func (m *mockPayoutService) Reject(id uuid.UUID, reason string) (*payout.Payout, error) {
+	m.reason = reason
+	return m.review(payout.RejectedStatus)
+}
+
+func (m *mockPayoutService) MarkPaid(id uuid.UUID, reference string) (*payout.Payout, error) {
+	return m.review(payout.PaidStatus)
+}
+
+func (m *mockPayoutService) review(status payout.Status) (*payout.Payout, error) {
+	if m.withError != nil {
+		return nil, m.withError
+	}
+
+	return &payout.Payout{ID: uuid.New(), Status: status}, nil
+}
+
+func send(handle func(c *gin.Context), userID uuid.UUID, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request = httptest.NewRequestWithContext(
+		auth.WithUser(context.Background(), &user.User{ID: userID}),
+		method,
+		path,
+		strings.NewReader(body),
+	)
+
+	if _, rest, ok := strings.Cut(path, "/payouts/"); ok {
+		id, _, _ := strings.Cut(rest, "/")
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: id})
+	}
+
+	handle(c)
+
+	return w
+}
+
+func TestRequest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		body     string
+		expected int
+	}{
+		{name: "success", body: `{"amount": 25}`, expected: 201},
+		{name: "below the minimum", err: payout.ErrBelowMinimum, body: `{"amount": 1}`, expected: 400},
+		{name: "invalid body", body: `{"amount": "all"}`, expected: 400},
+		{name: "more than earned", err: payout.ErrExceedsPayable, body: `{"amount": 25}`, expected: 402},
+		{name: "failure", err: errors.New("database unavailable"), body: `{"amount": 25}`, expected: 500},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(logrus.New(), policy.New(), &mockPayoutService{withError: tc.err})
+
+			w := send(h.Request, uuid.New(), "POST", "/tokens/payouts", tc.body)
+
+			if w.Code != tc.expected {
+				t.Fatalf("Expected %d, got %d", tc.expected, w.Code)
+			}
+
+			if w.Code != 201 {
+				return
+			}
+
+			var res dto.PayoutResponse
+
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if res.Payout.Amount != 25*transaction.Token || res.Payout.Status != string(payout.RequestedStatus) {
+				t.Errorf("Expected a requested payout of 25, got %+v", res.Payout)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	userID := uuid.New()
+	p := &payout.Payout{ID: uuid.New(), UserID: userID, Status: payout.PaidStatus}
+
+	t.Run("success", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{withPayout: p})
+
+		if w := send(h.Get, userID, "GET", "/tokens/payouts/"+p.ID.String(), ""); w.Code != 200 {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{})
+
+		if w := send(h.Get, userID, "GET", "/tokens/payouts/"+uuid.NewString(), ""); w.Code != 404 {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{withPayout: p})
+
+		if w := send(h.Get, uuid.New(), "GET", "/tokens/payouts/"+p.ID.String(), ""); w.Code != 403 {
+			t.Errorf("Expected 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	h := New(logrus.New(), policy.New(), &mockPayoutService{})
+
+	w := send(h.List, uuid.New(), "GET", "/tokens/payouts", "")
+
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var res dto.ListPayoutsResponse
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(res.Payouts) != 1 {
+		t.Errorf("Expected 1, got %d", len(res.Payouts))
+	}
+}
+
+func TestListByStatus(t *testing.T) {
+	t.Run("requested by default", func(t *testing.T) {
+		service := &mockPayoutService{}
+		h := New(logrus.New(), policy.New(), service)
+
+		if w := send(h.ListByStatus, uuid.Nil, "GET", "/admin/payouts", ""); w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		if service.status != payout.RequestedStatus {
+			t.Errorf("Expected %s, got %s", payout.RequestedStatus, service.status)
+		}
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{})
+
+		if w := send(h.ListByStatus, uuid.Nil, "GET", "/admin/payouts?status=lost", ""); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestReview(t *testing.T) {
+	path := "/admin/payouts/" + uuid.NewString()
+
+	t.Run("approve", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{})
+
+		if w := send(h.Approve, uuid.Nil, "POST", path+"/approve", ""); w.Code != 200 {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		service := &mockPayoutService{}
+		h := New(logrus.New(), policy.New(), service)
+
+		if w := send(h.Reject, uuid.Nil, "POST", path+"/reject", `{"reason": "unverified operator"}`); w.Code != 200 {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+
+		if service.reason != "unverified operator" {
+			t.Errorf("Expected the reason handed over, got %q", service.reason)
+		}
+
+		if w := send(h.Reject, uuid.Nil, "POST", path+"/reject", `{}`); w.Code != 400 {
+			t.Errorf("Expected a reason required, got %d", w.Code)
+		}
+	})
+
+	t.Run("paid", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{})
+
+		if w := send(h.MarkPaid, uuid.Nil, "POST", path+"/paid", `{"reference": "tr_1"}`); w.Code != 200 {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+	})
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "not found", err: payout.ErrNotFound, expected: 404},
+		{name: "invalid transition", err: payout.ErrInvalidTransition, expected: 409},
+		{name: "failure", err: errors.New("database unavailable"), expected: 500},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(logrus.New(), policy.New(), &mockPayoutService{withError: tc.err})
+
+			if w := send(h.Approve, uuid.Nil, "POST", path+"/approve", ""); w.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+
+	t.Run("invalid ID", func(t *testing.T) {
+		h := New(logrus.New(), policy.New(), &mockPayoutService{})
+
+		if w := send(h.Approve, uuid.Nil, "POST", "/admin/payouts/42/approve", ""); w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
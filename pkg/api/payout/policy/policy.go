@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"context"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payout"
+	"juno/pkg/can"
+)
+
+type Policy struct{}
+
+func New() *Policy {
+	return &Policy{}
+}
+
+// CanRequest lets anyone ask for a payout. Only what they earned can be paid
+// out.
+func (p *Policy) CanRequest() can.Result {
+	return can.Allowed()
+}
+
+func (p *Policy) CanGet(ctx context.Context, pay *payout.Payout) can.Result {
+	user := auth.MustUserFromContext(ctx)
+
+	if pay.UserID != user.ID {
+		return can.Denied("payout does not belong to user")
+	}
+
+	return can.Allowed()
+}
+
+func (p *Policy) CanList(ctx context.Context, payouts []*payout.Payout) can.Result {
+	user := auth.MustUserFromContext(ctx)
+
+	for _, pay := range payouts {
+		if pay.UserID != user.ID {
+			return can.Denied("payout does not belong to user")
+		}
+	}
+
+	return can.Allowed()
+}
@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"context"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/payout"
+	"juno/pkg/api/user"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRequest(t *testing.T) {
+	t.Run("anyone can request a payout", func(t *testing.T) {
+		if !New().CanRequest().Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("only the operator can get a payout", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanGet(ctx, &payout.Payout{UserID: userID}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanGet(ctx, &payout.Payout{UserID: uuid.New()}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("only the operator can list payouts", func(t *testing.T) {
+		p := New()
+		userID := uuid.New()
+		ctx := auth.WithUser(context.Background(), &user.User{ID: userID})
+
+		if !p.CanList(ctx, []*payout.Payout{{UserID: userID}}).Allowed {
+			t.Errorf("Expected allowed, got denied")
+		}
+
+		if p.CanList(ctx, []*payout.Payout{{UserID: userID}, {UserID: uuid.New()}}).Allowed {
+			t.Errorf("Expected denied, got allowed")
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/payout"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Repository keeps copies of the payouts, so a status is only changed
+// through Update.
+type Repository struct {
+	mu      sync.Mutex
+	payouts map[uuid.UUID]payout.Payout
+}
+
+func New() *Repository {
+	return &Repository{payouts: make(map[uuid.UUID]payout.Payout)}
+}
+
+func (r *Repository) Create(p *payout.Payout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.payouts[p.ID]; ok {
+		return errors.New("primary key violation")
+	}
+
+	r.payouts[p.ID] = *p
+
+	return nil
+}
+
+func (r *Repository) Get(id uuid.UUID) (*payout.Payout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.payouts[id]
+	if !ok {
+		return nil, payout.ErrNotFound
+	}
+
+	return &p, nil
+}
+
+func (r *Repository) ListByUserID(userID uuid.UUID) ([]*payout.Payout, error) {
+	return r.list(func(p *payout.Payout) bool { return p.UserID == userID }), nil
+}
+
+func (r *Repository) ListByStatus(status payout.Status) ([]*payout.Payout, error) {
+	return r.list(func(p *payout.Payout) bool { return p.Status == status }), nil
+}
+
+// list returns the payouts matching, oldest first.
+func (r *Repository) list(match func(p *payout.Payout) bool) []*payout.Payout {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var payouts []*payout.Payout
+
+	for _, p := range r.payouts {
+		if match(&p) {
+			payouts = append(payouts, &p)
+		}
+	}
+
+	sort.Slice(payouts, func(i, j int) bool {
+		return payouts[i].CreatedAt.Before(payouts[j].CreatedAt)
+	})
+
+	return payouts
+}
+
+func (r *Repository) Update(p *payout.Payout, from payout.Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.payouts[p.ID]
+	if !ok {
+		return payout.ErrNotFound
+	}
+
+	if stored.Status != from {
+		return payout.ErrConflict
+	}
+
+	r.payouts[p.ID] = *p
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package mem
+
+import (
+	"juno/pkg/api/payout"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newPayout() *payout.Payout {
+	return &payout.Payout{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Amount:    25,
+		Status:    payout.RequestedStatus,
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestCreate(t *testing.T) {
+	repo := New()
+
+	p := newPayout()
+
+	if err := repo.Create(p); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if err := repo.Create(p); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	if _, err := repo.Get(uuid.New()); err != payout.ErrNotFound {
+		t.Errorf("Expected %v, got %v", payout.ErrNotFound, err)
+	}
+}
+
+func TestListByUserID(t *testing.T) {
+	repo := New()
+
+	p := newPayout()
+	repo.Create(p)
+	repo.Create(newPayout())
+
+	payouts, err := repo.ListByUserID(p.UserID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(payouts) != 1 || payouts[0].ID != p.ID {
+		t.Errorf("Expected the user's payout, got %v", payouts)
+	}
+}
+
+func TestListByStatus(t *testing.T) {
+	repo := New()
+
+	first := newPayout()
+	second := newPayout()
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	approved := newPayout()
+	approved.Status = payout.ApprovedStatus
+
+	repo.Create(second)
+	repo.Create(first)
+	repo.Create(approved)
+
+	payouts, _ := repo.ListByStatus(payout.RequestedStatus)
+
+	if len(payouts) != 2 || payouts[0].ID != first.ID || payouts[1].ID != second.ID {
+		t.Errorf("Expected the requested payouts oldest first, got %v", payouts)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	repo := New()
+
+	p := newPayout()
+	repo.Create(p)
+
+	p.Status = payout.ApprovedStatus
+
+	if err := repo.Update(p, payout.RequestedStatus); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	// a concurrent change made from the same status loses
+	p.Status = payout.RejectedStatus
+
+	if err := repo.Update(p, payout.RequestedStatus); err != payout.ErrConflict {
+		t.Errorf("Expected %v, got %v", payout.ErrConflict, err)
+	}
+
+	if check, _ := repo.Get(p.ID); check.Status != payout.ApprovedStatus {
+		t.Errorf("Expected %s, got %s", payout.ApprovedStatus, check.Status)
+	}
+}
@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/payout"
+
+	"github.com/google/uuid"
+)
+
+const selectPayouts = "SELECT id, user_id, amount, status, reason, reference, created_at, updated_at FROM payouts"
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*payout.Payout, error) {
+	var p payout.Payout
+
+	err := row.Scan(&p.ID, &p.UserID, &p.Amount, &p.Status, &p.Reason, &p.Reference, &p.CreatedAt, &p.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, payout.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *Repository) Create(p *payout.Payout) error {
+	_, err := r.db.Exec(
+		"INSERT INTO payouts (id, user_id, amount, status, reason, reference, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		p.ID, p.UserID, p.Amount, p.Status, p.Reason, p.Reference, p.CreatedAt, p.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *Repository) Get(id uuid.UUID) (*payout.Payout, error) {
+	return scan(r.db.QueryRow(selectPayouts+" WHERE id = ?", id))
+}
+
+func (r *Repository) ListByUserID(userID uuid.UUID) ([]*payout.Payout, error) {
+	return r.list(selectPayouts+" WHERE user_id = ? ORDER BY created_at DESC", userID)
+}
+
+// ListByStatus returns the payouts oldest first, the order they are reviewed
+// in.
+func (r *Repository) ListByStatus(status payout.Status) ([]*payout.Payout, error) {
+	return r.list(selectPayouts+" WHERE status = ? ORDER BY created_at", status)
+}
+
+func (r *Repository) list(query string, args ...any) ([]*payout.Payout, error) {
+	rows, err := r.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var payouts []*payout.Payout
+
+	for rows.Next() {
+		p, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		payouts = append(payouts, p)
+	}
+
+	return payouts, rows.Err()
+}
+
+func (r *Repository) Update(p *payout.Payout, from payout.Status) error {
+	res, err := r.db.Exec(
+		"UPDATE payouts SET status = ?, reason = ?, reference = ?, updated_at = ? WHERE id = ? AND status = ?",
+		p.Status, p.Reason, p.Reference, p.UpdatedAt, p.ID, from,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return payout.ErrConflict
+	}
+
+	return nil
+}
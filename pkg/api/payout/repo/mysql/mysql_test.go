@@ -0,0 +1,129 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/payout"
+	"juno/pkg/api/payout/migration/mysql"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/payout_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func newPayout() *payout.Payout {
+	now := time.Now()
+
+	return &payout.Payout{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Amount:    25_000_001,
+		Status:    payout.RequestedStatus,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func TestCreate(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	p := newPayout()
+
+	defer db.Exec("DELETE FROM payouts WHERE id = ?", p.ID)
+
+	if err := repo.Create(p); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	check, err := repo.Get(p.ID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if check.UserID != p.UserID || check.Amount != p.Amount || check.Status != payout.RequestedStatus {
+		t.Errorf("Expected %+v, got %+v", p, check)
+	}
+
+	if _, err := repo.Get(uuid.New()); err != payout.ErrNotFound {
+		t.Errorf("Expected %v, got %v", payout.ErrNotFound, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	p := newPayout()
+
+	defer db.Exec("DELETE FROM payouts WHERE id = ?", p.ID)
+
+	repo.Create(p)
+
+	payouts, err := repo.ListByUserID(p.UserID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(payouts) != 1 || payouts[0].ID != p.ID {
+		t.Errorf("Expected the user's payout, got %v", payouts)
+	}
+
+	payouts, _ = repo.ListByStatus(payout.RequestedStatus)
+
+	found := false
+	for _, requested := range payouts {
+		found = found || requested.ID == p.ID
+	}
+
+	if !found {
+		t.Errorf("Expected the requested payout listed")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	p := newPayout()
+
+	defer db.Exec("DELETE FROM payouts WHERE id = ?", p.ID)
+
+	repo.Create(p)
+
+	p.Status = payout.RejectedStatus
+	p.Reason = "unverified operator"
+
+	if err := repo.Update(p, payout.RequestedStatus); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	p.Status = payout.ApprovedStatus
+
+	if err := repo.Update(p, payout.RequestedStatus); err != payout.ErrConflict {
+		t.Errorf("Expected %v, got %v", payout.ErrConflict, err)
+	}
+
+	check, _ := repo.Get(p.ID)
+
+	if check.Status != payout.RejectedStatus || check.Reason != p.Reason {
+		t.Errorf("Expected the rejection stored, got %+v", check)
+	}
+}
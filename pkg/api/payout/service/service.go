@@ -0,0 +1,204 @@
+package service
+
+import (
+	"juno/pkg/api/payout"
+	"juno/pkg/api/token"
+	"juno/pkg/api/transaction"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMinimum is the smallest amount paid out, so transfer fees do
+	// not eat the payouts
+	DefaultMinimum = 10 * transaction.Token
+	// DefaultHold is how long earnings are held before they can be paid
+	// out, so earnings credited by mistake can still be corrected before
+	// they leave the ledger
+	DefaultHold = 7 * 24 * time.Hour
+)
+
+type Service struct {
+	payoutRepo payout.Repository
+	ledger     payout.Ledger
+	minimum    transaction.Amount
+	hold       time.Duration
+	now        func() time.Time
+}
+
+func WithMinimum(minimum transaction.Amount) func(s *Service) {
+	return func(s *Service) {
+		s.minimum = minimum
+	}
+}
+
+func WithHold(hold time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.hold = hold
+	}
+}
+
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func New(payoutRepo payout.Repository, ledger payout.Ledger, opts ...func(s *Service)) *Service {
+	s := &Service{
+		payoutRepo: payoutRepo,
+		ledger:     ledger,
+		minimum:    DefaultMinimum,
+		hold:       DefaultHold,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Service) Request(userID uuid.UUID, amount transaction.Amount) (*payout.Payout, error) {
+	if amount <= 0 {
+		return nil, payout.ErrInvalidAmount
+	}
+
+	if amount < s.minimum {
+		return nil, payout.ErrBelowMinimum
+	}
+
+	now := s.now()
+
+	p := &payout.Payout{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Amount:    amount,
+		Status:    payout.RequestedStatus,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// the ledger checks the hold, so concurrent requests of any instance
+	// cannot lock more than the user can be paid
+	if err := s.ledger.LockPayout(userID, p.ID, amount, now.Add(-s.hold)); err == token.ErrInsufficientBalance {
+		return nil, payout.ErrExceedsPayable
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := s.payoutRepo.Create(p); err != nil {
+		if err := s.ledger.ReleasePayout(userID, p.ID, amount); err != nil {
+			return nil, err
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Service) Get(id uuid.UUID) (*payout.Payout, error) {
+	return s.payoutRepo.Get(id)
+}
+
+func (s *Service) ListByUserID(userID uuid.UUID) ([]*payout.Payout, error) {
+	return s.payoutRepo.ListByUserID(userID)
+}
+
+func (s *Service) ListByStatus(status payout.Status) ([]*payout.Payout, error) {
+	return s.payoutRepo.ListByStatus(status)
+}
+
+func (s *Service) Approve(id uuid.UUID) (*payout.Payout, error) {
+	p, err := s.payoutRepo.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Status != payout.RequestedStatus {
+		return nil, payout.ErrInvalidTransition
+	}
+
+	if err := s.transition(p, payout.RequestedStatus, payout.ApprovedStatus); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Reject changes the status before giving the amount back, so a payout being
+// paid concurrently is not also released. Rejecting a rejected payout again
+// gives back what a failure left locked.
+func (s *Service) Reject(id uuid.UUID, reason string) (*payout.Payout, error) {
+	p, err := s.payoutRepo.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Status {
+	case payout.RequestedStatus, payout.ApprovedStatus:
+		p.Reason = reason
+
+		if err := s.transition(p, p.Status, payout.RejectedStatus); err != nil {
+			return nil, err
+		}
+	case payout.RejectedStatus:
+	default:
+		return nil, payout.ErrInvalidTransition
+	}
+
+	if err := s.ledger.ReleasePayout(p.UserID, p.ID, p.Amount); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// MarkPaid changes the status before paying the amount out, for the same
+// reason as Reject. Marking a paid payout paid again pays out what a failure
+// left locked.
+func (s *Service) MarkPaid(id uuid.UUID, reference string) (*payout.Payout, error) {
+	p, err := s.payoutRepo.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Status {
+	case payout.ApprovedStatus:
+		p.Reference = reference
+
+		if err := s.transition(p, payout.ApprovedStatus, payout.PaidStatus); err != nil {
+			return nil, err
+		}
+	case payout.PaidStatus:
+	default:
+		return nil, payout.ErrInvalidTransition
+	}
+
+	if err := s.ledger.PayPayout(p.UserID, p.ID, p.Amount); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// transition changes the payout's status from the one it had, returning
+// ErrInvalidTransition when it was changed concurrently.
+func (s *Service) transition(p *payout.Payout, from, to payout.Status) error {
+	p.Status = to
+	p.UpdatedAt = s.now()
+
+	err := s.payoutRepo.Update(p, from)
+
+	if err == payout.ErrConflict {
+		return payout.ErrInvalidTransition
+	}
+
+	return err
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"juno/pkg/api/payout"
+	"juno/pkg/api/payout/repo/mem"
+	tokenService "juno/pkg/api/token/service"
+	"juno/pkg/api/transaction"
+	tranRepo "juno/pkg/api/transaction/repo/mem"
+	tranService "juno/pkg/api/transaction/service"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// later is a clock after the hold of what is earned now
+func later() time.Time {
+	return time.Now().Add(DefaultHold + time.Hour)
+}
+
+func setup(opts ...func(s *Service)) (*Service, *tokenService.Service) {
+	tokens := tokenService.New(tranService.New(logrus.New(), tranRepo.New()))
+
+	return New(mem.New(), tokens, append([]func(s *Service){WithClock(later)}, opts...)...), tokens
+}
+
+// earn credits the operator the amount, as a job their node answered would
+func earn(t *testing.T, tokens *tokenService.Service, operatorID uuid.UUID, amount transaction.Amount) {
+	t.Helper()
+
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	tokens.Deposit(userID, uuid.New(), amount)
+	tokens.Escrow(userID, jobID, amount)
+
+	earnings := map[transaction.Earner]transaction.Amount{{OwnerID: operatorID, SourceID: uuid.New()}: amount}
+
+	if err := tokens.Settle(userID, jobID, amount, amount, earnings); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	t.Run("locks the earnings", func(t *testing.T) {
+		s, tokens := setup()
+
+		operatorID := uuid.New()
+		earn(t, tokens, operatorID, 50*transaction.Token)
+
+		p, err := s.Request(operatorID, 20*transaction.Token)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if p.Status != payout.RequestedStatus || p.Amount != 20*transaction.Token {
+			t.Errorf("Expected a requested payout of 20, got %+v", p)
+		}
+
+		if b, _ := tokens.Balance(operatorID); b.Earnings != 30*transaction.Token || b.PayingOut != 20*transaction.Token {
+			t.Errorf("Expected 20 locked, got %+v", b)
+		}
+	})
+
+	t.Run("below the minimum", func(t *testing.T) {
+		s, tokens := setup(WithMinimum(25 * transaction.Token))
+
+		operatorID := uuid.New()
+		earn(t, tokens, operatorID, 50*transaction.Token)
+
+		if _, err := s.Request(operatorID, 20*transaction.Token); err != payout.ErrBelowMinimum {
+			t.Errorf("Expected %v, got %v", payout.ErrBelowMinimum, err)
+		}
+
+		if _, err := s.Request(operatorID, 0); err != payout.ErrInvalidAmount {
+			t.Errorf("Expected %v, got %v", payout.ErrInvalidAmount, err)
+		}
+	})
+
+	t.Run("holds recent earnings", func(t *testing.T) {
+		s, tokens := setup(WithClock(time.Now))
+
+		operatorID := uuid.New()
+		earn(t, tokens, operatorID, 50*transaction.Token)
+
+		if _, err := s.Request(operatorID, 20*transaction.Token); err != payout.ErrExceedsPayable {
+			t.Errorf("Expected %v, got %v", payout.ErrExceedsPayable, err)
+		}
+
+		if b, _ := tokens.Balance(operatorID); b.Earnings != 50*transaction.Token || b.PayingOut != 0 {
+			t.Errorf("Expected nothing locked, got %+v", b)
+		}
+	})
+
+	t.Run("more than earned", func(t *testing.T) {
+		s, tokens := setup()
+
+		operatorID := uuid.New()
+		earn(t, tokens, operatorID, 50*transaction.Token)
+
+		s.Request(operatorID, 40*transaction.Token)
+
+		if _, err := s.Request(operatorID, 20*transaction.Token); err != payout.ErrExceedsPayable {
+			t.Errorf("Expected %v, got %v", payout.ErrExceedsPayable, err)
+		}
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		s, tokens := setup()
+
+		operatorID := uuid.New()
+		earn(t, tokens, operatorID, 50*transaction.Token)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		requested := 0
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if _, err := s.Request(operatorID, 20*transaction.Token); err == nil {
+					mu.Lock()
+					requested++
+					mu.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if requested != 2 {
+			t.Errorf("Expected 2 payouts, got %d", requested)
+		}
+	})
+}
+
+func TestReview(t *testing.T) {
+	requested := func(t *testing.T) (*Service, *tokenService.Service, *payout.Payout) {
+		s, tokens := setup()
+
+		operatorID := uuid.New()
+		earn(t, tokens, operatorID, 50*transaction.Token)
+
+		p, err := s.Request(operatorID, 20*transaction.Token)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return s, tokens, p
+	}
+
+	t.Run("paid", func(t *testing.T) {
+		s, tokens, p := requested(t)
+
+		if _, err := s.MarkPaid(p.ID, "tr_1"); err != payout.ErrInvalidTransition {
+			t.Errorf("Expected unapproved payouts unpaid, got %v", err)
+		}
+
+		if _, err := s.Approve(p.ID); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if _, err := s.Approve(p.ID); err != payout.ErrInvalidTransition {
+			t.Errorf("Expected %v, got %v", payout.ErrInvalidTransition, err)
+		}
+
+		// marking it paid again changes nothing
+		for i := 0; i < 2; i++ {
+			if _, err := s.MarkPaid(p.ID, "tr_1"); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payout.PaidStatus || check.Reference != "tr_1" {
+			t.Errorf("Expected paid by tr_1, got %+v", check)
+		}
+
+		if b, _ := tokens.Balance(p.UserID); b.Earnings != 30*transaction.Token || b.PayingOut != 0 {
+			t.Errorf("Expected 20 paid out, got %+v", b)
+		}
+
+		if _, err := s.Reject(p.ID, "too late"); err != payout.ErrInvalidTransition {
+			t.Errorf("Expected %v, got %v", payout.ErrInvalidTransition, err)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		s, tokens, p := requested(t)
+
+		s.Approve(p.ID)
+
+		for i := 0; i < 2; i++ {
+			if _, err := s.Reject(p.ID, "unverified operator"); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+		}
+
+		if check, _ := s.Get(p.ID); check.Status != payout.RejectedStatus || check.Reason != "unverified operator" {
+			t.Errorf("Expected rejected, got %+v", check)
+		}
+
+		if b, _ := tokens.Balance(p.UserID); b.Earnings != 50*transaction.Token || b.PayingOut != 0 {
+			t.Errorf("Expected the earnings given back once, got %+v", b)
+		}
+
+		if _, err := s.MarkPaid(p.ID, "tr_1"); err != payout.ErrInvalidTransition {
+			t.Errorf("Expected %v, got %v", payout.ErrInvalidTransition, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, _ := setup()
+
+		if _, err := s.Approve(uuid.New()); err != payout.ErrNotFound {
+			t.Errorf("Expected %v, got %v", payout.ErrNotFound, err)
+		}
+	})
+}
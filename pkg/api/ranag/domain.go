@@ -14,13 +14,24 @@ var ErrAddressExists = errors.New("address already exists")
 var ErrInvalidAddress = errors.New("invalid address")
 var ErrInvalidShards = errors.New("invalid shards")
 var ErrNotFound = errors.New("ranag not found")
+var ErrInvalidPublicKey = errors.New("invalid public key")
 var ErrInternal = errors.New("internal error")
+var ErrParentNotFound = errors.New("parent ranag not found")
+var ErrOutsideParent = errors.New("shards must be within the parent's shard assignments")
+var ErrCycle = errors.New("ranag cannot be its own ancestor")
+var ErrTooDeep = errors.New("ranag tree is too deep")
+
+// MaxTreeDepth is how many levels of ranags a tree may have, the root
+// included. With a fan-out of 32 children per ranag, 4 levels cover a
+// million shard ranges.
+const MaxTreeDepth = 4
 
 type Repository interface {
 	All() ([]*Ranag, error)
 	Create(n *Ranag) error
 	Get(id uuid.UUID) (*Ranag, error)
 	ListByOwnerID(ownerID uuid.UUID) ([]*Ranag, error)
+	ListByParentID(parentID uuid.UUID) ([]*Ranag, error)
 	FirstWhereAddress(address string) (*Ranag, error)
 	Update(n *Ranag) error
 	Delete(id uuid.UUID) error
@@ -28,20 +39,29 @@ type Repository interface {
 
 type Service interface {
 	AllShardsRanags() (map[int][]*Ranag, error)
+	// GroupByRange groups the root ranags by shard range. Children are
+	// reached through their parents.
 	GroupByRange() (map[[2]int][]*Ranag, error)
 	Get(id uuid.UUID) (*Ranag, error)
+	GetByAddress(address string) (*Ranag, error)
 	ListByOwnerID(ownerID uuid.UUID) ([]*Ranag, error)
-	Create(ownerID uuid.UUID, addr string, shardAssignments [][2]int) (*Ranag, error)
+	// Children lists the ranags the ranag at address delegates to.
+	Children(address string) ([]*Ranag, error)
+	Create(ownerID uuid.UUID, addr string, shardAssignments [][2]int, parentID uuid.UUID) (*Ranag, error)
 	Update(id uuid.UUID, n *Ranag) (*Ranag, error)
+	// RegisterKey sets the public key the ranag's receipts are verified with.
+	RegisterKey(id uuid.UUID, publicKey string) (*Ranag, error)
 	Delete(id uuid.UUID) error
 }
 
 type Handler interface {
 	AllShardsRanags(c *gin.Context)
+	Children(c *gin.Context)
 	List(c *gin.Context)
 	Get(c *gin.Context)
 	Create(c *gin.Context)
 	Update(c *gin.Context)
+	RegisterKey(c *gin.Context)
 	Delete(c *gin.Context)
 }
 
@@ -53,11 +73,16 @@ type Policy interface {
 	CanDelete(ctx context.Context, n *Ranag) can.Result
 }
 
+// Ranag aggregates its shard assignments. A ranag with a parent is a child
+// the parent delegates the overlapping part of its ranges to.
 type Ranag struct {
 	ID               uuid.UUID `json:"id"`
 	OwnerID          uuid.UUID `json:"owner_id"`
+	ParentID         uuid.UUID `json:"parent_id"`
 	Address          string    `json:"address"`
 	ShardAssignments [][2]int  `json:"shard_assignments"`
+	// PublicKey verifies the receipts of the work the ranag is paid for
+	PublicKey string `json:"public_key"`
 }
 
 func New(id, ownerID uuid.UUID, address string, shardAssignments [][2]int) *Ranag {
@@ -14,18 +14,27 @@ const (
 type Ranag struct {
 	ID               string   `json:"id"`
 	OwnerID          string   `json:"owner_id"`
+	ParentID         string   `json:"parent_id,omitempty"`
 	Address          string   `json:"address"`
 	Status           string   `json:"status"`
 	ShardAssignments [][2]int `json:"shard_assignments"`
+	PublicKey        string   `json:"public_key,omitempty"`
 }
 
 func NewRanagFromDomain(n *ranag.Ranag) *Ranag {
-	return &Ranag{
+	r := &Ranag{
 		ID:               n.ID.String(),
 		OwnerID:          n.OwnerID.String(),
 		Address:          n.Address,
 		ShardAssignments: n.ShardAssignments,
+		PublicKey:        n.PublicKey,
 	}
+
+	if n.ParentID != uuid.Nil {
+		r.ParentID = n.ParentID.String()
+	}
+
+	return r
 }
 
 func (n Ranag) ToDomain() (*ranag.Ranag, error) {
@@ -38,7 +47,24 @@ func (n Ranag) ToDomain() (*ranag.Ranag, error) {
 	if err != nil {
 		return nil, err
 	}
-	return ranag.New(id, ownerID, n.Address, n.ShardAssignments), nil
+
+	parentID, err := parseParentID(n.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := ranag.New(id, ownerID, n.Address, n.ShardAssignments)
+	r.ParentID = parentID
+	return r, nil
+}
+
+// parseParentID parses an optional parent ID, empty for root ranags.
+func parseParentID(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(s)
 }
 
 type ListRanagsResponse struct {
@@ -90,14 +116,21 @@ func NewErrorGetRanagResponse(message string) GetRanagResponse {
 type CreateRanagRequest struct {
 	Address          string   `json:"address"`
 	ShardAssignments [][2]int `json:"shard_assignments"`
+	ParentID         string   `json:"parent_id,omitempty"`
 }
 
-func (r CreateRanagRequest) ToDomain() ranag.Ranag {
+func (r CreateRanagRequest) ToDomain() (ranag.Ranag, error) {
+	parentID, err := parseParentID(r.ParentID)
+	if err != nil {
+		return ranag.Ranag{}, err
+	}
+
 	return ranag.Ranag{
 		ID:               uuid.New(),
+		ParentID:         parentID,
 		Address:          r.Address,
 		ShardAssignments: r.ShardAssignments,
-	}
+	}, nil
 }
 
 type CreateRanagResponse struct {
@@ -125,15 +158,28 @@ func NewErrorCreateRanagResponse(message string) CreateRanagResponse {
 type UpdateRanagRequest struct {
 	Address          string   `json:"address"`
 	ShardAssignments [][2]int `json:"shard_assignments"`
+	ParentID         string   `json:"parent_id,omitempty"`
 }
 
 func (r UpdateRanagRequest) ToDomain() (*ranag.Ranag, error) {
+	parentID, err := parseParentID(r.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ranag.Ranag{
+		ParentID:         parentID,
 		Address:          r.Address,
 		ShardAssignments: r.ShardAssignments,
 	}, nil
 }
 
+// RegisterKeyRequest registers the public key the ranag's receipts are
+// verified with, as the ranag logs it when it starts.
+type RegisterKeyRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
 type UpdateRanagResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"` // Only present when there's an error
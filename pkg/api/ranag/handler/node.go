@@ -41,6 +41,35 @@ func (h *Handler) AllShardsRanags(c *gin.Context) {
 	c.JSON(200, dto.NewSuccessAllShardsRanagsResponse(shards))
 }
 
+// Children lists the children of the ranag at the address query parameter,
+// so ranags can find the ranags they delegate to.
+func (h *Handler) Children(c *gin.Context) {
+	address := c.Query("address")
+
+	if address == "" {
+		c.JSON(400, dto.NewErrorListRanagsResponse(
+			ranag.ErrInvalidAddress.Error(),
+		))
+		return
+	}
+
+	children, err := h.ranagService.Children(address)
+
+	if err != nil {
+		h.logger.Debug(
+			logrus.Fields{
+				"error":   err.Error(),
+				"address": address,
+			})
+		c.JSON(404, dto.NewErrorListRanagsResponse(
+			ranag.ErrNotFound.Error(),
+		))
+		return
+	}
+
+	c.JSON(200, dto.NewSuccessListRanagsResponse(children))
+}
+
 func (h *Handler) List(c *gin.Context) {
 	u := auth.MustUserFromContext(c.Request.Context())
 
@@ -134,9 +163,16 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
+	r, err := req.ToDomain()
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	h.policy.CanCreate().
 		Allow(func() {
-			n, err := h.ranagService.Create(u.ID, req.Address, req.ShardAssignments)
+			n, err := h.ranagService.Create(u.ID, r.Address, r.ShardAssignments, r.ParentID)
 
 			if err != nil {
 				h.logger.Debug(
@@ -238,6 +274,72 @@ func (h *Handler) Update(c *gin.Context) {
 
 }
 
+func (h *Handler) RegisterKey(c *gin.Context) {
+
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	id, err := uuid.Parse(c.Param("id"))
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	n, err := h.ranagService.Get(id)
+
+	if err != nil {
+		c.JSON(404, dto.NewErrorGetRanagResponse(
+			ranag.ErrNotFound.Error(),
+		))
+		return
+	}
+
+	h.policy.CanUpdate(c.Request.Context(), n).
+		Allow(func() {
+
+			var req dto.RegisterKeyRequest
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			n, err := h.ranagService.RegisterKey(id, req.PublicKey)
+
+			if err == ranag.ErrInvalidPublicKey {
+				c.JSON(400, dto.NewErrorUpdateRanagResponse(
+					err.Error(),
+				))
+				return
+			}
+
+			if err != nil {
+				h.logger.Debug(
+					logrus.Fields{
+						"error": err.Error(),
+						"user":  u.ID,
+						"ranag": id,
+					})
+				c.JSON(500, dto.NewErrorUpdateRanagResponse(
+					ranag.ErrInternal.Error(),
+				))
+				return
+			}
+			c.JSON(200, dto.NewSuccessUpdateRanagResponse(n))
+		}).
+		Deny(func(reason string) {
+			c.JSON(401, dto.NewErrorUpdateRanagResponse(
+				reason,
+			))
+		}).
+		Err(func(err error) {
+			h.logger.Debug(err)
+			c.JSON(500, dto.NewErrorUpdateRanagResponse(
+				ranag.ErrInternal.Error(),
+			))
+		})
+
+}
+
 func (h *Handler) Delete(c *gin.Context) {
 
 	u := auth.MustUserFromContext(c.Request.Context())
@@ -446,6 +446,89 @@ func TestCreate(t *testing.T) {
 			t.Errorf("Expected error message 'address already exists', got %s", res.Message)
 		}
 	})
+
+	t.Run("invalid parent id", func(t *testing.T) {
+		handler := New(logrus.New(), policy.New(), service.New(mem.New()))
+
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+
+		body, err := json.Marshal(dto.CreateRanagRequest{
+			Address:  "example.com:8080",
+			ParentID: "invalid",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		tc.Request = httptest.NewRequest("POST", "/ranags", bytes.NewReader(body)).
+			WithContext(auth.WithUser(context.Background(), &user.User{ID: uuid.New()}))
+
+		handler.Create(tc)
+
+		if w.Code != 400 {
+			t.Fatalf("Expected status code 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestChildren(t *testing.T) {
+	repo := mem.New()
+	handler := New(logrus.New(), policy.New(), service.New(repo))
+
+	parent := &ranag.Ranag{
+		ID:               uuid.New(),
+		OwnerID:          uuid.New(),
+		Address:          "parent.com:8080",
+		ShardAssignments: [][2]int{{0, 100}},
+	}
+
+	child := &ranag.Ranag{
+		ID:               uuid.New(),
+		OwnerID:          parent.OwnerID,
+		ParentID:         parent.ID,
+		Address:          "child.com:8080",
+		ShardAssignments: [][2]int{{0, 50}},
+	}
+
+	for _, n := range []*ranag.Ranag{parent, child} {
+		if err := repo.Create(n); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+		tc.Request = httptest.NewRequest("GET", "/shards/ranags/children?address=parent.com:8080", nil)
+
+		handler.Children(tc)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		var res dto.ListRanagsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(res.Ranags) != 1 || res.Ranags[0].Address != child.Address || res.Ranags[0].ParentID != parent.ID.String() {
+			t.Errorf("Expected child %s, got %v", child.Address, res.Ranags)
+		}
+	})
+
+	t.Run("unknown address", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+		tc.Request = httptest.NewRequest("GET", "/shards/ranags/children?address=unknown.com:8080", nil)
+
+		handler.Children(tc)
+
+		if w.Code != 404 {
+			t.Fatalf("Expected status code 404, got %d", w.Code)
+		}
+	})
 }
 
 func TestUpdate(t *testing.T) {
@@ -720,3 +803,43 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisterKey(t *testing.T) {
+	ownerID := uuid.New()
+
+	for _, tc := range []struct {
+		name     string
+		userID   uuid.UUID
+		body     string
+		expected int
+	}{
+		{name: "success", userID: ownerID, body: `{"public_key": "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`, expected: 200},
+		{name: "invalid key", userID: ownerID, body: `{"public_key": "c2hvcnQ="}`, expected: 400},
+		{name: "missing key", userID: ownerID, body: `{}`, expected: 400},
+		{name: "not the owner", userID: uuid.New(), body: `{"public_key": "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`, expected: 401},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mem.New()
+			handler := New(logrus.New(), policy.New(), service.New(repo))
+
+			n := &ranag.Ranag{ID: uuid.New(), OwnerID: ownerID, Address: "example.com:8000"}
+
+			if err := repo.Create(n); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest("PUT", "/ranags/"+n.ID.String()+"/key", strings.NewReader(tc.body)).
+				WithContext(auth.WithUser(context.Background(), &user.User{ID: tc.userID}))
+			c.Params = gin.Params{{Key: "id", Value: n.ID.String()}}
+
+			handler.RegisterKey(c)
+
+			if w.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
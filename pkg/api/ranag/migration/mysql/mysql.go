@@ -1,6 +1,9 @@
 package mysql
 
-import "database/sql"
+import (
+	"database/sql"
+	"sort"
+)
 
 var migrations = map[string]string{
 	"create_ranags_table": `
@@ -14,6 +17,16 @@ var migrations = map[string]string{
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 		);`,
+	"create_ranag_parents_table": `
+		CREATE TABLE IF NOT EXISTS ranag_parents (
+			ranag_id VARCHAR(36) PRIMARY KEY,
+			parent_id VARCHAR(36) NOT NULL,
+			INDEX (parent_id)
+		);`,
+
+	// the key the receipts of the ranag's work are verified with
+	"migrate_ranags_public_key": `
+		ALTER TABLE ranags ADD COLUMN public_key VARCHAR(64) NOT NULL DEFAULT '';`,
 }
 
 func ExecuteMigrations(db *sql.DB) error {
@@ -25,7 +38,16 @@ func ExecuteMigrations(db *sql.DB) error {
 		return err
 	}
 
-	for name, migration := range migrations {
+	// columns are added after their tables are created
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		migration := migrations[name]
 
 		// check if migration has already been executed
 		var count int
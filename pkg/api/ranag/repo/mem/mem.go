@@ -57,6 +57,18 @@ func (r *Repository) ListByOwnerID(ownerID uuid.UUID) ([]*ranag.Ranag, error) {
 	return ranags, nil
 }
 
+func (r *Repository) ListByParentID(parentID uuid.UUID) ([]*ranag.Ranag, error) {
+	var ranags []*ranag.Ranag
+
+	for _, n := range r.ranags {
+		if n.ParentID == parentID {
+			ranags = append(ranags, n)
+		}
+	}
+
+	return ranags, nil
+}
+
 func (r *Repository) FirstWhereAddress(address string) (*ranag.Ranag, error) {
 	for _, n := range r.ranags {
 		if n.Address == address {
@@ -295,3 +295,31 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestListByParentID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		parentID := uuid.New()
+
+		repo := New()
+
+		for _, n := range []*ranag.Ranag{
+			{ID: uuid.New(), Address: "ranag1:8080", ParentID: parentID},
+			{ID: uuid.New(), Address: "ranag2:8080", ParentID: parentID},
+			{ID: uuid.New(), Address: "ranag3:8080"},
+		} {
+			if err := repo.Create(n); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		}
+
+		children, err := repo.ListByParentID(parentID)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		if len(children) != 2 {
+			t.Errorf("Expected 2 children, got %d", len(children))
+		}
+	})
+}
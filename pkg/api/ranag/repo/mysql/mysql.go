@@ -8,6 +8,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// parents live in their own table so ranags registered before trees existed
+// are roots without migrating the ranags table
+const selectRanags = "SELECT r.id, r.owner_id, r.address, r.shard_assignments, r.public_key, p.parent_id FROM ranags r LEFT JOIN ranag_parents p ON p.ranag_id = r.id"
+
 type Repo struct {
 	db *sql.DB
 }
@@ -16,109 +20,105 @@ func New(db *sql.DB) *Repo {
 	return &Repo{db: db}
 }
 
-func (r *Repo) Create(n *ranag.Ranag) error {
+type scanner interface {
+	Scan(dest ...any) error
+}
 
-	assignments, err := json.Marshal(n.ShardAssignments)
+func scan(row scanner) (*ranag.Ranag, error) {
+	var n ranag.Ranag
+	var shardAssignmentJson string
+	var parentID sql.NullString
+
+	err := row.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson, &n.PublicKey, &parentID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = r.db.Exec("INSERT INTO ranags (id, owner_id, address, shard_assignments) VALUES (?, ?, ?, ?)", n.ID, n.OwnerID, n.Address, string(assignments))
+	err = json.Unmarshal([]byte(shardAssignmentJson), &n.ShardAssignments)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if parentID.Valid {
+		n.ParentID, err = uuid.Parse(parentID.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &n, nil
 }
 
-func (r *Repo) All() ([]*ranag.Ranag, error) {
+func (r *Repo) query(query string, args ...any) ([]*ranag.Ranag, error) {
 	var ranags []*ranag.Ranag
 
-	rows, err := r.db.Query("SELECT id, owner_id, address, shard_assignments FROM ranags")
+	rows, err := r.db.Query(query, args...)
 
 	if err != nil {
 		return nil, err
 	}
 
-	for rows.Next() {
-		var n ranag.Ranag
-		var shardAssignmentJson string
-		err = rows.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
-		if err != nil {
-			return nil, err
-		}
+	defer rows.Close()
 
-		err = json.Unmarshal([]byte(shardAssignmentJson), &n.ShardAssignments)
+	for rows.Next() {
+		n, err := scan(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		ranags = append(ranags, &n)
+		ranags = append(ranags, n)
 	}
 
 	return ranags, nil
 }
 
-func (r *Repo) Get(id uuid.UUID) (*ranag.Ranag, error) {
-	var n ranag.Ranag
+func (r *Repo) setParent(n *ranag.Ranag) error {
+	if n.ParentID == uuid.Nil {
+		_, err := r.db.Exec("DELETE FROM ranag_parents WHERE ranag_id = ?", n.ID)
+		return err
+	}
 
-	var shardAssignmentJson string
+	_, err := r.db.Exec("INSERT INTO ranag_parents (ranag_id, parent_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE parent_id = VALUES(parent_id)", n.ID, n.ParentID)
+	return err
+}
+
+func (r *Repo) Create(n *ranag.Ranag) error {
 
-	err := r.db.QueryRow("SELECT id, owner_id, address, shard_assignments FROM ranags WHERE id = ?", id).Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
+	assignments, err := json.Marshal(n.ShardAssignments)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = json.Unmarshal([]byte(shardAssignmentJson), &n.ShardAssignments)
+	_, err = r.db.Exec("INSERT INTO ranags (id, owner_id, address, shard_assignments, public_key) VALUES (?, ?, ?, ?, ?)", n.ID, n.OwnerID, n.Address, string(assignments), n.PublicKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &n, nil
+	return r.setParent(n)
 }
 
-func (r *Repo) ListByOwnerID(ownerID uuid.UUID) ([]*ranag.Ranag, error) {
-	var ranags []*ranag.Ranag
-
-	rows, err := r.db.Query("SELECT id, owner_id, address, shard_assignments FROM ranags WHERE owner_id = ?", ownerID)
-
-	if err != nil {
-		return nil, err
-	}
+func (r *Repo) All() ([]*ranag.Ranag, error) {
+	return r.query(selectRanags)
+}
 
-	for rows.Next() {
-		var n ranag.Ranag
-		var shardAssignmentJson string
-		err = rows.Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
-		if err != nil {
-			return nil, err
-		}
+func (r *Repo) Get(id uuid.UUID) (*ranag.Ranag, error) {
+	return scan(r.db.QueryRow(selectRanags+" WHERE r.id = ?", id))
+}
 
-		err = json.Unmarshal([]byte(shardAssignmentJson), &n.ShardAssignments)
-		if err != nil {
-			return nil, err
-		}
+func (r *Repo) ListByOwnerID(ownerID uuid.UUID) ([]*ranag.Ranag, error) {
+	return r.query(selectRanags+" WHERE r.owner_id = ?", ownerID)
+}
 
-		ranags = append(ranags, &n)
+func (r *Repo) ListByParentID(parentID uuid.UUID) ([]*ranag.Ranag, error) {
+	if parentID == uuid.Nil {
+		return r.query(selectRanags + " WHERE p.parent_id IS NULL")
 	}
 
-	return ranags, nil
+	return r.query(selectRanags+" WHERE p.parent_id = ?", parentID)
 }
 
 func (r *Repo) FirstWhereAddress(address string) (*ranag.Ranag, error) {
-	var n ranag.Ranag
-	var shardAssignmentJson string
-	err := r.db.QueryRow("SELECT id, owner_id, address, shard_assignments FROM ranags WHERE address = ?", address).Scan(&n.ID, &n.OwnerID, &n.Address, &shardAssignmentJson)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal([]byte(shardAssignmentJson), &n.ShardAssignments)
-	if err != nil {
-		return nil, err
-	}
-
-	return &n, nil
+	return scan(r.db.QueryRow(selectRanags+" WHERE r.address = ?", address))
 }
 
 func (r *Repo) Update(n *ranag.Ranag) error {
@@ -128,12 +128,12 @@ func (r *Repo) Update(n *ranag.Ranag) error {
 		return err
 	}
 
-	_, err = r.db.Exec("UPDATE ranags SET owner_id = ?, address = ?, shard_assignments = ? WHERE id = ?", n.OwnerID, n.Address, string(assignments), n.ID)
+	_, err = r.db.Exec("UPDATE ranags SET owner_id = ?, address = ?, shard_assignments = ?, public_key = ? WHERE id = ?", n.OwnerID, n.Address, string(assignments), n.PublicKey, n.ID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return r.setParent(n)
 }
 
 func (r *Repo) Delete(id uuid.UUID) error {
@@ -142,5 +142,10 @@ func (r *Repo) Delete(id uuid.UUID) error {
 		return err
 	}
 
+	_, err = r.db.Exec("DELETE FROM ranag_parents WHERE ranag_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
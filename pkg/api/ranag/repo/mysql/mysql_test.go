@@ -480,3 +480,75 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestListByParentID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		parent := &ranag.Ranag{
+			ID:               uuid.New(),
+			OwnerID:          uuid.New(),
+			Address:          "parent:8080",
+			ShardAssignments: [][2]int{{0, 10}},
+		}
+
+		child := &ranag.Ranag{
+			ID:               uuid.New(),
+			OwnerID:          parent.OwnerID,
+			ParentID:         parent.ID,
+			Address:          "child:8080",
+			ShardAssignments: [][2]int{{0, 5}},
+		}
+
+		conn, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/ranag_test?parseTime=true")
+		if err != nil {
+			t.Errorf("Error connecting to database: %s", err)
+		}
+		err = mysql.ExecuteMigrations(conn)
+		if err != nil {
+			t.Fatalf("Error executing migrations: %s", err)
+		}
+
+		defer conn.Close()
+
+		repo := New(conn)
+
+		defer repo.Delete(parent.ID)
+		defer repo.Delete(child.ID)
+
+		if err := repo.Create(parent); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if err := repo.Create(child); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		children, err := repo.ListByParentID(parent.ID)
+
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		if len(children) != 1 || children[0].ID != child.ID {
+			t.Fatalf("Expected child %s, got %v", child.ID, children)
+		}
+
+		if children[0].ParentID != parent.ID {
+			t.Errorf("Expected ParentID %s, got %s", parent.ID, children[0].ParentID)
+		}
+
+		// clearing the parent makes the child a root
+		child.ParentID = uuid.Nil
+		if err := repo.Update(child); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		check, err := repo.Get(child.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if check.ParentID != uuid.Nil {
+			t.Errorf("Expected no parent, got %s", check.ParentID)
+		}
+	})
+}
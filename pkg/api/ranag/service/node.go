@@ -2,6 +2,7 @@ package service
 
 import (
 	"juno/pkg/api/ranag"
+	"juno/pkg/receipt"
 	"juno/pkg/shard"
 	"juno/pkg/util"
 	"strings"
@@ -26,6 +27,16 @@ func (s *Service) Get(id uuid.UUID) (*ranag.Ranag, error) {
 	return n, nil
 }
 
+func (s *Service) GetByAddress(address string) (*ranag.Ranag, error) {
+	n, err := s.repo.FirstWhereAddress(address)
+
+	if err != nil || n == nil {
+		return nil, ranag.ErrNotFound
+	}
+
+	return n, nil
+}
+
 func (s *Service) ListByOwnerID(ownerID uuid.UUID) ([]*ranag.Ranag, error) {
 	ranags, err := s.repo.ListByOwnerID(ownerID)
 
@@ -87,7 +98,121 @@ func (s *Service) AllShardsRanags() (map[int][]*ranag.Ranag, error) {
 	return shardsRanags, nil
 }
 
-func (s *Service) Create(ownerID uuid.UUID, addr string, shardAssignments [][2]int) (*ranag.Ranag, error) {
+// within reports whether every assignment lies inside one of the parent's.
+func within(assignments, parent [][2]int) bool {
+	for _, a := range assignments {
+		covered := false
+
+		for _, p := range parent {
+			if a[0] >= p[0] && a[0]+a[1] <= p[0]+p[1] {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			return false
+		}
+	}
+
+	return true
+}
+
+// height is the number of levels of the subtree below the ranag, itself
+// included. It stops counting past the max depth.
+func (s *Service) height(id uuid.UUID, limit int) (int, error) {
+	if limit == 0 {
+		return 1, nil
+	}
+
+	children, err := s.repo.ListByParentID(id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	h := 1
+	for _, c := range children {
+		ch, err := s.height(c.ID, limit-1)
+
+		if err != nil {
+			return 0, err
+		}
+
+		h = max(h, ch+1)
+	}
+
+	return h, nil
+}
+
+// validateParent checks that the ranag fits under its parent: the parent
+// belongs to the same owner, covers the ranag's shards, is not the ranag or
+// one of its descendants and the tree stays within MaxTreeDepth.
+func (s *Service) validateParent(n *ranag.Ranag) error {
+	if n.ParentID == uuid.Nil {
+		return nil
+	}
+
+	parent, err := s.repo.Get(n.ParentID)
+
+	if err != nil || parent.OwnerID != n.OwnerID {
+		return ranag.ErrParentNotFound
+	}
+
+	if !within(n.ShardAssignments, parent.ShardAssignments) {
+		return ranag.ErrOutsideParent
+	}
+
+	depth := 0
+	for p := parent; p != nil; {
+		if p.ID == n.ID {
+			return ranag.ErrCycle
+		}
+
+		depth++
+
+		// also stops on cycles that are already stored
+		if depth >= ranag.MaxTreeDepth {
+			return ranag.ErrTooDeep
+		}
+
+		if p.ParentID == uuid.Nil {
+			break
+		}
+
+		p, _ = s.repo.Get(p.ParentID)
+	}
+
+	height, err := s.height(n.ID, ranag.MaxTreeDepth)
+
+	if err != nil {
+		return ranag.ErrInternal
+	}
+
+	if depth+height > ranag.MaxTreeDepth {
+		return ranag.ErrTooDeep
+	}
+
+	return nil
+}
+
+func (s *Service) Children(address string) ([]*ranag.Ranag, error) {
+	n, err := s.repo.FirstWhereAddress(address)
+
+	if err != nil {
+		return nil, ranag.ErrNotFound
+	}
+
+	children, err := s.repo.ListByParentID(n.ID)
+
+	if err != nil {
+		return nil, ranag.ErrInternal
+	}
+
+	return children, nil
+}
+
+func (s *Service) Create(ownerID uuid.UUID, addr string, shardAssignments [][2]int, parentID uuid.UUID) (*ranag.Ranag, error) {
 	if found, _ := s.repo.FirstWhereAddress(addr); found != nil {
 		return nil, ranag.ErrAddressExists
 	}
@@ -109,10 +234,15 @@ func (s *Service) Create(ownerID uuid.UUID, addr string, shardAssignments [][2]i
 	n := &ranag.Ranag{
 		ID:               uuid.New(),
 		OwnerID:          ownerID,
+		ParentID:         parentID,
 		Address:          addr,
 		ShardAssignments: shardAssignments,
 	}
 
+	if err := s.validateParent(n); err != nil {
+		return nil, err
+	}
+
 	err := s.repo.Create(n)
 
 	if err != nil {
@@ -143,8 +273,28 @@ func (s *Service) Update(id uuid.UUID, dirty *ranag.Ranag) (*ranag.Ranag, error)
 		return nil, ranag.ErrAddressExists
 	}
 
-	n.Address = dirty.Address
-	n.ShardAssignments = dirty.ShardAssignments
+	updated := *n
+	updated.Address = dirty.Address
+	updated.ShardAssignments = dirty.ShardAssignments
+	updated.ParentID = dirty.ParentID
+
+	if err := s.validateParent(&updated); err != nil {
+		return nil, err
+	}
+
+	children, err := s.repo.ListByParentID(n.ID)
+
+	if err != nil {
+		return nil, ranag.ErrInternal
+	}
+
+	for _, c := range children {
+		if !within(c.ShardAssignments, updated.ShardAssignments) {
+			return nil, ranag.ErrOutsideParent
+		}
+	}
+
+	*n = updated
 
 	err = s.repo.Update(n)
 
@@ -155,6 +305,26 @@ func (s *Service) Update(id uuid.UUID, dirty *ranag.Ranag) (*ranag.Ranag, error)
 	return n, nil
 }
 
+func (s *Service) RegisterKey(id uuid.UUID, publicKey string) (*ranag.Ranag, error) {
+	if _, err := receipt.ParsePublicKey(publicKey); err != nil {
+		return nil, ranag.ErrInvalidPublicKey
+	}
+
+	n, err := s.repo.Get(id)
+
+	if err != nil {
+		return nil, ranag.ErrNotFound
+	}
+
+	n.PublicKey = publicKey
+
+	if err := s.repo.Update(n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
 func (s *Service) Delete(id uuid.UUID) error {
 
 	n, err := s.repo.Get(id)
@@ -163,6 +333,21 @@ func (s *Service) Delete(id uuid.UUID) error {
 		return ranag.ErrNotFound
 	}
 
+	children, err := s.repo.ListByParentID(n.ID)
+
+	if err != nil {
+		return ranag.ErrInternal
+	}
+
+	// the children move up a level so their ranges stay reachable
+	for _, c := range children {
+		c.ParentID = n.ParentID
+
+		if err := s.repo.Update(c); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.Delete(n.ID)
 }
 
@@ -176,6 +361,10 @@ func (s *Service) GroupByRange() (map[[2]int][]*ranag.Ranag, error) {
 	}
 
 	for _, n := range ranags {
+		if n.ParentID != uuid.Nil {
+			continue
+		}
+
 		for _, s := range n.ShardAssignments {
 			grouped[s] = append(grouped[s], n)
 		}
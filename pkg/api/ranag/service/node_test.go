@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"juno/pkg/api/ranag"
 	"juno/pkg/api/ranag/repo/mem"
 	"juno/pkg/api/user"
@@ -159,7 +160,7 @@ func TestCreate(t *testing.T) {
 
 		addr := "example.com:7000"
 
-		n, err := svc.Create(u.ID, addr, [][2]int{{0, 1}, {1, 2}})
+		n, err := svc.Create(u.ID, addr, [][2]int{{0, 1}, {1, 2}}, uuid.Nil)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %s", err)
@@ -186,7 +187,7 @@ func TestCreate(t *testing.T) {
 
 		addr := "bad address"
 
-		n, err := svc.Create(u.ID, addr, [][2]int{{1, 100_001}, {1000, 1000}})
+		n, err := svc.Create(u.ID, addr, [][2]int{{1, 100_001}, {1000, 1000}}, uuid.Nil)
 
 		if err == nil {
 			t.Fatal("Expected an error")
@@ -227,7 +228,7 @@ func TestCreate(t *testing.T) {
 
 		addr := "http://example.com"
 
-		n, err = svc.Create(u.ID, addr, [][2]int{{0, 1}, {1, 2}})
+		n, err = svc.Create(u.ID, addr, [][2]int{{0, 1}, {1, 2}}, uuid.Nil)
 
 		if err != ranag.ErrAddressExists {
 			t.Errorf("Expected error, got nil")
@@ -518,3 +519,201 @@ func TestGroupByRange(t *testing.T) {
 		}
 	})
 }
+
+func TestTree(t *testing.T) {
+	ownerID := uuid.New()
+
+	// chain creates a chain of ranags, each the child of the previous one
+	chain := func(t *testing.T, svc *Service, levels int) []*ranag.Ranag {
+		var ranags []*ranag.Ranag
+		parentID := uuid.Nil
+
+		for i := 0; i < levels; i++ {
+			n, err := svc.Create(ownerID, fmt.Sprintf("ranag%d.com:8000", i), [][2]int{{0, 100 - i}}, parentID)
+
+			if err != nil {
+				t.Fatalf("Unexpected error at level %d: %s", i, err)
+			}
+
+			ranags = append(ranags, n)
+			parentID = n.ID
+		}
+
+		return ranags
+	}
+
+	t.Run("children are reached through their parent", func(t *testing.T) {
+		svc := New(mem.New())
+		ranags := chain(t, svc, 2)
+
+		children, err := svc.Children(ranags[0].Address)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(children) != 1 || children[0].ID != ranags[1].ID {
+			t.Errorf("Expected child %s, got %v", ranags[1].ID, children)
+		}
+
+		groups, err := svc.GroupByRange()
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(groups) != 1 || groups[[2]int{0, 100}][0].ID != ranags[0].ID {
+			t.Errorf("Expected only the root to be grouped, got %v", groups)
+		}
+	})
+
+	t.Run("child must be within its parent", func(t *testing.T) {
+		svc := New(mem.New())
+		root := chain(t, svc, 1)[0]
+
+		_, err := svc.Create(ownerID, "child.com:8000", [][2]int{{50, 60}}, root.ID)
+
+		if err != ranag.ErrOutsideParent {
+			t.Errorf("Expected %v, got %v", ranag.ErrOutsideParent, err)
+		}
+	})
+
+	t.Run("parent must belong to the owner", func(t *testing.T) {
+		svc := New(mem.New())
+		root := chain(t, svc, 1)[0]
+
+		_, err := svc.Create(uuid.New(), "child.com:8000", [][2]int{{0, 10}}, root.ID)
+
+		if err != ranag.ErrParentNotFound {
+			t.Errorf("Expected %v, got %v", ranag.ErrParentNotFound, err)
+		}
+	})
+
+	t.Run("depth is limited", func(t *testing.T) {
+		svc := New(mem.New())
+		ranags := chain(t, svc, ranag.MaxTreeDepth)
+
+		_, err := svc.Create(ownerID, "deep.com:8000", [][2]int{{0, 1}}, ranags[len(ranags)-1].ID)
+
+		if err != ranag.ErrTooDeep {
+			t.Errorf("Expected %v, got %v", ranag.ErrTooDeep, err)
+		}
+	})
+
+	t.Run("moving a subtree counts its height", func(t *testing.T) {
+		svc := New(mem.New())
+		ranags := chain(t, svc, ranag.MaxTreeDepth)
+
+		other, err := svc.Create(ownerID, "other.com:8000", [][2]int{{0, 100}}, uuid.Nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		root := *ranags[0]
+		root.ParentID = other.ID
+
+		if _, err := svc.Update(root.ID, &root); err != ranag.ErrTooDeep {
+			t.Errorf("Expected %v, got %v", ranag.ErrTooDeep, err)
+		}
+	})
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		svc := New(mem.New())
+		ranags := chain(t, svc, 3)
+
+		root := *ranags[0]
+		root.ParentID = ranags[2].ID
+		root.ShardAssignments = [][2]int{{0, 98}}
+
+		if _, err := svc.Update(root.ID, &root); err != ranag.ErrCycle {
+			t.Errorf("Expected %v, got %v", ranag.ErrCycle, err)
+		}
+	})
+
+	t.Run("shrinking a parent may not strand its children", func(t *testing.T) {
+		svc := New(mem.New())
+		ranags := chain(t, svc, 2)
+
+		root := *ranags[0]
+		root.ShardAssignments = [][2]int{{0, 10}}
+
+		if _, err := svc.Update(root.ID, &root); err != ranag.ErrOutsideParent {
+			t.Errorf("Expected %v, got %v", ranag.ErrOutsideParent, err)
+		}
+	})
+
+	t.Run("deleting a parent moves its children up", func(t *testing.T) {
+		svc := New(mem.New())
+		ranags := chain(t, svc, 3)
+
+		if err := svc.Delete(ranags[1].ID); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		check, err := svc.Get(ranags[2].ID)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if check.ParentID != ranags[0].ID {
+			t.Errorf("Expected parent %s, got %s", ranags[0].ID, check.ParentID)
+		}
+	})
+}
+
+func TestRegisterKey(t *testing.T) {
+	repo := mem.New()
+	svc := New(repo)
+
+	n := &ranag.Ranag{ID: uuid.New(), OwnerID: uuid.New(), Address: "example.com:8000"}
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		registered, err := svc.RegisterKey(n.ID, "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=")
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if registered.PublicKey != "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=" {
+			t.Errorf("Expected the key registered, got %q", registered.PublicKey)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := svc.RegisterKey(n.ID, "c2hvcnQ="); err != ranag.ErrInvalidPublicKey {
+			t.Errorf("Expected %v, got %v", ranag.ErrInvalidPublicKey, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := svc.RegisterKey(uuid.New(), "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="); err != ranag.ErrNotFound {
+			t.Errorf("Expected %v, got %v", ranag.ErrNotFound, err)
+		}
+	})
+}
+
+func TestGetByAddress(t *testing.T) {
+	repo := mem.New()
+	svc := New(repo)
+
+	n := &ranag.Ranag{ID: uuid.New(), OwnerID: uuid.New(), Address: "example.com:8000"}
+
+	if err := repo.Create(n); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	found, err := svc.GetByAddress("example.com:8000")
+
+	if err != nil || found.ID != n.ID {
+		t.Errorf("Expected ranag %s, got %v, %v", n.ID, found, err)
+	}
+
+	if _, err := svc.GetByAddress("example.org:8000"); err != ranag.ErrNotFound {
+		t.Errorf("Expected %v, got %v", ranag.ErrNotFound, err)
+	}
+}
@@ -1,18 +1,24 @@
 package router
 
 import (
+	"juno/pkg/api/apikey"
 	"juno/pkg/api/auth"
 	"juno/pkg/api/balancer"
+	"juno/pkg/api/challenge"
 	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/filter"
 	"juno/pkg/api/extractor/job"
 	"juno/pkg/api/extractor/selector"
 	"juno/pkg/api/extractor/strategy"
 	"juno/pkg/api/middleware"
+	"juno/pkg/api/mismatch"
 	"juno/pkg/api/node"
+	"juno/pkg/api/payment"
+	"juno/pkg/api/payout"
 	"juno/pkg/api/ranag"
 	"juno/pkg/api/token"
 	"juno/pkg/api/transaction"
+	"juno/pkg/api/usage"
 	"juno/pkg/api/user"
 	"time"
 
@@ -32,8 +38,18 @@ func New(
 	fieldHandler field.Handler,
 	strategyHandler strategy.Handler,
 	tokenHandler token.Handler,
+	paymentHandler payment.Handler,
+	payoutHandler payout.Handler,
+	usageHandler usage.Handler,
+	challengeHandler challenge.Handler,
+	mismatchHandler mismatch.Handler,
 	userHandler user.Handler,
 	authHandler auth.Handler,
+	authService auth.Service,
+	apiKeyHandler apikey.Handler,
+	apiKeyService apikey.Service,
+	adminToken string,
+	reportTokens []string,
 ) *gin.Engine {
 	r := gin.Default()
 
@@ -50,70 +66,137 @@ func New(
 	godotenv.Load("api.env")
 
 	r.POST("/auth/token", authHandler.Token)
+	r.POST("/auth/refresh", authHandler.Refresh)
 	r.POST("/users", userHandler.Create)
 	r.GET("/shards/nodes", nodeHandler.AllShardsNodes)
 	r.GET("/shards/balancers", balancerHandler.AllShardsBalancers)
+	r.GET("/shards/ranags/children", ranagHandler.Children)
+
+	// payment providers sign their webhooks instead of authenticating, there
+	// are no payment routes without a provider
+	if paymentHandler != nil {
+		r.POST("/payments/webhook", paymentHandler.Webhook)
+	}
 
 	authGroup := r.Group("/")
 
-	authGroup.Use(middleware.AuthMiddleware())
+	authGroup.Use(middleware.AuthMiddleware(authService, apiKeyService))
+
+	// API keys only reach the routes of their scopes, the routes without a
+	// scope are left to session tokens
+	var (
+		sessionOnly     = middleware.RequireScope()
+		jobsRead        = middleware.RequireScope(apikey.JobsRead)
+		jobsWrite       = middleware.RequireScope(apikey.JobsWrite)
+		strategiesRead  = middleware.RequireScope(apikey.JobsRead, apikey.JobsWrite, apikey.StrategiesWrite)
+		strategiesWrite = middleware.RequireScope(apikey.StrategiesWrite)
+		tokensRead      = middleware.RequireScope(apikey.TokensRead)
+		nodesManage     = middleware.RequireScope(apikey.NodesManage)
+	)
+
 	{
 
+		// any key may tell whose it is
 		authGroup.GET("/profile", userHandler.Profile)
 
 		authGroup.GET("/users/:id", userHandler.Get)
 
-		authGroup.GET("/nodes", nodeHandler.List)
-		authGroup.GET("/nodes/:id", nodeHandler.Get)
-		authGroup.POST("/nodes", nodeHandler.Create)
-		authGroup.PUT("/nodes/:id", nodeHandler.Update)
-		authGroup.DELETE("/nodes/:id", nodeHandler.Delete)
-
-		authGroup.GET("/balancers", balancerHandler.List)
-		authGroup.GET("/balancers/:id", balancerHandler.Get)
-		authGroup.POST("/balancers", balancerHandler.Create)
-		authGroup.PUT("/balancers/:id", balancerHandler.Update)
-
-		authGroup.GET("/ranags", ranagHandler.List)
-		authGroup.GET("/ranags/:id", ranagHandler.Get)
-		authGroup.POST("/ranags", ranagHandler.Create)
-		authGroup.PUT("/ranags/:id", ranagHandler.Update)
-
-		authGroup.GET("/tokens/balance", tokenHandler.Balance)
-		authGroup.POST("/tokens/deposit", tokenHandler.Deposit)
-
-		authGroup.GET("/transactions", transactionHandler.List)
-
-		authGroup.POST("/extractor/jobs", extractorJobHandler.Create)
-		authGroup.GET("/extractor/jobs/:id", extractorJobHandler.Get)
-		authGroup.GET("/extractor/jobs", extractorJobHandler.List)
-
-		authGroup.POST("/extractor/selectors", selectorHandler.Create)
-		authGroup.GET("/extractor/selectors/:id", selectorHandler.Get)
-		authGroup.GET("/extractor/selectors", selectorHandler.List)
-
-		authGroup.POST("/extractor/filters", filterHandler.Create)
-		authGroup.GET("/extractor/filters/:id", filterHandler.Get)
-		authGroup.GET("/extractor/filters", filterHandler.List)
-
-		authGroup.POST("/extractor/fields", fieldHandler.Create)
-		authGroup.GET("/extractor/fields/:id", fieldHandler.Get)
-		authGroup.GET("/extractor/fields", fieldHandler.List)
-		authGroup.GET("/extractor/selector/:id/fields", fieldHandler.ListBySelectorID)
-
-		authGroup.POST("/extractor/strategies", strategyHandler.Create)
-		authGroup.GET("/extractor/strategies/:id", strategyHandler.Get)
-		authGroup.GET("/extractor/strategies", strategyHandler.List)
-
-		authGroup.POST("/extractor/strategies/:id/selectors", strategyHandler.AddSelector)
-		authGroup.DELETE("/extractor/strategies/:id/selectors", strategyHandler.RemoveSelector)
-
-		authGroup.POST("/extractor/strategies/:id/filters", strategyHandler.AddFilter)
-		authGroup.DELETE("/extractor/strategies/:id/filters", strategyHandler.RemoveFilter)
+		authGroup.POST("/auth/logout", sessionOnly, authHandler.Logout)
+
+		authGroup.POST("/auth/keys", sessionOnly, apiKeyHandler.Create)
+		authGroup.GET("/auth/keys", sessionOnly, apiKeyHandler.List)
+		authGroup.DELETE("/auth/keys/:id", sessionOnly, apiKeyHandler.Revoke)
+
+		authGroup.GET("/nodes", nodesManage, nodeHandler.List)
+		authGroup.GET("/nodes/:id", nodesManage, nodeHandler.Get)
+		authGroup.POST("/nodes", nodesManage, nodeHandler.Create)
+		authGroup.PUT("/nodes/:id", nodesManage, nodeHandler.Update)
+		authGroup.PUT("/nodes/:id/key", nodesManage, nodeHandler.RegisterKey)
+		authGroup.DELETE("/nodes/:id", nodesManage, nodeHandler.Delete)
+		authGroup.GET("/nodes/:id/challenges", nodesManage, challengeHandler.Results)
+		authGroup.GET("/nodes/:id/mismatches", nodesManage, mismatchHandler.Entries)
+
+		authGroup.GET("/balancers", nodesManage, balancerHandler.List)
+		authGroup.GET("/balancers/:id", nodesManage, balancerHandler.Get)
+		authGroup.POST("/balancers", nodesManage, balancerHandler.Create)
+		authGroup.PUT("/balancers/:id", nodesManage, balancerHandler.Update)
+
+		authGroup.GET("/ranags", nodesManage, ranagHandler.List)
+		authGroup.GET("/ranags/:id", nodesManage, ranagHandler.Get)
+		authGroup.POST("/ranags", nodesManage, ranagHandler.Create)
+		authGroup.PUT("/ranags/:id", nodesManage, ranagHandler.Update)
+		authGroup.PUT("/ranags/:id/key", nodesManage, ranagHandler.RegisterKey)
+
+		authGroup.GET("/tokens/balance", tokensRead, tokenHandler.Balance)
+		authGroup.GET("/tokens/earnings", tokensRead, tokenHandler.Earnings)
+
+		authGroup.POST("/tokens/payouts", sessionOnly, payoutHandler.Request)
+		authGroup.GET("/tokens/payouts", tokensRead, payoutHandler.List)
+		authGroup.GET("/tokens/payouts/:id", tokensRead, payoutHandler.Get)
+
+		if paymentHandler != nil {
+			authGroup.POST("/tokens/deposit", sessionOnly, paymentHandler.Checkout)
+			authGroup.GET("/payments", tokensRead, paymentHandler.List)
+			authGroup.GET("/payments/:id", tokensRead, paymentHandler.Get)
+			authGroup.POST("/payments/:id/refund", sessionOnly, paymentHandler.Refund)
+		}
+
+		authGroup.GET("/transactions", tokensRead, transactionHandler.List)
+
+		authGroup.GET("/usage", tokensRead, usageHandler.Report)
+
+		authGroup.POST("/extractor/jobs", jobsWrite, extractorJobHandler.Create)
+		authGroup.GET("/extractor/jobs/:id", jobsRead, extractorJobHandler.Get)
+		authGroup.GET("/extractor/jobs/:id/results", jobsRead, extractorJobHandler.Results)
+		authGroup.GET("/extractor/jobs/:id/manifest", jobsRead, extractorJobHandler.Manifest)
+		authGroup.GET("/extractor/jobs/:id/receipts", jobsRead, extractorJobHandler.Receipts)
+		authGroup.GET("/extractor/jobs/:id/events", jobsRead, extractorJobHandler.Events)
+		authGroup.POST("/extractor/jobs/:id/cancel", jobsWrite, extractorJobHandler.Cancel)
+		authGroup.GET("/extractor/jobs", jobsRead, extractorJobHandler.List)
+
+		authGroup.POST("/extractor/selectors", strategiesWrite, selectorHandler.Create)
+		authGroup.GET("/extractor/selectors/:id", strategiesRead, selectorHandler.Get)
+		authGroup.GET("/extractor/selectors", strategiesRead, selectorHandler.List)
+
+		authGroup.POST("/extractor/filters", strategiesWrite, filterHandler.Create)
+		authGroup.GET("/extractor/filters/:id", strategiesRead, filterHandler.Get)
+		authGroup.GET("/extractor/filters", strategiesRead, filterHandler.List)
+
+		authGroup.POST("/extractor/fields", strategiesWrite, fieldHandler.Create)
+		authGroup.GET("/extractor/fields/:id", strategiesRead, fieldHandler.Get)
+		authGroup.GET("/extractor/fields", strategiesRead, fieldHandler.List)
+		authGroup.GET("/extractor/selector/:id/fields", strategiesRead, fieldHandler.ListBySelectorID)
+
+		authGroup.POST("/extractor/strategies", strategiesWrite, strategyHandler.Create)
+		authGroup.GET("/extractor/strategies/:id", strategiesRead, strategyHandler.Get)
+		authGroup.GET("/extractor/strategies", strategiesRead, strategyHandler.List)
+
+		authGroup.POST("/extractor/strategies/:id/selectors", strategiesWrite, strategyHandler.AddSelector)
+		authGroup.DELETE("/extractor/strategies/:id/selectors", strategiesWrite, strategyHandler.RemoveSelector)
+
+		authGroup.POST("/extractor/strategies/:id/filters", strategiesWrite, strategyHandler.AddFilter)
+		authGroup.DELETE("/extractor/strategies/:id/filters", strategiesWrite, strategyHandler.RemoveFilter)
+
+		authGroup.POST("/extractor/strategies/:id/fields", strategiesWrite, strategyHandler.AddField)
+		authGroup.DELETE("/extractor/strategies/:id/fields", strategiesWrite, strategyHandler.RemoveField)
+
+		authGroup.POST("/extractor/strategies/:id/aggregations", strategiesWrite, strategyHandler.AddAggregation)
+		authGroup.DELETE("/extractor/strategies/:id/aggregations", strategiesWrite, strategyHandler.RemoveAggregation)
+
+		authGroup.PUT("/extractor/strategies/:id/scope", strategiesWrite, strategyHandler.SetScope)
+		authGroup.POST("/extractor/strategies/:id/estimate", strategiesRead, strategyHandler.Estimate)
+	}
 
-		authGroup.POST("/extractor/strategies/:id/fields", strategyHandler.AddField)
-		authGroup.DELETE("/extractor/strategies/:id/fields", strategyHandler.RemoveField)
+	adminGroup := r.Group("/admin", middleware.AdminAuth(adminToken))
+	{
+		adminGroup.GET("/payouts", payoutHandler.ListByStatus)
+		adminGroup.POST("/payouts/:id/approve", payoutHandler.Approve)
+		adminGroup.POST("/payouts/:id/reject", payoutHandler.Reject)
+		adminGroup.POST("/payouts/:id/paid", payoutHandler.MarkPaid)
 	}
 
+	// balancers report crawls nodes are challenged on
+	r.POST("/crawls", middleware.ReportAuth(reportTokens), challengeHandler.RecordCrawl)
+
 	return r
 }
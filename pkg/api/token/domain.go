@@ -2,6 +2,8 @@ package token
 
 import (
 	"errors"
+	"juno/pkg/api/transaction"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -9,13 +11,33 @@ import (
 
 var ErrInsufficientBalance = errors.New("insufficient balance")
 var ErrInvalidAmount = errors.New("invalid amount")
+var ErrUnbalancedSettlement = errors.New("settlement earnings exceed its cost")
+
+// Balances are what a user holds in each of their accounts: what they can
+// spend, what their jobs hold in escrow, what their nodes earned and what of
+// it is being paid out.
+type Balances struct {
+	Available transaction.Amount
+	Escrowed  transaction.Amount
+	Earnings  transaction.Amount
+	PayingOut transaction.Amount
+}
+
+// Earning is what a node or ranag earned its owner on a day, in UTC.
+type Earning struct {
+	Day      time.Time
+	SourceID uuid.UUID
+	Amount   transaction.Amount
+}
 
 type Service interface {
-	Balance(userID uuid.UUID) (float64, error)
-	Deposit(userID uuid.UUID, amount float64) error
+	Balance(userID uuid.UUID) (*Balances, error)
+	// Earnings returns what the user's nodes and ranags earned on the days
+	// from and to include, by day and earner.
+	Earnings(userID uuid.UUID, from, to time.Time) ([]*Earning, error)
 }
 
 type Handler interface {
 	Balance(c *gin.Context)
-	Deposit(c *gin.Context)
+	Earnings(c *gin.Context)
 }
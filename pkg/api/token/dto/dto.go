@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"juno/pkg/api/token"
+	"juno/pkg/api/transaction"
+	"time"
+)
+
 const (
 	SUCCESS = "success"
 	ERROR   = "error"
@@ -9,13 +15,20 @@ type BalanceResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
 
-	Balance float64 `json:"balance"`
+	Balance  transaction.Amount `json:"balance"`
+	Escrowed transaction.Amount `json:"escrowed"`
+	Earnings transaction.Amount `json:"earnings"`
+	// PayingOut is what is locked for the payouts being processed
+	PayingOut transaction.Amount `json:"paying_out"`
 }
 
-func NewSuccessBalanceResponse(balance float64) *BalanceResponse {
+func NewSuccessBalanceResponse(b *token.Balances) *BalanceResponse {
 	return &BalanceResponse{
-		Status:  SUCCESS,
-		Balance: balance,
+		Status:    SUCCESS,
+		Balance:   b.Available,
+		Escrowed:  b.Escrowed,
+		Earnings:  b.Earnings,
+		PayingOut: b.PayingOut,
 	}
 }
 
@@ -26,23 +39,44 @@ func NewErrorBalanceResponse(message string) *BalanceResponse {
 	}
 }
 
-type DepositRequest struct {
-	Amount float64 `json:"amount"`
+type Earning struct {
+	Day      string             `json:"day"`
+	SourceID string             `json:"source_id"`
+	Amount   transaction.Amount `json:"amount"`
 }
 
-type DepositResponse struct {
+type EarningsResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
+
+	From     string             `json:"from,omitempty"`
+	To       string             `json:"to,omitempty"`
+	Total    transaction.Amount `json:"total"`
+	Earnings []Earning          `json:"earnings"`
 }
 
-func NewSuccessDepositResponse() *DepositResponse {
-	return &DepositResponse{
-		Status: SUCCESS,
+func NewSuccessEarningsResponse(from, to time.Time, earnings []*token.Earning) *EarningsResponse {
+	res := &EarningsResponse{
+		Status:   SUCCESS,
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Earnings: []Earning{},
 	}
+
+	for _, e := range earnings {
+		res.Total += e.Amount
+		res.Earnings = append(res.Earnings, Earning{
+			Day:      e.Day.Format(time.DateOnly),
+			SourceID: e.SourceID.String(),
+			Amount:   e.Amount,
+		})
+	}
+
+	return res
 }
 
-func NewErrorDepositResponse(message string) *DepositResponse {
-	return &DepositResponse{
+func NewErrorEarningsResponse(message string) *EarningsResponse {
+	return &EarningsResponse{
 		Status:  ERROR,
 		Message: message,
 	}
@@ -4,11 +4,19 @@ import (
 	"juno/pkg/api/auth"
 	"juno/pkg/api/token"
 	"juno/pkg/api/token/dto"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// earningsDays is how many days of earnings are returned when no range is
+// given, and maxEarningsDays how many can be asked for at once.
+const (
+	earningsDays    = 30
+	maxEarningsDays = 366
+)
+
 type Handler struct {
 	logger       logrus.FieldLogger
 	tokenService token.Service
@@ -35,21 +43,46 @@ func (h *Handler) Balance(c *gin.Context) {
 	c.JSON(200, dto.NewSuccessBalanceResponse(balance))
 }
 
-func (h *Handler) Deposit(c *gin.Context) {
+// Earnings returns the user's earnings by day and by node or ranag, on the
+// days from and to include, given as YYYY-MM-DD. The last 30 days are
+// returned by default.
+func (h *Handler) Earnings(c *gin.Context) {
 
 	u := auth.MustUserFromContext(c.Request.Context())
 
-	var req dto.DepositRequest
+	to := time.Now().UTC()
+
+	if param := c.Query("to"); param != "" {
+		t, err := time.Parse(time.DateOnly, param)
+		if err != nil {
+			c.JSON(400, dto.NewErrorEarningsResponse("invalid to date"))
+			return
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -(earningsDays - 1))
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, dto.NewErrorBalanceResponse(err.Error()))
+	if param := c.Query("from"); param != "" {
+		t, err := time.Parse(time.DateOnly, param)
+		if err != nil {
+			c.JSON(400, dto.NewErrorEarningsResponse("invalid from date"))
+			return
+		}
+		from = t
+	}
+
+	if from.After(to) || to.Sub(from) >= maxEarningsDays*24*time.Hour {
+		c.JSON(400, dto.NewErrorEarningsResponse("invalid date range"))
 		return
 	}
 
-	if err := h.tokenService.Deposit(u.ID, req.Amount); err != nil {
-		c.JSON(400, dto.NewErrorBalanceResponse(err.Error()))
+	earnings, err := h.tokenService.Earnings(u.ID, from, to)
+
+	if err != nil {
+		c.JSON(500, dto.NewErrorEarningsResponse(err.Error()))
 		return
 	}
 
-	c.JSON(200, dto.NewSuccessDepositResponse())
+	c.JSON(200, dto.NewSuccessEarningsResponse(from, to, earnings))
 }
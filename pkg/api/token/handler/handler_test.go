@@ -1,15 +1,17 @@
 package handler
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"juno/pkg/api/auth"
+	"juno/pkg/api/token"
 	"juno/pkg/api/token/dto"
+	"juno/pkg/api/transaction"
 	"juno/pkg/api/user"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,22 +19,30 @@ import (
 )
 
 type mockTokenService struct {
-	balance float64
+	balance transaction.Amount
 	withErr error
 
-	depositAmount float64
+	from, to time.Time
 }
 
-func (m *mockTokenService) Balance(userID uuid.UUID) (float64, error) {
-	return m.balance, m.withErr
+func (m *mockTokenService) Balance(userID uuid.UUID) (*token.Balances, error) {
+	if m.withErr != nil {
+		return nil, m.withErr
+	}
+	return &token.Balances{Available: m.balance, Escrowed: 5, Earnings: 7}, nil
 }
 
-func (m *mockTokenService) Deposit(userID uuid.UUID, amount float64) error {
+func (m *mockTokenService) Earnings(userID uuid.UUID, from, to time.Time) ([]*token.Earning, error) {
+	m.from, m.to = from, to
+
 	if m.withErr != nil {
-		return m.withErr
+		return nil, m.withErr
 	}
-	m.depositAmount = amount
-	return nil
+
+	return []*token.Earning{
+		{Day: from, SourceID: uuid.New(), Amount: 3},
+		{Day: to, SourceID: uuid.New(), Amount: 4},
+	}, nil
 }
 
 func TestBalance(t *testing.T) {
@@ -66,8 +76,8 @@ func TestBalance(t *testing.T) {
 			t.Errorf("Expected %s, got %s", dto.SUCCESS, res.Status)
 		}
 
-		if res.Balance != 100 {
-			t.Errorf("Expected 100, got %f", res.Balance)
+		if res.Balance != 100 || res.Escrowed != 5 || res.Earnings != 7 {
+			t.Errorf("Expected 100, 5 and 7, got %s, %s and %s", res.Balance, res.Escrowed, res.Earnings)
 		}
 	})
 
@@ -109,99 +119,60 @@ func TestBalance(t *testing.T) {
 	})
 }
 
-func TestDeposit(t *testing.T) {
-	t.Run("success", func(t *testing.T) {
-
-		mockTokenService := &mockTokenService{balance: 100, withErr: nil}
-		handler := New(logrus.New(), mockTokenService)
-
-		userID := uuid.New()
-
+func TestEarnings(t *testing.T) {
+	earnings := func(service *mockTokenService, query string) *httptest.ResponseRecorder {
 		w := httptest.NewRecorder()
 		tc, _ := gin.CreateTestContext(w)
 
-		req := dto.DepositRequest{Amount: 100}
-
-		body, err := json.Marshal(req)
-
-		if err != nil {
-			t.Errorf("Expected nil, got %v", err)
-		}
-
 		tc.Request = httptest.NewRequestWithContext(
-			auth.WithUser(context.Background(), &user.User{ID: userID}),
-			"POST",
-			"/tokens/deposit",
-			bytes.NewBuffer(body),
+			auth.WithUser(context.Background(), &user.User{ID: uuid.New()}),
+			"GET",
+			"/tokens/earnings"+query,
+			nil,
 		)
 
-		handler.Deposit(tc)
-
-		var res dto.DepositResponse
+		New(logrus.New(), service).Earnings(tc)
 
-		err = json.Unmarshal(w.Body.Bytes(), &res)
+		return w
+	}
 
-		if err != nil {
-			t.Errorf("Expected nil, got %v", err)
-		}
+	t.Run("success", func(t *testing.T) {
+		service := &mockTokenService{}
 
-		if res.Status != dto.SUCCESS {
-			t.Errorf("Expected %s, got %s", dto.SUCCESS, res.Status)
-		}
+		w := earnings(service, "?from=2026-10-01&to=2026-10-19")
 
-		if mockTokenService.depositAmount != 100 {
-			t.Errorf("Expected 100, got %f", mockTokenService.depositAmount)
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
 		}
-	})
-
-	t.Run("failure", func(t *testing.T) {
-
-		errTest := errors.New("test error")
-
-		mockTokenService := &mockTokenService{balance: 100, withErr: errTest}
-		handler := New(logrus.New(), mockTokenService)
 
-		userID := uuid.New()
-
-		w := httptest.NewRecorder()
-		tc, _ := gin.CreateTestContext(w)
+		var res dto.EarningsResponse
 
-		req := dto.DepositRequest{Amount: 100}
-
-		body, err := json.Marshal(req)
-
-		if err != nil {
-			t.Errorf("Expected nil, got %v", err)
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
 		}
 
-		tc.Request = httptest.NewRequestWithContext(
-			auth.WithUser(context.Background(), &user.User{ID: userID}),
-			"POST",
-			"/tokens/deposit",
-			bytes.NewBuffer(body),
-		)
-
-		handler.Deposit(tc)
-
-		var res dto.DepositResponse
-
-		err = json.Unmarshal(w.Body.Bytes(), &res)
-
-		if err != nil {
-			t.Errorf("Expected nil, got %v", err)
+		if res.Total != 7 || len(res.Earnings) != 2 || res.Earnings[0].Day != "2026-10-01" || res.Earnings[1].Day != "2026-10-19" {
+			t.Errorf("Expected 7 earned on the first and last day, got %+v", res)
 		}
+	})
 
-		if res.Status != dto.ERROR {
-			t.Errorf("Expected %s, got %s", dto.ERROR, res.Status)
-		}
+	t.Run("last 30 days by default", func(t *testing.T) {
+		service := &mockTokenService{}
 
-		if res.Message != errTest.Error() {
-			t.Errorf("Expected %s, got %s", errTest.Error(), res.Message)
+		if w := earnings(service, ""); w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
 		}
 
-		if mockTokenService.depositAmount != 0 {
-			t.Errorf("Expected 0, got %f", mockTokenService.depositAmount)
+		if days := service.to.Sub(service.from).Hours() / 24; days != 29 {
+			t.Errorf("Expected 30 days, got %v", days+1)
 		}
 	})
 
+	t.Run("invalid range", func(t *testing.T) {
+		for _, query := range []string{"?from=yesterday", "?from=2026-10-19&to=2026-10-01", "?from=2024-01-01&to=2026-01-01"} {
+			if w := earnings(&mockTokenService{}, query); w.Code != 400 {
+				t.Errorf("Expected 400 for %s, got %d", query, w.Code)
+			}
+		}
+	})
 }
@@ -18,30 +18,79 @@ func New(transactionService transaction.Service) *Service {
 	}
 }
 
-func (s *Service) Balance(userID uuid.UUID) (float64, error) {
-	return s.transactionService.Balance(userID)
+func (s *Service) Balance(userID uuid.UUID) (*token.Balances, error) {
+	var b token.Balances
+
+	for account, balance := range map[transaction.Account]*transaction.Amount{
+		transaction.User(userID):     &b.Available,
+		transaction.Escrow(userID):   &b.Escrowed,
+		transaction.Operator(userID): &b.Earnings,
+		transaction.Payout(userID):   &b.PayingOut,
+	} {
+		amount, err := s.transactionService.Balance(account)
+
+		if err != nil {
+			return nil, err
+		}
+
+		*balance = amount
+	}
+
+	return &b, nil
 }
 
-func (s *Service) Deposit(userID uuid.UUID, amount float64) error {
+// Deposit credits the user the tokens a payment bought. A payment is only
+// credited once.
+func (s *Service) Deposit(userID, paymentID uuid.UUID, amount transaction.Amount) error {
 
 	if amount <= 0 {
 		return token.ErrInvalidAmount
 	}
 
-	// simulate a delay
-	time.Sleep(1 * time.Second)
-
-	return s.transactionService.CreateTransaction(
-		userID,
-		amount,
+	return s.post(transaction.NewPosting(
+		"deposit:"+paymentID.String(),
 		transaction.DepositKey,
-		map[string]string{"provider": "paddle"},
-	)
+		map[string]string{"payment_id": paymentID.String()},
+		transaction.Transfer(transaction.External, transaction.User(userID), amount)...,
+	))
 }
 
-func (s *Service) Debit(userID uuid.UUID, tranKey transaction.TransactionKey, amount float64, meta map[string]string) error {
+// Withdraw takes back the tokens a refund returns to the user. A refund is
+// only withdrawn once.
+func (s *Service) Withdraw(userID, refundID uuid.UUID, amount transaction.Amount) error {
 
-	if amount < 0 {
+	if amount <= 0 {
+		return token.ErrInvalidAmount
+	}
+
+	return s.post(transaction.NewPosting(
+		"refund:"+refundID.String(),
+		transaction.WithdrawalKey,
+		map[string]string{"refund_id": refundID.String()},
+		transaction.Transfer(transaction.User(userID), transaction.External, amount)...,
+	))
+}
+
+// RestoreWithdrawal credits back what a refund that failed withdrew.
+func (s *Service) RestoreWithdrawal(userID, refundID uuid.UUID, amount transaction.Amount) error {
+
+	if amount <= 0 {
+		return token.ErrInvalidAmount
+	}
+
+	return s.post(transaction.NewPosting(
+		"refund_reversal:"+refundID.String(),
+		transaction.RefundReversalKey,
+		map[string]string{"refund_id": refundID.String()},
+		transaction.Transfer(transaction.External, transaction.User(userID), amount)...,
+	))
+}
+
+// Debit charges the user the amount as a platform fee. Debiting with the same
+// idempotency key again does nothing.
+func (s *Service) Debit(userID uuid.UUID, idempotencyKey string, tranKey transaction.TransactionKey, amount transaction.Amount, meta map[string]string) error {
+
+	if amount <= 0 {
 		return token.ErrInvalidAmount
 	}
 
@@ -53,18 +102,188 @@ func (s *Service) Debit(userID uuid.UUID, tranKey transaction.TransactionKey, am
 		return token.ErrInvalidAmount
 	}
 
-	balance, err := s.transactionService.Balance(userID)
+	return s.post(transaction.NewPosting(
+		idempotencyKey,
+		tranKey,
+		meta,
+		transaction.Transfer(transaction.User(userID), transaction.Platform, amount)...,
+	))
+}
+
+// Escrow moves the amount from the user's account to their escrow account
+// until the job is settled. A job's escrow is only held once.
+func (s *Service) Escrow(userID, jobID uuid.UUID, amount transaction.Amount) error {
+
+	if amount < 0 {
+		return token.ErrInvalidAmount
+	}
+
+	if amount == 0 {
+		return nil
+	}
+
+	return s.post(transaction.NewPosting(
+		"escrow:"+jobID.String(),
+		transaction.EscrowKey,
+		map[string]string{"job_id": jobID.String()},
+		transaction.Transfer(transaction.User(userID), transaction.Escrow(userID), amount)...,
+	))
+}
+
+// Settle empties what was escrowed for the job in a single posting: the
+// earnings go to the operator accounts of their earners' owners, marked with
+// the earner as their source, the rest of the cost to the
+// platform as its fee and what was not spent back to the user. The cost
+// cannot exceed what was escrowed, nor the earnings the cost. A job is only
+// settled once.
+func (s *Service) Settle(userID, jobID uuid.UUID, escrowed, cost transaction.Amount, earnings map[transaction.Earner]transaction.Amount) error {
+
+	if cost < 0 || cost > escrowed {
+		return token.ErrInvalidAmount
+	}
+
+	fee := cost
+
+	for _, amount := range earnings {
+		if amount < 0 {
+			return token.ErrInvalidAmount
+		}
+
+		fee -= amount
+	}
+
+	if fee < 0 {
+		return token.ErrUnbalancedSettlement
+	}
+
+	if escrowed == 0 {
+		return nil
+	}
+
+	entries := []transaction.Entry{
+		{Account: transaction.Escrow(userID), Amount: -escrowed},
+	}
+
+	if refund := escrowed - cost; refund > 0 {
+		entries = append(entries, transaction.Entry{Account: transaction.User(userID), Amount: refund})
+	}
+
+	for earner, amount := range earnings {
+		if amount > 0 {
+			entries = append(entries, transaction.Entry{Account: transaction.Operator(earner.OwnerID), Amount: amount, Source: earner.SourceID})
+		}
+	}
+
+	if fee > 0 {
+		entries = append(entries, transaction.Entry{Account: transaction.Platform, Amount: fee})
+	}
+
+	return s.post(transaction.NewPosting(
+		"settle:"+jobID.String(),
+		transaction.QueryExecutionKey,
+		map[string]string{"job_id": jobID.String()},
+		entries...,
+	))
+}
+
+// Earnings adds up the jobs' earnings credited to the user by day and by the
+// node or ranag that earned them.
+func (s *Service) Earnings(userID uuid.UUID, from, to time.Time) ([]*token.Earning, error) {
+	credits, err := s.transactionService.CreditsByDay(transaction.Operator(userID), transaction.QueryExecutionKey, from, to)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if balance < amount {
-		return token.ErrInsufficientBalance
+	earnings := make([]*token.Earning, len(credits))
+	for i, c := range credits {
+		earnings[i] = &token.Earning{Day: c.Day, SourceID: c.Source, Amount: c.Amount}
+	}
+
+	return earnings, nil
+}
+
+// Payable is what the user can be paid out of their earnings: what their
+// operator account holds less what they earned after since, which is still
+// held back.
+func (s *Service) Payable(userID uuid.UUID, since time.Time) (transaction.Amount, error) {
+	balance, err := s.transactionService.Balance(transaction.Operator(userID))
+
+	if err != nil {
+		return 0, err
+	}
+
+	held, err := s.transactionService.Credited(transaction.Operator(userID), transaction.QueryExecutionKey, since)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return max(balance-held, 0), nil
+}
+
+// LockPayout moves the amount of the payout from the user's earnings to their
+// payout account while it is processed. The ledger checks the amount against
+// what they earned before heldSince in the same transaction. A payout is only
+// locked once.
+func (s *Service) LockPayout(userID, payoutID uuid.UUID, amount transaction.Amount, heldSince time.Time) error {
+
+	if amount <= 0 {
+		return token.ErrInvalidAmount
+	}
+
+	p := transaction.NewPosting(
+		"payout:"+payoutID.String(),
+		transaction.PayoutKey,
+		map[string]string{"payout_id": payoutID.String()},
+		transaction.Transfer(transaction.Operator(userID), transaction.Payout(userID), amount)...,
+	)
+	p.Hold = &transaction.Hold{Account: transaction.Operator(userID), Key: transaction.QueryExecutionKey, Since: heldSince}
+
+	return s.post(p)
+}
+
+// PayPayout takes the locked amount of a paid payout out of the ledger.
+func (s *Service) PayPayout(userID, payoutID uuid.UUID, amount transaction.Amount) error {
+
+	if amount <= 0 {
+		return token.ErrInvalidAmount
 	}
 
-	// simulate a delay
-	time.Sleep(1 * time.Second)
+	return s.post(transaction.NewPosting(
+		"payout_paid:"+payoutID.String(),
+		transaction.PayoutPaidKey,
+		map[string]string{"payout_id": payoutID.String()},
+		transaction.Transfer(transaction.Payout(userID), transaction.External, amount)...,
+	))
+}
+
+// ReleasePayout gives the locked amount of a rejected payout back to the
+// user's earnings.
+func (s *Service) ReleasePayout(userID, payoutID uuid.UUID, amount transaction.Amount) error {
+
+	if amount <= 0 {
+		return token.ErrInvalidAmount
+	}
+
+	return s.post(transaction.NewPosting(
+		"payout_reversal:"+payoutID.String(),
+		transaction.PayoutReversalKey,
+		map[string]string{"payout_id": payoutID.String()},
+		transaction.Transfer(transaction.Payout(userID), transaction.Operator(userID), amount)...,
+	))
+}
+
+// post records the posting, treating one already recorded as done.
+func (s *Service) post(p *transaction.Posting) error {
+	err := s.transactionService.Post(p)
+
+	switch err {
+	case transaction.ErrDuplicatePosting:
+		return nil
+	case transaction.ErrInsufficientFunds:
+		return token.ErrInsufficientBalance
+	}
 
-	return s.transactionService.CreateTransaction(userID, -amount, transaction.WithdrawalKey, meta)
+	return err
 }
@@ -1,165 +1,527 @@
 package service
 
 import (
+	"juno/pkg/api/token"
 	"juno/pkg/api/transaction"
 	tranRepo "juno/pkg/api/transaction/repo/mem"
 	tranService "juno/pkg/api/transaction/service"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+func setup() (*Service, transaction.Service) {
+	tranService := tranService.New(logrus.New(), tranRepo.New())
+
+	return New(tranService), tranService
+}
+
+// deposit credits the user as a paid payment would
+func deposit(tranService transaction.Service, userID uuid.UUID, amount transaction.Amount) {
+	tranService.Post(transaction.NewPosting(
+		uuid.NewString(),
+		transaction.DepositKey,
+		nil,
+		transaction.Transfer(transaction.External, transaction.User(userID), amount)...,
+	))
+}
+
 func TestBalance(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
-
-		tokenService := New(tranService)
+		tokenService, tranService := setup()
 
 		userID := uuid.New()
 
-		tranRepo.CreateTransaction(&transaction.Transaction{
-			ID:     uuid.New(),
-			UserID: userID,
-			Amount: 100,
-			Key:    transaction.DepositKey,
-			Meta:   map[string]string{"provider": "paddle"},
-		})
+		deposit(tranService, userID, 100*transaction.Token)
+		tokenService.Escrow(userID, uuid.New(), 30*transaction.Token)
 
-		balance, err := tokenService.Balance(userID)
+		b, err := tokenService.Balance(userID)
 
 		if err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		if balance != 100.00 {
-			t.Errorf("Expected 100, got %f", balance)
+		if b.Available != 70*transaction.Token || b.Escrowed != 30*transaction.Token || b.Earnings != 0 {
+			t.Errorf("Expected 70 available and 30 escrowed, got %+v", b)
 		}
 	})
 }
 
 func TestDeposit(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
-
-		tokenService := New(tranService)
+		tokenService, _ := setup()
 
 		userID := uuid.New()
-
-		err := tokenService.Deposit(userID, 100)
-
-		if err != nil {
-			t.Errorf("Expected nil, got %v", err)
+		paymentID := uuid.New()
+
+		// a payment is credited once, however many times its webhook
+		// arrives
+		for range 2 {
+			if err := tokenService.Deposit(userID, paymentID, 100*transaction.Token); err != nil {
+				t.Errorf("Expected nil, got %v", err)
+			}
 		}
 
-		balance, err := tokenService.Balance(userID)
+		b, err := tokenService.Balance(userID)
 
 		if err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		if balance != 100 {
-			t.Errorf("Expected 100, got %f", balance)
+		if b.Available != 100*transaction.Token {
+			t.Errorf("Expected 100, got %s", b.Available)
 		}
 	})
 
 	t.Run("invalid amount", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
-
-		tokenService := New(tranService)
+		tokenService, _ := setup()
 
 		userID := uuid.New()
 
-		err := tokenService.Deposit(userID, 0)
+		err := tokenService.Deposit(userID, uuid.New(), 0)
 		if err == nil {
 			t.Errorf("Expected error, got nil")
 		}
 	})
 }
 
-func TestDebit(t *testing.T) {
+func TestWithdraw(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
+		tokenService, tranService := setup()
+
+		userID := uuid.New()
+		refundID := uuid.New()
+
+		deposit(tranService, userID, 100*transaction.Token)
+
+		for range 2 {
+			if err := tokenService.Withdraw(userID, refundID, 60*transaction.Token); err != nil {
+				t.Errorf("Expected nil, got %v", err)
+			}
+		}
 
-		tokenService := New(tranService)
+		if b, _ := tokenService.Balance(userID); b.Available != 40*transaction.Token {
+			t.Errorf("Expected a single withdrawal, got %s", b.Available)
+		}
+
+		for range 2 {
+			if err := tokenService.RestoreWithdrawal(userID, refundID, 60*transaction.Token); err != nil {
+				t.Errorf("Expected nil, got %v", err)
+			}
+		}
+
+		if b, _ := tokenService.Balance(userID); b.Available != 100*transaction.Token {
+			t.Errorf("Expected the withdrawal restored once, got %s", b.Available)
+		}
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		userID := uuid.New()
+
+		deposit(tranService, userID, 10*transaction.Token)
+
+		if err := tokenService.Withdraw(userID, uuid.New(), 30*transaction.Token); err != token.ErrInsufficientBalance {
+			t.Errorf("Expected %v, got %v", token.ErrInsufficientBalance, err)
+		}
+	})
+}
+
+func TestDebit(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tokenService, tranService := setup()
 
 		userID := uuid.New()
 
-		tranRepo.CreateTransaction(&transaction.Transaction{
-			ID:     uuid.New(),
-			UserID: userID,
-			Amount: 100,
-			Key:    transaction.DepositKey,
-			Meta:   map[string]string{"provider": "paddle"},
-		})
+		deposit(tranService, userID, 100*transaction.Token)
 
-		err := tokenService.Debit(userID, transaction.QueryExecutionKey, 100, map[string]string{"provider": "paddle"})
+		err := tokenService.Debit(userID, "query:1", transaction.QueryExecutionKey, 100*transaction.Token, map[string]string{"provider": "paddle"})
 
 		if err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		balance, err := tokenService.Balance(userID)
+		b, err := tokenService.Balance(userID)
 
 		if err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		if balance != 0.00 {
-			t.Errorf("Expected 0.00, got %f", balance)
+		if b.Available != 0 {
+			t.Errorf("Expected 0, got %s", b.Available)
+		}
+
+		if fees, _ := tranService.Balance(transaction.Platform); fees != 100*transaction.Token {
+			t.Errorf("Expected the platform to get 100, got %s", fees)
 		}
 	})
 
-	t.Run("invalid amount", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
+	t.Run("same idempotency key", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		userID := uuid.New()
+
+		deposit(tranService, userID, 100*transaction.Token)
+
+		for range 2 {
+			if err := tokenService.Debit(userID, "query:1", transaction.QueryExecutionKey, 40*transaction.Token, nil); err != nil {
+				t.Errorf("Expected nil, got %v", err)
+			}
+		}
+
+		if b, _ := tokenService.Balance(userID); b.Available != 60*transaction.Token {
+			t.Errorf("Expected a single debit, got %s", b.Available)
+		}
+	})
 
-		tokenService := New(tranService)
+	t.Run("concurrent debits", func(t *testing.T) {
+		tokenService, tranService := setup()
 
 		userID := uuid.New()
 
-		err := tokenService.Debit(userID, transaction.QueryExecutionKey, -100, map[string]string{"provider": "paddle"})
+		deposit(tranService, userID, 10*transaction.Token)
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			debited  int
+			rejected int
+		)
+
+		for range 50 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := tokenService.Debit(userID, uuid.NewString(), transaction.QueryExecutionKey, transaction.Token, nil)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch err {
+				case nil:
+					debited++
+				case token.ErrInsufficientBalance:
+					rejected++
+				default:
+					t.Errorf("Expected nil or %v, got %v", token.ErrInsufficientBalance, err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if debited != 10 || rejected != 40 {
+			t.Errorf("Expected 10 debits and 40 rejected, got %d and %d", debited, rejected)
+		}
+
+		if b, _ := tokenService.Balance(userID); b.Available != 0 {
+			t.Errorf("Expected 0, got %s", b.Available)
+		}
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		tokenService, _ := setup()
+
+		userID := uuid.New()
+
+		err := tokenService.Debit(userID, "query:1", transaction.QueryExecutionKey, -100, map[string]string{"provider": "paddle"})
 		if err == nil {
 			t.Errorf("Expected error, got nil")
 		}
 	})
 
 	t.Run("insufficient balance", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
+		tokenService, _ := setup()
 
-		tokenService := New(tranService)
+		userID := uuid.New()
+
+		err := tokenService.Debit(userID, "query:1", transaction.QueryExecutionKey, 100, map[string]string{"provider": "paddle"})
+		if err != token.ErrInsufficientBalance {
+			t.Errorf("Expected %v, got %v", token.ErrInsufficientBalance, err)
+		}
+	})
+
+	t.Run("invalid transaction key", func(t *testing.T) {
+		tokenService, _ := setup()
 
 		userID := uuid.New()
 
-		err := tokenService.Debit(userID, transaction.QueryExecutionKey, 100, map[string]string{"provider": "paddle"})
+		err := tokenService.Debit(userID, "query:1", transaction.DepositKey, 100, map[string]string{"provider": "paddle"})
 		if err == nil {
 			t.Errorf("Expected error, got nil")
 		}
 	})
+}
 
-	t.Run("invalid transaction key", func(t *testing.T) {
-		logger := logrus.New()
-		tranRepo := tranRepo.New()
-		tranService := tranService.New(logger, tranRepo)
+func TestEscrow(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		userID := uuid.New()
+		jobID := uuid.New()
+
+		deposit(tranService, userID, 100*transaction.Token)
+
+		if err := tokenService.Escrow(userID, jobID, 30*transaction.Token); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		// a job's escrow is only held once
+		if err := tokenService.Escrow(userID, jobID, 30*transaction.Token); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
 
-		tokenService := New(tranService)
+		b, _ := tokenService.Balance(userID)
+
+		if b.Available != 70*transaction.Token || b.Escrowed != 30*transaction.Token {
+			t.Errorf("Expected 70 available and 30 escrowed, got %+v", b)
+		}
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		tokenService, tranService := setup()
 
 		userID := uuid.New()
 
-		err := tokenService.Debit(userID, transaction.DepositKey, 100, map[string]string{"provider": "paddle"})
-		if err == nil {
-			t.Errorf("Expected error, got nil")
+		deposit(tranService, userID, 10*transaction.Token)
+
+		if err := tokenService.Escrow(userID, uuid.New(), 30*transaction.Token); err != token.ErrInsufficientBalance {
+			t.Errorf("Expected %v, got %v", token.ErrInsufficientBalance, err)
+		}
+	})
+}
+
+func TestSettle(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		userID := uuid.New()
+		jobID := uuid.New()
+		nodeOwnerID := uuid.New()
+		ranagOwnerID := uuid.New()
+		nodeID := uuid.New()
+
+		deposit(tranService, userID, 100*transaction.Token)
+		tokenService.Escrow(userID, jobID, 30*transaction.Token)
+
+		earnings := map[transaction.Earner]transaction.Amount{
+			{OwnerID: nodeOwnerID, SourceID: nodeID}:      7 * transaction.Token,
+			{OwnerID: ranagOwnerID, SourceID: uuid.New()}: 2 * transaction.Token,
+		}
+
+		if err := tokenService.Settle(userID, jobID, 30*transaction.Token, 10*transaction.Token, earnings); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		// settling again does nothing
+		if err := tokenService.Settle(userID, jobID, 30*transaction.Token, 10*transaction.Token, earnings); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if b, _ := tokenService.Balance(userID); b.Available != 90*transaction.Token || b.Escrowed != 0 {
+			t.Errorf("Expected 90 available and nothing escrowed, got %+v", b)
+		}
+
+		for id, expected := range map[uuid.UUID]transaction.Amount{nodeOwnerID: 7 * transaction.Token, ranagOwnerID: 2 * transaction.Token} {
+			if b, _ := tokenService.Balance(id); b.Earnings != expected {
+				t.Errorf("Expected %s, got %s", expected, b.Earnings)
+			}
+		}
+
+		if fees, _ := tranService.Balance(transaction.Platform); fees != transaction.Token {
+			t.Errorf("Expected a fee of 1, got %s", fees)
+		}
+
+		transactions, _ := tranService.GetTransactionsByUserID(nodeOwnerID)
+
+		if len(transactions) != 1 || transactions[0].Account != transaction.OperatorAccount || transactions[0].Source != nodeID || transactions[0].Meta["job_id"] != jobID.String() {
+			t.Errorf("Expected the node earnings of the job, got %v", transactions)
+		}
+	})
+
+	t.Run("cost above escrow", func(t *testing.T) {
+		tokenService, _ := setup()
+
+		err := tokenService.Settle(uuid.New(), uuid.New(), 10, 20, map[transaction.Earner]transaction.Amount{{OwnerID: uuid.New()}: 20})
+
+		if err != token.ErrInvalidAmount {
+			t.Errorf("Expected %v, got %v", token.ErrInvalidAmount, err)
+		}
+	})
+
+	t.Run("earnings above cost", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		userID := uuid.New()
+
+		err := tokenService.Settle(userID, uuid.New(), 30, 10, map[transaction.Earner]transaction.Amount{{OwnerID: uuid.New()}: 15})
+
+		if err != token.ErrUnbalancedSettlement {
+			t.Errorf("Expected %v, got %v", token.ErrUnbalancedSettlement, err)
+		}
+
+		if transactions, _ := tranService.GetTransactionsByUserID(userID); len(transactions) != 0 {
+			t.Errorf("Expected no transactions, got %d", len(transactions))
+		}
+	})
+}
+
+// earn settles a job of another user that earns the owner the amount through
+// the node
+func earn(t *testing.T, tokenService *Service, tranService transaction.Service, ownerID, nodeID uuid.UUID, amount transaction.Amount) {
+	t.Helper()
+
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	deposit(tranService, userID, amount)
+	tokenService.Escrow(userID, jobID, amount)
+
+	earnings := map[transaction.Earner]transaction.Amount{{OwnerID: ownerID, SourceID: nodeID}: amount}
+
+	if err := tokenService.Settle(userID, jobID, amount, amount, earnings); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+}
+
+func TestEarnings(t *testing.T) {
+	tokenService, tranService := setup()
+
+	ownerID := uuid.New()
+	node1 := uuid.New()
+	node2 := uuid.New()
+
+	earn(t, tokenService, tranService, ownerID, node1, 3*transaction.Token)
+	earn(t, tokenService, tranService, ownerID, node1, 4*transaction.Token)
+	earn(t, tokenService, tranService, ownerID, node2, 5*transaction.Token)
+
+	// payouts are not earnings
+	tokenService.LockPayout(ownerID, uuid.New(), transaction.Token, time.Now())
+
+	now := time.Now()
+
+	earnings, err := tokenService.Earnings(ownerID, now, now)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(earnings) != 2 {
+		t.Fatalf("Expected the earnings of 2 nodes, got %d", len(earnings))
+	}
+
+	for _, e := range earnings {
+		expected := map[uuid.UUID]transaction.Amount{node1: 7 * transaction.Token, node2: 5 * transaction.Token}[e.SourceID]
+
+		if e.Amount != expected || !e.Day.Equal(now.UTC().Truncate(24*time.Hour)) {
+			t.Errorf("Expected %s today, got %s on %s", expected, e.Amount, e.Day)
+		}
+	}
+
+	if earnings, _ := tokenService.Earnings(ownerID, now.AddDate(0, 0, -7), now.AddDate(0, 0, -1)); len(earnings) != 0 {
+		t.Errorf("Expected nothing earned last week, got %d", len(earnings))
+	}
+}
+
+func TestPayable(t *testing.T) {
+	tokenService, tranService := setup()
+
+	ownerID := uuid.New()
+
+	earn(t, tokenService, tranService, ownerID, uuid.New(), 10*transaction.Token)
+
+	if payable, _ := tokenService.Payable(ownerID, time.Now().Add(-time.Hour)); payable != 0 {
+		t.Errorf("Expected the earnings of the last hour held, got %s", payable)
+	}
+
+	if payable, _ := tokenService.Payable(ownerID, time.Now()); payable != 10*transaction.Token {
+		t.Errorf("Expected 10, got %s", payable)
+	}
+
+	tokenService.LockPayout(ownerID, uuid.New(), 4*transaction.Token, time.Now())
+
+	if payable, _ := tokenService.Payable(ownerID, time.Now()); payable != 6*transaction.Token {
+		t.Errorf("Expected 6 once 4 are paid out, got %s", payable)
+	}
+}
+
+func TestPayout(t *testing.T) {
+	t.Run("paid", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		ownerID := uuid.New()
+		payoutID := uuid.New()
+
+		earn(t, tokenService, tranService, ownerID, uuid.New(), 10*transaction.Token)
+
+		for i := 0; i < 2; i++ {
+			if err := tokenService.LockPayout(ownerID, payoutID, 6*transaction.Token, time.Now()); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+		}
+
+		if b, _ := tokenService.Balance(ownerID); b.Earnings != 4*transaction.Token || b.PayingOut != 6*transaction.Token {
+			t.Errorf("Expected 6 locked once, got %+v", b)
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := tokenService.PayPayout(ownerID, payoutID, 6*transaction.Token); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+		}
+
+		if b, _ := tokenService.Balance(ownerID); b.Earnings != 4*transaction.Token || b.PayingOut != 0 {
+			t.Errorf("Expected 6 paid out, got %+v", b)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		ownerID := uuid.New()
+		payoutID := uuid.New()
+
+		earn(t, tokenService, tranService, ownerID, uuid.New(), 10*transaction.Token)
+
+		tokenService.LockPayout(ownerID, payoutID, 6*transaction.Token, time.Now())
+
+		for i := 0; i < 2; i++ {
+			if err := tokenService.ReleasePayout(ownerID, payoutID, 6*transaction.Token); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+		}
+
+		if b, _ := tokenService.Balance(ownerID); b.Earnings != 10*transaction.Token || b.PayingOut != 0 {
+			t.Errorf("Expected the earnings given back once, got %+v", b)
+		}
+	})
+
+	t.Run("more than earned", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		ownerID := uuid.New()
+
+		earn(t, tokenService, tranService, ownerID, uuid.New(), transaction.Token)
+
+		if err := tokenService.LockPayout(ownerID, uuid.New(), 2*transaction.Token, time.Now()); err != token.ErrInsufficientBalance {
+			t.Errorf("Expected %v, got %v", token.ErrInsufficientBalance, err)
+		}
+	})
+	t.Run("held earnings", func(t *testing.T) {
+		tokenService, tranService := setup()
+
+		ownerID := uuid.New()
+
+		earn(t, tokenService, tranService, ownerID, uuid.New(), transaction.Token)
+
+		if err := tokenService.LockPayout(ownerID, uuid.New(), transaction.Token, time.Now().Add(-time.Hour)); err != token.ErrInsufficientBalance {
+			t.Errorf("Expected %v, got %v", token.ErrInsufficientBalance, err)
 		}
 	})
 }
@@ -0,0 +1,133 @@
+package transaction
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Amount is a number of tokens in millionths of a token, so sums are exact.
+type Amount int64
+
+// Decimals is how many decimals of a token amounts keep.
+const Decimals = 6
+
+// Token is one token.
+const Token Amount = 1_000_000
+
+// ParseAmount parses a decimal number of tokens, such as "12" or "-0.25".
+// Amounts with more than Decimals decimals are invalid.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+
+	if whole == "" && frac == "" || len(frac) > Decimals {
+		return 0, ErrInvalidAmount
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	frac += strings.Repeat("0", Decimals-len(frac))
+
+	for _, part := range []string{whole, frac} {
+		if strings.ContainsAny(part, "+-") {
+			return 0, ErrInvalidAmount
+		}
+	}
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w > math.MaxInt64/int64(Token)-1 {
+		return 0, ErrInvalidAmount
+	}
+
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	a := Amount(w)*Token + Amount(f)
+
+	if negative {
+		a = -a
+	}
+
+	return a, nil
+}
+
+// AmountFromFloat rounds f tokens to the nearest amount.
+func AmountFromFloat(f float64) Amount {
+	return Amount(math.Round(f * float64(Token)))
+}
+
+func (a Amount) Float64() float64 {
+	return float64(a) / float64(Token)
+}
+
+// String formats the amount as a decimal number of tokens without trailing
+// zeros, such as "12.5".
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+
+	whole := strconv.FormatInt(int64(a/Token), 10)
+	frac := strings.TrimRight(strconv.FormatInt(int64(Token+a%Token), 10)[1:], "0")
+
+	if frac == "" {
+		return sign + whole
+	}
+
+	return sign + whole + "." + frac
+}
+
+// Mul multiplies the amount by n.
+func (a Amount) Mul(n int) Amount {
+	return a * Amount(n)
+}
+
+// Share is the part of the amount, rounded down, a fraction between 0 and 1
+// is worth.
+func (a Amount) Share(fraction float64) Amount {
+	return Amount(math.Floor(float64(a) * fraction))
+}
+
+// MarshalJSON writes the amount as a JSON number with its exact decimals.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number, or a string holding one, without going
+// through a float.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+
+	// exponents are not amounts people type, but encoders write them
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return ErrInvalidAmount
+		}
+
+		*a = AmountFromFloat(f)
+		return nil
+	}
+
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
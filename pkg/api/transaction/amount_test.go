@@ -0,0 +1,97 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected Amount
+		err      error
+	}{
+		{in: "12", expected: 12 * Token},
+		{in: "0.25", expected: 250_000},
+		{in: "-1.5", expected: -1_500_000},
+		{in: ".000001", expected: 1},
+		{in: "3.", expected: 3 * Token},
+		{in: "0.0000001", err: ErrInvalidAmount},
+		{in: "1.-5", err: ErrInvalidAmount},
+		{in: "abc", err: ErrInvalidAmount},
+		{in: "", err: ErrInvalidAmount},
+		{in: "99999999999999", err: ErrInvalidAmount},
+	} {
+		a, err := ParseAmount(tc.in)
+
+		if err != tc.err {
+			t.Errorf("%q: expected %v, got %v", tc.in, tc.err, err)
+			continue
+		}
+
+		if a != tc.expected {
+			t.Errorf("%q: expected %d, got %d", tc.in, tc.expected, a)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	for a, expected := range map[Amount]string{
+		0:          "0",
+		12 * Token: "12",
+		250_000:    "0.25",
+		-1_500_000: "-1.5",
+		1:          "0.000001",
+	} {
+		if s := a.String(); s != expected {
+			t.Errorf("Expected %s, got %s", expected, s)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		b, err := json.Marshal(map[string]Amount{"amount": 1_000_001})
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if string(b) != `{"amount":1.000001}` {
+			t.Errorf("Expected the exact decimals, got %s", b)
+		}
+
+		var v map[string]Amount
+
+		if err := json.Unmarshal(b, &v); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if v["amount"] != 1_000_001 {
+			t.Errorf("Expected 1000001, got %d", v["amount"])
+		}
+	})
+
+	t.Run("strings and exponents", func(t *testing.T) {
+		var v struct {
+			A Amount `json:"a"`
+			B Amount `json:"b"`
+		}
+
+		if err := json.Unmarshal([]byte(`{"a": "0.1", "b": 1e-3}`), &v); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if v.A != 100_000 || v.B != 1_000 {
+			t.Errorf("Expected 100000 and 1000, got %d and %d", v.A, v.B)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var a Amount
+
+		if err := json.Unmarshal([]byte(`"ten"`), &a); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+}
@@ -1,10 +1,20 @@
 package transaction
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUnbalanced        = errors.New("posting entries do not add up to zero")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrDuplicatePosting  = errors.New("posting already recorded")
+	ErrMissingKey        = errors.New("posting has no idempotency key")
+)
+
 type TransactionKey string
 
 const (
@@ -12,40 +22,205 @@ const (
 	QueryExecutionKey TransactionKey = "query_execution"
 	WithdrawalKey     TransactionKey = "withdrawal"
 	NodeEarningsKey   TransactionKey = "node_earnings"
+	// RefundReversalKey credits back a withdrawal whose refund failed
+	RefundReversalKey TransactionKey = "refund_reversal"
+
+	// EscrowKey holds tokens back from a user's balance until
+	// EscrowReleaseKey gives them back, when the job they were held for
+	// is settled.
+	EscrowKey        TransactionKey = "escrow"
+	EscrowReleaseKey TransactionKey = "escrow_release"
+
+	// PayoutKey locks an operator's earnings in their payout account while
+	// the payout is processed. PayoutPaidKey pays them out and
+	// PayoutReversalKey gives them back when the payout is rejected.
+	PayoutKey         TransactionKey = "payout"
+	PayoutPaidKey     TransactionKey = "payout_paid"
+	PayoutReversalKey TransactionKey = "payout_reversal"
 )
 
-type Transaction struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
-	Amount float64
-	Key    TransactionKey
-	Meta   map[string]string
-}
-
-func NewTransaction(userID uuid.UUID, amount float64, key TransactionKey, meta map[string]string) *Transaction {
-	return &Transaction{
-		ID:     uuid.New(),
-		UserID: userID,
-		Amount: amount,
-		Key:    key,
-		Meta:   meta,
+// AccountType is what an account holds tokens for.
+type AccountType string
+
+const (
+	// UserAccount holds what a user can spend
+	UserAccount AccountType = "user"
+	// EscrowAccount holds what a user's running jobs may cost
+	EscrowAccount AccountType = "escrow"
+	// OperatorAccount holds what the nodes and ranags of a user earned
+	OperatorAccount AccountType = "operator"
+	// PayoutAccount holds the earnings of a user being paid out
+	PayoutAccount AccountType = "payout"
+	// PlatformAccount holds the fees of the platform
+	PlatformAccount AccountType = "platform"
+	// ExternalAccount is where tokens come from and go to outside of the
+	// ledger, so it is the only account that goes below zero
+	ExternalAccount AccountType = "external"
+)
+
+// Account is an owner's account of a type. The platform and external
+// accounts have no owner.
+type Account struct {
+	Type    AccountType
+	OwnerID uuid.UUID
+}
+
+func User(ownerID uuid.UUID) Account {
+	return Account{Type: UserAccount, OwnerID: ownerID}
+}
+
+func Escrow(ownerID uuid.UUID) Account {
+	return Account{Type: EscrowAccount, OwnerID: ownerID}
+}
+
+func Operator(ownerID uuid.UUID) Account {
+	return Account{Type: OperatorAccount, OwnerID: ownerID}
+}
+
+func Payout(ownerID uuid.UUID) Account {
+	return Account{Type: PayoutAccount, OwnerID: ownerID}
+}
+
+var (
+	Platform = Account{Type: PlatformAccount}
+	External = Account{Type: ExternalAccount}
+)
+
+// Overdraws reports whether the account can go below zero.
+func (a Account) Overdraws() bool {
+	return a.Type == ExternalAccount
+}
+
+// Entry credits the amount to the account, or debits it when negative.
+// Source is the node or ranag that earned what the entry credits, uuid.Nil
+// for entries that were not earned.
+type Entry struct {
+	Account Account
+	Amount  Amount
+	Source  uuid.UUID
+}
+
+// Earner is a node or ranag and the user who owns it, who is credited what it
+// earns.
+type Earner struct {
+	OwnerID  uuid.UUID
+	SourceID uuid.UUID
+}
+
+// Transfer moves the amount from one account to the other.
+func Transfer(from, to Account, amount Amount) []Entry {
+	return []Entry{
+		{Account: from, Amount: -amount},
+		{Account: to, Amount: amount},
+	}
+}
+
+// Hold keeps what postings of the key credited the account after Since from
+// being taken out of it.
+type Hold struct {
+	Account Account
+	Key     TransactionKey
+	Since   time.Time
+}
+
+// Posting is a set of entries recorded together. Its entries add up to zero,
+// so tokens only ever move between accounts. The idempotency key identifies
+// the posting: a key is recorded once.
+type Posting struct {
+	ID             uuid.UUID
+	IdempotencyKey string
+	Key            TransactionKey
+	Meta           map[string]string
+	Entries        []Entry
+	CreatedAt      time.Time
+	// Hold, when set, is checked with the balances, so the posting cannot
+	// take held tokens out of the account.
+	Hold *Hold
+}
+
+func NewPosting(idempotencyKey string, key TransactionKey, meta map[string]string, entries ...Entry) *Posting {
+	return &Posting{
+		ID:             uuid.New(),
+		IdempotencyKey: idempotencyKey,
+		Key:            key,
+		Meta:           meta,
+		Entries:        entries,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func (p *Posting) Validate() error {
+	if p.IdempotencyKey == "" {
+		return ErrMissingKey
+	}
+
+	if len(p.Entries) < 2 {
+		return ErrUnbalanced
+	}
+
+	var sum Amount
+
+	for _, e := range p.Entries {
+		if e.Amount == 0 {
+			return ErrInvalidAmount
+		}
+
+		sum += e.Amount
+	}
+
+	if sum != 0 {
+		return ErrUnbalanced
 	}
+
+	return nil
+}
+
+// Transaction is an entry of a posting as the owner of its account sees it.
+type Transaction struct {
+	ID        uuid.UUID
+	PostingID uuid.UUID
+	UserID    uuid.UUID
+	Account   AccountType
+	Amount    Amount
+	Source    uuid.UUID
+	Key       TransactionKey
+	Meta      map[string]string
+	CreatedAt time.Time
+}
+
+// Credit is what postings of a key credited an account from a source on a
+// day, in UTC.
+type Credit struct {
+	Day    time.Time
+	Source uuid.UUID
+	Amount Amount
 }
 
 type Repository interface {
-	CreateTransaction(t *Transaction) error
+	// Post records the posting and updates the balances of its accounts
+	// atomically. It returns ErrInsufficientFunds when an account other
+	// than the external one would go below zero, or below what the
+	// posting's hold keeps, and ErrDuplicatePosting when the idempotency
+	// key was already recorded.
+	Post(p *Posting) error
+	Balance(a Account) (Amount, error)
 	GetTransactionsByUserID(userID uuid.UUID) ([]*Transaction, error)
+	// Credited returns what postings of the key credited the account after
+	// since.
+	Credited(a Account, key TransactionKey, since time.Time) (Amount, error)
+	// CreditsByDay returns what postings of the key credited the account on
+	// the days from and to include, by day and source.
+	CreditsByDay(a Account, key TransactionKey, from, to time.Time) ([]*Credit, error)
 }
 
 type Service interface {
-	CreateTransaction(
-		userID uuid.UUID,
-		amount float64,
-		key TransactionKey,
-		meta map[string]string,
-	) error
-	Balance(userID uuid.UUID) (float64, error)
+	// Post validates and records the posting. Recording a key again does
+	// nothing and returns ErrDuplicatePosting.
+	Post(p *Posting) error
+	Balance(a Account) (Amount, error)
 	GetTransactionsByUserID(userID uuid.UUID) ([]*Transaction, error)
+	Credited(a Account, key TransactionKey, since time.Time) (Amount, error)
+	CreditsByDay(a Account, key TransactionKey, from, to time.Time) ([]*Credit, error)
 }
 
 type Handler interface {
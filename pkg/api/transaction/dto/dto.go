@@ -1,6 +1,11 @@
 package dto
 
-import "juno/pkg/api/transaction"
+import (
+	"juno/pkg/api/transaction"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 const (
 	SUCCESS = "success"
@@ -8,11 +13,15 @@ const (
 )
 
 type Transaction struct {
-	ID     string            `json:"id"`
-	UserID string            `json:"user_id"`
-	Amount float64           `json:"amount"`
-	Key    string            `json:"key"`
-	Meta   map[string]string `json:"meta"`
+	ID        string             `json:"id"`
+	PostingID string             `json:"posting_id"`
+	UserID    string             `json:"user_id"`
+	Account   string             `json:"account"`
+	Amount    transaction.Amount `json:"amount"`
+	Source    string             `json:"source,omitempty"`
+	Key       string             `json:"key"`
+	Meta      map[string]string  `json:"meta"`
+	CreatedAt string             `json:"created_at"`
 }
 
 type ListResponse struct {
@@ -26,12 +35,21 @@ func NewSuccessListResponse(transactions []*transaction.Transaction) *ListRespon
 
 	var dtoTransactions []Transaction
 	for _, t := range transactions {
+		var source string
+		if t.Source != uuid.Nil {
+			source = t.Source.String()
+		}
+
 		dtoTransactions = append(dtoTransactions, Transaction{
-			ID:     t.ID.String(),
-			UserID: t.UserID.String(),
-			Amount: t.Amount,
-			Key:    string(t.Key),
-			Meta:   t.Meta,
+			ID:        t.ID.String(),
+			PostingID: t.PostingID.String(),
+			UserID:    t.UserID.String(),
+			Account:   string(t.Account),
+			Amount:    t.Amount,
+			Source:    source,
+			Key:       string(t.Key),
+			Meta:      t.Meta,
+			CreatedAt: t.CreatedAt.Format(time.RFC3339),
 		})
 	}
 
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -25,14 +26,22 @@ func (m *mockTransactionService) GetTransactionsByUserID(userID uuid.UUID) ([]*t
 	return m.transactions, m.withErr
 }
 
-func (m *mockTransactionService) CreateTransaction(userID uuid.UUID, amount float64, key transaction.TransactionKey, meta map[string]string) error {
+func (m *mockTransactionService) Post(p *transaction.Posting) error {
 	return m.withErr
 }
 
-func (m *mockTransactionService) Balance(userID uuid.UUID) (float64, error) {
+func (m *mockTransactionService) Balance(a transaction.Account) (transaction.Amount, error) {
 	return 0, m.withErr
 }
 
+func (m *mockTransactionService) Credited(a transaction.Account, key transaction.TransactionKey, since time.Time) (transaction.Amount, error) {
+	return 0, m.withErr
+}
+
+func (m *mockTransactionService) CreditsByDay(a transaction.Account, key transaction.TransactionKey, from, to time.Time) ([]*transaction.Credit, error) {
+	return nil, m.withErr
+}
+
 func TestList(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		service := &mockTransactionService{
@@ -40,7 +49,7 @@ func TestList(t *testing.T) {
 				{
 					ID:     uuid.New(),
 					UserID: uuid.New(),
-					Amount: 100,
+					Amount: 100 * transaction.Token,
 					Key:    "deposit",
 					Meta:   map[string]string{"provider": "paddle"},
 				},
@@ -91,7 +100,7 @@ func TestList(t *testing.T) {
 			}
 
 			if tran.Amount != service.transactions[i].Amount {
-				t.Errorf("Expected %f, got %f", service.transactions[i].Amount, tran.Amount)
+				t.Errorf("Expected %s, got %s", service.transactions[i].Amount, tran.Amount)
 			}
 
 			if tran.Key != string(service.transactions[i].Key) {
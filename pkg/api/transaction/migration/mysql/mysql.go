@@ -1,7 +1,12 @@
 package mysql
 
-import "database/sql"
+import (
+	"database/sql"
+	"sort"
+)
 
+// migrations run in the order of their names, so the transactions recorded
+// before the ledger are moved into it after its tables exist
 var migrations = map[string]string{
 	"create_transactions_table": `
 		CREATE TABLE IF NOT EXISTS transactions (
@@ -12,6 +17,61 @@ var migrations = map[string]string{
 			meta MEDIUMTEXT NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);`,
+	"create_ledger_postings_table": `
+		CREATE TABLE IF NOT EXISTS ledger_postings (
+			id VARCHAR(36) PRIMARY KEY,
+			idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+			type VARCHAR(32) NOT NULL,
+			meta MEDIUMTEXT NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+		);`,
+	"create_ledger_entries_table": `
+		CREATE TABLE IF NOT EXISTS ledger_entries (
+			id VARCHAR(36) PRIMARY KEY,
+			posting_id VARCHAR(36) NOT NULL,
+			account_type VARCHAR(16) NOT NULL,
+			owner_id VARCHAR(36) NOT NULL,
+			amount BIGINT NOT NULL,
+			INDEX (posting_id),
+			INDEX (owner_id)
+		);`,
+	"create_ledger_balances_table": `
+		CREATE TABLE IF NOT EXISTS ledger_balances (
+			account_type VARCHAR(16) NOT NULL,
+			owner_id VARCHAR(36) NOT NULL,
+			balance BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (account_type, owner_id)
+		);`,
+
+	// the node or ranag that earned an entry, NULL for the rest
+	"migrate_ledger_entries_source": `
+		ALTER TABLE ledger_entries ADD COLUMN source_id VARCHAR(36) NULL;`,
+
+	// every transaction becomes a posting between its user's account and
+	// the escrow account it moved tokens to or from, or the external one
+	"migrate_transactions_1_postings": `
+		INSERT IGNORE INTO ledger_postings (id, idempotency_key, type, meta, created_at)
+		SELECT id, CONCAT('transaction:', id), type, meta, created_at
+		FROM transactions;`,
+	"migrate_transactions_2_entries": `
+		INSERT IGNORE INTO ledger_entries (id, posting_id, account_type, owner_id, amount)
+		SELECT UUID(), id, IF(type = 'node_earnings', 'operator', 'user'), user_id, ROUND(amount * 1000000)
+		FROM transactions;`,
+	"migrate_transactions_3_counterparts": `
+		INSERT IGNORE INTO ledger_entries (id, posting_id, account_type, owner_id, amount)
+		SELECT
+			UUID(),
+			id,
+			IF(type IN ('escrow', 'escrow_release'), 'escrow', 'external'),
+			IF(type IN ('escrow', 'escrow_release'), user_id, '00000000-0000-0000-0000-000000000000'),
+			-ROUND(amount * 1000000)
+		FROM transactions;`,
+	"migrate_transactions_4_balances": `
+		INSERT INTO ledger_balances (account_type, owner_id, balance)
+		SELECT account_type, owner_id, SUM(amount)
+		FROM ledger_entries
+		GROUP BY account_type, owner_id
+		ON DUPLICATE KEY UPDATE balance = VALUES(balance);`,
 }
 
 func ExecuteMigrations(db *sql.DB) error {
@@ -23,7 +83,15 @@ func ExecuteMigrations(db *sql.DB) error {
 		return err
 	}
 
-	for name, migration := range migrations {
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		migration := migrations[name]
 
 		// check if migration has already been executed
 		var count int
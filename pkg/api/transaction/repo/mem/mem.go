@@ -2,29 +2,163 @@ package mem
 
 import (
 	"juno/pkg/api/transaction"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Repository keeps the ledger in memory. Postings are recorded one at a time,
+// so balances are checked and updated without races.
 type Repository struct {
-	tokens []*transaction.Transaction
+	mu           sync.Mutex
+	transactions []*transaction.Transaction
+	balances     map[transaction.Account]transaction.Amount
+	keys         map[string]bool
 }
 
 func New() *Repository {
-	return &Repository{}
+	return &Repository{
+		balances: make(map[transaction.Account]transaction.Amount),
+		keys:     make(map[string]bool),
+	}
 }
 
-func (r *Repository) CreateTransaction(t *transaction.Transaction) error {
-	r.tokens = append(r.tokens, t)
+func (r *Repository) Post(p *transaction.Posting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys[p.IdempotencyKey] {
+		return transaction.ErrDuplicatePosting
+	}
+
+	balances := map[transaction.Account]transaction.Amount{}
+
+	for _, e := range p.Entries {
+		if _, ok := balances[e.Account]; !ok {
+			balances[e.Account] = r.balances[e.Account]
+		}
+
+		balances[e.Account] += e.Amount
+	}
+
+	for a, balance := range balances {
+		if balance < 0 && !a.Overdraws() {
+			return transaction.ErrInsufficientFunds
+		}
+	}
+
+	if h := p.Hold; h != nil {
+		if balance, ok := balances[h.Account]; ok && balance < r.credited(h.Account, h.Key, h.Since) {
+			return transaction.ErrInsufficientFunds
+		}
+	}
+
+	for a, balance := range balances {
+		r.balances[a] = balance
+	}
+
+	for _, e := range p.Entries {
+		r.transactions = append(r.transactions, &transaction.Transaction{
+			ID:        uuid.New(),
+			PostingID: p.ID,
+			UserID:    e.Account.OwnerID,
+			Account:   e.Account.Type,
+			Amount:    e.Amount,
+			Source:    e.Source,
+			Key:       p.Key,
+			Meta:      p.Meta,
+			CreatedAt: p.CreatedAt,
+		})
+	}
+
+	r.keys[p.IdempotencyKey] = true
+
 	return nil
 }
 
+func (r *Repository) Balance(a transaction.Account) (transaction.Amount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.balances[a], nil
+}
+
 func (r *Repository) GetTransactionsByUserID(userID uuid.UUID) ([]*transaction.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	var transactions []*transaction.Transaction
-	for _, t := range r.tokens {
-		if t.UserID == userID {
+	for _, t := range r.transactions {
+		if t.UserID == userID && t.Account != transaction.PlatformAccount && t.Account != transaction.ExternalAccount {
 			transactions = append(transactions, t)
 		}
 	}
 	return transactions, nil
 }
+
+func (r *Repository) Credited(a transaction.Account, key transaction.TransactionKey, since time.Time) (transaction.Amount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.credited(a, key, since), nil
+}
+
+func (r *Repository) credited(a transaction.Account, key transaction.TransactionKey, since time.Time) transaction.Amount {
+	var sum transaction.Amount
+
+	for _, t := range r.transactions {
+		if t.UserID == a.OwnerID && t.Account == a.Type && t.Key == key && t.Amount > 0 && t.CreatedAt.After(since) {
+			sum += t.Amount
+		}
+	}
+
+	return sum
+}
+
+func (r *Repository) CreditsByDay(a transaction.Account, key transaction.TransactionKey, from, to time.Time) ([]*transaction.Credit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+
+	type group struct {
+		day    time.Time
+		source uuid.UUID
+	}
+
+	credits := map[group]*transaction.Credit{}
+	var list []*transaction.Credit
+
+	for _, t := range r.transactions {
+		if t.UserID != a.OwnerID || t.Account != a.Type || t.Key != key || t.Amount <= 0 {
+			continue
+		}
+
+		day := t.CreatedAt.UTC().Truncate(24 * time.Hour)
+
+		if day.Before(from) || day.After(to) {
+			continue
+		}
+
+		g := group{day: day, source: t.Source}
+
+		if _, ok := credits[g]; !ok {
+			credits[g] = &transaction.Credit{Day: day, Source: t.Source}
+			list = append(list, credits[g])
+		}
+
+		credits[g].Amount += t.Amount
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Day.Equal(list[j].Day) {
+			return list[i].Day.Before(list[j].Day)
+		}
+		return list[i].Source.String() < list[j].Source.String()
+	})
+
+	return list, nil
+}
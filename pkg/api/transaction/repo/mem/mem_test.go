@@ -2,63 +2,250 @@ package mem
 
 import (
 	"juno/pkg/api/transaction"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-func TestCreateTransaction(t *testing.T) {
+func deposit(userID uuid.UUID, amount transaction.Amount) *transaction.Posting {
+	return transaction.NewPosting(
+		uuid.NewString(),
+		transaction.DepositKey,
+		map[string]string{"provider": "paddle"},
+		transaction.Transfer(transaction.External, transaction.User(userID), amount)...,
+	)
+}
+
+func TestPost(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := New()
+
+		userID := uuid.New()
+
+		if err := repo.Post(deposit(userID, 100)); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+
+		if balance, _ := repo.Balance(transaction.User(userID)); balance != 100 {
+			t.Errorf("Expected 100, got %s", balance)
+		}
+
+		if len(repo.transactions) != 2 {
+			t.Errorf("Expected 2, got %d", len(repo.transactions))
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		repo := New()
+
+		userID := uuid.New()
+		p := deposit(userID, 100)
+
+		repo.Post(p)
+
+		if err := repo.Post(p); err != transaction.ErrDuplicatePosting {
+			t.Errorf("Expected %v, got %v", transaction.ErrDuplicatePosting, err)
+		}
+
+		if balance, _ := repo.Balance(transaction.User(userID)); balance != 100 {
+			t.Errorf("Expected 100, got %s", balance)
+		}
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		repo := New()
+
+		userID := uuid.New()
+
+		repo.Post(deposit(userID, 100))
+
+		err := repo.Post(transaction.NewPosting(
+			uuid.NewString(),
+			transaction.EscrowKey,
+			nil,
+			transaction.Transfer(transaction.User(userID), transaction.Escrow(userID), 101)...,
+		))
+
+		if err != transaction.ErrInsufficientFunds {
+			t.Errorf("Expected %v, got %v", transaction.ErrInsufficientFunds, err)
+		}
+
+		if balance, _ := repo.Balance(transaction.Escrow(userID)); balance != 0 {
+			t.Errorf("Expected nothing escrowed, got %s", balance)
+		}
+	})
+
+	t.Run("concurrent debits", func(t *testing.T) {
+		repo := New()
+
+		userID := uuid.New()
+
+		repo.Post(deposit(userID, 10))
+
+		var wg sync.WaitGroup
+
+		for range 100 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				repo.Post(transaction.NewPosting(
+					uuid.NewString(),
+					transaction.QueryExecutionKey,
+					nil,
+					transaction.Transfer(transaction.User(userID), transaction.Platform, 1)...,
+				))
+			}()
+		}
+
+		wg.Wait()
+
+		if balance, _ := repo.Balance(transaction.User(userID)); balance != 0 {
+			t.Errorf("Expected 0, got %s", balance)
+		}
+
+		if fees, _ := repo.Balance(transaction.Platform); fees != 10 {
+			t.Errorf("Expected 10, got %s", fees)
+		}
+	})
+}
+
+func TestGetTransactionsByUserID(t *testing.T) {
 	repo := New()
 
-	tran := transaction.NewTransaction(
-		uuid.New(),
-		1,
-		transaction.QueryExecutionKey,
-		map[string]string{"query_id": uuid.New().String()},
-	)
+	userID := uuid.New()
+	p := deposit(userID, 1)
+
+	if err := repo.Post(p); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
 
-	if err := repo.CreateTransaction(tran); err != nil {
+	transactions, err := repo.GetTransactionsByUserID(userID)
+	if err != nil {
 		t.Errorf("Expected nil, got %v", err)
 	}
 
-	if len(repo.tokens) != 1 {
-		t.Errorf("Expected 1, got %d", len(repo.tokens))
+	// the external side of the deposit is not the user's
+	if len(transactions) != 1 {
+		t.Fatalf("Expected 1, got %d", len(transactions))
 	}
 
-	if repo.tokens[0].ID != tran.ID {
-		t.Errorf("Expected %s, got %s", tran.ID, repo.tokens[0].ID)
+	if transactions[0].PostingID != p.ID {
+		t.Errorf("Expected %s, got %s", p.ID, transactions[0].PostingID)
+	}
+
+	if transactions[0].UserID != userID {
+		t.Errorf("Expected %s, got %s", userID, transactions[0].UserID)
 	}
 }
 
-func TestGetTransactionsByUserID(t *testing.T) {
+func TestEarningsSource(t *testing.T) {
 	repo := New()
 
-	userID := uuid.New()
-	tran := transaction.NewTransaction(
-		userID,
-		1,
+	ownerID := uuid.New()
+	nodeID := uuid.New()
+
+	p := transaction.NewPosting(
+		uuid.NewString(),
 		transaction.QueryExecutionKey,
-		map[string]string{"query_id": uuid.New().String()},
+		nil,
+		transaction.Entry{Account: transaction.External, Amount: -5},
+		transaction.Entry{Account: transaction.Operator(ownerID), Amount: 5, Source: nodeID},
 	)
 
-	if err := repo.CreateTransaction(tran); err != nil {
-		t.Errorf("Expected nil, got %v", err)
+	if err := repo.Post(p); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
 	}
 
-	transactions, err := repo.GetTransactionsByUserID(userID)
-	if err != nil {
+	transactions, _ := repo.GetTransactionsByUserID(ownerID)
+
+	if len(transactions) != 1 || transactions[0].Source != nodeID {
+		t.Errorf("Expected the earnings of %s, got %v", nodeID, transactions)
+	}
+}
+
+func earn(ownerID, nodeID uuid.UUID, amount transaction.Amount) *transaction.Posting {
+	return transaction.NewPosting(
+		uuid.NewString(),
+		transaction.QueryExecutionKey,
+		nil,
+		transaction.Entry{Account: transaction.External, Amount: -amount},
+		transaction.Entry{Account: transaction.Operator(ownerID), Amount: amount, Source: nodeID},
+	)
+}
+
+func TestHold(t *testing.T) {
+	repo := New()
+
+	ownerID := uuid.New()
+
+	old := earn(ownerID, uuid.New(), 4)
+	old.CreatedAt = time.Now().Add(-2 * time.Hour)
+	repo.Post(old)
+	repo.Post(earn(ownerID, uuid.New(), 6))
+
+	since := time.Now().Add(-time.Hour)
+
+	if held, _ := repo.Credited(transaction.Operator(ownerID), transaction.QueryExecutionKey, since); held != 6 {
+		t.Errorf("Expected 6 held, got %s", held)
+	}
+
+	payout := func(amount transaction.Amount) *transaction.Posting {
+		p := transaction.NewPosting(
+			uuid.NewString(),
+			transaction.PayoutKey,
+			nil,
+			transaction.Transfer(transaction.Operator(ownerID), transaction.Payout(ownerID), amount)...,
+		)
+		p.Hold = &transaction.Hold{Account: transaction.Operator(ownerID), Key: transaction.QueryExecutionKey, Since: since}
+
+		return p
+	}
+
+	if err := repo.Post(payout(5)); err != transaction.ErrInsufficientFunds {
+		t.Errorf("Expected %v, got %v", transaction.ErrInsufficientFunds, err)
+	}
+
+	if err := repo.Post(payout(4)); err != nil {
 		t.Errorf("Expected nil, got %v", err)
 	}
+}
 
-	if len(transactions) != 1 {
-		t.Errorf("Expected 1, got %d", len(transactions))
+func TestCreditsByDay(t *testing.T) {
+	repo := New()
+
+	ownerID := uuid.New()
+	node1 := uuid.New()
+	node2 := uuid.New()
+
+	repo.Post(earn(ownerID, node1, 3))
+	repo.Post(earn(ownerID, node1, 4))
+	repo.Post(earn(ownerID, node2, 5))
+
+	yesterday := earn(ownerID, node1, 8)
+	yesterday.CreatedAt = time.Now().AddDate(0, 0, -1)
+	repo.Post(yesterday)
+
+	now := time.Now()
+
+	credits, err := repo.CreditsByDay(transaction.Operator(ownerID), transaction.QueryExecutionKey, now, now)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
 	}
 
-	if transactions[0].ID != tran.ID {
-		t.Errorf("Expected %s, got %s", tran.ID, transactions[0].ID)
+	if len(credits) != 2 {
+		t.Fatalf("Expected the credits of 2 nodes, got %d", len(credits))
 	}
 
-	if transactions[0].UserID != tran.UserID {
-		t.Errorf("Expected %s, got %s", tran.UserID, transactions[0].UserID)
+	for _, c := range credits {
+		expected := map[uuid.UUID]transaction.Amount{node1: 7, node2: 5}[c.Source]
+
+		if c.Amount != expected || !c.Day.Equal(now.UTC().Truncate(24*time.Hour)) {
+			t.Errorf("Expected %s today, got %s on %s", expected, c.Amount, c.Day)
+		}
 	}
 }
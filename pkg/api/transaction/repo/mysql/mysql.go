@@ -3,8 +3,12 @@ package mysql
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"juno/pkg/api/transaction"
+	"sort"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 )
 
@@ -16,27 +20,158 @@ func New(db *sql.DB) *Repository {
 	return &Repository{db}
 }
 
-func (r *Repository) CreateTransaction(t *transaction.Transaction) error {
+// errDuplicateEntry is the MySQL error of an insert that violates a unique
+// key.
+const errDuplicateEntry = 1062
 
-	meta, err := json.Marshal(t.Meta)
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
+
+// Post locks the balance rows of the posting's accounts, in the same order
+// for every posting so concurrent ones wait on each other instead of
+// deadlocking, and only updates them when none would overdraw.
+func (r *Repository) Post(p *transaction.Posting) error {
+
+	meta, err := json.Marshal(p.Meta)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.Exec(`
-		INSERT INTO transactions (id, user_id, type, amount, meta)
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	// a posting with the same key, even one not committed yet, makes this
+	// insert wait and then fail on the unique key
+	_, err = tx.Exec(`
+		INSERT INTO ledger_postings (id, idempotency_key, type, meta, created_at)
 		VALUES (?, ?, ?, ?, ?)
-	`, t.ID, t.UserID, t.Key, t.Amount, meta)
-	return err
+	`, p.ID, p.IdempotencyKey, p.Key, meta, p.CreatedAt)
+
+	if isDuplicate(err) {
+		return transaction.ErrDuplicatePosting
+	}
+
+	if err != nil {
+		return err
+	}
+
+	deltas := map[transaction.Account]transaction.Amount{}
+	for _, e := range p.Entries {
+		deltas[e.Account] += e.Amount
+	}
+
+	accounts := make([]transaction.Account, 0, len(deltas))
+	for a := range deltas {
+		accounts = append(accounts, a)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Type != accounts[j].Type {
+			return accounts[i].Type < accounts[j].Type
+		}
+		return accounts[i].OwnerID.String() < accounts[j].OwnerID.String()
+	})
+
+	for _, a := range accounts {
+		// creates the row if needed while taking its lock, which a shared
+		// lock from a plain insert would not
+		_, err := tx.Exec(`
+			INSERT INTO ledger_balances (account_type, owner_id, balance)
+			VALUES (?, ?, 0)
+			ON DUPLICATE KEY UPDATE balance = balance
+		`, a.Type, a.OwnerID)
+
+		if err != nil {
+			return err
+		}
+
+		var balance transaction.Amount
+
+		err = tx.QueryRow(`
+			SELECT balance FROM ledger_balances
+			WHERE account_type = ? AND owner_id = ?
+			FOR UPDATE
+		`, a.Type, a.OwnerID).Scan(&balance)
+
+		if err != nil {
+			return err
+		}
+
+		balance += deltas[a]
+
+		if balance < 0 && !a.Overdraws() {
+			return transaction.ErrInsufficientFunds
+		}
+
+		// the balance row is locked, so no credit can land between the
+		// sum and the update
+		if h := p.Hold; h != nil && h.Account == a {
+			held, err := credited(tx, a, h.Key, h.Since)
+
+			if err != nil {
+				return err
+			}
+
+			if balance < held {
+				return transaction.ErrInsufficientFunds
+			}
+		}
+
+		_, err = tx.Exec(`
+			UPDATE ledger_balances SET balance = ?
+			WHERE account_type = ? AND owner_id = ?
+		`, balance, a.Type, a.OwnerID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range p.Entries {
+		_, err := tx.Exec(`
+			INSERT INTO ledger_entries (id, posting_id, account_type, owner_id, amount, source_id)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, uuid.New(), p.ID, e.Account.Type, e.Account.OwnerID, e.Amount, source(e.Source))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) Balance(a transaction.Account) (transaction.Amount, error) {
+	var balance transaction.Amount
+
+	err := r.db.QueryRow(`
+		SELECT balance FROM ledger_balances
+		WHERE account_type = ? AND owner_id = ?
+	`, a.Type, a.OwnerID).Scan(&balance)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return balance, err
 }
 
 func (r *Repository) GetTransactionsByUserID(userID uuid.UUID) ([]*transaction.Transaction, error) {
 	rows, err := r.db.Query(`
-		SELECT id, user_id, type, amount, meta
-		FROM transactions
-		WHERE user_id = ?
-	`, userID)
+		SELECT e.id, e.posting_id, e.owner_id, e.account_type, e.amount, e.source_id, p.type, p.meta, p.created_at
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.id = e.posting_id
+		WHERE e.owner_id = ? AND e.account_type IN (?, ?, ?, ?)
+		ORDER BY p.created_at
+	`, userID, transaction.UserAccount, transaction.EscrowAccount, transaction.OperatorAccount, transaction.PayoutAccount)
 	if err != nil {
 		return nil, err
 	}
@@ -44,13 +179,23 @@ func (r *Repository) GetTransactionsByUserID(userID uuid.UUID) ([]*transaction.T
 
 	var transactions []*transaction.Transaction
 
-	var meta string
+	var (
+		meta     string
+		sourceID sql.NullString
+	)
+
 	for rows.Next() {
 		var t transaction.Transaction
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Key, &t.Amount, &meta); err != nil {
+		if err := rows.Scan(&t.ID, &t.PostingID, &t.UserID, &t.Account, &t.Amount, &sourceID, &t.Key, &meta, &t.CreatedAt); err != nil {
 			return nil, err
 		}
 
+		if sourceID.Valid {
+			if t.Source, err = uuid.Parse(sourceID.String); err != nil {
+				return nil, err
+			}
+		}
+
 		err := json.Unmarshal([]byte(meta), &t.Meta)
 
 		if err != nil {
@@ -62,3 +207,75 @@ func (r *Repository) GetTransactionsByUserID(userID uuid.UUID) ([]*transaction.T
 
 	return transactions, nil
 }
+
+// queryer is a connection or a transaction.
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (r *Repository) Credited(a transaction.Account, key transaction.TransactionKey, since time.Time) (transaction.Amount, error) {
+	return credited(r.db, a, key, since)
+}
+
+func credited(q queryer, a transaction.Account, key transaction.TransactionKey, since time.Time) (transaction.Amount, error) {
+	var sum transaction.Amount
+
+	err := q.QueryRow(`
+		SELECT CAST(COALESCE(SUM(e.amount), 0) AS SIGNED)
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.id = e.posting_id
+		WHERE e.owner_id = ? AND e.account_type = ? AND p.type = ? AND e.amount > 0 AND p.created_at > ?
+	`, a.OwnerID, a.Type, key, since).Scan(&sum)
+
+	return sum, err
+}
+
+func (r *Repository) CreditsByDay(a transaction.Account, key transaction.TransactionKey, from, to time.Time) ([]*transaction.Credit, error) {
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	rows, err := r.db.Query(`
+		SELECT DATE(p.created_at) AS day, e.source_id, CAST(SUM(e.amount) AS SIGNED)
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.id = e.posting_id
+		WHERE e.owner_id = ? AND e.account_type = ? AND p.type = ? AND e.amount > 0 AND p.created_at >= ? AND p.created_at < ?
+		GROUP BY day, e.source_id
+		ORDER BY day, e.source_id
+	`, a.OwnerID, a.Type, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credits []*transaction.Credit
+
+	for rows.Next() {
+		var (
+			c        transaction.Credit
+			sourceID sql.NullString
+		)
+
+		if err := rows.Scan(&c.Day, &sourceID, &c.Amount); err != nil {
+			return nil, err
+		}
+
+		if sourceID.Valid {
+			if c.Source, err = uuid.Parse(sourceID.String); err != nil {
+				return nil, err
+			}
+		}
+
+		credits = append(credits, &c)
+	}
+
+	return credits, rows.Err()
+}
+
+// source stores entries that were not earned without a source.
+func source(id uuid.UUID) interface{} {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	return id.String()
+}
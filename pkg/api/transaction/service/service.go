@@ -2,6 +2,7 @@ package service
 
 import (
 	"juno/pkg/api/transaction"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -19,28 +20,26 @@ func New(logger *logrus.Logger, transactionRepo transaction.Repository) *Service
 	}
 }
 
-func (s *Service) CreateTransaction(userID uuid.UUID, amount float64, key transaction.TransactionKey, meta map[string]string) error {
-	return s.transactionRepo.CreateTransaction(
-		transaction.NewTransaction(userID, amount, key, meta),
-	)
+func (s *Service) Post(p *transaction.Posting) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	return s.transactionRepo.Post(p)
 }
 
 func (s *Service) GetTransactionsByUserID(userID uuid.UUID) ([]*transaction.Transaction, error) {
 	return s.transactionRepo.GetTransactionsByUserID(userID)
 }
 
-func (s *Service) Balance(userID uuid.UUID) (float64, error) {
-	transactions, err := s.GetTransactionsByUserID(userID)
-
-	if err != nil {
-		return 0, err
-	}
-
-	balance := 0.0
+func (s *Service) Balance(a transaction.Account) (transaction.Amount, error) {
+	return s.transactionRepo.Balance(a)
+}
 
-	for _, t := range transactions {
-		balance += t.Amount
-	}
+func (s *Service) Credited(a transaction.Account, key transaction.TransactionKey, since time.Time) (transaction.Amount, error) {
+	return s.transactionRepo.Credited(a, key, since)
+}
 
-	return balance, nil
+func (s *Service) CreditsByDay(a transaction.Account, key transaction.TransactionKey, from, to time.Time) ([]*transaction.Credit, error) {
+	return s.transactionRepo.CreditsByDay(a, key, from, to)
 }
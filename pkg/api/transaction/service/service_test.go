@@ -9,93 +9,116 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func TestCreateTransaction(t *testing.T) {
+func TestPost(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		repo := mem.New()
-
-		service := New(logrus.New(), repo)
-
-		tran := transaction.NewTransaction(
-			uuid.New(),
-			138492,
-			transaction.QueryExecutionKey,
-			map[string]string{"query_id": uuid.New().String()},
-		)
-
-		if err := service.CreateTransaction(
-			tran.UserID,
-			tran.Amount,
-			tran.Key,
-			tran.Meta,
-		); err != nil {
+		service := New(logrus.New(), mem.New())
+
+		userID := uuid.New()
+
+		err := service.Post(transaction.NewPosting(
+			"deposit:1",
+			transaction.DepositKey,
+			map[string]string{"provider": "paddle"},
+			transaction.Transfer(transaction.External, transaction.User(userID), 138492)...,
+		))
+
+		if err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		transactions, err := service.GetTransactionsByUserID(tran.UserID)
+		transactions, err := service.GetTransactionsByUserID(userID)
 
 		if err != nil {
 			t.Fatalf("Expected nil, got %v", err)
 		}
 
 		if len(transactions) != 1 {
-			t.Errorf("Expected 1, got %d", len(transactions))
+			t.Fatalf("Expected 1, got %d", len(transactions))
 		}
 
-		if transactions[0].Amount != tran.Amount {
-			t.Errorf("Expected %f, got %f", 138492.0, transactions[0].Amount)
+		if transactions[0].Amount != 138492 || transactions[0].Account != transaction.UserAccount {
+			t.Errorf("Expected 138492 credited to the user account, got %s to %s", transactions[0].Amount, transactions[0].Account)
+		}
+	})
+
+	t.Run("invalid postings", func(t *testing.T) {
+		service := New(logrus.New(), mem.New())
+
+		userID := uuid.New()
+
+		for _, tc := range []struct {
+			name     string
+			posting  *transaction.Posting
+			expected error
+		}{
+			{
+				name:     "no idempotency key",
+				posting:  transaction.NewPosting("", transaction.DepositKey, nil, transaction.Transfer(transaction.External, transaction.User(userID), 10)...),
+				expected: transaction.ErrMissingKey,
+			},
+			{
+				name:     "unbalanced",
+				posting:  transaction.NewPosting("1", transaction.DepositKey, nil, transaction.Entry{Account: transaction.User(userID), Amount: 10}, transaction.Entry{Account: transaction.External, Amount: -5}),
+				expected: transaction.ErrUnbalanced,
+			},
+			{
+				name:     "single entry",
+				posting:  transaction.NewPosting("2", transaction.DepositKey, nil, transaction.Entry{Account: transaction.User(userID), Amount: 10}),
+				expected: transaction.ErrUnbalanced,
+			},
+			{
+				name:     "zero amount",
+				posting:  transaction.NewPosting("3", transaction.DepositKey, nil, transaction.Transfer(transaction.External, transaction.User(userID), 0)...),
+				expected: transaction.ErrInvalidAmount,
+			},
+		} {
+			if err := service.Post(tc.posting); err != tc.expected {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, err)
+			}
+		}
+
+		if balance, _ := service.Balance(transaction.User(userID)); balance != 0 {
+			t.Errorf("Expected 0, got %s", balance)
 		}
 	})
 }
 
 func TestBalance(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		repo := mem.New()
-
-		service := New(logrus.New(), repo)
+		service := New(logrus.New(), mem.New())
 
 		userID := uuid.New()
 
-		tran := transaction.NewTransaction(
-			userID,
-			138492,
+		if err := service.Post(transaction.NewPosting(
+			"deposit:1",
 			transaction.DepositKey,
-			map[string]string{"query_id": uuid.New().String()},
-		)
-
-		if err := service.CreateTransaction(
-			tran.UserID,
-			tran.Amount,
-			tran.Key,
-			tran.Meta,
-		); err != nil {
+			nil,
+			transaction.Transfer(transaction.External, transaction.User(userID), 138492)...,
+		)); err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		// debit
-		tran = transaction.NewTransaction(
-			userID,
-			-1000,
+		if err := service.Post(transaction.NewPosting(
+			"withdrawal:1",
 			transaction.WithdrawalKey,
-			map[string]string{"query_id": uuid.New().String()},
-		)
-
-		if err := service.CreateTransaction(
-			tran.UserID,
-			tran.Amount,
-			tran.Key,
-			tran.Meta,
-		); err != nil {
+			nil,
+			transaction.Transfer(transaction.User(userID), transaction.External, 1000)...,
+		)); err != nil {
 			t.Errorf("Expected nil, got %v", err)
 		}
 
-		balance, err := service.Balance(userID)
+		balance, err := service.Balance(transaction.User(userID))
 
 		if err != nil {
 			t.Fatalf("Expected nil, got %v", err)
 		}
 
-		if balance != 137492.0 {
-			t.Errorf("Expected 137492, got %f", balance)
+		if balance != 137492 {
+			t.Errorf("Expected 137492, got %s", balance)
+		}
+
+		if external, _ := service.Balance(transaction.External); external != -137492 {
+			t.Errorf("Expected -137492, got %s", external)
 		}
 	})
 }
@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SignerType string
+
+const (
+	NodeSigner  SignerType = "node"
+	RanagSigner SignerType = "ranag"
+)
+
+// Receipt is a verified receipt of the work a node or ranag did for a job.
+type Receipt struct {
+	ID        uuid.UUID
+	JobID     uuid.UUID
+	RequestID string
+	// SignerType and SignerID name the node or ranag that signed the
+	// receipt, and OwnerID its operator
+	SignerType SignerType
+	SignerID   uuid.UUID
+	OwnerID    uuid.UUID
+	Shards     []int
+	Pages      int
+	Rows       int
+	Signature  []byte
+	IssuedAt   time.Time
+	CreatedAt  time.Time
+}
+
+// Usage is the work a node or ranag did over a period, added up from its
+// receipts.
+type Usage struct {
+	SignerType SignerType
+	SignerID   uuid.UUID
+	// Requests is how many receipts the work was signed in
+	Requests int
+	Shards   int
+	Pages    int
+	Rows     int
+}
+
+type Repository interface {
+	Create(receipts []*Receipt) error
+	ListByJobID(jobID uuid.UUID) ([]*Receipt, error)
+	// ListByOwnerID lists the receipts of the owner's nodes and ranags
+	// recorded from from until to.
+	ListByOwnerID(ownerID uuid.UUID, from, to time.Time) ([]*Receipt, error)
+}
+
+type Service interface {
+	// Record stores receipts that were verified.
+	Record(receipts []*Receipt) error
+	ListByJobID(jobID uuid.UUID) ([]*Receipt, error)
+	// Report adds up the work of each of the owner's nodes and ranags on the
+	// days from from to to, both included.
+	Report(ownerID uuid.UUID, from, to time.Time) ([]*Usage, error)
+}
+
+type Handler interface {
+	Report(c *gin.Context)
+}
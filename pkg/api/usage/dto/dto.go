@@ -0,0 +1,111 @@
+package dto
+
+import (
+	"juno/pkg/api/usage"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type Usage struct {
+	SignerType string `json:"signer_type"`
+	SignerID   string `json:"signer_id"`
+	Requests   int    `json:"requests"`
+	Shards     int    `json:"shards"`
+	Pages      int    `json:"pages"`
+	Rows       int    `json:"rows"`
+}
+
+type UsageReportResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	From  string  `json:"from,omitempty"`
+	To    string  `json:"to,omitempty"`
+	Usage []Usage `json:"usage"`
+}
+
+func NewSuccessUsageReportResponse(from, to time.Time, report []*usage.Usage) *UsageReportResponse {
+	res := &UsageReportResponse{
+		Status: SUCCESS,
+		From:   from.Format(time.DateOnly),
+		To:     to.Format(time.DateOnly),
+		Usage:  []Usage{},
+	}
+
+	for _, u := range report {
+		res.Usage = append(res.Usage, Usage{
+			SignerType: string(u.SignerType),
+			SignerID:   u.SignerID.String(),
+			Requests:   u.Requests,
+			Shards:     u.Shards,
+			Pages:      u.Pages,
+			Rows:       u.Rows,
+		})
+	}
+
+	return res
+}
+
+func NewErrorUsageReportResponse(message string) *UsageReportResponse {
+	return &UsageReportResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type Receipt struct {
+	ID         string    `json:"id"`
+	RequestID  string    `json:"request_id"`
+	SignerType string    `json:"signer_type"`
+	SignerID   string    `json:"signer_id"`
+	Shards     []int     `json:"shards"`
+	Pages      int       `json:"pages"`
+	Rows       int       `json:"rows"`
+	Signature  []byte    `json:"signature"`
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
+func NewReceiptFromDomain(r *usage.Receipt) Receipt {
+	return Receipt{
+		ID:         r.ID.String(),
+		RequestID:  r.RequestID,
+		SignerType: string(r.SignerType),
+		SignerID:   r.SignerID.String(),
+		Shards:     r.Shards,
+		Pages:      r.Pages,
+		Rows:       r.Rows,
+		Signature:  r.Signature,
+		IssuedAt:   r.IssuedAt,
+	}
+}
+
+type ListReceiptsResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Receipts []Receipt `json:"receipts"`
+}
+
+func NewSuccessListReceiptsResponse(receipts []*usage.Receipt) *ListReceiptsResponse {
+	res := &ListReceiptsResponse{
+		Status:   SUCCESS,
+		Receipts: []Receipt{},
+	}
+
+	for _, r := range receipts {
+		res.Receipts = append(res.Receipts, NewReceiptFromDomain(r))
+	}
+
+	return res
+}
+
+func NewErrorListReceiptsResponse(message string) *ListReceiptsResponse {
+	return &ListReceiptsResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"juno/pkg/api/auth"
+	"juno/pkg/api/usage"
+	"juno/pkg/api/usage/dto"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// reportDays is how many days a report covers when no range is given, and
+// maxReportDays how many can be asked for at once.
+const (
+	reportDays    = 30
+	maxReportDays = 366
+)
+
+type Handler struct {
+	logger       logrus.FieldLogger
+	usageService usage.Service
+}
+
+func New(logger logrus.FieldLogger, usageService usage.Service) *Handler {
+	return &Handler{
+		logger:       logger,
+		usageService: usageService,
+	}
+}
+
+// Report returns the work the user's nodes and ranags signed receipts for,
+// on the days from and to include, given as YYYY-MM-DD. The last 30 days are
+// reported by default.
+func (h *Handler) Report(c *gin.Context) {
+
+	u := auth.MustUserFromContext(c.Request.Context())
+
+	to := time.Now().UTC()
+
+	if param := c.Query("to"); param != "" {
+		t, err := time.Parse(time.DateOnly, param)
+		if err != nil {
+			c.JSON(400, dto.NewErrorUsageReportResponse("invalid to date"))
+			return
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -(reportDays - 1))
+
+	if param := c.Query("from"); param != "" {
+		t, err := time.Parse(time.DateOnly, param)
+		if err != nil {
+			c.JSON(400, dto.NewErrorUsageReportResponse("invalid from date"))
+			return
+		}
+		from = t
+	}
+
+	if from.After(to) || to.Sub(from) >= maxReportDays*24*time.Hour {
+		c.JSON(400, dto.NewErrorUsageReportResponse("invalid date range"))
+		return
+	}
+
+	report, err := h.usageService.Report(u.ID, from, to)
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to report usage")
+		c.JSON(500, dto.NewErrorUsageReportResponse(err.Error()))
+		return
+	}
+
+	c.JSON(200, dto.NewSuccessUsageReportResponse(from, to, report))
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"juno/pkg/api/auth"
+	"juno/pkg/api/usage"
+	"juno/pkg/api/usage/dto"
+	"juno/pkg/api/user"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type mockUsageService struct {
+	ownerID  uuid.UUID
+	from, to time.Time
+}
+
+func (m *mockUsageService) Record(receipts []*usage.Receipt) error {
+	return nil
+}
+
+func (m *mockUsageService) ListByJobID(jobID uuid.UUID) ([]*usage.Receipt, error) {
+	return nil, nil
+}
+
+func (m *mockUsageService) Report(ownerID uuid.UUID, from, to time.Time) ([]*usage.Usage, error) {
+	m.ownerID, m.from, m.to = ownerID, from, to
+
+	return []*usage.Usage{
+		{SignerType: usage.NodeSigner, SignerID: uuid.New(), Requests: 2, Shards: 2, Pages: 15, Rows: 3},
+	}, nil
+}
+
+func TestReport(t *testing.T) {
+	userID := uuid.New()
+
+	report := func(service *mockUsageService, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		tc, _ := gin.CreateTestContext(w)
+
+		tc.Request = httptest.NewRequestWithContext(
+			auth.WithUser(context.Background(), &user.User{ID: userID}),
+			"GET",
+			"/usage"+query,
+			nil,
+		)
+
+		New(logrus.New(), service).Report(tc)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		service := &mockUsageService{}
+
+		w := report(service, "?from=2026-10-01&to=2026-10-19")
+
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		var res dto.UsageReportResponse
+
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if service.ownerID != userID {
+			t.Errorf("Expected the user's usage, got %s", service.ownerID)
+		}
+
+		if res.From != "2026-10-01" || res.To != "2026-10-19" || len(res.Usage) != 1 || res.Usage[0].Pages != 15 {
+			t.Errorf("Expected the usage of one node, got %+v", res)
+		}
+	})
+
+	t.Run("last 30 days by default", func(t *testing.T) {
+		service := &mockUsageService{}
+
+		if w := report(service, ""); w.Code != 200 {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		if days := service.to.Sub(service.from).Hours() / 24; days != 29 {
+			t.Errorf("Expected 30 days, got %v", days+1)
+		}
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		for _, query := range []string{"?to=tomorrow", "?from=2026-10-19&to=2026-10-01", "?from=2024-01-01&to=2026-01-01"} {
+			if w := report(&mockUsageService{}, query); w.Code != 400 {
+				t.Errorf("Expected 400 for %s, got %d", query, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import "database/sql"
+
+var migrations = map[string]string{
+	"create_receipts_table": `
+		CREATE TABLE IF NOT EXISTS receipts (
+			id VARCHAR(36) PRIMARY KEY,
+			job_id VARCHAR(36) NOT NULL,
+			request_id VARCHAR(36) NOT NULL,
+			signer_type VARCHAR(16) NOT NULL,
+			signer_id VARCHAR(36) NOT NULL,
+			owner_id VARCHAR(36) NOT NULL,
+			shards MEDIUMTEXT NOT NULL,
+			pages INT NOT NULL,
+			row_count INT NOT NULL,
+			signature VARBINARY(64) NOT NULL,
+			issued_at TIMESTAMP(6) NOT NULL,
+			created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX (job_id),
+			INDEX (owner_id, created_at)
+		);`,
+}
+
+func ExecuteMigrations(db *sql.DB) error {
+
+	// create migrations table if it doesn't exist
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+			name VARCHAR(255) NOT NULL PRIMARY KEY
+		);`); err != nil {
+		return err
+	}
+
+	for name, migration := range migrations {
+
+		// check if migration has already been executed
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = ?", name).Scan(&count); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(migration); err != nil {
+			return err
+		}
+
+		db.Exec("INSERT INTO migrations (name) VALUES (?)", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package mem
+
+import (
+	"errors"
+	"juno/pkg/api/usage"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository struct {
+	mu       sync.Mutex
+	receipts map[uuid.UUID]usage.Receipt
+}
+
+func New() *Repository {
+	return &Repository{receipts: make(map[uuid.UUID]usage.Receipt)}
+}
+
+func (r *Repository) Create(receipts []*usage.Receipt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rc := range receipts {
+		if _, ok := r.receipts[rc.ID]; ok {
+			return errors.New("primary key violation")
+		}
+	}
+
+	for _, rc := range receipts {
+		r.receipts[rc.ID] = *rc
+	}
+
+	return nil
+}
+
+func (r *Repository) ListByJobID(jobID uuid.UUID) ([]*usage.Receipt, error) {
+	return r.list(func(rc *usage.Receipt) bool { return rc.JobID == jobID }), nil
+}
+
+func (r *Repository) ListByOwnerID(ownerID uuid.UUID, from, to time.Time) ([]*usage.Receipt, error) {
+	return r.list(func(rc *usage.Receipt) bool {
+		return rc.OwnerID == ownerID && !rc.CreatedAt.Before(from) && rc.CreatedAt.Before(to)
+	}), nil
+}
+
+// list returns the receipts matching, oldest first.
+func (r *Repository) list(match func(rc *usage.Receipt) bool) []*usage.Receipt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var receipts []*usage.Receipt
+
+	for _, rc := range r.receipts {
+		if match(&rc) {
+			receipts = append(receipts, &rc)
+		}
+	}
+
+	sort.Slice(receipts, func(i, j int) bool {
+		return receipts[i].CreatedAt.Before(receipts[j].CreatedAt)
+	})
+
+	return receipts
+}
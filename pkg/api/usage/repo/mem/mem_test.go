@@ -0,0 +1,94 @@
+package mem
+
+import (
+	"juno/pkg/api/usage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newReceipt(jobID, ownerID uuid.UUID, createdAt time.Time) *usage.Receipt {
+	return &usage.Receipt{
+		ID:         uuid.New(),
+		JobID:      jobID,
+		RequestID:  uuid.NewString(),
+		SignerType: usage.NodeSigner,
+		SignerID:   uuid.New(),
+		OwnerID:    ownerID,
+		Shards:     []int{1},
+		Pages:      10,
+		Rows:       3,
+		CreatedAt:  createdAt,
+	}
+}
+
+func TestCreate(t *testing.T) {
+	repo := New()
+
+	r := newReceipt(uuid.New(), uuid.New(), time.Now())
+
+	if err := repo.Create([]*usage.Receipt{r}); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	// none of the receipts is stored when one of them already is
+	other := newReceipt(r.JobID, r.OwnerID, time.Now())
+
+	if err := repo.Create([]*usage.Receipt{other, r}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	if receipts, _ := repo.ListByJobID(r.JobID); len(receipts) != 1 {
+		t.Errorf("Expected 1 receipt, got %d", len(receipts))
+	}
+}
+
+func TestListByJobID(t *testing.T) {
+	repo := New()
+
+	jobID := uuid.New()
+	now := time.Now()
+
+	first := newReceipt(jobID, uuid.New(), now.Add(-time.Minute))
+	second := newReceipt(jobID, uuid.New(), now)
+
+	repo.Create([]*usage.Receipt{second, first, newReceipt(uuid.New(), uuid.New(), now)})
+
+	receipts, err := repo.ListByJobID(jobID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(receipts) != 2 || receipts[0].ID != first.ID || receipts[1].ID != second.ID {
+		t.Errorf("Expected the job's receipts oldest first, got %v", receipts)
+	}
+}
+
+func TestListByOwnerID(t *testing.T) {
+	repo := New()
+
+	ownerID := uuid.New()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	inside := newReceipt(uuid.New(), ownerID, from)
+
+	repo.Create([]*usage.Receipt{
+		inside,
+		newReceipt(uuid.New(), ownerID, to),
+		newReceipt(uuid.New(), ownerID, from.Add(-time.Second)),
+		newReceipt(uuid.New(), uuid.New(), from),
+	})
+
+	receipts, err := repo.ListByOwnerID(ownerID, from, to)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(receipts) != 1 || receipts[0].ID != inside.ID {
+		t.Errorf("Expected the owner's receipt of the day, got %v", receipts)
+	}
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"juno/pkg/api/usage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const selectReceipts = "SELECT id, job_id, request_id, signer_type, signer_id, owner_id, shards, pages, row_count, signature, issued_at, created_at FROM receipts"
+
+type Repository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create stores the receipts in one transaction, so a job's answer is
+// recorded whole or not at all.
+func (r *Repository) Create(receipts []*usage.Receipt) error {
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for _, rc := range receipts {
+		shards, err := json.Marshal(rc.Shards)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO receipts (id, job_id, request_id, signer_type, signer_id, owner_id, shards, pages, row_count, signature, issued_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			rc.ID, rc.JobID, rc.RequestID, rc.SignerType, rc.SignerID, rc.OwnerID, string(shards), rc.Pages, rc.Rows, rc.Signature, rc.IssuedAt, rc.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) ListByJobID(jobID uuid.UUID) ([]*usage.Receipt, error) {
+	return r.list(selectReceipts+" WHERE job_id = ? ORDER BY created_at", jobID)
+}
+
+func (r *Repository) ListByOwnerID(ownerID uuid.UUID, from, to time.Time) ([]*usage.Receipt, error) {
+	return r.list(selectReceipts+" WHERE owner_id = ? AND created_at >= ? AND created_at < ? ORDER BY created_at", ownerID, from, to)
+}
+
+func (r *Repository) list(query string, args ...any) ([]*usage.Receipt, error) {
+	rows, err := r.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var receipts []*usage.Receipt
+
+	for rows.Next() {
+		var (
+			rc     usage.Receipt
+			shards string
+		)
+
+		if err := rows.Scan(&rc.ID, &rc.JobID, &rc.RequestID, &rc.SignerType, &rc.SignerID, &rc.OwnerID, &shards, &rc.Pages, &rc.Rows, &rc.Signature, &rc.IssuedAt, &rc.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(shards), &rc.Shards); err != nil {
+			return nil, err
+		}
+
+		receipts = append(receipts, &rc)
+	}
+
+	return receipts, rows.Err()
+}
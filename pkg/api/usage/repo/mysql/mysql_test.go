@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"database/sql"
+	"juno/pkg/api/usage"
+	"juno/pkg/api/usage/migration/mysql"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("mysql", "root:juno@tcp(localhost:3306)/usage_test?parseTime=true")
+
+	if err != nil {
+		t.Fatalf("could not connect to mysql: %v", err)
+	}
+
+	if err := mysql.ExecuteMigrations(db); err != nil {
+		t.Fatalf("could not execute migrations: %v", err)
+	}
+
+	return db
+}
+
+func newReceipt(jobID, ownerID uuid.UUID, createdAt time.Time) *usage.Receipt {
+	return &usage.Receipt{
+		ID:         uuid.New(),
+		JobID:      jobID,
+		RequestID:  uuid.NewString(),
+		SignerType: usage.NodeSigner,
+		SignerID:   uuid.New(),
+		OwnerID:    ownerID,
+		Shards:     []int{4, 5},
+		Pages:      10,
+		Rows:       3,
+		Signature:  []byte{1, 2, 3},
+		IssuedAt:   createdAt,
+		CreatedAt:  createdAt,
+	}
+}
+
+func TestListByJobID(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	jobID := uuid.New()
+
+	defer db.Exec("DELETE FROM receipts WHERE job_id = ?", jobID)
+
+	r := newReceipt(jobID, uuid.New(), time.Now().UTC())
+
+	if err := repo.Create([]*usage.Receipt{r}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	receipts, err := repo.ListByJobID(jobID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(receipts) != 1 {
+		t.Fatalf("Expected 1 receipt, got %d", len(receipts))
+	}
+
+	check := receipts[0]
+
+	if check.ID != r.ID || check.SignerType != usage.NodeSigner || len(check.Shards) != 2 || check.Rows != 3 || string(check.Signature) != string(r.Signature) {
+		t.Errorf("Expected %+v, got %+v", r, check)
+	}
+}
+
+func TestListByOwnerID(t *testing.T) {
+	db := newTestDB(t)
+	repo := New(db)
+
+	ownerID := uuid.New()
+
+	defer db.Exec("DELETE FROM receipts WHERE owner_id = ?", ownerID)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	inside := newReceipt(uuid.New(), ownerID, from)
+
+	if err := repo.Create([]*usage.Receipt{inside, newReceipt(uuid.New(), ownerID, from.AddDate(0, 0, 1))}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	receipts, err := repo.ListByOwnerID(ownerID, from, from.AddDate(0, 0, 1))
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(receipts) != 1 || receipts[0].ID != inside.ID {
+		t.Errorf("Expected the owner's receipt of the day, got %v", receipts)
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"juno/pkg/api/usage"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo usage.Repository
+	now  func() time.Time
+}
+
+func WithClock(now func() time.Time) func(s *Service) {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func New(repo usage.Repository, opts ...func(s *Service)) *Service {
+	s := &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Record stamps the receipts with when they were recorded, which reports go
+// by rather than the time their signers claim.
+func (s *Service) Record(receipts []*usage.Receipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	now := s.now().UTC()
+
+	for _, r := range receipts {
+		if r.ID == uuid.Nil {
+			r.ID = uuid.New()
+		}
+
+		r.CreatedAt = now
+	}
+
+	return s.repo.Create(receipts)
+}
+
+func (s *Service) ListByJobID(jobID uuid.UUID) ([]*usage.Receipt, error) {
+	return s.repo.ListByJobID(jobID)
+}
+
+func (s *Service) Report(ownerID uuid.UUID, from, to time.Time) ([]*usage.Usage, error) {
+	receipts, err := s.repo.ListByOwnerID(ownerID, day(from), day(to).AddDate(0, 0, 1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		signerType usage.SignerType
+		signerID   uuid.UUID
+	}
+
+	byKey := map[key]*usage.Usage{}
+	report := []*usage.Usage{}
+
+	for _, r := range receipts {
+		k := key{r.SignerType, r.SignerID}
+
+		u, ok := byKey[k]
+
+		if !ok {
+			u = &usage.Usage{SignerType: r.SignerType, SignerID: r.SignerID}
+			byKey[k] = u
+			report = append(report, u)
+		}
+
+		u.Requests++
+		u.Shards += len(r.Shards)
+		u.Pages += r.Pages
+		u.Rows += r.Rows
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].SignerType != report[j].SignerType {
+			return report[i].SignerType < report[j].SignerType
+		}
+
+		return report[i].SignerID.String() < report[j].SignerID.String()
+	})
+
+	return report, nil
+}
+
+// day is the start of the UTC day of t.
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
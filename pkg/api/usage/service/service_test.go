@@ -0,0 +1,86 @@
+package service
+
+import (
+	"juno/pkg/api/usage"
+	"juno/pkg/api/usage/repo/mem"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := New(mem.New(), WithClock(func() time.Time { return now }))
+
+	jobID := uuid.New()
+
+	if err := s.Record([]*usage.Receipt{{JobID: jobID, IssuedAt: now.Add(time.Hour)}}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	receipts, err := s.ListByJobID(jobID)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(receipts) != 1 || receipts[0].ID == uuid.Nil || !receipts[0].CreatedAt.Equal(now) {
+		t.Errorf("Expected the receipt stamped with when it was recorded, got %+v", receipts)
+	}
+
+	if err := s.Record(nil); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := New(mem.New(), WithClock(func() time.Time { return now }))
+
+	ownerID := uuid.New()
+	node := uuid.New()
+	ranag := uuid.New()
+
+	s.Record([]*usage.Receipt{
+		{OwnerID: ownerID, SignerType: usage.NodeSigner, SignerID: node, Shards: []int{1}, Pages: 10, Rows: 2},
+		{OwnerID: ownerID, SignerType: usage.NodeSigner, SignerID: node, Shards: []int{2}, Pages: 5, Rows: 1},
+		{OwnerID: ownerID, SignerType: usage.RanagSigner, SignerID: ranag, Shards: []int{1, 2}, Pages: 15, Rows: 3},
+		{OwnerID: uuid.New(), SignerType: usage.NodeSigner, SignerID: uuid.New(), Shards: []int{3}, Pages: 7},
+	})
+
+	t.Run("adds up the work of every node and ranag", func(t *testing.T) {
+		report, err := s.Report(ownerID, now, now)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(report) != 2 {
+			t.Fatalf("Expected 2, got %d", len(report))
+		}
+
+		expected := []usage.Usage{
+			{SignerType: usage.NodeSigner, SignerID: node, Requests: 2, Shards: 2, Pages: 15, Rows: 3},
+			{SignerType: usage.RanagSigner, SignerID: ranag, Requests: 1, Shards: 2, Pages: 15, Rows: 3},
+		}
+
+		for i, e := range expected {
+			if *report[i] != e {
+				t.Errorf("Expected %+v, got %+v", e, *report[i])
+			}
+		}
+	})
+
+	t.Run("leaves out other days", func(t *testing.T) {
+		report, err := s.Report(ownerID, now.AddDate(0, 0, -3), now.AddDate(0, 0, -1))
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(report) != 0 {
+			t.Errorf("Expected nothing, got %v", report)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPeekSize     = 10
+	MaxPeekSize         = 1000
+	DefaultOutcomeLimit = 20
+)
+
+var ErrInvalidCrawlInterval = errors.New("crawl interval must be a positive duration")
+
+// Handler exposes the operator endpoints of a balancer. Every route is
+// behind the admin token.
+type Handler interface {
+	Queue(c *gin.Context)
+	Peek(c *gin.Context)
+	PurgeHost(c *gin.Context)
+	Pause(c *gin.Context)
+	Resume(c *gin.Context)
+	Policy(c *gin.Context)
+	UpdatePolicy(c *gin.Context)
+	Outcomes(c *gin.Context)
+}
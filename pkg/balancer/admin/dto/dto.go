@@ -0,0 +1,152 @@
+package dto
+
+import (
+	"juno/pkg/balancer/outcome"
+	"juno/pkg/balancer/policy"
+	"juno/pkg/balancer/queue"
+	"time"
+)
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type QueueResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Paused bool         `json:"paused"`
+	Queue  *queue.Stats `json:"queue,omitempty"`
+}
+
+func NewSuccessQueueResponse(stats *queue.Stats, paused bool) QueueResponse {
+	return QueueResponse{
+		Status: SUCCESS,
+		Paused: paused,
+		Queue:  stats,
+	}
+}
+
+func NewErrorQueueResponse(message string) QueueResponse {
+	return QueueResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type PeekResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Items []*queue.Item `json:"items"`
+}
+
+func NewSuccessPeekResponse(items []*queue.Item) PeekResponse {
+	return PeekResponse{
+		Status: SUCCESS,
+		Items:  items,
+	}
+}
+
+func NewErrorPeekResponse(message string) PeekResponse {
+	return PeekResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type PurgeResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Hostname string `json:"hostname,omitempty"`
+	Purged   int    `json:"purged"`
+}
+
+func NewSuccessPurgeResponse(hostname string, purged int) PurgeResponse {
+	return PurgeResponse{
+		Status:   SUCCESS,
+		Hostname: hostname,
+		Purged:   purged,
+	}
+}
+
+func NewErrorPurgeResponse(message string) PurgeResponse {
+	return PurgeResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type ProcessingResponse struct {
+	Status string `json:"status"`
+	Paused bool   `json:"paused"`
+}
+
+func NewSuccessProcessingResponse(paused bool) ProcessingResponse {
+	return ProcessingResponse{
+		Status: SUCCESS,
+		Paused: paused,
+	}
+}
+
+type Policy struct {
+	Hostname         string    `json:"hostname"`
+	CrawlInterval    string    `json:"crawl_interval"`
+	IntervalOverride bool      `json:"interval_override"`
+	Blocked          bool      `json:"blocked"`
+	LastCrawled      time.Time `json:"last_crawled"`
+	TimesCrawled     int       `json:"times_crawled"`
+}
+
+func NewPolicyFromDomain(p *policy.CrawlPolicy) *Policy {
+	return &Policy{
+		Hostname:         p.Hostname,
+		CrawlInterval:    p.CrawlInterval.String(),
+		IntervalOverride: p.IntervalOverride,
+		Blocked:          p.Blocked,
+		LastCrawled:      p.LastCrawled,
+		TimesCrawled:     p.TimesCrawled,
+	}
+}
+
+// UpdatePolicyRequest overrides the fields that are set. An empty
+// crawl_interval drops the override and restores the default interval.
+type UpdatePolicyRequest struct {
+	CrawlInterval *string `json:"crawl_interval"`
+	Blocked       *bool   `json:"blocked"`
+}
+
+type PolicyResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Policy *Policy `json:"policy,omitempty"`
+}
+
+func NewSuccessPolicyResponse(p *policy.CrawlPolicy) PolicyResponse {
+	return PolicyResponse{
+		Status: SUCCESS,
+		Policy: NewPolicyFromDomain(p),
+	}
+}
+
+func NewErrorPolicyResponse(message string) PolicyResponse {
+	return PolicyResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type OutcomesResponse struct {
+	Status   string             `json:"status"`
+	Outcomes []*outcome.Outcome `json:"outcomes"`
+}
+
+func NewSuccessOutcomesResponse(outcomes []*outcome.Outcome) OutcomesResponse {
+	return OutcomesResponse{
+		Status:   SUCCESS,
+		Outcomes: outcomes,
+	}
+}
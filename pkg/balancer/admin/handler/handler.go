@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"juno/pkg/balancer/admin"
+	"juno/pkg/balancer/admin/dto"
+	"juno/pkg/balancer/crawl"
+	"juno/pkg/balancer/outcome"
+	"juno/pkg/balancer/policy"
+	"juno/pkg/balancer/queue"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger         *logrus.Logger
+	queueService   queue.Service
+	policyService  policy.Service
+	crawlService   crawl.Service
+	outcomeService outcome.Service
+}
+
+func New(
+	logger *logrus.Logger,
+	queueService queue.Service,
+	policyService policy.Service,
+	crawlService crawl.Service,
+	outcomeService outcome.Service,
+) *Handler {
+	return &Handler{
+		logger:         logger,
+		queueService:   queueService,
+		policyService:  policyService,
+		crawlService:   crawlService,
+		outcomeService: outcomeService,
+	}
+}
+
+func (h *Handler) Queue(c *gin.Context) {
+	stats, err := h.queueService.Stats()
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get queue stats")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorQueueResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessQueueResponse(stats, h.crawlService.Paused()))
+}
+
+func (h *Handler) Peek(c *gin.Context) {
+	n, err := strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(admin.DefaultPeekSize)))
+
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, dto.NewErrorPeekResponse("n must be a positive number"))
+		return
+	}
+
+	items, err := h.queueService.Peek(min(n, admin.MaxPeekSize))
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to peek queue")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorPeekResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessPeekResponse(items))
+}
+
+func (h *Handler) PurgeHost(c *gin.Context) {
+	hostname := strings.ToLower(c.Param("hostname"))
+
+	purged, err := h.queueService.PurgeHost(hostname)
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to purge host")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorPurgeResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessPurgeResponse(hostname, purged))
+}
+
+func (h *Handler) Pause(c *gin.Context) {
+	h.crawlService.Pause()
+	h.logger.Info("queue processing paused")
+
+	c.JSON(http.StatusOK, dto.NewSuccessProcessingResponse(true))
+}
+
+func (h *Handler) Resume(c *gin.Context) {
+	h.crawlService.Resume()
+	h.logger.Info("queue processing resumed")
+
+	c.JSON(http.StatusOK, dto.NewSuccessProcessingResponse(false))
+}
+
+func (h *Handler) Policy(c *gin.Context) {
+	pol, err := h.policy(strings.ToLower(c.Param("hostname")))
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get policy")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorPolicyResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessPolicyResponse(pol))
+}
+
+func (h *Handler) UpdatePolicy(c *gin.Context) {
+	var req dto.UpdatePolicyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorPolicyResponse(err.Error()))
+		return
+	}
+
+	hostname := strings.ToLower(c.Param("hostname"))
+
+	pol, err := h.policy(hostname)
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to get policy")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorPolicyResponse(err.Error()))
+		return
+	}
+
+	if req.CrawlInterval != nil {
+		if *req.CrawlInterval == "" {
+			pol.CrawlInterval = policy.DefaultCrawlInterval
+			pol.IntervalOverride = false
+		} else {
+			interval, err := time.ParseDuration(*req.CrawlInterval)
+
+			if err != nil || interval <= 0 {
+				c.JSON(http.StatusBadRequest, dto.NewErrorPolicyResponse(admin.ErrInvalidCrawlInterval.Error()))
+				return
+			}
+
+			pol.CrawlInterval = interval
+			pol.IntervalOverride = true
+		}
+	}
+
+	if req.Blocked != nil {
+		pol.Blocked = *req.Blocked
+	}
+
+	if err := h.policyService.Set(hostname, pol); err != nil {
+		h.logger.WithError(err).Error("failed to set policy")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorPolicyResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessPolicyResponse(pol))
+}
+
+func (h *Handler) Outcomes(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(admin.DefaultOutcomeLimit)))
+
+	if err != nil || limit < 1 {
+		limit = admin.DefaultOutcomeLimit
+	}
+
+	outcomes := h.outcomeService.Recent(strings.ToLower(c.Param("hostname")), limit)
+
+	c.JSON(http.StatusOK, dto.NewSuccessOutcomesResponse(outcomes))
+}
+
+// policy returns the stored policy of a host, or the default one if the host
+// has not been crawled yet.
+func (h *Handler) policy(hostname string) (*policy.CrawlPolicy, error) {
+	pol, err := h.policyService.Get(hostname)
+
+	if err == policy.ErrPolicyNotFound {
+		return policy.New(hostname), nil
+	}
+
+	return pol, err
+}
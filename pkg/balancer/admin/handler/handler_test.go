@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"juno/pkg/balancer/admin/dto"
+	"juno/pkg/balancer/outcome"
+	"juno/pkg/balancer/policy"
+	"juno/pkg/balancer/queue"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	crawlService "juno/pkg/balancer/crawl/service"
+	outcomeService "juno/pkg/balancer/outcome/service"
+	polRepo "juno/pkg/balancer/policy/repo/mem"
+	polService "juno/pkg/balancer/policy/service"
+	queueRepo "juno/pkg/balancer/queue/repo/mem"
+	queueService "juno/pkg/balancer/queue/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type fixture struct {
+	handler   *Handler
+	queueRepo *queueRepo.Repository
+	polSvc    *polService.Service
+	crawlSvc  *crawlService.Service
+	outcomes  *outcomeService.Service
+}
+
+func newFixture() *fixture {
+	logger := logrus.New()
+	f := &fixture{
+		queueRepo: queueRepo.New(),
+		polSvc:    polService.New(polRepo.New()),
+		outcomes:  outcomeService.New(outcome.DefaultHistory, outcome.DefaultMaxHosts),
+	}
+	f.crawlSvc = crawlService.New(crawlService.WithLogger(logger))
+	f.handler = New(logger, queueService.New(logger, f.queueRepo), f.polSvc, f.crawlSvc, f.outcomes)
+	return f
+}
+
+func newContext(method, target, body string, params ...gin.Param) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	return c, w
+}
+
+func TestQueue(t *testing.T) {
+	t.Run("should return depth by state and host", func(t *testing.T) {
+		f := newFixture()
+		f.queueRepo.Push("http://example.com/a")
+		f.queueRepo.Push("http://example.com/b")
+		f.queueRepo.Lease(time.Minute, queue.DefaultMaxAttempts)
+		f.crawlSvc.Pause()
+
+		c, w := newContext(http.MethodGet, "/admin/queue", "")
+
+		// When
+		f.handler.Queue(c)
+
+		// Then
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200 but got %d", w.Code)
+		}
+
+		var res dto.QueueResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if !res.Paused {
+			t.Errorf("expected paused to be reported")
+		}
+
+		if res.Queue.Ready != 1 || res.Queue.Leased != 1 {
+			t.Errorf("expected 1 ready and 1 leased but got %+v", res.Queue)
+		}
+
+		if res.Queue.Hosts["example.com"] != 2 {
+			t.Errorf("expected 2 urls for example.com but got %d", res.Queue.Hosts["example.com"])
+		}
+	})
+}
+
+func TestPeek(t *testing.T) {
+	t.Run("should return the next n urls", func(t *testing.T) {
+		f := newFixture()
+		f.queueRepo.Push("http://example.com/a")
+		f.queueRepo.Push("http://example.com/b")
+
+		c, w := newContext(http.MethodGet, "/admin/queue/peek?n=1", "")
+
+		// When
+		f.handler.Peek(c)
+
+		// Then
+		var res dto.PeekResponse
+		json.Unmarshal(w.Body.Bytes(), &res)
+
+		if len(res.Items) != 1 || res.Items[0].URL != "http://example.com/a" {
+			t.Errorf("expected only http://example.com/a but got %+v", res.Items)
+		}
+	})
+
+	t.Run("should reject invalid n", func(t *testing.T) {
+		f := newFixture()
+
+		c, w := newContext(http.MethodGet, "/admin/queue/peek?n=abc", "")
+
+		// When
+		f.handler.Peek(c)
+
+		// Then
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 but got %d", w.Code)
+		}
+	})
+}
+
+func TestPurgeHost(t *testing.T) {
+	t.Run("should purge queued urls of the host", func(t *testing.T) {
+		f := newFixture()
+		f.queueRepo.Push("http://example.com/a")
+		f.queueRepo.Push("http://other.com")
+
+		c, w := newContext(http.MethodDelete, "/admin/hosts/Example.com/queue", "", gin.Param{Key: "hostname", Value: "Example.com"})
+
+		// When
+		f.handler.PurgeHost(c)
+
+		// Then
+		var res dto.PurgeResponse
+		json.Unmarshal(w.Body.Bytes(), &res)
+
+		if res.Purged != 1 || res.Hostname != "example.com" {
+			t.Errorf("expected 1 url of example.com to be purged but got %+v", res)
+		}
+	})
+}
+
+func TestPauseResume(t *testing.T) {
+	t.Run("should pause and resume queue processing", func(t *testing.T) {
+		f := newFixture()
+
+		c, _ := newContext(http.MethodPost, "/admin/processing/pause", "")
+		f.handler.Pause(c)
+
+		if !f.crawlSvc.Paused() {
+			t.Errorf("expected processing to be paused")
+		}
+
+		c, _ = newContext(http.MethodPost, "/admin/processing/resume", "")
+		f.handler.Resume(c)
+
+		if f.crawlSvc.Paused() {
+			t.Errorf("expected processing to be resumed")
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	t.Run("should return the default policy of unknown hosts", func(t *testing.T) {
+		f := newFixture()
+
+		c, w := newContext(http.MethodGet, "/admin/hosts/example.com/policy", "", gin.Param{Key: "hostname", Value: "example.com"})
+
+		// When
+		f.handler.Policy(c)
+
+		// Then
+		var res dto.PolicyResponse
+		json.Unmarshal(w.Body.Bytes(), &res)
+
+		if res.Policy == nil || res.Policy.CrawlInterval != policy.DefaultCrawlInterval.String() {
+			t.Errorf("expected the default policy but got %+v", res.Policy)
+		}
+	})
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	t.Run("should override interval and block the host", func(t *testing.T) {
+		f := newFixture()
+
+		c, w := newContext(http.MethodPut, "/admin/hosts/example.com/policy", `{"crawl_interval": "2m", "blocked": true}`, gin.Param{Key: "hostname", Value: "example.com"})
+
+		// When
+		f.handler.UpdatePolicy(c)
+
+		// Then
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200 but got %d", w.Code)
+		}
+
+		pol, err := f.polSvc.Get("example.com")
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if pol.CrawlInterval != 2*time.Minute || !pol.IntervalOverride || !pol.Blocked {
+			t.Errorf("expected 2m override and blocked but got %+v", pol)
+		}
+	})
+
+	t.Run("should restore the default interval", func(t *testing.T) {
+		f := newFixture()
+		f.polSvc.Set("example.com", &policy.CrawlPolicy{
+			Hostname:         "example.com",
+			CrawlInterval:    time.Hour,
+			IntervalOverride: true,
+			Blocked:          true,
+		})
+
+		c, _ := newContext(http.MethodPut, "/admin/hosts/example.com/policy", `{"crawl_interval": ""}`, gin.Param{Key: "hostname", Value: "example.com"})
+
+		// When
+		f.handler.UpdatePolicy(c)
+
+		// Then
+		pol, _ := f.polSvc.Get("example.com")
+
+		if pol.CrawlInterval != policy.DefaultCrawlInterval || pol.IntervalOverride {
+			t.Errorf("expected the default interval but got %+v", pol)
+		}
+
+		if !pol.Blocked {
+			t.Errorf("expected blocked to be unchanged")
+		}
+	})
+
+	t.Run("should reject invalid intervals", func(t *testing.T) {
+		f := newFixture()
+
+		c, w := newContext(http.MethodPut, "/admin/hosts/example.com/policy", `{"crawl_interval": "-1s"}`, gin.Param{Key: "hostname", Value: "example.com"})
+
+		// When
+		f.handler.UpdatePolicy(c)
+
+		// Then
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 but got %d", w.Code)
+		}
+	})
+}
+
+func TestOutcomes(t *testing.T) {
+	t.Run("should return recent outcomes of the host", func(t *testing.T) {
+		f := newFixture()
+		f.outcomes.Record(&outcome.Outcome{URL: "http://example.com/a", Hostname: "example.com", Status: outcome.StatusFailed, Error: "too many tries"})
+		f.outcomes.Record(&outcome.Outcome{URL: "http://example.com/b", Hostname: "example.com", Status: outcome.StatusCrawled})
+
+		c, w := newContext(http.MethodGet, "/admin/hosts/example.com/outcomes?limit=1", "", gin.Param{Key: "hostname", Value: "example.com"})
+
+		// When
+		f.handler.Outcomes(c)
+
+		// Then
+		var res dto.OutcomesResponse
+		json.Unmarshal(w.Body.Bytes(), &res)
+
+		if len(res.Outcomes) != 1 || res.Outcomes[0].URL != "http://example.com/b" {
+			t.Errorf("expected only the latest outcome but got %+v", res.Outcomes)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"juno/pkg/balancer/admin/dto"
+	queueDto "juno/pkg/balancer/queue/dto"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// AdminClient talks to the token protected /admin endpoints of a balancer.
+type AdminClient struct {
+	baseURL string
+	token   string
+}
+
+func NewAdmin(baseURL, token string) *AdminClient {
+	return &AdminClient{baseURL: baseURL, token: token}
+}
+
+func (c *AdminClient) Queue() (*dto.QueueResponse, error) {
+	var res dto.QueueResponse
+	return &res, c.do(http.MethodGet, "/admin/queue", nil, &res)
+}
+
+func (c *AdminClient) Peek(n int) (*dto.PeekResponse, error) {
+	var res dto.PeekResponse
+	return &res, c.do(http.MethodGet, "/admin/queue/peek?n="+strconv.Itoa(n), nil, &res)
+}
+
+func (c *AdminClient) PurgeHost(hostname string) (*dto.PurgeResponse, error) {
+	var res dto.PurgeResponse
+	return &res, c.do(http.MethodDelete, "/admin/hosts/"+url.PathEscape(hostname)+"/queue", nil, &res)
+}
+
+func (c *AdminClient) Pause() (*dto.ProcessingResponse, error) {
+	var res dto.ProcessingResponse
+	return &res, c.do(http.MethodPost, "/admin/processing/pause", nil, &res)
+}
+
+func (c *AdminClient) Resume() (*dto.ProcessingResponse, error) {
+	var res dto.ProcessingResponse
+	return &res, c.do(http.MethodPost, "/admin/processing/resume", nil, &res)
+}
+
+func (c *AdminClient) Policy(hostname string) (*dto.PolicyResponse, error) {
+	var res dto.PolicyResponse
+	return &res, c.do(http.MethodGet, "/admin/hosts/"+url.PathEscape(hostname)+"/policy", nil, &res)
+}
+
+func (c *AdminClient) UpdatePolicy(hostname string, req dto.UpdatePolicyRequest) (*dto.PolicyResponse, error) {
+	var res dto.PolicyResponse
+	return &res, c.do(http.MethodPut, "/admin/hosts/"+url.PathEscape(hostname)+"/policy", req, &res)
+}
+
+func (c *AdminClient) Outcomes(hostname string, limit int) (*dto.OutcomesResponse, error) {
+	var res dto.OutcomesResponse
+	return &res, c.do(http.MethodGet, "/admin/hosts/"+url.PathEscape(hostname)+"/outcomes?limit="+strconv.Itoa(limit), nil, &res)
+}
+
+func (c *AdminClient) DeadLetters() (*queueDto.DeadLettersResponse, error) {
+	var res queueDto.DeadLettersResponse
+	return &res, c.do(http.MethodGet, "/admin/dead-letters", nil, &res)
+}
+
+func (c *AdminClient) Replay(urls []string) (*queueDto.ReplayResponse, error) {
+	var res queueDto.ReplayResponse
+	return &res, c.do(http.MethodPost, "/admin/dead-letters/replay", queueDto.ReplayRequest{URLs: urls}, &res)
+}
+
+func (c *AdminClient) do(method, path string, body any, out any) error {
+	var reader io.Reader
+
+	if body != nil {
+		jsonB, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewBuffer(jsonB)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s failed with %s: %s", method, path, resp.Status, bytes.TrimSpace(data))
+	}
+
+	return json.Unmarshal(data, out)
+}
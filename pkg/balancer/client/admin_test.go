@@ -0,0 +1,86 @@
+package client
+
+import (
+	"juno/pkg/balancer/admin/dto"
+	"testing"
+
+	"github.com/h2non/gock"
+)
+
+func TestAdminQueue(t *testing.T) {
+	t.Run("should send the admin token", func(t *testing.T) {
+		defer gock.Off()
+
+		baseURL := "http://localhost:7070"
+
+		gock.New(baseURL).
+			Get("/admin/queue").
+			MatchHeader("Authorization", "^Bearer secret$").
+			Reply(200).
+			JSON(map[string]any{"status": "success", "paused": true, "queue": map[string]any{"ready": 3}})
+
+		client := NewAdmin(baseURL, "secret")
+
+		res, err := client.Queue()
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if !res.Paused || res.Queue.Ready != 3 {
+			t.Errorf("Unexpected response: %+v", res)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("should return error when unauthorized", func(t *testing.T) {
+		defer gock.Off()
+
+		baseURL := "http://localhost:7070"
+
+		gock.New(baseURL).
+			Get("/admin/queue").
+			Reply(401).
+			JSON(map[string]string{"error": "Invalid token"})
+
+		client := NewAdmin(baseURL, "wrong")
+
+		if _, err := client.Queue(); err == nil {
+			t.Errorf("Expected an error")
+		}
+	})
+}
+
+func TestAdminUpdatePolicy(t *testing.T) {
+	t.Run("should send the policy override", func(t *testing.T) {
+		defer gock.Off()
+
+		baseURL := "http://localhost:7070"
+
+		gock.New(baseURL).
+			Put("/admin/hosts/example.com/policy").
+			JSON(map[string]any{"crawl_interval": "1m", "blocked": nil}).
+			Reply(200).
+			JSON(map[string]any{"status": "success", "policy": map[string]any{"hostname": "example.com", "crawl_interval": "1m0s"}})
+
+		client := NewAdmin(baseURL, "secret")
+
+		interval := "1m"
+		res, err := client.UpdatePolicy("example.com", dto.UpdatePolicyRequest{CrawlInterval: &interval})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if res.Policy.CrawlInterval != "1m0s" {
+			t.Errorf("Unexpected policy: %+v", res.Policy)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+}
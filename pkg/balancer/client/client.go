@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"juno/pkg/balancer/crawl"
 	"juno/pkg/balancer/crawl/dto"
+	discoveryDto "juno/pkg/balancer/discovery/dto"
 	"net/http"
 )
 
@@ -75,3 +76,33 @@ func (c *Client) CrawlURLs(urls []string) error {
 
 	return nil
 }
+
+func (c *Client) Feeds(urls []string) error {
+
+	feedsReq := discoveryDto.FeedsRequest{URLs: urls}
+
+	jsonB, err := json.Marshal(feedsReq)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL+"/crawl/feeds", bytes.NewBuffer(jsonB))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return crawl.ErrFailedCrawlRequest
+	}
+
+	return nil
+}
@@ -101,3 +101,46 @@ func TestCrawl(t *testing.T) {
 		}
 	})
 }
+
+func TestFeeds(t *testing.T) {
+	t.Run("should make feeds request", func(t *testing.T) {
+		defer gock.Off()
+
+		baseURL := "http://localhost:8080"
+
+		gock.New(baseURL).
+			Post("/crawl/feeds").
+			JSON(map[string][]string{"urls": {"http://example.com/rss.xml"}}).
+			Reply(200)
+
+		client := New(baseURL)
+
+		err := client.Feeds([]string{"http://example.com/rss.xml"})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("should return error on failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		baseURL := "http://localhost:8080"
+
+		gock.New(baseURL).
+			Post("/crawl/feeds").
+			Reply(500)
+
+		client := New(baseURL)
+
+		err := client.Feeds([]string{"http://example.com/rss.xml"})
+
+		if err == nil {
+			t.Errorf("Expected an error")
+		}
+	})
+}
@@ -10,6 +10,10 @@ var ErrFailedCrawlRequest = errors.New("failed to crawl request")
 var ErrNoNodesAvailableInShard = errors.New("no nodes available in shard")
 var ErrTooManyTries = errors.New("too many tries")
 
+// MaxTries is how many nodes of a shard a URL is sent to before the attempt
+// counts as failed.
+const MaxTries = 3
+
 type Handler interface {
 	Crawl(c *gin.Context)
 	CrawlURLs(c *gin.Context)
@@ -17,4 +21,7 @@ type Handler interface {
 
 type Service interface {
 	Crawl(url string) error
+	Pause()
+	Resume()
+	Paused() bool
 }
@@ -4,6 +4,7 @@ import (
 	"juno/pkg/balancer/crawl/dto"
 	"juno/pkg/balancer/queue"
 	"juno/pkg/balancer/robotstxt"
+	"juno/pkg/balancer/scope"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,17 +15,20 @@ type Handler struct {
 	logger           *logrus.Logger
 	queueService     queue.Service
 	robotsTxtService robotstxt.Service
+	scopeService     scope.Service
 }
 
 func New(
 	logger *logrus.Logger,
 	queueService queue.Service,
 	robotsTxtService robotstxt.Service,
+	scopeService scope.Service,
 ) *Handler {
 	return &Handler{
 		logger:           logger,
 		queueService:     queueService,
 		robotsTxtService: robotsTxtService,
+		scopeService:     scopeService,
 	}
 }
 
@@ -38,6 +42,10 @@ func (h *Handler) CrawlURLs(c *gin.Context) {
 
 	for _, url := range req.URLs {
 
+		if ok, _ := h.scopeService.Check(url); !ok {
+			continue
+		}
+
 		if !h.robotsTxtService.CanCrawlURL(url) {
 			continue
 		}
@@ -58,6 +66,11 @@ func (h *Handler) Crawl(c *gin.Context) {
 		return
 	}
 
+	if ok, reason := h.scopeService.Check(req.URL); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "url out of scope", "reason": reason})
+		return
+	}
+
 	if !h.robotsTxtService.CanCrawlURL(req.URL) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to crawl"})
 		return
@@ -11,6 +11,10 @@ import (
 	robotstxtRepo "juno/pkg/balancer/robotstxt/repo/mem"
 	robotstxtService "juno/pkg/balancer/robotstxt/service"
 
+	"juno/pkg/balancer/queue"
+	"juno/pkg/balancer/scope"
+	scopeService "juno/pkg/balancer/scope/service"
+
 	"juno/pkg/shard"
 	"net/http"
 	"net/http/httptest"
@@ -18,16 +22,32 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/h2non/gock"
 	"github.com/sirupsen/logrus"
 )
 
+func newScopeService(t *testing.T, queueSvc queue.Service) *scopeService.Service {
+	svc, err := scopeService.New(scope.DefaultRules(), queueSvc)
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	return svc
+}
+
 func TestCrawlURLs(t *testing.T) {
 	t.Run("should return ok", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(404)
 
 		repo := queueRepo.New()
 		queueSvc := queueService.New(logrus.New(), repo)
 
-		h := New(logrus.New(), queueSvc, robotstxtService.New(robotstxtRepo.New()))
+		h := New(logrus.New(), queueSvc, robotstxtService.New(robotstxtRepo.New()), newScopeService(t, queueSvc))
 
 		req := dto.CrawlURLsRequest{
 			URLs: []string{"http://example.com"},
@@ -73,6 +93,11 @@ func TestCrawlURLs(t *testing.T) {
 
 func TestCrawl(t *testing.T) {
 	t.Run("should return ok", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(404)
 
 		repo := queueRepo.New()
 		queueSvc := queueService.New(logrus.New(), repo)
@@ -80,7 +105,7 @@ func TestCrawl(t *testing.T) {
 		svc.SetShards([shard.SHARDS][]string{
 			72435: {"node1.com:9090"},
 		})
-		h := New(logrus.New(), queueSvc, robotstxtService.New(robotstxtRepo.New()))
+		h := New(logrus.New(), queueSvc, robotstxtService.New(robotstxtRepo.New()), newScopeService(t, queueSvc))
 
 		req := dto.CrawlRequest{
 			URL: "http://example.com",
@@ -123,3 +148,39 @@ func TestCrawl(t *testing.T) {
 		}
 	})
 }
+
+func TestCrawlOutOfScope(t *testing.T) {
+	t.Run("should reject urls outside of scope", func(t *testing.T) {
+		repo := queueRepo.New()
+		queueSvc := queueService.New(logrus.New(), repo)
+
+		rules := scope.DefaultRules()
+		rules.DenyHosts = []string{"example.com"}
+		scopeSvc, _ := scopeService.New(rules, queueSvc)
+
+		h := New(logrus.New(), queueSvc, robotstxtService.New(robotstxtRepo.New()), scopeSvc)
+
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request, _ = http.NewRequest(http.MethodPost, "/crawl", strings.NewReader(`{"url": "http://example.com"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		// When
+		h.Crawl(c)
+
+		// Then
+		if c.Writer.Status() != http.StatusForbidden {
+			t.Errorf("expected status 403 but got %d", c.Writer.Status())
+		}
+
+		if _, err := repo.Pop(); err == nil {
+			t.Errorf("expected url not to be queued")
+		}
+
+		if scopeSvc.Rejections()[scope.ReasonHostDenied] != 1 {
+			t.Errorf("expected rejection to be counted")
+		}
+	})
+}
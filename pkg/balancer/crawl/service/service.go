@@ -2,16 +2,19 @@ package service
 
 import (
 	"context"
-	"fmt"
 	apiClient "juno/pkg/api/client"
 	"juno/pkg/balancer/crawl"
+	"juno/pkg/balancer/discovery"
+	"juno/pkg/balancer/outcome"
 	"juno/pkg/balancer/policy"
 	"juno/pkg/balancer/queue"
 	"juno/pkg/node/client"
+	"juno/pkg/nodepool"
 	"juno/pkg/shard"
 	"juno/pkg/url"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -58,17 +61,64 @@ func WithPolicyService(policyService policy.Service) func(s *Service) {
 	}
 }
 
+// WithDiscoveryService registers the sitemaps and feeds of every host whose
+// URLs are processed.
+func WithDiscoveryService(discoveryService discovery.Service) func(s *Service) {
+	return func(s *Service) {
+		s.discoveryService = discoveryService
+	}
+}
+
+// WithOutcomeService records the outcome of every processed URL.
+func WithOutcomeService(outcomeService outcome.Service) func(s *Service) {
+	return func(s *Service) {
+		s.outcomeService = outcomeService
+	}
+}
+
+// WithCrawlReports reports a sample of the crawls, at the rate from 0 to 1,
+// to the API with the report token of the balancer. Nodes are challenged to
+// prove they still store the pages reported.
+func WithCrawlReports(reportToken string, rate float64) func(s *Service) {
+	return func(s *Service) {
+
+		if s.apiClient == nil {
+			panic("api client is required")
+		}
+
+		s.reportToken = reportToken
+		s.reportRate = rate
+	}
+}
+
+// WithNodePool sets the pool that picks the node of a shard a URL is sent to.
+func WithNodePool(pool *nodepool.Pool) func(s *Service) {
+	return func(s *Service) {
+		s.pool = pool
+	}
+}
+
 type Service struct {
 	logger        *logrus.Logger
 	apiClient     *apiClient.Client
 	shards        [shard.SHARDS][]string
 	shardsLock    sync.Mutex
+	pool          *nodepool.Pool
 	queueService  queue.Service
 	policyService policy.Service
+
+	discoveryService discovery.Service
+	outcomeService   outcome.Service
+
+	reportToken string
+	reportRate  float64
+
+	paused atomic.Bool
 }
 
 func New(options ...func(s *Service)) *Service {
-	s := &Service{}
+	// the pool is set before the options, which may start fetching shards
+	s := &Service{pool: nodepool.New()}
 
 	for _, option := range options {
 		option(s)
@@ -88,6 +138,8 @@ func (s *Service) fetchShards() {
 		return
 	}
 
+	s.pool.SetReputations(res.Reputations)
+
 	s.shardsLock.Lock()
 	defer s.shardsLock.Unlock()
 
@@ -113,14 +165,25 @@ func (s *Service) SetShards(shards [shard.SHARDS][]string) {
 	s.shards = shards
 }
 
-func (s *Service) randomNode(shard int) (string, error) {
+func (s *Service) nodes(shard int) []string {
 	s.shardsLock.Lock()
 	defer s.shardsLock.Unlock()
-	if len(s.shards[shard]) == 0 {
-		return "", crawl.ErrNoNodesAvailableInShard
-	}
 
-	return s.shards[shard][rand.Intn(len(s.shards[shard]))], nil
+	return s.shards[shard]
+}
+
+// Pause stops ProcessQueue from leasing URLs until Resume is called. URLs
+// that are being crawled when it is paused are still finished.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+}
+
+func (s *Service) Resume() {
+	s.paused.Store(false)
+}
+
+func (s *Service) Paused() bool {
+	return s.paused.Load()
 }
 
 func (s *Service) ProcessQueue(ctx context.Context) error {
@@ -138,9 +201,22 @@ func (s *Service) ProcessQueue(ctx context.Context) error {
 		case <-ctx.Done():
 			return queue.ErrProcessQueueCancelled
 		default:
-			u, err := s.queueService.Pop()
+			if s.Paused() {
+				select {
+				case <-ctx.Done():
+					return queue.ErrProcessQueueCancelled
+				case <-time.After(500 * time.Millisecond):
+				}
+				continue
+			}
+
+			item, err := s.queueService.Lease()
+
+			if err != nil {
+				if err != queue.ErrNoURLsInQueue {
+					s.logger.Errorf("failed to lease url from queue: %v", err)
+				}
 
-			if err == queue.ErrNoURLsInQueue {
 				select {
 				case <-ctx.Done():
 					return queue.ErrProcessQueueCancelled
@@ -148,15 +224,13 @@ func (s *Service) ProcessQueue(ctx context.Context) error {
 					// continue after sleep
 				}
 				continue
-			} else if err != nil {
-				s.logger.Errorf("failed to pop url from queue: %v", err)
-				continue
 			}
 
-			hostname, err := url.ToHostname(u)
+			hostname, err := url.ToHostname(item.URL)
 
 			if err != nil {
 				s.logger.Errorf("failed to get hostname from url: %v", err)
+				s.fail(item, err)
 				continue
 			}
 
@@ -166,25 +240,43 @@ func (s *Service) ProcessQueue(ctx context.Context) error {
 				pol = policy.New(hostname)
 			} else if err != nil {
 				s.logger.Errorf("failed to get policy for url: %v", err)
+				s.release(item)
 				continue
 			}
 
-			if !s.policyService.CanCrawl(pol) {
-				err = s.queueService.Push(u)
-
-				if err != nil {
-					s.logger.Errorf("failed to push url to queue: %v", err)
+			if pol.Blocked {
+				if err := s.queueService.Ack(item); err != nil {
+					s.logger.Errorf("failed to drop url of blocked host: %v", err)
 				}
+				s.record(item, hostname, "", outcome.StatusBlocked, nil)
+				continue
+			}
 
+			if !s.policyService.CanCrawl(pol) {
+				s.release(item)
 				continue
 			}
 
-			crawlErr := s.Crawl(u)
+			if s.discoveryService != nil {
+				if err := s.discoveryService.DiscoverHost(item.URL); err != nil {
+					s.logger.Errorf("failed to discover host: %v", err)
+				}
+			}
+
+			node, crawlErr := s.crawl(item.URL)
 
 			if crawlErr != nil {
 				s.logger.Errorf("failed to crawl url: %v", crawlErr)
+				s.fail(item, crawlErr)
+				s.record(item, hostname, node, outcome.StatusFailed, crawlErr)
+			} else {
+				if err := s.queueService.Ack(item); err != nil {
+					s.logger.Errorf("failed to ack url: %v", err)
+				}
+				s.record(item, hostname, node, outcome.StatusCrawled, nil)
 			}
-			err = s.policyService.RecordCrawl(pol)
+
+			err = s.policyService.RecordCrawl(hostname)
 			if err != nil {
 				s.logger.Errorf("failed to set policy for url: %v", err)
 			}
@@ -192,26 +284,81 @@ func (s *Service) ProcessQueue(ctx context.Context) error {
 	}
 }
 
+func (s *Service) release(item *queue.Item) {
+	if err := s.queueService.Release(item); err != nil {
+		s.logger.Errorf("failed to release url to queue: %v", err)
+	}
+}
+
+func (s *Service) fail(item *queue.Item, cause error) {
+	if err := s.queueService.Fail(item, cause); err != nil {
+		s.logger.Errorf("failed to record failed attempt for url: %v", err)
+	}
+}
+
+func (s *Service) record(item *queue.Item, hostname, node string, status outcome.Status, err error) {
+	if s.outcomeService == nil {
+		return
+	}
+
+	o := &outcome.Outcome{
+		URL:      item.URL,
+		Hostname: hostname,
+		Node:     node,
+		Status:   status,
+		Attempts: item.Attempts,
+	}
+
+	if err != nil {
+		o.Error = err.Error()
+	}
+
+	s.outcomeService.Record(o)
+}
+
 func (s *Service) Crawl(url string) error {
-	shard := shard.GetShard(url)
-
-	tries := 0
-	for tries < 3 {
-		node, err := s.randomNode(shard)
-		fmt.Println(node)
-		if err == crawl.ErrNoNodesAvailableInShard {
-			s.logger.Errorf("no nodes available in shard %d", shard)
-			return err
-		}
-		err = client.SendCrawlRequest(node, url)
-		if err == nil {
-			return nil
-		}
+	_, err := s.crawl(url)
+	return err
+}
 
-		tries++
+// crawl sends the url to a node of its shard and returns the last node tried.
+func (s *Service) crawl(url string) (string, error) {
+	shard := shard.GetURLShard(url)
+
+	var stored, version string
+
+	node, err := s.pool.Try(s.nodes(shard), crawl.MaxTries, func(node string) error {
+		var err error
+		stored, version, err = client.SendCrawlRequest(node, url)
+		return err
+	})
+
+	switch err {
+	case nil:
+		s.report(stored, version, node)
+		return node, nil
+	case nodepool.ErrNoNodes:
+		s.logger.Errorf("no nodes available in shard %d", shard)
+		return "", crawl.ErrNoNodesAvailableInShard
+	case nodepool.ErrAllCircuitsOpen:
+		s.logger.Errorf("all nodes of shard %d are unhealthy", shard)
+		return "", err
 	}
 
 	s.logger.Errorf("failed to send link %s to shard", url)
 
-	return crawl.ErrTooManyTries
+	return node, crawl.ErrTooManyTries
+}
+
+// report reports a sample of the pages stored on nodes. Pages of nodes that
+// did not report the version they stored are not, since nodes are challenged
+// on the version they stored at the time of the crawl.
+func (s *Service) report(url, version, node string) {
+	if s.reportToken == "" || version == "" || rand.Float64() >= s.reportRate {
+		return
+	}
+
+	if err := s.apiClient.ReportCrawl(s.reportToken, url, node, version); err != nil {
+		s.logger.Errorf("failed to report crawl: %v", err)
+	}
 }
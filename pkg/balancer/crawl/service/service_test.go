@@ -2,18 +2,25 @@ package service
 
 import (
 	"context"
+	"errors"
 	"juno/pkg/api/client"
 	"juno/pkg/api/node/dto"
+	"juno/pkg/balancer/crawl"
+	"juno/pkg/balancer/outcome"
 	"juno/pkg/balancer/policy"
 	"juno/pkg/balancer/queue"
 	"juno/pkg/balancer/queue/repo/mem"
+	"juno/pkg/nodepool"
 	"juno/pkg/shard"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	polRepo "juno/pkg/balancer/policy/repo/mem"
 	polService "juno/pkg/balancer/policy/service"
 
+	outcomeService "juno/pkg/balancer/outcome/service"
+
 	queueRepo "juno/pkg/balancer/queue/repo/mem"
 	queueService "juno/pkg/balancer/queue/service"
 
@@ -100,7 +107,7 @@ func TestCrawl(t *testing.T) {
 
 		svc := New(WithLogger(logrus.New()))
 		svc.SetShards([shard.SHARDS][]string{
-			68735: {"node1.com:9090"},
+			72435: {"node1.com:9090"},
 		})
 
 		svc.Crawl("http://example.com")
@@ -122,7 +129,7 @@ func TestCrawl(t *testing.T) {
 
 		svc := New(WithLogger(logrus.New()))
 		svc.SetShards([shard.SHARDS][]string{
-			68735: {"node1.com:9090"},
+			72435: {"node1.com:9090"},
 		})
 
 		err := svc.Crawl("http://example.com")
@@ -137,6 +144,173 @@ func TestCrawl(t *testing.T) {
 	})
 }
 
+func TestCrawlReports(t *testing.T) {
+	t.Run("should panic when api client is not set", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected a panic")
+			}
+		}()
+
+		WithCrawlReports("secret", 1)(&Service{})
+	})
+
+	t.Run("reports the url the node stored the page under", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Reply(200).
+			JSON(map[string]string{"status": "success", "url": "https://example.com/", "version": "abc"})
+
+		gock.New("http://localhost:8080").
+			Post("/crawls").
+			MatchHeader("Authorization", "Bearer secret").
+			JSON(map[string]string{"url": "https://example.com/", "node": "node1.com:9090", "version": "abc"}).
+			Reply(201)
+
+		svc := New(
+			WithLogger(logrus.New()),
+			WithApiClient(client.New("http://localhost:8080")),
+			WithCrawlReports("secret", 1),
+		)
+		svc.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		if err := svc.Crawl("http://example.com"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("reports nothing when the node did not report the version", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Reply(200).
+			JSON(map[string]string{"status": "success", "url": "https://example.com/"})
+
+		gock.New("http://localhost:8080").
+			Post("/crawls").
+			Reply(201)
+
+		svc := New(
+			WithLogger(logrus.New()),
+			WithApiClient(client.New("http://localhost:8080")),
+			WithCrawlReports("secret", 1),
+		)
+		svc.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		if err := svc.Crawl("http://example.com"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		if !gock.IsPending() {
+			t.Errorf("expected no report")
+		}
+	})
+
+	t.Run("reports nothing at a zero rate", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Reply(200)
+
+		gock.New("http://localhost:8080").
+			Post("/crawls").
+			Reply(201)
+
+		svc := New(
+			WithLogger(logrus.New()),
+			WithApiClient(client.New("http://localhost:8080")),
+			WithCrawlReports("secret", 0),
+		)
+		svc.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		if err := svc.Crawl("http://example.com"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		if !gock.IsPending() {
+			t.Errorf("expected no report")
+		}
+	})
+}
+
+func TestCrawlNodeSelection(t *testing.T) {
+	t.Run("fails over to another node of the shard", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Persist().
+			Reply(500)
+
+		gock.New("http://node2.com:9090").
+			Post("/crawl").
+			Persist().
+			Reply(200)
+
+		svc := New(WithLogger(logrus.New()))
+		svc.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090", "node2.com:9090"},
+		})
+
+		node, err := svc.crawl("http://example.com")
+
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if node != "node2.com:9090" {
+			t.Errorf("expected node2.com:9090 but got %s", node)
+		}
+	})
+
+	t.Run("skips nodes with an open circuit", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node2.com:9090").
+			Post("/crawl").
+			Times(1).
+			Reply(200)
+
+		pool := nodepool.New(nodepool.WithFailureThreshold(1), nodepool.WithOpenTimeout(time.Hour))
+		pool.Report("node1.com:9090", time.Millisecond, crawl.ErrFailedCrawlRequest)
+
+		svc := New(WithLogger(logrus.New()), WithNodePool(pool))
+		svc.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090", "node2.com:9090"},
+		})
+
+		if err := svc.Crawl("http://example.com"); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("returns no nodes error for empty shards", func(t *testing.T) {
+		svc := New(WithLogger(logrus.New()))
+
+		if err := svc.Crawl("http://example.com"); err != crawl.ErrNoNodesAvailableInShard {
+			t.Errorf("expected ErrNoNodesAvailableInShard but got %v", err)
+		}
+	})
+}
+
 func TestProcess(t *testing.T) {
 	t.Run("should process queue", func(t *testing.T) {
 
@@ -160,7 +334,7 @@ func TestProcess(t *testing.T) {
 			WithPolicyService(polSvc),
 		)
 		crawlService.SetShards([shard.SHARDS][]string{
-			68735: {"node1.com:9090"},
+			72435: {"node1.com:9090"},
 		})
 
 		pol := policy.New("example.com")
@@ -230,7 +404,7 @@ func TestProcess(t *testing.T) {
 			WithPolicyService(polSvc),
 		)
 		crawlService.SetShards([shard.SHARDS][]string{
-			68735: {"node1.com:9090"},
+			72435: {"node1.com:9090"},
 		})
 
 		lastCrawledTime := time.Now()
@@ -285,3 +459,259 @@ func TestProcess(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessRetries(t *testing.T) {
+	t.Run("requeues url with backoff when crawl fails", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Times(3).
+			Reply(500)
+
+		logger := logrus.New()
+		queueRepo := queueRepo.New()
+		polSvc := polService.New(polRepo.New())
+		queueSvc := queueService.New(logger, queueRepo, queueService.WithRetryBackoff(time.Hour, time.Hour))
+		crawlService := New(
+			WithLogger(logger),
+			WithQueueService(queueSvc),
+			WithPolicyService(polSvc),
+		)
+		crawlService.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		queueRepo.Push("http://example.com")
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go crawlService.ProcessQueue(ctx)
+
+		time.Sleep(49 * time.Millisecond)
+		cancel()
+
+		time.Sleep(99 * time.Millisecond) // Give time for cancellation to propagate
+
+		exists, _ := queueRepo.Exists("http://example.com")
+		if !exists {
+			t.Errorf("expected url to be requeued")
+		}
+
+		if _, err := queueRepo.Lease(time.Minute, queue.DefaultMaxAttempts); err != queue.ErrNoURLsInQueue {
+			t.Errorf("expected url to be backing off but got %v", err)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("dead-letters url after max attempts", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Times(3).
+			Reply(500)
+
+		logger := logrus.New()
+		queueRepo := queueRepo.New()
+		polSvc := polService.New(polRepo.New())
+		queueSvc := queueService.New(logger, queueRepo, queueService.WithMaxAttempts(1))
+		crawlService := New(
+			WithLogger(logger),
+			WithQueueService(queueSvc),
+			WithPolicyService(polSvc),
+		)
+		crawlService.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		queueRepo.Push("http://example.com")
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go crawlService.ProcessQueue(ctx)
+
+		time.Sleep(49 * time.Millisecond)
+		cancel()
+
+		time.Sleep(99 * time.Millisecond) // Give time for cancellation to propagate
+
+		deadLetters, _ := queueSvc.DeadLetters()
+		if len(deadLetters) != 1 {
+			t.Fatalf("expected 1 dead letter but got %d", len(deadLetters))
+		}
+
+		if deadLetters[0].URL != "http://example.com" {
+			t.Errorf("expected http://example.com but got %s", deadLetters[0].URL)
+		}
+	})
+
+	t.Run("backs off after a failed lease", func(t *testing.T) {
+		logger := logrus.New()
+		queueSvc := &failingQueue{}
+		crawlService := New(
+			WithLogger(logger),
+			WithQueueService(queueSvc),
+			WithPolicyService(polService.New(polRepo.New())),
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go crawlService.ProcessQueue(ctx)
+
+		time.Sleep(49 * time.Millisecond)
+		cancel()
+
+		time.Sleep(99 * time.Millisecond) // Give time for cancellation to propagate
+
+		if leases := queueSvc.leases.Load(); leases != 1 {
+			t.Errorf("expected 1 lease before backing off but got %d", leases)
+		}
+	})
+}
+
+// failingQueue fails every lease, as a queue whose store is down does.
+type failingQueue struct {
+	queue.Service
+	leases atomic.Int32
+}
+
+func (q *failingQueue) Lease() (*queue.Item, error) {
+	q.leases.Add(1)
+
+	return nil, errors.New("queue unavailable")
+}
+
+func TestPause(t *testing.T) {
+	t.Run("does not lease urls while paused", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Reply(200)
+
+		logger := logrus.New()
+		queueRepo := queueRepo.New()
+		crawlService := New(
+			WithLogger(logger),
+			WithQueueService(queueService.New(logger, queueRepo)),
+			WithPolicyService(polService.New(polRepo.New())),
+		)
+		crawlService.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		crawlService.Pause()
+
+		if !crawlService.Paused() {
+			t.Fatal("expected service to be paused")
+		}
+
+		queueRepo.Push("http://example.com")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go crawlService.ProcessQueue(ctx)
+
+		time.Sleep(49 * time.Millisecond)
+
+		if gock.IsDone() {
+			t.Errorf("expected no crawl request while paused")
+		}
+
+		crawlService.Resume()
+
+		time.Sleep(599 * time.Millisecond)
+		cancel()
+
+		time.Sleep(99 * time.Millisecond) // Give time for cancellation to propagate
+
+		if !gock.IsDone() {
+			t.Errorf("expected url to be crawled after resuming")
+		}
+	})
+}
+
+func TestProcessOutcomes(t *testing.T) {
+	t.Run("records crawled urls", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/crawl").
+			Reply(200)
+
+		logger := logrus.New()
+		queueRepo := queueRepo.New()
+		outcomeSvc := outcomeService.New(outcome.DefaultHistory, outcome.DefaultMaxHosts)
+		crawlService := New(
+			WithLogger(logger),
+			WithQueueService(queueService.New(logger, queueRepo)),
+			WithPolicyService(polService.New(polRepo.New())),
+			WithOutcomeService(outcomeSvc),
+		)
+		crawlService.SetShards([shard.SHARDS][]string{
+			72435: {"node1.com:9090"},
+		})
+
+		queueRepo.Push("http://example.com")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go crawlService.ProcessQueue(ctx)
+
+		time.Sleep(49 * time.Millisecond)
+		cancel()
+
+		time.Sleep(99 * time.Millisecond) // Give time for cancellation to propagate
+
+		recent := outcomeSvc.Recent("example.com", 10)
+
+		if len(recent) != 1 {
+			t.Fatalf("expected 1 outcome but got %d", len(recent))
+		}
+
+		if recent[0].Status != outcome.StatusCrawled || recent[0].Node != "node1.com:9090" {
+			t.Errorf("expected crawled by node1.com:9090 but got %s by %s", recent[0].Status, recent[0].Node)
+		}
+	})
+
+	t.Run("drops urls of blocked hosts", func(t *testing.T) {
+		defer gock.Off()
+
+		logger := logrus.New()
+		queueRepo := queueRepo.New()
+		polSvc := polService.New(polRepo.New())
+		outcomeSvc := outcomeService.New(outcome.DefaultHistory, outcome.DefaultMaxHosts)
+		crawlService := New(
+			WithLogger(logger),
+			WithQueueService(queueService.New(logger, queueRepo)),
+			WithPolicyService(polSvc),
+			WithOutcomeService(outcomeSvc),
+		)
+
+		pol := policy.New("example.com")
+		pol.Blocked = true
+		polSvc.Set("example.com", pol)
+
+		queueRepo.Push("http://example.com")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go crawlService.ProcessQueue(ctx)
+
+		time.Sleep(49 * time.Millisecond)
+		cancel()
+
+		time.Sleep(99 * time.Millisecond) // Give time for cancellation to propagate
+
+		if exists, _ := queueRepo.Exists("http://example.com"); exists {
+			t.Errorf("expected url of blocked host to be dropped")
+		}
+
+		recent := outcomeSvc.Recent("example.com", 10)
+
+		if len(recent) != 1 || recent[0].Status != outcome.StatusBlocked {
+			t.Errorf("expected a blocked outcome but got %v", recent)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrHintNotFound = errors.New("hint not found")
+var ErrUnknownDocument = errors.New("document is not a sitemap or feed")
+var ErrFailedFetch = errors.New("failed to fetch source")
+
+const (
+	SitemapRefreshInterval = 24 * time.Hour
+	FeedRefreshInterval    = time.Hour
+	// FailedRefreshInterval applies to sources that could not be fetched,
+	// e.g. conventional paths the host does not serve.
+	FailedRefreshInterval = 7 * 24 * time.Hour
+
+	// DefaultRevisitInterval is used for entries without a changefreq.
+	DefaultRevisitInterval = 7 * 24 * time.Hour
+
+	// MaxDocumentSize matches the 50MB uncompressed sitemap limit.
+	MaxDocumentSize = 50 * 1024 * 1024
+	FetchTimeout    = 30 * time.Second
+)
+
+// ConventionalPaths are probed on every new host in addition to the
+// Sitemap: lines of its robots.txt.
+var ConventionalPaths = []string{
+	"/sitemap.xml",
+	"/sitemap_index.xml",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed",
+}
+
+type Kind string
+
+const (
+	KindUnknown      Kind = ""
+	KindSitemap      Kind = "sitemap"
+	KindSitemapIndex Kind = "sitemap_index"
+	KindFeed         Kind = "feed"
+)
+
+// Source is a sitemap, sitemap index or feed that is fetched periodically.
+type Source struct {
+	URL         string
+	Hostname    string
+	Kind        Kind
+	NextFetch   time.Time
+	LastFetched time.Time
+	LastError   string
+}
+
+// Entry is a URL found in a source, with its scheduling hints.
+type Entry struct {
+	URL        string
+	LastMod    time.Time
+	ChangeFreq string
+}
+
+// Hint remembers when a discovered URL was last pushed so it is only pushed
+// again once it changed or its changefreq says it is due.
+type Hint struct {
+	URL        string
+	LastMod    time.Time
+	ChangeFreq string
+	NextDue    time.Time
+}
+
+type Document struct {
+	Kind    Kind
+	Entries []Entry
+	// Sitemaps holds the children of a sitemap index.
+	Sitemaps []string
+}
+
+type Repository interface {
+	// AddHost records a host and reports whether it was seen for the first time.
+	AddHost(hostname string) (bool, error)
+	// AddSource stores a source unless one with the same URL exists.
+	AddSource(source *Source) error
+	UpdateSource(source *Source) error
+	DueSources(now time.Time) ([]*Source, error)
+	GetHint(url string) (*Hint, error)
+	SetHint(hint *Hint) error
+}
+
+type Service interface {
+	DiscoverHost(url string) error
+	AddFeeds(urls []string) error
+	Run(ctx context.Context) error
+}
+
+type Handler interface {
+	Feeds(c *gin.Context)
+}
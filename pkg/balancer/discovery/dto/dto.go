@@ -0,0 +1,26 @@
+package dto
+
+const (
+	OK    = "ok"
+	ERROR = "error"
+)
+
+type FeedsRequest struct {
+	URLs []string `json:"urls"`
+}
+
+type FeedsResponse struct {
+	Status string `json:"status"`
+}
+
+func NewOKFeedsResponse() FeedsResponse {
+	return FeedsResponse{
+		Status: OK,
+	}
+}
+
+func NewErrorFeedsResponse() FeedsResponse {
+	return FeedsResponse{
+		Status: ERROR,
+	}
+}
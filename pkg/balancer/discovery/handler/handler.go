@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"juno/pkg/balancer/discovery"
+	"juno/pkg/balancer/discovery/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger           *logrus.Logger
+	discoveryService discovery.Service
+}
+
+func New(
+	logger *logrus.Logger,
+	discoveryService discovery.Service,
+) *Handler {
+	return &Handler{
+		logger:           logger,
+		discoveryService: discoveryService,
+	}
+}
+
+func (h *Handler) Feeds(c *gin.Context) {
+	var req dto.FeedsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.discoveryService.AddFeeds(req.URLs); err != nil {
+		h.logger.WithError(err).Error("failed to add feeds")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorFeedsResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewOKFeedsResponse())
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type mockDiscoveryService struct {
+	feeds []string
+	err   error
+}
+
+func (m *mockDiscoveryService) DiscoverHost(url string) error {
+	return nil
+}
+
+func (m *mockDiscoveryService) AddFeeds(urls []string) error {
+	m.feeds = urls
+	return m.err
+}
+
+func (m *mockDiscoveryService) Run(ctx context.Context) error {
+	return nil
+}
+
+func TestFeeds(t *testing.T) {
+	t.Run("should add feeds", func(t *testing.T) {
+		svc := &mockDiscoveryService{}
+		h := New(logrus.New(), svc)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/crawl/feeds", strings.NewReader(`{"urls": ["http://example.com/rss.xml"]}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Feeds(c)
+
+		if c.Writer.Status() != http.StatusOK {
+			t.Errorf("expected status 200 but got %d", c.Writer.Status())
+		}
+
+		if len(svc.feeds) != 1 || svc.feeds[0] != "http://example.com/rss.xml" {
+			t.Errorf("expected feed to be added, got %v", svc.feeds)
+		}
+	})
+
+	t.Run("should return error when service fails", func(t *testing.T) {
+		h := New(logrus.New(), &mockDiscoveryService{err: errors.New("error")})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/crawl/feeds", strings.NewReader(`{"urls": ["http://example.com/rss.xml"]}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Feeds(c)
+
+		if c.Writer.Status() != http.StatusInternalServerError {
+			t.Errorf("expected status 500 but got %d", c.Writer.Status())
+		}
+	})
+}
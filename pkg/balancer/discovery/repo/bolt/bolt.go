@@ -0,0 +1,142 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"juno/pkg/balancer/discovery"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	hostsBucket   = []byte("discovery_hosts")
+	sourcesBucket = []byte("discovery_sources")
+	hintsBucket   = []byte("discovery_hints")
+)
+
+type Repository struct {
+	db *bolt.DB
+}
+
+// New initializes a new BoltDB repository.
+func New(dbPath string) (*Repository, error) {
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the buckets for discovery if they don't exist
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{hostsBucket, sourcesBucket, hintsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repository{db: db}, nil
+}
+
+// AddHost records a host and reports whether it was seen for the first time.
+func (r *Repository) AddHost(hostname string) (bool, error) {
+	var added bool
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(hostsBucket)
+
+		if b.Get([]byte(hostname)) != nil {
+			return nil
+		}
+
+		added = true
+		return b.Put([]byte(hostname), []byte{1})
+	})
+	if err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// AddSource stores a source unless one with the same URL exists.
+func (r *Repository) AddSource(source *discovery.Source) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sourcesBucket)
+
+		if b.Get([]byte(source.URL)) != nil {
+			return nil
+		}
+
+		return put(b, source.URL, source)
+	})
+}
+
+// UpdateSource overwrites a source.
+func (r *Repository) UpdateSource(source *discovery.Source) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(sourcesBucket), source.URL, source)
+	})
+}
+
+// DueSources returns the sources whose next fetch is not after now.
+func (r *Repository) DueSources(now time.Time) ([]*discovery.Source, error) {
+	due := []*discovery.Source{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sourcesBucket).ForEach(func(k, v []byte) error {
+			var source discovery.Source
+			if err := json.Unmarshal(v, &source); err != nil {
+				return fmt.Errorf("failed to unmarshal source: %w", err)
+			}
+
+			if !source.NextFetch.After(now) {
+				due = append(due, &source)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// GetHint retrieves the scheduling hint of a discovered URL.
+func (r *Repository) GetHint(url string) (*discovery.Hint, error) {
+	var hint *discovery.Hint
+	err := r.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(hintsBucket).Get([]byte(url))
+
+		if v == nil {
+			return discovery.ErrHintNotFound
+		}
+
+		if err := json.Unmarshal(v, &hint); err != nil {
+			return fmt.Errorf("failed to unmarshal hint: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hint, nil
+}
+
+// SetHint stores the scheduling hint of a discovered URL.
+func (r *Repository) SetHint(hint *discovery.Hint) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(hintsBucket), hint.URL, hint)
+	})
+}
+
+func put(b *bolt.Bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+
+	return b.Put([]byte(key), data)
+}
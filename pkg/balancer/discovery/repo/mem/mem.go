@@ -0,0 +1,95 @@
+package mem
+
+import (
+	"juno/pkg/balancer/discovery"
+	"sync"
+	"time"
+)
+
+type Repository struct {
+	mu      sync.Mutex
+	hosts   map[string]bool
+	sources map[string]*discovery.Source
+	hints   map[string]*discovery.Hint
+}
+
+func New() *Repository {
+	return &Repository{
+		hosts:   make(map[string]bool),
+		sources: make(map[string]*discovery.Source),
+		hints:   make(map[string]*discovery.Hint),
+	}
+}
+
+func (r *Repository) AddHost(hostname string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hosts[hostname] {
+		return false, nil
+	}
+
+	r.hosts[hostname] = true
+	return true, nil
+}
+
+func (r *Repository) AddSource(source *discovery.Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sources[source.URL]; ok {
+		return nil
+	}
+
+	s := *source
+	r.sources[source.URL] = &s
+	return nil
+}
+
+func (r *Repository) UpdateSource(source *discovery.Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := *source
+	r.sources[source.URL] = &s
+	return nil
+}
+
+func (r *Repository) DueSources(now time.Time) ([]*discovery.Source, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := []*discovery.Source{}
+	for _, source := range r.sources {
+		if source.NextFetch.After(now) {
+			continue
+		}
+
+		s := *source
+		due = append(due, &s)
+	}
+
+	return due, nil
+}
+
+func (r *Repository) GetHint(url string) (*discovery.Hint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hint, ok := r.hints[url]
+	if !ok {
+		return nil, discovery.ErrHintNotFound
+	}
+
+	h := *hint
+	return &h, nil
+}
+
+func (r *Repository) SetHint(hint *discovery.Hint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := *hint
+	r.hints[hint.URL] = &h
+	return nil
+}
@@ -0,0 +1,72 @@
+package mem
+
+import (
+	"juno/pkg/balancer/discovery"
+	"testing"
+	"time"
+)
+
+func TestAddHost(t *testing.T) {
+	repo := New()
+
+	added, err := repo.AddHost("example.com")
+
+	if err != nil || !added {
+		t.Errorf("expected host to be added, got %v %v", added, err)
+	}
+
+	added, err = repo.AddHost("example.com")
+
+	if err != nil || added {
+		t.Errorf("expected host not to be added twice, got %v %v", added, err)
+	}
+}
+
+func TestSources(t *testing.T) {
+	repo := New()
+
+	repo.AddSource(&discovery.Source{URL: "http://example.com/sitemap.xml"})
+	repo.AddSource(&discovery.Source{URL: "http://example.com/feed", NextFetch: time.Now().Add(time.Hour)})
+
+	due, err := repo.DueSources(time.Now())
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if len(due) != 1 || due[0].URL != "http://example.com/sitemap.xml" {
+		t.Fatalf("expected sitemap to be due, got %v", due)
+	}
+
+	due[0].NextFetch = time.Now().Add(time.Hour)
+	repo.UpdateSource(due[0])
+
+	// adding an existing source does not reset it
+	repo.AddSource(&discovery.Source{URL: "http://example.com/sitemap.xml"})
+
+	due, _ = repo.DueSources(time.Now())
+
+	if len(due) != 0 {
+		t.Errorf("expected no sources to be due, got %d", len(due))
+	}
+}
+
+func TestHints(t *testing.T) {
+	repo := New()
+
+	if _, err := repo.GetHint("http://example.com"); err != discovery.ErrHintNotFound {
+		t.Errorf("expected ErrHintNotFound but got %v", err)
+	}
+
+	repo.SetHint(&discovery.Hint{URL: "http://example.com", ChangeFreq: "daily"})
+
+	hint, err := repo.GetHint("http://example.com")
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if hint.ChangeFreq != "daily" {
+		t.Errorf("expected daily but got %s", hint.ChangeFreq)
+	}
+}
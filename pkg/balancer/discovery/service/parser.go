@@ -0,0 +1,244 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"juno/pkg/balancer/discovery"
+	"strings"
+	"time"
+)
+
+type urlset struct {
+	URLs []struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod"`
+		ChangeFreq string `xml:"changefreq"`
+	} `xml:"url"`
+}
+
+type sitemapindex struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+type rssItem struct {
+	Link    string `xml:"link"`
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"date"`
+}
+
+type rss struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 (RDF) puts items next to the channel
+	Items []rssItem `xml:"item"`
+}
+
+type atom struct {
+	Entries []struct {
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+	} `xml:"entry"`
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// decompress transparently gunzips sitemaps served as .xml.gz.
+func decompress(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(io.LimitReader(r, discovery.MaxDocumentSize))
+}
+
+func rootElement(data []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// Parse detects whether data is a sitemap, sitemap index, RSS or Atom feed
+// (optionally gzipped) and returns the URLs it lists.
+func Parse(data []byte) (*discovery.Document, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) > 0 && trimmed[0] != '<' {
+		return parseText(trimmed), nil
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, discovery.ErrUnknownDocument
+	}
+
+	unmarshal := func(v interface{}) error {
+		d := xml.NewDecoder(bytes.NewReader(data))
+		d.Strict = false
+		return d.Decode(v)
+	}
+
+	doc := &discovery.Document{}
+
+	switch root {
+	case "urlset":
+		var set urlset
+		if err := unmarshal(&set); err != nil {
+			return nil, err
+		}
+
+		doc.Kind = discovery.KindSitemap
+		for _, u := range set.URLs {
+			doc.Entries = append(doc.Entries, discovery.Entry{
+				URL:        strings.TrimSpace(u.Loc),
+				LastMod:    parseDate(u.LastMod),
+				ChangeFreq: strings.ToLower(strings.TrimSpace(u.ChangeFreq)),
+			})
+		}
+	case "sitemapindex":
+		var index sitemapindex
+		if err := unmarshal(&index); err != nil {
+			return nil, err
+		}
+
+		doc.Kind = discovery.KindSitemapIndex
+		for _, s := range index.Sitemaps {
+			doc.Sitemaps = append(doc.Sitemaps, strings.TrimSpace(s.Loc))
+		}
+	case "rss", "RDF":
+		var feed rss
+		if err := unmarshal(&feed); err != nil {
+			return nil, err
+		}
+
+		doc.Kind = discovery.KindFeed
+		for _, item := range append(feed.Channel.Items, feed.Items...) {
+			lastMod := parseDate(item.PubDate)
+			if lastMod.IsZero() {
+				lastMod = parseDate(item.Date)
+			}
+
+			doc.Entries = append(doc.Entries, discovery.Entry{
+				URL:     strings.TrimSpace(item.Link),
+				LastMod: lastMod,
+			})
+		}
+	case "feed":
+		var feed atom
+		if err := unmarshal(&feed); err != nil {
+			return nil, err
+		}
+
+		doc.Kind = discovery.KindFeed
+		for _, entry := range feed.Entries {
+			var link string
+			for _, l := range entry.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+
+			lastMod := parseDate(entry.Updated)
+			if lastMod.IsZero() {
+				lastMod = parseDate(entry.Published)
+			}
+
+			doc.Entries = append(doc.Entries, discovery.Entry{
+				URL:     strings.TrimSpace(link),
+				LastMod: lastMod,
+			})
+		}
+	default:
+		return nil, discovery.ErrUnknownDocument
+	}
+
+	return doc, nil
+}
+
+// parseText reads a plain text sitemap with one URL per line.
+func parseText(data []byte) *discovery.Document {
+	doc := &discovery.Document{Kind: discovery.KindSitemap}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			doc.Entries = append(doc.Entries, discovery.Entry{URL: line})
+		}
+	}
+
+	return doc
+}
+
+// revisitInterval maps a sitemap changefreq to how long a URL is left
+// alone before it is pushed again.
+func revisitInterval(changeFreq string) time.Duration {
+	switch changeFreq {
+	case "always", "hourly":
+		return time.Hour
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	case "monthly":
+		return 30 * 24 * time.Hour
+	case "yearly", "never":
+		return 365 * 24 * time.Hour
+	}
+
+	return discovery.DefaultRevisitInterval
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"juno/pkg/balancer/discovery"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("sitemap", func(t *testing.T) {
+		doc, err := Parse([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url>
+		<loc>http://example.com/a</loc>
+		<lastmod>2024-10-01</lastmod>
+		<changefreq>Daily</changefreq>
+	</url>
+	<url><loc> http://example.com/b </loc></url>
+</urlset>`))
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if doc.Kind != discovery.KindSitemap {
+			t.Errorf("expected sitemap but got %s", doc.Kind)
+		}
+
+		if len(doc.Entries) != 2 {
+			t.Fatalf("expected 2 entries but got %d", len(doc.Entries))
+		}
+
+		if doc.Entries[0].URL != "http://example.com/a" {
+			t.Errorf("expected http://example.com/a but got %s", doc.Entries[0].URL)
+		}
+
+		if !doc.Entries[0].LastMod.Equal(time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected lastmod %v", doc.Entries[0].LastMod)
+		}
+
+		if doc.Entries[0].ChangeFreq != "daily" {
+			t.Errorf("expected daily but got %s", doc.Entries[0].ChangeFreq)
+		}
+
+		if doc.Entries[1].URL != "http://example.com/b" {
+			t.Errorf("expected http://example.com/b but got %s", doc.Entries[1].URL)
+		}
+	})
+
+	t.Run("sitemap index", func(t *testing.T) {
+		doc, err := Parse([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>http://example.com/sitemap-1.xml.gz</loc></sitemap>
+	<sitemap><loc>http://example.com/sitemap-2.xml</loc></sitemap>
+</sitemapindex>`))
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if doc.Kind != discovery.KindSitemapIndex {
+			t.Errorf("expected sitemap index but got %s", doc.Kind)
+		}
+
+		if len(doc.Sitemaps) != 2 || doc.Sitemaps[0] != "http://example.com/sitemap-1.xml.gz" {
+			t.Errorf("unexpected sitemaps %v", doc.Sitemaps)
+		}
+	})
+
+	t.Run("gzipped sitemap", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write([]byte(`<urlset><url><loc>http://example.com/a</loc></url></urlset>`))
+		w.Close()
+
+		doc, err := Parse(buf.Bytes())
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(doc.Entries) != 1 || doc.Entries[0].URL != "http://example.com/a" {
+			t.Errorf("unexpected entries %v", doc.Entries)
+		}
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		doc, err := Parse([]byte(`<rss version="2.0"><channel>
+	<item><link>http://example.com/post-1</link><pubDate>Tue, 01 Oct 2024 10:00:00 +0000</pubDate></item>
+	<item><link>http://example.com/post-2</link></item>
+</channel></rss>`))
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if doc.Kind != discovery.KindFeed {
+			t.Errorf("expected feed but got %s", doc.Kind)
+		}
+
+		if len(doc.Entries) != 2 {
+			t.Fatalf("expected 2 entries but got %d", len(doc.Entries))
+		}
+
+		if doc.Entries[0].LastMod.IsZero() {
+			t.Errorf("expected pubDate to be parsed")
+		}
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		doc, err := Parse([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry>
+		<link rel="edit" href="http://example.com/edit/1"/>
+		<link href="http://example.com/entry-1"/>
+		<updated>2024-10-01T10:00:00Z</updated>
+	</entry>
+</feed>`))
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if doc.Kind != discovery.KindFeed {
+			t.Errorf("expected feed but got %s", doc.Kind)
+		}
+
+		if len(doc.Entries) != 1 || doc.Entries[0].URL != "http://example.com/entry-1" {
+			t.Errorf("unexpected entries %v", doc.Entries)
+		}
+	})
+
+	t.Run("text sitemap", func(t *testing.T) {
+		doc, err := Parse([]byte("http://example.com/a\nnot a url\nhttps://example.com/b\n"))
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(doc.Entries) != 2 {
+			t.Errorf("expected 2 entries but got %d", len(doc.Entries))
+		}
+	})
+
+	t.Run("html is rejected", func(t *testing.T) {
+		_, err := Parse([]byte(`<html><body>Not found</body></html>`))
+
+		if err != discovery.ErrUnknownDocument {
+			t.Errorf("expected ErrUnknownDocument but got %v", err)
+		}
+	})
+}
+
+func TestRevisitInterval(t *testing.T) {
+	if revisitInterval("daily") != 24*time.Hour {
+		t.Errorf("expected 24h for daily")
+	}
+
+	if revisitInterval("") != discovery.DefaultRevisitInterval {
+		t.Errorf("expected default revisit interval")
+	}
+}
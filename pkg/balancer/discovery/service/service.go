@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"juno/pkg/balancer/discovery"
+	"juno/pkg/balancer/queue"
+	"juno/pkg/balancer/robotstxt"
+	"juno/pkg/balancer/scope"
+	"net/http"
+	"net/url"
+	"time"
+
+	junourl "juno/pkg/url"
+
+	"github.com/sirupsen/logrus"
+)
+
+func WithLogger(logger *logrus.Logger) func(s *Service) {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+func WithRepository(repo discovery.Repository) func(s *Service) {
+	return func(s *Service) {
+		s.repo = repo
+	}
+}
+
+func WithQueueService(queueService queue.Service) func(s *Service) {
+	return func(s *Service) {
+		s.queueService = queueService
+	}
+}
+
+func WithRobotsTxtService(robotsTxtService robotstxt.Service) func(s *Service) {
+	return func(s *Service) {
+		s.robotsTxtService = robotsTxtService
+	}
+}
+
+// WithScopeService applies the balancer's scope rules to discovered URLs.
+func WithScopeService(scopeService scope.Service) func(s *Service) {
+	return func(s *Service) {
+		s.scopeService = scopeService
+	}
+}
+
+func WithHTTPClient(client *http.Client) func(s *Service) {
+	return func(s *Service) {
+		s.client = client
+	}
+}
+
+func WithInterval(interval time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.interval = interval
+	}
+}
+
+type Service struct {
+	logger           *logrus.Logger
+	repo             discovery.Repository
+	queueService     queue.Service
+	robotsTxtService robotstxt.Service
+	scopeService     scope.Service
+	client           *http.Client
+	interval         time.Duration
+}
+
+func New(options ...func(s *Service)) *Service {
+	s := &Service{
+		client:   &http.Client{Timeout: discovery.FetchTimeout},
+		interval: time.Minute,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.logger == nil {
+		panic("logger is required")
+	}
+
+	if s.repo == nil {
+		panic("repository is required")
+	}
+
+	if s.queueService == nil {
+		panic("queue service is required")
+	}
+
+	if s.robotsTxtService == nil {
+		panic("robots.txt service is required")
+	}
+
+	return s
+}
+
+// DiscoverHost registers the sitemaps listed in the host's robots.txt and
+// the conventional sitemap and feed paths the first time a host is seen.
+func (s *Service) DiscoverHost(link string) error {
+	u, err := url.Parse(link)
+
+	if err != nil {
+		return err
+	}
+
+	hostname, err := junourl.ToHostname(link)
+
+	if err != nil {
+		return err
+	}
+
+	added, err := s.repo.AddHost(hostname)
+
+	if err != nil || !added {
+		return err
+	}
+
+	base := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	sources := []string{}
+
+	if rtxt, err := s.robotsTxtService.Get(link); err == nil {
+		sources = append(sources, rtxt.Sitemaps...)
+	}
+
+	for _, path := range discovery.ConventionalPaths {
+		sources = append(sources, base+path)
+	}
+
+	for _, source := range sources {
+		if err := s.addSource(source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddFeeds registers feeds advertised by crawled pages through
+// <link rel="alternate" type="application/rss+xml">.
+func (s *Service) AddFeeds(urls []string) error {
+	for _, u := range urls {
+		if err := s.addSource(u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) addSource(link string) error {
+	hostname, err := junourl.ToHostname(link)
+
+	if err != nil {
+		return nil
+	}
+
+	return s.repo.AddSource(&discovery.Source{
+		URL:      link,
+		Hostname: hostname,
+	})
+}
+
+// Run fetches every due source on each interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		s.FetchDueSources()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+func (s *Service) FetchDueSources() {
+	sources, err := s.repo.DueSources(time.Now())
+
+	if err != nil {
+		s.logger.Errorf("failed to get due sources: %v", err)
+		return
+	}
+
+	for _, source := range sources {
+		s.fetchSource(source)
+	}
+}
+
+func (s *Service) fetchSource(source *discovery.Source) {
+	now := time.Now()
+	source.LastFetched = now
+
+	doc, err := s.fetch(source.URL)
+
+	if err != nil {
+		source.LastError = err.Error()
+		source.NextFetch = now.Add(discovery.FailedRefreshInterval)
+
+		if err := s.repo.UpdateSource(source); err != nil {
+			s.logger.Errorf("failed to update source: %v", err)
+		}
+		return
+	}
+
+	source.Kind = doc.Kind
+	source.LastError = ""
+	source.NextFetch = now.Add(discovery.SitemapRefreshInterval)
+
+	if doc.Kind == discovery.KindFeed {
+		source.NextFetch = now.Add(discovery.FeedRefreshInterval)
+	}
+
+	if err := s.repo.UpdateSource(source); err != nil {
+		s.logger.Errorf("failed to update source: %v", err)
+		return
+	}
+
+	for _, sitemap := range doc.Sitemaps {
+		if !sameHost(doc, source, sitemap) {
+			continue
+		}
+
+		if err := s.addSource(sitemap); err != nil {
+			s.logger.Errorf("failed to add sitemap: %v", err)
+		}
+	}
+
+	pushed := 0
+	for _, entry := range doc.Entries {
+		if !sameHost(doc, source, entry.URL) {
+			continue
+		}
+
+		ok, err := s.enqueue(entry, now)
+
+		if err != nil {
+			s.logger.Errorf("failed to enqueue discovered url: %v", err)
+			continue
+		}
+
+		if ok {
+			pushed++
+		}
+	}
+
+	s.logger.Infof("discovered %d urls from %s, pushed %d", len(doc.Entries), source.URL, pushed)
+}
+
+// sameHost reports whether a URL listed in a document may be followed. The
+// sitemap protocol only lets a sitemap list URLs of its own host, so a
+// sitemap can not be used to push other sites into the queue. Feeds
+// routinely link elsewhere and are not restricted.
+func sameHost(doc *discovery.Document, source *discovery.Source, link string) bool {
+	if doc.Kind == discovery.KindFeed {
+		return true
+	}
+
+	sourceHost, err := junourl.ToHostname(source.URL)
+
+	if err != nil {
+		return false
+	}
+
+	host, err := junourl.ToHostname(link)
+
+	return err == nil && host == sourceHost
+}
+
+func (s *Service) fetch(link string) (*discovery.Document, error) {
+	res, err := s.client.Get(link)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status code %d", discovery.ErrFailedFetch, res.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, discovery.MaxDocumentSize))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// enqueue pushes a discovered URL unless it was pushed before, has not been
+// modified since and its changefreq does not make it due yet.
+func (s *Service) enqueue(entry discovery.Entry, now time.Time) (bool, error) {
+	if !junourl.IsHTTPOrHTTPS(entry.URL) {
+		return false, nil
+	}
+
+	hint, err := s.repo.GetHint(entry.URL)
+
+	if err != nil && err != discovery.ErrHintNotFound {
+		return false, err
+	}
+
+	if hint != nil && !entry.LastMod.After(hint.LastMod) && now.Before(hint.NextDue) {
+		return false, nil
+	}
+
+	if s.scopeService != nil {
+		if ok, _ := s.scopeService.Check(entry.URL); !ok {
+			return false, nil
+		}
+	}
+
+	if !s.robotsTxtService.CanCrawlURL(entry.URL) {
+		return false, nil
+	}
+
+	if err := s.queueService.Push(entry.URL); err != nil {
+		return false, err
+	}
+
+	return true, s.repo.SetHint(&discovery.Hint{
+		URL:        entry.URL,
+		LastMod:    entry.LastMod,
+		ChangeFreq: entry.ChangeFreq,
+		NextDue:    now.Add(revisitInterval(entry.ChangeFreq)),
+	})
+}
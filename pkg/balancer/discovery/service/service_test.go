@@ -0,0 +1,249 @@
+package service
+
+import (
+	"juno/pkg/balancer/discovery"
+	"juno/pkg/balancer/discovery/repo/mem"
+	"testing"
+	"time"
+
+	queueRepo "juno/pkg/balancer/queue/repo/mem"
+	queueService "juno/pkg/balancer/queue/service"
+
+	robotstxtRepo "juno/pkg/balancer/robotstxt/repo/mem"
+	robotstxtService "juno/pkg/balancer/robotstxt/service"
+
+	"github.com/h2non/gock"
+	"github.com/sirupsen/logrus"
+)
+
+func setupService() (*Service, *mem.Repository, *queueRepo.Repository) {
+	logger := logrus.New()
+	repo := mem.New()
+	qRepo := queueRepo.New()
+
+	svc := New(
+		WithLogger(logger),
+		WithRepository(repo),
+		WithQueueService(queueService.New(logger, qRepo)),
+		WithRobotsTxtService(robotstxtService.New(robotstxtRepo.New())),
+	)
+
+	return svc, repo, qRepo
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should panic without a repository", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected a panic")
+			}
+		}()
+
+		New(WithLogger(logrus.New()))
+	})
+}
+
+func TestDiscoverHost(t *testing.T) {
+	t.Run("registers robots.txt sitemaps and conventional paths once", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Times(1).
+			Reply(200).
+			BodyString("User-agent: *\nSitemap: http://example.com/custom-sitemap.xml")
+
+		svc, repo, _ := setupService()
+
+		if err := svc.DiscoverHost("http://example.com/page"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if err := svc.DiscoverHost("http://example.com/other"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		sources, _ := repo.DueSources(time.Now())
+
+		if len(sources) != len(discovery.ConventionalPaths)+1 {
+			t.Fatalf("expected %d sources but got %d", len(discovery.ConventionalPaths)+1, len(sources))
+		}
+
+		found := false
+		for _, source := range sources {
+			if source.URL == "http://example.com/custom-sitemap.xml" {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("expected robots.txt sitemap to be registered")
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+}
+
+func TestFetchDueSources(t *testing.T) {
+	t.Run("pushes sitemap urls and follows sitemap indexes", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(404)
+
+		gock.New("http://example.com").
+			Get("/sitemap_index.xml").
+			Reply(200).
+			BodyString(`<sitemapindex><sitemap><loc>http://example.com/sitemap-1.xml</loc></sitemap></sitemapindex>`)
+
+		gock.New("http://example.com").
+			Get("/sitemap-1.xml").
+			Reply(200).
+			BodyString(`<urlset><url><loc>http://example.com/a</loc><changefreq>daily</changefreq></url></urlset>`)
+
+		svc, repo, qRepo := setupService()
+
+		repo.AddSource(&discovery.Source{URL: "http://example.com/sitemap_index.xml", Hostname: "example.com"})
+
+		svc.FetchDueSources()
+		svc.FetchDueSources()
+
+		url, err := qRepo.Pop()
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if url != "http://example.com/a" {
+			t.Errorf("expected http://example.com/a but got %s", url)
+		}
+
+		hint, err := repo.GetHint("http://example.com/a")
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if hint.NextDue.Before(time.Now().Add(23 * time.Hour)) {
+			t.Errorf("expected daily changefreq to schedule the next visit, got %v", hint.NextDue)
+		}
+
+		due, _ := repo.DueSources(time.Now())
+
+		if len(due) != 0 {
+			t.Errorf("expected fetched sources not to be due, got %d", len(due))
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("ignores sitemap urls of other hosts", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(404)
+
+		gock.New("http://example.com").
+			Get("/sitemap.xml").
+			Reply(200).
+			BodyString(`<urlset><url><loc>http://other.com/a</loc></url><url><loc>http://example.com/b</loc></url></urlset>`)
+
+		svc, repo, qRepo := setupService()
+
+		repo.AddSource(&discovery.Source{URL: "http://example.com/sitemap.xml", Hostname: "example.com"})
+
+		svc.FetchDueSources()
+
+		items, _ := qRepo.Peek(10)
+
+		if len(items) != 1 || items[0].URL != "http://example.com/b" {
+			t.Errorf("expected only http://example.com/b to be pushed but got %v", items)
+		}
+	})
+
+	t.Run("skips urls that have not changed and are not due", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(404)
+
+		gock.New("http://example.com").
+			Get("/sitemap.xml").
+			Reply(200).
+			BodyString(`<urlset>
+				<url><loc>http://example.com/old</loc><lastmod>2024-01-01</lastmod></url>
+				<url><loc>http://example.com/new</loc><lastmod>2024-06-01</lastmod></url>
+			</urlset>`)
+
+		svc, repo, qRepo := setupService()
+
+		repo.AddSource(&discovery.Source{URL: "http://example.com/sitemap.xml", Hostname: "example.com"})
+
+		for _, u := range []string{"http://example.com/old", "http://example.com/new"} {
+			repo.SetHint(&discovery.Hint{
+				URL:     u,
+				LastMod: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				NextDue: time.Now().Add(time.Hour),
+			})
+		}
+
+		svc.FetchDueSources()
+
+		url, err := qRepo.Pop()
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if url != "http://example.com/new" {
+			t.Errorf("expected http://example.com/new but got %s", url)
+		}
+
+		if _, err := qRepo.Pop(); err == nil {
+			t.Errorf("expected unchanged url not to be pushed")
+		}
+	})
+
+	t.Run("backs off missing sources", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/feed").
+			Reply(404)
+
+		svc, repo, _ := setupService()
+
+		repo.AddSource(&discovery.Source{URL: "http://example.com/feed", Hostname: "example.com"})
+
+		svc.FetchDueSources()
+
+		due, _ := repo.DueSources(time.Now().Add(discovery.FailedRefreshInterval - time.Minute))
+
+		if len(due) != 0 {
+			t.Errorf("expected missing source to back off")
+		}
+	})
+}
+
+func TestAddFeeds(t *testing.T) {
+	svc, repo, _ := setupService()
+
+	err := svc.AddFeeds([]string{"http://example.com/rss.xml", "not a url"})
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	sources, _ := repo.DueSources(time.Now())
+
+	if len(sources) != 1 || sources[0].URL != "http://example.com/rss.xml" {
+		t.Errorf("expected feed to be registered, got %v", sources)
+	}
+}
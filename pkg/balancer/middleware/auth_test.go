@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(token string) *gin.Engine {
+		r := gin.New()
+		r.Use(AdminAuth(token))
+		r.GET("/admin", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "Access granted"})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"missing authorization header", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"valid bearer token", "secret", "Bearer secret", http.StatusOK},
+		{"valid raw token", "secret", "secret", http.StatusOK},
+		{"admin api disabled", "", "Bearer ", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			newRouter(tt.token).ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d but got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
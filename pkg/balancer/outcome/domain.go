@@ -0,0 +1,36 @@
+package outcome
+
+import "time"
+
+const (
+	// DefaultHistory is how many outcomes are kept per host.
+	DefaultHistory = 20
+	// DefaultMaxHosts bounds memory; the host that was crawled least recently
+	// is forgotten first.
+	DefaultMaxHosts = 10000
+)
+
+type Status string
+
+const (
+	StatusCrawled Status = "crawled"
+	StatusFailed  Status = "failed"
+	StatusBlocked Status = "blocked"
+)
+
+// Outcome is the result of handing one queued URL to the nodes.
+type Outcome struct {
+	URL      string    `json:"url"`
+	Hostname string    `json:"hostname"`
+	Node     string    `json:"node,omitempty"`
+	Status   Status    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Attempts int       `json:"attempts"`
+	At       time.Time `json:"at"`
+}
+
+type Service interface {
+	Record(o *Outcome)
+	// Recent returns up to limit outcomes of a host, newest first.
+	Recent(hostname string, limit int) []*Outcome
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"juno/pkg/balancer/outcome"
+	"sync"
+	"time"
+)
+
+type history struct {
+	outcomes []*outcome.Outcome
+	next     int
+	last     time.Time
+}
+
+// Service keeps the latest outcomes of every host in memory. They are only
+// meant for operators to see what the balancer is doing, so they are not
+// persisted.
+type Service struct {
+	mu       sync.Mutex
+	size     int
+	maxHosts int
+	hosts    map[string]*history
+}
+
+func New(size, maxHosts int) *Service {
+	return &Service{
+		size:     size,
+		maxHosts: maxHosts,
+		hosts:    make(map[string]*history),
+	}
+}
+
+func (s *Service) Record(o *outcome.Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if o.At.IsZero() {
+		o.At = time.Now()
+	}
+
+	h, ok := s.hosts[o.Hostname]
+
+	if !ok {
+		if len(s.hosts) >= s.maxHosts {
+			s.evict()
+		}
+
+		h = &history{outcomes: make([]*outcome.Outcome, 0, s.size)}
+		s.hosts[o.Hostname] = h
+	}
+
+	if len(h.outcomes) < s.size {
+		h.outcomes = append(h.outcomes, o)
+	} else {
+		h.outcomes[h.next] = o
+	}
+
+	h.next = (h.next + 1) % s.size
+	h.last = o.At
+}
+
+func (s *Service) Recent(hostname string, limit int) []*outcome.Outcome {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recent := []*outcome.Outcome{}
+
+	h, ok := s.hosts[hostname]
+	if !ok {
+		return recent
+	}
+
+	n := len(h.outcomes)
+	for i := 1; i <= n && len(recent) < limit; i++ {
+		o := *h.outcomes[(h.next-i+n)%n]
+		recent = append(recent, &o)
+	}
+
+	return recent
+}
+
+// evict forgets the host whose last outcome is the oldest.
+func (s *Service) evict() {
+	var oldest string
+	var oldestAt time.Time
+
+	for hostname, h := range s.hosts {
+		if oldest == "" || h.last.Before(oldestAt) {
+			oldest = hostname
+			oldestAt = h.last
+		}
+	}
+
+	delete(s.hosts, oldest)
+}
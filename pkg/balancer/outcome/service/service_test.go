@@ -0,0 +1,69 @@
+package service
+
+import (
+	"juno/pkg/balancer/outcome"
+	"testing"
+	"time"
+)
+
+func TestRecent(t *testing.T) {
+	t.Run("returns the newest outcomes first", func(t *testing.T) {
+		s := New(3, 10)
+
+		for _, url := range []string{"http://example.com/a", "http://example.com/b", "http://example.com/c", "http://example.com/d"} {
+			s.Record(&outcome.Outcome{URL: url, Hostname: "example.com", Status: outcome.StatusCrawled})
+		}
+
+		recent := s.Recent("example.com", 10)
+
+		if len(recent) != 3 {
+			t.Fatalf("expected 3 outcomes, got %d", len(recent))
+		}
+
+		if recent[0].URL != "http://example.com/d" || recent[2].URL != "http://example.com/b" {
+			t.Errorf("expected d to b, got %s to %s", recent[0].URL, recent[2].URL)
+		}
+
+		if recent[0].At.IsZero() {
+			t.Errorf("expected the outcome time to be set")
+		}
+	})
+
+	t.Run("respects the limit", func(t *testing.T) {
+		s := New(3, 10)
+
+		s.Record(&outcome.Outcome{URL: "http://example.com/a", Hostname: "example.com"})
+		s.Record(&outcome.Outcome{URL: "http://example.com/b", Hostname: "example.com"})
+
+		recent := s.Recent("example.com", 1)
+
+		if len(recent) != 1 || recent[0].URL != "http://example.com/b" {
+			t.Errorf("expected only b, got %v", recent)
+		}
+	})
+
+	t.Run("returns nothing for unknown hosts", func(t *testing.T) {
+		s := New(3, 10)
+
+		if recent := s.Recent("example.com", 10); len(recent) != 0 {
+			t.Errorf("expected no outcomes, got %d", len(recent))
+		}
+	})
+
+	t.Run("forgets the least recently crawled host", func(t *testing.T) {
+		s := New(3, 2)
+
+		now := time.Now()
+		s.Record(&outcome.Outcome{Hostname: "a.com", At: now.Add(-time.Minute)})
+		s.Record(&outcome.Outcome{Hostname: "b.com", At: now})
+		s.Record(&outcome.Outcome{Hostname: "c.com", At: now})
+
+		if len(s.Recent("a.com", 10)) != 0 {
+			t.Errorf("expected a.com to be evicted")
+		}
+
+		if len(s.Recent("b.com", 10)) != 1 || len(s.Recent("c.com", 10)) != 1 {
+			t.Errorf("expected b.com and c.com to be kept")
+		}
+	})
+}
@@ -16,6 +16,13 @@ type CrawlPolicy struct {
 
 	// The number of times the hostname has been crawled
 	TimesCrawled int
+
+	// Blocked hosts are never crawled; their queued URLs are dropped
+	Blocked bool
+
+	// IntervalOverride is set when an operator chose the crawl interval, so
+	// a robots.txt Crawl-delay must not replace it
+	IntervalOverride bool
 }
 
 func New(hostname string) *CrawlPolicy {
@@ -30,11 +37,15 @@ func New(hostname string) *CrawlPolicy {
 type Repository interface {
 	Get(hostname string) (*CrawlPolicy, error)
 	Set(hostname string, policy *CrawlPolicy) error
+	// RecordCrawl sets LastCrawled and increments TimesCrawled in one step,
+	// leaving the rest of the policy untouched. A missing policy is created
+	// with the defaults.
+	RecordCrawl(hostname string, at time.Time) error
 }
 
 type Service interface {
 	Get(hostname string) (*CrawlPolicy, error)
 	Set(hostname string, policy *CrawlPolicy) error
 	CanCrawl(p *CrawlPolicy) bool
-	RecordCrawl(p *CrawlPolicy) error
+	RecordCrawl(hostname string) error
 }
@@ -3,6 +3,7 @@ package bolt
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"juno/pkg/balancer/policy"
 
@@ -75,3 +76,29 @@ func (r *Repository) Set(hostname string, p *policy.CrawlPolicy) error {
 		return b.Put([]byte(hostname), data)
 	})
 }
+
+// RecordCrawl updates the crawl time and count of a policy in a single
+// transaction, so concurrent changes to the rest of the policy are kept.
+func (r *Repository) RecordCrawl(hostname string, at time.Time) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("policies"))
+
+		p := policy.New(hostname)
+
+		if v := b.Get([]byte(hostname)); v != nil {
+			if err := json.Unmarshal(v, &p); err != nil {
+				return fmt.Errorf("failed to unmarshal policy: %w", err)
+			}
+		}
+
+		p.LastCrawled = at
+		p.TimesCrawled++
+
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal policy: %w", err)
+		}
+
+		return b.Put([]byte(hostname), data)
+	})
+}
@@ -73,3 +73,36 @@ func TestRepository_GetNonExistent(t *testing.T) {
 		t.Errorf("expected policy.ErrPolicyNotFound, got %v", err)
 	}
 }
+
+func TestRepository_RecordCrawl(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Record a crawl of a host without a policy
+	crawledAt := time.Now().Truncate(time.Second)
+	if err := repo.RecordCrawl("example.com", crawledAt); err != nil {
+		t.Fatalf("failed to record crawl: %v", err)
+	}
+
+	// Block the host and record another crawl
+	p, _ := repo.Get("example.com")
+	p.Blocked = true
+	repo.Set("example.com", p)
+
+	if err := repo.RecordCrawl("example.com", crawledAt.Add(time.Minute)); err != nil {
+		t.Fatalf("failed to record crawl: %v", err)
+	}
+
+	p, err := repo.Get("example.com")
+	if err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+
+	if p.TimesCrawled != 2 || !p.LastCrawled.Equal(crawledAt.Add(time.Minute)) {
+		t.Errorf("expected 2 crawls, the last at %v, got %+v", crawledAt.Add(time.Minute), p)
+	}
+
+	if !p.Blocked || p.CrawlInterval != policy.DefaultCrawlInterval {
+		t.Errorf("expected the rest of the policy to be kept, got %+v", p)
+	}
+}
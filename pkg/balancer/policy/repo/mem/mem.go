@@ -1,8 +1,13 @@
 package mem
 
-import "juno/pkg/balancer/policy"
+import (
+	"juno/pkg/balancer/policy"
+	"sync"
+	"time"
+)
 
 type Repository struct {
+	mu       sync.Mutex
 	policies map[string]*policy.CrawlPolicy
 }
 
@@ -13,6 +18,9 @@ func New() *Repository {
 }
 
 func (r *Repository) Get(hostname string) (*policy.CrawlPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	p, ok := r.policies[hostname]
 
 	if !ok {
@@ -23,7 +31,30 @@ func (r *Repository) Get(hostname string) (*policy.CrawlPolicy, error) {
 }
 
 func (r *Repository) Set(hostname string, policy *policy.CrawlPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.policies[hostname] = policy
 
 	return nil
 }
+
+func (r *Repository) RecordCrawl(hostname string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.policies[hostname]
+
+	if !ok {
+		p = policy.New(hostname)
+	}
+
+	// copy so callers holding the previous policy do not see it change
+	recorded := *p
+	recorded.LastCrawled = at
+	recorded.TimesCrawled++
+
+	r.policies[hostname] = &recorded
+
+	return nil
+}
@@ -16,6 +16,10 @@ func New(repo policy.Repository) *Service {
 }
 
 func (s *Service) CanCrawl(p *policy.CrawlPolicy) bool {
+	if p.Blocked {
+		return false
+	}
+
 	return time.Since(p.LastCrawled) > p.CrawlInterval
 }
 
@@ -23,11 +27,8 @@ func (s *Service) Get(hostname string) (*policy.CrawlPolicy, error) {
 	return s.repo.Get(hostname)
 }
 
-func (s *Service) RecordCrawl(p *policy.CrawlPolicy) error {
-	p.LastCrawled = time.Now()
-	p.TimesCrawled++
-
-	return s.repo.Set(p.Hostname, p)
+func (s *Service) RecordCrawl(hostname string) error {
+	return s.repo.RecordCrawl(hostname, time.Now())
 }
 
 func (s *Service) Set(hostname string, p *policy.CrawlPolicy) error {
@@ -37,6 +37,22 @@ func TestCanCrawl(t *testing.T) {
 			t.Errorf("expected true, got false")
 		}
 	})
+
+	t.Run("should return false when the host is blocked", func(t *testing.T) {
+		svc := New(mem.New())
+
+		p := &policy.CrawlPolicy{
+			CrawlInterval: 10 * time.Minute,
+			LastCrawled:   time.Now().Add(-15 * time.Minute),
+			Blocked:       true,
+		}
+
+		canCrawl := svc.CanCrawl(p)
+
+		if canCrawl {
+			t.Errorf("expected false, got true")
+		}
+	})
 }
 
 func TestRecordCrawl(t *testing.T) {
@@ -50,12 +66,12 @@ func TestRecordCrawl(t *testing.T) {
 			CrawlInterval: 5 * time.Second,
 		})
 
-		p, err := svc.Get("example.com")
+		err := svc.RecordCrawl("example.com")
 		if err != nil {
 			t.Fatalf("expected no error but got %v", err)
 		}
 
-		err = svc.RecordCrawl(p)
+		p, err := svc.Get("example.com")
 		if err != nil {
 			t.Fatalf("expected no error but got %v", err)
 		}
@@ -67,6 +83,28 @@ func TestRecordCrawl(t *testing.T) {
 		if p.TimesCrawled != 1 {
 			t.Errorf("expected times crawled to be 1 but got %d", p.TimesCrawled)
 		}
+
+		if p.CrawlInterval != 5*time.Second {
+			t.Errorf("expected crawl interval to be kept but got %v", p.CrawlInterval)
+		}
+	})
+
+	t.Run("should keep changes made while the host was crawled", func(t *testing.T) {
+		repo := mem.New()
+		svc := New(repo)
+
+		// an operator blocks the host while its url is being crawled
+		svc.Set("example.com", &policy.CrawlPolicy{Hostname: "example.com", Blocked: true})
+
+		if err := svc.RecordCrawl("example.com"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		p, _ := svc.Get("example.com")
+
+		if !p.Blocked || p.TimesCrawled != 1 {
+			t.Errorf("expected a blocked policy crawled once but got %+v", p)
+		}
 	})
 }
 
@@ -1,17 +1,99 @@
 package queue
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
 
 var ErrNoURLsInQueue = errors.New("no urls in queue")
 var ErrProcessQueueCancelled = errors.New("process queue cancelled")
+var ErrLeaseNotFound = errors.New("lease not found")
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+var ErrLeaseExpired = errors.New("lease expired")
+
+const (
+	// DefaultVisibilityTimeout is how long a leased URL stays invisible to
+	// other consumers before it is re-delivered.
+	DefaultVisibilityTimeout = 2 * time.Minute
+	DefaultMaxAttempts       = 5
+	DefaultRetryBackoff      = 30 * time.Second
+	DefaultMaxRetryBackoff   = 30 * time.Minute
+)
+
+// Item is a URL that has been leased from the queue. It must be acked,
+// released, retried or buried before LeasedUntil or it will be
+// re-delivered.
+type Item struct {
+	LeaseID     string    `json:"lease_id,omitempty"`
+	URL         string    `json:"url"`
+	Attempts    int       `json:"attempts"`
+	AvailableAt time.Time `json:"available_at"`
+	LeasedUntil time.Time `json:"leased_until"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Stats is the depth of the queue. The queue is FIFO without priority
+// levels, so depth is broken down by delivery state: fresh URLs first, then
+// retries whose backoff has elapsed, retries still backing off, leases in
+// flight and dead letters.
+type Stats struct {
+	Ready       int            `json:"ready"`
+	Retrying    int            `json:"retrying"`
+	Delayed     int            `json:"delayed"`
+	Leased      int            `json:"leased"`
+	DeadLetters int            `json:"dead_letters"`
+	Hosts       map[string]int `json:"hosts"`
+}
+
+type Handler interface {
+	DeadLetters(c *gin.Context)
+	Replay(c *gin.Context)
+}
 
 type Service interface {
 	Push(url string) error
 	Pop() (string, error)
+	Lease() (*Item, error)
+	Ack(item *Item) error
+	Release(item *Item) error
+	Fail(item *Item, err error) error
+	DeadLetters() ([]*Item, error)
+	Replay(url string) error
+	CountHost(hostname string) (int, error)
+	Stats() (*Stats, error)
+	Peek(n int) ([]*Item, error)
+	PurgeHost(hostname string) (int, error)
 }
 
 type Repository interface {
 	Exists(url string) (bool, error)
 	Push(url string) error
 	Pop() (string, error)
+
+	// Lease hands out the first available URL, re-delivering leases whose
+	// visibility timeout has expired before taking new URLs. Every delivery
+	// counts as an attempt, and expired leases that already used maxAttempts
+	// are dead-lettered instead of re-delivered.
+	Lease(visibility time.Duration, maxAttempts int) (*Item, error)
+	Ack(leaseID string) error
+	// Requeue returns a leased item to the back of the queue with its
+	// attempts, availability and last error.
+	Requeue(item *Item) error
+	// Bury moves a leased item to the dead-letter bucket.
+	Bury(item *Item) error
+	DeadLetters() ([]*Item, error)
+	Replay(url string) error
+	// CountHost returns how many URLs of a host are queued or leased.
+	CountHost(hostname string) (int, error)
+	// Stats counts queued, leased and dead-lettered URLs, with queued and
+	// leased URLs also counted per host.
+	Stats(now time.Time) (*Stats, error)
+	// Peek returns up to n URLs from the front of the queue without leasing
+	// them.
+	Peek(n int) ([]*Item, error)
+	// PurgeHost removes every queued URL of a host and returns how many were
+	// removed. Leased URLs are left to finish.
+	PurgeHost(hostname string) (int, error)
 }
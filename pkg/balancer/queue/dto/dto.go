@@ -0,0 +1,73 @@
+package dto
+
+import "juno/pkg/balancer/queue"
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type DeadLetter struct {
+	URL       string `json:"url"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func NewDeadLetterFromDomain(item *queue.Item) DeadLetter {
+	return DeadLetter{
+		URL:       item.URL,
+		Attempts:  item.Attempts,
+		LastError: item.LastError,
+	}
+}
+
+type DeadLettersResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	DeadLetters []DeadLetter `json:"dead_letters"`
+}
+
+func NewSuccessDeadLettersResponse(items []*queue.Item) DeadLettersResponse {
+	deadLetters := make([]DeadLetter, len(items))
+	for i, item := range items {
+		deadLetters[i] = NewDeadLetterFromDomain(item)
+	}
+
+	return DeadLettersResponse{
+		Status:      SUCCESS,
+		DeadLetters: deadLetters,
+	}
+}
+
+func NewErrorDeadLettersResponse(message string) DeadLettersResponse {
+	return DeadLettersResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
+
+type ReplayRequest struct {
+	URLs []string `json:"urls" binding:"required"`
+}
+
+type ReplayResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	Replayed []string `json:"replayed"`
+}
+
+func NewSuccessReplayResponse(replayed []string) ReplayResponse {
+	return ReplayResponse{
+		Status:   SUCCESS,
+		Replayed: replayed,
+	}
+}
+
+func NewErrorReplayResponse(message string) ReplayResponse {
+	return ReplayResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
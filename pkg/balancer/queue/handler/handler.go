@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"juno/pkg/balancer/queue"
+	"juno/pkg/balancer/queue/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger       *logrus.Logger
+	queueService queue.Service
+}
+
+func New(
+	logger *logrus.Logger,
+	queueService queue.Service,
+) *Handler {
+	return &Handler{
+		logger:       logger,
+		queueService: queueService,
+	}
+}
+
+func (h *Handler) DeadLetters(c *gin.Context) {
+	items, err := h.queueService.DeadLetters()
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to list dead letters")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorDeadLettersResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessDeadLettersResponse(items))
+}
+
+func (h *Handler) Replay(c *gin.Context) {
+	var req dto.ReplayRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorReplayResponse(err.Error()))
+		return
+	}
+
+	replayed := []string{}
+	for _, url := range req.URLs {
+		err := h.queueService.Replay(url)
+
+		if err == queue.ErrDeadLetterNotFound {
+			continue
+		}
+
+		if err != nil {
+			h.logger.WithError(err).Error("failed to replay dead letter")
+			c.JSON(http.StatusInternalServerError, dto.NewErrorReplayResponse(err.Error()))
+			return
+		}
+
+		replayed = append(replayed, url)
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessReplayResponse(replayed))
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"juno/pkg/balancer/queue"
+	"juno/pkg/balancer/queue/dto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	queueRepo "juno/pkg/balancer/queue/repo/mem"
+	queueService "juno/pkg/balancer/queue/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDeadLetters(t *testing.T) {
+	t.Run("should list dead letters", func(t *testing.T) {
+		repo := queueRepo.New()
+		repo.Push("http://example.com")
+		item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+		item.Attempts = 5
+		item.LastError = "node down"
+		repo.Bury(item)
+
+		h := New(logrus.New(), queueService.New(logrus.New(), repo))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/dead-letters", nil)
+
+		// When
+		h.DeadLetters(c)
+
+		// Then
+		if c.Writer.Status() != http.StatusOK {
+			t.Errorf("expected status 200 but got %d", c.Writer.Status())
+		}
+
+		var res dto.DeadLettersResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(res.DeadLetters) != 1 {
+			t.Fatalf("expected 1 dead letter but got %d", len(res.DeadLetters))
+		}
+
+		if res.DeadLetters[0].URL != "http://example.com" {
+			t.Errorf("expected http://example.com but got %s", res.DeadLetters[0].URL)
+		}
+
+		if res.DeadLetters[0].Attempts != 5 {
+			t.Errorf("expected 5 attempts but got %d", res.DeadLetters[0].Attempts)
+		}
+	})
+}
+
+func TestReplay(t *testing.T) {
+	t.Run("should replay dead letters", func(t *testing.T) {
+		repo := queueRepo.New()
+		repo.Push("http://example.com")
+		item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+		repo.Bury(item)
+
+		h := New(logrus.New(), queueService.New(logrus.New(), repo))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/dead-letters/replay", strings.NewReader(`{"urls": ["http://example.com", "http://unknown.com"]}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		// When
+		h.Replay(c)
+
+		// Then
+		if c.Writer.Status() != http.StatusOK {
+			t.Errorf("expected status 200 but got %d", c.Writer.Status())
+		}
+
+		var res dto.ReplayResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(res.Replayed) != 1 || res.Replayed[0] != "http://example.com" {
+			t.Errorf("expected http://example.com to be replayed but got %v", res.Replayed)
+		}
+
+		pop, err := repo.Pop()
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if pop != "http://example.com" {
+			t.Errorf("expected http://example.com but got %s", pop)
+		}
+	})
+
+	t.Run("should return bad request on invalid body", func(t *testing.T) {
+		h := New(logrus.New(), queueService.New(logrus.New(), queueRepo.New()))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/dead-letters/replay", strings.NewReader(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Replay(c)
+
+		if c.Writer.Status() != http.StatusBadRequest {
+			t.Errorf("expected status 400 but got %d", c.Writer.Status())
+		}
+	})
+}
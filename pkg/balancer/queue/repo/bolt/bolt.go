@@ -1,12 +1,25 @@
 package bolt
 
 import (
+	"encoding/json"
 	"fmt"
 	"juno/pkg/balancer/queue"
+	"strconv"
+	"time"
+
+	junourl "juno/pkg/url"
 
 	bolt "go.etcd.io/bbolt"
 )
 
+var (
+	queueBucket       = []byte("url_queue")
+	metaBucket        = []byte("url_meta")
+	leasesBucket      = []byte("url_leases")
+	deadLettersBucket = []byte("url_dead_letters")
+	hostCountsBucket  = []byte("url_host_counts")
+)
+
 type Repository struct {
 	db *bolt.DB
 }
@@ -18,10 +31,14 @@ func New(dbPath string) (*Repository, error) {
 		return nil, err
 	}
 
-	// Create the bucket for the queue if it doesn't exist
+	// Create the buckets for the queue if they don't exist
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("url_queue"))
-		return err
+		for _, name := range [][]byte{queueBucket, metaBucket, leasesBucket, deadLettersBucket, hostCountsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -30,22 +47,32 @@ func New(dbPath string) (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
-// Exists checks if a URL is already in the queue.
+// Exists checks if a URL is already in the queue or currently leased.
 func (r *Repository) Exists(url string) (bool, error) {
 	var exists bool
 	err := r.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("url_queue"))
+		b := tx.Bucket(queueBucket)
 
 		// Iterate over each item in the bucket
 		cursor := b.Cursor()
 		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
 			if string(v) == url {
 				exists = true
-				break
+				return nil
 			}
 		}
 
-		return nil
+		return tx.Bucket(leasesBucket).ForEach(func(k, v []byte) error {
+			item, err := decodeItem(v)
+			if err != nil {
+				return err
+			}
+
+			if item.URL == url {
+				exists = true
+			}
+			return nil
+		})
 	})
 	if err != nil {
 		return false, err
@@ -56,16 +83,7 @@ func (r *Repository) Exists(url string) (bool, error) {
 // Push adds a URL to the queue by appending it to the end.
 func (r *Repository) Push(url string) error {
 	return r.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("url_queue"))
-
-		// Get the last sequence number
-		seq, err := b.NextSequence()
-		if err != nil {
-			return err
-		}
-
-		// Use the sequence number as the implicit key
-		return b.Put(itob(seq), []byte(url))
+		return push(tx, url)
 	})
 }
 
@@ -73,7 +91,7 @@ func (r *Repository) Push(url string) error {
 func (r *Repository) Pop() (string, error) {
 	var url string
 	err := r.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("url_queue"))
+		b := tx.Bucket(queueBucket)
 
 		// Start from the first item in the bucket
 		cursor := b.Cursor()
@@ -86,6 +104,14 @@ func (r *Repository) Pop() (string, error) {
 		// Store the first value (URL)
 		url = string(value)
 
+		if err := tx.Bucket(metaBucket).Delete(value); err != nil {
+			return err
+		}
+
+		if err := adjustHostCount(tx, url, -1); err != nil {
+			return err
+		}
+
 		// Delete the item from the queue
 		return b.Delete(key)
 	})
@@ -95,6 +121,436 @@ func (r *Repository) Pop() (string, error) {
 	return url, nil
 }
 
+// Lease hands out the oldest expired lease, or otherwise the first URL in
+// the queue that is available, and hides it for the visibility timeout.
+// Expired leases that already used maxAttempts are dead-lettered.
+func (r *Repository) Lease(visibility time.Duration, maxAttempts int) (*queue.Item, error) {
+	var item *queue.Item
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		leases := tx.Bucket(leasesBucket)
+
+		// collect first, deleting while iterating skips keys
+		var exhausted []*queue.Item
+		err := leases.ForEach(func(k, v []byte) error {
+			l, err := decodeItem(v)
+			if err != nil {
+				return err
+			}
+
+			if !l.LeasedUntil.Before(now) {
+				return nil
+			}
+
+			if maxAttempts > 0 && l.Attempts >= maxAttempts {
+				exhausted = append(exhausted, l)
+				return nil
+			}
+
+			if item == nil || l.LeasedUntil.Before(item.LeasedUntil) {
+				item = l
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, l := range exhausted {
+			// the last delivery was never acknowledged either
+			l.LastError = queue.ErrLeaseExpired.Error()
+			if err := bury(tx, l); err != nil {
+				return err
+			}
+		}
+
+		if item != nil {
+			// the previous delivery was never acknowledged, so it is
+			// re-delivered under a new lease that the previous holder can no
+			// longer ack
+			if err := leases.Delete([]byte(item.LeaseID)); err != nil {
+				return err
+			}
+
+			return lease(leases, item, now, visibility)
+		}
+
+		b := tx.Bucket(queueBucket)
+		meta := tx.Bucket(metaBucket)
+
+		cursor := b.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			candidate := &queue.Item{URL: string(v)}
+
+			if m := meta.Get(v); m != nil {
+				candidate, err = decodeItem(m)
+				if err != nil {
+					return err
+				}
+			}
+
+			if candidate.AvailableAt.After(now) {
+				continue
+			}
+
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+
+			if err := meta.Delete(v); err != nil {
+				return err
+			}
+
+			item = candidate
+			return lease(leases, item, now, visibility)
+		}
+
+		// commit the dead-lettered leases even though nothing is handed out
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, queue.ErrNoURLsInQueue
+	}
+	return item, nil
+}
+
+// lease counts a delivery attempt and stores the item under a new lease.
+func lease(leases *bolt.Bucket, item *queue.Item, now time.Time, visibility time.Duration) error {
+	seq, err := leases.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	item.LeaseID = string(itob(seq))
+	item.LeasedUntil = now.Add(visibility)
+	item.Attempts++
+
+	return putItem(leases, []byte(item.LeaseID), item)
+}
+
+// Ack removes a lease once its URL has been handled.
+func (r *Repository) Ack(leaseID string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		leases := tx.Bucket(leasesBucket)
+		v := leases.Get([]byte(leaseID))
+
+		if v == nil {
+			return queue.ErrLeaseNotFound
+		}
+
+		item, err := decodeItem(v)
+		if err != nil {
+			return err
+		}
+
+		if err := adjustHostCount(tx, item.URL, -1); err != nil {
+			return err
+		}
+
+		return leases.Delete([]byte(leaseID))
+	})
+}
+
+// Requeue returns a leased item to the end of the queue, keeping its
+// attempts, availability and last error.
+func (r *Repository) Requeue(item *queue.Item) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		leases := tx.Bucket(leasesBucket)
+
+		if leases.Get([]byte(item.LeaseID)) == nil {
+			return queue.ErrLeaseNotFound
+		}
+
+		if err := leases.Delete([]byte(item.LeaseID)); err != nil {
+			return err
+		}
+
+		requeued := *item
+		requeued.LeaseID = ""
+		requeued.LeasedUntil = time.Time{}
+
+		if err := putItem(tx.Bucket(metaBucket), []byte(item.URL), &requeued); err != nil {
+			return err
+		}
+
+		if err := adjustHostCount(tx, item.URL, -1); err != nil {
+			return err
+		}
+
+		return push(tx, item.URL)
+	})
+}
+
+// Bury moves a leased item to the dead-letter bucket.
+func (r *Repository) Bury(item *queue.Item) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(leasesBucket).Get([]byte(item.LeaseID)) == nil {
+			return queue.ErrLeaseNotFound
+		}
+
+		return bury(tx, item)
+	})
+}
+
+// DeadLetters returns every URL that exhausted its attempts.
+func (r *Repository) DeadLetters() ([]*queue.Item, error) {
+	items := []*queue.Item{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadLettersBucket).ForEach(func(k, v []byte) error {
+			item, err := decodeItem(v)
+			if err != nil {
+				return err
+			}
+
+			items = append(items, item)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Replay moves a dead letter back onto the queue with its attempts reset.
+func (r *Repository) Replay(url string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		deadLetters := tx.Bucket(deadLettersBucket)
+
+		if deadLetters.Get([]byte(url)) == nil {
+			return queue.ErrDeadLetterNotFound
+		}
+
+		if err := deadLetters.Delete([]byte(url)); err != nil {
+			return err
+		}
+
+		return push(tx, url)
+	})
+}
+
+func bury(tx *bolt.Tx, item *queue.Item) error {
+	if err := tx.Bucket(leasesBucket).Delete([]byte(item.LeaseID)); err != nil {
+		return err
+	}
+
+	if err := adjustHostCount(tx, item.URL, -1); err != nil {
+		return err
+	}
+
+	buried := *item
+	buried.LeaseID = ""
+	buried.LeasedUntil = time.Time{}
+
+	return putItem(tx.Bucket(deadLettersBucket), []byte(item.URL), &buried)
+}
+
+func push(tx *bolt.Tx, url string) error {
+	b := tx.Bucket(queueBucket)
+
+	// Get the last sequence number
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	if err := adjustHostCount(tx, url, 1); err != nil {
+		return err
+	}
+
+	// Use the sequence number as the implicit key
+	return b.Put(itob(seq), []byte(url))
+}
+
+// CountHost returns how many URLs of a host are queued or leased.
+func (r *Repository) CountHost(hostname string) (int, error) {
+	var count int
+	err := r.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(hostCountsBucket).Get([]byte(hostname))
+
+		if v == nil {
+			return nil
+		}
+
+		var err error
+		count, err = strconv.Atoi(string(v))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Stats counts the queue by delivery state. Per-host depth comes from the
+// host counters, so it includes leased URLs.
+func (r *Repository) Stats(now time.Time) (*queue.Stats, error) {
+	stats := &queue.Stats{Hosts: make(map[string]int)}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+
+		err := tx.Bucket(queueBucket).ForEach(func(k, v []byte) error {
+			item := &queue.Item{URL: string(v)}
+
+			if m := meta.Get(v); m != nil {
+				var err error
+				item, err = decodeItem(m)
+				if err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case item.AvailableAt.After(now):
+				stats.Delayed++
+			case item.Attempts > 0:
+				stats.Retrying++
+			default:
+				stats.Ready++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		stats.Leased = tx.Bucket(leasesBucket).Stats().KeyN
+		stats.DeadLetters = tx.Bucket(deadLettersBucket).Stats().KeyN
+
+		return tx.Bucket(hostCountsBucket).ForEach(func(k, v []byte) error {
+			count, err := strconv.Atoi(string(v))
+			if err != nil {
+				return err
+			}
+
+			stats.Hosts[string(k)] = count
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Peek returns the first n URLs in the queue without leasing them.
+func (r *Repository) Peek(n int) ([]*queue.Item, error) {
+	items := []*queue.Item{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+
+		cursor := tx.Bucket(queueBucket).Cursor()
+		for k, v := cursor.First(); k != nil && len(items) < n; k, v = cursor.Next() {
+			item := &queue.Item{URL: string(v)}
+
+			if m := meta.Get(v); m != nil {
+				var err error
+				item, err = decodeItem(m)
+				if err != nil {
+					return err
+				}
+			}
+
+			items = append(items, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PurgeHost deletes every queued URL of a host.
+func (r *Repository) PurgeHost(hostname string) (int, error) {
+	var purged int
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		meta := tx.Bucket(metaBucket)
+
+		// collect first, deleting while iterating skips keys
+		var keys, urls [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if h, err := junourl.ToHostname(string(v)); err == nil && h == hostname {
+				keys = append(keys, append([]byte{}, k...))
+				urls = append(urls, append([]byte{}, v...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+
+			if err := meta.Delete(urls[i]); err != nil {
+				return err
+			}
+
+			if err := adjustHostCount(tx, string(urls[i]), -1); err != nil {
+				return err
+			}
+		}
+
+		purged = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// adjustHostCount keeps a per-host counter so CountHost does not need to
+// scan the queue.
+func adjustHostCount(tx *bolt.Tx, url string, delta int) error {
+	hostname, err := junourl.ToHostname(url)
+	if err != nil {
+		return nil
+	}
+
+	b := tx.Bucket(hostCountsBucket)
+
+	count := 0
+	if v := b.Get([]byte(hostname)); v != nil {
+		count, err = strconv.Atoi(string(v))
+		if err != nil {
+			return err
+		}
+	}
+
+	count += delta
+
+	if count <= 0 {
+		return b.Delete([]byte(hostname))
+	}
+
+	return b.Put([]byte(hostname), []byte(strconv.Itoa(count)))
+}
+
+func putItem(b *bolt.Bucket, key []byte, item *queue.Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue item: %w", err)
+	}
+
+	return b.Put(key, data)
+}
+
+func decodeItem(data []byte) (*queue.Item, error) {
+	var item queue.Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal queue item: %w", err)
+	}
+	return &item, nil
+}
+
 // itob converts an integer to a byte slice (for BoltDB keys).
 func itob(v uint64) []byte {
 	return []byte(fmt.Sprintf("%d", v))
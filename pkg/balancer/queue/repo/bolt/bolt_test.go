@@ -4,6 +4,7 @@ import (
 	"juno/pkg/balancer/queue"
 	"os"
 	"testing"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
@@ -122,3 +123,285 @@ func TestPop(t *testing.T) {
 		t.Error("expected error when popping from empty queue, got nil")
 	}
 }
+
+func TestLease(t *testing.T) {
+	dbPath := "test_queue_lease.db"
+	defer os.Remove(dbPath) // Clean up after test
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	defer repo.db.Close()
+
+	repo.Push("https://example.com")
+	repo.Push("https://another-example.com")
+
+	// Lease the first URL with a short visibility timeout
+	first, err := repo.Lease(time.Millisecond, queue.DefaultMaxAttempts)
+	if err != nil {
+		t.Fatalf("failed to lease: %v", err)
+	}
+	if first.URL != "https://example.com" {
+		t.Errorf("expected https://example.com, got %s", first.URL)
+	}
+
+	exists, _ := repo.Exists("https://example.com")
+	if !exists {
+		t.Errorf("expected leased url to exist")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	// The expired lease is re-delivered before new URLs
+	redelivered, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if err != nil {
+		t.Fatalf("failed to lease: %v", err)
+	}
+	if redelivered.URL != first.URL || redelivered.LeaseID == first.LeaseID {
+		t.Errorf("expected %s under a new lease, got %+v", first.URL, redelivered)
+	}
+	if redelivered.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", redelivered.Attempts)
+	}
+
+	// The previous holder can no longer ack the re-delivered URL
+	if err := repo.Ack(first.LeaseID); err != queue.ErrLeaseNotFound {
+		t.Errorf("expected ErrLeaseNotFound, got %v", err)
+	}
+
+	if err := repo.Ack(redelivered.LeaseID); err != nil {
+		t.Fatalf("failed to ack: %v", err)
+	}
+
+	second, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if err != nil {
+		t.Fatalf("failed to lease: %v", err)
+	}
+	if second.URL != "https://another-example.com" {
+		t.Errorf("expected https://another-example.com, got %s", second.URL)
+	}
+
+	// Requeue with a backoff so it is not available yet
+	second.Attempts = 1
+	second.AvailableAt = time.Now().Add(time.Hour)
+	if err := repo.Requeue(second); err != nil {
+		t.Fatalf("failed to requeue: %v", err)
+	}
+
+	_, err = repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if err != queue.ErrNoURLsInQueue {
+		t.Errorf("expected ErrNoURLsInQueue, got %v", err)
+	}
+}
+
+func TestLeaseDeadLettersExhaustedLeases(t *testing.T) {
+	dbPath := "test_queue_lease_exhausted.db"
+	defer os.Remove(dbPath) // Clean up after test
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	defer repo.db.Close()
+
+	repo.Push("https://example.com")
+
+	// Two deliveries that are never acknowledged
+	for i := 0; i < 2; i++ {
+		if _, err := repo.Lease(time.Millisecond, 2); err != nil {
+			t.Fatalf("failed to lease: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := repo.Lease(time.Minute, 2); err != queue.ErrNoURLsInQueue {
+		t.Errorf("expected ErrNoURLsInQueue, got %v", err)
+	}
+
+	deadLetters, _ := repo.DeadLetters()
+	if len(deadLetters) != 1 || deadLetters[0].Attempts != 2 || deadLetters[0].LastError != queue.ErrLeaseExpired.Error() {
+		t.Fatalf("expected the expired lease to be dead-lettered, got %+v", deadLetters)
+	}
+
+	count, _ := repo.CountHost("example.com")
+	if count != 0 {
+		t.Errorf("expected 0 urls for example.com, got %d", count)
+	}
+}
+
+func TestBuryAndReplay(t *testing.T) {
+	dbPath := "test_queue_bury.db"
+	defer os.Remove(dbPath) // Clean up after test
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	defer repo.db.Close()
+
+	repo.Push("https://example.com")
+
+	item, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if err != nil {
+		t.Fatalf("failed to lease: %v", err)
+	}
+
+	item.Attempts = 5
+	item.LastError = "node down"
+	if err := repo.Bury(item); err != nil {
+		t.Fatalf("failed to bury: %v", err)
+	}
+
+	deadLetters, err := repo.DeadLetters()
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %v", err)
+	}
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(deadLetters))
+	}
+	if deadLetters[0].Attempts != 5 || deadLetters[0].LastError != "node down" {
+		t.Errorf("unexpected dead letter: %+v", deadLetters[0])
+	}
+
+	if err := repo.Replay("https://example.com"); err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+
+	replayed, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if err != nil {
+		t.Fatalf("failed to lease: %v", err)
+	}
+	if replayed.URL != "https://example.com" || replayed.Attempts != 1 {
+		t.Errorf("unexpected replayed item: %+v", replayed)
+	}
+
+	if err := repo.Replay("https://example.com"); err != queue.ErrDeadLetterNotFound {
+		t.Errorf("expected ErrDeadLetterNotFound, got %v", err)
+	}
+}
+
+func TestCountHost(t *testing.T) {
+	dbPath := "test_queue_count.db"
+	defer os.Remove(dbPath) // Clean up after test
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	defer repo.db.Close()
+
+	repo.Push("https://example.com/a")
+	repo.Push("https://example.com/b")
+	repo.Push("https://another-example.com")
+
+	count, err := repo.CountHost("example.com")
+	if err != nil {
+		t.Fatalf("failed to count host: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2, got %d", count)
+	}
+
+	// Leased URLs still count until they are acked
+	item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	count, _ = repo.CountHost("example.com")
+	if count != 2 {
+		t.Errorf("expected 2, got %d", count)
+	}
+
+	repo.Requeue(item)
+	count, _ = repo.CountHost("example.com")
+	if count != 2 {
+		t.Errorf("expected 2 after requeue, got %d", count)
+	}
+
+	// b and another-example.com are now ahead of the requeued url
+	repo.Pop()
+	repo.Pop()
+	item, _ = repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	repo.Ack(item.LeaseID)
+
+	count, _ = repo.CountHost("example.com")
+	if count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+}
+
+func TestStats(t *testing.T) {
+	dbPath := "test_queue_stats.db"
+	defer os.Remove(dbPath) // Clean up after test
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	defer repo.db.Close()
+
+	repo.Push("https://example.com/a")
+	repo.Push("https://example.com/b")
+	repo.Push("https://another-example.com")
+
+	item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	item.Attempts = 1
+	item.AvailableAt = time.Now().Add(time.Hour)
+	repo.Requeue(item)
+
+	repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+	stats, err := repo.Stats(time.Now())
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+
+	if stats.Ready != 1 || stats.Delayed != 1 || stats.Leased != 1 || stats.Retrying != 0 {
+		t.Errorf("expected 1 ready, delayed and leased, got %+v", stats)
+	}
+
+	if stats.Hosts["example.com"] != 2 {
+		t.Errorf("expected 2 urls for example.com, got %d", stats.Hosts["example.com"])
+	}
+}
+
+func TestPeekAndPurgeHost(t *testing.T) {
+	dbPath := "test_queue_purge.db"
+	defer os.Remove(dbPath) // Clean up after test
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	defer repo.db.Close()
+
+	repo.Push("https://example.com/a")
+	repo.Push("https://another-example.com")
+	repo.Push("https://example.com/b")
+
+	items, err := repo.Peek(2)
+	if err != nil {
+		t.Fatalf("failed to peek: %v", err)
+	}
+
+	if len(items) != 2 || items[0].URL != "https://example.com/a" || items[1].URL != "https://another-example.com" {
+		t.Fatalf("unexpected peek result: %+v", items)
+	}
+
+	purged, err := repo.PurgeHost("example.com")
+	if err != nil {
+		t.Fatalf("failed to purge host: %v", err)
+	}
+
+	if purged != 2 {
+		t.Errorf("expected 2 purged, got %d", purged)
+	}
+
+	count, _ := repo.CountHost("example.com")
+	if count != 0 {
+		t.Errorf("expected 0 urls left for example.com, got %d", count)
+	}
+
+	items, _ = repo.Peek(10)
+	if len(items) != 1 || items[0].URL != "https://another-example.com" {
+		t.Errorf("expected only another-example.com to remain, got %+v", items)
+	}
+}
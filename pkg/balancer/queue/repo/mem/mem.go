@@ -1,36 +1,314 @@
 package mem
 
-import "juno/pkg/balancer/queue"
+import (
+	"juno/pkg/balancer/queue"
+	junourl "juno/pkg/url"
+	"strconv"
+	"sync"
+	"time"
+)
 
 type Repository struct {
-	urls []string
+	mu          sync.Mutex
+	urls        []string
+	meta        map[string]*queue.Item
+	leases      map[string]*queue.Item
+	leaseSeq    int
+	deadLetters []*queue.Item
 }
 
 func New() *Repository {
-	return &Repository{}
+	return &Repository{
+		meta:   make(map[string]*queue.Item),
+		leases: make(map[string]*queue.Item),
+	}
 }
 
 func (r *Repository) Exists(url string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	for _, u := range r.urls {
 		if u == url {
 			return true, nil
 		}
 	}
 
+	for _, l := range r.leases {
+		if l.URL == url {
+			return true, nil
+		}
+	}
+
 	return false, nil
 }
 
 func (r *Repository) Push(url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.urls = append(r.urls, url)
 	return nil
 }
 
 func (r *Repository) Pop() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if len(r.urls) == 0 {
 		return "", queue.ErrNoURLsInQueue
 	}
 
 	url := r.urls[0]
 	r.urls = r.urls[1:]
+	delete(r.meta, url)
 	return url, nil
 }
+
+func (r *Repository) Lease(visibility time.Duration, maxAttempts int) (*queue.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var expired *queue.Item
+	for id, l := range r.leases {
+		if !l.LeasedUntil.Before(now) {
+			continue
+		}
+
+		if maxAttempts > 0 && l.Attempts >= maxAttempts {
+			// the last delivery was never acknowledged either
+			delete(r.leases, id)
+
+			buried := *l
+			buried.LeaseID = ""
+			buried.LeasedUntil = time.Time{}
+			buried.LastError = queue.ErrLeaseExpired.Error()
+
+			r.deadLetters = append(r.deadLetters, &buried)
+			continue
+		}
+
+		if expired == nil || l.LeasedUntil.Before(expired.LeasedUntil) {
+			expired = l
+		}
+	}
+
+	if expired != nil {
+		// the previous delivery was never acknowledged, so it is re-delivered
+		// under a new lease that the previous holder can no longer ack
+		delete(r.leases, expired.LeaseID)
+		return r.lease(expired, now, visibility), nil
+	}
+
+	for i, u := range r.urls {
+		item, ok := r.meta[u]
+		if !ok {
+			item = &queue.Item{URL: u}
+		}
+
+		if item.AvailableAt.After(now) {
+			continue
+		}
+
+		r.urls = append(r.urls[:i:i], r.urls[i+1:]...)
+		delete(r.meta, u)
+
+		return r.lease(item, now, visibility), nil
+	}
+
+	return nil, queue.ErrNoURLsInQueue
+}
+
+// lease counts a delivery attempt and stores the item under a new lease.
+func (r *Repository) lease(item *queue.Item, now time.Time, visibility time.Duration) *queue.Item {
+	r.leaseSeq++
+	item.LeaseID = strconv.Itoa(r.leaseSeq)
+	item.LeasedUntil = now.Add(visibility)
+	item.Attempts++
+	r.leases[item.LeaseID] = item
+
+	leased := *item
+	return &leased
+}
+
+func (r *Repository) Ack(leaseID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.leases[leaseID]; !ok {
+		return queue.ErrLeaseNotFound
+	}
+
+	delete(r.leases, leaseID)
+	return nil
+}
+
+func (r *Repository) Requeue(item *queue.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.leases[item.LeaseID]; !ok {
+		return queue.ErrLeaseNotFound
+	}
+
+	delete(r.leases, item.LeaseID)
+
+	requeued := *item
+	requeued.LeaseID = ""
+	requeued.LeasedUntil = time.Time{}
+
+	r.urls = append(r.urls, item.URL)
+	r.meta[item.URL] = &requeued
+	return nil
+}
+
+func (r *Repository) Bury(item *queue.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.leases[item.LeaseID]; !ok {
+		return queue.ErrLeaseNotFound
+	}
+
+	delete(r.leases, item.LeaseID)
+
+	buried := *item
+	buried.LeaseID = ""
+	buried.LeasedUntil = time.Time{}
+
+	r.deadLetters = append(r.deadLetters, &buried)
+	return nil
+}
+
+func (r *Repository) DeadLetters() ([]*queue.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := make([]*queue.Item, len(r.deadLetters))
+	for i, d := range r.deadLetters {
+		item := *d
+		items[i] = &item
+	}
+
+	return items, nil
+}
+
+func (r *Repository) Replay(url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, d := range r.deadLetters {
+		if d.URL != url {
+			continue
+		}
+
+		r.deadLetters = append(r.deadLetters[:i:i], r.deadLetters[i+1:]...)
+		r.urls = append(r.urls, url)
+		return nil
+	}
+
+	return queue.ErrDeadLetterNotFound
+}
+
+func (r *Repository) CountHost(hostname string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, u := range r.urls {
+		if h, err := junourl.ToHostname(u); err == nil && h == hostname {
+			count++
+		}
+	}
+
+	for _, l := range r.leases {
+		if h, err := junourl.ToHostname(l.URL); err == nil && h == hostname {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *Repository) Stats(now time.Time) (*queue.Stats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := &queue.Stats{
+		Leased:      len(r.leases),
+		DeadLetters: len(r.deadLetters),
+		Hosts:       make(map[string]int),
+	}
+
+	for _, u := range r.urls {
+		item, ok := r.meta[u]
+		if !ok {
+			item = &queue.Item{URL: u}
+		}
+
+		switch {
+		case item.AvailableAt.After(now):
+			stats.Delayed++
+		case item.Attempts > 0:
+			stats.Retrying++
+		default:
+			stats.Ready++
+		}
+
+		if h, err := junourl.ToHostname(u); err == nil {
+			stats.Hosts[h]++
+		}
+	}
+
+	for _, l := range r.leases {
+		if h, err := junourl.ToHostname(l.URL); err == nil {
+			stats.Hosts[h]++
+		}
+	}
+
+	return stats, nil
+}
+
+func (r *Repository) Peek(n int) ([]*queue.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := []*queue.Item{}
+	for _, u := range r.urls {
+		if len(items) >= n {
+			break
+		}
+
+		item := queue.Item{URL: u}
+		if m, ok := r.meta[u]; ok {
+			item = *m
+		}
+
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+func (r *Repository) PurgeHost(hostname string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	urls := r.urls[:0:0]
+	purged := 0
+
+	for _, u := range r.urls {
+		if h, err := junourl.ToHostname(u); err == nil && h == hostname {
+			delete(r.meta, u)
+			purged++
+			continue
+		}
+
+		urls = append(urls, u)
+	}
+
+	r.urls = urls
+	return purged, nil
+}
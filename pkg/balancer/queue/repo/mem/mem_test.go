@@ -1,6 +1,10 @@
 package mem
 
-import "testing"
+import (
+	"juno/pkg/balancer/queue"
+	"testing"
+	"time"
+)
 
 func TestExists(t *testing.T) {
 	t.Run("should return false when url does not exist", func(t *testing.T) {
@@ -102,3 +106,290 @@ func TestPop(t *testing.T) {
 		}
 	})
 }
+
+func TestLease(t *testing.T) {
+	t.Run("should lease first available url", func(t *testing.T) {
+		// Given
+		repo := New()
+		repo.Push("http://example.com")
+
+		// When
+		item, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+		// Then
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if item.URL != "http://example.com" {
+			t.Errorf("expected http://example.com but got %s", item.URL)
+		}
+
+		if len(repo.urls) != 0 {
+			t.Errorf("expected 0 urls but got %d", len(repo.urls))
+		}
+
+		if _, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts); err != queue.ErrNoURLsInQueue {
+			t.Errorf("expected ErrNoURLsInQueue but got %v", err)
+		}
+	})
+
+	t.Run("should skip urls that are backing off", func(t *testing.T) {
+		// Given
+		repo := New()
+		repo.Push("http://example.com")
+		item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+		item.AvailableAt = time.Now().Add(time.Hour)
+		repo.Requeue(item)
+		repo.Push("http://other.com")
+
+		// When
+		leased, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+		// Then
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if leased.URL != "http://other.com" {
+			t.Errorf("expected http://other.com but got %s", leased.URL)
+		}
+	})
+
+	t.Run("should re-deliver expired leases", func(t *testing.T) {
+		// Given
+		repo := New()
+		repo.Push("http://example.com")
+		first, _ := repo.Lease(time.Millisecond, queue.DefaultMaxAttempts)
+
+		time.Sleep(5 * time.Millisecond)
+
+		// When
+		second, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+		// Then
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if second.URL != first.URL || second.LeaseID == first.LeaseID {
+			t.Errorf("expected %s under a new lease but got %+v", first.URL, second)
+		}
+
+		if second.Attempts != 2 {
+			t.Errorf("expected 2 attempts but got %d", second.Attempts)
+		}
+
+		if err := repo.Ack(first.LeaseID); err != queue.ErrLeaseNotFound {
+			t.Errorf("expected ErrLeaseNotFound but got %v", err)
+		}
+	})
+
+	t.Run("should dead-letter expired leases at max attempts", func(t *testing.T) {
+		// Given
+		repo := New()
+		repo.Push("http://example.com")
+		repo.Lease(time.Millisecond, 2)
+		time.Sleep(5 * time.Millisecond)
+		repo.Lease(time.Millisecond, 2)
+		time.Sleep(5 * time.Millisecond)
+
+		// When
+		_, err := repo.Lease(time.Minute, 2)
+
+		// Then
+		if err != queue.ErrNoURLsInQueue {
+			t.Errorf("expected ErrNoURLsInQueue but got %v", err)
+		}
+
+		if len(repo.leases) != 0 {
+			t.Errorf("expected 0 leases but got %d", len(repo.leases))
+		}
+
+		if len(repo.deadLetters) != 1 || repo.deadLetters[0].Attempts != 2 {
+			t.Fatalf("expected the expired lease to be dead-lettered but got %v", repo.deadLetters)
+		}
+
+		if repo.deadLetters[0].LastError != queue.ErrLeaseExpired.Error() {
+			t.Errorf("expected %s but got %s", queue.ErrLeaseExpired, repo.deadLetters[0].LastError)
+		}
+	})
+}
+
+func TestAck(t *testing.T) {
+	repo := New()
+	repo.Push("http://example.com")
+	item, _ := repo.Lease(time.Millisecond, queue.DefaultMaxAttempts)
+
+	if err := repo.Ack(item.LeaseID); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts); err != queue.ErrNoURLsInQueue {
+		t.Errorf("expected ErrNoURLsInQueue but got %v", err)
+	}
+
+	if err := repo.Ack(item.LeaseID); err != queue.ErrLeaseNotFound {
+		t.Errorf("expected ErrLeaseNotFound but got %v", err)
+	}
+}
+
+func TestBuryAndReplay(t *testing.T) {
+	repo := New()
+	repo.Push("http://example.com")
+	item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	item.Attempts = 5
+	item.LastError = "node down"
+
+	if err := repo.Bury(item); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	deadLetters, err := repo.DeadLetters()
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if len(deadLetters) != 1 || deadLetters[0].URL != "http://example.com" {
+		t.Fatalf("expected http://example.com to be dead-lettered but got %v", deadLetters)
+	}
+
+	if deadLetters[0].LastError != "node down" {
+		t.Errorf("expected node down but got %s", deadLetters[0].LastError)
+	}
+
+	if err := repo.Replay("http://example.com"); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	replayed, err := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if replayed.Attempts != 1 {
+		t.Errorf("expected attempts to be reset to the new delivery but got %d", replayed.Attempts)
+	}
+
+	if err := repo.Replay("http://example.com"); err != queue.ErrDeadLetterNotFound {
+		t.Errorf("expected ErrDeadLetterNotFound but got %v", err)
+	}
+}
+
+func TestCountHost(t *testing.T) {
+	repo := New()
+	repo.Push("http://example.com/a")
+	repo.Push("http://example.com/b")
+	repo.Push("http://other.com")
+	repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+	count, err := repo.CountHost("example.com")
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 but got %d", count)
+	}
+}
+
+func TestStats(t *testing.T) {
+	repo := New()
+	repo.Push("http://example.com/a")
+	repo.Push("http://example.com/b")
+	repo.Push("http://example.com/c")
+	repo.Push("http://other.com")
+
+	// a is leased and fails, so it backs off
+	item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	item.Attempts = 1
+	item.AvailableAt = time.Now().Add(time.Hour)
+	repo.Requeue(item)
+
+	// b is leased and fails, but its backoff already elapsed
+	item, _ = repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	item.Attempts = 1
+	repo.Requeue(item)
+
+	// c stays leased
+	repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+	stats, err := repo.Stats(time.Now())
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if stats.Ready != 1 || stats.Retrying != 1 || stats.Delayed != 1 || stats.Leased != 1 {
+		t.Errorf("expected 1 ready, retrying, delayed and leased but got %+v", stats)
+	}
+
+	if stats.Hosts["example.com"] != 3 {
+		t.Errorf("expected 3 urls for example.com but got %d", stats.Hosts["example.com"])
+	}
+
+	if stats.Hosts["other.com"] != 1 {
+		t.Errorf("expected 1 url for other.com but got %d", stats.Hosts["other.com"])
+	}
+}
+
+func TestPeek(t *testing.T) {
+	repo := New()
+	repo.Push("http://example.com/a")
+	repo.Push("http://example.com/b")
+	repo.Push("http://example.com/c")
+
+	items, err := repo.Peek(2)
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items but got %d", len(items))
+	}
+
+	if items[0].URL != "http://example.com/a" || items[1].URL != "http://example.com/b" {
+		t.Errorf("expected a and b but got %s and %s", items[0].URL, items[1].URL)
+	}
+
+	// peeking does not lease
+	item, _ := repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+	if item.URL != "http://example.com/a" {
+		t.Errorf("expected a to still be first but got %s", item.URL)
+	}
+}
+
+func TestPurgeHost(t *testing.T) {
+	repo := New()
+	repo.Push("http://example.com/a")
+	repo.Push("http://other.com")
+	repo.Push("http://example.com/b")
+	repo.Push("http://example.com/c")
+
+	// leased urls are left alone
+	repo.Lease(time.Minute, queue.DefaultMaxAttempts)
+
+	purged, err := repo.PurgeHost("example.com")
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if purged != 2 {
+		t.Errorf("expected 2 purged but got %d", purged)
+	}
+
+	count, _ := repo.CountHost("example.com")
+	if count != 1 {
+		t.Errorf("expected only the leased url to remain but got %d", count)
+	}
+
+	url, _ := repo.Pop()
+	if url != "http://other.com" {
+		t.Errorf("expected http://other.com but got %s", url)
+	}
+}
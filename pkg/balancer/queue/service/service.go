@@ -2,24 +2,59 @@ package service
 
 import (
 	"juno/pkg/balancer/queue"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+func WithVisibilityTimeout(timeout time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.visibilityTimeout = timeout
+	}
+}
+
+func WithMaxAttempts(attempts int) func(s *Service) {
+	return func(s *Service) {
+		s.maxAttempts = attempts
+	}
+}
+
+func WithRetryBackoff(base, max time.Duration) func(s *Service) {
+	return func(s *Service) {
+		s.retryBackoff = base
+		s.maxRetryBackoff = max
+	}
+}
+
 type Service struct {
 	logger *logrus.Logger
 	repo   queue.Repository
+
+	visibilityTimeout time.Duration
+	maxAttempts       int
+	retryBackoff      time.Duration
+	maxRetryBackoff   time.Duration
 }
 
 func New(
 	logger *logrus.Logger,
 	repo queue.Repository,
-
+	options ...func(s *Service),
 ) *Service {
-	return &Service{
-		logger: logger,
-		repo:   repo,
+	s := &Service{
+		logger:            logger,
+		repo:              repo,
+		visibilityTimeout: queue.DefaultVisibilityTimeout,
+		maxAttempts:       queue.DefaultMaxAttempts,
+		retryBackoff:      queue.DefaultRetryBackoff,
+		maxRetryBackoff:   queue.DefaultMaxRetryBackoff,
+	}
+
+	for _, option := range options {
+		option(s)
 	}
+
+	return s
 }
 
 func (s *Service) Push(url string) error {
@@ -39,3 +74,80 @@ func (s *Service) Push(url string) error {
 func (s *Service) Pop() (string, error) {
 	return s.repo.Pop()
 }
+
+func (s *Service) Lease() (*queue.Item, error) {
+	return s.repo.Lease(s.visibilityTimeout, s.maxAttempts)
+}
+
+func (s *Service) Ack(item *queue.Item) error {
+	return s.repo.Ack(item.LeaseID)
+}
+
+// Release puts a leased URL back on the queue without counting an attempt,
+// e.g. when the host's crawl policy does not allow crawling it yet.
+func (s *Service) Release(item *queue.Item) error {
+	// the lease counted the delivery, hand it back
+	if item.Attempts > 0 {
+		item.Attempts--
+	}
+
+	return s.repo.Requeue(item)
+}
+
+// Fail records a failed attempt. The attempt was already counted when the
+// URL was leased. The URL is retried with exponential backoff until it
+// reaches the max attempts, after which it is dead-lettered.
+func (s *Service) Fail(item *queue.Item, err error) error {
+	item.LastError = err.Error()
+
+	if item.Attempts >= s.maxAttempts {
+		s.logger.Warnf("url %s failed %d times, moving to dead letters", item.URL, item.Attempts)
+		return s.repo.Bury(item)
+	}
+
+	item.AvailableAt = time.Now().Add(s.backoff(item.Attempts))
+
+	return s.repo.Requeue(item)
+}
+
+func (s *Service) DeadLetters() ([]*queue.Item, error) {
+	return s.repo.DeadLetters()
+}
+
+func (s *Service) Replay(url string) error {
+	return s.repo.Replay(url)
+}
+
+func (s *Service) CountHost(hostname string) (int, error) {
+	return s.repo.CountHost(hostname)
+}
+
+func (s *Service) Stats() (*queue.Stats, error) {
+	return s.repo.Stats(time.Now())
+}
+
+func (s *Service) Peek(n int) ([]*queue.Item, error) {
+	return s.repo.Peek(n)
+}
+
+func (s *Service) PurgeHost(hostname string) (int, error) {
+	purged, err := s.repo.PurgeHost(hostname)
+
+	if err == nil && purged > 0 {
+		s.logger.Infof("purged %d urls of %s from the queue", purged, hostname)
+	}
+
+	return purged, err
+}
+
+func (s *Service) backoff(attempts int) time.Duration {
+	d := s.retryBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= s.maxRetryBackoff {
+			return s.maxRetryBackoff
+		}
+	}
+
+	return d
+}
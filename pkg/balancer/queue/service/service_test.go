@@ -2,7 +2,9 @@ package service
 
 import (
 	"errors"
+	"juno/pkg/balancer/queue"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -11,6 +13,11 @@ type mockQueueRepo struct {
 	pushedURL string
 	withError error
 	exists    bool
+
+	requeued *queue.Item
+	buried   *queue.Item
+
+	purgedHost string
 }
 
 func (m *mockQueueRepo) Push(url string) error {
@@ -26,6 +33,49 @@ func (m *mockQueueRepo) Exists(url string) (bool, error) {
 	return m.exists, nil
 }
 
+func (m *mockQueueRepo) Lease(visibility time.Duration, maxAttempts int) (*queue.Item, error) {
+	return nil, queue.ErrNoURLsInQueue
+}
+
+func (m *mockQueueRepo) Ack(leaseID string) error {
+	return m.withError
+}
+
+func (m *mockQueueRepo) Requeue(item *queue.Item) error {
+	m.requeued = item
+	return m.withError
+}
+
+func (m *mockQueueRepo) Bury(item *queue.Item) error {
+	m.buried = item
+	return m.withError
+}
+
+func (m *mockQueueRepo) DeadLetters() ([]*queue.Item, error) {
+	return nil, m.withError
+}
+
+func (m *mockQueueRepo) Replay(url string) error {
+	return m.withError
+}
+
+func (m *mockQueueRepo) CountHost(hostname string) (int, error) {
+	return 0, m.withError
+}
+
+func (m *mockQueueRepo) Stats(now time.Time) (*queue.Stats, error) {
+	return &queue.Stats{}, m.withError
+}
+
+func (m *mockQueueRepo) Peek(n int) ([]*queue.Item, error) {
+	return nil, m.withError
+}
+
+func (m *mockQueueRepo) PurgeHost(hostname string) (int, error) {
+	m.purgedHost = hostname
+	return 3, m.withError
+}
+
 func TestPush(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		repo := &mockQueueRepo{}
@@ -68,3 +118,116 @@ func TestPush(t *testing.T) {
 		}
 	})
 }
+
+func TestFail(t *testing.T) {
+	t.Run("requeues with backoff", func(t *testing.T) {
+		repo := &mockQueueRepo{}
+		service := New(logrus.New(), repo, WithRetryBackoff(time.Minute, time.Hour))
+
+		// the second delivery was counted when it was leased
+		item := &queue.Item{LeaseID: "1", URL: "http://example.com", Attempts: 2}
+
+		err := service.Fail(item, errors.New("node down"))
+
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		if repo.requeued != item {
+			t.Fatalf("expected item to be requeued")
+		}
+
+		if item.Attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", item.Attempts)
+		}
+
+		if item.LastError != "node down" {
+			t.Errorf("expected last error to be node down, got %s", item.LastError)
+		}
+
+		// second failure waits twice the base backoff
+		if item.AvailableAt.Before(time.Now().Add(119 * time.Second)) {
+			t.Errorf("expected available at to be at least 2 minutes away, got %v", item.AvailableAt)
+		}
+	})
+
+	t.Run("buries after max attempts", func(t *testing.T) {
+		repo := &mockQueueRepo{}
+		service := New(logrus.New(), repo, WithMaxAttempts(3))
+
+		item := &queue.Item{LeaseID: "1", URL: "http://example.com", Attempts: 3}
+
+		err := service.Fail(item, errors.New("node down"))
+
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		if repo.buried != item {
+			t.Errorf("expected item to be buried")
+		}
+
+		if repo.requeued != nil {
+			t.Errorf("expected item not to be requeued")
+		}
+	})
+}
+
+func TestRelease(t *testing.T) {
+	repo := &mockQueueRepo{}
+	service := New(logrus.New(), repo)
+
+	// the lease counted the delivery
+	item := &queue.Item{LeaseID: "1", URL: "http://example.com", Attempts: 2}
+
+	err := service.Release(item)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if repo.requeued != item {
+		t.Fatalf("expected item to be requeued")
+	}
+
+	if item.Attempts != 1 {
+		t.Errorf("expected the delivery not to be counted, got %d", item.Attempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	service := New(logrus.New(), &mockQueueRepo{}, WithRetryBackoff(time.Second, 5*time.Second))
+
+	cases := map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 5 * time.Second,
+		9: 5 * time.Second,
+	}
+
+	for attempts, expected := range cases {
+		if got := service.backoff(attempts); got != expected {
+			t.Errorf("attempts %d: expected %v, got %v", attempts, expected, got)
+		}
+	}
+}
+
+func TestPurgeHost(t *testing.T) {
+	repo := &mockQueueRepo{}
+	service := New(logrus.New(), repo)
+
+	purged, err := service.PurgeHost("example.com")
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if purged != 3 {
+		t.Errorf("expected 3 purged, got %d", purged)
+	}
+
+	if repo.purgedHost != "example.com" {
+		t.Errorf("expected example.com to be purged, got %q", repo.purgedHost)
+	}
+}
@@ -1,15 +1,64 @@
 package robotstxt
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var ErrRobotsTxtNotInCache = errors.New("robots.txt not in cache")
 var ErrCoultNotFetchRobotsTxt = errors.New("could not fetch robots.txt")
 
+const (
+	// DefaultTTL is used when the response carries no cache headers. RFC 9309
+	// asks crawlers not to use a cached robots.txt for more than 24 hours.
+	DefaultTTL = 24 * time.Hour
+	MaxTTL     = 24 * time.Hour
+	MinTTL     = time.Minute
+
+	// UnreachableTTL is how long a host stays disallowed after a 5xx or a
+	// network error before robots.txt is fetched again.
+	UnreachableTTL = 10 * time.Minute
+
+	// MaxSize is the amount of robots.txt that is parsed, RFC 9309 requires
+	// at least 500 KiB.
+	MaxSize = 500 * 1024
+
+	FetchTimeout = 10 * time.Second
+)
+
+type Status string
+
+const (
+	// StatusAvailable means robots.txt was fetched and its rules apply.
+	StatusAvailable Status = "available"
+	// StatusUnavailable means a 4xx was returned, crawling is fully allowed.
+	StatusUnavailable Status = "unavailable"
+	// StatusUnreachable means a 5xx or a network error, crawling is fully
+	// disallowed until the entry expires.
+	StatusUnreachable Status = "unreachable"
+)
+
+type RobotsTxt struct {
+	Hostname   string
+	Status     Status
+	StatusCode int
+	Body       string
+	CrawlDelay time.Duration
+	Sitemaps   []string
+	FetchedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+func (r *RobotsTxt) Expired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
+
 type Repository interface {
-	Get(hostname string) (string, error)
-	Set(hostname, robotsTxt string) error
+	Get(hostname string) (*RobotsTxt, error)
+	Set(hostname string, robotsTxt *RobotsTxt) error
 }
 
 type Service interface {
 	CanCrawlURL(url string) bool
+	Get(url string) (*RobotsTxt, error)
 }
@@ -0,0 +1,77 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"juno/pkg/balancer/robotstxt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type Repository struct {
+	db *bolt.DB
+}
+
+// New initializes a new BoltDB repository.
+func New(dbPath string) (*Repository, error) {
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a bucket for robots.txt files if it doesn't exist
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte("robotstxt"))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repository{db: db}, nil
+}
+
+// Get retrieves a cached robots.txt by hostname from the BoltDB store.
+func (r *Repository) Get(hostname string) (*robotstxt.RobotsTxt, error) {
+	var rtxt *robotstxt.RobotsTxt
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("robotstxt"))
+		v := b.Get([]byte(hostname))
+
+		if v == nil {
+			return robotstxt.ErrRobotsTxtNotInCache
+		}
+
+		// Deserialize the robots.txt
+		err := json.Unmarshal(v, &rtxt)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal robots.txt: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rtxt, nil
+}
+
+// Set stores a robots.txt by hostname in the BoltDB store.
+func (r *Repository) Set(hostname string, rtxt *robotstxt.RobotsTxt) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("robotstxt"))
+
+		// Serialize the robots.txt
+		data, err := json.Marshal(rtxt)
+		if err != nil {
+			return fmt.Errorf("failed to marshal robots.txt: %w", err)
+		}
+
+		// Store the serialized robots.txt
+		return b.Put([]byte(hostname), data)
+	})
+}
@@ -0,0 +1,74 @@
+package bolt
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"juno/pkg/balancer/robotstxt"
+)
+
+func setupTestRepo(t *testing.T) (*Repository, func()) {
+	// Create a temporary BoltDB file for testing
+	dbPath := "test_robotstxt.db"
+
+	repo, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	// Cleanup function
+	cleanup := func() {
+		repo.db.Close()
+		os.Remove(dbPath)
+	}
+
+	return repo, cleanup
+}
+
+func TestRepository_SetAndGet(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	err := repo.Set("example.com", &robotstxt.RobotsTxt{
+		Hostname:   "example.com",
+		Status:     robotstxt.StatusAvailable,
+		StatusCode: 200,
+		Body:       "User-agent: *\nCrawl-delay: 5",
+		CrawlDelay: 5 * time.Second,
+		Sitemaps:   []string{"https://example.com/sitemap.xml"},
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("failed to set robots.txt: %v", err)
+	}
+
+	rtxt, err := repo.Get("example.com")
+	if err != nil {
+		t.Fatalf("failed to get robots.txt: %v", err)
+	}
+
+	if rtxt.CrawlDelay != 5*time.Second {
+		t.Errorf("expected crawl delay of 5s, got %v", rtxt.CrawlDelay)
+	}
+
+	if len(rtxt.Sitemaps) != 1 || rtxt.Sitemaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("expected sitemap to be stored, got %v", rtxt.Sitemaps)
+	}
+
+	if !rtxt.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected expires at %v, got %v", expiresAt, rtxt.ExpiresAt)
+	}
+}
+
+func TestRepository_GetNotFound(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	_, err := repo.Get("unknown.com")
+	if err != robotstxt.ErrRobotsTxtNotInCache {
+		t.Errorf("expected ErrRobotsTxtNotInCache, got %v", err)
+	}
+}
@@ -1,27 +1,36 @@
 package mem
 
-import "juno/pkg/balancer/robotstxt"
+import (
+	"juno/pkg/balancer/robotstxt"
+	"sync"
+)
 
 type Repository struct {
-	robotsTxt map[string]string
+	mu        sync.Mutex
+	robotsTxt map[string]*robotstxt.RobotsTxt
 }
 
 func New() *Repository {
 	return &Repository{
-		robotsTxt: make(map[string]string),
+		robotsTxt: make(map[string]*robotstxt.RobotsTxt),
 	}
 }
 
-func (r *Repository) Get(hostname string) (string, error) {
+func (r *Repository) Get(hostname string) (*robotstxt.RobotsTxt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	if val, ok := r.robotsTxt[hostname]; ok {
 		return val, nil
 	}
 
-	return "", robotstxt.ErrRobotsTxtNotInCache
+	return nil, robotstxt.ErrRobotsTxtNotInCache
 }
 
-func (r *Repository) Set(hostname, robotsTxt string) error {
+func (r *Repository) Set(hostname string, robotsTxt *robotstxt.RobotsTxt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.robotsTxt[hostname] = robotsTxt
 	return nil
 }
@@ -1,21 +1,34 @@
 package mem
 
-import "testing"
+import (
+	"juno/pkg/balancer/robotstxt"
+	"testing"
+)
 
 func TestGet(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		repo := New()
 
-		repo.Set("http://example.com", "User-agent: *\nDisallow: /private")
+		repo.Set("example.com", &robotstxt.RobotsTxt{Body: "User-agent: *\nDisallow: /private"})
 
-		hit, err := repo.Get("http://example.com")
+		hit, err := repo.Get("example.com")
 
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		if hit != "User-agent: *\nDisallow: /private" {
-			t.Errorf("expected hit to be User-agent: *\nDisallow: /private, got %s", hit)
+		if hit.Body != "User-agent: *\nDisallow: /private" {
+			t.Errorf("expected hit to be User-agent: *\nDisallow: /private, got %s", hit.Body)
+		}
+	})
+
+	t.Run("not in cache", func(t *testing.T) {
+		repo := New()
+
+		_, err := repo.Get("example.com")
+
+		if err != robotstxt.ErrRobotsTxtNotInCache {
+			t.Errorf("expected ErrRobotsTxtNotInCache, got %v", err)
 		}
 	})
 }
@@ -24,20 +37,20 @@ func TestSet(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		repo := New()
 
-		err := repo.Set("http://example.com", "User-agent: *\nDisallow: /private")
+		err := repo.Set("example.com", &robotstxt.RobotsTxt{Body: "User-agent: *\nDisallow: /private"})
 
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		hit, err := repo.Get("http://example.com")
+		hit, err := repo.Get("example.com")
 
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		if hit != "User-agent: *\nDisallow: /private" {
-			t.Errorf("expected hit to be User-agent: *\nDisallow: /private, got %s", hit)
+		if hit.Body != "User-agent: *\nDisallow: /private" {
+			t.Errorf("expected hit to be User-agent: *\nDisallow: /private, got %s", hit.Body)
 		}
 	})
 }
@@ -3,38 +3,89 @@ package service
 import (
 	"fmt"
 	"io"
+	"juno/pkg/balancer/policy"
 	"juno/pkg/balancer/robotstxt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	junourl "juno/pkg/url"
 
+	"github.com/sirupsen/logrus"
 	temtorobots "github.com/temoto/robotstxt"
 )
 
+const agent = "JunoBot/1.0"
+
+func WithLogger(logger *logrus.Logger) func(s *Service) {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+func WithHTTPClient(client *http.Client) func(s *Service) {
+	return func(s *Service) {
+		s.client = client
+	}
+}
+
+// WithPolicyService feeds the Crawl-delay of every fetched robots.txt into
+// the host's crawl policy.
+func WithPolicyService(policyService policy.Service) func(s *Service) {
+	return func(s *Service) {
+		s.policyService = policyService
+	}
+}
+
 type Service struct {
-	repo robotstxt.Repository
+	logger        *logrus.Logger
+	repo          robotstxt.Repository
+	client        *http.Client
+	policyService policy.Service
 }
 
-func New(repo robotstxt.Repository) *Service {
-	return &Service{
-		repo: repo,
+func New(repo robotstxt.Repository, options ...func(s *Service)) *Service {
+	s := &Service{
+		logger: logrus.New(),
+		repo:   repo,
+		client: &http.Client{Timeout: robotstxt.FetchTimeout},
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
-func isAllowed(robotsTxt string, url string) bool {
+func isAllowed(rtxt *robotstxt.RobotsTxt, link string) bool {
+	switch rtxt.Status {
+	case robotstxt.StatusUnavailable:
+		return true
+	case robotstxt.StatusUnreachable:
+		return false
+	}
+
+	robots, err := temtorobots.FromString(rtxt.Body)
+
+	if err != nil {
+		return false
+	}
 
-	robots, err := temtorobots.FromString(robotsTxt)
+	u, err := url.Parse(link)
 
 	if err != nil {
 		return false
 	}
 
-	agent := "JunoBot/1.0"
+	path := u.RequestURI()
 
-	return robots.TestAgent(url, agent)
+	return robots.TestAgent(path, agent)
 }
 
-func (s *Service) fetchRobotsTxt(https bool, hostname string) (string, error) {
+func (s *Service) fetchRobotsTxt(https bool, hostname string) (*robotstxt.RobotsTxt, error) {
 
 	proto := "http"
 
@@ -44,44 +95,164 @@ func (s *Service) fetchRobotsTxt(https bool, hostname string) (string, error) {
 
 	robotsURL := fmt.Sprintf("%s://%s/robots.txt", proto, hostname)
 
-	fmt.Println(robotsURL)
-
-	// try both http and https
-	res, err := http.Get(robotsURL)
+	res, err := s.client.Get(robotsURL)
 
 	if err != nil {
-		return "", robotstxt.ErrCoultNotFetchRobotsTxt
+		return nil, robotstxt.ErrCoultNotFetchRobotsTxt
 	}
 
 	defer res.Body.Close()
 
-	data, err := io.ReadAll(res.Body)
+	now := time.Now()
+	rtxt := &robotstxt.RobotsTxt{
+		Hostname:   hostname,
+		StatusCode: res.StatusCode,
+		FetchedAt:  now,
+	}
 
-	if err != nil {
-		return "", robotstxt.ErrCoultNotFetchRobotsTxt
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		data, err := io.ReadAll(io.LimitReader(res.Body, robotstxt.MaxSize))
+
+		if err != nil {
+			return nil, robotstxt.ErrCoultNotFetchRobotsTxt
+		}
+
+		rtxt.Status = robotstxt.StatusAvailable
+		rtxt.Body = string(data)
+
+		if robots, err := temtorobots.FromBytes(data); err == nil {
+			rtxt.CrawlDelay = robots.FindGroup(agent).CrawlDelay
+			rtxt.Sitemaps = robots.Sitemaps
+		}
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		rtxt.Status = robotstxt.StatusUnavailable
+	default:
+		return nil, robotstxt.ErrCoultNotFetchRobotsTxt
 	}
 
-	return string(data), nil
+	rtxt.ExpiresAt = now.Add(ttl(res.Header, now))
+
+	return rtxt, nil
 }
 
-func (s *Service) CanCrawlURL(url string) bool {
+// ttl derives how long a robots.txt can be cached from the Cache-Control
+// and Expires headers, clamped to [MinTTL, MaxTTL].
+func ttl(header http.Header, now time.Time) time.Duration {
+	d := robotstxt.DefaultTTL
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		d = expires.Sub(now)
+	}
 
-	hostname, err := junourl.ToHostname(url)
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			d = 0
+		case strings.HasPrefix(directive, "max-age="):
+			if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				d = time.Duration(secs) * time.Second
+			}
+		}
+	}
+
+	if d < robotstxt.MinTTL {
+		return robotstxt.MinTTL
+	}
+
+	if d > robotstxt.MaxTTL {
+		return robotstxt.MaxTTL
+	}
+
+	return d
+}
+
+// Get returns the robots.txt for the URL's host, fetching it when it is not
+// cached or has expired. If the host is unreachable a previously fetched copy
+// keeps being used, otherwise the host is disallowed until UnreachableTTL.
+func (s *Service) Get(link string) (*robotstxt.RobotsTxt, error) {
+	hostname, err := junourl.ToHostname(link)
 
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	rtxt, err := s.repo.Get(hostname)
-	if err == robotstxt.ErrRobotsTxtNotInCache {
-		isHTTPS := junourl.IsHTTPS(url)
-		rtxt, _ = s.fetchRobotsTxt(isHTTPS, hostname)
+	cached, err := s.repo.Get(hostname)
 
-		err = s.repo.Set(hostname, rtxt)
+	if err == nil && !cached.Expired() {
+		return cached, nil
+	}
 
-		if err != nil {
-			return false
+	if err != nil && err != robotstxt.ErrRobotsTxtNotInCache {
+		return nil, err
+	}
+
+	rtxt, err := s.fetchRobotsTxt(junourl.IsHTTPS(link), hostname)
+
+	if err != nil {
+		now := time.Now()
+
+		if cached != nil && cached.Status != robotstxt.StatusUnreachable {
+			rtxt = cached
+		} else {
+			rtxt = &robotstxt.RobotsTxt{
+				Hostname:  hostname,
+				Status:    robotstxt.StatusUnreachable,
+				FetchedAt: now,
+			}
 		}
+
+		rtxt.ExpiresAt = now.Add(robotstxt.UnreachableTTL)
+	}
+
+	if err := s.repo.Set(hostname, rtxt); err != nil {
+		return nil, err
+	}
+
+	// the robots.txt is cached by now, so a failure here is not retried
+	// until it expires
+	if err := s.applyCrawlDelay(rtxt); err != nil {
+		s.logger.Errorf("failed to apply crawl delay of %s: %v", hostname, err)
+	}
+
+	return rtxt, nil
+}
+
+func (s *Service) applyCrawlDelay(rtxt *robotstxt.RobotsTxt) error {
+	if s.policyService == nil || rtxt.CrawlDelay == 0 {
+		return nil
+	}
+
+	pol, err := s.policyService.Get(rtxt.Hostname)
+
+	if err == policy.ErrPolicyNotFound {
+		pol = policy.New(rtxt.Hostname)
+	} else if err != nil {
+		return err
+	}
+
+	if pol.IntervalOverride {
+		return nil
+	}
+
+	interval := max(policy.DefaultCrawlInterval, rtxt.CrawlDelay)
+
+	if pol.CrawlInterval == interval {
+		return nil
+	}
+
+	pol.CrawlInterval = interval
+	return s.policyService.Set(rtxt.Hostname, pol)
+}
+
+func (s *Service) CanCrawlURL(url string) bool {
+
+	rtxt, err := s.Get(url)
+
+	if err != nil {
+		return false
 	}
 
 	return isAllowed(rtxt, url)
@@ -1,8 +1,16 @@
 package service
 
 import (
+	"errors"
+	"juno/pkg/balancer/policy"
+	"juno/pkg/balancer/robotstxt"
 	"juno/pkg/balancer/robotstxt/repo/mem"
+	"net/http"
 	"testing"
+	"time"
+
+	polRepo "juno/pkg/balancer/policy/repo/mem"
+	polService "juno/pkg/balancer/policy/service"
 
 	"github.com/h2non/gock"
 )
@@ -32,8 +40,8 @@ func TestCanCrawlURL(t *testing.T) {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		if hit != "User-agent: *\nDisallow: /private" {
-			t.Errorf("expected hit to be User-agent: *\nDisallow: /private, got %s", hit)
+		if hit.Body != "User-agent: *\nDisallow: /private" {
+			t.Errorf("expected hit to be User-agent: *\nDisallow: /private, got %s", hit.Body)
 		}
 
 		if !gock.IsDone() {
@@ -64,8 +72,8 @@ func TestCanCrawlURL(t *testing.T) {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		if hit != "" {
-			t.Errorf("expected hit to be empty, got %s", hit)
+		if hit.Status != robotstxt.StatusUnavailable {
+			t.Errorf("expected hit to be unavailable, got %s", hit.Status)
 		}
 
 		if !gock.IsDone() {
@@ -97,12 +105,318 @@ func TestCanCrawlURL(t *testing.T) {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		if hit != "User-agent: *\nDisallow: /" {
-			t.Errorf("expected hit to be User-agent: *\nDisallow: /, got %s", hit)
+		if hit.Body != "User-agent: *\nDisallow: /" {
+			t.Errorf("expected hit to be User-agent: *\nDisallow: /, got %s", hit.Body)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("expected all mocks to be called")
+		}
+	})
+
+	t.Run("disallowed path", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(200).
+			BodyString("User-agent: *\nDisallow: /private")
+
+		service := New(mem.New())
+
+		if service.CanCrawlURL("http://example.com/private/page?id=1") {
+			t.Errorf("expected not to be able to crawl /private/page")
+		}
+
+		if !service.CanCrawlURL("http://example.com/public") {
+			t.Errorf("expected to be able to crawl /public")
+		}
+	})
+
+	t.Run("server error disallows temporarily", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(503)
+
+		repo := mem.New()
+		service := New(repo)
+
+		if service.CanCrawlURL("http://example.com") {
+			t.Errorf("expected not to be able to crawl")
+		}
+
+		hit, err := repo.Get("example.com")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if hit.Status != robotstxt.StatusUnreachable {
+			t.Errorf("expected hit to be unreachable, got %s", hit.Status)
+		}
+
+		if hit.ExpiresAt.After(time.Now().Add(robotstxt.UnreachableTTL)) {
+			t.Errorf("expected hit to expire within %v, got %v", robotstxt.UnreachableTTL, hit.ExpiresAt)
+		}
+	})
+
+	t.Run("unreachable keeps previously fetched copy", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(500)
+
+		repo := mem.New()
+		repo.Set("example.com", &robotstxt.RobotsTxt{
+			Hostname:  "example.com",
+			Status:    robotstxt.StatusAvailable,
+			Body:      "User-agent: *\nDisallow: /private",
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		service := New(repo)
+
+		if !service.CanCrawlURL("http://example.com/public") {
+			t.Errorf("expected to be able to crawl /public")
+		}
+
+		if service.CanCrawlURL("http://example.com/private") {
+			t.Errorf("expected not to be able to crawl /private")
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("uses cache until expired", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Times(1).
+			Reply(200).
+			SetHeader("Cache-Control", "public, max-age=3600").
+			BodyString("User-agent: *\nDisallow: /private")
+
+		service := New(mem.New())
+
+		first, err := service.Get("http://example.com")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		second, err := service.Get("http://example.com/other")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if first != second {
+			t.Errorf("expected cached robots.txt to be returned")
+		}
+
+		if first.ExpiresAt.After(time.Now().Add(time.Hour)) {
+			t.Errorf("expected max-age to be honored, got %v", first.ExpiresAt)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("expected all mocks to be called")
+		}
+	})
+
+	t.Run("refetches when expired", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(200).
+			BodyString("User-agent: *\nDisallow: /")
+
+		repo := mem.New()
+		repo.Set("example.com", &robotstxt.RobotsTxt{
+			Hostname:  "example.com",
+			Status:    robotstxt.StatusUnavailable,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+
+		service := New(repo)
+
+		rtxt, err := service.Get("http://example.com")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if rtxt.Status != robotstxt.StatusAvailable {
+			t.Errorf("expected robots.txt to be refetched, got %s", rtxt.Status)
 		}
 
 		if !gock.IsDone() {
 			t.Errorf("expected all mocks to be called")
 		}
 	})
+
+	t.Run("extracts crawl delay and sitemaps", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(200).
+			BodyString("User-agent: *\nCrawl-delay: 90\nDisallow: /private\n\nSitemap: http://example.com/sitemap.xml")
+
+		polSvc := polService.New(polRepo.New())
+		service := New(mem.New(), WithPolicyService(polSvc))
+
+		rtxt, err := service.Get("http://example.com")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if rtxt.CrawlDelay != 90*time.Second {
+			t.Errorf("expected crawl delay of 90s, got %v", rtxt.CrawlDelay)
+		}
+
+		if len(rtxt.Sitemaps) != 1 || rtxt.Sitemaps[0] != "http://example.com/sitemap.xml" {
+			t.Errorf("expected sitemap to be extracted, got %v", rtxt.Sitemaps)
+		}
+
+		pol, err := polSvc.Get("example.com")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if pol.CrawlInterval != 90*time.Second {
+			t.Errorf("expected crawl interval of 90s, got %v", pol.CrawlInterval)
+		}
+	})
+
+	t.Run("crawl delay below default keeps default interval", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(200).
+			BodyString("User-agent: *\nCrawl-delay: 1")
+
+		polSvc := polService.New(polRepo.New())
+		service := New(mem.New(), WithPolicyService(polSvc))
+
+		if _, err := service.Get("http://example.com"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		pol, err := polSvc.Get("example.com")
+
+		if err == nil && pol.CrawlInterval != policy.DefaultCrawlInterval {
+			t.Errorf("expected crawl interval of %v, got %v", policy.DefaultCrawlInterval, pol.CrawlInterval)
+		}
+	})
+
+	t.Run("crawl delay does not replace an overridden interval", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(200).
+			BodyString("User-agent: *\nCrawl-delay: 90")
+
+		polSvc := polService.New(polRepo.New())
+		polSvc.Set("example.com", &policy.CrawlPolicy{
+			Hostname:         "example.com",
+			CrawlInterval:    5 * time.Second,
+			IntervalOverride: true,
+		})
+
+		service := New(mem.New(), WithPolicyService(polSvc))
+
+		if _, err := service.Get("http://example.com"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		pol, _ := polSvc.Get("example.com")
+
+		if pol.CrawlInterval != 5*time.Second {
+			t.Errorf("expected crawl interval of 5s, got %v", pol.CrawlInterval)
+		}
+	})
+
+	t.Run("failing to save the crawl delay still returns the robots.txt", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/robots.txt").
+			Reply(200).
+			BodyString("User-agent: *\nCrawl-delay: 90")
+
+		polSvc := &failingPolicyService{}
+		service := New(mem.New(), WithPolicyService(polSvc))
+
+		rtxt, err := service.Get("http://example.com")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if rtxt.CrawlDelay != 90*time.Second {
+			t.Errorf("expected crawl delay of 90s, got %v", rtxt.CrawlDelay)
+		}
+
+		if !polSvc.set {
+			t.Errorf("expected the crawl delay to be saved")
+		}
+	})
+}
+
+type failingPolicyService struct {
+	policy.Service
+	set bool
+}
+
+func (f *failingPolicyService) Get(hostname string) (*policy.CrawlPolicy, error) {
+	return nil, policy.ErrPolicyNotFound
+}
+
+func (f *failingPolicyService) Set(hostname string, pol *policy.CrawlPolicy) error {
+	f.set = true
+	return errors.New("disk full")
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{"default", http.Header{}, robotstxt.DefaultTTL},
+		{"max-age", http.Header{"Cache-Control": {"max-age=600"}}, 10 * time.Minute},
+		{"clamped to max", http.Header{"Cache-Control": {"max-age=604800"}}, robotstxt.MaxTTL},
+		{"no-cache clamped to min", http.Header{"Cache-Control": {"no-cache"}}, robotstxt.MinTTL},
+		{"expires", http.Header{"Expires": {now.Add(2 * time.Hour).UTC().Format(http.TimeFormat)}}, 2 * time.Hour},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := ttl(c.header, now)
+
+			if got < c.expected-time.Second || got > c.expected {
+				t.Errorf("expected %v, got %v", c.expected, got)
+			}
+		})
+	}
 }
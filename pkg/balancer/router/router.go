@@ -1,18 +1,47 @@
 package router
 
 import (
+	"juno/pkg/balancer/admin"
 	"juno/pkg/balancer/crawl"
+	"juno/pkg/balancer/discovery"
+	"juno/pkg/balancer/middleware"
+	"juno/pkg/balancer/queue"
+	"juno/pkg/balancer/scope"
 
 	"github.com/gin-gonic/gin"
 )
 
 func New(
 	crawlHandler crawl.Handler,
+	queueHandler queue.Handler,
+	discoveryHandler discovery.Handler,
+	scopeHandler scope.Handler,
+	adminHandler admin.Handler,
+	adminToken string,
 ) *gin.Engine {
 	r := gin.Default()
 
 	r.POST("/crawl", crawlHandler.Crawl)
 	r.POST("/crawl/urls", crawlHandler.CrawlURLs)
+	r.POST("/crawl/feeds", discoveryHandler.Feeds)
+
+	a := r.Group("/admin", middleware.AdminAuth(adminToken))
+
+	a.GET("/dead-letters", queueHandler.DeadLetters)
+	a.POST("/dead-letters/replay", queueHandler.Replay)
+
+	a.GET("/scope/rules", scopeHandler.Rules)
+	a.GET("/scope/rejections", scopeHandler.Rejections)
+
+	a.GET("/queue", adminHandler.Queue)
+	a.GET("/queue/peek", adminHandler.Peek)
+	a.POST("/processing/pause", adminHandler.Pause)
+	a.POST("/processing/resume", adminHandler.Resume)
+
+	a.DELETE("/hosts/:hostname/queue", adminHandler.PurgeHost)
+	a.GET("/hosts/:hostname/policy", adminHandler.Policy)
+	a.PUT("/hosts/:hostname/policy", adminHandler.UpdatePolicy)
+	a.GET("/hosts/:hostname/outcomes", adminHandler.Outcomes)
 
 	return r
 }
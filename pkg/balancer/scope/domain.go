@@ -0,0 +1,93 @@
+package scope
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Reason string
+
+const (
+	ReasonInvalidURL         Reason = "invalid_url"
+	ReasonHostDenied         Reason = "host_denied"
+	ReasonHostNotAllowed     Reason = "host_not_allowed"
+	ReasonPatternDenied      Reason = "pattern_denied"
+	ReasonPatternNotAllowed  Reason = "pattern_not_allowed"
+	ReasonURLTooLong         Reason = "url_too_long"
+	ReasonPathTooDeep        Reason = "path_too_deep"
+	ReasonTooManyQueryParams Reason = "too_many_query_params"
+	ReasonHostQueueFull      Reason = "host_queue_full"
+	ReasonRepeatingSegments  Reason = "repeating_path_segments"
+	ReasonSessionID          Reason = "session_id"
+	ReasonCalendarTrap       Reason = "calendar_trap"
+)
+
+// Rules decide which URLs the balancer accepts into its queue. A zero limit
+// disables the corresponding check.
+type Rules struct {
+	// AllowHosts restricts crawling to these hosts when not empty. Entries
+	// starting with "." also match subdomains.
+	AllowHosts []string `json:"allow_hosts"`
+	DenyHosts  []string `json:"deny_hosts"`
+
+	// AllowPatterns are regular expressions of which a URL must match at
+	// least one when not empty.
+	AllowPatterns []string `json:"allow_patterns"`
+	DenyPatterns  []string `json:"deny_patterns"`
+
+	MaxURLLength   int `json:"max_url_length"`
+	MaxPathDepth   int `json:"max_path_depth"`
+	MaxQueryParams int `json:"max_query_params"`
+	MaxURLsPerHost int `json:"max_urls_per_host"`
+
+	// MaxSegmentRepeats is how often the same path segment, or sequence of
+	// segments, may repeat back to back before the URL is considered a trap.
+	MaxSegmentRepeats int `json:"max_segment_repeats"`
+
+	RejectSessionIDs bool `json:"reject_session_ids"`
+	// MaxCalendarYears rejects calendar URLs dated more than this many years
+	// in the future.
+	MaxCalendarYears int `json:"max_calendar_years"`
+}
+
+func DefaultRules() *Rules {
+	return &Rules{
+		MaxURLLength:      2048,
+		MaxPathDepth:      16,
+		MaxQueryParams:    8,
+		MaxURLsPerHost:    10000,
+		MaxSegmentRepeats: 2,
+		RejectSessionIDs:  true,
+		MaxCalendarYears:  2,
+	}
+}
+
+// LoadRules reads rules from a JSON file on top of the defaults.
+func LoadRules(path string) (*Rules, error) {
+	rules := DefaultRules()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+type Service interface {
+	// Check returns whether a URL may be queued and, if not, why.
+	Check(url string) (bool, Reason)
+	Rules() *Rules
+	Rejections() map[Reason]int
+}
+
+type Handler interface {
+	Rules(c *gin.Context)
+	Rejections(c *gin.Context)
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "juno/pkg/balancer/scope"
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type RulesResponse struct {
+	Status string       `json:"status"`
+	Rules  *scope.Rules `json:"rules"`
+}
+
+func NewSuccessRulesResponse(rules *scope.Rules) RulesResponse {
+	return RulesResponse{
+		Status: SUCCESS,
+		Rules:  rules,
+	}
+}
+
+type RejectionsResponse struct {
+	Status     string         `json:"status"`
+	Total      int            `json:"total"`
+	Rejections map[string]int `json:"rejections"`
+}
+
+func NewSuccessRejectionsResponse(rejections map[scope.Reason]int) RejectionsResponse {
+	res := RejectionsResponse{
+		Status:     SUCCESS,
+		Rejections: make(map[string]int, len(rejections)),
+	}
+
+	for reason, count := range rejections {
+		res.Rejections[string(reason)] = count
+		res.Total += count
+	}
+
+	return res
+}
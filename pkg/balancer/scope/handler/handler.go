@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"juno/pkg/balancer/scope"
+	"juno/pkg/balancer/scope/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	scopeService scope.Service
+}
+
+func New(scopeService scope.Service) *Handler {
+	return &Handler{
+		scopeService: scopeService,
+	}
+}
+
+func (h *Handler) Rules(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.NewSuccessRulesResponse(h.scopeService.Rules()))
+}
+
+func (h *Handler) Rejections(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.NewSuccessRejectionsResponse(h.scopeService.Rejections()))
+}
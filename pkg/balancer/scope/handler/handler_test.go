@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"juno/pkg/balancer/scope"
+	"juno/pkg/balancer/scope/dto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	scopeService "juno/pkg/balancer/scope/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRules(t *testing.T) {
+	svc, _ := scopeService.New(scope.DefaultRules(), nil)
+	h := New(svc)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/admin/scope/rules", nil)
+
+	h.Rules(c)
+
+	if c.Writer.Status() != http.StatusOK {
+		t.Errorf("expected status 200 but got %d", c.Writer.Status())
+	}
+
+	var res dto.RulesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if res.Rules.MaxURLLength != scope.DefaultRules().MaxURLLength {
+		t.Errorf("expected max url length %d but got %d", scope.DefaultRules().MaxURLLength, res.Rules.MaxURLLength)
+	}
+}
+
+func TestRejections(t *testing.T) {
+	svc, _ := scopeService.New(scope.DefaultRules(), nil)
+	svc.Check("http://example.com/cart;jsessionid=ABC")
+	svc.Check("not a url")
+
+	h := New(svc)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/admin/scope/rejections", nil)
+
+	h.Rejections(c)
+
+	if c.Writer.Status() != http.StatusOK {
+		t.Errorf("expected status 200 but got %d", c.Writer.Status())
+	}
+
+	var res dto.RejectionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	if res.Total != 2 {
+		t.Errorf("expected 2 rejections but got %d", res.Total)
+	}
+
+	if res.Rejections[string(scope.ReasonSessionID)] != 1 {
+		t.Errorf("expected 1 session id rejection but got %d", res.Rejections[string(scope.ReasonSessionID)])
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"fmt"
+	"juno/pkg/balancer/queue"
+	"juno/pkg/balancer/scope"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sessionParams = map[string]bool{
+	"jsessionid":   true,
+	"phpsessid":    true,
+	"aspsessionid": true,
+	"sessionid":    true,
+	"session_id":   true,
+	"sid":          true,
+	"cfid":         true,
+	"cftoken":      true,
+}
+
+// matches ;jsessionid=... path parameters and ASP.NET cookieless (S(...)) segments
+var sessionPathRegex = regexp.MustCompile(`(?i);(jsessionid|phpsessid|sessionid|sid)=|\(s\([a-z0-9]+\)\)`)
+
+// matches yyyy/mm and yyyy-mm as found in calendar pages
+var calendarRegex = regexp.MustCompile(`(?:^|[^0-9])((?:19|20|21)[0-9]{2})[-/](?:0?[1-9]|1[0-2])(?:[^0-9]|$)`)
+
+var calendarParams = map[string]bool{
+	"year": true,
+	"date": true,
+	"day":  true,
+}
+
+type Service struct {
+	rules *scope.Rules
+
+	allowPatterns []*regexp.Regexp
+	denyPatterns  []*regexp.Regexp
+
+	queueService queue.Service
+
+	rejectionsLock sync.Mutex
+	rejections     map[scope.Reason]int
+}
+
+// New compiles the rules. queueService is used to enforce MaxURLsPerHost and
+// may be nil.
+func New(rules *scope.Rules, queueService queue.Service) (*Service, error) {
+	s := &Service{
+		rules:        rules,
+		queueService: queueService,
+		rejections:   make(map[scope.Reason]int),
+	}
+
+	for _, p := range rules.AllowPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %w", p, err)
+		}
+		s.allowPatterns = append(s.allowPatterns, re)
+	}
+
+	for _, p := range rules.DenyPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny pattern %q: %w", p, err)
+		}
+		s.denyPatterns = append(s.denyPatterns, re)
+	}
+
+	return s, nil
+}
+
+func (s *Service) Rules() *scope.Rules {
+	return s.rules
+}
+
+func (s *Service) Rejections() map[scope.Reason]int {
+	s.rejectionsLock.Lock()
+	defer s.rejectionsLock.Unlock()
+
+	rejections := make(map[scope.Reason]int, len(s.rejections))
+	for reason, count := range s.rejections {
+		rejections[reason] = count
+	}
+
+	return rejections
+}
+
+func (s *Service) Check(link string) (bool, scope.Reason) {
+	reason := s.check(link)
+
+	if reason == "" {
+		return true, ""
+	}
+
+	s.rejectionsLock.Lock()
+	s.rejections[reason]++
+	s.rejectionsLock.Unlock()
+
+	return false, reason
+}
+
+func (s *Service) check(link string) scope.Reason {
+	u, err := url.Parse(link)
+
+	if err != nil || u.Hostname() == "" {
+		return scope.ReasonInvalidURL
+	}
+
+	hostname := strings.ToLower(u.Hostname())
+
+	if s.rules.MaxURLLength > 0 && len(link) > s.rules.MaxURLLength {
+		return scope.ReasonURLTooLong
+	}
+
+	if matchesHost(s.rules.DenyHosts, hostname) {
+		return scope.ReasonHostDenied
+	}
+
+	if len(s.rules.AllowHosts) > 0 && !matchesHost(s.rules.AllowHosts, hostname) {
+		return scope.ReasonHostNotAllowed
+	}
+
+	for _, re := range s.denyPatterns {
+		if re.MatchString(link) {
+			return scope.ReasonPatternDenied
+		}
+	}
+
+	if len(s.allowPatterns) > 0 && !matchesAny(s.allowPatterns, link) {
+		return scope.ReasonPatternNotAllowed
+	}
+
+	segments := pathSegments(u.EscapedPath())
+
+	if s.rules.MaxPathDepth > 0 && len(segments) > s.rules.MaxPathDepth {
+		return scope.ReasonPathTooDeep
+	}
+
+	query := u.Query()
+
+	if s.rules.MaxQueryParams > 0 && len(query) > s.rules.MaxQueryParams {
+		return scope.ReasonTooManyQueryParams
+	}
+
+	if s.rules.RejectSessionIDs && hasSessionID(u, query) {
+		return scope.ReasonSessionID
+	}
+
+	if s.rules.MaxSegmentRepeats > 0 && repeatsSegments(segments, s.rules.MaxSegmentRepeats) {
+		return scope.ReasonRepeatingSegments
+	}
+
+	if s.rules.MaxCalendarYears > 0 && isCalendarTrap(u, query, time.Now().Year()+s.rules.MaxCalendarYears) {
+		return scope.ReasonCalendarTrap
+	}
+
+	if s.rules.MaxURLsPerHost > 0 && s.queueService != nil {
+		count, err := s.queueService.CountHost(hostname)
+
+		if err == nil && count >= s.rules.MaxURLsPerHost {
+			return scope.ReasonHostQueueFull
+		}
+	}
+
+	return ""
+}
+
+func matchesHost(hosts []string, hostname string) bool {
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+
+		if h == hostname {
+			return true
+		}
+
+		if strings.HasPrefix(h, ".") && (strings.HasSuffix(hostname, h) || hostname == h[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(patterns []*regexp.Regexp, link string) bool {
+	for _, re := range patterns {
+		if re.MatchString(link) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func pathSegments(path string) []string {
+	segments := []string{}
+
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+func hasSessionID(u *url.URL, query url.Values) bool {
+	if sessionPathRegex.MatchString(u.EscapedPath()) {
+		return true
+	}
+
+	for key := range query {
+		if sessionParams[strings.ToLower(key)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// repeatsSegments reports whether a segment, or a sequence of segments,
+// repeats back to back more than max times, which catches /a/b/a/b/a/b
+// style loops from relative links.
+func repeatsSegments(segments []string, max int) bool {
+	for length := 1; length*(max+1) <= len(segments); length++ {
+		for start := 0; start+length*(max+1) <= len(segments); start++ {
+			repeats := 1
+
+			for next := start + length; next+length <= len(segments); next += length {
+				if !slices.Equal(segments[start:start+length], segments[next:next+length]) {
+					break
+				}
+
+				repeats++
+			}
+
+			if repeats > max {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isCalendarTrap(u *url.URL, query url.Values, maxYear int) bool {
+	for _, m := range calendarRegex.FindAllStringSubmatch(u.Path, -1) {
+		if year, _ := strconv.Atoi(m[1]); year > maxYear {
+			return true
+		}
+	}
+
+	for key, values := range query {
+		if !calendarParams[strings.ToLower(key)] {
+			continue
+		}
+
+		for _, v := range values {
+			if len(v) < 4 {
+				continue
+			}
+
+			if year, err := strconv.Atoi(v[:4]); err == nil && year > maxYear {
+				return true
+			}
+		}
+	}
+
+	return false
+}
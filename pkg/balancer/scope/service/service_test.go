@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"juno/pkg/balancer/scope"
+	"strings"
+	"testing"
+	"time"
+
+	queueRepo "juno/pkg/balancer/queue/repo/mem"
+	queueService "juno/pkg/balancer/queue/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should return error on invalid pattern", func(t *testing.T) {
+		rules := scope.DefaultRules()
+		rules.DenyPatterns = []string{"("}
+
+		_, err := New(rules, nil)
+
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+func TestCheck(t *testing.T) {
+	rules := scope.DefaultRules()
+	rules.DenyHosts = []string{"spam.com", ".ads.net"}
+	rules.DenyPatterns = []string{`/search\?`}
+	rules.MaxPathDepth = 5
+	rules.MaxQueryParams = 2
+
+	svc, err := New(rules, nil)
+
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	future := time.Now().Year() + 5
+
+	cases := []struct {
+		url    string
+		reason scope.Reason
+	}{
+		{"http://example.com/about", ""},
+		{"http://example.com/blog/2015/03/post", ""},
+		{"not a url", scope.ReasonInvalidURL},
+		{"http://example.com/" + strings.Repeat("a", 2048), scope.ReasonURLTooLong},
+		{"http://spam.com/", scope.ReasonHostDenied},
+		{"http://tracker.ads.net/", scope.ReasonHostDenied},
+		{"http://example.com/search?q=juno", scope.ReasonPatternDenied},
+		{"http://example.com/a/b/c/d/e/f", scope.ReasonPathTooDeep},
+		{"http://example.com/shop?color=red&size=m&sort=asc", scope.ReasonTooManyQueryParams},
+		{"http://example.com/cart;jsessionid=ABC123", scope.ReasonSessionID},
+		{"http://example.com/cart?PHPSESSID=abc", scope.ReasonSessionID},
+		{"http://example.com/x/a/a/a", scope.ReasonRepeatingSegments},
+		{"http://example.com/en/api/en/guide/en", ""},
+		{fmt.Sprintf("http://example.com/calendar/%d/01", future), scope.ReasonCalendarTrap},
+		{fmt.Sprintf("http://example.com/events?year=%d", future), scope.ReasonCalendarTrap},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			ok, reason := svc.Check(c.url)
+
+			if ok != (c.reason == "") {
+				t.Errorf("expected ok to be %v, got %v", c.reason == "", ok)
+			}
+
+			if reason != c.reason {
+				t.Errorf("expected reason %q but got %q", c.reason, reason)
+			}
+		})
+	}
+
+	rejections := svc.Rejections()
+
+	if rejections[scope.ReasonHostDenied] != 2 {
+		t.Errorf("expected 2 host denied rejections but got %d", rejections[scope.ReasonHostDenied])
+	}
+
+	if rejections[scope.ReasonSessionID] != 2 {
+		t.Errorf("expected 2 session id rejections but got %d", rejections[scope.ReasonSessionID])
+	}
+}
+
+func TestRepeatsSegments(t *testing.T) {
+	cases := []struct {
+		path    string
+		repeats bool
+	}{
+		{"a/b/c", false},
+		{"a/a", false},
+		{"a/a/a", true},
+		{"x/a/b/a/b/a/b", true},
+		{"a/b/c/a/b/c/a/b/c/d", true},
+		{"a/b/a/b/a", false},
+		{"docs/en/api/en/guide/en", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			if got := repeatsSegments(strings.Split(c.path, "/"), 2); got != c.repeats {
+				t.Errorf("expected %v but got %v", c.repeats, got)
+			}
+		})
+	}
+}
+
+func TestCheckAllowLists(t *testing.T) {
+	rules := scope.DefaultRules()
+	rules.AllowHosts = []string{".example.com"}
+	rules.AllowPatterns = []string{`^https://`}
+
+	svc, _ := New(rules, nil)
+
+	if ok, _ := svc.Check("https://www.example.com/"); !ok {
+		t.Errorf("expected subdomain to be allowed")
+	}
+
+	if ok, reason := svc.Check("https://other.com/"); ok || reason != scope.ReasonHostNotAllowed {
+		t.Errorf("expected host not allowed but got %q", reason)
+	}
+
+	if ok, reason := svc.Check("http://example.com/"); ok || reason != scope.ReasonPatternNotAllowed {
+		t.Errorf("expected pattern not allowed but got %q", reason)
+	}
+}
+
+func TestCheckMaxURLsPerHost(t *testing.T) {
+	repo := queueRepo.New()
+	queueSvc := queueService.New(logrus.New(), repo)
+
+	rules := scope.DefaultRules()
+	rules.MaxURLsPerHost = 2
+
+	svc, _ := New(rules, queueSvc)
+
+	queueSvc.Push("http://example.com/a")
+	queueSvc.Push("http://example.com/b")
+
+	if ok, reason := svc.Check("http://example.com/c"); ok || reason != scope.ReasonHostQueueFull {
+		t.Errorf("expected host queue full but got %q", reason)
+	}
+
+	if ok, _ := svc.Check("http://other.com/c"); !ok {
+		t.Errorf("expected other host to be allowed")
+	}
+}
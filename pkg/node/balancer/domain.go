@@ -3,5 +3,6 @@ package balancer
 type Service interface {
 	SendCrawlRequest(url string) error
 	SendBatchedLinks(links []string) error
+	SendFeedLinks(links []string) error
 	ReportURLProcessed(url string, status int) error
 }
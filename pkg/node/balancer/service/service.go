@@ -98,6 +98,20 @@ func randomisedBalancersList(balancers []string) []string {
 }
 
 func (s *Service) SendBatchedLinks(links []string) error {
+	return s.sendGroupedByShard(links, func(c *balancerClient.Client, links []string) error {
+		return c.CrawlURLs(links)
+	})
+}
+
+// SendFeedLinks hands RSS and Atom feeds found on crawled pages to the
+// balancers responsible for their hosts so they can be polled for new URLs.
+func (s *Service) SendFeedLinks(links []string) error {
+	return s.sendGroupedByShard(links, func(c *balancerClient.Client, links []string) error {
+		return c.Feeds(links)
+	})
+}
+
+func (s *Service) sendGroupedByShard(links []string, send func(c *balancerClient.Client, links []string) error) error {
 	// group by shard
 	groupedLinks := map[int][]string{}
 
@@ -115,35 +129,49 @@ func (s *Service) SendBatchedLinks(links []string) error {
 		groupedLinks[shardNum] = append(groupedLinks[shardNum], link)
 	}
 
-	for shardNum, links := range groupedLinks {
-		balancers := randomisedBalancersList(s.balancers[shardNum])
+	failed := false
 
-		if len(balancers) == 0 {
-			if s.logger != nil {
-				s.logger.Error("no balancers found for shard")
-			}
-			continue
+	for shardNum, links := range groupedLinks {
+		if !s.sendToShard(shardNum, links, send) {
+			failed = true
 		}
+	}
 
-		for _, b := range balancers {
-			balancerClient := balancerClient.New(
-				"http://" + b,
-			)
+	if failed {
+		return errors.New("failed to send batched links")
+	}
 
-			err := balancerClient.CrawlURLs(links)
+	return nil
+}
 
-			if err != nil {
-				if s.logger != nil {
-					s.logger.Error(err)
-					continue
-				}
-			}
+// sendToShard sends the links to the first balancer of the shard that takes
+// them.
+func (s *Service) sendToShard(shardNum int, links []string, send func(c *balancerClient.Client, links []string) error) bool {
+	balancers := randomisedBalancersList(s.balancers[shardNum])
 
-			return nil
+	if len(balancers) == 0 {
+		if s.logger != nil {
+			s.logger.Error("no balancers found for shard")
+		}
+		return false
+	}
+
+	for _, b := range balancers {
+		balancerClient := balancerClient.New(
+			"http://" + b,
+		)
+
+		if err := send(balancerClient, links); err != nil {
+			if s.logger != nil {
+				s.logger.Error(err)
+			}
+			continue
 		}
+
+		return true
 	}
 
-	return errors.New("failed to send batched links")
+	return false
 }
 
 func (s *Service) SendCrawlRequest(urlStr string) error {
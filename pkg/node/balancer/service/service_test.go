@@ -127,3 +127,36 @@ func TestSendCrawlRequest(t *testing.T) {
 		}
 	})
 }
+
+func TestSendBatchedLinks(t *testing.T) {
+	t.Run("should send the links of every shard", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://balancer1.com:9090").
+			Post("/crawl/urls").
+			JSON(map[string][]string{"urls": {"http://example.com/a"}}).
+			Reply(200)
+
+		gock.New("http://balancer2.com:9090").
+			Post("/crawl/urls").
+			JSON(map[string][]string{"urls": {"http://example.org/b"}}).
+			Reply(200)
+
+		svc := New(WithLogger(logrus.New()))
+
+		var balancers [shard.SHARDS][]string
+		balancers[shard.GetShard("example.com")] = []string{"balancer1.com:9090"}
+		balancers[shard.GetShard("example.org")] = []string{"balancer2.com:9090"}
+		svc.SetBalancers(balancers)
+
+		err := svc.SendBatchedLinks([]string{"http://example.com/a", "http://example.org/b"})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+}
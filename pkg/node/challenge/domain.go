@@ -0,0 +1,27 @@
+package challenge
+
+import (
+	"errors"
+	"juno/pkg/node/page"
+	"juno/pkg/proof"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrVersionNotFound = errors.New("version not found")
+
+// Service answers the API's challenges on the pages the node stores, which
+// prove it still stores them.
+type Service interface {
+	// Blob returns the stored version of the page at url, or its latest
+	// version when version is zero.
+	Blob(url string, version page.VersionHash) ([]byte, error)
+	// Answer returns the hash of the stored version of the page at url, and
+	// the answer to the challenge on it when there is one.
+	Answer(url string, version page.VersionHash, c *proof.Challenge) (page.VersionHash, []byte, error)
+}
+
+type Handler interface {
+	Challenge(c *gin.Context)
+	Blob(c *gin.Context)
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "juno/pkg/proof"
+
+const (
+	SUCCESS = "success"
+	ERROR   = "error"
+)
+
+type ChallengeRequest struct {
+	URL     string `json:"url" binding:"required"`
+	Version string `json:"version" binding:"required"`
+
+	// Challenge is answered on the version when it is set, otherwise only
+	// its hash is returned
+	Challenge *proof.Challenge `json:"challenge,omitempty"`
+}
+
+type ChallengeResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	// Hash is the hash of the stored version, and MAC the answer to the
+	// challenge
+	Hash string `json:"hash,omitempty"`
+	MAC  []byte `json:"mac,omitempty"`
+}
+
+func NewSuccessChallengeResponse(hash string, mac []byte) *ChallengeResponse {
+	return &ChallengeResponse{
+		Status: SUCCESS,
+		Hash:   hash,
+		MAC:    mac,
+	}
+}
+
+func NewErrorChallengeResponse(message string) *ChallengeResponse {
+	return &ChallengeResponse{
+		Status:  ERROR,
+		Message: message,
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"juno/pkg/node/challenge"
+	"juno/pkg/node/challenge/dto"
+	"juno/pkg/node/page"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type Handler struct {
+	logger           *logrus.Logger
+	challengeService challenge.Service
+}
+
+func New(logger *logrus.Logger, challengeService challenge.Service) *Handler {
+	return &Handler{
+		logger:           logger,
+		challengeService: challengeService,
+	}
+}
+
+func (h *Handler) Challenge(c *gin.Context) {
+	var req dto.ChallengeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorChallengeResponse(err.Error()))
+		return
+	}
+
+	version, err := page.ParseVersionHash(req.Version)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorChallengeResponse(err.Error()))
+		return
+	}
+
+	hash, mac, err := h.challengeService.Answer(req.URL, version, req.Challenge)
+
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessChallengeResponse(hash.String(), mac))
+}
+
+// Blob serves the stored version of a page, or its latest version without a
+// version query.
+func (h *Handler) Blob(c *gin.Context) {
+	var version page.VersionHash
+
+	if v := c.Query("version"); v != "" {
+		parsed, err := page.ParseVersionHash(v)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorChallengeResponse(err.Error()))
+			return
+		}
+
+		version = parsed
+	}
+
+	blob, err := h.challengeService.Blob(c.Query("url"), version)
+
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/octet-stream", blob)
+}
+
+func (h *Handler) error(c *gin.Context, err error) {
+	if err == page.ErrPageNotFound || err == challenge.ErrVersionNotFound {
+		c.JSON(http.StatusNotFound, dto.NewErrorChallengeResponse(err.Error()))
+		return
+	}
+
+	h.logger.WithError(err).Error("failed to answer challenge")
+	c.JSON(http.StatusInternalServerError, dto.NewErrorChallengeResponse(err.Error()))
+}
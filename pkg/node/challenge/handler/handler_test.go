@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"juno/pkg/node/challenge"
+	"juno/pkg/node/challenge/dto"
+	"juno/pkg/node/page"
+	"juno/pkg/proof"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var body = []byte("<html></html>")
+
+type mockChallengeService struct{}
+
+func (m *mockChallengeService) Blob(url string, version page.VersionHash) ([]byte, error) {
+	if url != "http://example.com" {
+		return nil, page.ErrPageNotFound
+	}
+
+	if version != (page.VersionHash{}) && version != page.NewVersionHash(body) {
+		return nil, challenge.ErrVersionNotFound
+	}
+
+	return body, nil
+}
+
+func (m *mockChallengeService) Answer(url string, version page.VersionHash, c *proof.Challenge) (page.VersionHash, []byte, error) {
+	blob, err := m.Blob(url, version)
+
+	if err != nil {
+		return page.VersionHash{}, nil, err
+	}
+
+	return page.NewVersionHash(blob), c.MAC(blob), nil
+}
+
+func TestChallenge(t *testing.T) {
+	h := New(logrus.New(), &mockChallengeService{})
+
+	request := func(req dto.ChallengeRequest) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(req)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/challenge", bytes.NewReader(b))
+
+		h.Challenge(c)
+
+		return w
+	}
+
+	vHash := page.NewVersionHash(body).String()
+	chal := &proof.Challenge{Nonce: []byte("nonce"), Ranges: []proof.Range{{Offset: 0, Length: 4}}}
+
+	t.Run("success", func(t *testing.T) {
+		w := request(dto.ChallengeRequest{URL: "http://example.com", Version: vHash, Challenge: chal})
+
+		if w.Code != 200 {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+
+		var res dto.ChallengeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Hash != vHash || !bytes.Equal(res.MAC, chal.MAC(body)) {
+			t.Errorf("expected the hash and MAC of the body, got %+v", res)
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		if w := request(dto.ChallengeRequest{URL: "http://example.com", Version: "nope"}); w.Code != 400 {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if w := request(dto.ChallengeRequest{URL: "http://other.com", Version: vHash, Challenge: chal}); w.Code != 404 {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestBlob(t *testing.T) {
+	h := New(logrus.New(), &mockChallengeService{})
+
+	request := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/blob?"+query, nil)
+
+		h.Blob(c)
+
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := request("url=http://example.com&version=" + page.NewVersionHash(body).String())
+
+		if w.Code != 200 || !bytes.Equal(w.Body.Bytes(), body) {
+			t.Errorf("expected the body, got %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		if w := request("url=http://example.com&version=nope"); w.Code != 400 {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if w := request("url=http://example.com&version=" + page.NewVersionHash([]byte("other")).String()); w.Code != 404 {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"juno/pkg/node/challenge"
+	"juno/pkg/node/page"
+	"juno/pkg/node/storage"
+	"juno/pkg/proof"
+	"os"
+)
+
+type Service struct {
+	pageService    page.Service
+	storageService storage.Service
+}
+
+func New(pageService page.Service, storageService storage.Service) *Service {
+	return &Service{
+		pageService:    pageService,
+		storageService: storageService,
+	}
+}
+
+func (s *Service) Blob(url string, version page.VersionHash) ([]byte, error) {
+	p, err := s.pageService.GetByURL(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.pageService.GetVersions(p.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if version == (page.VersionHash{}) && len(versions) > 0 {
+		version = versions[len(versions)-1].Hash
+	}
+
+	for _, v := range versions {
+		if v.Hash != version {
+			continue
+		}
+
+		blob, err := s.storageService.Read(version)
+
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, challenge.ErrVersionNotFound
+		}
+
+		return blob, err
+	}
+
+	return nil, challenge.ErrVersionNotFound
+}
+
+// Answer hashes the stored blob again rather than trusting the page's
+// versions, so a node that lost the blob cannot answer.
+func (s *Service) Answer(url string, version page.VersionHash, c *proof.Challenge) (page.VersionHash, []byte, error) {
+	blob, err := s.Blob(url, version)
+
+	if err != nil {
+		return page.VersionHash{}, nil, err
+	}
+
+	if c == nil {
+		return page.NewVersionHash(blob), nil, nil
+	}
+
+	return page.NewVersionHash(blob), c.MAC(blob), nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bytes"
+	"juno/pkg/node/challenge"
+	"juno/pkg/node/page"
+	pageRepo "juno/pkg/node/page/repo/mem"
+	pageService "juno/pkg/node/page/service"
+	storageService "juno/pkg/node/storage/service"
+	"juno/pkg/proof"
+	"testing"
+)
+
+func setup(t *testing.T, bodies ...[]byte) (*Service, *storageService.Service) {
+	t.Helper()
+
+	pages := pageService.New(pageRepo.New())
+	storage := storageService.New(t.TempDir())
+
+	p := page.NewPage("http://example.com")
+
+	if err := pages.Create(p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, body := range bodies {
+		vHash := page.NewVersionHash(body)
+		pages.AddVersion(p.ID, page.NewVersion(vHash))
+
+		if err := storage.Write(vHash, body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	return New(pages, storage), storage
+}
+
+func TestBlob(t *testing.T) {
+	v1, v2 := []byte("<html>v1</html>"), []byte("<html>v2</html>")
+
+	s, _ := setup(t, v1, v2)
+
+	t.Run("latest", func(t *testing.T) {
+		blob, err := s.Blob("http://example.com", page.VersionHash{})
+
+		if err != nil || !bytes.Equal(blob, v2) {
+			t.Errorf("expected %s, got %s and %v", v2, blob, err)
+		}
+	})
+
+	t.Run("version", func(t *testing.T) {
+		blob, err := s.Blob("http://example.com", page.NewVersionHash(v1))
+
+		if err != nil || !bytes.Equal(blob, v1) {
+			t.Errorf("expected %s, got %s and %v", v1, blob, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := s.Blob("http://example.com", page.NewVersionHash([]byte("other"))); err != challenge.ErrVersionNotFound {
+			t.Errorf("expected %v, got %v", challenge.ErrVersionNotFound, err)
+		}
+
+		if _, err := s.Blob("http://other.com", page.VersionHash{}); err != page.ErrPageNotFound {
+			t.Errorf("expected %v, got %v", page.ErrPageNotFound, err)
+		}
+	})
+}
+
+func TestAnswer(t *testing.T) {
+	body := []byte("<html><head><title>Test</title></head><body></body></html>")
+	vHash := page.NewVersionHash(body)
+
+	c, err := proof.NewChallenge(len(body))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		s, _ := setup(t, body)
+
+		hash, mac, err := s.Answer("http://example.com", vHash, c)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if hash != vHash || !bytes.Equal(mac, c.MAC(body)) {
+			t.Errorf("expected the hash and MAC of the body, got %s and %x", hash, mac)
+		}
+
+		if _, mac, _ := s.Answer("http://example.com", vHash, nil); mac != nil {
+			t.Errorf("expected no MAC without a challenge, got %x", mac)
+		}
+	})
+
+	t.Run("changed blob", func(t *testing.T) {
+		s, storage := setup(t, body)
+
+		storage.Write(vHash, []byte("something else"))
+
+		hash, mac, err := s.Answer("http://example.com", vHash, c)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if hash == vHash || bytes.Equal(mac, c.MAC(body)) {
+			t.Errorf("expected another hash and MAC, got %s", hash)
+		}
+	})
+}
@@ -2,18 +2,27 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"juno/pkg/aggregation"
 	"juno/pkg/node"
+	challengeDto "juno/pkg/node/challenge/dto"
 	domain "juno/pkg/node/crawl"
 	crawlDto "juno/pkg/node/crawl/dto"
 	extractionDto "juno/pkg/node/extraction/dto"
 	infoDto "juno/pkg/node/info/dto"
+	"juno/pkg/scope"
 	"juno/pkg/util"
 	"net/http"
+	"net/url"
 )
 
-func SendCrawlRequest(node string, url string) error {
+// SendCrawlRequest asks the node to crawl the url. It returns the URL the node
+// stored the page under, which is url itself unless it redirected, and the
+// hash of the version stored, empty when the node did not report it.
+func SendCrawlRequest(node string, url string) (string, string, error) {
 	var req crawlDto.CrawlRequest
 
 	req.URL = url
@@ -21,42 +30,99 @@ func SendCrawlRequest(node string, url string) error {
 	b, err := json.Marshal(req)
 
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
 	res, err := http.Post("http://"+node+"/crawl", "application/json", bytes.NewBuffer(b))
 
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
+	defer res.Body.Close()
+
 	if res.StatusCode != http.StatusOK {
-		return util.WrapErr(
+		return "", "", util.WrapErr(
 			domain.ErrFailedCrawlRequest,
 			fmt.Sprintf("status code: %d", res.StatusCode),
 		)
 	}
 
-	return nil
+	var response crawlDto.CrawlResponse
+
+	// nodes that do not report where they stored the page stored it at url
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil || response.URL == "" {
+		return url, "", nil
+	}
+
+	return response.URL, response.Version, nil
 }
 
 func SendExtractionRequest(nodeAddr string, shard int, selectors []*extractionDto.Selector, fields []*extractionDto.Field) ([]map[string]interface{}, error) {
-	b, err := json.Marshal(&extractionDto.ExtractionRequest{
+	return SendExtractionRequestContext(context.Background(), nodeAddr, shard, selectors, fields, nil)
+}
+
+// SendExtractionRequestContext is SendExtractionRequest that gives up when
+// ctx is cancelled, e.g. once a hedged request to another replica won. Only
+// the pages in the scope are extracted, a nil scope extracts them all.
+func SendExtractionRequestContext(ctx context.Context, nodeAddr string, shard int, selectors []*extractionDto.Selector, fields []*extractionDto.Field, scope *scope.Scope) ([]map[string]interface{}, error) {
+	response, err := Extract(ctx, nodeAddr, &extractionDto.ExtractionRequest{
 		Shard:     shard,
 		Selectors: selectors,
 		Fields:    fields,
+		Scope:     scope,
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := http.Post("http://"+nodeAddr+"/extract", "application/json", bytes.NewBuffer(b))
+	return response.Extractions, nil
+}
+
+// SendAggregationRequestContext has the node fold the rows of the shard into
+// partials of the aggregations instead of returning them.
+func SendAggregationRequestContext(ctx context.Context, nodeAddr string, shard int, selectors []*extractionDto.Selector, fields []*extractionDto.Field, aggregations []*aggregation.Aggregation, scope *scope.Scope) (aggregation.Partials, error) {
+	response, err := Extract(ctx, nodeAddr, &extractionDto.ExtractionRequest{
+		Shard:        shard,
+		Selectors:    selectors,
+		Fields:       fields,
+		Aggregations: aggregations,
+		Scope:        scope,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Partials, nil
+}
+
+// Extract sends the request as is and returns the whole response, the
+// node's receipt of the work included.
+func Extract(ctx context.Context, nodeAddr string, extractionReq *extractionDto.ExtractionRequest) (*extractionDto.ExtractionResponse, error) {
+	b, err := json.Marshal(extractionReq)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+nodeAddr+"/extract", bytes.NewBuffer(b))
 
 	if err != nil {
 		return nil, err
 	}
 
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
 	if res.StatusCode != http.StatusOK {
 		return nil, util.WrapErr(
 			node.ErrFailedQueryRequest,
@@ -72,7 +138,81 @@ func SendExtractionRequest(nodeAddr string, shard int, selectors []*extractionDt
 		return nil, err
 	}
 
-	return response.Extractions, nil
+	return &response, nil
+}
+
+// SendChallengeRequest asks the node for the hash of a stored version of a
+// page, and the answer to the request's challenge on it.
+func SendChallengeRequest(ctx context.Context, nodeAddr string, challengeReq *challengeDto.ChallengeRequest) (*challengeDto.ChallengeResponse, error) {
+	b, err := json.Marshal(challengeReq)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+nodeAddr+"/challenge", bytes.NewBuffer(b))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, util.WrapErr(
+			node.ErrFailedChallengeRequest,
+			fmt.Sprintf("status code: %d", res.StatusCode),
+		)
+	}
+
+	var response challengeDto.ChallengeResponse
+
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// GetBlob downloads a stored version of a page, or its latest version when
+// version is empty.
+func GetBlob(ctx context.Context, nodeAddr, pageURL, version string) ([]byte, error) {
+	query := url.Values{"url": {pageURL}}
+
+	if version != "" {
+		query.Set("version", version)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+nodeAddr+"/blob?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, util.WrapErr(
+			node.ErrFailedChallengeRequest,
+			fmt.Sprintf("status code: %d", res.StatusCode),
+		)
+	}
+
+	return io.ReadAll(res.Body)
 }
 
 func SendInfoRequest(nodeAddr string) (*infoDto.InfoResponse, error) {
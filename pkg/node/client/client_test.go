@@ -1,10 +1,15 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"juno/pkg/aggregation"
+	"juno/pkg/node"
 	"strings"
 	"testing"
 
+	challengeDto "juno/pkg/node/challenge/dto"
 	extractionDto "juno/pkg/node/extraction/dto"
 	"juno/pkg/node/info"
 	infoDto "juno/pkg/node/info/dto"
@@ -24,17 +29,48 @@ func TestSendCrawlRequest(t *testing.T) {
 			Times(1).
 			Reply(200)
 
-		err := SendCrawlRequest("example.com", "http://shop.org")
+		stored, version, err := SendCrawlRequest("example.com", "http://shop.org")
 
 		if err != nil {
 			t.Errorf("Unexpected error: %s", err)
 		}
 
+		if stored != "http://shop.org" {
+			t.Errorf("Expected http://shop.org, got %s", stored)
+		}
+
+		if version != "" {
+			t.Errorf("Expected no version, got %s", version)
+		}
+
 		if !gock.IsDone() {
 			t.Errorf("Not all expectations were met")
 		}
 	})
 
+	t.Run("returns the url the page was stored under", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Post("/crawl").
+			Reply(200).
+			JSON(map[string]string{"status": "success", "url": "https://shop.org/", "version": "abc"})
+
+		stored, version, err := SendCrawlRequest("example.com", "http://shop.org")
+
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		if stored != "https://shop.org/" {
+			t.Errorf("Expected https://shop.org/, got %s", stored)
+		}
+
+		if version != "abc" {
+			t.Errorf("Expected version abc, got %s", version)
+		}
+	})
+
 	t.Run("returns error if request fails", func(t *testing.T) {
 
 		tests := []struct {
@@ -58,7 +94,7 @@ func TestSendCrawlRequest(t *testing.T) {
 					Times(1).
 					Reply(test.statusCode)
 
-				err := SendCrawlRequest("example.com", "http://shop.org")
+				_, _, err := SendCrawlRequest("example.com", "http://shop.org")
 
 				if err == nil {
 					t.Errorf("Expected an error")
@@ -135,6 +171,117 @@ func TestSendExtractionRequest(t *testing.T) {
 	})
 }
 
+func TestSendExtractionRequestContext(t *testing.T) {
+	t.Run("gives up when the context is cancelled", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://node1.com:8080").
+			Post("/extract").
+			Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"product_title": "charger"},
+				},
+			))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := SendExtractionRequestContext(ctx, "node1.com:8080", 0, nil, nil, nil)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestSendAggregationRequestContext(t *testing.T) {
+	t.Run("returns the partials", func(t *testing.T) {
+		defer gock.Off()
+
+		aggs := []*aggregation.Aggregation{{Name: "pages", Op: aggregation.OpCount}}
+
+		gock.New("http://node1.com:8080").
+			Post("/extract").
+			MatchType("json").
+			BodyString(`"aggregations":\[\{"name":"pages","op":"count"\}\]`).
+			Reply(200).
+			JSON(extractionDto.NewSuccessAggregationResponse(aggregation.Partials{
+				"pages": {Count: 3},
+			}))
+
+		partials, err := SendAggregationRequestContext(context.Background(), "node1.com:8080", 0, nil, nil, aggs, nil)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if partials["pages"].Count != 3 {
+			t.Errorf("Expected 3, got %d", partials["pages"].Count)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+}
+
+func TestSendChallengeRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Post("/challenge").
+			MatchType("json").
+			JSON(map[string]string{"url": "http://shop.org", "version": "abc"}).
+			Reply(200).
+			JSON(challengeDto.NewSuccessChallengeResponse("abc", []byte("mac")))
+
+		res, err := SendChallengeRequest(context.Background(), "example.com", &challengeDto.ChallengeRequest{URL: "http://shop.org", Version: "abc"})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if res.Hash != "abc" || string(res.MAC) != "mac" {
+			t.Errorf("Expected the hash and MAC, got %+v", res)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Post("/challenge").
+			Reply(404)
+
+		if _, err := SendChallengeRequest(context.Background(), "example.com", &challengeDto.ChallengeRequest{}); err == nil || !strings.HasPrefix(err.Error(), node.ErrFailedChallengeRequest.Error()) {
+			t.Errorf("Expected %v, got %v", node.ErrFailedChallengeRequest, err)
+		}
+	})
+}
+
+func TestGetBlob(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://example.com").
+		Get("/blob").
+		MatchParam("url", "http://shop.org").
+		MatchParam("version", "abc").
+		Reply(200).
+		BodyString("<html></html>")
+
+	blob, err := GetBlob(context.Background(), "example.com", "http://shop.org", "abc")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if string(blob) != "<html></html>" {
+		t.Errorf("Expected the blob, got %s", blob)
+	}
+}
+
 func TestSendInfoRequest(t *testing.T) {
 
 	t.Run("sends info request", func(t *testing.T) {
@@ -19,7 +19,10 @@ var (
 var ErrFailedCrawlRequest = errors.New("failed to send crawl request")
 
 type Service interface {
-	Crawl(ctx context.Context, url string) error
+	// Crawl fetches and stores the page at the URL. It returns the URL the
+	// page was stored under, which differs from url when it redirected, and
+	// the hash of the version stored.
+	Crawl(ctx context.Context, url string) (string, string, error)
 }
 
 type Handler interface {
@@ -12,11 +12,17 @@ type CrawlRequest struct {
 type CrawlResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
+	// URL is the URL the page was stored under, after redirects
+	URL string `json:"url,omitempty"`
+	// Version is the hash of the version stored
+	Version string `json:"version,omitempty"`
 }
 
-func NewSuccessCrawlResponse() *CrawlResponse {
+func NewSuccessCrawlResponse(url, version string) *CrawlResponse {
 	return &CrawlResponse{
-		Status: SUCCESS,
+		Status:  SUCCESS,
+		URL:     url,
+		Version: version,
 	}
 }
 
@@ -30,12 +30,12 @@ func (h *Handler) Crawl(c *gin.Context) {
 		return
 	}
 
-	err := h.crawlService.Crawl(context.Background(), req.URL)
+	url, version, err := h.crawlService.Crawl(context.Background(), req.URL)
 
 	if err != nil {
 		c.JSON(400, dto.NewErrorCrawlResponse(err.Error()))
 		return
 	}
 
-	c.JSON(200, dto.NewSuccessCrawlResponse())
+	c.JSON(200, dto.NewSuccessCrawlResponse(url, version))
 }
@@ -13,17 +13,19 @@ import (
 )
 
 type mockCrawlService struct {
-	withError error
+	withURL     string
+	withVersion string
+	withError   error
 }
 
-func (m *mockCrawlService) Crawl(ctx context.Context, url string) error {
-	return m.withError
+func (m *mockCrawlService) Crawl(ctx context.Context, url string) (string, string, error) {
+	return m.withURL, m.withVersion, m.withError
 }
 
 func TestCrawl(t *testing.T) {
 	t.Run("should return ok", func(t *testing.T) {
 
-		svc := New(logrus.New(), &mockCrawlService{})
+		svc := New(logrus.New(), &mockCrawlService{withURL: "http://example.com/home", withVersion: "abc"})
 
 		w := httptest.NewRecorder()
 
@@ -41,8 +43,8 @@ func TestCrawl(t *testing.T) {
 			t.Errorf("Expected status code 200, got %d", w.Code)
 		}
 
-		if w.Body.String() != `{"status":"success"}` {
-			t.Errorf(`Expected response body to be {"status":"success"}, got %s`, w.Body.String())
+		if w.Body.String() != `{"status":"success","url":"http://example.com/home","version":"abc"}` {
+			t.Errorf(`Expected response body to be {"status":"success","url":"http://example.com/home","version":"abc"}, got %s`, w.Body.String())
 		}
 
 	})
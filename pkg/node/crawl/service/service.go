@@ -11,11 +11,14 @@ import (
 	"juno/pkg/shard"
 	"juno/pkg/url"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 const CRAWL_TIMEOUT = time.Second * 10
 
 type Service struct {
+	logger          *logrus.Logger
 	balancerService balancer.Service
 	pageService     page.Service
 	storageService  storage.Service
@@ -24,6 +27,7 @@ type Service struct {
 }
 
 func New(
+	logger *logrus.Logger,
 	balancerService balancer.Service,
 	pageService page.Service,
 	storageService storage.Service,
@@ -31,6 +35,7 @@ func New(
 	htmlService html.Service,
 ) *Service {
 	return &Service{
+		logger:          logger,
 		balancerService: balancerService,
 		pageService:     pageService,
 		storageService:  storageService,
@@ -39,18 +44,18 @@ func New(
 	}
 }
 
-func (s *Service) Crawl(ctx context.Context, urlStr string) error {
+func (s *Service) Crawl(ctx context.Context, urlStr string) (string, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, CRAWL_TIMEOUT)
 
 	defer cancel()
 	body, status, finalURL, err := s.fetcher.FetchPage(ctx, urlStr)
 
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
 	if status != 200 {
-		return crawl.ErrNon200Response
+		return "", "", crawl.ErrNon200Response
 	}
 
 	p, err := s.pageService.Get(page.NewPageID(finalURL))
@@ -58,16 +63,16 @@ func (s *Service) Crawl(ctx context.Context, urlStr string) error {
 	if err == page.ErrPageNotFound {
 		p = page.NewPage(finalURL)
 
-		shard := shard.GetShard(finalURL)
+		shard := shard.GetURLShard(finalURL)
 		p.Shard = shard
 
 		err = s.pageService.Create(p)
 
 		if err != nil {
-			return err
+			return "", "", err
 		}
 	} else if err != nil {
-		return err
+		return "", "", err
 	}
 
 	vHash := page.NewVersionHash(body)
@@ -78,13 +83,13 @@ func (s *Service) Crawl(ctx context.Context, urlStr string) error {
 	)
 
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
 	links, err := s.htmlService.ExtractLinks(body)
 
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
 	var fullLinks []string
@@ -106,11 +111,38 @@ func (s *Service) Crawl(ctx context.Context, urlStr string) error {
 
 	go s.balancerService.SendBatchedLinks(fullLinks)
 
+	// feeds are only a discovery hint, the page is still stored without them
+	feeds, err := s.htmlService.ExtractFeedLinks(body)
+
+	if err != nil {
+		s.logger.Errorf("failed to extract feed links of %s: %v", finalURL, err)
+	}
+
+	var fullFeeds []string
+
+	for _, feed := range feeds {
+		full, err := url.LinkToFullURL(finalURL, feed)
+
+		if err != nil || !url.IsHTTPOrHTTPS(full) {
+			continue
+		}
+
+		fullFeeds = append(fullFeeds, full)
+	}
+
+	if len(fullFeeds) > 0 {
+		go s.balancerService.SendFeedLinks(fullFeeds)
+	}
+
 	err = s.pageService.AddVersion(p.ID, page.NewVersion(vHash))
 
 	if err != nil {
-		return err
+		return "", "", err
+	}
+
+	if err := s.balancerService.ReportURLProcessed(urlStr, status); err != nil {
+		return "", "", err
 	}
 
-	return s.balancerService.ReportURLProcessed(urlStr, status)
+	return finalURL, vHash.String(), nil
 }
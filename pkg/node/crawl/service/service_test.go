@@ -2,11 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	balancerService "juno/pkg/node/balancer/service"
 	fetcherService "juno/pkg/node/fetcher/service"
+	"juno/pkg/node/html"
 	htmlService "juno/pkg/node/html/service"
 	"juno/pkg/node/page"
 	pageRepo "juno/pkg/node/page/repo/mem"
@@ -15,10 +17,14 @@ import (
 	"juno/pkg/shard"
 
 	"github.com/h2non/gock"
+	"github.com/sirupsen/logrus"
 )
 
 func setupService(t *testing.T) *Service {
-	htmlService := htmlService.New()
+	return setupServiceWithHTML(t, htmlService.New())
+}
+
+func setupServiceWithHTML(t *testing.T, htmlService html.Service) *Service {
 	balancerService := balancerService.New()
 	pageRepo := pageRepo.New()
 	pageService := pageService.New(pageRepo)
@@ -32,6 +38,7 @@ func setupService(t *testing.T) *Service {
 	})
 
 	return New(
+		logrus.New(),
 		balancerService,
 		pageService,
 		storageService,
@@ -65,12 +72,20 @@ func TestCrawl(t *testing.T) {
 			JSON(map[string][]string{"urls": {"http://example.com/about"}}).
 			Reply(200)
 
-		err := s.Crawl(context.Background(), "http://example.com/home")
+		url, version, err := s.Crawl(context.Background(), "http://example.com/home")
 
 		if err != nil {
 			t.Errorf("expected no error but got %v", err)
 		}
 
+		if url != "http://example.com/home" {
+			t.Errorf("expected http://example.com/home but got %s", url)
+		}
+
+		if version != page.NewVersionHash(testFile).String() {
+			t.Errorf("expected version %s but got %s", page.NewVersionHash(testFile), version)
+		}
+
 		p, err := s.pageService.Get(page.NewPageID("http://example.com/home"))
 
 		if err != nil {
@@ -85,8 +100,8 @@ func TestCrawl(t *testing.T) {
 			t.Errorf("expected 1 version but got %d", len(p.Versions))
 		}
 
-		if p.Shard != 52283 {
-			t.Errorf("expected 52283 but got %d", p.Shard)
+		if p.Shard != 72435 {
+			t.Errorf("expected 72435 but got %d", p.Shard)
 		}
 
 		if p.Versions[0].Hash != page.NewVersionHash(testFile) {
@@ -109,4 +124,42 @@ func TestCrawl(t *testing.T) {
 			t.Errorf("Not all expectations were met")
 		}
 	})
+	t.Run("should store the page when feed links can not be extracted", func(t *testing.T) {
+		s := setupServiceWithHTML(t, &failingFeedsHTMLService{htmlService.New()})
+
+		defer gock.Off()
+
+		gock.New("http://example.com").
+			Get("/home").
+			Reply(200).
+			BodyString(string(testFile))
+
+		gock.New("http://balancer1:8080").
+			Post("/crawl/urls").
+			Reply(200)
+
+		if _, _, err := s.Crawl(context.Background(), "http://example.com/home"); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		p, err := s.pageService.Get(page.NewPageID("http://example.com/home"))
+
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if len(p.Versions) != 1 {
+			t.Errorf("expected 1 version but got %d", len(p.Versions))
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	})
+}
+
+type failingFeedsHTMLService struct {
+	html.Service
+}
+
+func (f *failingFeedsHTMLService) ExtractFeedLinks(body []byte) ([]string, error) {
+	return nil, errors.New("malformed head")
 }
@@ -4,3 +4,4 @@ import "errors"
 
 var ErrFailedQueryRequest = errors.New("failed query request")
 var ErrFailedInfoRequest = errors.New("failed info request")
+var ErrFailedChallengeRequest = errors.New("failed challenge request")
@@ -1,6 +1,9 @@
 package extraction
 
 import (
+	"juno/pkg/aggregation"
+	"juno/pkg/receipt"
+
 	"github.com/gin-gonic/gin"
 
 	"juno/pkg/node/extraction/dto"
@@ -10,6 +13,12 @@ type Handler interface {
 	Extract(c *gin.Context)
 }
 
+// Service returns the digests of what each version of the pages yielded,
+// when the request asked for them, and the receipt of the work done with the
+// rows or partials, nil when the node has no key to sign it with.
 type Service interface {
-	Extract(req dto.ExtractionRequest) ([]map[string]interface{}, error)
+	Extract(req dto.ExtractionRequest) ([]map[string]interface{}, map[string]string, *receipt.Receipt, error)
+	// Aggregate folds the extracted rows of the shard into partials of the
+	// request's aggregations.
+	Aggregate(req dto.ExtractionRequest) (aggregation.Partials, map[string]string, *receipt.Receipt, error)
 }
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"juno/pkg/aggregation"
+	"juno/pkg/receipt"
+	"juno/pkg/scope"
+)
+
 const (
 	SUCCESS = "success"
 	ERROR   = "error"
@@ -17,9 +23,21 @@ type Field struct {
 }
 
 type ExtractionRequest struct {
+	// RequestID is signed into the receipt of the work
+	RequestID string      `json:"request_id,omitempty"`
 	Shard     int         `json:"shard"`
 	Selectors []*Selector `json:"selectors" binding:"required"`
 	Fields    []*Field    `json:"fields" binding:"required"`
+
+	// Aggregations are folded into partials instead of returning the rows
+	Aggregations []*aggregation.Aggregation `json:"aggregations,omitempty"`
+
+	// Scope skips the pages outside it before they are parsed
+	Scope *scope.Scope `json:"scope,omitempty"`
+
+	// Versions asks for the digests of what each version of the pages
+	// yielded, which replicas are compared on
+	Versions bool `json:"versions,omitempty"`
 }
 
 type ExtractionResponse struct {
@@ -27,6 +45,14 @@ type ExtractionResponse struct {
 	Message string `json:"message,omitempty"`
 
 	Extractions []map[string]interface{} `json:"extractions,omitempty"`
+	Partials    aggregation.Partials     `json:"partials,omitempty"`
+
+	// Versions are the digests of what each version of the pages yielded,
+	// by replica.VersionKey, when the request asked for them
+	Versions map[string]string `json:"versions,omitempty"`
+
+	// Receipt is the node's signed receipt of the work
+	Receipt *receipt.Receipt `json:"receipt,omitempty"`
 }
 
 func NewSuccessExtractionResponse(extractions []map[string]interface{}) *ExtractionResponse {
@@ -36,6 +62,13 @@ func NewSuccessExtractionResponse(extractions []map[string]interface{}) *Extract
 	}
 }
 
+func NewSuccessAggregationResponse(partials aggregation.Partials) *ExtractionResponse {
+	return &ExtractionResponse{
+		Status:   SUCCESS,
+		Partials: partials,
+	}
+}
+
 func NewErrorExtractionResponse(err error) *ExtractionResponse {
 	return &ExtractionResponse{
 		Status:  ERROR,
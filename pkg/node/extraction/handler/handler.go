@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"juno/pkg/aggregation"
 	"juno/pkg/node/extraction"
 	"juno/pkg/node/extraction/dto"
 	"net/http"
@@ -33,7 +34,19 @@ func (h *Handler) Extract(c *gin.Context) {
 		return
 	}
 
-	data, err := h.extractionService.Extract(req)
+	if req.Scope != nil {
+		if err := req.Scope.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorExtractionResponse(err))
+			return
+		}
+	}
+
+	if len(req.Aggregations) > 0 {
+		h.aggregate(c, req)
+		return
+	}
+
+	data, versions, r, err := h.extractionService.Extract(req)
 
 	if err != nil {
 		h.logger.WithError(err).Error("failed to get titles")
@@ -41,5 +54,30 @@ func (h *Handler) Extract(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.NewSuccessExtractionResponse(data))
+	res := dto.NewSuccessExtractionResponse(data)
+	res.Versions = versions
+	res.Receipt = r
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *Handler) aggregate(c *gin.Context, req dto.ExtractionRequest) {
+	if err := aggregation.Validate(req.Aggregations); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorExtractionResponse(err))
+		return
+	}
+
+	partials, versions, r, err := h.extractionService.Aggregate(req)
+
+	if err != nil {
+		h.logger.WithError(err).Error("failed to aggregate")
+		c.JSON(http.StatusInternalServerError, dto.NewErrorExtractionResponse(err))
+		return
+	}
+
+	res := dto.NewSuccessAggregationResponse(partials)
+	res.Versions = versions
+	res.Receipt = r
+
+	c.JSON(http.StatusOK, res)
 }
@@ -3,7 +3,9 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"juno/pkg/aggregation"
 	extractionDto "juno/pkg/node/extraction/dto"
+	"juno/pkg/receipt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,12 +16,18 @@ import (
 
 type mockService struct{}
 
-func (m *mockService) Extract(req extractionDto.ExtractionRequest) ([]map[string]interface{}, error) {
+func (m *mockService) Extract(req extractionDto.ExtractionRequest) ([]map[string]interface{}, map[string]string, *receipt.Receipt, error) {
 	return []map[string]interface{}{
 		{
 			"page_title": "test",
 		},
-	}, nil
+	}, nil, &receipt.Receipt{RequestID: req.RequestID, Shards: []int{req.Shard}, Rows: 1}, nil
+}
+
+func (m *mockService) Aggregate(req extractionDto.ExtractionRequest) (aggregation.Partials, map[string]string, *receipt.Receipt, error) {
+	partials := aggregation.NewPartials(req.Aggregations)
+	partials.Add(req.Aggregations, map[string]interface{}{"page_title": "test"})
+	return partials, nil, &receipt.Receipt{RequestID: req.RequestID, Shards: []int{req.Shard}, Rows: 1}, nil
 }
 
 func TestExtract(t *testing.T) {
@@ -29,6 +37,7 @@ func TestExtract(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 
 	req := extractionDto.ExtractionRequest{
+		RequestID: "req-1",
 		Selectors: []*extractionDto.Selector{
 			{
 				ID:    "1",
@@ -72,4 +81,66 @@ func TestExtract(t *testing.T) {
 	if res.Extractions[0]["page_title"] != "test" {
 		t.Fatalf("unexpected data: %v", res.Extractions)
 	}
+
+	if res.Receipt == nil || res.Receipt.RequestID != "req-1" {
+		t.Errorf("expected the receipt of the request, got %+v", res.Receipt)
+	}
+}
+
+func TestExtractAggregations(t *testing.T) {
+	h := New(logrus.New(), &mockService{})
+
+	send := func(aggs []*aggregation.Aggregation) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		encoded, err := json.Marshal(extractionDto.ExtractionRequest{
+			Selectors:    []*extractionDto.Selector{{ID: "1", Value: "title"}},
+			Fields:       []*extractionDto.Field{{SelectorID: "1", Name: "page_title"}},
+			Aggregations: aggs,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c.Request, _ = http.NewRequest(http.MethodPost, "/extract", bytes.NewBuffer(encoded))
+		h.Extract(c)
+
+		return w, c
+	}
+
+	t.Run("should return partials", func(t *testing.T) {
+		w, c := send([]*aggregation.Aggregation{{Name: "pages", Op: aggregation.OpCount}})
+
+		if c.Writer.Status() != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", c.Writer.Status())
+		}
+
+		var res extractionDto.ExtractionResponse
+
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(res.Extractions) != 0 {
+			t.Errorf("expected no rows, got %d", len(res.Extractions))
+		}
+
+		if res.Partials["pages"] == nil || res.Partials["pages"].Count != 1 {
+			t.Errorf("unexpected partials: %v", res.Partials)
+		}
+
+		if res.Receipt == nil {
+			t.Errorf("expected a receipt")
+		}
+	})
+
+	t.Run("should reject invalid aggregations", func(t *testing.T) {
+		_, c := send([]*aggregation.Aggregation{{Name: "pages", Op: "median"}})
+
+		if c.Writer.Status() != http.StatusBadRequest {
+			t.Fatalf("unexpected status code: %d", c.Writer.Status())
+		}
+	})
 }
@@ -2,9 +2,12 @@ package service
 
 import (
 	"errors"
+	"juno/pkg/aggregation"
 	"juno/pkg/node/html"
 	"juno/pkg/node/page"
 	"juno/pkg/node/storage"
+	"juno/pkg/receipt"
+	"juno/pkg/replica"
 
 	extractionDto "juno/pkg/node/extraction/dto"
 
@@ -16,6 +19,15 @@ type Service struct {
 	pageService    page.Service
 	storageService storage.Service
 	htmlService    html.Service
+
+	// signer signs the receipts of the work, which are left out without it
+	signer *receipt.Signer
+}
+
+func WithSigner(signer *receipt.Signer) func(s *Service) {
+	return func(s *Service) {
+		s.signer = signer
+	}
 }
 
 func New(
@@ -23,13 +35,20 @@ func New(
 	pageService page.Service,
 	storageService storage.Service,
 	htmlService html.Service,
+	opts ...func(s *Service),
 ) *Service {
-	return &Service{
+	s := &Service{
 		logger:         logger,
 		pageService:    pageService,
 		storageService: storageService,
 		htmlService:    htmlService,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func getSelector(selectorID string, selectors []*extractionDto.Selector) (*extractionDto.Selector, error) {
@@ -52,14 +71,59 @@ func allFieldsEmpty(data map[string]interface{}) bool {
 	return true
 }
 
-func (s *Service) Extract(req extractionDto.ExtractionRequest) ([]map[string]interface{}, error) {
+func (s *Service) Extract(req extractionDto.ExtractionRequest) ([]map[string]interface{}, map[string]string, *receipt.Receipt, error) {
+	extractions, pages, versions := s.extract(req)
+
+	r, err := s.receipt(req, pages, len(extractions), versions)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return extractions, versions, r, nil
+}
+
+// extract returns the rows of the request, how many pages were scanned for
+// them and, when the request asked for them, the digests of what each
+// version yielded.
+func (s *Service) extract(req extractionDto.ExtractionRequest) ([]map[string]interface{}, int, map[string]string) {
 	extractions := make([]map[string]interface{}, 0)
+	pages := 0
+
+	var versions map[string]string
+	if req.Versions {
+		versions = map[string]string{}
+	}
+
+	// digest records what the version yielded, when digests were asked for
+	digest := func(p *page.Page, v page.Version, rows ...map[string]interface{}) {
+		if versions == nil {
+			return
+		}
+
+		d, err := replica.Digest(rows)
+
+		if err != nil {
+			s.logger.WithError(err).Error("failed to digest version")
+			return
+		}
+
+		versions[replica.VersionKey(p.URL, v.Hash.String())] = d
+	}
+
 	s.pageService.Iterator(func(p *page.Page) {
 
 		if req.Shard != p.Shard {
 			return
 		}
 
+		// skipped before anything is read or parsed
+		if !req.Scope.Matches(p.URL) {
+			return
+		}
+
+		pages++
+
 		for _, v := range p.Versions {
 			body, err := s.storageService.Read(v.Hash)
 
@@ -97,14 +161,62 @@ func (s *Service) Extract(req extractionDto.ExtractionRequest) ([]map[string]int
 			}
 
 			if allFieldsEmpty(pageData) {
+				digest(p, v)
 				return
 			}
 
 			pageData["_juno_meta_url"] = p.URL
 
+			digest(p, v, pageData)
+
 			extractions = append(extractions, pageData)
 		}
 	})
 
-	return extractions, nil
+	return extractions, pages, versions
+}
+
+func (s *Service) Aggregate(req extractionDto.ExtractionRequest) (aggregation.Partials, map[string]string, *receipt.Receipt, error) {
+	extractions, pages, versions := s.extract(req)
+
+	r, err := s.receipt(req, pages, len(extractions), versions)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	partials := aggregation.NewPartials(req.Aggregations)
+
+	for _, e := range extractions {
+		partials.Add(req.Aggregations, e)
+	}
+
+	partials.Compact(req.Aggregations)
+
+	return partials, versions, r, nil
+}
+
+// receipt signs the work done for the request, and the digests of the
+// versions when there are any, nil when the service has no signer.
+func (s *Service) receipt(req extractionDto.ExtractionRequest, pages, rows int, versions map[string]string) (*receipt.Receipt, error) {
+	if s.signer == nil {
+		return nil, nil
+	}
+
+	r := &receipt.Receipt{
+		RequestID: req.RequestID,
+		Shards:    []int{req.Shard},
+		Pages:     pages,
+		Rows:      rows,
+	}
+
+	if versions != nil {
+		r.Digest = replica.DigestVersions(versions)
+	}
+
+	if err := s.signer.Sign(r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
 }
@@ -1,11 +1,18 @@
 package service
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"juno/pkg/aggregation"
 	htmlService "juno/pkg/node/html/service"
 	"juno/pkg/node/page"
 	pageRepo "juno/pkg/node/page/repo/mem"
 	pageService "juno/pkg/node/page/service"
 	storageService "juno/pkg/node/storage/service"
+	"juno/pkg/receipt"
+	"juno/pkg/replica"
+	"juno/pkg/scope"
+	"maps"
 
 	extractionDto "juno/pkg/node/extraction/dto"
 	"testing"
@@ -48,9 +55,9 @@ func TestExtract(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	data, err := s.Extract(
+	data, _, _, err := s.Extract(
 		extractionDto.ExtractionRequest{
-			Shard: 68735,
+			Shard: 72435,
 			Selectors: []*extractionDto.Selector{
 				{
 					ID:    "1",
@@ -82,3 +89,186 @@ func TestExtract(t *testing.T) {
 		t.Fatalf("expected http://example.com, got %s", data[0]["_juno_meta_url"])
 	}
 }
+
+func TestAggregate(t *testing.T) {
+	pageRepo := pageRepo.New()
+	pageService := pageService.New(pageRepo)
+	storageService := storageService.New(t.TempDir())
+
+	s := New(logrus.New(), pageService, storageService, htmlService.New())
+
+	p := page.NewPage("http://example.com")
+	if err := pageService.Create(p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// two versions of the same page
+	for _, body := range [][]byte{
+		[]byte("<html><head><title>Test</title></head><body></body></html>"),
+		[]byte("<html><head><title>Test</title></head><body>v2</body></html>"),
+	} {
+		vHash := page.NewVersionHash(body)
+		pageService.AddVersion(p.ID, page.NewVersion(vHash))
+
+		if err := storageService.Write(vHash, body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	aggs := []*aggregation.Aggregation{
+		{Name: "pages", Op: aggregation.OpCount},
+		{Name: "titles", Op: aggregation.OpCountDistinct, Field: "page_title"},
+	}
+
+	partials, _, _, err := s.Aggregate(extractionDto.ExtractionRequest{
+		Shard:        72435,
+		Selectors:    []*extractionDto.Selector{{ID: "1", Value: "title"}},
+		Fields:       []*extractionDto.Field{{SelectorID: "1", Name: "page_title"}},
+		Aggregations: aggs,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results := aggregation.Finalize(aggs, partials)
+
+	if results["pages"] != int64(2) {
+		t.Errorf("expected 2 pages, got %v", results["pages"])
+	}
+
+	if results["titles"] != int64(1) {
+		t.Errorf("expected 1 distinct title, got %v", results["titles"])
+	}
+}
+
+func TestExtractScope(t *testing.T) {
+	pageRepo := pageRepo.New()
+	pageService := pageService.New(pageRepo)
+	storageService := storageService.New(t.TempDir())
+
+	s := New(logrus.New(), pageService, storageService, htmlService.New())
+
+	body := []byte("<html><head><title>Test</title></head><body></body></html>")
+
+	for _, u := range []string{"http://example.com/products/1", "http://example.com/about"} {
+		p := page.NewPage(u)
+		if err := pageService.Create(p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		vHash := page.NewVersionHash(body)
+		pageService.AddVersion(p.ID, page.NewVersion(vHash))
+
+		if err := storageService.Write(vHash, body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	data, _, _, err := s.Extract(extractionDto.ExtractionRequest{
+		Shard:     72435,
+		Selectors: []*extractionDto.Selector{{ID: "1", Value: "title"}},
+		Fields:    []*extractionDto.Field{{SelectorID: "1", Name: "page_title"}},
+		Scope:     &scope.Scope{URLPrefix: "http://example.com/products/"},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(data) != 1 || data[0]["_juno_meta_url"] != "http://example.com/products/1" {
+		t.Fatalf("expected only the products page, got %v", data)
+	}
+}
+
+func TestReceipt(t *testing.T) {
+	pageRepo := pageRepo.New()
+	pageService := pageService.New(pageRepo)
+	storageService := storageService.New(t.TempDir())
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer := receipt.NewSigner(key)
+
+	s := New(logrus.New(), pageService, storageService, htmlService.New(), WithSigner(signer))
+
+	for _, u := range []string{"http://example.com/products/1", "http://example.com/products/2", "http://example.com/about"} {
+		body := []byte("<html><head><title>" + u + "</title></head><body></body></html>")
+
+		p := page.NewPage(u)
+		if err := pageService.Create(p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		vHash := page.NewVersionHash(body)
+		pageService.AddVersion(p.ID, page.NewVersion(vHash))
+
+		if err := storageService.Write(vHash, body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	req := extractionDto.ExtractionRequest{
+		RequestID: "req-1",
+		Shard:     72435,
+		Selectors: []*extractionDto.Selector{{ID: "1", Value: "title"}},
+		Fields:    []*extractionDto.Field{{SelectorID: "1", Name: "page_title"}},
+		Scope:     &scope.Scope{URLPrefix: "http://example.com/products/"},
+	}
+
+	_, versions, r, err := s.Extract(req)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r == nil || r.RequestID != "req-1" || !r.Covers(72435) || r.Pages != 2 || r.Rows != 2 {
+		t.Fatalf("expected a receipt of 2 pages and 2 rows, got %+v", r)
+	}
+
+	if err := receipt.Verify(signer.PublicKey(), r); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	if versions != nil || r.Digest != "" {
+		t.Errorf("expected no digests without asking for them, got %v", versions)
+	}
+
+	t.Run("with the digests of the versions", func(t *testing.T) {
+		req := req
+		req.Versions = true
+
+		_, versions, r, err := s.Extract(req)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(versions) != 2 {
+			t.Fatalf("expected the digests of 2 versions, got %v", versions)
+		}
+
+		if r.Digest != replica.DigestVersions(versions) || receipt.Verify(signer.PublicKey(), r) != nil {
+			t.Errorf("expected a signed receipt committing to the digests, got %+v", r)
+		}
+
+		_, aggregated, _, _ := s.Aggregate(req)
+
+		if !maps.Equal(versions, aggregated) {
+			t.Errorf("expected the same digests when aggregating, got %v", aggregated)
+		}
+	})
+
+	req.Aggregations = []*aggregation.Aggregation{{Name: "pages", Op: aggregation.OpCount}}
+
+	if _, _, r, _ := s.Aggregate(req); r == nil || r.Rows != 2 || receipt.Verify(signer.PublicKey(), r) != nil {
+		t.Errorf("expected a signed receipt of 2 rows, got %+v", r)
+	}
+
+	t.Run("without a signer", func(t *testing.T) {
+		s := New(logrus.New(), pageService, storageService, htmlService.New())
+
+		if _, _, r, _ := s.Extract(req); r != nil {
+			t.Errorf("expected no receipt, got %+v", r)
+		}
+	})
+}
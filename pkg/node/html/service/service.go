@@ -29,6 +29,32 @@ func (s *Service) ExtractLinks(body []byte) ([]string, error) {
 	return links, nil
 }
 
+// ExtractFeedLinks returns the RSS and Atom feeds a page advertises through
+// <link rel="alternate">.
+func (s *Service) ExtractFeedLinks(body []byte) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+
+	doc.Find(`link[rel="alternate"]`).Each(func(i int, s *goquery.Selection) {
+		t, _ := s.Attr("type")
+
+		if t != "application/rss+xml" && t != "application/atom+xml" {
+			return
+		}
+
+		if link, ok := s.Attr("href"); ok {
+			links = append(links, link)
+		}
+	})
+
+	return links, nil
+}
+
 func (s *Service) Title(body []byte) (string, error) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 
@@ -39,3 +39,32 @@ func TestExtractLinks(t *testing.T) {
 		}
 	})
 }
+
+func TestExtractFeedLinks(t *testing.T) {
+	t.Run("should return rss and atom feeds", func(t *testing.T) {
+		body := `<html><head>
+			<link rel="alternate" type="application/rss+xml" href="/rss.xml">
+			<link rel="alternate" type="application/atom+xml" href="http://example.com/atom.xml">
+			<link rel="alternate" hreflang="fr" href="http://example.com/fr">
+			<link rel="stylesheet" href="/style.css">
+		</head><body></body></html>`
+
+		links, err := New().ExtractFeedLinks([]byte(body))
+
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if len(links) != 2 {
+			t.Fatalf("expected 2 links but got %d", len(links))
+		}
+
+		if links[0] != "/rss.xml" {
+			t.Errorf("expected /rss.xml but got %s", links[0])
+		}
+
+		if links[1] != "http://example.com/atom.xml" {
+			t.Errorf("expected http://example.com/atom.xml but got %s", links[1])
+		}
+	})
+}
@@ -31,6 +31,10 @@ func (s *mockPageService) GetVersions(pageID page.PageID) ([]page.Version, error
 	return nil, nil
 }
 
+func (s *mockPageService) Reshard() ([]string, error) {
+	return nil, nil
+}
+
 func (s *mockPageService) Count() (int, error) {
 	return 10, nil
 }
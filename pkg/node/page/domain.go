@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"juno/pkg/shard"
 	"time"
 )
@@ -32,6 +33,21 @@ func (h VersionHash) String() string {
 	return hex.EncodeToString(h[:])
 }
 
+// ParseVersionHash parses a version hash from its hex string.
+func ParseVersionHash(s string) (VersionHash, error) {
+	var h VersionHash
+
+	b, err := hex.DecodeString(s)
+
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("invalid version hash %q", s)
+	}
+
+	copy(h[:], b)
+
+	return h, nil
+}
+
 func NewVersionHash(data []byte) VersionHash {
 	hash := sha256.New()
 	hash.Write(data)
@@ -64,7 +80,7 @@ func NewPage(url string) *Page {
 	return &Page{
 		ID:    NewPageID(url),
 		URL:   url,
-		Shard: shard.GetShard(url),
+		Shard: shard.GetURLShard(url),
 	}
 }
 
@@ -73,6 +89,8 @@ type Repository interface {
 	GetPage(id PageID) (*Page, error)
 	AddVersion(pageID PageID, version Version) error
 	GetVersions(pageID PageID) ([]Version, error)
+	// SetShard moves a page to another shard.
+	SetShard(pageID PageID, shard int) error
 	Iterator(fn func(*Page)) error
 	Count() (int, error)
 }
@@ -83,6 +101,9 @@ type Service interface {
 	GetByURL(url string) (*Page, error)
 	AddVersion(pageID PageID, version Version) error
 	GetVersions(pageID PageID) ([]Version, error)
+	// Reshard moves the pages stored under the shard of their whole URL to
+	// the shard of their host, and returns the URLs of the moved pages.
+	Reshard() ([]string, error)
 	Iterator(fn func(*Page)) error
 	Count() (int, error)
 }
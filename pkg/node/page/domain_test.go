@@ -11,3 +11,19 @@ func TestNewPageID(t *testing.T) {
 		}
 	})
 }
+
+func TestParseVersionHash(t *testing.T) {
+	h := NewVersionHash([]byte("data"))
+
+	parsed, err := ParseVersionHash(h.String())
+
+	if err != nil || parsed != h {
+		t.Errorf("expected %s, got %s and %v", h, parsed, err)
+	}
+
+	for _, s := range []string{"", "not hex", h.String()[:10]} {
+		if _, err := ParseVersionHash(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
@@ -153,6 +153,32 @@ func (r *Repository) GetVersions(pageID page.PageID) ([]page.Version, error) {
 	return versions, nil
 }
 
+// SetShard moves a page to another shard.
+func (r *Repository) SetShard(pageID page.PageID, shard int) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("pages"))
+
+		data := b.Get(pageID[:])
+		if data == nil {
+			return page.ErrPageNotFound
+		}
+
+		var p page.Page
+		if err := json.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("failed to unmarshal page: %w", err)
+		}
+
+		p.Shard = shard
+
+		updatedData, err := json.Marshal(&p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal updated page: %w", err)
+		}
+
+		return b.Put(pageID[:], updatedData)
+	})
+}
+
 func (r *Repository) Count() (int, error) {
 	var count int
 
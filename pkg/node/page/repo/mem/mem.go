@@ -87,6 +87,20 @@ func (r *Repository) GetVersions(pageID page.PageID) ([]page.Version, error) {
 	return p.Versions, nil
 }
 
+// SetShard moves a page to another shard.
+func (r *Repository) SetShard(pageID page.PageID, shard int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, exists := r.pages[pageID]
+	if !exists {
+		return page.ErrPageNotFound
+	}
+
+	p.Shard = shard
+	return nil
+}
+
 func (r *Repository) Count() (int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
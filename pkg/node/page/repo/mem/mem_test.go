@@ -194,3 +194,33 @@ func TestRepository_Count(t *testing.T) {
 		t.Errorf("expected 1 page, got %d", count)
 	}
 }
+
+func TestRepository_SetShard(t *testing.T) {
+	repo := setupTestRepo()
+
+	testPage := &page.Page{
+		ID:    page.NewPageID("https://example.com"),
+		URL:   "https://example.com",
+		Shard: 1,
+	}
+	if err := repo.CreatePage(testPage); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+
+	if err := repo.SetShard(testPage.ID, 2); err != nil {
+		t.Fatalf("failed to set shard: %v", err)
+	}
+
+	p, err := repo.GetPage(testPage.ID)
+	if err != nil {
+		t.Fatalf("failed to get page: %v", err)
+	}
+
+	if p.Shard != 2 {
+		t.Errorf("expected shard 2, got %d", p.Shard)
+	}
+
+	if err := repo.SetShard(page.NewPageID("https://nonexistent.com"), 2); err != page.ErrPageNotFound {
+		t.Errorf("expected ErrPageNotFound, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
-import "juno/pkg/node/page"
+import (
+	"juno/pkg/node/page"
+	"juno/pkg/shard"
+)
 
 type Service struct {
 	repo page.Repository
@@ -39,3 +42,28 @@ func (s *Service) GetVersions(pageID page.PageID) ([]page.Version, error) {
 func (s *Service) Count() (int, error) {
 	return s.repo.Count()
 }
+
+func (s *Service) Reshard() ([]string, error) {
+	moves := map[page.PageID]int{}
+	urls := []string{}
+
+	// collected first, the iterator holds the store while it runs
+	err := s.repo.Iterator(func(p *page.Page) {
+		if to := shard.GetURLShard(p.URL); to != p.Shard {
+			moves[p.ID] = to
+			urls = append(urls, p.URL)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for id, to := range moves {
+		if err := s.repo.SetShard(id, to); err != nil {
+			return nil, err
+		}
+	}
+
+	return urls, nil
+}
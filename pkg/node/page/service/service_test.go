@@ -6,6 +6,7 @@ import (
 
 	"juno/pkg/node/page"
 	"juno/pkg/node/page/repo/mem"
+	"juno/pkg/shard"
 )
 
 func setupTestService() *Service {
@@ -194,3 +195,40 @@ func TestService_Count(t *testing.T) {
 		t.Errorf("expected count 1, got %d", count)
 	}
 }
+
+func TestService_Reshard(t *testing.T) {
+	service := setupTestService()
+
+	// stored under the shard of its whole URL before pages were sharded by host
+	moved := &page.Page{
+		ID:    page.NewPageID("https://example.com/about"),
+		URL:   "https://example.com/about",
+		Shard: shard.GetShard("https://example.com/about"),
+	}
+	kept := page.NewPage("https://example.com")
+
+	for _, p := range []*page.Page{moved, kept} {
+		if err := service.Create(p); err != nil {
+			t.Fatalf("failed to create page: %v", err)
+		}
+	}
+
+	urls, err := service.Reshard()
+	if err != nil {
+		t.Fatalf("failed to reshard: %v", err)
+	}
+
+	if len(urls) != 1 || urls[0] != moved.URL {
+		t.Errorf("expected %s to be moved, got %v", moved.URL, urls)
+	}
+
+	p, _ := service.Get(moved.ID)
+	if p.Shard != shard.GetURLShard(moved.URL) {
+		t.Errorf("expected shard %d, got %d", shard.GetURLShard(moved.URL), p.Shard)
+	}
+
+	// nothing is left to move
+	if urls, _ := service.Reshard(); len(urls) != 0 {
+		t.Errorf("expected no pages to be moved, got %v", urls)
+	}
+}
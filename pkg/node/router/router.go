@@ -1,6 +1,7 @@
 package router
 
 import (
+	"juno/pkg/node/challenge"
 	"juno/pkg/node/crawl"
 	"juno/pkg/node/extraction"
 	"juno/pkg/node/info"
@@ -12,12 +13,15 @@ func New(
 	crawlHandler crawl.Handler,
 	extractionHandler extraction.Handler,
 	infoHandler info.Handler,
+	challengeHandler challenge.Handler,
 ) *gin.Engine {
 	r := gin.Default()
 
 	r.POST("/crawl", crawlHandler.Crawl)
 	r.POST("/extract", extractionHandler.Extract)
 	r.GET("/info", infoHandler.Info)
+	r.POST("/challenge", challengeHandler.Challenge)
+	r.GET("/blob", challengeHandler.Blob)
 
 	return r
 }
@@ -0,0 +1,298 @@
+package nodepool
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNoNodes = errors.New("no nodes available")
+var ErrAllCircuitsOpen = errors.New("all node circuits are open")
+
+const (
+	// DefaultFailureThreshold is how many consecutive failures open a node's
+	// circuit.
+	DefaultFailureThreshold = 5
+	// DefaultOpenTimeout is how long an open circuit rejects requests before a
+	// single probe is let through.
+	DefaultOpenTimeout = 30 * time.Second
+
+	// smoothing is the weight of the latest sample in the moving averages
+	smoothing = 0.2
+	// minLatency keeps weights finite for nodes that answer instantly
+	minLatency = time.Millisecond
+)
+
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half_open"
+)
+
+// NodeStats is what the pool knows about a node.
+type NodeStats struct {
+	Node                string        `json:"node"`
+	State               State         `json:"state"`
+	Latency             time.Duration `json:"latency"`
+	ErrorRate           float64       `json:"error_rate"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	Requests            int64         `json:"requests"`
+	Failures            int64         `json:"failures"`
+	// Reputation is the node's reputation from the API, from 0 to 1
+	Reputation float64 `json:"reputation"`
+}
+
+type node struct {
+	NodeStats
+
+	sampled      bool
+	openedAt     time.Time
+	probeStarted time.Time
+}
+
+func WithFailureThreshold(threshold int) func(p *Pool) {
+	return func(p *Pool) {
+		p.failureThreshold = threshold
+	}
+}
+
+func WithOpenTimeout(timeout time.Duration) func(p *Pool) {
+	return func(p *Pool) {
+		p.openTimeout = timeout
+	}
+}
+
+// Pool picks nodes by weighted least latency and keeps a circuit breaker per
+// node. Callers pass the candidate nodes, e.g. the replicas of a shard, and
+// report the result of every request they send.
+type Pool struct {
+	mu    sync.Mutex
+	nodes map[string]*node
+
+	failureThreshold int
+	openTimeout      time.Duration
+}
+
+func New(options ...func(p *Pool)) *Pool {
+	p := &Pool{
+		nodes:            make(map[string]*node),
+		failureThreshold: DefaultFailureThreshold,
+		openTimeout:      DefaultOpenTimeout,
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+// Pick chooses one of the candidates. Excluded nodes, e.g. ones that already
+// failed this request, are only used when no other candidate is usable. A
+// half-open node is handed out as a probe before any closed node.
+func (p *Pool) Pick(candidates []string, exclude ...string) (string, error) {
+	if len(candidates) == 0 {
+		return "", ErrNoNodes
+	}
+
+	preferred := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if !slices.Contains(exclude, c) {
+			preferred = append(preferred, c)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if addr, ok := p.pick(preferred); ok {
+		return addr, nil
+	}
+
+	if addr, ok := p.pick(candidates); ok {
+		return addr, nil
+	}
+
+	return "", ErrAllCircuitsOpen
+}
+
+func (p *Pool) pick(addrs []string) (string, bool) {
+	now := time.Now()
+
+	closed := make([]*node, 0, len(addrs))
+	for _, addr := range addrs {
+		n := p.node(addr)
+
+		if n.State == StateOpen && now.Sub(n.openedAt) >= p.openTimeout {
+			n.State = StateHalfOpen
+		}
+
+		switch n.State {
+		case StateHalfOpen:
+			// one probe at a time, unless the last one was never reported
+			if n.probeStarted.IsZero() || now.Sub(n.probeStarted) >= p.openTimeout {
+				n.probeStarted = now
+				return addr, true
+			}
+		case StateClosed:
+			closed = append(closed, n)
+		}
+	}
+
+	if len(closed) == 0 {
+		return "", false
+	}
+
+	return weightedPick(closed), true
+}
+
+// Report records the outcome of a request sent to a node.
+func (p *Pool) Report(addr string, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := p.node(addr)
+	n.Requests++
+	n.probeStarted = time.Time{}
+
+	if err != nil {
+		n.Failures++
+		n.ConsecutiveFailures++
+		n.ErrorRate = average(n.ErrorRate, 1)
+
+		if n.State == StateHalfOpen || n.ConsecutiveFailures >= p.failureThreshold {
+			n.State = StateOpen
+			n.openedAt = time.Now()
+		}
+		return
+	}
+
+	if n.sampled {
+		n.Latency = time.Duration(average(float64(n.Latency), float64(latency)))
+	} else {
+		n.Latency = latency
+		n.sampled = true
+	}
+
+	n.ErrorRate = average(n.ErrorRate, 0)
+	n.ConsecutiveFailures = 0
+	n.State = StateClosed
+}
+
+// SetReputations sets the reputations of nodes, by address, which scale how
+// often they are picked. Nodes without one have the best reputation, 1.
+func (p *Pool) SetReputations(reputations map[string]float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, reputation := range reputations {
+		p.node(addr).Reputation = reputation
+	}
+}
+
+// Try sends fn to up to attempts nodes, preferring nodes it has not tried
+// yet, and reports every attempt. It returns the last node tried.
+func (p *Pool) Try(candidates []string, attempts int, fn func(node string) error) (string, error) {
+	var node string
+	var err error
+
+	tried := []string{}
+	for i := 0; i < attempts; i++ {
+		picked, pickErr := p.Pick(candidates, tried...)
+
+		if pickErr != nil {
+			if err == nil {
+				err = pickErr
+			}
+			break
+		}
+
+		node = picked
+
+		start := time.Now()
+		err = fn(node)
+		p.Report(node, time.Since(start), err)
+
+		if err == nil {
+			return node, nil
+		}
+
+		tried = append(tried, node)
+	}
+
+	return node, err
+}
+
+// Stats returns the health of every node the pool has seen, sorted by node.
+func (p *Pool) Stats() []NodeStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]NodeStats, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		stats = append(stats, n.NodeStats)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Node < stats[j].Node
+	})
+
+	return stats
+}
+
+func (p *Pool) node(addr string) *node {
+	n, ok := p.nodes[addr]
+
+	if !ok {
+		n = &node{NodeStats: NodeStats{Node: addr, State: StateClosed, Reputation: 1}}
+		p.nodes[addr] = n
+	}
+
+	return n
+}
+
+// weightedPick picks a node with a probability proportional to the inverse
+// of its latency, scaled down by its error rate and reputation. Nodes without samples are
+// assumed to be as fast as the fastest known node so they get traffic.
+func weightedPick(nodes []*node) string {
+	fastest := time.Duration(0)
+	for _, n := range nodes {
+		if n.sampled && (fastest == 0 || n.Latency < fastest) {
+			fastest = n.Latency
+		}
+	}
+
+	weights := make([]float64, len(nodes))
+	total := 0.0
+
+	for i, n := range nodes {
+		latency := n.Latency
+		if !n.sampled {
+			latency = fastest
+		}
+
+		latency = max(latency, minLatency)
+
+		weights[i] = (1.05 - n.ErrorRate) * n.Reputation / latency.Seconds()
+		total += weights[i]
+	}
+
+	r := rand.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return nodes[i].Node
+		}
+	}
+
+	return nodes[len(nodes)-1].Node
+}
+
+func average(current, sample float64) float64 {
+	return current*(1-smoothing) + sample*smoothing
+}
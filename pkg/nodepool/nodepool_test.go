@@ -0,0 +1,209 @@
+package nodepool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errNodeDown = errors.New("node down")
+
+func TestPick(t *testing.T) {
+	t.Run("returns error without candidates", func(t *testing.T) {
+		p := New()
+
+		if _, err := p.Pick(nil); err != ErrNoNodes {
+			t.Errorf("expected ErrNoNodes, got %v", err)
+		}
+	})
+
+	t.Run("prefers nodes with lower latency", func(t *testing.T) {
+		p := New()
+		p.Report("fast:9090", 10*time.Millisecond, nil)
+		p.Report("slow:9090", 500*time.Millisecond, nil)
+
+		picks := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			node, err := p.Pick([]string{"fast:9090", "slow:9090"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			picks[node]++
+		}
+
+		if picks["fast:9090"] < 900 {
+			t.Errorf("expected fast node to get most picks, got %v", picks)
+		}
+
+		if picks["slow:9090"] == 0 {
+			t.Errorf("expected slow node to still get some picks, got %v", picks)
+		}
+	})
+
+	t.Run("prefers nodes with a better reputation", func(t *testing.T) {
+		p := New()
+		p.SetReputations(map[string]float64{"trusted:9090": 1, "failing:9090": 0.05})
+
+		picks := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			node, _ := p.Pick([]string{"trusted:9090", "failing:9090"})
+			picks[node]++
+		}
+
+		if picks["trusted:9090"] < 900 {
+			t.Errorf("expected the trusted node to get most picks, got %v", picks)
+		}
+	})
+
+	t.Run("skips excluded nodes while others are left", func(t *testing.T) {
+		p := New()
+
+		for i := 0; i < 100; i++ {
+			node, _ := p.Pick([]string{"a:9090", "b:9090"}, "a:9090")
+			if node != "b:9090" {
+				t.Fatalf("expected b:9090, got %s", node)
+			}
+		}
+
+		node, _ := p.Pick([]string{"a:9090"}, "a:9090")
+		if node != "a:9090" {
+			t.Errorf("expected excluded node when it is the only one, got %s", node)
+		}
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		p := New(WithFailureThreshold(3), WithOpenTimeout(time.Hour))
+
+		for i := 0; i < 3; i++ {
+			p.Report("a:9090", time.Millisecond, errNodeDown)
+		}
+
+		if _, err := p.Pick([]string{"a:9090"}); err != ErrAllCircuitsOpen {
+			t.Errorf("expected ErrAllCircuitsOpen, got %v", err)
+		}
+
+		for i := 0; i < 100; i++ {
+			if node, _ := p.Pick([]string{"a:9090", "b:9090"}); node != "b:9090" {
+				t.Fatalf("expected b:9090, got %s", node)
+			}
+		}
+	})
+
+	t.Run("a success resets the failure count", func(t *testing.T) {
+		p := New(WithFailureThreshold(2))
+
+		p.Report("a:9090", time.Millisecond, errNodeDown)
+		p.Report("a:9090", time.Millisecond, nil)
+		p.Report("a:9090", time.Millisecond, errNodeDown)
+
+		if _, err := p.Pick([]string{"a:9090"}); err != nil {
+			t.Errorf("expected circuit to stay closed, got %v", err)
+		}
+	})
+
+	t.Run("probes a half-open node once", func(t *testing.T) {
+		p := New(WithFailureThreshold(1), WithOpenTimeout(10*time.Millisecond))
+
+		p.Report("a:9090", time.Millisecond, errNodeDown)
+		time.Sleep(15 * time.Millisecond)
+
+		node, err := p.Pick([]string{"a:9090", "b:9090"})
+		if err != nil || node != "a:9090" {
+			t.Fatalf("expected a:9090 to be probed, got %s, %v", node, err)
+		}
+
+		// no second probe while the first one is in flight
+		if node, _ := p.Pick([]string{"a:9090", "b:9090"}); node != "b:9090" {
+			t.Errorf("expected b:9090 during the probe, got %s", node)
+		}
+
+		p.Report("a:9090", time.Millisecond, nil)
+
+		if stats := p.Stats(); stats[0].State != StateClosed {
+			t.Errorf("expected circuit to close after a successful probe, got %s", stats[0].State)
+		}
+	})
+
+	t.Run("a failed probe opens the circuit again", func(t *testing.T) {
+		p := New(WithFailureThreshold(3), WithOpenTimeout(10*time.Millisecond))
+
+		for i := 0; i < 3; i++ {
+			p.Report("a:9090", time.Millisecond, errNodeDown)
+		}
+		time.Sleep(15 * time.Millisecond)
+
+		p.Pick([]string{"a:9090"})
+		p.Report("a:9090", time.Millisecond, errNodeDown)
+
+		if _, err := p.Pick([]string{"a:9090"}); err != ErrAllCircuitsOpen {
+			t.Errorf("expected ErrAllCircuitsOpen, got %v", err)
+		}
+	})
+}
+
+func TestTry(t *testing.T) {
+	t.Run("fails over to another node", func(t *testing.T) {
+		p := New()
+		p.Report("a:9090", time.Millisecond, nil)
+		p.Report("b:9090", time.Second, nil)
+
+		calls := []string{}
+		node, err := p.Try([]string{"a:9090", "b:9090"}, 3, func(node string) error {
+			calls = append(calls, node)
+			if len(calls) == 1 {
+				return errNodeDown
+			}
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(calls) != 2 || calls[0] == calls[1] || node != calls[1] {
+			t.Errorf("expected a second, different node, got %v", calls)
+		}
+	})
+
+	t.Run("returns the last error after all attempts", func(t *testing.T) {
+		p := New()
+
+		calls := 0
+		node, err := p.Try([]string{"a:9090"}, 3, func(node string) error {
+			calls++
+			return errNodeDown
+		})
+
+		if err != errNodeDown || node != "a:9090" {
+			t.Errorf("expected errNodeDown from a:9090, got %v from %s", err, node)
+		}
+
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+
+		if stats := p.Stats(); stats[0].Failures != 3 || stats[0].Requests != 3 {
+			t.Errorf("expected 3 failed requests, got %+v", stats[0])
+		}
+	})
+
+	t.Run("stops when every circuit is open", func(t *testing.T) {
+		p := New(WithFailureThreshold(1), WithOpenTimeout(time.Hour))
+
+		calls := 0
+		_, err := p.Try([]string{"a:9090"}, 3, func(node string) error {
+			calls++
+			return errNodeDown
+		})
+
+		if err != errNodeDown {
+			t.Errorf("expected errNodeDown, got %v", err)
+		}
+
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
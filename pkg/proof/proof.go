@@ -0,0 +1,73 @@
+// Package proof challenges nodes to prove they still store a blob. The
+// challenger picks a random nonce and byte ranges of the blob, and only a node
+// holding the blob can answer with the HMAC of the ranges keyed by the nonce.
+package proof
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+const (
+	// NonceSize is the size of the nonces challenges are keyed by.
+	NonceSize = 16
+	// DefaultRanges is how many ranges a challenge covers, and
+	// DefaultRangeLength how long each is at most.
+	DefaultRanges      = 4
+	DefaultRangeLength = 64
+)
+
+// Range is a byte range of a blob.
+type Range struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+// Challenge asks for the HMAC of the ranges of a blob, keyed by the nonce.
+type Challenge struct {
+	Nonce  []byte  `json:"nonce"`
+	Ranges []Range `json:"ranges"`
+}
+
+// NewChallenge picks a random nonce and ranges of a blob of size bytes.
+func NewChallenge(size int) (*Challenge, error) {
+	c := &Challenge{Nonce: make([]byte, NonceSize)}
+
+	if _, err := rand.Read(c.Nonce); err != nil {
+		return nil, err
+	}
+
+	length := min(size, DefaultRangeLength)
+
+	for i := 0; i < DefaultRanges; i++ {
+		offset, err := rand.Int(rand.Reader, big.NewInt(int64(size-length+1)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.Ranges = append(c.Ranges, Range{Offset: int(offset.Int64()), Length: length})
+	}
+
+	return c, nil
+}
+
+// MAC answers the challenge on the blob. Ranges are clamped to the blob, and
+// their bounds are part of the MAC so an answer is only valid for them.
+func (c *Challenge) MAC(blob []byte) []byte {
+	mac := hmac.New(sha256.New, c.Nonce)
+
+	for _, r := range c.Ranges {
+		binary.Write(mac, binary.BigEndian, [2]int64{int64(r.Offset), int64(r.Length)})
+
+		start := min(max(r.Offset, 0), len(blob))
+		end := min(start+max(r.Length, 0), len(blob))
+
+		mac.Write(blob[start:end])
+	}
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,66 @@
+package proof
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewChallenge(t *testing.T) {
+	for _, size := range []int{0, 10, 1_000} {
+		c, err := NewChallenge(size)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		if len(c.Nonce) != NonceSize || len(c.Ranges) != DefaultRanges {
+			t.Fatalf("Expected a nonce and %d ranges, got %+v", DefaultRanges, c)
+		}
+
+		for _, r := range c.Ranges {
+			if r.Offset < 0 || r.Length != min(size, DefaultRangeLength) || r.Offset+r.Length > size {
+				t.Errorf("Expected a range within %d bytes, got %+v", size, r)
+			}
+		}
+	}
+}
+
+func TestMAC(t *testing.T) {
+	blob := bytes.Repeat([]byte("juno"), 100)
+
+	c := &Challenge{Nonce: []byte("nonce"), Ranges: []Range{{Offset: 10, Length: 20}, {Offset: 390, Length: 64}}}
+
+	mac := c.MAC(blob)
+
+	t.Run("same blob", func(t *testing.T) {
+		if !bytes.Equal(c.MAC(bytes.Clone(blob)), mac) {
+			t.Errorf("Expected the same MAC")
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		changed := bytes.Clone(blob)
+		changed[15] = 'x'
+
+		other := &Challenge{Nonce: []byte("other"), Ranges: c.Ranges}
+		shifted := &Challenge{Nonce: c.Nonce, Ranges: []Range{{Offset: 14, Length: 20}, {Offset: 390, Length: 64}}}
+
+		for name, got := range map[string][]byte{
+			"blob":   c.MAC(changed),
+			"nonce":  other.MAC(blob),
+			"ranges": shifted.MAC(blob),
+		} {
+			if bytes.Equal(got, mac) {
+				t.Errorf("%s: expected another MAC", name)
+			}
+		}
+	})
+
+	t.Run("outside the blob", func(t *testing.T) {
+		c := &Challenge{Nonce: []byte("nonce"), Ranges: []Range{{Offset: -5, Length: 1_000}, {Offset: 1_000, Length: 10}}}
+
+		if len(c.MAC(blob)) == 0 {
+			t.Errorf("Expected a MAC")
+		}
+	})
+}
@@ -2,8 +2,10 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"juno/pkg/aggregation"
 	fieldDto "juno/pkg/api/extractor/field/dto"
 	filterDto "juno/pkg/api/extractor/filter/dto"
 	selectorDto "juno/pkg/api/extractor/selector/dto"
@@ -11,6 +13,7 @@ import (
 	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/filter"
 	"juno/pkg/api/extractor/selector"
+	"juno/pkg/scope"
 	"net/http"
 
 	dto "juno/pkg/ranag/dto"
@@ -26,7 +29,48 @@ func New(baseURL string) *Client {
 	}
 }
 
-func (c Client) SendRangeAggregationRequest(offset, total int, selectors []*selector.Selector, fields []*field.Field, filters []*filter.Filter) (*dto.RangeAggregatorResponse, error) {
+// SendRangeAggregationRequest aggregates the shard range on the ranag, only
+// the pages in the scope when it is set. When too few shards answered the
+// response is returned along with the error.
+func (c Client) SendRangeAggregationRequest(offset, total int, selectors []*selector.Selector, fields []*field.Field, filters []*filter.Filter, scope *scope.Scope) (*dto.RangeAggregatorResponse, error) {
+	return c.SendRangeAggregationRequestContext(context.Background(), "", offset, total, selectors, fields, filters, scope)
+}
+
+// SendRangeAggregationRequestContext is SendRangeAggregationRequest that
+// gives up when ctx is cancelled. The ranag then cancels its own requests to
+// the nodes. The receipts of the work are signed with the request ID.
+func (c Client) SendRangeAggregationRequestContext(ctx context.Context, requestID string, offset, total int, selectors []*selector.Selector, fields []*field.Field, filters []*filter.Filter, scope *scope.Scope) (*dto.RangeAggregatorResponse, error) {
+	req := newRangeAggregatorRequest(offset, total, selectors, fields, filters)
+	req.RequestID = requestID
+	req.Scope = scope
+
+	return c.send(ctx, req)
+}
+
+// SendRangeReduceRequest has the ranag reduce the shard range with the
+// aggregations. The response carries the merged partials instead of rows.
+func (c Client) SendRangeReduceRequest(offset, total int, selectors []*selector.Selector, fields []*field.Field, filters []*filter.Filter, aggregations []*aggregation.Aggregation, scope *scope.Scope) (*dto.RangeAggregatorResponse, error) {
+	return c.SendRangeReduceRequestContext(context.Background(), "", offset, total, selectors, fields, filters, aggregations, scope)
+}
+
+// SendRangeReduceRequestContext is SendRangeReduceRequest that gives up when
+// ctx is cancelled. The receipts of the work are signed with the request ID.
+func (c Client) SendRangeReduceRequestContext(ctx context.Context, requestID string, offset, total int, selectors []*selector.Selector, fields []*field.Field, filters []*filter.Filter, aggregations []*aggregation.Aggregation, scope *scope.Scope) (*dto.RangeAggregatorResponse, error) {
+	req := newRangeAggregatorRequest(offset, total, selectors, fields, filters)
+	req.RequestID = requestID
+	req.Aggregations = aggregations
+	req.Scope = scope
+
+	return c.send(ctx, req)
+}
+
+// Forward sends a request as is, for ranags delegating part of their range
+// to a child. It gives up when ctx is cancelled.
+func (c Client) Forward(ctx context.Context, req *dto.RangeAggregatorRequest) (*dto.RangeAggregatorResponse, error) {
+	return c.send(ctx, req)
+}
+
+func newRangeAggregatorRequest(offset, total int, selectors []*selector.Selector, fields []*field.Field, filters []*filter.Filter) *dto.RangeAggregatorRequest {
 
 	selectorDtos := make([]*selectorDto.Selector, 0, len(selectors))
 
@@ -46,36 +90,50 @@ func (c Client) SendRangeAggregationRequest(offset, total int, selectors []*sele
 		filterDtos = append(filterDtos, filterDto.NewFilterFromDomain(f))
 	}
 
-	req := &dto.RangeAggregatorRequest{
+	return &dto.RangeAggregatorRequest{
 		Offset:    offset,
 		Total:     total,
 		Selectors: selectorDtos,
 		Fields:    fieldDtos,
 		Filters:   filterDtos,
 	}
+}
 
+func (c Client) send(ctx context.Context, req *dto.RangeAggregatorRequest) (*dto.RangeAggregatorResponse, error) {
 	encoded, err := json.Marshal(req)
 
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.Post("http://"+c.baseURL+"/aggregate", "application/json", bytes.NewBuffer(encoded))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+c.baseURL+"/aggregate", bytes.NewBuffer(encoded))
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("unexpected status code")
+	resp, err := http.DefaultClient.Do(httpReq)
+
+	if err != nil {
+		return nil, err
 	}
 
+	defer resp.Body.Close()
+
 	var rangeAggregatorResponse dto.RangeAggregatorResponse
 
 	err = json.NewDecoder(resp.Body).Decode(&rangeAggregatorResponse)
 
+	if resp.StatusCode != http.StatusOK {
+		// the shard statuses tell the caller which shards were missing
+		if err == nil && rangeAggregatorResponse.Message != "" {
+			return &rangeAggregatorResponse, errors.New(rangeAggregatorResponse.Message)
+		}
+		return nil, errors.New("unexpected status code")
+	}
+
 	if err != nil {
 		return nil, err
 	}
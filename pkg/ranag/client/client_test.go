@@ -6,10 +6,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/h2non/gock"
 
+	"juno/pkg/aggregation"
 	"juno/pkg/api/extractor/field"
 	"juno/pkg/api/extractor/filter"
 	"juno/pkg/api/extractor/selector"
 	"juno/pkg/ranag/dto"
+	"juno/pkg/scope"
 )
 
 func TestSendRangeAggregationRequest(t *testing.T) {
@@ -25,7 +27,7 @@ func TestSendRangeAggregationRequest(t *testing.T) {
 					"product_title": "charger",
 					"price":         100,
 				},
-			}))
+			}, []*dto.ShardStatus{{Shard: 0, Status: dto.ShardOK, Attempts: 1}}))
 
 		client := New("localhost:8080")
 		selectors := []*selector.Selector{
@@ -55,6 +57,7 @@ func TestSendRangeAggregationRequest(t *testing.T) {
 			selectors,
 			fields,
 			filters,
+			nil,
 		)
 
 		if err != nil {
@@ -76,10 +79,40 @@ func TestSendRangeAggregationRequest(t *testing.T) {
 
 		client := New("localhost:8080")
 
-		_, err := client.SendRangeAggregationRequest(0, 0, nil, nil, nil)
+		_, err := client.SendRangeAggregationRequest(0, 0, nil, nil, nil, nil)
 
 		if err == nil {
 			t.Fatal("expected error")
 		}
 	})
 }
+
+func TestSendRangeReduceRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://localhost:8080").
+			Post("/aggregate").
+			MatchType("json").
+			BodyString(`"aggregations":\[\{"name":"products","op":"count"\}\],"scope":\{"hosts":\["example.com"\]\}`).
+			Reply(200).
+			JSON(dto.NewSuccessRangeReduceResponse(aggregation.Partials{
+				"products": {Count: 7},
+			}, []*dto.ShardStatus{{Shard: 0, Status: dto.ShardOK, Attempts: 1}}))
+
+		client := New("localhost:8080")
+
+		resp, err := client.SendRangeReduceRequest(0, 10, nil, nil, nil, []*aggregation.Aggregation{
+			{Name: "products", Op: aggregation.OpCount},
+		}, &scope.Scope{Hosts: []string{"example.com"}})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Partials["products"].Count != 7 {
+			t.Errorf("expected 7, got %d", resp.Partials["products"].Count)
+		}
+	})
+}
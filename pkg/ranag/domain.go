@@ -1,13 +1,60 @@
 package ranag
 
 import (
+	"context"
+	"errors"
+	"juno/pkg/aggregation"
 	"juno/pkg/ranag/dto"
+	"juno/pkg/receipt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+var ErrInsufficientCoverage = errors.New("too few shards answered")
+var ErrCycle = errors.New("request already passed this ranag")
+
+const (
+	// DefaultHedgePercentile is the latency percentile after which a shard
+	// request is duplicated to a second replica.
+	DefaultHedgePercentile = 0.95
+	// DefaultHedgeDelay is used until enough latencies were sampled.
+	DefaultHedgeDelay = 500 * time.Millisecond
+	MinHedgeDelay     = 10 * time.Millisecond
+	HedgeMinSamples   = 20
+	LatencySamples    = 512
+
+	// DefaultNodeConcurrency is how many requests a node gets at first. The
+	// limit adapts to how the node copes, up to MaxNodeConcurrency.
+	DefaultNodeConcurrency = 4
+	MaxNodeConcurrency     = 64
+
+	// DefaultShardConcurrency is how many shards of a range are queried at
+	// once, whatever the size of the range.
+	DefaultShardConcurrency = 256
+
+	// VerificationReplicas is how many other replicas the answer of a
+	// sampled shard is compared with.
+	VerificationReplicas = 2
+
+	// MaxDepth is how many ranags a request may pass through. A ranag at
+	// the last level queries its nodes itself instead of delegating.
+	MaxDepth = 4
+)
+
 type Service interface {
-	RangeAggregate(offset, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, error)
+	// RangeAggregate queries every shard of the range and returns the data of
+	// the shards that answered with the status of every shard. It fails with
+	// ErrInsufficientCoverage when fewer than req.MinCoverage percent answered.
+	// Cancelling ctx cancels the requests to the nodes and child ranags.
+	RangeAggregate(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, []*dto.ShardStatus, error)
+	// RangeReduce is RangeAggregate for requests with aggregations. The
+	// shards' partials are merged instead of their rows concatenated.
+	RangeReduce(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) (aggregation.Partials, []*dto.ShardStatus, error)
+	// Receipt signs the ranag's receipt of the shards that answered, adding
+	// up the pages and rows of their nodes' receipts. It is nil when the
+	// ranag has no key to sign it with.
+	Receipt(requestID string, shards []*dto.ShardStatus) (*receipt.Receipt, error)
 }
 
 type Handler interface {
@@ -1,9 +1,13 @@
 package dto
 
 import (
+	"juno/pkg/aggregation"
 	fieldDto "juno/pkg/api/extractor/field/dto"
 	filterDto "juno/pkg/api/extractor/filter/dto"
 	selectorDto "juno/pkg/api/extractor/selector/dto"
+	"juno/pkg/receipt"
+	"juno/pkg/replica"
+	"juno/pkg/scope"
 )
 
 const (
@@ -11,12 +15,108 @@ const (
 	ERROR   = "error"
 )
 
+// Shard statuses. A retried shard was answered by another replica than the
+// one asked first, after a failover or a hedge.
+const (
+	ShardOK      = "ok"
+	ShardRetried = "retried"
+	ShardFailed  = "failed"
+	ShardNoNodes = "no_nodes"
+)
+
 type RangeAggregatorRequest struct {
+	// RequestID is passed on to the nodes, which sign it into the receipts
+	// of their work
+	RequestID string                  `json:"request_id,omitempty"`
 	Offset    int                     `json:"offset"`
 	Total     int                     `json:"total" binding:"required"`
 	Selectors []*selectorDto.Selector `json:"selectors" binding:"required"`
 	Fields    []*fieldDto.Field       `json:"fields" binding:"required"`
 	Filters   []*filterDto.Filter     `json:"filters" binding:"required"`
+
+	// Aggregations make the response carry merged partials instead of rows
+	Aggregations []*aggregation.Aggregation `json:"aggregations,omitempty"`
+	// Scope is passed on to the nodes, which skip the pages outside it
+	Scope *scope.Scope `json:"scope,omitempty"`
+
+	// MinCoverage is the percentage of shards that must answer, 0 accepts
+	// any partial result
+	MinCoverage float64 `json:"min_coverage" binding:"min=0,max=100"`
+	// Path holds the addresses of the ranags that delegated the request,
+	// so cycles between ranags are detected
+	Path []string `json:"path,omitempty"`
+}
+
+type ShardStatus struct {
+	Shard    int    `json:"shard"`
+	Status   string `json:"status"`
+	Node     string `json:"node,omitempty"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+
+	// Hedged is set when a duplicate request went to a second replica, and
+	// HedgeWon when that duplicate answered first
+	Hedged   bool `json:"hedged,omitempty"`
+	HedgeWon bool `json:"hedge_won,omitempty"`
+	// Via is the child ranag the shard was delegated to
+	Via string `json:"via,omitempty"`
+	// Verification is what other replicas answered when the shard was
+	// sampled to compare its answer with them
+	Verification *replica.Verification `json:"verification,omitempty"`
+	// Receipt is the receipt the node that answered signed for the shard
+	Receipt *receipt.Receipt `json:"receipt,omitempty"`
+	// Delegations are the receipts of the child ranags whose delegated run
+	// this is the first answered shard of, so each is sent once
+	Delegations []*Delegation `json:"delegations,omitempty"`
+}
+
+// Delegation is the receipt a child ranag signed for the run of shards it was
+// delegated.
+type Delegation struct {
+	Ranag   string           `json:"ranag"`
+	Receipt *receipt.Receipt `json:"receipt"`
+}
+
+// Answered reports whether the shard's data is part of the result.
+func (s *ShardStatus) Answered() bool {
+	return s.Status == ShardOK || s.Status == ShardRetried
+}
+
+// Coverage is the percentage of shards that answered.
+func Coverage(shards []*ShardStatus) float64 {
+	if len(shards) == 0 {
+		return 0
+	}
+
+	answered := 0
+	for _, s := range shards {
+		if s.Answered() {
+			answered++
+		}
+	}
+
+	return float64(answered) * 100 / float64(len(shards))
+}
+
+type HedgingStats struct {
+	Hedged int `json:"hedged"`
+	Won    int `json:"won"`
+}
+
+func NewHedgingStats(shards []*ShardStatus) *HedgingStats {
+	stats := &HedgingStats{}
+
+	for _, s := range shards {
+		if s.Hedged {
+			stats.Hedged++
+		}
+
+		if s.HedgeWon {
+			stats.Won++
+		}
+	}
+
+	return stats
 }
 
 type RangeAggregatorResponse struct {
@@ -24,12 +124,43 @@ type RangeAggregatorResponse struct {
 	Message string `json:"message,omitempty"`
 
 	Aggregations []map[string]interface{} `json:"aggregations,omitempty"`
+	Partials     aggregation.Partials     `json:"partials,omitempty"`
+	Coverage     float64                  `json:"coverage"`
+	Hedging      *HedgingStats            `json:"hedging,omitempty"`
+	Shards       []*ShardStatus           `json:"shards,omitempty"`
+
+	// Receipt is the ranag's signed receipt of the work its nodes did
+	Receipt *receipt.Receipt `json:"receipt,omitempty"`
 }
 
-func NewSuccessRangeAggregatorResponse(aggregations []map[string]interface{}) *RangeAggregatorResponse {
+func NewSuccessRangeAggregatorResponse(aggregations []map[string]interface{}, shards []*ShardStatus) *RangeAggregatorResponse {
 	return &RangeAggregatorResponse{
 		Status:       SUCCESS,
 		Aggregations: aggregations,
+		Coverage:     Coverage(shards),
+		Hedging:      NewHedgingStats(shards),
+		Shards:       shards,
+	}
+}
+
+func NewSuccessRangeReduceResponse(partials aggregation.Partials, shards []*ShardStatus) *RangeAggregatorResponse {
+	return &RangeAggregatorResponse{
+		Status:   SUCCESS,
+		Partials: partials,
+		Coverage: Coverage(shards),
+		Hedging:  NewHedgingStats(shards),
+		Shards:   shards,
+	}
+}
+
+// NewCoverageErrorRangeAggregatorResponse reports which shards failed when
+// too few answered. The partial data is left out.
+func NewCoverageErrorRangeAggregatorResponse(err error, shards []*ShardStatus) *RangeAggregatorResponse {
+	return &RangeAggregatorResponse{
+		Status:   ERROR,
+		Message:  err.Error(),
+		Coverage: Coverage(shards),
+		Shards:   shards,
 	}
 }
 
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"juno/pkg/aggregation"
 	"juno/pkg/ranag"
 	"juno/pkg/ranag/dto"
 
@@ -25,12 +27,74 @@ func (h *Handler) RangeAggregate(c *gin.Context) {
 		return
 	}
 
-	res, err := h.service.RangeAggregate(req.Offset, req.Total, req)
+	if req.Scope != nil {
+		if err := req.Scope.Validate(); err != nil {
+			c.JSON(400, dto.NewErrorRangeAggregatorResponse(err))
+			return
+		}
+	}
+
+	if len(req.Aggregations) > 0 {
+		h.rangeReduce(c, req)
+		return
+	}
+
+	res, shards, err := h.service.RangeAggregate(c.Request.Context(), req.Offset, req.Total, req)
+
+	if errors.Is(err, ranag.ErrCycle) {
+		c.JSON(508, dto.NewErrorRangeAggregatorResponse(err))
+		return
+	}
+
+	if errors.Is(err, ranag.ErrInsufficientCoverage) {
+		c.JSON(503, dto.NewCoverageErrorRangeAggregatorResponse(err, shards))
+		return
+	}
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, req, dto.NewSuccessRangeAggregatorResponse(res, shards))
+}
+
+func (h *Handler) rangeReduce(c *gin.Context, req dto.RangeAggregatorRequest) {
+	if err := aggregation.Validate(req.Aggregations); err != nil {
+		c.JSON(400, dto.NewErrorRangeAggregatorResponse(err))
+		return
+	}
+
+	partials, shards, err := h.service.RangeReduce(c.Request.Context(), req.Offset, req.Total, req)
+
+	if errors.Is(err, ranag.ErrCycle) {
+		c.JSON(508, dto.NewErrorRangeAggregatorResponse(err))
+		return
+	}
+
+	if errors.Is(err, ranag.ErrInsufficientCoverage) {
+		c.JSON(503, dto.NewCoverageErrorRangeAggregatorResponse(err, shards))
+		return
+	}
 
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, dto.NewSuccessRangeAggregatorResponse(res))
+	h.respond(c, req, dto.NewSuccessRangeReduceResponse(partials, shards))
+}
+
+// respond signs the ranag's receipt into the response.
+func (h *Handler) respond(c *gin.Context, req dto.RangeAggregatorRequest, res *dto.RangeAggregatorResponse) {
+	r, err := h.service.Receipt(req.RequestID, res.Shards)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	res.Receipt = r
+
+	c.JSON(200, res)
 }
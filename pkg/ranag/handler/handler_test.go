@@ -2,8 +2,12 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"juno/pkg/aggregation"
+	"juno/pkg/ranag"
 	"juno/pkg/ranag/dto"
+	"juno/pkg/receipt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -17,20 +21,35 @@ import (
 
 type mockService struct{}
 
-func (m *mockService) RangeAggregate(offset, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, error) {
+func (m *mockService) RangeAggregate(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, []*dto.ShardStatus, error) {
 	return []map[string]interface{}{
 		{
 			"product_title": "test",
 		},
+	}, []*dto.ShardStatus{
+		{Shard: 0, Status: dto.ShardOK, Attempts: 1},
 	}, nil
 }
 
+func (m *mockService) RangeReduce(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) (aggregation.Partials, []*dto.ShardStatus, error) {
+	return aggregation.Partials{
+		"products": {Count: 42},
+	}, []*dto.ShardStatus{
+		{Shard: 0, Status: dto.ShardOK, Attempts: 1},
+	}, nil
+}
+
+func (m *mockService) Receipt(requestID string, shards []*dto.ShardStatus) (*receipt.Receipt, error) {
+	return &receipt.Receipt{RequestID: requestID, Shards: []int{0}}, nil
+}
+
 func TestRangeAggregate(t *testing.T) {
 	h := New(&mockService{})
 
 	req := dto.RangeAggregatorRequest{
-		Offset: 0,
-		Total:  1,
+		RequestID: "req-1",
+		Offset:    0,
+		Total:     1,
 		Selectors: []*selectorDto.Selector{
 			{
 				ID:   "1",
@@ -92,4 +111,129 @@ func TestRangeAggregate(t *testing.T) {
 	if aggregation["product_title"] != "test" {
 		t.Errorf("Expected product_title to be test, got %s", aggregation["product_title"])
 	}
+
+	if resp.Receipt == nil || resp.Receipt.RequestID != "req-1" {
+		t.Errorf("Expected the receipt of the request, got %+v", resp.Receipt)
+	}
+}
+
+type coverageErrorService struct{}
+
+func (m *coverageErrorService) RangeAggregate(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, []*dto.ShardStatus, error) {
+	return nil, []*dto.ShardStatus{
+		{Shard: 0, Status: dto.ShardOK, Attempts: 1},
+		{Shard: 1, Status: dto.ShardNoNodes},
+	}, ranag.ErrInsufficientCoverage
+}
+
+func (m *coverageErrorService) Receipt(requestID string, shards []*dto.ShardStatus) (*receipt.Receipt, error) {
+	return nil, nil
+}
+
+func (m *coverageErrorService) RangeReduce(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) (aggregation.Partials, []*dto.ShardStatus, error) {
+	return nil, nil, ranag.ErrInsufficientCoverage
+}
+
+func TestRangeAggregateInsufficientCoverage(t *testing.T) {
+	h := New(&coverageErrorService{})
+
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest(http.MethodPost, "/aggregate", bytes.NewReader([]byte(`{"total": 2, "selectors": [], "fields": [], "filters": [], "min_coverage": 100}`)))
+
+	h.RangeAggregate(c)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code 503, got %d", w.Code)
+	}
+
+	var resp dto.RangeAggregatorResponse
+
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Coverage != 50 {
+		t.Errorf("Expected coverage 50, got %f", resp.Coverage)
+	}
+
+	if len(resp.Shards) != 2 {
+		t.Errorf("Expected 2 shard statuses, got %d", len(resp.Shards))
+	}
+}
+
+type cycleService struct{}
+
+func (m *cycleService) RangeAggregate(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, []*dto.ShardStatus, error) {
+	return nil, nil, ranag.ErrCycle
+}
+
+func (m *cycleService) Receipt(requestID string, shards []*dto.ShardStatus) (*receipt.Receipt, error) {
+	return nil, nil
+}
+
+func (m *cycleService) RangeReduce(ctx context.Context, offset, total int, req dto.RangeAggregatorRequest) (aggregation.Partials, []*dto.ShardStatus, error) {
+	return nil, nil, ranag.ErrCycle
+}
+
+func TestRangeAggregateCycle(t *testing.T) {
+	h := New(&cycleService{})
+
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest(http.MethodPost, "/aggregate", bytes.NewReader([]byte(`{"total": 2, "selectors": [], "fields": [], "filters": [], "path": ["ranag.com:6060"]}`)))
+
+	h.RangeAggregate(c)
+
+	if w.Code != http.StatusLoopDetected {
+		t.Errorf("Expected status code 508, got %d", w.Code)
+	}
+}
+
+func TestRangeReduce(t *testing.T) {
+	h := New(&mockService{})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/aggregate", bytes.NewReader([]byte(body)))
+
+		h.RangeAggregate(c)
+
+		return w
+	}
+
+	t.Run("returns the merged partials", func(t *testing.T) {
+		w := send(`{"total": 1, "selectors": [], "fields": [], "filters": [], "aggregations": [{"name": "products", "op": "count"}]}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		var resp dto.RangeAggregatorResponse
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Aggregations) != 0 {
+			t.Errorf("Expected no rows, got %d", len(resp.Aggregations))
+		}
+
+		if resp.Partials["products"].Count != 42 {
+			t.Errorf("Expected 42, got %d", resp.Partials["products"].Count)
+		}
+	})
+
+	t.Run("rejects invalid aggregations", func(t *testing.T) {
+		w := send(`{"total": 1, "selectors": [], "fields": [], "filters": [], "aggregations": [{"name": "products", "op": "top_k", "field": "title"}]}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
 }
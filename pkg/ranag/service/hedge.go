@@ -0,0 +1,53 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencies keeps the latest shard request latencies to derive the hedge
+// delay from.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newLatencies(size int) *latencies {
+	return &latencies{samples: make([]time.Duration, 0, size)}
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.samples) < cap(l.samples) {
+		l.samples = append(l.samples, d)
+		return
+	}
+
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % len(l.samples)
+}
+
+// percentile returns the p-th percentile (0-1) of the samples, or false when
+// there are too few samples to tell.
+func (l *latencies) percentile(p float64, minSamples int) (time.Duration, bool) {
+	l.mu.Lock()
+	sorted := make([]time.Duration, len(l.samples))
+	copy(sorted, l.samples)
+	l.mu.Unlock()
+
+	if len(sorted) < minSamples || len(sorted) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	i := int(p * float64(len(sorted)-1))
+
+	return sorted[i], true
+}
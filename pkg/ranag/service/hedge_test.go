@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencies(t *testing.T) {
+	t.Run("needs enough samples", func(t *testing.T) {
+		l := newLatencies(10)
+		l.add(time.Millisecond)
+
+		if _, ok := l.percentile(0.95, 2); ok {
+			t.Errorf("expected too few samples")
+		}
+	})
+
+	t.Run("returns the percentile of the latest samples", func(t *testing.T) {
+		l := newLatencies(10)
+
+		// the first 10 samples are overwritten
+		for i := 1; i <= 20; i++ {
+			l.add(time.Duration(i) * time.Millisecond)
+		}
+
+		p50, _ := l.percentile(0.5, 1)
+		if p50 != 15*time.Millisecond {
+			t.Errorf("expected p50 of 15ms, got %v", p50)
+		}
+
+		p100, _ := l.percentile(1, 1)
+		if p100 != 20*time.Millisecond {
+			t.Errorf("expected p100 of 20ms, got %v", p100)
+		}
+	})
+}
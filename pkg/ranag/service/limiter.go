@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter caps the requests in flight to one node. The cap grows by one per
+// window of successful requests and halves when the node fails or answers
+// slower than the hedge delay (additive increase, multiplicative decrease).
+type limiter struct {
+	mu       sync.Mutex
+	limit    float64
+	min      float64
+	max      float64
+	inflight int
+	waiters  []chan struct{}
+}
+
+func newLimiter(initial, max int) *limiter {
+	return &limiter{
+		limit: float64(initial),
+		min:   1,
+		max:   float64(max),
+	}
+}
+
+// acquire blocks until a slot is free or ctx is done.
+func (l *limiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+
+	if l.inflight < int(l.limit) {
+		l.inflight++
+		l.mu.Unlock()
+		return nil
+	}
+
+	ch := make(chan struct{})
+	l.waiters = append(l.waiters, ch)
+	l.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for i, w := range l.waiters {
+			if w == ch {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+
+		// the slot was handed over while giving up, pass it on
+		l.inflight--
+		l.wake()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot. ok is false when the request failed or was slow;
+// neutral releases, e.g. of cancelled hedges, leave the limit alone.
+func (l *limiter) release(ok, neutral bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+
+	switch {
+	case neutral:
+	case ok:
+		l.limit = min(l.max, l.limit+1/l.limit)
+	default:
+		l.limit = max(l.min, l.limit/2)
+	}
+
+	l.wake()
+}
+
+func (l *limiter) wake() {
+	for l.inflight < int(l.limit) && len(l.waiters) > 0 {
+		ch := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		l.inflight++
+		close(ch)
+	}
+}
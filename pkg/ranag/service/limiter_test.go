@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("blocks above the limit until a slot is released", func(t *testing.T) {
+		l := newLimiter(2, 10)
+
+		l.acquire(context.Background())
+		l.acquire(context.Background())
+
+		acquired := make(chan struct{})
+		go func() {
+			l.acquire(context.Background())
+			close(acquired)
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("expected third acquire to block")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		l.release(true, false)
+
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Fatal("expected third acquire to get the released slot")
+		}
+	})
+
+	t.Run("grows on success and halves on failure", func(t *testing.T) {
+		l := newLimiter(4, 10)
+
+		for i := 0; i < 4; i++ {
+			l.acquire(context.Background())
+			l.release(true, false)
+		}
+
+		// roughly one more slot per window of successes
+		if l.limit < 4.9 || l.limit > 5 {
+			t.Errorf("expected limit close to 5 after a window of successes, got %f", l.limit)
+		}
+
+		grown := l.limit
+
+		l.acquire(context.Background())
+		l.release(false, false)
+
+		if l.limit != grown/2 {
+			t.Errorf("expected limit %f after a failure, got %f", grown/2, l.limit)
+		}
+	})
+
+	t.Run("never drops below one", func(t *testing.T) {
+		l := newLimiter(1, 10)
+
+		for i := 0; i < 5; i++ {
+			l.acquire(context.Background())
+			l.release(false, false)
+		}
+
+		if l.limit != 1 {
+			t.Errorf("expected limit 1, got %f", l.limit)
+		}
+	})
+
+	t.Run("neutral releases keep the limit", func(t *testing.T) {
+		l := newLimiter(4, 10)
+
+		l.acquire(context.Background())
+		l.release(false, true)
+
+		if l.limit != 4 || l.inflight != 0 {
+			t.Errorf("expected limit 4 and nothing in flight, got %f and %d", l.limit, l.inflight)
+		}
+	})
+
+	t.Run("gives up when the context is cancelled", func(t *testing.T) {
+		l := newLimiter(1, 10)
+		l.acquire(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := l.acquire(ctx); err != context.DeadlineExceeded {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+
+		if len(l.waiters) != 0 || l.inflight != 1 {
+			t.Errorf("expected the waiter to be removed, got %d waiters and %d in flight", len(l.waiters), l.inflight)
+		}
+	})
+}
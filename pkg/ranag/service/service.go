@@ -1,14 +1,23 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"juno/pkg/aggregation"
 	apiClient "juno/pkg/api/client"
 	nodeClient "juno/pkg/node/client"
+	ranagClient "juno/pkg/ranag/client"
 
-	"juno/pkg/balancer/crawl"
 	extractionDto "juno/pkg/node/extraction/dto"
+	"juno/pkg/nodepool"
+	"juno/pkg/ranag"
 	"juno/pkg/ranag/dto"
+	"juno/pkg/receipt"
+	"juno/pkg/replica"
+	"juno/pkg/scope"
 	"juno/pkg/shard"
 	"math/rand"
+	"slices"
 	"sync"
 	"time"
 
@@ -27,6 +36,15 @@ func WithApiClient(apiClient *apiClient.Client) func(s *Service) {
 	}
 }
 
+// WithAddress sets the address the ranag is registered with in the API. It
+// must come before WithShardFetchInterval for the ranag to fetch and delegate
+// to its children.
+func WithAddress(address string) func(s *Service) {
+	return func(s *Service) {
+		s.address = address
+	}
+}
+
 func WithShardFetchInterval(interval time.Duration) func(s *Service) {
 	return func(s *Service) {
 
@@ -34,25 +52,135 @@ func WithShardFetchInterval(interval time.Duration) func(s *Service) {
 			panic("api client is required")
 		}
 
+		fetchChildren := s.address != ""
+
 		go func() {
-			s.fetchShards()
 			for {
-				time.Sleep(interval)
 				s.fetchShards()
+				if fetchChildren {
+					s.fetchChildren()
+				}
+				time.Sleep(interval)
 			}
 		}()
 	}
 }
 
+// WithNodePool sets the pool that picks the replica each shard is queried on.
+func WithNodePool(pool *nodepool.Pool) func(s *Service) {
+	return func(s *Service) {
+		s.pool = pool
+	}
+}
+
+// WithHedgePercentile sets the latency percentile (0-1) after which a shard
+// request is hedged to another replica. 0 disables hedging.
+func WithHedgePercentile(percentile float64) func(s *Service) {
+	return func(s *Service) {
+		s.hedgePercentile = percentile
+	}
+}
+
+// WithNodeConcurrency sets the initial and max number of requests in flight
+// to a single node.
+func WithNodeConcurrency(initial, max int) func(s *Service) {
+	return func(s *Service) {
+		s.initialConcurrency = initial
+		s.maxConcurrency = max
+	}
+}
+
+// WithShardConcurrency sets how many shards of a range are queried at once.
+func WithShardConcurrency(concurrency int) func(s *Service) {
+	return func(s *Service) {
+		s.shardConcurrency = concurrency
+	}
+}
+
+// WithSigner sets the key the ranag's receipts are signed with, which are
+// left out without it.
+func WithSigner(signer *receipt.Signer) func(s *Service) {
+	return func(s *Service) {
+		s.signer = signer
+	}
+}
+
+// WithVerification sets the fraction (0-1) of shards whose answer is compared
+// with other replicas. What the replicas answered is passed on with the
+// shard's status. 0 disables it.
+func WithVerification(rate float64) func(s *Service) {
+	return func(s *Service) {
+		s.verificationRate = rate
+	}
+}
+
 type Service struct {
 	logger     *logrus.Logger
 	apiClient  *apiClient.Client
 	shards     [shard.SHARDS][]string
 	shardsLock sync.Mutex
+	pool       *nodepool.Pool
+
+	// children are the shard assignments of the child ranags by address
+	address      string
+	children     map[string][][2]int
+	childrenLock sync.Mutex
+
+	hedgePercentile float64
+	latencies       *latencies
+
+	initialConcurrency int
+	maxConcurrency     int
+	limitersLock       sync.Mutex
+	limiters           map[string]*limiter
+	shardConcurrency   int
+
+	signer *receipt.Signer
+
+	verificationRate float64
+}
+
+// query is what every shard of a range is asked. With aggregations the nodes
+// answer with partials instead of rows.
+type query struct {
+	requestID    string
+	selectors    []*extractionDto.Selector
+	fields       []*extractionDto.Field
+	aggregations []*aggregation.Aggregation
+	scope        *scope.Scope
+	// versions asks for the digests replicas are compared on
+	versions bool
+}
+
+// withVersions is the query asking for the digests of the versions.
+func (q *query) withVersions() *query {
+	v := *q
+	v.versions = true
+
+	return &v
+}
+
+type attempt struct {
+	node        string
+	hedge       bool
+	extractions []map[string]interface{}
+	partials    aggregation.Partials
+	versions    map[string]string
+	receipt     *receipt.Receipt
+	err         error
 }
 
 func New(options ...func(s *Service)) *Service {
-	s := &Service{}
+	// the pool is set before the options, which may start fetching shards
+	s := &Service{
+		hedgePercentile:    ranag.DefaultHedgePercentile,
+		latencies:          newLatencies(ranag.LatencySamples),
+		initialConcurrency: ranag.DefaultNodeConcurrency,
+		maxConcurrency:     ranag.MaxNodeConcurrency,
+		limiters:           make(map[string]*limiter),
+		shardConcurrency:   ranag.DefaultShardConcurrency,
+		pool:               nodepool.New(),
+	}
 
 	for _, option := range options {
 		option(s)
@@ -72,6 +200,8 @@ func (s *Service) fetchShards() {
 		return
 	}
 
+	s.pool.SetReputations(res.Reputations)
+
 	s.shardsLock.Lock()
 	defer s.shardsLock.Unlock()
 
@@ -97,94 +227,567 @@ func (s *Service) SetShards(shards [shard.SHARDS][]string) {
 	s.shards = shards
 }
 
-func (s *Service) randomNode(shard int) (string, error) {
+func (s *Service) fetchChildren() {
+	res, err := s.apiClient.GetRanagChildren(s.address)
+	if err != nil {
+		s.logger.Printf("failed to fetch children: %v", err)
+		return
+	}
+
+	children := make(map[string][][2]int, len(res.Ranags))
+
+	for _, child := range res.Ranags {
+		children[child.Address] = child.ShardAssignments
+	}
+
+	s.SetChildren(children)
+
+	s.logger.Infof("children fetched: %d", len(children))
+}
+
+func (s *Service) SetChildren(children map[string][][2]int) {
+	s.childrenLock.Lock()
+	defer s.childrenLock.Unlock()
+	s.children = children
+}
+
+// plan assigns every shard a child ranag covers to the first such child by
+// address and groups each child's shards into runs of consecutive shards.
+// Children the request already passed through are skipped, and nothing is
+// delegated once the request would pass through too many ranags.
+func (s *Service) plan(shards []int, path []string) (direct []int, delegated map[string][][2]int) {
+	delegated = map[string][][2]int{}
+
+	s.childrenLock.Lock()
+	var addresses []string
+	for address := range s.children {
+		if !slices.Contains(path, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	slices.Sort(addresses)
+
+	children := make(map[string][][2]int, len(addresses))
+	for _, address := range addresses {
+		children[address] = s.children[address]
+	}
+	s.childrenLock.Unlock()
+
+	if len(path)+1 >= ranag.MaxDepth {
+		addresses = nil
+	}
+
+	for _, shard := range shards {
+		child := ""
+
+		for _, address := range addresses {
+			if covers(children[address], shard) {
+				child = address
+				break
+			}
+		}
+
+		if child == "" {
+			direct = append(direct, shard)
+			continue
+		}
+
+		runs := delegated[child]
+		if last := len(runs) - 1; last >= 0 && runs[last][0]+runs[last][1] == shard {
+			runs[last][1]++
+		} else {
+			runs = append(runs, [2]int{shard, 1})
+		}
+		delegated[child] = runs
+	}
+
+	return direct, delegated
+}
+
+func covers(assignments [][2]int, shard int) bool {
+	for _, a := range assignments {
+		if shard >= a[0] && shard < a[0]+a[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) nodes(shard int) []string {
 	s.shardsLock.Lock()
 	defer s.shardsLock.Unlock()
-	if len(s.shards[shard]) == 0 {
-		return "", crawl.ErrNoNodesAvailableInShard
-	}
 
-	return s.shards[shard][rand.Intn(len(s.shards[shard]))], nil
+	return s.shards[shard]
 }
 
-func (s *Service) RangeAggregate(offset int, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, error) {
+func (s *Service) RangeAggregate(ctx context.Context, offset int, total int, req dto.RangeAggregatorRequest) ([]map[string]interface{}, []*dto.ShardStatus, error) {
 	data := make([]map[string]interface{}, 0)
+
+	statuses, err := s.queryRange(ctx, offset, total, req, func(a *attempt) {
+		data = append(data, a.extractions...)
+	})
+
+	if err != nil {
+		return nil, statuses, err
+	}
+
+	return data, statuses, nil
+}
+
+func (s *Service) RangeReduce(ctx context.Context, offset int, total int, req dto.RangeAggregatorRequest) (aggregation.Partials, []*dto.ShardStatus, error) {
+	partials := aggregation.NewPartials(req.Aggregations)
+
+	statuses, err := s.queryRange(ctx, offset, total, req, func(a *attempt) {
+		partials.Merge(req.Aggregations, a.partials)
+	})
+
+	if err != nil {
+		return nil, statuses, err
+	}
+
+	return partials, statuses, nil
+}
+
+// queryRange queries every shard of the range and hands the answers to
+// collect, one at a time.
+func (s *Service) queryRange(ctx context.Context, offset int, total int, req dto.RangeAggregatorRequest, collect func(a *attempt)) ([]*dto.ShardStatus, error) {
+	if s.address != "" && slices.Contains(req.Path, s.address) {
+		return nil, ranag.ErrCycle
+	}
+
+	shards, err := shard.GetShardRange(offset, total)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{
+		requestID:    req.RequestID,
+		selectors:    make([]*extractionDto.Selector, len(req.Selectors)),
+		fields:       make([]*extractionDto.Field, len(req.Fields)),
+		aggregations: req.Aggregations,
+		scope:        req.Scope,
+	}
+
+	for i, s := range req.Selectors {
+		q.selectors[i] = &extractionDto.Selector{
+			ID:    s.ID,
+			Value: s.Value,
+		}
+	}
+
+	for i, f := range req.Fields {
+		q.fields[i] = &extractionDto.Field{
+			SelectorID: f.SelectorID,
+			Name:       f.Name,
+		}
+	}
+
+	statuses := make([]*dto.ShardStatus, len(shards))
+	positions := make(map[int]int, len(shards))
+	for i, shard := range shards {
+		positions[shard] = i
+	}
+
 	var (
-		mu       sync.Mutex
-		wg       sync.WaitGroup
-		errChan  = make(chan error, 1) // To capture errors from goroutines
-		dataChan = make(chan []map[string]interface{}, total)
-		sem      = make(chan struct{}, 10) // Buffered channel to limit to 10 concurrent workers
+		mu sync.Mutex
+		wg sync.WaitGroup
 	)
 
-	// Launch workers for each shard
-	for shard := offset; shard < offset+total; shard++ {
+	// bounds the goroutines of a range, which spans up to every shard
+	slots := make(chan struct{}, max(s.shardConcurrency, 1))
+
+	queryShard := func(shard int) {
+		slots <- struct{}{}
 		wg.Add(1)
-		go func(shard int) {
+
+		go func() {
+			defer func() { <-slots }()
 			defer wg.Done()
-			sem <- struct{}{} // Block if there are already 10 workers
 
-			node, err := s.randomNode(shard)
-			if err != nil {
-				select {
-				case errChan <- err: // Send error if no error has been sent
-				default:
-				}
-				<-sem // Release a spot in the semaphore
+			// the replicas of sampled shards are all asked for the
+			// digests they are compared on
+			sq := q
+			verify := s.verificationRate > 0 && rand.Float64() < s.verificationRate
+			if verify {
+				sq = q.withVersions()
+			}
+
+			a, status := s.aggregateShard(ctx, shard, sq)
+
+			mu.Lock()
+			statuses[positions[shard]] = status
+			if a != nil {
+				collect(a)
+			}
+			mu.Unlock()
+
+			if !verify || a == nil {
 				return
 			}
 
-			selectors := make([]*extractionDto.Selector, len(req.Selectors))
-			for i, s := range req.Selectors {
-				selectors[i] = &extractionDto.Selector{
-					ID:    s.ID,
-					Value: s.Value,
+			// the shard's answer is collected first, and its verification
+			// takes a slot of its own
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				slots <- struct{}{}
+				defer func() { <-slots }()
+
+				v := s.verify(ctx, shard, sq, a)
+
+				mu.Lock()
+				status.Verification = v
+				mu.Unlock()
+			}()
+		}()
+	}
+
+	direct, delegated := s.plan(shards, req.Path)
+
+	// the children are started first, they only wait on their ranag
+	for child, runs := range delegated {
+		for _, run := range runs {
+			wg.Add(1)
+			go func(child string, run [2]int) {
+				defer wg.Done()
+
+				a, childStatuses, rc := s.delegate(ctx, child, run, req)
+				answered := map[int]bool{}
+
+				mu.Lock()
+				for _, status := range childStatuses {
+					i, ok := positions[status.Shard]
+					if !ok || !status.Answered() {
+						continue
+					}
+
+					if status.Via == "" {
+						status.Via = child
+					}
+
+					// the child's receipt is passed on with its first shard
+					if rc != nil {
+						status.Delegations = append(status.Delegations, &dto.Delegation{Ranag: child, Receipt: rc})
+						rc = nil
+					}
+
+					statuses[i] = status
+					answered[status.Shard] = true
 				}
-			}
 
-			fields := make([]*extractionDto.Field, len(req.Fields))
-			for i, f := range req.Fields {
-				fields[i] = &extractionDto.Field{
-					SelectorID: f.SelectorID,
-					Name:       f.Name,
+				if a != nil {
+					collect(a)
 				}
-			}
+				mu.Unlock()
 
-			// Send request to the node
-			extractions, err := nodeClient.SendExtractionRequest(node, shard, selectors, fields)
-			if err != nil {
-				s.logger.Errorf("failed to send request to node: %v", err)
-				select {
-				case errChan <- err:
-				default:
+				// the shards the child could not answer are queried directly
+				for shard := run[0]; shard < run[0]+run[1]; shard++ {
+					if !answered[shard] {
+						queryShard(shard)
+					}
 				}
-				<-sem
-				return
-			}
+			}(child, run)
+		}
+	}
+
+	// the node limiters decide how many of the started shards are sent
+	for _, shard := range direct {
+		queryShard(shard)
+	}
+
+	wg.Wait()
+
+	if coverage := dto.Coverage(statuses); coverage < req.MinCoverage {
+		return statuses, fmt.Errorf("%w: %.1f%% of shards answered, %.1f%% required", ranag.ErrInsufficientCoverage, coverage, req.MinCoverage)
+	}
+
+	return statuses, nil
+}
+
+// delegate forwards a run of shards to a child ranag. The child answers
+// with whatever part of the run it could, the coverage is checked over the
+// whole range here. The child's receipt is returned with its answer.
+func (s *Service) delegate(ctx context.Context, child string, run [2]int, req dto.RangeAggregatorRequest) (*attempt, []*dto.ShardStatus, *receipt.Receipt) {
+	forwarded := req
+	forwarded.Offset = run[0]
+	forwarded.Total = run[1]
+	forwarded.MinCoverage = 0
+	forwarded.Path = append(slices.Clone(req.Path), s.address)
+
+	res, err := ranagClient.New(child).Forward(ctx, &forwarded)
+
+	if err != nil {
+		s.logger.Errorf("failed to delegate shards %d-%d to %s: %v", run[0], run[0]+run[1]-1, child, err)
+		return nil, nil, nil
+	}
 
-			dataChan <- extractions // Send extractions to data channel
-			<-sem                   // Release a spot in the semaphore
-		}(shard)
+	return &attempt{
+		node:        child,
+		extractions: res.Aggregations,
+		partials:    res.Partials,
+	}, res.Shards, res.Receipt
+}
+
+// aggregateShard queries the healthiest replica of a shard. When it is
+// slower than the hedge delay the request is duplicated to a second replica
+// and the first answer wins; failed requests fail over to the remaining
+// replicas before the shard is given up.
+func (s *Service) aggregateShard(ctx context.Context, shard int, q *query) (*attempt, *dto.ShardStatus) {
+	status := &dto.ShardStatus{Shard: shard}
+
+	nodes := s.nodes(shard)
+
+	if len(nodes) == 0 {
+		status.Status = dto.ShardNoNodes
+		return nil, status
+	}
+
+	// cancels the request that lost the race
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *attempt, len(nodes))
+	acquired := make(chan struct{}, 1)
+	tried := []string{}
+
+	send := func(hedge bool) error {
+		node, err := s.pool.Pick(nodes, tried...)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(tried, node) {
+			return nodepool.ErrNoNodes
+		}
+
+		tried = append(tried, node)
+		status.Attempts++
+
+		// only the first request is hedged, so only it reports when it got
+		// past the node's limiter
+		var sent chan<- struct{}
+		if len(tried) == 1 {
+			sent = acquired
+		}
+
+		go s.send(ctx, node, hedge, shard, q, results, sent)
+		return nil
 	}
 
-	// Close the data channel once all goroutines complete
-	go func() {
-		wg.Wait()
-		close(dataChan)
-		close(errChan)
+	lastErr := send(false)
+	inflight := 1
+
+	if lastErr != nil {
+		inflight = 0
+	}
+
+	// the hedge delay counts from when the request reached the node, time
+	// spent waiting for the node's limiter is not latency of the replica
+	var hedge <-chan time.Time
+	if s.hedgePercentile <= 0 || len(nodes) < 2 {
+		acquired = nil
+	}
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
 	}()
 
-	// Collect results from dataChan
-	for extraction := range dataChan {
-		mu.Lock()
-		data = append(data, extraction...)
-		mu.Unlock()
+	for inflight > 0 {
+		select {
+		case <-acquired:
+			acquired = nil
+			timer = time.NewTimer(s.hedgeDelay())
+			hedge = timer.C
+		case <-hedge:
+			hedge = nil
+			if len(tried) < len(nodes) && send(true) == nil {
+				status.Hedged = true
+				inflight++
+			}
+		case a := <-results:
+			inflight--
+
+			if a.err == nil {
+				status.Node = a.node
+				status.HedgeWon = a.hedge
+				status.Receipt = a.receipt
+				status.Status = dto.ShardOK
+				if a.node != tried[0] {
+					status.Status = dto.ShardRetried
+				}
+				return a, status
+			}
+
+			lastErr = a.err
+			status.Node = a.node
+
+			// fail over unless a hedge is still running or the query was
+			// cancelled
+			if inflight == 0 && ctx.Err() == nil && len(tried) < len(nodes) && send(false) == nil {
+				inflight++
+			}
+		}
 	}
 
-	// Check if any error occurred
-	if err, ok := <-errChan; ok {
+	s.logger.Errorf("failed to query shard %d: %v", shard, lastErr)
+	status.Status = dto.ShardFailed
+	status.Error = lastErr.Error()
+	return nil, status
+}
+
+// verify asks other replicas of the shard, all at once, for the answer a
+// gave, with the digests of what each version of their pages yielded. Their
+// receipts are passed on so their work is paid, and their digests only when
+// the replicas disagree on a version they share.
+func (s *Service) verify(ctx context.Context, shard int, q *query, a *attempt) *replica.Verification {
+	// nodes that do not send digests cannot be compared
+	if a.versions == nil {
+		return nil
+	}
+
+	nodes := s.nodes(shard)
+	tried := []string{a.node}
+	results := make(chan *attempt, ranag.VerificationReplicas)
+
+	for len(tried) <= ranag.VerificationReplicas {
+		node, err := s.pool.Pick(nodes, tried...)
+
+		if err != nil || slices.Contains(tried, node) {
+			break
+		}
+
+		tried = append(tried, node)
+		go s.send(ctx, node, false, shard, q, results, nil)
+	}
+
+	v := &replica.Verification{Answers: []*replica.Answer{{Node: a.node, Receipt: a.receipt, Versions: a.versions}}}
+
+	for range tried[1:] {
+		if b := <-results; b.err == nil {
+			v.Answers = append(v.Answers, &replica.Answer{Node: b.node, Receipt: b.receipt, Versions: b.versions})
+		}
+	}
+
+	if len(v.Answers) < 2 {
+		return nil
+	}
+
+	if conflicts := v.Conflicts(); len(conflicts) > 0 {
+		s.logger.Warnf("replicas of shard %d disagree on %d versions of request %s", shard, len(conflicts), q.requestID)
+		return v
+	}
+
+	for _, answer := range v.Answers {
+		answer.Versions = nil
+	}
+
+	return v
+}
+
+// send runs one shard request within the node's concurrency limit, and
+// signals acquired, when set, once the limit let it through. Requests
+// cancelled because another replica answered first are not held against the
+// node.
+func (s *Service) send(ctx context.Context, node string, hedge bool, shard int, q *query, results chan<- *attempt, acquired chan<- struct{}) {
+	a := &attempt{node: node, hedge: hedge}
+	l := s.limiter(node)
+
+	if a.err = l.acquire(ctx); a.err != nil {
+		results <- a
+		return
+	}
+
+	if acquired != nil {
+		acquired <- struct{}{}
+	}
+
+	start := time.Now()
+	res, err := nodeClient.Extract(ctx, node, &extractionDto.ExtractionRequest{
+		RequestID:    q.requestID,
+		Shard:        shard,
+		Selectors:    q.selectors,
+		Fields:       q.fields,
+		Aggregations: q.aggregations,
+		Scope:        q.scope,
+		Versions:     q.versions,
+	})
+	latency := time.Since(start)
+
+	if a.err = err; err == nil {
+		a.extractions = res.Extractions
+		a.partials = res.Partials
+		a.versions = res.Versions
+		a.receipt = res.Receipt
+	}
+
+	cancelled := ctx.Err() != nil
+
+	if !cancelled {
+		s.pool.Report(node, latency, err)
+
+		if err == nil {
+			s.latencies.add(latency)
+		}
+	}
+
+	l.release(err == nil && latency <= 2*s.hedgeDelay(), cancelled)
+
+	results <- a
+}
+
+func (s *Service) Receipt(requestID string, shards []*dto.ShardStatus) (*receipt.Receipt, error) {
+	if s.signer == nil {
+		return nil, nil
+	}
+
+	r := &receipt.Receipt{RequestID: requestID, Shards: []int{}}
+
+	for _, status := range shards {
+		if !status.Answered() {
+			continue
+		}
+
+		r.Shards = append(r.Shards, status.Shard)
+
+		if status.Receipt != nil {
+			r.Pages += status.Receipt.Pages
+			r.Rows += status.Receipt.Rows
+		}
+	}
+
+	if err := s.signer.Sign(r); err != nil {
 		return nil, err
 	}
 
-	return data, nil
+	return r, nil
+}
+
+// hedgeDelay is the configured percentile of recent shard latencies.
+func (s *Service) hedgeDelay() time.Duration {
+	d, ok := s.latencies.percentile(s.hedgePercentile, ranag.HedgeMinSamples)
+
+	if !ok {
+		return ranag.DefaultHedgeDelay
+	}
+
+	return max(d, ranag.MinHedgeDelay)
+}
+
+func (s *Service) limiter(node string) *limiter {
+	s.limitersLock.Lock()
+	defer s.limitersLock.Unlock()
+
+	l, ok := s.limiters[node]
+
+	if !ok {
+		l = newLimiter(s.initialConcurrency, s.maxConcurrency)
+		s.limiters[node] = l
+	}
+
+	return l
 }
@@ -1,8 +1,16 @@
 package service
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"juno/pkg/aggregation"
 	"juno/pkg/api/client"
 	"juno/pkg/api/node/dto"
+	"juno/pkg/nodepool"
+	"juno/pkg/ranag"
+	"juno/pkg/receipt"
 	"juno/pkg/shard"
 
 	ranagDto "juno/pkg/ranag/dto"
@@ -168,7 +176,7 @@ func TestRangeAggregate(t *testing.T) {
 			2: {"node3.com:9090"},
 		})
 
-		_, err := svc.RangeAggregate(0, 3, ranagDto.RangeAggregatorRequest{
+		_, _, err := svc.RangeAggregate(context.Background(), 0, 3, ranagDto.RangeAggregatorRequest{
 			Selectors: []*selectorDto.Selector{
 				{
 					ID:    "1",
@@ -193,3 +201,777 @@ func TestRangeAggregate(t *testing.T) {
 		}
 	})
 }
+
+func TestRangeAggregateFailover(t *testing.T) {
+	t.Run("should query another replica when a node fails", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/extract").
+			Persist().
+			Reply(500)
+
+		gock.New("http://node2.com:9090").
+			Post("/extract").
+			Persist().
+			Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"https://google.com": "Google"},
+				},
+			))
+
+		svc := New(WithLogger(logrus.New()))
+
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090", "node2.com:9090"},
+		})
+
+		data, shards, err := svc.RangeAggregate(context.Background(), 0, 1, ranagDto.RangeAggregatorRequest{
+			Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+			Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 1 {
+			t.Errorf("expected 1 extraction but got %d", len(data))
+		}
+
+		if len(shards) != 1 || shards[0].Node != "node2.com:9090" {
+			t.Fatalf("expected shard to be answered by node2.com:9090 but got %+v", shards)
+		}
+
+		// node2 may have been picked first
+		if shards[0].Attempts == 2 && shards[0].Status != ranagDto.ShardRetried {
+			t.Errorf("expected shard to be retried but got %s", shards[0].Status)
+		}
+	})
+}
+
+func TestRangeAggregatePartialResults(t *testing.T) {
+	req := ranagDto.RangeAggregatorRequest{
+		Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+		Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+	}
+
+	newService := func() *Service {
+		svc := New(WithLogger(logrus.New()))
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090"},
+			1: {"node2.com:9090"},
+			// shard 2 has no nodes
+		})
+		return svc
+	}
+
+	mockNodes := func() {
+		gock.New("http://node1.com:9090").
+			Post("/extract").
+			Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"https://google.com": "Google"},
+				},
+			))
+
+		gock.New("http://node2.com:9090").
+			Post("/extract").
+			Persist().
+			Reply(500)
+	}
+
+	t.Run("should return the data of the shards that answered", func(t *testing.T) {
+		defer gock.Off()
+		mockNodes()
+
+		data, shards, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 1 {
+			t.Errorf("expected 1 extraction but got %d", len(data))
+		}
+
+		expected := []string{ranagDto.ShardOK, ranagDto.ShardFailed, ranagDto.ShardNoNodes}
+		for i, status := range expected {
+			if shards[i].Shard != i || shards[i].Status != status {
+				t.Errorf("expected shard %d to be %s but got %+v", i, status, shards[i])
+			}
+		}
+
+		if shards[1].Error == "" {
+			t.Errorf("expected failed shard to carry the error")
+		}
+	})
+
+	t.Run("should fail below the minimum coverage", func(t *testing.T) {
+		defer gock.Off()
+		mockNodes()
+
+		req := req
+		req.MinCoverage = 50
+
+		data, shards, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if !errors.Is(err, ranag.ErrInsufficientCoverage) {
+			t.Fatalf("expected ErrInsufficientCoverage but got %v", err)
+		}
+
+		if data != nil {
+			t.Errorf("expected no data but got %v", data)
+		}
+
+		if len(shards) != 3 {
+			t.Errorf("expected 3 shard statuses but got %d", len(shards))
+		}
+	})
+}
+
+func TestRangeReduce(t *testing.T) {
+	t.Run("should merge the partials of every shard", func(t *testing.T) {
+		defer gock.Off()
+
+		for _, node := range []string{"http://node1.com:9090", "http://node2.com:9090"} {
+			gock.New(node).
+				Post("/extract").
+				Reply(200).
+				JSON(extractionDto.NewSuccessAggregationResponse(aggregation.Partials{
+					"products": {Count: 2},
+					"titles":   {Counts: map[string]int64{"charger": 1, node: 1}},
+				}))
+		}
+
+		svc := New(WithLogger(logrus.New()))
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090"},
+			1: {"node2.com:9090"},
+		})
+
+		aggs := []*aggregation.Aggregation{
+			{Name: "products", Op: aggregation.OpCount},
+			{Name: "titles", Op: aggregation.OpTopK, Field: "product_title", K: 1},
+		}
+
+		partials, shards, err := svc.RangeReduce(context.Background(), 0, 2, ranagDto.RangeAggregatorRequest{
+			Selectors:    []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+			Fields:       []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+			Aggregations: aggs,
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if ranagDto.Coverage(shards) != 100 {
+			t.Errorf("expected full coverage but got %+v", shards)
+		}
+
+		results := aggregation.Finalize(aggs, partials)
+
+		if results["products"] != int64(4) {
+			t.Errorf("expected 4 products but got %v", results["products"])
+		}
+
+		top := results["titles"].([]*aggregation.ValueCount)
+		if top[0].Value != "charger" || top[0].Count != 2 {
+			t.Errorf("expected charger twice but got %+v", top[0])
+		}
+	})
+}
+
+func TestRangeAggregateHedging(t *testing.T) {
+	t.Run("should hedge slow requests to another replica", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/extract").
+			Reply(200).
+			Delay(300 * time.Millisecond).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"https://google.com": "Google"},
+				},
+			))
+
+		gock.New("http://node2.com:9090").
+			Post("/extract").
+			Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"https://google.com": "Google"},
+				},
+			))
+
+		// node1 looks much faster, so it is almost always asked first
+		pool := nodepool.New()
+		pool.Report("node1.com:9090", time.Millisecond, nil)
+		pool.Report("node2.com:9090", time.Second, nil)
+
+		svc := New(WithLogger(logrus.New()), WithNodePool(pool))
+
+		// hedge after 10ms
+		for i := 0; i < ranag.HedgeMinSamples; i++ {
+			svc.latencies.add(10 * time.Millisecond)
+		}
+
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090", "node2.com:9090"},
+		})
+
+		start := time.Now()
+
+		data, shards, err := svc.RangeAggregate(context.Background(), 0, 1, ranagDto.RangeAggregatorRequest{
+			Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+			Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 1 {
+			t.Errorf("expected 1 extraction but got %d", len(data))
+		}
+
+		if time.Since(start) >= 300*time.Millisecond {
+			t.Errorf("expected the hedge to answer before the slow node")
+		}
+
+		if shards[0].Attempts == 2 && (!shards[0].Hedged || !shards[0].HedgeWon || shards[0].Node != "node2.com:9090") {
+			t.Errorf("expected the hedge to node2.com:9090 to win but got %+v", shards[0])
+		}
+	})
+}
+
+func TestRangeAggregateHedgeDelay(t *testing.T) {
+	t.Run("should not count the wait for the node's limiter", func(t *testing.T) {
+
+		defer gock.Off()
+
+		gock.New("http://node1.com:9090").
+			Post("/extract").
+			Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"https://google.com": "Google"},
+				},
+			))
+
+		pool := nodepool.New()
+		pool.Report("node1.com:9090", time.Millisecond, nil)
+		pool.Report("node2.com:9090", time.Second, nil)
+
+		svc := New(WithLogger(logrus.New()), WithNodePool(pool), WithNodeConcurrency(1, 1))
+
+		// hedge after 10ms
+		for i := 0; i < ranag.HedgeMinSamples; i++ {
+			svc.latencies.add(10 * time.Millisecond)
+		}
+
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090", "node2.com:9090"},
+		})
+
+		// node1 is busy with another request for 100ms
+		l := svc.limiter("node1.com:9090")
+		l.acquire(context.Background())
+		time.AfterFunc(100*time.Millisecond, func() { l.release(true, true) })
+
+		_, shards, err := svc.RangeAggregate(context.Background(), 0, 1, ranagDto.RangeAggregatorRequest{
+			Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+			Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if shards[0].Hedged || shards[0].Attempts != 1 || shards[0].Node != "node1.com:9090" {
+			t.Errorf("expected node1.com:9090 to answer without a hedge but got %+v", shards[0])
+		}
+	})
+}
+
+func TestRangeAggregateShardConcurrency(t *testing.T) {
+	t.Run("should query at most the configured number of shards at once", func(t *testing.T) {
+
+		defer gock.Off()
+
+		for _, node := range []string{"http://node1.com:9090", "http://node2.com:9090", "http://node3.com:9090"} {
+			gock.New(node).
+				Post("/extract").
+				Reply(200).
+				Delay(50 * time.Millisecond).
+				JSON(extractionDto.NewSuccessExtractionResponse(
+					[]map[string]interface{}{
+						{"https://google.com": "Google"},
+					},
+				))
+		}
+
+		svc := New(WithLogger(logrus.New()), WithShardConcurrency(1))
+
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090"},
+			1: {"node2.com:9090"},
+			2: {"node3.com:9090"},
+		})
+
+		start := time.Now()
+
+		data, _, err := svc.RangeAggregate(context.Background(), 0, 3, ranagDto.RangeAggregatorRequest{
+			Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+			Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 3 {
+			t.Errorf("expected 3 extractions but got %d", len(data))
+		}
+
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("expected the shards to be queried one at a time but took %v", elapsed)
+		}
+	})
+}
+
+func TestRangeAggregateDelegation(t *testing.T) {
+	req := ranagDto.RangeAggregatorRequest{
+		Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+		Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+	}
+
+	newService := func() *Service {
+		svc := New(WithLogger(logrus.New()), WithAddress("parent.com:6060"))
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090"},
+			1: {"node2.com:9090"},
+			2: {"node3.com:9090"},
+		})
+		svc.SetChildren(map[string][][2]int{
+			"child.com:6060": {{1, 2}},
+		})
+		return svc
+	}
+
+	mockNode := func() {
+		gock.New("http://node1.com:9090").
+			Post("/extract").
+			Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(
+				[]map[string]interface{}{
+					{"https://google.com": "Google"},
+				},
+			))
+	}
+
+	t.Run("should merge the results of the children", func(t *testing.T) {
+		defer gock.Off()
+		mockNode()
+
+		gock.New("http://child.com:6060").
+			Post("/aggregate").
+			MatchType("json").
+			BodyString(`"offset":1,"total":2,.*"path":\["parent.com:6060"\]`).
+			Reply(200).
+			JSON(ranagDto.NewSuccessRangeAggregatorResponse(
+				[]map[string]interface{}{
+					{"https://google.com/about": "Google About"},
+					{"https://amazon.com/about": "Amazon About"},
+				},
+				[]*ranagDto.ShardStatus{
+					{Shard: 1, Status: ranagDto.ShardOK, Node: "node2.com:9090", Attempts: 1},
+					{Shard: 2, Status: ranagDto.ShardOK, Node: "node3.com:9090", Attempts: 1},
+				},
+			))
+
+		data, shards, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 3 {
+			t.Errorf("expected 3 extractions but got %d", len(data))
+		}
+
+		for i, via := range []string{"", "child.com:6060", "child.com:6060"} {
+			if shards[i].Shard != i || shards[i].Via != via || !shards[i].Answered() {
+				t.Errorf("expected shard %d to be answered via %q but got %+v", i, via, shards[i])
+			}
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("should pass on the receipts of the children", func(t *testing.T) {
+		defer gock.Off()
+		mockNode()
+
+		res := ranagDto.NewSuccessRangeAggregatorResponse(
+			nil,
+			[]*ranagDto.ShardStatus{
+				{Shard: 1, Status: ranagDto.ShardOK, Node: "node2.com:9090", Attempts: 1},
+				{Shard: 2, Status: ranagDto.ShardOK, Node: "node3.com:9090", Attempts: 1},
+			},
+		)
+		res.Receipt = &receipt.Receipt{RequestID: "req-1", Shards: []int{1, 2}}
+
+		gock.New("http://child.com:6060").
+			Post("/aggregate").
+			Reply(200).
+			JSON(res)
+
+		_, shards, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(shards[1].Delegations) != 1 || shards[1].Delegations[0].Ranag != "child.com:6060" || shards[1].Delegations[0].Receipt.RequestID != "req-1" {
+			t.Errorf("expected the child's receipt with its first shard but got %+v", shards[1].Delegations)
+		}
+
+		if len(shards[0].Delegations) != 0 || len(shards[2].Delegations) != 0 {
+			t.Errorf("expected the child's receipt once but got %+v and %+v", shards[0].Delegations, shards[2].Delegations)
+		}
+	})
+
+	t.Run("should query the nodes when a child fails", func(t *testing.T) {
+		defer gock.Off()
+		mockNode()
+
+		gock.New("http://child.com:6060").
+			Post("/aggregate").
+			Reply(500)
+
+		for _, node := range []string{"http://node2.com:9090", "http://node3.com:9090"} {
+			gock.New(node).
+				Post("/extract").
+				Reply(200).
+				JSON(extractionDto.NewSuccessExtractionResponse(
+					[]map[string]interface{}{
+						{node: "About"},
+					},
+				))
+		}
+
+		data, shards, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 3 {
+			t.Errorf("expected 3 extractions but got %d", len(data))
+		}
+
+		if ranagDto.Coverage(shards) != 100 || shards[1].Via != "" {
+			t.Errorf("expected every shard to be answered directly but got %+v", shards)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("should not delegate to ranags on the path", func(t *testing.T) {
+		defer gock.Off()
+		mockNode()
+
+		gock.New("http://node2.com:9090").Post("/extract").Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(nil))
+		gock.New("http://node3.com:9090").Post("/extract").Reply(200).
+			JSON(extractionDto.NewSuccessExtractionResponse(nil))
+
+		req := req
+		req.Path = []string{"child.com:6060"}
+
+		_, shards, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if ranagDto.Coverage(shards) != 100 {
+			t.Errorf("expected full coverage but got %+v", shards)
+		}
+
+		if !gock.IsDone() {
+			t.Errorf("Not all expectations were met")
+		}
+	})
+
+	t.Run("should reject requests that passed through it", func(t *testing.T) {
+		req := req
+		req.Path = []string{"root.com:6060", "parent.com:6060"}
+
+		_, _, err := newService().RangeAggregate(context.Background(), 0, 3, req)
+
+		if !errors.Is(err, ranag.ErrCycle) {
+			t.Errorf("expected ErrCycle but got %v", err)
+		}
+	})
+}
+
+func TestPlan(t *testing.T) {
+	svc := New(WithLogger(logrus.New()), WithAddress("parent.com:6060"))
+	svc.SetChildren(map[string][][2]int{
+		"b.com:6060": {{0, 10}},
+		"a.com:6060": {{5, 3}},
+	})
+
+	t.Run("should group the shards of each child in runs", func(t *testing.T) {
+		direct, delegated := svc.plan([]int{0, 1, 5, 6, 7, 8, 12}, nil)
+
+		if len(direct) != 1 || direct[0] != 12 {
+			t.Errorf("expected shard 12 to be queried directly but got %v", direct)
+		}
+
+		if a := delegated["a.com:6060"]; len(a) != 1 || a[0] != [2]int{5, 3} {
+			t.Errorf("expected shards 5-7 on a.com but got %v", a)
+		}
+
+		if b := delegated["b.com:6060"]; len(b) != 2 || b[0] != [2]int{0, 2} || b[1] != [2]int{8, 1} {
+			t.Errorf("expected shards 0-1 and 8 on b.com but got %v", b)
+		}
+	})
+
+	t.Run("should not delegate past the max depth", func(t *testing.T) {
+		path := make([]string, ranag.MaxDepth-1)
+
+		direct, delegated := svc.plan([]int{0, 1}, path)
+
+		if len(direct) != 2 || len(delegated) != 0 {
+			t.Errorf("expected every shard to be queried directly but got %v and %v", direct, delegated)
+		}
+	})
+}
+
+func TestRangeAggregateCancel(t *testing.T) {
+	t.Run("should cancel the node requests with the context", func(t *testing.T) {
+
+		defer gock.Off()
+
+		for _, node := range []string{"http://node1.com:9090", "http://node2.com:9090"} {
+			gock.New(node).
+				Post("/extract").
+				Persist().
+				Reply(200).
+				Delay(5 * time.Second).
+				JSON(extractionDto.NewSuccessExtractionResponse(nil))
+		}
+
+		svc := New(WithLogger(logrus.New()))
+
+		svc.SetShards([shard.SHARDS][]string{
+			0: {"node1.com:9090", "node2.com:9090"},
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+
+		_, shards, _ := svc.RangeAggregate(ctx, 0, 1, ranagDto.RangeAggregatorRequest{
+			Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+			Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+		})
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the query to stop with the context, took %s", elapsed)
+		}
+
+		if len(shards) != 1 || shards[0].Status != ranagDto.ShardFailed || shards[0].Attempts != 1 {
+			t.Errorf("expected the shard to fail without failing over, got %+v", shards[0])
+		}
+	})
+}
+
+func TestReceipt(t *testing.T) {
+	defer gock.Off()
+
+	nodeReceipt := func(shard, pages, rows int) *extractionDto.ExtractionResponse {
+		res := extractionDto.NewSuccessExtractionResponse(nil)
+		res.Receipt = &receipt.Receipt{RequestID: "req-1", Shards: []int{shard}, Pages: pages, Rows: rows}
+		return res
+	}
+
+	// the request ID is passed on to the nodes
+	gock.New("http://node1.com:9090").
+		Post("/extract").
+		JSON(extractionDto.ExtractionRequest{RequestID: "req-1", Shard: 0, Selectors: []*extractionDto.Selector{}, Fields: []*extractionDto.Field{}}).
+		Reply(200).
+		JSON(nodeReceipt(0, 10, 4))
+	gock.New("http://node2.com:9090").
+		Post("/extract").
+		JSON(extractionDto.ExtractionRequest{RequestID: "req-1", Shard: 1, Selectors: []*extractionDto.Selector{}, Fields: []*extractionDto.Field{}}).
+		Reply(200).
+		JSON(nodeReceipt(1, 5, 2))
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer := receipt.NewSigner(key)
+
+	svc := New(WithLogger(logrus.New()), WithSigner(signer))
+
+	svc.SetShards([shard.SHARDS][]string{
+		0: {"node1.com:9090"},
+		1: {"node2.com:9090"},
+	})
+
+	_, statuses, err := svc.RangeAggregate(context.Background(), 0, 3, ranagDto.RangeAggregatorRequest{RequestID: "req-1"})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if statuses[0].Receipt == nil || statuses[0].Receipt.Rows != 4 || statuses[1].Receipt == nil || statuses[2].Receipt != nil {
+		t.Fatalf("Expected the receipts of the nodes that answered, got %+v", statuses)
+	}
+
+	r, err := svc.Receipt("req-1", statuses)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(r.Shards) != 2 || r.Pages != 15 || r.Rows != 6 {
+		t.Errorf("Expected shards 0 and 1 with 15 pages and 6 rows, got %+v", r)
+	}
+
+	if err := receipt.Verify(signer.PublicKey(), r); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	t.Run("without a signer", func(t *testing.T) {
+		if r, _ := New(WithLogger(logrus.New())).Receipt("req-1", statuses); r != nil {
+			t.Errorf("Expected no receipt, got %+v", r)
+		}
+	})
+}
+
+func TestRangeAggregateVerification(t *testing.T) {
+	// answer replies with the digests the node yielded from each version
+	answer := func(node string, versions map[string]string) {
+		res := extractionDto.NewSuccessExtractionResponse(
+			[]map[string]interface{}{
+				{"https://google.com": "Google"},
+			},
+		)
+		res.Versions = versions
+
+		gock.New(node).
+			Post("/extract").
+			Persist().
+			Reply(200).
+			JSON(res)
+	}
+
+	req := ranagDto.RangeAggregatorRequest{
+		RequestID: "req-1",
+		Selectors: []*selectorDto.Selector{{ID: "1", Value: "#productTitle"}},
+		Fields:    []*fieldDto.Field{{SelectorID: "1", Name: "product_title"}},
+	}
+
+	verify := func(t *testing.T, nodes ...string) *ranagDto.ShardStatus {
+		t.Helper()
+
+		svc := New(WithLogger(logrus.New()), WithVerification(1))
+
+		svc.SetShards([shard.SHARDS][]string{
+			0: nodes,
+		})
+
+		data, shards, err := svc.RangeAggregate(context.Background(), 0, 1, req)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(data) != 1 {
+			t.Errorf("expected the shard answered but got %v", data)
+		}
+
+		return shards[0]
+	}
+
+	t.Run("should pass on what the replicas answered when they disagree", func(t *testing.T) {
+		defer gock.Off()
+
+		answer("http://node1.com:9090", map[string]string{"v1": "x"})
+		answer("http://node2.com:9090", map[string]string{"v1": "x"})
+		answer("http://node3.com:9090", map[string]string{"v1": "y"})
+
+		status := verify(t, "node1.com:9090", "node2.com:9090", "node3.com:9090")
+
+		if status.Verification == nil || len(status.Verification.Answers) != 3 {
+			t.Fatalf("expected the answers of the 3 replicas but got %+v", status.Verification)
+		}
+
+		if status.Verification.Answers[0].Node != status.Node {
+			t.Errorf("expected the answer of %s first but got %s", status.Node, status.Verification.Answers[0].Node)
+		}
+
+		conflicts := status.Verification.Conflicts()
+
+		if len(conflicts) != 1 || conflicts[0].Version != "v1" {
+			t.Fatalf("expected a conflict on v1 but got %+v", conflicts)
+		}
+
+		if majority, _ := conflicts[0].Majority(); majority != "x" {
+			t.Errorf("expected the majority x but got %q", majority)
+		}
+	})
+
+	t.Run("should compare only the versions replicas share", func(t *testing.T) {
+		defer gock.Off()
+
+		answer("http://node1.com:9090", map[string]string{"v1": "x"})
+		answer("http://node2.com:9090", map[string]string{"v2": "y"})
+
+		status := verify(t, "node1.com:9090", "node2.com:9090")
+
+		if status.Verification == nil || len(status.Verification.Answers) != 2 {
+			t.Fatalf("expected the answers of the 2 replicas but got %+v", status.Verification)
+		}
+
+		for _, a := range status.Verification.Answers {
+			if a.Versions != nil {
+				t.Errorf("expected the digests left out when replicas agree but got %+v", a)
+			}
+		}
+	})
+
+	t.Run("should not verify without other replicas", func(t *testing.T) {
+		defer gock.Off()
+
+		answer("http://node1.com:9090", map[string]string{"v1": "x"})
+
+		if status := verify(t, "node1.com:9090"); status.Verification != nil || status.Attempts != 1 {
+			t.Errorf("expected the shard not verified but got %+v", status)
+		}
+	})
+
+	t.Run("should not verify nodes without digests", func(t *testing.T) {
+		defer gock.Off()
+
+		answer("http://node1.com:9090", nil)
+		answer("http://node2.com:9090", nil)
+
+		if status := verify(t, "node1.com:9090", "node2.com:9090"); status.Verification != nil {
+			t.Errorf("expected the shard not verified but got %+v", status)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+// Package receipt signs the work nodes and ranags do for a request, so the API
+// pays them for the work they can prove. Each node and ranag signs with its own
+// ed25519 key, whose public half is registered with the API.
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid public key")
+	ErrInvalidSignature = errors.New("invalid receipt signature")
+)
+
+// Receipt is the work done for a request.
+type Receipt struct {
+	RequestID string `json:"request_id"`
+	// Shards are the shards served
+	Shards []int `json:"shards"`
+	// Pages is how many pages were scanned
+	Pages int `json:"pages"`
+	// Rows is how many rows were returned, or folded into partials
+	Rows int `json:"rows"`
+	// Digest commits to the digests of what each version of the pages
+	// scanned yielded, when the request asked for them
+	Digest   string    `json:"digest,omitempty"`
+	IssuedAt time.Time `json:"issued_at"`
+
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// Covers reports whether the receipt lists the shard.
+func (r *Receipt) Covers(shard int) bool {
+	return slices.Contains(r.Shards, shard)
+}
+
+// payload is what is signed: the receipt without its signature.
+func (r *Receipt) payload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+
+	return json.Marshal(unsigned)
+}
+
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// PublicKey is the key the signer's receipts are verified with, as it is
+// registered with the API.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign stamps the receipt with the time it was issued and signs it.
+func (s *Signer) Sign(r *Receipt) error {
+	r.IssuedAt = time.Now().UTC()
+
+	payload, err := r.payload()
+
+	if err != nil {
+		return err
+	}
+
+	r.Signature = s.SignPayload(payload)
+
+	return nil
+}
+
+// SignPayload signs anything else the ranag or node vouches for with its key.
+func (s *Signer) SignPayload(payload []byte) []byte {
+	return ed25519.Sign(s.key, payload)
+}
+
+// ParsePublicKey parses a base64 public key.
+func ParsePublicKey(publicKey string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// Verify checks the receipt was signed with the public key's private half.
+func Verify(publicKey string, r *Receipt) error {
+	key, err := ParsePublicKey(publicKey)
+
+	if err != nil {
+		return err
+	}
+
+	payload, err := r.payload()
+
+	if err != nil {
+		return err
+	}
+
+	return verify(key, payload, r.Signature)
+}
+
+// VerifyPayload checks the payload was signed with the public key's private
+// half.
+func VerifyPayload(publicKey string, payload, signature []byte) error {
+	key, err := ParsePublicKey(publicKey)
+
+	if err != nil {
+		return err
+	}
+
+	return verify(key, payload, signature)
+}
+
+func verify(key ed25519.PublicKey, payload, signature []byte) error {
+	if !ed25519.Verify(key, payload, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// LoadKey reads the base64 private key seed at path, generating and writing
+// one when there is none yet.
+func LoadKey(path string) (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+
+		if err != nil {
+			return nil, err
+		}
+
+		seed := base64.StdEncoding.EncodeToString(key.Seed())
+
+		if err := os.WriteFile(path, []byte(seed+"\n"), 0600); err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("invalid key file")
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
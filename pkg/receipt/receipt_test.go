@@ -0,0 +1,122 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+)
+
+func newSigner(t *testing.T) *Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	return NewSigner(key)
+}
+
+func TestVerify(t *testing.T) {
+	s := newSigner(t)
+
+	signed := func() *Receipt {
+		r := &Receipt{RequestID: "req-1", Shards: []int{3}, Pages: 10, Rows: 4}
+
+		if err := s.Sign(r); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return r
+	}
+
+	t.Run("success", func(t *testing.T) {
+		r := signed()
+
+		if r.IssuedAt.IsZero() {
+			t.Errorf("Expected the receipt stamped")
+		}
+
+		if err := Verify(s.PublicKey(), r); err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		for name, tamper := range map[string]func(r *Receipt){
+			"request":  func(r *Receipt) { r.RequestID = "req-2" },
+			"shards":   func(r *Receipt) { r.Shards = append(r.Shards, 4) },
+			"pages":    func(r *Receipt) { r.Pages++ },
+			"rows":     func(r *Receipt) { r.Rows = 1_000 },
+			"unsigned": func(r *Receipt) { r.Signature = nil },
+		} {
+			r := signed()
+			tamper(r)
+
+			if err := Verify(s.PublicKey(), r); err != ErrInvalidSignature {
+				t.Errorf("%s: expected %v, got %v", name, ErrInvalidSignature, err)
+			}
+		}
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		if err := Verify(newSigner(t).PublicKey(), signed()); err != ErrInvalidSignature {
+			t.Errorf("Expected %v, got %v", ErrInvalidSignature, err)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		for _, key := range []string{"", "not base64!", "c2hvcnQ="} {
+			if err := Verify(key, signed()); err != ErrInvalidKey {
+				t.Errorf("%q: expected %v, got %v", key, ErrInvalidKey, err)
+			}
+		}
+	})
+}
+
+func TestVerifyPayload(t *testing.T) {
+	s := newSigner(t)
+	signature := s.SignPayload([]byte("payload"))
+
+	if err := VerifyPayload(s.PublicKey(), []byte("payload"), signature); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if err := VerifyPayload(s.PublicKey(), []byte("tampered"), signature); err != ErrInvalidSignature {
+		t.Errorf("Expected %v, got %v", ErrInvalidSignature, err)
+	}
+
+	if err := VerifyPayload("c2hvcnQ=", []byte("payload"), signature); err != ErrInvalidKey {
+		t.Errorf("Expected %v, got %v", ErrInvalidKey, err)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	r := &Receipt{Shards: []int{1, 5}}
+
+	if !r.Covers(5) || r.Covers(2) {
+		t.Errorf("Expected only 1 and 5 covered, got %v", r.Shards)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipt.key")
+
+	generated, err := LoadKey(path)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	loaded, err := LoadKey(path)
+
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if !generated.Equal(loaded) {
+		t.Errorf("Expected the generated key loaded again")
+	}
+}
@@ -0,0 +1,181 @@
+// Package replica compares the answers replicas of a shard give to the same
+// request, so a node returning fabricated or corrupt rows stands out against
+// the replicas that disagree with it. Replicas do not store the same pages,
+// so answers are compared by the digests of what each version of a page
+// yielded, which the nodes sign into their receipts.
+package replica
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"juno/pkg/receipt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+var ErrInvalidAnswer = errors.New("invalid replica answer")
+
+// FloatPrecision is how many significant digits of numbers are compared, so
+// numbers parsed or formatted differently still agree.
+const FloatPrecision = 12
+
+// Digest hashes rows regardless of their order and of the order of their
+// fields.
+func Digest(rows []map[string]interface{}) (string, error) {
+	encoded := make([]string, len(rows))
+
+	for i, row := range rows {
+		b, err := json.Marshal(normalize(row))
+
+		if err != nil {
+			return "", err
+		}
+
+		encoded[i] = string(b)
+	}
+
+	slices.Sort(encoded)
+
+	h := sha256.New()
+
+	for _, row := range encoded {
+		h.Write([]byte(row))
+		h.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// normalize rounds the numbers in v to FloatPrecision digits. Maps are
+// marshalled with sorted keys already.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', FloatPrecision, 64), 64)
+		return rounded
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			normalized[k] = normalize(e)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, e := range v {
+			normalized[i] = normalize(e)
+		}
+		return normalized
+	}
+
+	return v
+}
+
+// VersionKey names a version of a page, which replicas that both stored it
+// must extract the same rows from.
+func VersionKey(url, hash string) string {
+	return hash + " " + url
+}
+
+// DigestVersions hashes the digests of what each version yielded, which a
+// node signs into its receipt to commit to them.
+func DigestVersions(versions map[string]string) string {
+	keys := slices.Sorted(maps.Keys(versions))
+
+	h := sha256.New()
+
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{'\t'})
+		h.Write([]byte(versions[key]))
+		h.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Answer is what a replica answered for a shard: its receipt and the digests
+// of the rows each version of the pages it scanned yielded, by VersionKey.
+type Answer struct {
+	Node     string            `json:"node"`
+	Receipt  *receipt.Receipt  `json:"receipt,omitempty"`
+	Versions map[string]string `json:"versions,omitempty"`
+}
+
+// Check checks the node signed the answer for the shard of the request, and
+// that its receipt commits to its digests.
+func (a *Answer) Check(publicKey, requestID string, shard int) error {
+	if a.Receipt == nil || a.Receipt.RequestID != requestID || !a.Receipt.Covers(shard) {
+		return ErrInvalidAnswer
+	}
+
+	if a.Receipt.Digest != DigestVersions(a.Versions) {
+		return ErrInvalidAnswer
+	}
+
+	return receipt.Verify(publicKey, a.Receipt)
+}
+
+// Verification is the answers replicas of a shard gave to the same request,
+// the first of them the one the shard was answered with.
+type Verification struct {
+	Answers []*Answer `json:"answers"`
+}
+
+// Conflict is a version replicas extracted different rows from, with the
+// digest each of them answered by node.
+type Conflict struct {
+	Version string            `json:"version"`
+	Digests map[string]string `json:"digests"`
+}
+
+// Conflicts lists the versions the replicas disagree on. Replicas store
+// different pages and versions of them, so only the versions several of them
+// scanned are compared.
+func (v *Verification) Conflicts() []*Conflict {
+	digests := map[string]map[string]string{}
+
+	for _, a := range v.Answers {
+		for version, digest := range a.Versions {
+			if digests[version] == nil {
+				digests[version] = map[string]string{}
+			}
+
+			digests[version][a.Node] = digest
+		}
+	}
+
+	var conflicts []*Conflict
+
+	for _, version := range slices.Sorted(maps.Keys(digests)) {
+		byNode := digests[version]
+		distinct := map[string]bool{}
+
+		for _, digest := range byNode {
+			distinct[digest] = true
+		}
+
+		if len(distinct) > 1 {
+			conflicts = append(conflicts, &Conflict{Version: version, Digests: byNode})
+		}
+	}
+
+	return conflicts
+}
+
+// Majority is the digest more than half of the replicas agree on.
+func (c *Conflict) Majority() (string, bool) {
+	counts := map[string]int{}
+
+	for _, digest := range c.Digests {
+		counts[digest]++
+
+		if counts[digest]*2 > len(c.Digests) {
+			return digest, true
+		}
+	}
+
+	return "", false
+}
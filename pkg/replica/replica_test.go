@@ -0,0 +1,167 @@
+package replica
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"juno/pkg/receipt"
+	"maps"
+	"testing"
+)
+
+func TestDigest(t *testing.T) {
+	digest := func(rows []map[string]interface{}) string {
+		t.Helper()
+
+		d, err := Digest(rows)
+
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return d
+	}
+
+	rows := []map[string]interface{}{
+		{"title": "a", "price": 1.5},
+		{"title": "b", "price": 2.0},
+	}
+
+	t.Run("ignores the order of rows", func(t *testing.T) {
+		reordered := []map[string]interface{}{rows[1], rows[0]}
+
+		if digest(rows) != digest(reordered) {
+			t.Errorf("Expected the same digest")
+		}
+	})
+
+	t.Run("ignores rounding errors", func(t *testing.T) {
+		rounded := []map[string]interface{}{
+			{"title": "a", "price": 0.1 + 0.2 + 1.2},
+			{"title": "b", "price": 2.0},
+		}
+
+		if digest(rows) != digest(rounded) {
+			t.Errorf("Expected the same digest")
+		}
+	})
+
+	t.Run("tells different rows apart", func(t *testing.T) {
+		fabricated := []map[string]interface{}{
+			{"title": "a", "price": 1.5},
+			{"title": "b", "price": 2.5},
+		}
+
+		if digest(rows) == digest(fabricated) {
+			t.Errorf("Expected different digests")
+		}
+
+		if digest(rows) == digest(rows[:1]) {
+			t.Errorf("Expected different digests")
+		}
+	})
+}
+
+func TestDigestVersions(t *testing.T) {
+	versions := map[string]string{
+		VersionKey("http://example.com/a", "01"): "x",
+		VersionKey("http://example.com/b", "02"): "y",
+	}
+
+	if DigestVersions(versions) != DigestVersions(maps.Clone(versions)) {
+		t.Errorf("Expected the same digest")
+	}
+
+	changed := maps.Clone(versions)
+	changed[VersionKey("http://example.com/b", "02")] = "z"
+
+	if DigestVersions(versions) == DigestVersions(changed) {
+		t.Errorf("Expected different digests")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer := receipt.NewSigner(key)
+
+	signed := func(t *testing.T) *Answer {
+		t.Helper()
+
+		a := &Answer{Node: "a.com:9090", Versions: map[string]string{VersionKey("http://example.com", "01"): "x"}}
+		a.Receipt = &receipt.Receipt{RequestID: "req-1", Shards: []int{3}, Digest: DigestVersions(a.Versions)}
+
+		if err := signer.Sign(a.Receipt); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+
+		return a
+	}
+
+	if err := signed(t).Check(signer.PublicKey(), "req-1", 3); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	if err := signed(t).Check(signer.PublicKey(), "req-2", 3); err != ErrInvalidAnswer {
+		t.Errorf("Expected %v for another request, got %v", ErrInvalidAnswer, err)
+	}
+
+	if err := signed(t).Check(signer.PublicKey(), "req-1", 4); err != ErrInvalidAnswer {
+		t.Errorf("Expected %v for another shard, got %v", ErrInvalidAnswer, err)
+	}
+
+	a := signed(t)
+	a.Versions[VersionKey("http://example.com", "01")] = "y"
+
+	if err := a.Check(signer.PublicKey(), "req-1", 3); err != ErrInvalidAnswer {
+		t.Errorf("Expected %v for digests the receipt does not commit to, got %v", ErrInvalidAnswer, err)
+	}
+
+	a = signed(t)
+	a.Receipt.Rows = 10
+
+	if err := a.Check(signer.PublicKey(), "req-1", 3); err != receipt.ErrInvalidSignature {
+		t.Errorf("Expected %v, got %v", receipt.ErrInvalidSignature, err)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	answer := func(node string, versions map[string]string) *Answer {
+		return &Answer{Node: node, Versions: versions}
+	}
+
+	t.Run("compares only shared versions", func(t *testing.T) {
+		v := &Verification{Answers: []*Answer{
+			answer("a.com:9090", map[string]string{"1 http://example.com/a": "x", "2 http://example.com/b": "y"}),
+			answer("b.com:9090", map[string]string{"1 http://example.com/a": "x", "3 http://example.com/c": "z"}),
+		}}
+
+		if conflicts := v.Conflicts(); len(conflicts) != 0 {
+			t.Errorf("Expected no conflicts, got %+v", conflicts)
+		}
+	})
+
+	t.Run("finds versions replicas disagree on", func(t *testing.T) {
+		v := &Verification{Answers: []*Answer{
+			answer("a.com:9090", map[string]string{"1 http://example.com/a": "x", "2 http://example.com/b": "y"}),
+			answer("b.com:9090", map[string]string{"1 http://example.com/a": "fabricated"}),
+			answer("c.com:9090", map[string]string{"1 http://example.com/a": "x"}),
+		}}
+
+		conflicts := v.Conflicts()
+
+		if len(conflicts) != 1 || conflicts[0].Version != "1 http://example.com/a" || len(conflicts[0].Digests) != 3 {
+			t.Fatalf("Expected a conflict on the shared version, got %+v", conflicts)
+		}
+
+		if d, ok := conflicts[0].Majority(); !ok || d != "x" {
+			t.Errorf("Expected x, got %q", d)
+		}
+	})
+
+	t.Run("no majority between two replicas", func(t *testing.T) {
+		c := &Conflict{Version: "1 http://example.com/a", Digests: map[string]string{"a.com:9090": "x", "b.com:9090": "y"}}
+
+		if _, ok := c.Majority(); ok {
+			t.Errorf("Expected no majority")
+		}
+	})
+}
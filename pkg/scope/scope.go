@@ -0,0 +1,142 @@
+// Package scope narrows a strategy to the pages of some hosts. Hosts and URL
+// prefixes name their shards, so jobs only query those; a host glob cannot
+// be hashed and still runs over every shard, but nodes skip the pages
+// outside any scope before parsing them.
+package scope
+
+import (
+	"errors"
+	"juno/pkg/shard"
+	"juno/pkg/util"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// MaxHosts caps the hosts of a scope.
+const MaxHosts = 1_000
+
+// Scope is set by exactly one of its members.
+type Scope struct {
+	Hosts []string `json:"hosts,omitempty"`
+	// HostGlob matches hostnames with path.Match, like *.amazon.com
+	HostGlob  string `json:"host_glob,omitempty"`
+	URLPrefix string `json:"url_prefix,omitempty"`
+}
+
+// Empty reports whether the scope leaves every page in.
+func (s *Scope) Empty() bool {
+	return s == nil || (len(s.Hosts) == 0 && s.HostGlob == "" && s.URLPrefix == "")
+}
+
+func (s Scope) Validate() error {
+	var errs []error
+
+	set := 0
+	if len(s.Hosts) > 0 {
+		set++
+	}
+	if s.HostGlob != "" {
+		set++
+	}
+	if s.URLPrefix != "" {
+		set++
+	}
+
+	if set > 1 {
+		errs = append(errs, errors.New("only one of hosts, host_glob and url_prefix can be set"))
+	}
+
+	if len(s.Hosts) > MaxHosts {
+		errs = append(errs, errors.New("too many hosts"))
+	}
+
+	for _, h := range s.Hosts {
+		if strings.TrimSpace(h) == "" || strings.ContainsAny(h, "/:*") {
+			errs = append(errs, errors.New("hosts must be hostnames"))
+			break
+		}
+	}
+
+	if s.HostGlob != "" {
+		if _, err := path.Match(s.HostGlob, ""); err != nil {
+			errs = append(errs, errors.New("host_glob is not a valid pattern"))
+		}
+	}
+
+	if s.URLPrefix != "" {
+		if u, err := url.Parse(s.URLPrefix); err != nil || u.Hostname() == "" {
+			errs = append(errs, errors.New("url_prefix must be an absolute URL"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return util.ValidationErrs(errs)
+	}
+
+	return nil
+}
+
+// Shards returns the sorted shards of the scope's hosts. It returns false
+// when the scope does not narrow the shards.
+func (s *Scope) Shards() ([]int, bool) {
+	if s.Empty() || s.HostGlob != "" {
+		return nil, false
+	}
+
+	seen := map[int]bool{}
+
+	if s.URLPrefix != "" {
+		seen[shard.GetURLShard(s.URLPrefix)] = true
+	}
+
+	for _, h := range s.Hosts {
+		seen[shard.GetShard(normalize(h))] = true
+	}
+
+	shards := make([]int, 0, len(seen))
+	for sh := range seen {
+		shards = append(shards, sh)
+	}
+
+	sort.Ints(shards)
+
+	return shards, true
+}
+
+// Matches reports whether the page at the URL is in the scope.
+func (s *Scope) Matches(rawURL string) bool {
+	if s.Empty() {
+		return true
+	}
+
+	if s.URLPrefix != "" {
+		return strings.HasPrefix(rawURL, s.URLPrefix)
+	}
+
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return false
+	}
+
+	host := normalize(u.Hostname())
+
+	if s.HostGlob != "" {
+		ok, _ := path.Match(normalize(s.HostGlob), host)
+		return ok
+	}
+
+	for _, h := range s.Hosts {
+		if normalize(h) == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalize(host string) string {
+	return strings.ToLower(strings.TrimSpace(host))
+}
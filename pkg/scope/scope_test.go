@@ -0,0 +1,88 @@
+package scope
+
+import (
+	"juno/pkg/shard"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope Scope
+		valid bool
+	}{
+		{"empty", Scope{}, true},
+		{"hosts", Scope{Hosts: []string{"amazon.com", "ebay.com"}}, true},
+		{"glob", Scope{HostGlob: "*.amazon.com"}, true},
+		{"prefix", Scope{URLPrefix: "https://amazon.com/dp/"}, true},
+		{"two kinds", Scope{Hosts: []string{"amazon.com"}, HostGlob: "*.amazon.com"}, false},
+		{"url as host", Scope{Hosts: []string{"https://amazon.com"}}, false},
+		{"bad glob", Scope{HostGlob: "[amazon.com"}, false},
+		{"relative prefix", Scope{URLPrefix: "/dp/"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scope.Validate()
+
+			if tt.valid && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestShards(t *testing.T) {
+	t.Run("should hash hosts and prefixes", func(t *testing.T) {
+		s := &Scope{Hosts: []string{"example.com", "EXAMPLE.com", "amazon.com"}}
+
+		shards, ok := s.Shards()
+
+		if !ok || len(shards) != 2 {
+			t.Fatalf("expected 2 shards, got %v", shards)
+		}
+
+		prefix := &Scope{URLPrefix: "http://example.com/products/"}
+
+		if shards, _ := prefix.Shards(); len(shards) != 1 || shards[0] != shard.GetShard("example.com") {
+			t.Errorf("expected the shard of example.com, got %v", shards)
+		}
+	})
+
+	t.Run("should not narrow globs", func(t *testing.T) {
+		if _, ok := (&Scope{HostGlob: "*.example.com"}).Shards(); ok {
+			t.Errorf("expected a glob not to narrow the shards")
+		}
+
+		var s *Scope
+		if _, ok := s.Shards(); ok {
+			t.Errorf("expected no scope not to narrow the shards")
+		}
+	})
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		scope   *Scope
+		url     string
+		matches bool
+	}{
+		{nil, "https://example.com", true},
+		{&Scope{Hosts: []string{"example.com"}}, "https://Example.com/about", true},
+		{&Scope{Hosts: []string{"example.com"}}, "https://www.example.com/about", false},
+		{&Scope{HostGlob: "*.example.com"}, "https://shop.example.com/", true},
+		{&Scope{HostGlob: "*.example.com"}, "https://example.org/", false},
+		{&Scope{URLPrefix: "https://example.com/dp/"}, "https://example.com/dp/123", true},
+		{&Scope{URLPrefix: "https://example.com/dp/"}, "https://example.com/about", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.scope.Matches(tt.url); got != tt.matches {
+			t.Errorf("expected %v for %s in %+v, got %v", tt.matches, tt.url, tt.scope, got)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package shard
 
 import (
 	"errors"
+	"net/url"
+	"strings"
 
 	"github.com/spaolacci/murmur3"
 )
@@ -15,6 +17,19 @@ func GetShard(hostname string) int {
 	return int(hash % uint32(SHARDS))
 }
 
+// GetURLShard is the shard of the URL's host, so all pages of a host live on
+// the same shard and jobs scoped to hosts only query their shards. URLs
+// without a host are hashed whole.
+func GetURLShard(rawURL string) int {
+	u, err := url.Parse(rawURL)
+
+	if err != nil || u.Hostname() == "" {
+		return GetShard(rawURL)
+	}
+
+	return GetShard(strings.ToLower(u.Hostname()))
+}
+
 func GetShardRange(offset, total int) ([]int, error) {
 
 	if offset < 0 {
@@ -14,6 +14,16 @@ func TestGetShard(t *testing.T) {
 	})
 }
 
+func TestGetURLShard(t *testing.T) {
+	t.Run("should return the shard of the host", func(t *testing.T) {
+		for _, u := range []string{"http://example.com", "https://EXAMPLE.com/about?x=1"} {
+			if shard := GetURLShard(u); shard != 72435 {
+				t.Errorf("expected shard 72435 for %s, got %d", u, shard)
+			}
+		}
+	})
+}
+
 func TestGetShardRange(t *testing.T) {
 	t.Run("should return error when offset is less than 0", func(t *testing.T) {
 		_, err := GetShardRange(-1, 1)